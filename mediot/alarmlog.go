@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Alarm history persistence
+const (
+	alarmLogFile    = "alarm_history.json"
+	maxAlarmRecords = 5000 // Oldest records are dropped beyond this
+)
+
+// AlarmRecord is the auditable lifecycle of one alarm
+type AlarmRecord struct {
+	ID             int64          `json:"id"`
+	Channel        string         `json:"channel"`
+	Severity       string         `json:"severity"` // Highest severity the alarm reached
+	Value          float64        `json:"value"`    // Reading that raised the alarm
+	Limit          float64        `json:"limit"`
+	Message        string         `json:"message"`
+	RaisedAt       time.Time      `json:"raisedAt"`
+	ClearedAt      time.Time      `json:"clearedAt"`      // Zero while the alarm is active
+	AcknowledgedAt time.Time      `json:"acknowledgedAt"` // Zero until acknowledged
+	AcknowledgedBy string         `json:"acknowledgedBy,omitempty"`
+	Note           string         `json:"note,omitempty"`
+	Escalation     int            `json:"escalation"` // Escalation steps fired before acknowledgment
+	Silences       []AlarmSilence `json:"silences,omitempty"`
+}
+
+// AlarmHistoryFilter selects alarm records. Empty fields match everything.
+type AlarmHistoryFilter struct {
+	Channel            string    `json:"channel,omitempty"`
+	Severity           string    `json:"severity,omitempty"`
+	Since              time.Time `json:"since"`
+	Until              time.Time `json:"until"`
+	UnacknowledgedOnly bool      `json:"unacknowledgedOnly"`
+	Limit              int       `json:"limit"` // Most recent records to return, 0 for all
+}
+
+// alarmLog keeps the persistent alarm records, oldest first
+type alarmLog struct {
+	mu      sync.Mutex
+	records []AlarmRecord
+}
+
+// newAlarmLog creates the alarm log and loads the persisted records
+func newAlarmLog() *alarmLog {
+	l := &alarmLog{records: make([]AlarmRecord, 0)}
+	if err := loadJSONFile(alarmLogFile, &l.records); err != nil {
+		alarmsLog.Errorf("Error loading alarm history: %v", err)
+	}
+	return l
+}
+
+// reload replaces the records with the persisted ones once the patient
+// data is unlocked. No alarm is raised while it is locked.
+func (l *alarmLog) reload() {
+	records := make([]AlarmRecord, 0)
+	if err := loadJSONFile(alarmLogFile, &records); err != nil {
+		alarmsLog.Errorf("Error loading alarm history: %v", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.records = records
+}
+
+// lastID returns the highest recorded alarm ID so new alarms continue the sequence
+func (l *alarmLog) lastID() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.records) == 0 {
+		return 0
+	}
+	return l.records[len(l.records)-1].ID
+}
+
+// find returns the record of an alarm; the caller holds the lock
+func (l *alarmLog) find(id int64) *AlarmRecord {
+	for i := len(l.records) - 1; i >= 0; i-- {
+		if l.records[i].ID == id {
+			return &l.records[i]
+		}
+	}
+	return nil
+}
+
+// record applies an alarm event to the history and persists it
+func (l *alarmLog) record(event AlarmEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r := l.find(event.ID)
+	switch {
+	case r == nil:
+		l.records = append(l.records, AlarmRecord{
+			ID:       event.ID,
+			Channel:  event.Channel,
+			Severity: event.Severity,
+			Value:    event.Value,
+			Limit:    event.Limit,
+			Message:  event.Message,
+			RaisedAt: event.Timestamp,
+		})
+		if len(l.records) > maxAlarmRecords {
+			l.records = append(make([]AlarmRecord, 0, maxAlarmRecords), l.records[len(l.records)-maxAlarmRecords:]...)
+		}
+	case event.Severity == SeverityNormal:
+		r.ClearedAt = event.Timestamp
+		r.endSilences(event.Timestamp, func(AlarmSilence) bool { return true })
+	case severityRank[event.Severity] > severityRank[event.Previous]:
+		// Escalating ends a snooze
+		r.endSilences(event.Timestamp, func(s AlarmSilence) bool { return !s.Global })
+		if severityRank[event.Severity] > severityRank[r.Severity] {
+			r.Severity = event.Severity
+			r.Message = event.Message
+		}
+	}
+
+	if err := saveJSONFile(alarmLogFile, l.records); err != nil {
+		alarmsLog.Errorf("Error saving alarm history: %v", err)
+	}
+}
+
+// escalated records the escalation level an alarm reached and persists it
+func (l *alarmLog) escalated(id int64, level int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r := l.find(id); r != nil {
+		r.Escalation = level
+		if err := saveJSONFile(alarmLogFile, l.records); err != nil {
+			alarmsLog.Errorf("Error saving alarm history: %v", err)
+		}
+	}
+}
+
+// endSilences closes the open silences selected by match
+func (r *AlarmRecord) endSilences(at time.Time, match func(AlarmSilence) bool) {
+	for i := range r.Silences {
+		if r.Silences[i].EndedAt.IsZero() && match(r.Silences[i]) {
+			r.Silences[i].EndedAt = at
+		}
+	}
+}
+
+// silence records the start of a silence or snooze of an alarm and persists it
+func (l *alarmLog) silence(id int64, silence AlarmSilence) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r := l.find(id); r != nil {
+		r.Silences = append(r.Silences, silence)
+		if err := saveJSONFile(alarmLogFile, l.records); err != nil {
+			alarmsLog.Errorf("Error saving alarm history: %v", err)
+		}
+	}
+}
+
+// rearm records the end of the global silence or of the snooze of an alarm and persists it
+func (l *alarmLog) rearm(id int64, global bool, at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r := l.find(id); r != nil {
+		r.endSilences(at, func(s AlarmSilence) bool { return s.Global == global })
+		if err := saveJSONFile(alarmLogFile, l.records); err != nil {
+			alarmsLog.Errorf("Error saving alarm history: %v", err)
+		}
+	}
+}
+
+// acknowledge records who acknowledged an alarm and persists it
+func (l *alarmLog) acknowledge(id int64, user, note string, at time.Time) (AlarmRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r := l.find(id)
+	if r == nil {
+		return AlarmRecord{}, fmt.Errorf("no alarm #%d in the history", id)
+	}
+	if !r.AcknowledgedAt.IsZero() {
+		return AlarmRecord{}, fmt.Errorf("alarm #%d was already acknowledged by %s", id, r.AcknowledgedBy)
+	}
+	r.AcknowledgedAt = at
+	r.AcknowledgedBy = user
+	r.Note = note
+	return *r, saveJSONFile(alarmLogFile, l.records)
+}
+
+// matches reports whether a record passes the filter
+func (f AlarmHistoryFilter) matches(r AlarmRecord) bool {
+	if f.Channel != "" && r.Channel != f.Channel {
+		return false
+	}
+	if f.Severity != "" && r.Severity != f.Severity {
+		return false
+	}
+	if !f.Since.IsZero() && r.RaisedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && r.RaisedAt.After(f.Until) {
+		return false
+	}
+	return !f.UnacknowledgedOnly || r.AcknowledgedAt.IsZero()
+}
+
+// GetAlarmHistory returns the alarm records matching the filter, oldest first
+func (a *App) GetAlarmHistory(filter AlarmHistoryFilter) ([]AlarmRecord, error) {
+	if err := a.requireReadRole(RoleOperator); err != nil {
+		return nil, err
+	}
+	return a.alarmHistory(filter), nil
+}
+
+// alarmHistory is GetAlarmHistory without the role check
+func (a *App) alarmHistory(filter AlarmHistoryFilter) []AlarmRecord {
+	a.alarmLog.mu.Lock()
+	defer a.alarmLog.mu.Unlock()
+
+	result := make([]AlarmRecord, 0)
+	for _, r := range a.alarmLog.records {
+		if filter.matches(r) {
+			result = append(result, r)
+		}
+	}
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[len(result)-filter.Limit:]
+	}
+	return result
+}
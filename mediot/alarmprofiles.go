@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// alarmProfilesFile stores the saved alarm profiles with all their versions
+const alarmProfilesFile = "alarm_profiles.json"
+
+// AlarmProfile is one saved version of a named set of alarm rules, e.g. for
+// a ward or a study protocol. Saved versions never change.
+type AlarmProfile struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Version     int         `json:"version"` // Starts at 1 and increases with every save
+	SavedAt     time.Time   `json:"savedAt"`
+	Rules       []AlarmRule `json:"rules"`
+}
+
+// alarmProfileFile is the persisted form of the profile store
+type alarmProfileFile struct {
+	Startup  string                    `json:"startup"`  // Profile loaded when a session starts, empty for none
+	Profiles map[string][]AlarmProfile `json:"profiles"` // Versions of every profile, oldest first
+}
+
+// alarmProfileStore keeps the saved alarm profiles and which one is loaded
+type alarmProfileStore struct {
+	mu     sync.Mutex
+	file   alarmProfileFile
+	loaded *AlarmProfileLoad // Profile whose rules are in force, nil if none
+}
+
+// newAlarmProfileStore creates the store and loads the saved profiles
+func newAlarmProfileStore() *alarmProfileStore {
+	s := &alarmProfileStore{file: alarmProfileFile{Profiles: make(map[string][]AlarmProfile)}}
+	if err := loadJSONFile(alarmProfilesFile, &s.file); err != nil {
+		alarmsLog.Errorf("Error loading alarm profiles: %v", err)
+	}
+	return s
+}
+
+// find returns a version of a profile, 0 meaning the latest; the caller holds the lock
+func (s *alarmProfileStore) find(name string, version int) (AlarmProfile, error) {
+	versions := s.file.Profiles[name]
+	if len(versions) == 0 {
+		return AlarmProfile{}, fmt.Errorf("no alarm profile '%s'", name)
+	}
+	if version == 0 {
+		return versions[len(versions)-1], nil
+	}
+	for _, profile := range versions {
+		if profile.Version == version {
+			return profile, nil
+		}
+	}
+	return AlarmProfile{}, fmt.Errorf("alarm profile '%s' has no version %d", name, version)
+}
+
+// loadedProfile returns the profile whose rules are in force, nil if none
+func (s *alarmProfileStore) loadedProfile() *AlarmProfileLoad {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loaded == nil {
+		return nil
+	}
+	load := *s.loaded
+	return &load
+}
+
+// startupProfile returns the profile to load when a session starts
+func (s *alarmProfileStore) startupProfile() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Startup
+}
+
+// SaveAlarmProfile saves the current alarm rules as the next version of a
+// named profile
+func (a *App) SaveAlarmProfile(name string, description string) (AlarmProfile, error) {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return AlarmProfile{}, err
+	}
+
+	if name == "" {
+		return AlarmProfile{}, fmt.Errorf("name is required")
+	}
+	rules := a.GetAlarmRules()
+	if len(rules) == 0 {
+		return AlarmProfile{}, fmt.Errorf("no alarm rules to save")
+	}
+
+	a.limits.mu.Lock()
+	defer a.limits.mu.Unlock()
+
+	versions := a.limits.file.Profiles[name]
+	profile := AlarmProfile{
+		Name:        name,
+		Description: description,
+		Version:     1,
+		SavedAt:     time.Now(),
+		Rules:       rules,
+	}
+	if len(versions) > 0 {
+		profile.Version = versions[len(versions)-1].Version + 1
+	}
+	a.limits.file.Profiles[name] = append(versions, profile)
+	if err := saveJSONFile(alarmProfilesFile, a.limits.file); err != nil {
+		a.limits.file.Profiles[name] = versions
+		return AlarmProfile{}, err
+	}
+
+	alarmsLog.Infof("Alarm profile %s saved as version %d", name, profile.Version)
+	a.audit.record("", AuditAlarmLimits, fmt.Sprintf("Alarm profile %s saved as version %d", name, profile.Version))
+	return profile, nil
+}
+
+// GetAlarmProfiles returns the latest version of every profile sorted by name
+func (a *App) GetAlarmProfiles() []AlarmProfile {
+	a.limits.mu.Lock()
+	defer a.limits.mu.Unlock()
+
+	result := make([]AlarmProfile, 0, len(a.limits.file.Profiles))
+	for _, versions := range a.limits.file.Profiles {
+		latest := versions[len(versions)-1]
+		latest.Rules = append([]AlarmRule{}, latest.Rules...)
+		result = append(result, latest)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// GetAlarmProfileVersions returns every saved version of a profile, oldest first
+func (a *App) GetAlarmProfileVersions(name string) ([]AlarmProfile, error) {
+	a.limits.mu.Lock()
+	defer a.limits.mu.Unlock()
+
+	versions, ok := a.limits.file.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("no alarm profile '%s'", name)
+	}
+	result := make([]AlarmProfile, len(versions))
+	for i, profile := range versions {
+		result[i] = profile
+		result[i].Rules = append([]AlarmRule{}, profile.Rules...)
+	}
+	return result, nil
+}
+
+// LoadAlarmProfile replaces all alarm rules with a version of a profile, 0
+// meaning the latest, and records the load in the running session
+func (a *App) LoadAlarmProfile(name string, version int) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+	return a.loadAlarmProfile(name, version)
+}
+
+// loadAlarmProfile is LoadAlarmProfile without the role check, for rules the app applies itself
+func (a *App) loadAlarmProfile(name string, version int) error {
+	a.limits.mu.Lock()
+	profile, err := a.limits.find(name, version)
+	a.limits.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range profile.Rules {
+		if err := a.setAlarmRule(rule); err != nil {
+			return fmt.Errorf("profile rule for %s: %v", rule.Channel, err)
+		}
+	}
+	for _, rule := range a.GetAlarmRules() {
+		covered := false
+		for _, own := range profile.Rules {
+			covered = covered || own.Channel == rule.Channel
+		}
+		if !covered {
+			a.removeAlarmRule(rule.Channel)
+		}
+	}
+
+	// The profile replaced any preset rules
+	a.presets.mu.Lock()
+	a.presets.active = ""
+	a.presets.channels = nil
+	a.presets.mu.Unlock()
+
+	load := AlarmProfileLoad{Profile: profile.Name, Version: profile.Version, LoadedAt: time.Now()}
+	a.limits.mu.Lock()
+	a.limits.loaded = &load
+	a.limits.mu.Unlock()
+	a.sessions.recordProfile(load)
+
+	alarmsLog.Infof("Alarm profile %s version %d loaded", profile.Name, profile.Version)
+	a.audit.record("", AuditAlarmLimits, fmt.Sprintf("Alarm profile %s version %d loaded", profile.Name, profile.Version))
+	return nil
+}
+
+// GetLoadedAlarmProfile returns the profile whose rules were last loaded, or nil if none
+func (a *App) GetLoadedAlarmProfile() *AlarmProfileLoad {
+	return a.limits.loadedProfile()
+}
+
+// DeleteAlarmProfile removes a profile with all its versions. Past sessions
+// keep referring to it by name and version.
+func (a *App) DeleteAlarmProfile(name string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.limits.mu.Lock()
+	defer a.limits.mu.Unlock()
+
+	versions, ok := a.limits.file.Profiles[name]
+	if !ok {
+		return fmt.Errorf("no alarm profile '%s'", name)
+	}
+	startup := a.limits.file.Startup
+	delete(a.limits.file.Profiles, name)
+	if startup == name {
+		a.limits.file.Startup = ""
+	}
+	if err := saveJSONFile(alarmProfilesFile, a.limits.file); err != nil {
+		a.limits.file.Profiles[name] = versions
+		a.limits.file.Startup = startup
+		return err
+	}
+
+	alarmsLog.Infof("Alarm profile %s deleted", name)
+	a.audit.record("", AuditAlarmLimits, fmt.Sprintf("Alarm profile %s deleted", name))
+	return nil
+}
+
+// SetStartupAlarmProfile chooses the profile whose latest version is loaded
+// when a session starts; an empty name keeps the rules as they are
+func (a *App) SetStartupAlarmProfile(name string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.limits.mu.Lock()
+	defer a.limits.mu.Unlock()
+
+	if name != "" {
+		if _, err := a.limits.find(name, 0); err != nil {
+			return err
+		}
+	}
+	previous := a.limits.file.Startup
+	a.limits.file.Startup = name
+	if err := saveJSONFile(alarmProfilesFile, a.limits.file); err != nil {
+		a.limits.file.Startup = previous
+		return err
+	}
+	return nil
+}
+
+// GetStartupAlarmProfile returns the profile loaded when a session starts, empty if none
+func (a *App) GetStartupAlarmProfile() string {
+	return a.limits.startupProfile()
+}
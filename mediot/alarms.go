@@ -0,0 +1,517 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Alarm severities, in increasing order of urgency
+const (
+	SeverityNormal   = "normal"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// severityRank orders severities so escalations can be compared
+var severityRank = map[string]int{
+	SeverityNormal:   0,
+	SeverityWarning:  1,
+	SeverityCritical: 2,
+}
+
+// AlarmRule defines the limits of one channel. Limits left nil are not checked.
+type AlarmRule struct {
+	Channel            string          `json:"channel"`
+	WarningLow         *float64        `json:"warningLow,omitempty"`
+	WarningHigh        *float64        `json:"warningHigh,omitempty"`
+	CriticalLow        *float64        `json:"criticalLow,omitempty"`
+	CriticalHigh       *float64        `json:"criticalHigh,omitempty"`
+	Hysteresis         float64         `json:"hysteresis"`               // Margin the value must move back inside a limit before the alarm drops
+	MinDurationSeconds float64         `json:"minDurationSeconds"`       // Time a violation must persist before it is raised
+	MinQuality         float64         `json:"minQuality"`               // Evaluation is suspended while signal quality is below this (0 disables)
+	QualityChannel     string          `json:"qualityChannel,omitempty"` // Channel whose quality gates the rule, defaults to Channel
+	Adaptive           *AdaptiveLimits `json:"adaptive,omitempty"`       // Limits following the channel's rolling percentiles
+	Unit               string          `json:"unit,omitempty"`           // Unit of the limits, converted to the channel's unit; empty means the channel's unit
+	Latching           bool            `json:"latching"`                 // Alarms stay at their severity after the value recovers until acknowledged
+}
+
+// AdaptiveLimits derive alarm limits from the rolling percentiles of the
+// channel: low = LowPercentile - Margin, high = HighPercentile + Margin.
+// They fill in the limits of their severity that the rule leaves nil.
+type AdaptiveLimits struct {
+	Severity       string  `json:"severity"`
+	LowPercentile  float64 `json:"lowPercentile"`  // 0 disables the adaptive low limit
+	HighPercentile float64 `json:"highPercentile"` // 0 disables the adaptive high limit
+	Margin         float64 `json:"margin"`
+}
+
+// Alarm is a currently active limit violation
+type Alarm struct {
+	ID           int64     `json:"id"`
+	Channel      string    `json:"channel"`
+	Severity     string    `json:"severity"`
+	Value        float64   `json:"value"`
+	Limit        float64   `json:"limit"`
+	RaisedAt     time.Time `json:"raisedAt"`
+	Acknowledged bool      `json:"acknowledged"` // Reset when the alarm escalates
+	SnoozedUntil time.Time `json:"snoozedUntil"` // Zero unless snoozed; reset when the alarm escalates
+	Latched      bool      `json:"latched"`      // Held by a latching rule although the value recovered
+}
+
+// AlarmEvent is pushed to the frontend whenever an alarm changes severity.
+// A cleared alarm is reported with SeverityNormal.
+type AlarmEvent struct {
+	ID        int64     `json:"id"`
+	Channel   string    `json:"channel"`
+	Severity  string    `json:"severity"`
+	Previous  string    `json:"previous"`
+	Value     float64   `json:"value"`
+	Limit     float64   `json:"limit"`
+	Code      string    `json:"code"`    // Message code, see i18n.go
+	Message   string    `json:"message"` // In the user's language
+	Timestamp time.Time `json:"timestamp"`
+	Silenced  bool      `json:"silenced"` // Silenced or snoozed, so no sound or notifications
+}
+
+// alarmState tracks the evaluation of one rule between samples
+type alarmState struct {
+	rule         AlarmRule
+	active       *Alarm    // nil while the channel is in range
+	pending      string    // Severity waiting for its minimum duration
+	pendingSince time.Time // When the pending severity was first seen
+}
+
+// alarmEngine evaluates the alarm rules against every sample
+type alarmEngine struct {
+	mu         sync.Mutex
+	rules      map[string]*alarmState
+	conditions map[string]*alarmState // Alarms raised by the app itself rather than by limits
+	nextID     int64
+	silence    *AlarmSilence                        // Silence covering all alarms, nil while armed
+	notify     func(AlarmEvent)                     // Called for every severity change, outside the lock
+	quality    func(channel string) (float64, bool) // Current signal quality of a channel
+	unitOf     func(channel string) string          // Engineering unit of a channel
+}
+
+// newAlarmEngine creates an engine without rules
+func newAlarmEngine(notify func(AlarmEvent), quality func(string) (float64, bool), unitOf func(string) string) *alarmEngine {
+	return &alarmEngine{
+		rules:      make(map[string]*alarmState),
+		conditions: make(map[string]*alarmState),
+		nextID:     1,
+		notify:     notify,
+		quality:    quality,
+		unitOf:     unitOf,
+	}
+}
+
+// states returns the states of the rules and conditions; the caller holds the lock
+func (e *alarmEngine) states() []*alarmState {
+	result := make([]*alarmState, 0, len(e.rules)+len(e.conditions))
+	for _, state := range e.rules {
+		result = append(result, state)
+	}
+	for _, state := range e.conditions {
+		result = append(result, state)
+	}
+	return result
+}
+
+func (e *alarmEngine) process(sample *SensorData) {
+	e.mu.Lock()
+	var events []AlarmEvent
+	wall := time.Now()
+	for channel, state := range e.rules {
+		value, ok := sample.channelValue(channel)
+		if !ok || e.suppressed(state.rule) {
+			continue
+		}
+		rule, err := convertRule(state.rule, e.unitOf(channel))
+		if err != nil {
+			continue
+		}
+		rule = adaptRule(rule, sample)
+		if event := e.evaluate(state, rule, value, sample.Timestamp); event != nil {
+			event.Silenced = state.active != nil && e.quiet(state.active, wall)
+			events = append(events, *event)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, event := range events {
+		e.notify(event)
+	}
+}
+
+// suppressed reports whether the signal feeding a rule is too poor to trust.
+// The alarm state is held as is until the quality recovers.
+func (e *alarmEngine) suppressed(rule AlarmRule) bool {
+	if rule.MinQuality <= 0 {
+		return false
+	}
+	channel := rule.QualityChannel
+	if channel == "" {
+		channel = rule.Channel
+	}
+	quality, known := e.quality(channel)
+	return known && quality < rule.MinQuality
+}
+
+// convertRule expresses the limits of a rule in the channel's unit
+func convertRule(rule AlarmRule, unit string) (AlarmRule, error) {
+	if rule.Unit == "" || rule.Unit == unit {
+		return rule, nil
+	}
+	if unit == "" {
+		return rule, fmt.Errorf("channel '%s' has no unit to convert %s limits to", rule.Channel, rule.Unit)
+	}
+
+	for _, limit := range []**float64{&rule.WarningLow, &rule.WarningHigh, &rule.CriticalLow, &rule.CriticalHigh} {
+		if *limit == nil {
+			continue
+		}
+		converted, err := convertUnit(**limit, rule.Unit, unit)
+		if err != nil {
+			return rule, err
+		}
+		*limit = &converted
+	}
+
+	var err error
+	if rule.Hysteresis, err = convertDelta(rule.Hysteresis, rule.Unit, unit); err != nil {
+		return rule, err
+	}
+	if rule.Adaptive != nil {
+		adaptive := *rule.Adaptive
+		if adaptive.Margin, err = convertDelta(adaptive.Margin, rule.Unit, unit); err != nil {
+			return rule, err
+		}
+		rule.Adaptive = &adaptive
+	}
+	rule.Unit = unit
+	return rule, nil
+}
+
+// adaptRule fills in the adaptive limits of a rule from the percentiles in
+// the sample. Limits whose percentile isn't available yet stay unchecked.
+func adaptRule(rule AlarmRule, sample *SensorData) AlarmRule {
+	adaptive := rule.Adaptive
+	if adaptive == nil {
+		return rule
+	}
+
+	low, high := &rule.WarningLow, &rule.WarningHigh
+	if adaptive.Severity == SeverityCritical {
+		low, high = &rule.CriticalLow, &rule.CriticalHigh
+	}
+	if *low == nil && adaptive.LowPercentile > 0 {
+		if p, ok := sample.channelValue(percentileChannel(rule.Channel, adaptive.LowPercentile)); ok {
+			limit := p - adaptive.Margin
+			*low = &limit
+		}
+	}
+	if *high == nil && adaptive.HighPercentile > 0 {
+		if p, ok := sample.channelValue(percentileChannel(rule.Channel, adaptive.HighPercentile)); ok {
+			limit := p + adaptive.Margin
+			*high = &limit
+		}
+	}
+	return rule
+}
+
+// evaluate advances the state of one rule and returns an event on severity changes
+func (e *alarmEngine) evaluate(state *alarmState, rule AlarmRule, value float64, now time.Time) *AlarmEvent {
+	current := SeverityNormal
+	if state.active != nil {
+		current = state.active.Severity
+	}
+
+	target, limit := classify(rule, value, current)
+	if state.active != nil {
+		if severityRank[target] >= severityRank[current] {
+			state.active.Latched = false
+		} else if rule.Latching && !state.active.Acknowledged {
+			// Latching alarms only de-escalate once acknowledged
+			state.active.Latched = true
+			state.pending = ""
+			return nil
+		}
+	}
+	if target == current {
+		state.pending = ""
+		return nil
+	}
+
+	// Escalations must persist for the minimum duration; de-escalations are
+	// already debounced by the hysteresis and apply immediately
+	if severityRank[target] > severityRank[current] {
+		if state.pending != target {
+			state.pending = target
+			state.pendingSince = now
+		}
+		if now.Sub(state.pendingSince) < secondsToDuration(rule.MinDurationSeconds) {
+			return nil
+		}
+	}
+	state.pending = ""
+
+	event := &AlarmEvent{
+		Channel:   rule.Channel,
+		Severity:  target,
+		Previous:  current,
+		Value:     value,
+		Limit:     limit,
+		Timestamp: now,
+	}
+
+	switch {
+	case state.active == nil:
+		state.active = &Alarm{
+			ID:       e.nextID,
+			Channel:  rule.Channel,
+			Severity: target,
+			Value:    value,
+			Limit:    limit,
+			RaisedAt: now,
+		}
+		e.nextID++
+		event.Code = MsgAlarmRaised
+		event.Message = tr(MsgAlarmRaised, rule.Channel, tr(target), value, limit)
+	case target == SeverityNormal:
+		event.Code = MsgAlarmCleared
+		event.Message = tr(MsgAlarmCleared, rule.Channel, value)
+	default:
+		event.Code = MsgAlarmChanged
+		event.Message = tr(MsgAlarmChanged, rule.Channel, tr(current), tr(target), value, limit)
+	}
+	event.ID = state.active.ID
+
+	if target == SeverityNormal {
+		state.active = nil
+	} else {
+		if severityRank[target] > severityRank[current] {
+			state.active.Acknowledged = false
+			state.active.SnoozedUntil = time.Time{}
+		}
+		state.active.Severity = target
+		state.active.Value = value
+		state.active.Limit = limit
+	}
+	return event
+}
+
+// classify returns the severity the value falls into and the limit it crossed.
+// Limits of the current severity and below are relaxed by the hysteresis so a
+// value hovering at a limit doesn't toggle the alarm.
+func classify(rule AlarmRule, value float64, current string) (string, float64) {
+	check := func(severity string, low, high *float64) (bool, float64) {
+		margin := 0.0
+		if severityRank[current] >= severityRank[severity] {
+			margin = rule.Hysteresis
+		}
+		if low != nil && value < *low+margin {
+			return true, *low
+		}
+		if high != nil && value > *high-margin {
+			return true, *high
+		}
+		return false, 0
+	}
+
+	if violated, limit := check(SeverityCritical, rule.CriticalLow, rule.CriticalHigh); violated {
+		return SeverityCritical, limit
+	}
+	if violated, limit := check(SeverityWarning, rule.WarningLow, rule.WarningHigh); violated {
+		return SeverityWarning, limit
+	}
+	return SeverityNormal, 0
+}
+
+// onAlarmEvent logs an alarm change and forwards it to the frontend
+func (a *App) onAlarmEvent(event AlarmEvent) {
+	alarmsLog.Infof("Alarm #%d [%s]: %s", event.ID, event.Severity, event.Message)
+	a.alarmLog.record(event)
+	a.silenceNewAlarm(event)
+	a.emit(EventAlarm, event)
+	if event.Silenced {
+		return
+	}
+	a.notifyAlarm(event)
+	a.email.queueAlarm(event)
+	a.messaging.notifyAlarm(event)
+}
+
+// SetAlarmRule adds or replaces the alarm limits of a channel
+func (a *App) SetAlarmRule(rule AlarmRule) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+	return a.setAlarmRule(rule)
+}
+
+// setAlarmRule is SetAlarmRule without the role check, for rules the app applies itself
+func (a *App) setAlarmRule(rule AlarmRule) error {
+	if rule.Channel == "" {
+		return fmt.Errorf("channel is required")
+	}
+	if rule.Channel == ChannelNoData {
+		return fmt.Errorf("channel '%s' is reserved for the data-stream watchdog", rule.Channel)
+	}
+	if _, ok := statusChannelUnits[rule.Channel]; ok {
+		return fmt.Errorf("'%s' is a device status channel, set its rule with SetHealthAlarmRule", rule.Channel)
+	}
+	if rule.Hysteresis < 0 || rule.MinDurationSeconds < 0 {
+		return fmt.Errorf("hysteresis and minimum duration must not be negative")
+	}
+	if rule.MinQuality < 0 || rule.MinQuality > 1 {
+		return fmt.Errorf("minimum quality must be between 0 and 1, got %.2f", rule.MinQuality)
+	}
+	if rule.WarningLow == nil && rule.WarningHigh == nil && rule.CriticalLow == nil && rule.CriticalHigh == nil &&
+		rule.Adaptive == nil {
+		return fmt.Errorf("at least one limit is required")
+	}
+	if adaptive := rule.Adaptive; adaptive != nil {
+		if adaptive.Severity != SeverityWarning && adaptive.Severity != SeverityCritical {
+			return fmt.Errorf("unknown severity '%s'", adaptive.Severity)
+		}
+		if adaptive.LowPercentile == 0 && adaptive.HighPercentile == 0 {
+			return fmt.Errorf("adaptive limits need a low or high percentile")
+		}
+		for _, percentile := range []float64{adaptive.LowPercentile, adaptive.HighPercentile} {
+			if percentile != 0 && !a.percentiles.tracks(rule.Channel, percentile) {
+				return fmt.Errorf("percentile %.4g of '%s' is not tracked", percentile, rule.Channel)
+			}
+		}
+	}
+
+	if _, err := convertRule(rule, a.units.unitOf(rule.Channel)); err != nil {
+		return err
+	}
+
+	a.alarms.mu.Lock()
+	defer a.alarms.mu.Unlock()
+
+	// Keep the active alarm so replacing limits doesn't silently clear it
+	state, ok := a.alarms.rules[rule.Channel]
+	if !ok {
+		state = &alarmState{}
+		a.alarms.rules[rule.Channel] = state
+	}
+	state.rule = rule
+	state.pending = ""
+
+	alarmsLog.Infof("Alarm rule set for channel %s", rule.Channel)
+	a.audit.record("", AuditAlarmLimits, fmt.Sprintf("Alarm rule set for %s", rule.Channel))
+	return nil
+}
+
+// RemoveAlarmRule deletes the alarm limits of a channel, dropping any active alarm
+func (a *App) RemoveAlarmRule(channel string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+	return a.removeAlarmRule(channel)
+}
+
+// removeAlarmRule is RemoveAlarmRule without the role check, for rules the app applies itself
+func (a *App) removeAlarmRule(channel string) error {
+	a.alarms.mu.Lock()
+	defer a.alarms.mu.Unlock()
+
+	if _, ok := a.alarms.rules[channel]; !ok {
+		return fmt.Errorf("no alarm rule for channel '%s'", channel)
+	}
+	delete(a.alarms.rules, channel)
+
+	alarmsLog.Infof("Alarm rule removed for channel %s", channel)
+	a.audit.record("", AuditAlarmLimits, fmt.Sprintf("Alarm rule removed for %s", channel))
+	return nil
+}
+
+// GetAlarmRules returns the configured alarm rules sorted by channel
+func (a *App) GetAlarmRules() []AlarmRule {
+	a.alarms.mu.Lock()
+	defer a.alarms.mu.Unlock()
+
+	result := make([]AlarmRule, 0, len(a.alarms.rules))
+	for _, state := range a.alarms.rules {
+		result = append(result, state.rule)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Channel < result[j].Channel })
+	return result
+}
+
+// GetActiveAlarms returns the alarms currently raised, oldest first
+func (a *App) GetActiveAlarms() []Alarm {
+	a.alarms.mu.Lock()
+	defer a.alarms.mu.Unlock()
+
+	result := make([]Alarm, 0)
+	for _, state := range a.alarms.states() {
+		if state.active != nil {
+			result = append(result, *state.active)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// loudestUnacknowledged returns the highest severity among the active
+// alarms nobody has acknowledged or silenced
+func (e *alarmEngine) loudestUnacknowledged() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	loudest := SeverityNormal
+	for _, state := range e.states() {
+		if state.active != nil && !state.active.Acknowledged && !e.quiet(state.active, now) &&
+			severityRank[state.active.Severity] > severityRank[loudest] {
+			loudest = state.active.Severity
+		}
+	}
+	return loudest
+}
+
+// acknowledge marks an active alarm as seen; cleared alarms are ignored
+func (e *alarmEngine) acknowledge(id int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, state := range e.states() {
+		if state.active != nil && state.active.ID == id {
+			state.active.Acknowledged = true
+		}
+	}
+}
+
+// AcknowledgeAlarm records that a user has seen an alarm, which stops its
+// tone and lets a latched alarm clear with the next sample. Alarms that
+// already cleared can still be acknowledged for the record. With accounts,
+// the acknowledgement is by the user logged in.
+func (a *App) AcknowledgeAlarm(id int64, user string, note string) error {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return err
+	}
+
+	user, err := a.actingUser(user)
+	if err != nil {
+		return err
+	}
+	if user == "" {
+		return fmt.Errorf("user is required")
+	}
+
+	record, err := a.alarmLog.acknowledge(id, user, note, time.Now())
+	if err != nil {
+		return err
+	}
+	a.alarms.acknowledge(id)
+
+	alarmsLog.Infof("Alarm #%d acknowledged by %s", id, user)
+	a.audit.record(user, AuditAlarmAcknowledge, fmt.Sprintf("Alarm #%d acknowledged: %s", id, note))
+	a.emit(EventAlarmAcknowledged, record)
+	return nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Anomaly scoring methods
+const (
+	AnomalyMethodZScore = "zscore" // Distance from the rolling mean in standard deviations
+	AnomalyMethodMAD    = "mad"    // Robust z-score using the rolling median and median absolute deviation
+)
+
+// AnomalyConfig configures the anomaly detector of a channel
+type AnomalyConfig struct {
+	Enabled         bool    `json:"enabled"`
+	Method          string  `json:"method"`
+	WindowSeconds   float64 `json:"windowSeconds"`   // Length of the reference window
+	Threshold       float64 `json:"threshold"`       // Score above which a reading is anomalous; lower is more sensitive
+	MinSamples      int     `json:"minSamples"`      // Readings required in the window before scoring starts
+	CooldownSeconds float64 `json:"cooldownSeconds"` // Minimum time between two events of the same channel
+}
+
+// AnomalySettings holds the default detector configuration and per-channel overrides
+type AnomalySettings struct {
+	Default   AnomalyConfig            `json:"default"`
+	Overrides map[string]AnomalyConfig `json:"overrides"`
+}
+
+// AnomalyEvent is pushed to the frontend when a reading is statistically unusual
+type AnomalyEvent struct {
+	Channel   string    `json:"channel"`
+	Value     float64   `json:"value"`
+	Score     float64   `json:"score"`
+	Baseline  float64   `json:"baseline"` // Rolling mean or median the reading was compared to
+	Method    string    `json:"method"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// defaultAnomalyConfig flags readings more than 6 standard deviations from
+// the last 10 s. The z-score comes from the running sums of the window; the
+// robust MAD method costs a sort of the window and is set per channel.
+func defaultAnomalyConfig() AnomalyConfig {
+	return AnomalyConfig{
+		Enabled:         true,
+		Method:          AnomalyMethodZScore,
+		WindowSeconds:   10,
+		Threshold:       6,
+		MinSamples:      50,
+		CooldownSeconds: 5,
+	}
+}
+
+// madScale makes the MAD a consistent estimator of the standard deviation
+const madScale = 1.4826
+
+// madRefreshInterval is how often the MAD of a window is recomputed. The
+// spread of a 10 s window barely moves within a second, and computing it
+// sorts the whole window.
+const madRefreshInterval = time.Second
+
+// anomalyTracker is the rolling reference window of one channel
+type anomalyTracker struct {
+	config    AnomalyConfig
+	window    *statsTracker
+	lastEvent time.Time
+	mad       float64   // Scaled MAD of the window as of madAt
+	madAt     time.Time // Zero until the MAD is first computed
+}
+
+// anomalyDetector scores every channel against its own recent history
+type anomalyDetector struct {
+	mu       sync.Mutex
+	settings AnomalySettings
+	trackers map[string]*anomalyTracker
+	notify   func(AnomalyEvent) // Called for every anomaly, outside the lock
+}
+
+// newAnomalyDetector creates a detector applying the default config to every channel
+func newAnomalyDetector(notify func(AnomalyEvent)) *anomalyDetector {
+	return &anomalyDetector{
+		settings: AnomalySettings{
+			Default:   defaultAnomalyConfig(),
+			Overrides: make(map[string]AnomalyConfig),
+		},
+		trackers: make(map[string]*anomalyTracker),
+		notify:   notify,
+	}
+}
+
+// configFor returns the effective configuration of a channel
+func (d *anomalyDetector) configFor(channel string) AnomalyConfig {
+	if config, ok := d.settings.Overrides[channel]; ok {
+		return config
+	}
+	return d.settings.Default
+}
+
+func (d *anomalyDetector) process(sample *SensorData) {
+	d.mu.Lock()
+	var events []AnomalyEvent
+	sample.forEachChannel(func(name string, value float64) {
+		config := d.configFor(name)
+		if !config.Enabled {
+			return
+		}
+
+		tracker, ok := d.trackers[name]
+		if !ok {
+			tracker = &anomalyTracker{
+				config: config,
+				window: newStatsTracker(secondsToDuration(config.WindowSeconds), nil),
+			}
+			d.trackers[name] = tracker
+		}
+
+		// Score against the window before the reading joins it
+		if score, baseline, ok := tracker.score(sample.Timestamp, value); ok && math.Abs(score) > config.Threshold &&
+			sample.Timestamp.Sub(tracker.lastEvent) >= secondsToDuration(config.CooldownSeconds) {
+			tracker.lastEvent = sample.Timestamp
+			events = append(events, AnomalyEvent{
+				Channel:   name,
+				Value:     value,
+				Score:     score,
+				Baseline:  baseline,
+				Method:    config.Method,
+				Timestamp: sample.Timestamp,
+			})
+		}
+		tracker.window.add(sample.Timestamp, value)
+	})
+	d.mu.Unlock()
+
+	for _, event := range events {
+		d.notify(event)
+	}
+}
+
+// score returns the signed anomaly score of a reading at a time and the
+// baseline it was compared to. ok is false while the window is too short or
+// flat to judge.
+func (t *anomalyTracker) score(at time.Time, value float64) (float64, float64, bool) {
+	n := len(t.window.sorted)
+	if n < t.config.MinSamples {
+		return 0, 0, false
+	}
+
+	if t.config.Method == AnomalyMethodZScore {
+		stats := t.window.snapshot()
+		if stats.StdDev == 0 {
+			return 0, 0, false
+		}
+		return (value - stats.Mean) / stats.StdDev, stats.Mean, true
+	}
+
+	median := t.window.sorted.quantile(0.5)
+	if t.madAt.IsZero() || at.Sub(t.madAt) >= madRefreshInterval {
+		deviations := make(sortedValues, n)
+		for i, v := range t.window.sorted {
+			deviations[i] = math.Abs(v - median)
+		}
+		sort.Float64s(deviations)
+		t.mad, t.madAt = deviations.quantile(0.5)*madScale, at
+	}
+	if t.mad == 0 {
+		return 0, 0, false
+	}
+	return (value - median) / t.mad, median, true
+}
+
+// onAnomalyEvent logs an anomaly and forwards it to the frontend
+func (a *App) onAnomalyEvent(event AnomalyEvent) {
+	processingLog.Infof("Anomaly on %s: %.2f (score %.1f, baseline %.2f)", event.Channel, event.Value, event.Score, event.Baseline)
+	a.emit(EventAnomaly, event)
+}
+
+// GetAnomalySettings returns the default anomaly detector config and per-channel overrides
+func (a *App) GetAnomalySettings() AnomalySettings {
+	a.anomaly.mu.Lock()
+	defer a.anomaly.mu.Unlock()
+
+	overrides := make(map[string]AnomalyConfig, len(a.anomaly.settings.Overrides))
+	for channel, config := range a.anomaly.settings.Overrides {
+		overrides[channel] = config
+	}
+	return AnomalySettings{Default: a.anomaly.settings.Default, Overrides: overrides}
+}
+
+// SetAnomalyConfig configures the detector of a channel, or the default for
+// all channels without an override when channel is empty
+func (a *App) SetAnomalyConfig(channel string, config AnomalyConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if config.Method != AnomalyMethodZScore && config.Method != AnomalyMethodMAD {
+		return fmt.Errorf("unknown anomaly method '%s'", config.Method)
+	}
+	if config.WindowSeconds <= 0 || config.Threshold <= 0 {
+		return fmt.Errorf("window and threshold must be positive")
+	}
+	if config.MinSamples < 2 {
+		return fmt.Errorf("at least 2 samples are required, got %d", config.MinSamples)
+	}
+
+	a.anomaly.mu.Lock()
+	defer a.anomaly.mu.Unlock()
+
+	if channel == "" {
+		a.anomaly.settings.Default = config
+		// Restart every channel following the default
+		for name := range a.anomaly.trackers {
+			if _, ok := a.anomaly.settings.Overrides[name]; !ok {
+				delete(a.anomaly.trackers, name)
+			}
+		}
+	} else {
+		a.anomaly.settings.Overrides[channel] = config
+		delete(a.anomaly.trackers, channel)
+	}
+	return nil
+}
+
+// ClearAnomalyOverride makes a channel follow the default anomaly config again
+func (a *App) ClearAnomalyOverride(channel string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.anomaly.mu.Lock()
+	defer a.anomaly.mu.Unlock()
+
+	delete(a.anomaly.settings.Overrides, channel)
+	delete(a.anomaly.trackers, channel)
+	return nil
+}
@@ -2,8 +2,8 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,11 +15,76 @@ import (
 // App struct
 type App struct {
 	ctx              context.Context
-	serialPort       serial.Port
-	isConnected      bool
-	dataBuffer       []byte       // Buffer to accumulate incoming data
-	parsedDataBuffer []SensorData // Buffer to store parsed sensor data
-	bufferMutex      sync.RWMutex // Mutex to protect the buffer
+	conn             *connectionManager // Connection state, the open port and who may use it
+	dataBuffer       []byte             // Buffer to accumulate incoming data
+	parsedDataBuffer []SensorData       // Buffer to store parsed sensor data
+	bufferMutex      sync.RWMutex       // Mutex to protect the buffer
+
+	processors  []processor           // Processing stages run on every parsed sample
+	spo2        *spo2Processor        // SpO2 and perfusion index from the PPG channels
+	history     *channelHistory       // Recent readings of every channel for analysis queries
+	stats       *statsProcessor       // Rolling statistics for dashboard tiles
+	alarms      *alarmEngine          // Threshold alarms on any channel
+	anomaly     *anomalyDetector      // Statistical outlier detection on every channel
+	trends      *trendProcessor       // Rate-of-change derived channels
+	baseline    *baselineProcessor    // Drift removal on waveform channels
+	resample    *resampleProcessor    // Fixed-rate copy of the stream
+	derived     *derivedProcessor     // User-defined expression channels
+	peaks       *peakDetector         // Peak/trough events on configured channels
+	quality     *qualityMonitor       // Signal quality index of the raw channels
+	artifacts   *artifactProcessor    // Motion/artifact marking
+	respiration *respirationEstimator // Respiratory rate derived channel
+	hrv         *hrvAnalyzer          // Beat detection and heart rate variability
+	calibration *calibrationStore     // Per-channel gain/offset and the calibration procedure
+	percentiles *percentileProcessor  // Rolling percentile derived channels
+	episodes    *episodeDetector      // Named episodes with hysteresis and debounce
+	rollups     *rollupProcessor      // Min/mean/max rollups for long trend charts
+	calculus    *calculusProcessor    // Integral and derivative derived channels
+	units       *unitStore            // Engineering unit of every channel
+	presets     *alarmPresets         // Patient-category alarm limit bundles
+	notifier    *desktopNotifier      // OS-level alarm notifications
+	sound       *alarmSound           // Audible alarm tones
+	alarmLog    *alarmLog             // Persistent alarm records and acknowledgments
+	email       *emailNotifier        // SMTP alert summaries
+	messaging   *messagingNotifier    // Telegram and SMS alerts
+	escalation  *escalationTracker    // Notification tiers for unacknowledged alarms
+	limits      *alarmProfileStore    // Named, versioned alarm limit profiles
+	sessions    *sessionLog           // Metadata of every monitoring session
+	watchdog    *streamWatchdog       // No-data alarm while connected
+	devices     *deviceRegistry       // Known devices and their settings
+	firmware    *firmwareUpdater      // XMODEM/YMODEM firmware flashing
+	console     *deviceConsole        // Raw terminal to the device
+	simulator   *waveformSimulator    // Built-in device for demos and tests
+	health      *deviceHealth         // Battery, temperature and RSSI status channels
+	latest      *latestSample         // Last values of every channel for the overview
+	parser      lineParser            // Line format of the connected device, guarded by bufferMutex
+	lenient     fieldParser           // Lenient decoder of the format, nil if it has none; guarded by bufferMutex
+	lastRaw     []float64             // Raw channel values of the last decoded line; guarded by bufferMutex
+	offParse    bool                  // The parser is a plugin's and runs off the reader; guarded by bufferMutex
+	pluginLines chan pluginLine       // Lines waiting for the plugin parser
+	quarantine  *lineQuarantine       // Malformed lines for review
+	faults      *faultInjector        // Damages the received stream in developer mode
+	benchmark   *benchmarkGuard       // Lets one benchmark run at a time
+	audit       *auditLog             // Append-only record of user and system actions
+	pseudonyms  *pseudonymStore       // Stable pseudonyms of patients in exports
+	users       *userStore            // Local accounts and the operator or admin logged in
+	sealer      *sessionSealer        // Signs finished sessions
+	lock        *appLock              // PIN lock of the protected bindings
+	settings    *settingsStore        // General application preferences
+	updater     *updater              // Release checks and installer downloads
+	quit        chan struct{}         // Closed on shutdown to stop the background readers
+	loops       sync.WaitGroup        // Background loops, waited for on shutdown
+	capture     *rawCapture           // Last bytes from the device for diagnostic bundles
+	tray        *trayState            // System tray icon and menu
+	recorder    *recorder             // Writes the samples of every session to files while recording
+	mqtt        *mqttSink             // Publishes every sample to an MQTT broker
+	plugins     *pluginManager        // Parser and sink plugins running as child processes
+	resume      *resumeState          // Last connection, restored at startup when auto-connect is on
+	uiErrors    *frontendErrors       // Exceptions reported by the UI
+	schedules   *recordingScheduler   // Unattended recordings
+	sniffer     *serialSniffer        // Raw dump of the serial traffic
+	perf        *perfMetrics          // Throughput and latency of the data path
+	clock       sampleClock           // Arrival time of the last valid sample
 }
 
 // SerialPortInfo represents information about a serial port
@@ -31,27 +96,139 @@ type SerialPortInfo struct {
 // ConnectionResult represents the result of a connection attempt
 type ConnectionResult struct {
 	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Code    string `json:"code,omitempty"` // Message code, see i18n.go; empty for errors of other subsystems
+	Message string `json:"message"`        // In the user's language
 }
 
 // SensorData represents the data received from the sensor
 type SensorData struct {
-	Value1    float64   `json:"value1"`
-	Value2    float64   `json:"value2"`
-	Value3    float64   `json:"value3"`
-	Timestamp time.Time `json:"timestamp"`
+	Value1    float64            `json:"value1"`
+	Value2    float64            `json:"value2"`
+	Value3    float64            `json:"value3"`
+	Derived   map[string]float64 `json:"derived,omitempty"`   // Channels computed by the processing pipeline
+	Artifacts []string           `json:"artifacts,omitempty"` // Channels whose reading is marked as an artifact
+	Timestamp time.Time          `json:"timestamp"`
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
+	setupLogging()
+	atRest.load()
+	profiles.load()
+	certificates.load()
+	telemetry.load()
 	app := &App{
-		isConnected:      false,
+		conn:             newConnectionManager(),
 		dataBuffer:       make([]byte, 0),
 		parsedDataBuffer: make([]SensorData, 0),
+		spo2:             newSpO2Processor(),
+		history:          newChannelHistory(),
+		trends:           newTrendProcessor(),
+		baseline:         newBaselineProcessor(),
+		resample:         newResampleProcessor(),
+		derived:          newDerivedProcessor(),
+		artifacts:        newArtifactProcessor(),
+		respiration:      newRespirationEstimator(),
+		hrv:              newHRVAnalyzer(),
+		percentiles:      newPercentileProcessor(),
+		rollups:          newRollupProcessor(),
+		calculus:         newCalculusProcessor(),
+		units:            newUnitStore(),
+		presets:          newAlarmPresets(),
+		notifier:         newDesktopNotifier(),
+		sound:            newAlarmSound(),
+		alarmLog:         newAlarmLog(),
+		email:            newEmailNotifier(),
+		messaging:        newMessagingNotifier(),
+		escalation:       newEscalationTracker(),
+		limits:           newAlarmProfileStore(),
+		sessions:         newSessionLog(),
+		watchdog:         newStreamWatchdog(),
+		devices:          newDeviceRegistry(),
+		firmware:         newFirmwareUpdater(),
+		console:          newDeviceConsole(),
+		simulator:        newWaveformSimulator(),
+		health:           newDeviceHealth(),
+		latest:           newLatestSample(),
+		parser:           parseHexData,
+		lenient:          hexFields,
+		audit:            newAuditLog(),
+		pseudonyms:       newPseudonymStore(),
+		users:            newUserStore(),
+		sealer:           newSessionSealer(),
+		lock:             newAppLock(),
+		settings:         newSettingsStore(),
+		updater:          newUpdater(),
+		quit:             make(chan struct{}),
+		capture:          newRawCapture(),
+		tray:             newTrayState(),
+		resume:           newResumeState(),
+		uiErrors:         newFrontendErrors(),
+		schedules:        newRecordingScheduler(),
+		sniffer:          newSerialSniffer(),
+		perf:             newPerfMetrics(),
+		quarantine:       newLineQuarantine(),
+		pluginLines:      make(chan pluginLine, pluginLineQueue),
+		faults:           newFaultInjector(),
+		benchmark:        &benchmarkGuard{},
+	}
+	app.stats = newStatsProcessor(app.history)
+	app.calibration = newCalibrationStore(app.onCalibrationPoint)
+	app.quality = newQualityMonitor(app.onSignalQuality)
+	app.alarms = newAlarmEngine(app.onAlarmEvent, app.quality.qualityOf, app.units.unitOf)
+	app.alarms.nextID = app.alarmLog.lastID() + 1
+	app.anomaly = newAnomalyDetector(app.onAnomalyEvent)
+	app.peaks = newPeakDetector(app.onPeakEvent)
+	app.episodes = newEpisodeDetector(app.onEpisode)
+	app.recorder = newRecorder(app.sessions.currentID)
+	app.mqtt = newMQTTSink(app.devices.connectedID)
+	app.mqtt.applyOverrides()
+	app.plugins = newPluginManager(app.onPluginChange)
+	app.processors = []processor{
+		app.calibration,
+		app.artifacts,
+		app.spo2,
+		app.baseline,
+		app.derived,
+		app.calculus,
+		app.respiration,
+		app.hrv,
+		app.trends,
+		app.percentiles,
+		app.peaks,
+		app.episodes,
+		app.history,
+		app.stats,
+		app.rollups,
+		app.quality,
+		app.alarms,
+		app.anomaly,
+		app.resample,
+		app.latest,
+		app.recorder,
+		app.mqtt,
+		app.plugins,
 	}
 
 	// Start background serial reader
-	go app.serialReader()
+	app.goLoop("serial reader", app.serialReader)
+	app.goLoop("plugin parser", app.pluginParseLoop)
+	app.goLoop("alarm sound", app.alarmSoundLoop)
+	app.goLoop("email", app.emailLoop)
+	app.goLoop("escalation", app.escalationLoop)
+	app.goLoop("silence", app.silenceLoop)
+	app.goLoop("watchdog", app.watchdogLoop)
+	app.goLoop("dashboard", app.dashboardLoop)
+	app.goLoop("app lock", app.lockLoop)
+	app.goLoop("log stream", app.logStreamLoop)
+	app.goLoop("sleep watch", app.sleepWatchLoop)
+	app.goLoop("MQTT", app.mqttLoop)
+	app.goLoop("telemetry", app.telemetryLoop)
+	app.goLoop("config watch", app.configWatchLoop)
+	app.goLoop("recording schedule", app.recordingScheduleLoop)
+	app.goLoop("serial sniffer", app.snifferLoop)
+	app.plugins.startAll()
+	backendErrors.listen(func(e BackendError) { app.emit(EventAppError, e) })
 
 	return app
 }
@@ -60,13 +237,15 @@ func NewApp() *App {
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.startTray()
+	goSafe("auto-connect", a.autoConnectLoop)
 }
 
 // GetSerialPorts returns a list of available serial ports
 func (a *App) GetSerialPorts() ([]SerialPortInfo, error) {
 	ports, err := serial.GetPortsList()
 	if err != nil {
-		log.Printf("Error getting serial ports: %v", err)
+		serialLog.Errorf("Error getting serial ports: %v", err)
 		return nil, err
 	}
 
@@ -78,149 +257,292 @@ func (a *App) GetSerialPorts() ([]SerialPortInfo, error) {
 		})
 	}
 
-	log.Printf("Found %d serial ports", len(result))
+	serialLog.Debugf("Found %d serial ports", len(result))
 	return result, nil
 }
 
 // ConnectToSerialPort attempts to connect to the specified serial port
 func (a *App) ConnectToSerialPort(portName string, baudRate int) ConnectionResult {
-	if a.isConnected {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return ConnectionResult{Success: false, Message: err.Error()}
+	}
+	telemetry.count(FeatureSerial)
+	return a.connect(portName, baudRate)
+}
+
+// connect is ConnectToSerialPort without the role check, for headless mode
+func (a *App) connect(portName string, baudRate int) ConnectionResult {
+	if atRest.locked() {
+		return ConnectionResult{Success: false, Message: errStorageLocked.Error()}
+	}
+	if err := a.beginConnection(portName, baudRate); err != nil {
 		return ConnectionResult{
 			Success: false,
-			Message: "Already connected to a port",
+			Code:    MsgAlreadyConnected,
+			Message: tr(MsgAlreadyConnected),
 		}
 	}
 
-	mode := &serial.Mode{
-		BaudRate: baudRate,
-		Parity:   serial.NoParity,
-		DataBits: 8,
-		StopBits: serial.OneStopBit,
-	}
-
-	port, err := serial.Open(portName, mode)
+	port, err := openSerialPort(portName, serialMode(baudRate))
 	if err != nil {
-		log.Printf("Error opening serial port %s: %v", portName, err)
+		serialLog.Errorf("Error opening serial port %s: %v", portName, err)
+		a.setConnection(ConnectionError, err.Error(), nil)
 		return ConnectionResult{
 			Success: false,
-			Message: fmt.Sprintf("Failed to open port: %v", err),
+			Code:    MsgPortOpenFailed,
+			Message: tr(MsgPortOpenFailed, err),
 		}
 	}
+	port = a.sniffer.wrap(port)
 
-	a.serialPort = port
-	a.isConnected = true
+	// Apply the device's settings before its first sample is read
+	device := a.devices.attach(portName, time.Now())
+	a.applyDeviceSettings(device)
+
+	a.bufferMutex.Lock()
 	a.dataBuffer = make([]byte, 0) // Clear buffer on new connection
+	a.bufferMutex.Unlock()
+	a.clock.mark(time.Now()) // A port that never sends counts as a lost stream
+	a.startSession(device, baudRate)
+	if err := a.setConnection(ConnectionConnected, "", port); err != nil {
+		// Disconnected while the port was being opened
+		port.Close()
+		a.closeSession()
+		return ConnectionResult{Success: false, Message: err.Error()}
+	}
+	if device.Provisioned == nil {
+		a.emit(EventNewDevice, device)
+	}
+	goSafe("capability discovery", a.discoverOnConnect)
 
-	log.Printf("Successfully connected to %s at %d baud", portName, baudRate)
+	a.resume.connected(portName, baudRate, device.ID)
+	serialLog.Infof("Successfully connected to %s at %d baud", portName, baudRate)
+	a.audit.record("", AuditConnect, fmt.Sprintf("Connected to %s (%s) at %d baud", portName, device.ID, baudRate))
 	return ConnectionResult{
 		Success: true,
-		Message: fmt.Sprintf("Connected to %s at %d baud", portName, baudRate),
+		Code:    MsgConnected,
+		Message: tr(MsgConnected, portName, baudRate),
+	}
+}
+
+// serialMode is the line setup of the sensor port: 8 data bits, no parity, one stop bit
+func serialMode(baudRate int) *serial.Mode {
+	return &serial.Mode{
+		BaudRate: baudRate,
+		Parity:   serial.NoParity,
+		DataBits: 8,
+		StopBits: serial.OneStopBit,
 	}
 }
 
 // serialReader runs in background to continuously read and buffer serial data
 func (a *App) serialReader() {
 	for {
-		if !a.isConnected || a.serialPort == nil {
+		select {
+		case <-a.quit:
+			return
+		default:
+		}
+		status, port := a.conn.current()
+		if status.State == ConnectionReconnecting {
+			a.reconnect(status)
+			continue
+		}
+		if status.State != ConnectionConnected || port == nil {
 			time.Sleep(100 * time.Millisecond)
 			continue
 		}
 
-		// Set read timeout
-		a.serialPort.SetReadTimeout(10 * time.Millisecond)
-
 		// Read available data from serial port
 		tempBuffer := make([]byte, 100)
-		n, err := a.serialPort.Read(tempBuffer)
+		n, err := a.conn.read(port, tempBuffer)
+		if errors.Is(err, errPortTaken) {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		if errors.Is(err, errPortReplaced) {
+			continue
+		}
 		if err != nil {
 			if !strings.Contains(err.Error(), "timeout") {
-				log.Printf("Error reading from serial port: %v", err)
+				serialLog.Errorf("Error reading from serial port: %v", err)
+				backendErrors.report(ErrorRead, "Reading from the serial port failed", err)
+				a.connectionLost(port, err.Error(), time.Now())
 			}
 			continue
 		}
 
-		if n == 0 {
+		data := a.faults.apply(tempBuffer[:n], len(tempBuffer))
+		if len(data) == 0 {
 			continue
 		}
 
-		// Add new data to buffer
-		a.bufferMutex.Lock()
-		a.dataBuffer = append(a.dataBuffer, tempBuffer[:n]...)
-
-		// Process complete lines
-		dataStr := string(a.dataBuffer)
-		lines := strings.Split(dataStr, "\n")
-
-		// Process all complete lines except the last one (which might be incomplete)
-		for i := 0; i < len(lines)-1; i++ {
-			line := strings.TrimSpace(lines[i])
-
-			if line != "" {
-				sensorData, err := a.parseHexData(line)
-				if err == nil {
-					// Add to parsed data buffer
-					a.parsedDataBuffer = append(a.parsedDataBuffer, *sensorData)
-					log.Printf("Parsed sensor data - ECG: %.1f, Resp: %.1f, SpO2: %.1f",
-						sensorData.Value1, sensorData.Value2, sensorData.Value3)
-				} else {
-					log.Printf("Error parsing line '%s': %v", line, err)
-				}
-			}
-		}
+		a.receive(data)
+	}
+}
 
-		// Keep only the last incomplete line in buffer
-		if len(lines) > 0 {
-			lastLine := lines[len(lines)-1]
-			a.dataBuffer = []byte(lastLine)
-		}
+// receive adds bytes read from the device to the buffer and processes the
+// complete lines
+func (a *App) receive(data []byte) {
+	arrived := time.Now()
+	a.capture.write(data)
 
-		// Clear buffer if it gets too large
-		if len(a.dataBuffer) > 500 {
-			a.dataBuffer = a.dataBuffer[:0]
+	// Add new data to buffer
+	a.bufferMutex.Lock()
+	a.dataBuffer = append(a.dataBuffer, data...)
+
+	// Process complete lines
+	dataStr := string(a.dataBuffer)
+	lines := strings.Split(dataStr, "\n")
+
+	// Process all complete lines except the last one (which might be incomplete)
+	for i := 0; i < len(lines)-1; i++ {
+		line := lines[i]
+		buffered := len(a.parsedDataBuffer)
+		// A line that crashes the parser or a processor is dropped, not the stream
+		if safely("line processing", func() { a.processLine(line) }) {
+			a.quality.recordParseError()
+			backendErrors.report(ErrorParse, "Processing a line from the device crashed", nil)
 		}
+		if len(a.parsedDataBuffer) > buffered {
+			a.perf.processed(arrived, time.Now())
+		}
+	}
+	a.perf.received(arrived, len(data), len(lines)-1)
+
+	// Keep only the last incomplete line in buffer
+	if len(lines) > 0 {
+		lastLine := lines[len(lines)-1]
+		a.dataBuffer = []byte(lastLine)
+	}
+
+	// Clear buffer if it gets too large
+	if len(a.dataBuffer) > 500 {
+		a.dataBuffer = a.dataBuffer[:0]
+	}
 
-		a.bufferMutex.Unlock()
+	a.bufferMutex.Unlock()
+}
+
+// processLine parses one line of the stream, as received without its line
+// feed, and runs the sample through the pipeline. A line the parser rejects
+// is recovered in lenient mode, or else quarantined. Lines for a plugin
+// parser are queued for the plugin parse loop instead. The caller holds
+// bufferMutex.
+func (a *App) processLine(raw string) {
+	line := strings.TrimSpace(raw)
+	if isHousekeepingLine(line) {
+		a.recordHousekeeping(line)
+		return
+	}
+	if line == "" {
+		return
+	}
+	if a.offParse {
+		a.queuePluginLine(raw)
+		return
+	}
+	sensorData, err := a.parser(line)
+	a.acceptLine(raw, line, sensorData, err)
+}
+
+// acceptLine runs a parsed line through the pipeline, or recovers or
+// quarantines it if the parser rejected it. The caller holds bufferMutex.
+func (a *App) acceptLine(raw, line string, sensorData *SensorData, err error) {
+	if err != nil {
+		a.quality.recordParseError()
+		serialLog.Debugf("Error parsing line '%s': %v", line, err)
+		backendErrors.report(ErrorParse, "A line from the device could not be decoded", err)
+		if sensorData = a.recoverLine(line); sensorData == nil {
+			a.quarantine.add(raw, err)
+			return
+		}
+	}
+	a.lastRaw = a.lastRaw[:0]
+	for _, channel := range rawChannels {
+		value, _ := sensorData.channelValue(channel)
+		a.lastRaw = append(a.lastRaw, value)
 	}
+	a.processSample(sensorData)
+
+	// Add to parsed data buffer
+	a.parsedDataBuffer = append(a.parsedDataBuffer, *sensorData)
+	serialLog.Debugf("Parsed sensor data - ECG: %.1f, Resp: %.1f, SpO2: %.1f",
+		sensorData.Value1, sensorData.Value2, sensorData.Value3)
 }
 
 // DisconnectFromSerialPort disconnects from the current serial port
 func (a *App) DisconnectFromSerialPort() ConnectionResult {
-	if !a.isConnected || a.serialPort == nil {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return ConnectionResult{Success: false, Message: err.Error()}
+	}
+	return a.disconnect()
+}
+
+// disconnect is DisconnectFromSerialPort without the role check, for shutdown
+func (a *App) disconnect() ConnectionResult {
+	if a.IsSimulatorRunning() {
+		if err := a.stopSimulator(); err != nil {
+			return ConnectionResult{Success: false, Message: err.Error()}
+		}
+		return ConnectionResult{Success: true, Code: MsgSimulatorStopped, Message: tr(MsgSimulatorStopped)}
+	}
+	status, _ := a.conn.current()
+	switch status.State {
+	case ConnectionError:
+		a.setConnection(ConnectionDisconnected, "disconnected by the user", nil)
+		return ConnectionResult{Success: true, Code: MsgDisconnected, Message: tr(MsgDisconnected)}
+	case ConnectionConnected, ConnectionReconnecting:
+	default:
 		return ConnectionResult{
 			Success: false,
-			Message: "No active connection",
+			Code:    MsgNoConnection,
+			Message: tr(MsgNoConnection),
 		}
 	}
-
-	err := a.serialPort.Close()
-	if err != nil {
-		log.Printf("Error closing serial port: %v", err)
+	owner, err := a.closeConnection(ConnectionDisconnected, "disconnected by the user")
+	if owner != "" {
 		return ConnectionResult{
 			Success: false,
-			Message: fmt.Sprintf("Error closing port: %v", err),
+			Code:    MsgPortInUse,
+			Message: tr(MsgPortInUse, owner),
 		}
 	}
+	if err != nil {
+		return ConnectionResult{Success: false, Message: err.Error()}
+	}
 
-	a.serialPort = nil
-	a.isConnected = false
-	a.dataBuffer = make([]byte, 0) // Clear buffer on disconnect
+	a.closeSession()
 
-	log.Println("Serial port disconnected")
+	serialLog.Infof("Serial port disconnected")
+	a.audit.record("", AuditDisconnect, "Serial port disconnected")
 	return ConnectionResult{
 		Success: true,
-		Message: "Disconnected successfully",
+		Code:    MsgDisconnected,
+		Message: tr(MsgDisconnected),
 	}
 }
 
-// IsConnected returns the current connection status
+// closeSession ends the session of a device that is no longer attached
+func (a *App) closeSession() {
+	a.bufferMutex.Lock()
+	a.dataBuffer = make([]byte, 0) // Clear buffer on disconnect
+	a.bufferMutex.Unlock()
+	a.endSession()
+	a.devices.detach()
+	a.clearDeviceHealth()
+	a.latest.reset()
+}
+
+// IsConnected reports whether a device or the simulator is attached
 func (a *App) IsConnected() bool {
-	return a.isConnected
+	return a.conn.connected()
 }
 
 // ReadSensorData returns all buffered sensor data and clears the buffer
 func (a *App) ReadSensorData() ([]SensorData, error) {
-	if !a.isConnected {
-		return nil, fmt.Errorf("not connected to serial port")
+	if !a.conn.connected() {
+		return nil, trError(MsgNotConnected)
 	}
 
 	a.bufferMutex.Lock()
@@ -232,16 +554,17 @@ func (a *App) ReadSensorData() ([]SensorData, error) {
 
 	// Clear the buffer after returning data
 	a.parsedDataBuffer = a.parsedDataBuffer[:0]
+	a.perf.delivered(time.Now())
 
 	if len(result) > 0 {
-		log.Printf("Returning %d sensor data points to frontend", len(result))
+		serialLog.Debugf("Returning %d sensor data points to frontend", len(result))
 	}
 
 	return result, nil
 }
 
 // parseHexData parses comma-separated hex values (e.g., "0x215c,0x3711,0xffffa4d9")
-func (a *App) parseHexData(dataStr string) (*SensorData, error) {
+func parseHexData(dataStr string) (*SensorData, error) {
 	// Clean the data string
 	dataStr = strings.TrimSpace(dataStr)
 
@@ -249,7 +572,7 @@ func (a *App) parseHexData(dataStr string) (*SensorData, error) {
 	parts := strings.Split(dataStr, ",")
 
 	if len(parts) < 3 {
-		return nil, fmt.Errorf("invalid format: expected 3 hex values, got %d in '%s'", len(parts), dataStr)
+		return nil, trError(MsgParseHexCount, len(parts), dataStr)
 	}
 
 	// Check if all parts are valid hex format
@@ -257,12 +580,12 @@ func (a *App) parseHexData(dataStr string) (*SensorData, error) {
 		part = strings.TrimSpace(part)
 
 		if !strings.HasPrefix(part, "0x") && !strings.HasPrefix(part, "0X") {
-			return nil, fmt.Errorf("part %d '%s' is not valid hex format", i+1, part)
+			return nil, trError(MsgParseHexValue, i+1, part)
 		}
 		// Check if hex part has enough characters
 		hexPart := part[2:]
 		if len(hexPart) == 0 || len(hexPart) > 8 {
-			return nil, fmt.Errorf("part %d '%s' has invalid hex length", i+1, part)
+			return nil, trError(MsgParseHexLength, i+1, part)
 		}
 	}
 
@@ -272,14 +595,13 @@ func (a *App) parseHexData(dataStr string) (*SensorData, error) {
 	value3, err3 := parseHexToInt32(strings.TrimSpace(parts[2]))
 
 	if err1 != nil || err2 != nil || err3 != nil {
-		return nil, fmt.Errorf("error parsing hex values: %v, %v, %v", err1, err2, err3)
+		return nil, trError(MsgParseHexValues, err1, err2, err3)
 	}
 
 	// Apply basic scaling to convert raw values to medical ranges
-	// These scaling factors may need adjustment based on your specific sensor
-	ecgValue := float64(value1) / 100.0   // Scale ECG to reasonable mV range
-	respValue := float64(value2) / 200.0  // Scale respiratory signal
-	spo2Value := float64(value3) / 1000.0 // Scale SpO2 signal
+	ecgValue := float64(value1) / hexScales[0]
+	respValue := float64(value2) / hexScales[1]
+	spo2Value := float64(value3) / hexScales[2]
 
 	return &SensorData{
 		Value1:    ecgValue,
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information, set at build time with
+// -ldflags "-X main.appVersion=1.2.0 -X main.appCommit=$(git rev-parse HEAD) -X main.appBuildDate=$(date -u +%FT%TZ)".
+// Commit and build date fall back to the VCS stamp of the Go toolchain.
+var (
+	appVersion   = "dev"
+	appCommit    = ""
+	appBuildDate = ""
+)
+
+// Modules whose versions are reported
+const (
+	serialModule = "go.bug.st/serial"
+	wailsModule  = "github.com/wailsapp/wails/v2"
+)
+
+// AppInfo describes the running build and its environment, for bug reports
+// and the About dialog
+type AppInfo struct {
+	Version       string `json:"version"`
+	Commit        string `json:"commit"`
+	Modified      bool   `json:"modified"` // Built from a tree with uncommitted changes
+	BuildDate     string `json:"buildDate"`
+	GoVersion     string `json:"goVersion"`
+	OS            string `json:"os"`
+	Arch          string `json:"arch"`
+	SerialVersion string `json:"serialVersion"`
+	WailsVersion  string `json:"wailsVersion"`
+	DataDir       string `json:"dataDir"`
+	Portable      bool   `json:"portable"` // Data is kept beside the executable
+}
+
+// GetAppInfo returns the app version, build and environment
+func (a *App) GetAppInfo() AppInfo {
+	info := AppInfo{
+		Version:   appVersion,
+		Commit:    appCommit,
+		BuildDate: appBuildDate,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Portable:  portableDir != "",
+	}
+	info.DataDir, _ = appDataDir()
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, dep := range build.Deps {
+		switch dep.Path {
+		case serialModule:
+			info.SerialVersion = dep.Version
+		case wailsModule:
+			info.WailsVersion = dep.Version
+		}
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildDate == "" {
+				info.BuildDate = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
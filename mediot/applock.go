@@ -0,0 +1,223 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// appLockFile stores the PIN hash and idle timeout
+const appLockFile = "app_lock.json"
+
+// App lock timing
+const (
+	appLockCheckInterval = 5 * time.Second
+	maxPINFailures       = 5                // Wrong PINs before unlocking is blocked for a while
+	pinFailureBackoff    = 30 * time.Second // How long unlocking stays blocked
+)
+
+// errAppLocked is returned by protected bindings while the app is locked
+var errAppLocked = errors.New("the app is locked; enter the PIN to unlock it")
+
+// LockStatus describes the app lock
+type LockStatus struct {
+	Enabled     bool    `json:"enabled"` // A PIN is set
+	Locked      bool    `json:"locked"`
+	IdleMinutes float64 `json:"idleMinutes"` // Lock after this long without activity, 0 for manual locking only
+}
+
+// appLockConfig is the persisted app lock setup
+type appLockConfig struct {
+	Salt        []byte  `json:"salt,omitempty"`
+	PINHash     []byte  `json:"pinHash,omitempty"` // Argon2id of the PIN, empty while disabled
+	IdleMinutes float64 `json:"idleMinutes"`
+}
+
+// appLock rejects the protected bindings while the app is locked. Data
+// acquisition, recording and alarms keep running; only changes through the
+// UI are blocked.
+type appLock struct {
+	mu           sync.Mutex
+	config       appLockConfig
+	locked       bool
+	lastActivity time.Time
+	failures     int       // Consecutive wrong PINs
+	blockedUntil time.Time // No unlock attempts before this
+}
+
+// newAppLock loads the lock setup
+func newAppLock() *appLock {
+	l := &appLock{lastActivity: time.Now()}
+	if err := loadJSONFile(appLockFile, &l.config); err != nil {
+		securityLog.Errorf("Error loading app lock settings: %v", err)
+	}
+	return l
+}
+
+// check returns errAppLocked while locked and otherwise counts the call as activity
+func (l *appLock) check() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.locked {
+		return errAppLocked
+	}
+	l.lastActivity = time.Now()
+	return nil
+}
+
+// checkLocked returns errAppLocked while locked without counting the call
+// as activity, for bindings the UI polls
+func (l *appLock) checkLocked() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.locked {
+		return errAppLocked
+	}
+	return nil
+}
+
+// lock locks the app; the caller holds the lock and emits the event
+func (l *appLock) lock() bool {
+	if len(l.config.PINHash) == 0 || l.locked {
+		return false
+	}
+	l.locked = true
+	return true
+}
+
+// lockLoop locks the app once it has been idle for the configured time
+func (a *App) lockLoop() {
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-time.After(appLockCheckInterval):
+		}
+
+		a.lock.mu.Lock()
+		idle := a.lock.config.IdleMinutes
+		locked := idle > 0 && time.Since(a.lock.lastActivity) >= secondsToDuration(idle*60) && a.lock.lock()
+		a.lock.mu.Unlock()
+
+		if locked {
+			securityLog.Infof("App locked after inactivity")
+			a.audit.record(auditSystemUser, AuditSecurity, "App locked after inactivity")
+			a.emit(EventAppLock, true)
+		}
+	}
+}
+
+// GetLockStatus returns whether a PIN is set and the app is locked
+func (a *App) GetLockStatus() LockStatus {
+	a.lock.mu.Lock()
+	defer a.lock.mu.Unlock()
+
+	return LockStatus{
+		Enabled:     len(a.lock.config.PINHash) > 0,
+		Locked:      a.lock.locked,
+		IdleMinutes: a.lock.config.IdleMinutes,
+	}
+}
+
+// SetLockPIN sets the unlock PIN of 4 to 12 digits and the idle time after
+// which the app locks itself. An empty PIN disables the lock.
+func (a *App) SetLockPIN(pin string, idleMinutes float64) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+	if idleMinutes < 0 {
+		return fmt.Errorf("idle time must not be negative")
+	}
+
+	config := appLockConfig{IdleMinutes: idleMinutes}
+	if pin != "" {
+		if len(pin) < 4 || len(pin) > 12 {
+			return fmt.Errorf("PIN must have 4 to 12 digits")
+		}
+		for _, c := range pin {
+			if c < '0' || c > '9' {
+				return fmt.Errorf("PIN must have 4 to 12 digits")
+			}
+		}
+		config.Salt = make([]byte, 16)
+		if _, err := rand.Read(config.Salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %v", err)
+		}
+		config.PINHash = hashPassword(pin, config.Salt)
+	}
+
+	a.lock.mu.Lock()
+	defer a.lock.mu.Unlock()
+
+	a.lock.config = config
+	if pin == "" {
+		securityLog.Infof("App lock disabled")
+		a.audit.record("", AuditSecurity, "App lock disabled")
+	} else {
+		securityLog.Infof("App lock PIN set, locking after %g idle minutes", idleMinutes)
+		a.audit.record("", AuditSecurity, fmt.Sprintf("App lock PIN set, locking after %g idle minutes", idleMinutes))
+	}
+	return saveJSONFile(appLockFile, config)
+}
+
+// LockApp locks the app at once, for staff leaving the bedside
+func (a *App) LockApp() error {
+	a.lock.mu.Lock()
+	if len(a.lock.config.PINHash) == 0 {
+		a.lock.mu.Unlock()
+		return fmt.Errorf("set a PIN before locking the app")
+	}
+	locked := a.lock.lock()
+	a.lock.mu.Unlock()
+
+	if locked {
+		securityLog.Infof("App locked")
+		a.audit.record("", AuditSecurity, "App locked")
+		a.emit(EventAppLock, true)
+	}
+	return nil
+}
+
+// UnlockApp unlocks the app with the PIN. After repeated wrong PINs,
+// unlocking is blocked for a short time.
+func (a *App) UnlockApp(pin string) error {
+	a.lock.mu.Lock()
+	if !a.lock.locked {
+		a.lock.mu.Unlock()
+		return nil
+	}
+	if wait := time.Until(a.lock.blockedUntil); wait > 0 {
+		a.lock.mu.Unlock()
+		return fmt.Errorf("too many wrong PINs; try again in %.0f seconds", wait.Seconds())
+	}
+	if subtle.ConstantTimeCompare(hashPassword(pin, a.lock.config.Salt), a.lock.config.PINHash) != 1 {
+		a.lock.failures++
+		if a.lock.failures >= maxPINFailures {
+			a.lock.failures = 0
+			a.lock.blockedUntil = time.Now().Add(pinFailureBackoff)
+		}
+		a.lock.mu.Unlock()
+		a.audit.record("", AuditSecurity, "Wrong app lock PIN")
+		return fmt.Errorf("wrong PIN")
+	}
+	a.lock.locked = false
+	a.lock.failures = 0
+	a.lock.lastActivity = time.Now()
+	a.lock.mu.Unlock()
+
+	securityLog.Infof("App unlocked")
+	a.audit.record("", AuditSecurity, "App unlocked")
+	a.emit(EventAppLock, false)
+	return nil
+}
+
+// ReportActivity tells the backend the user is at the keyboard, postponing
+// the idle lock. The frontend calls it on input, throttled.
+func (a *App) ReportActivity() {
+	a.lock.check()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ArtifactConfig configures motion/artifact detection on a channel. Limits set to 0 are not checked.
+type ArtifactConfig struct {
+	Channel     string  `json:"channel"`
+	MaxJump     float64 `json:"maxJump"`     // Largest plausible change between consecutive readings
+	MaxSlope    float64 `json:"maxSlope"`    // Largest physiologically possible rate of change, in units/s
+	HoldSeconds float64 `json:"holdSeconds"` // How long readings stay marked after an artifact
+}
+
+// artifactDetector tracks one channel for sudden jumps and impossible slopes
+type artifactDetector struct {
+	config    ArtifactConfig
+	last      *timedValue
+	holdUntil time.Time
+	inEpisode bool
+}
+
+// check returns whether the reading is part of an artifact
+func (d *artifactDetector) check(at time.Time, v float64) bool {
+	last := d.last
+	d.last = &timedValue{t: at, v: v}
+
+	if last != nil {
+		jump := math.Abs(v - last.v)
+		dt := at.Sub(last.t).Seconds()
+		if (d.config.MaxJump > 0 && jump > d.config.MaxJump) ||
+			(d.config.MaxSlope > 0 && dt > 0 && jump/dt > d.config.MaxSlope) {
+			d.holdUntil = at.Add(secondsToDuration(d.config.HoldSeconds))
+			if !d.inEpisode {
+				processingLog.Infof("Artifact detected on %s: jump of %.2f in %.3f s", d.config.Channel, jump, dt)
+			}
+			d.inEpisode = true
+			return true
+		}
+	}
+
+	if at.Before(d.holdUntil) {
+		return true
+	}
+	d.inEpisode = false
+	return false
+}
+
+// artifactProcessor marks artifact readings so later stages can exclude them.
+// It runs right after calibration, on the calibrated readings.
+type artifactProcessor struct {
+	mu        sync.Mutex
+	detectors map[string]*artifactDetector
+}
+
+// newArtifactProcessor creates the artifact stage without configured channels
+func newArtifactProcessor() *artifactProcessor {
+	return &artifactProcessor{
+		detectors: make(map[string]*artifactDetector),
+	}
+}
+
+func (p *artifactProcessor) process(sample *SensorData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for channel, detector := range p.detectors {
+		value, ok := sample.channelValue(channel)
+		if ok && detector.check(sample.Timestamp, value) {
+			sample.Artifacts = append(sample.Artifacts, channel)
+		}
+	}
+}
+
+// SetArtifactDetection enables artifact marking on a channel
+func (a *App) SetArtifactDetection(config ArtifactConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if config.Channel == "" {
+		return fmt.Errorf("channel is required")
+	}
+	if config.MaxJump < 0 || config.MaxSlope < 0 || config.HoldSeconds < 0 {
+		return fmt.Errorf("artifact limits must not be negative")
+	}
+	if config.MaxJump == 0 && config.MaxSlope == 0 {
+		return fmt.Errorf("a jump or slope limit is required")
+	}
+
+	a.artifacts.mu.Lock()
+	defer a.artifacts.mu.Unlock()
+
+	a.artifacts.detectors[config.Channel] = &artifactDetector{config: config}
+
+	processingLog.Infof("Artifact detection enabled on channel %s", config.Channel)
+	return nil
+}
+
+// ClearArtifactDetection stops artifact marking on a channel
+func (a *App) ClearArtifactDetection(channel string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.artifacts.mu.Lock()
+	defer a.artifacts.mu.Unlock()
+
+	delete(a.artifacts.detectors, channel)
+	return nil
+}
+
+// GetArtifactDetection returns the channels with artifact detection sorted by channel
+func (a *App) GetArtifactDetection() []ArtifactConfig {
+	a.artifacts.mu.Lock()
+	defer a.artifacts.mu.Unlock()
+
+	result := make([]ArtifactConfig, 0, len(a.artifacts.detectors))
+	for _, detector := range a.artifacts.detectors {
+		result = append(result, detector.config)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Channel < result[j].Channel })
+	return result
+}
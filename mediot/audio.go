@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// audioFile stores the alarm sound settings
+const audioFile = "audio.json"
+
+// Alarm tone synthesis
+const (
+	toneSampleRate = 22050
+	tonePulse      = 150 * time.Millisecond // Length of one pulse of a burst
+	toneGap        = 100 * time.Millisecond // Silence between pulses
+	toneBurstGap   = 350 * time.Millisecond // Silence between the bursts of a critical tone
+	toneRamp       = 10 * time.Millisecond  // Fade in/out of each pulse to avoid clicks
+	audioTick      = 250 * time.Millisecond // How often the sound loop checks the active alarms
+)
+
+// AudioConfig configures the audible alarms
+type AudioConfig struct {
+	Enabled               bool    `json:"enabled"`
+	Volume                float64 `json:"volume"`                // Master volume, 0..1
+	WarningRepeatSeconds  float64 `json:"warningRepeatSeconds"`  // Time between two warning tones
+	CriticalRepeatSeconds float64 `json:"criticalRepeatSeconds"` // Time between two critical tones
+}
+
+// defaultAudioConfig repeats the critical tone every 5 s and the warning tone every 15 s
+func defaultAudioConfig() AudioConfig {
+	return AudioConfig{
+		Enabled:               true,
+		Volume:                0.8,
+		WarningRepeatSeconds:  15,
+		CriticalRepeatSeconds: 5,
+	}
+}
+
+// alarmTone describes the pulse pattern of a severity: bursts of pulses at a pitch
+type alarmTone struct {
+	frequency float64
+	bursts    []int // Pulses per burst
+}
+
+// alarmTones are loosely modelled on IEC 60601-1-8: a three-pulse medium
+// priority tone and a higher 3+2 pulse high priority tone
+var alarmTones = map[string]alarmTone{
+	SeverityWarning:  {frequency: 660, bursts: []int{3}},
+	SeverityCritical: {frequency: 880, bursts: []int{3, 2}},
+}
+
+// alarmSound plays the tone of the most severe unacknowledged alarm
+type alarmSound struct {
+	mu       sync.Mutex
+	config   AudioConfig
+	lastPlay time.Time
+	files    map[string]string // Rendered tone files by severity and volume
+}
+
+// newAlarmSound creates the sound player and loads the persisted settings
+func newAlarmSound() *alarmSound {
+	s := &alarmSound{
+		config: defaultAudioConfig(),
+		files:  make(map[string]string),
+	}
+	if err := loadJSONFile(audioFile, &s.config); err != nil {
+		alarmsLog.Errorf("Error loading audio settings: %v", err)
+	}
+	return s
+}
+
+// renderTone synthesizes the tone of a severity as a 16-bit mono WAV file.
+// The master volume is applied to the samples, so it works the same with
+// every platform player.
+func renderTone(tone alarmTone, volume float64) []byte {
+	samplesOf := func(d time.Duration) int { return int(d.Seconds() * toneSampleRate) }
+	pulse, ramp := samplesOf(tonePulse), samplesOf(toneRamp)
+
+	var pcm []int16
+	silence := func(d time.Duration) { pcm = append(pcm, make([]int16, samplesOf(d))...) }
+	for b, pulses := range tone.bursts {
+		if b > 0 {
+			silence(toneBurstGap)
+		}
+		for p := 0; p < pulses; p++ {
+			if p > 0 {
+				silence(toneGap)
+			}
+			for i := 0; i < pulse; i++ {
+				envelope := math.Min(1, math.Min(float64(i), float64(pulse-i))/float64(ramp))
+				v := math.Sin(2*math.Pi*tone.frequency*float64(i)/toneSampleRate) * envelope * volume
+				pcm = append(pcm, int16(v*math.MaxInt16))
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	dataSize := uint32(len(pcm) * 2)
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, 36+dataSize)
+	buf.WriteString("WAVEfmt ")
+	// PCM format chunk: 1 channel, 16 bits per sample
+	for _, field := range []interface{}{
+		uint32(16), uint16(1), uint16(1), uint32(toneSampleRate), uint32(toneSampleRate * 2), uint16(2), uint16(16),
+	} {
+		binary.Write(&buf, binary.LittleEndian, field)
+	}
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, dataSize)
+	binary.Write(&buf, binary.LittleEndian, pcm)
+	return buf.Bytes()
+}
+
+// toneFile returns the path of the rendered tone, writing it on first use
+func (s *alarmSound) toneFile(severity string, volume float64) (string, error) {
+	key := fmt.Sprintf("%s-%.2f", severity, volume)
+	if path, ok := s.files[key]; ok {
+		return path, nil
+	}
+
+	path := filepath.Join(os.TempDir(), fmt.Sprintf("mediot-%s.wav", key))
+	if err := os.WriteFile(path, renderTone(alarmTones[severity], volume), 0600); err != nil {
+		return "", fmt.Errorf("failed to write alarm tone: %v", err)
+	}
+	s.files[key] = path
+	return path, nil
+}
+
+// play renders and plays the tone of a severity, blocking until it ends
+func (s *alarmSound) play(severity string) error {
+	s.mu.Lock()
+	path, err := s.toneFile(severity, s.config.Volume)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return playSoundFile(path)
+}
+
+// alarmSoundLoop repeats the tone of the most severe unacknowledged alarm
+// until it is acknowledged or cleared
+func (a *App) alarmSoundLoop() {
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-time.After(audioTick):
+		}
+
+		severity := a.alarms.loudestUnacknowledged()
+		if severity == SeverityNormal {
+			continue
+		}
+
+		a.sound.mu.Lock()
+		config := a.sound.config
+		repeat := config.WarningRepeatSeconds
+		if severity == SeverityCritical {
+			repeat = config.CriticalRepeatSeconds
+		}
+		due := time.Since(a.sound.lastPlay) >= secondsToDuration(repeat)
+		if due {
+			a.sound.lastPlay = time.Now()
+		}
+		a.sound.mu.Unlock()
+
+		if !config.Enabled || config.Volume == 0 || !due {
+			continue
+		}
+		if err := a.sound.play(severity); err != nil {
+			alarmsLog.Errorf("Error playing alarm tone: %v", err)
+		}
+	}
+}
+
+// GetAudioConfig returns the audible alarm settings
+func (a *App) GetAudioConfig() AudioConfig {
+	a.sound.mu.Lock()
+	defer a.sound.mu.Unlock()
+
+	return a.sound.config
+}
+
+// SetAudioConfig replaces and persists the audible alarm settings
+func (a *App) SetAudioConfig(config AudioConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if config.Volume < 0 || config.Volume > 1 {
+		return fmt.Errorf("volume must be between 0 and 1, got %.2f", config.Volume)
+	}
+	if config.WarningRepeatSeconds <= 0 || config.CriticalRepeatSeconds <= 0 {
+		return fmt.Errorf("repeat intervals must be positive")
+	}
+
+	a.sound.mu.Lock()
+	defer a.sound.mu.Unlock()
+
+	a.sound.config = config
+	return saveJSONFile(audioFile, config)
+}
+
+// TestAlarmSound plays the tone of a severity once at the configured volume
+func (a *App) TestAlarmSound(severity string) error {
+	if _, ok := alarmTones[severity]; !ok {
+		return fmt.Errorf("no alarm tone for severity '%s'", severity)
+	}
+	go func() {
+		if err := a.sound.play(severity); err != nil {
+			alarmsLog.Errorf("Error playing test tone: %v", err)
+		}
+	}()
+	return nil
+}
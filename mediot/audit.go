@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Audit log persistence. Entries are appended as JSON lines and never
+// rewritten; only the most recent ones are kept in memory for queries.
+const (
+	auditFile      = "audit.jsonl"
+	maxAuditMemory = 10000
+)
+
+// Audited actions
+const (
+	AuditConnect          = "connect"
+	AuditDisconnect       = "disconnect"
+	AuditCalibration      = "calibration"
+	AuditAlarmAcknowledge = "alarm-acknowledge"
+	AuditAlarmSilence     = "alarm-silence"
+	AuditAlarmLimits      = "alarm-limits"
+	AuditDeviceSettings   = "device-settings"
+	AuditFirmware         = "firmware"
+	AuditSecurity         = "security"
+	AuditExport           = "export"
+	AuditErasure          = "erasure"
+	AuditUpdate           = "update"
+	AuditRecording        = "recording"
+	AuditPlugin           = "plugin"
+	AuditProfile          = "profile"
+	AuditTelemetry        = "telemetry"
+	AuditConfig           = "config"
+	AuditAnnotation       = "annotation"
+	AuditBackup           = "backup"
+)
+
+// auditSystemUser is the identity of actions the app takes on its own
+const auditSystemUser = "system"
+
+// AuditEntry is one action in the audit log
+type AuditEntry struct {
+	ID      int64     `json:"id"`
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	Action  string    `json:"action"`
+	Detail  string    `json:"detail"`
+	Patient string    `json:"patient,omitempty"` // Patient the entry concerns, the key of erasure requests
+}
+
+// AuditQuery selects audit entries; empty fields match everything
+type AuditQuery struct {
+	Action string    `json:"action,omitempty"`
+	User   string    `json:"user,omitempty"`
+	Since  time.Time `json:"since"`
+	Until  time.Time `json:"until"`
+	Limit  int       `json:"limit"` // Most recent entries to return, 0 for all kept in memory
+}
+
+// auditLog appends user and system actions to the audit file
+type auditLog struct {
+	mu       sync.Mutex
+	entries  []AuditEntry // Most recent entries, oldest first
+	nextID   int64
+	operator string // OS login of the person at the computer
+	user     string // App user logged in, attributed instead of the operator
+}
+
+// newAuditLog loads the most recent audit entries
+func newAuditLog() *auditLog {
+	l := &auditLog{entries: make([]AuditEntry, 0), nextID: 1, operator: auditSystemUser}
+	if u, err := user.Current(); err == nil {
+		l.operator = u.Username
+	}
+
+	dir, err := appDataDir()
+	if err != nil {
+		securityLog.Errorf("Error loading audit log: %v", err)
+		return l
+	}
+	file, err := os.Open(filepath.Join(dir, auditFile))
+	if os.IsNotExist(err) {
+		return l
+	}
+	if err != nil {
+		securityLog.Errorf("Error loading audit log: %v", err)
+		return l
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			securityLog.Warnf("Skipping malformed audit entry: %v", err)
+			continue
+		}
+		l.entries = append(l.entries, entry)
+		if len(l.entries) > maxAuditMemory {
+			l.entries = l.entries[1:]
+		}
+		l.nextID = entry.ID + 1
+	}
+	return l
+}
+
+// setUser attributes later entries to an app user, or to the operator again when empty
+func (l *auditLog) setUser(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.user = name
+}
+
+// actor returns who entries without a user are attributed to
+func (l *auditLog) actor() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.user != "" {
+		return l.user
+	}
+	return l.operator
+}
+
+// record appends an action to the audit log. An empty user is the app user
+// logged in, or else the operator logged in to the computer.
+func (l *auditLog) record(user, action, detail string) {
+	l.recordPatient(user, action, "", detail)
+}
+
+// recordPatient appends an action concerning a patient, so an erasure
+// request for the patient redacts it
+func (l *auditLog) recordPatient(user, action, patient, detail string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if user == "" {
+		user = l.user
+	}
+	if user == "" {
+		user = l.operator
+	}
+	entry := AuditEntry{ID: l.nextID, Time: time.Now(), User: user, Action: action, Detail: detail, Patient: patient}
+	l.nextID++
+	l.entries = append(l.entries, entry)
+	if len(l.entries) > maxAuditMemory {
+		l.entries = append(make([]AuditEntry, 0, maxAuditMemory), l.entries[len(l.entries)-maxAuditMemory:]...)
+	}
+
+	if err := l.append(entry); err != nil {
+		securityLog.Errorf("Error writing audit log: %v", err)
+	}
+}
+
+// append writes one entry to the end of the audit file; the caller holds the lock
+func (l *auditLog) append(entry AuditEntry) error {
+	dir, err := appDataDir()
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, auditFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// redactedDetail replaces the detail of entries that referenced erased data
+const redactedDetail = "[erased]"
+
+// redact blanks the detail and patient of every entry recorded for one of
+// the patients, in memory and on disk, and returns how many entries were
+// changed. Entries match on their patient field exactly, never on their
+// text, so a short identifier cannot take unrelated entries with it. The
+// file is rewritten; this is the only exception to append-only, for erasure
+// requests.
+func (l *auditLog) redact(patients []string) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	concerns := func(entry AuditEntry) bool {
+		for _, patient := range patients {
+			if patient != "" && entry.Patient == patient {
+				return true
+			}
+		}
+		return false
+	}
+	for i := range l.entries {
+		if concerns(l.entries[i]) {
+			l.entries[i].Detail, l.entries[i].Patient = redactedDetail, ""
+		}
+	}
+
+	dir, err := appDataDir()
+	if err != nil {
+		return 0, err
+	}
+	path := filepath.Join(dir, auditFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	var out []byte
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		var entry AuditEntry
+		if json.Unmarshal([]byte(line), &entry) == nil && concerns(entry) {
+			entry.Detail, entry.Patient = redactedDetail, ""
+			redacted, err := json.Marshal(entry)
+			if err != nil {
+				return 0, err
+			}
+			line = string(redacted) + "\n"
+			count++
+		}
+		out = append(out, line...)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0600); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	return count, nil
+}
+
+// matches reports whether an entry is selected by the query
+func (q AuditQuery) matches(entry AuditEntry) bool {
+	return (q.Action == "" || entry.Action == q.Action) &&
+		(q.User == "" || strings.EqualFold(entry.User, q.User)) &&
+		(q.Since.IsZero() || !entry.Time.Before(q.Since)) &&
+		(q.Until.IsZero() || entry.Time.Before(q.Until))
+}
+
+// GetAuditLog returns the audit entries matching the query, oldest first.
+// Admin only.
+func (a *App) GetAuditLog(query AuditQuery) ([]AuditEntry, error) {
+	if err := a.requireReadRole(RoleAdmin); err != nil {
+		return nil, err
+	}
+	return a.auditLog(query), nil
+}
+
+// auditLog is GetAuditLog without the role check
+func (a *App) auditLog(query AuditQuery) []AuditEntry {
+	a.audit.mu.Lock()
+	defer a.audit.mu.Unlock()
+
+	result := make([]AuditEntry, 0)
+	for _, entry := range a.audit.entries {
+		if query.matches(entry) {
+			result = append(result, entry)
+		}
+	}
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[len(result)-query.Limit:]
+	}
+	return result
+}
+
+// ExportAuditLog writes the audit entries matching the query to a CSV file
+// for compliance reviews. The export itself is audited.
+func (a *App) ExportAuditLog(path string, query AuditQuery) (int, error) {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return 0, err
+	}
+
+	entries := a.auditLog(query)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export: %v", err)
+	}
+	w := csv.NewWriter(file)
+	w.Write([]string{"id", "time", "user", "action", "detail", "patient"})
+	for _, entry := range entries {
+		w.Write([]string{
+			strconv.FormatInt(entry.ID, 10),
+			entry.Time.Format(time.RFC3339Nano),
+			entry.User,
+			entry.Action,
+			entry.Detail,
+			entry.Patient,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		file.Close()
+		return 0, fmt.Errorf("failed to write export: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write export: %v", err)
+	}
+
+	a.audit.record("", AuditExport, fmt.Sprintf("Audit log exported to %s (%d entries)", path, len(entries)))
+	return len(entries), nil
+}
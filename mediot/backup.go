@@ -0,0 +1,326 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Backup archives
+const (
+	backupManifestName = "backup.json"
+	backupFormat       = 2       // Version of the archive layout; newer archives are refused
+	maxBackupFileSize  = 1 << 30 // Largest file restored from an archive
+)
+
+// BackupManifest describes a backup archive
+type BackupManifest struct {
+	Format     int       `json:"format"`
+	AppVersion string    `json:"appVersion"`
+	CreatedAt  time.Time `json:"createdAt"`
+	Profiles   []string  `json:"profiles"`
+	Sessions   bool      `json:"sessions"`  // The patient data files are included
+	Encrypted  bool      `json:"encrypted"` // The patient data is encrypted; restoring it needs its passphrase
+	Files      []string  `json:"files"`     // Paths in the data directory, with forward slashes
+	Secrets    []string  `json:"secrets"`   // Keyring entries left out, to be entered again after restoring
+}
+
+// backupConfigFiles returns the shared configuration files every backup
+// holds, next to the files of each profile
+func backupConfigFiles() []string {
+	var names []string
+	for _, name := range dataFileNames(dataConfig) {
+		if !profileFiles[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// backupFileAllowed reports whether a file of an archive may be restored:
+// only the files a backup holds, so an archive cannot write elsewhere
+func backupFileAllowed(name string) bool {
+	if kind, ok := dataFiles[name]; ok && (kind == dataConfig || kind == dataPatient || name == encryptionFile) {
+		return true
+	}
+	parts := strings.Split(name, "/")
+	return len(parts) == 3 && parts[0] == profilesDirName && profileNamePattern.MatchString(parts[1]) &&
+		parts[1] != defaultProfile && profileFiles[parts[2]]
+}
+
+// profileFileNames returns the files each profile has its own copy of
+func profileFileNames() []string {
+	names := make([]string, 0, len(profileFiles))
+	for name := range profileFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CreateBackup writes the files of every profile and the shared
+// configuration files of dataFiles to a zip archive at path, with the
+// patient data when sessions is set. It is meant for moving the app to
+// another computer. Secrets kept in the OS keyring stay on this computer;
+// the manifest lists them so they can be entered again after restoring.
+func (a *App) CreateBackup(path string, sessions bool) (BackupManifest, error) {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return BackupManifest{}, err
+	}
+
+	dir, err := appDataDir()
+	if err != nil {
+		return BackupManifest{}, err
+	}
+	profileList, err := a.GetProfiles()
+	if err != nil {
+		return BackupManifest{}, err
+	}
+
+	manifest := BackupManifest{
+		Format:     backupFormat,
+		AppVersion: appVersion,
+		CreatedAt:  time.Now(),
+		Profiles:   make([]string, 0, len(profileList)),
+		Sessions:   sessions,
+		Files:      make([]string, 0),
+		Secrets:    a.GetSecretStorage().InKeyring,
+	}
+	var paths []string
+	for _, profile := range profileList {
+		manifest.Profiles = append(manifest.Profiles, profile.Name)
+		for _, file := range profileFileNames() {
+			paths = append(paths, filepath.Join(profile.Dir, file))
+		}
+	}
+	for _, file := range backupConfigFiles() {
+		paths = append(paths, filepath.Join(dir, file))
+	}
+	if sessions {
+		for _, file := range dataFileNames(dataPatient) {
+			paths = append(paths, filepath.Join(dir, file))
+		}
+		if manifest.Encrypted = a.GetEncryptionStatus().Enabled; manifest.Encrypted {
+			paths = append(paths, filepath.Join(dir, encryptionFile))
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return BackupManifest{}, fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	w := zip.NewWriter(file)
+	for _, source := range paths {
+		data, err := os.ReadFile(source)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			file.Close()
+			os.Remove(path)
+			return BackupManifest{}, fmt.Errorf("failed to read %s: %v", source, err)
+		}
+		rel, err := filepath.Rel(dir, source)
+		if err != nil {
+			file.Close()
+			os.Remove(path)
+			return BackupManifest{}, err
+		}
+		name := filepath.ToSlash(rel)
+		if err := addBundleFile(w, name, data); err != nil {
+			file.Close()
+			os.Remove(path)
+			return BackupManifest{}, err
+		}
+		manifest.Files = append(manifest.Files, name)
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = addBundleFile(w, backupManifestName, data)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return BackupManifest{}, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+
+	appLog.Infof("Backup of %d files written to %s", len(manifest.Files), path)
+	a.audit.record("", AuditBackup, fmt.Sprintf("Backup written to %s (sessions: %t)", path, sessions))
+	return manifest, nil
+}
+
+// readBackup reads and checks every file of a backup archive
+func readBackup(archive string) (BackupManifest, map[string][]byte, error) {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return BackupManifest{}, nil, fmt.Errorf("failed to open %s: %v", archive, err)
+	}
+	defer r.Close()
+
+	var manifest BackupManifest
+	files := make(map[string][]byte)
+	for _, entry := range r.File {
+		if entry.UncompressedSize64 > maxBackupFileSize {
+			return BackupManifest{}, nil, fmt.Errorf("%s is too large", entry.Name)
+		}
+		if entry.Name != backupManifestName && !backupFileAllowed(path.Clean(entry.Name)) {
+			return BackupManifest{}, nil, fmt.Errorf("unexpected file '%s' in backup", entry.Name)
+		}
+		reader, err := entry.Open()
+		if err != nil {
+			return BackupManifest{}, nil, fmt.Errorf("failed to read %s: %v", entry.Name, err)
+		}
+		data, err := io.ReadAll(io.LimitReader(reader, maxBackupFileSize))
+		reader.Close()
+		if err != nil {
+			return BackupManifest{}, nil, fmt.Errorf("failed to read %s: %v", entry.Name, err)
+		}
+		if entry.Name == backupManifestName {
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				return BackupManifest{}, nil, fmt.Errorf("invalid backup manifest: %v", err)
+			}
+			continue
+		}
+		files[path.Clean(entry.Name)] = data
+	}
+
+	if manifest.Format == 0 {
+		return BackupManifest{}, nil, fmt.Errorf("%s is not a mediot backup", archive)
+	}
+	if manifest.Format > backupFormat {
+		return BackupManifest{}, nil, fmt.Errorf("backup was made by a newer version of the app (%s); update before restoring it", manifest.AppVersion)
+	}
+	for name, data := range files {
+		if isEncrypted(data) {
+			continue
+		}
+		if !json.Valid(data) {
+			return BackupManifest{}, nil, fmt.Errorf("%s in backup is damaged", name)
+		}
+		if path.Base(name) != settingsFile {
+			continue
+		}
+		var stored struct {
+			Version int `json:"version"`
+		}
+		if err := json.Unmarshal(data, &stored); err == nil && stored.Version > settingsVersion {
+			return BackupManifest{}, nil, fmt.Errorf("backup was made by a newer version of the app (%s); update before restoring it", manifest.AppVersion)
+		}
+	}
+	return manifest, files, nil
+}
+
+// writeDataFile replaces a file of the data directory atomically
+func writeDataFile(dir, name string, data []byte) error {
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return fmt.Errorf("failed to create folder of %s: %v", name, err)
+	}
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %v", name, err)
+	}
+	return nil
+}
+
+// RestoreBackup replaces the configuration, and the sessions if the
+// archive holds them, with those of a backup made by CreateBackup, and
+// applies the profiles, calibrations and alarm rules. The other settings,
+// such as accounts and notifiers, take effect at the next start. Profiles
+// missing from the backup are kept. Encrypted sessions are locked
+// afterwards until the passphrase they were encrypted with is entered.
+func (a *App) RestoreBackup(path string) (BackupManifest, error) {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return BackupManifest{}, err
+	}
+	if a.conn.connected() {
+		return BackupManifest{}, fmt.Errorf("disconnect the device before restoring a backup")
+	}
+	if a.recorder.status().Active {
+		return BackupManifest{}, fmt.Errorf("stop recording before restoring a backup")
+	}
+
+	manifest, files, err := readBackup(path)
+	if err != nil {
+		return BackupManifest{}, err
+	}
+	_, restoreSessions := files[sessionsFile]
+	if restoreSessions && atRest.locked() {
+		return BackupManifest{}, fmt.Errorf("unlock the patient data before restoring sessions")
+	}
+	dir, err := appDataDir()
+	if err != nil {
+		return BackupManifest{}, err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeDataFile(dir, name, files[name]); err != nil {
+			return BackupManifest{}, err
+		}
+	}
+
+	profiles.load()
+	a.settings.reload()
+	a.devices.reload()
+	a.limits.reload()
+	a.calibration.reload()
+	if _, err := a.reloadHealthRulesFile(); err != nil {
+		appLog.Errorf("Error loading restored health alarm rules: %v", err)
+	}
+	if _, ok := files[encryptionFile]; ok {
+		var config encryptionConfig
+		if err := json.Unmarshal(files[encryptionFile], &config); err != nil {
+			securityLog.Errorf("Error loading restored encryption settings: %v", err)
+		}
+		if config.Keyring {
+			// The key in the keyring of the old computer did not come along
+			config.Keyring = false
+			if err := saveJSONFile(encryptionFile, config); err != nil {
+				securityLog.Errorf("Error saving restored encryption settings: %v", err)
+			}
+		}
+		atRest.mu.Lock()
+		atRest.config, atRest.key = config, nil
+		atRest.mu.Unlock()
+	}
+	if restoreSessions && !atRest.locked() {
+		a.sessions.reload()
+		a.alarmLog.reload()
+		a.pseudonyms.reload()
+		a.alarms.mu.Lock()
+		a.alarms.nextID = a.alarmLog.lastID() + 1
+		a.alarms.mu.Unlock()
+		// Plain sessions are encrypted if this computer encrypts its own
+		a.saveProtected()
+	}
+
+	appLog.Infof("Backup from %s restored (%d files); restart the app to apply every setting", path, len(files))
+	if len(manifest.Secrets) > 0 {
+		appLog.Warnf("Secrets to enter again after restoring: %s", strings.Join(manifest.Secrets, ", "))
+	}
+	a.audit.record("", AuditBackup, fmt.Sprintf("Backup from %s of %s restored (sessions: %t)", path, manifest.CreatedAt.Format(time.RFC3339), restoreSessions))
+	a.emit(EventProfile, profiles.current())
+	a.emit(EventSettings, a.GetSettings())
+	return manifest, nil
+}
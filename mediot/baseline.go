@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Baseline correction modes
+const (
+	BaselineHighPass = "highpass" // First-order high-pass filter
+	BaselineMedian   = "median"   // Subtract the rolling median
+)
+
+// baselineChannel names the derived channel a channel's corrected value is
+// published as
+func baselineChannel(channel string) string {
+	return channel + "_detrended"
+}
+
+// BaselineConfig configures the drift removal of a waveform channel
+type BaselineConfig struct {
+	Channel       string  `json:"channel"`
+	Mode          string  `json:"mode"`
+	CutoffHz      float64 `json:"cutoffHz"`      // High-pass corner frequency
+	WindowSeconds float64 `json:"windowSeconds"` // Median window length
+}
+
+// baselineFilter removes the slow drift of one channel
+type baselineFilter struct {
+	config    BaselineConfig
+	median    *statsTracker // Rolling window for the median mode
+	lastInput float64
+	output    float64
+	lastTime  time.Time
+}
+
+// apply returns the baseline-corrected value of a reading
+func (f *baselineFilter) apply(at time.Time, value float64) float64 {
+	if f.config.Mode == BaselineMedian {
+		f.median.add(at, value)
+		return value - f.median.sorted.quantile(0.5)
+	}
+
+	if f.lastTime.IsZero() {
+		f.lastTime, f.lastInput, f.output = at, value, 0
+		return 0
+	}
+
+	// y[n] = alpha * (y[n-1] + x[n] - x[n-1]) with alpha from the actual sample spacing
+	dt := at.Sub(f.lastTime).Seconds()
+	rc := 1 / (2 * math.Pi * f.config.CutoffHz)
+	alpha := rc / (rc + dt)
+	f.output = alpha * (f.output + value - f.lastInput)
+	f.lastTime, f.lastInput = at, value
+	return f.output
+}
+
+// baselineProcessor publishes the drift-free value of each configured channel
+// as a derived channel, leaving the reading itself to quality checks, alarms
+// and the recorder. It runs after the SpO2 stage, which needs the DC component
+// of the PPG channels.
+type baselineProcessor struct {
+	mu      sync.Mutex
+	filters map[string]*baselineFilter
+}
+
+// newBaselineProcessor creates the baseline stage without configured channels
+func newBaselineProcessor() *baselineProcessor {
+	return &baselineProcessor{
+		filters: make(map[string]*baselineFilter),
+	}
+}
+
+func (p *baselineProcessor) process(sample *SensorData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for channel, filter := range p.filters {
+		if value, ok := sample.channelValue(channel); ok {
+			sample.setDerived(baselineChannel(channel), filter.apply(sample.Timestamp, value))
+		}
+	}
+}
+
+// SetBaselineCorrection enables drift removal on a waveform channel, published
+// as the channel name followed by _detrended, e.g. value1_detrended
+func (a *App) SetBaselineCorrection(config BaselineConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if config.Channel == "" {
+		return fmt.Errorf("channel is required")
+	}
+
+	filter := &baselineFilter{config: config}
+	switch config.Mode {
+	case BaselineHighPass:
+		if config.CutoffHz <= 0 {
+			return fmt.Errorf("cutoff must be positive, got %.3f Hz", config.CutoffHz)
+		}
+	case BaselineMedian:
+		if config.WindowSeconds <= 0 {
+			return fmt.Errorf("window must be positive, got %.2f s", config.WindowSeconds)
+		}
+		filter.median = newStatsTracker(secondsToDuration(config.WindowSeconds), nil)
+	default:
+		return fmt.Errorf("unknown baseline mode '%s'", config.Mode)
+	}
+
+	a.baseline.mu.Lock()
+	defer a.baseline.mu.Unlock()
+
+	a.baseline.filters[config.Channel] = filter
+
+	processingLog.Infof("Baseline correction (%s) enabled on channel %s as %s", config.Mode, config.Channel, baselineChannel(config.Channel))
+	return nil
+}
+
+// ClearBaselineCorrection passes a channel through uncorrected again
+func (a *App) ClearBaselineCorrection(channel string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.baseline.mu.Lock()
+	defer a.baseline.mu.Unlock()
+
+	delete(a.baseline.filters, channel)
+	return nil
+}
+
+// GetBaselineCorrections returns the channels with drift removal sorted by channel
+func (a *App) GetBaselineCorrections() []BaselineConfig {
+	a.baseline.mu.Lock()
+	defer a.baseline.mu.Unlock()
+
+	result := make([]BaselineConfig, 0, len(a.baseline.filters))
+	for _, filter := range a.baseline.filters {
+		result = append(result, filter.config)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Channel < result[j].Channel })
+	return result
+}
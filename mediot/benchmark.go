@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Benchmark timing
+const (
+	benchmarkTick      = 10 * time.Millisecond // Lines are fed in batches at this interval
+	benchmarkDrainTick = 50 * time.Millisecond // The buffer is read as the frontend polls it
+	benchmarkReadSize  = 100                   // Bytes per chunk, as the serial reader reads them
+	benchmarkLines     = 5000                  // Distinct simulated lines, fed over and over
+	benchmarkSustained = 0.98                  // Share of the rate a step must reach to be sustained
+	benchmarkMaxLag    = time.Second           // A step falling further behind is not sustained
+	benchmarkDeviceID  = "benchmark"
+)
+
+// BenchmarkConfig sets the rates RunBenchmark tries. Zero fields take the
+// defaults.
+type BenchmarkConfig struct {
+	StartRateHz float64 `json:"startRateHz"` // First rate tried, 250 Hz by default
+	MaxRateHz   float64 `json:"maxRateHz"`   // Highest rate tried, 64000 Hz by default; rates double up to it
+	StepSeconds float64 `json:"stepSeconds"` // Time spent at each rate, 2 s by default
+}
+
+// BenchmarkStep is the outcome of one rate of a benchmark
+type BenchmarkStep struct {
+	RateHz              float64 `json:"rateHz"`
+	AchievedHz          float64 `json:"achievedHz"` // Samples parsed, processed and read per second
+	Sustained           bool    `json:"sustained"`
+	CPUPercent          float64 `json:"cpuPercent"` // CPU time of the whole app over the step, 100 per busy core
+	AllocBytesPerSecond float64 `json:"allocBytesPerSecond"`
+	AllocsPerSecond     float64 `json:"allocsPerSecond"`
+	AllocBytesPerSample float64 `json:"allocBytesPerSample"`
+}
+
+// BenchmarkResult is what RunBenchmark measured on this computer
+type BenchmarkResult struct {
+	MaxSustainedHz float64         `json:"maxSustainedHz"` // Highest rate sustained, 0 if even the first was not
+	CPUs           int             `json:"cpus"`
+	OS             string          `json:"os"`
+	Arch           string          `json:"arch"`
+	StartedAt      time.Time       `json:"startedAt"`
+	Seconds        float64         `json:"seconds"`
+	Steps          []BenchmarkStep `json:"steps"`
+}
+
+// benchmarkGuard lets one benchmark run at a time
+type benchmarkGuard struct {
+	mu      sync.Mutex
+	running bool
+}
+
+// withBenchmarkDefaults fills the zero fields of a benchmark config and
+// checks it
+func withBenchmarkDefaults(config BenchmarkConfig) (BenchmarkConfig, error) {
+	if config.StartRateHz == 0 {
+		config.StartRateHz = 250
+	}
+	if config.MaxRateHz == 0 {
+		config.MaxRateHz = 64000
+	}
+	if config.StepSeconds == 0 {
+		config.StepSeconds = 2
+	}
+	switch {
+	case config.StartRateHz < 1 || config.MaxRateHz < config.StartRateHz:
+		return config, fmt.Errorf("rates must be at least 1 Hz and the start rate at most the maximum")
+	case config.MaxRateHz > 1e6:
+		return config, fmt.Errorf("maximum rate must be at most 1000000 Hz, got %g", config.MaxRateHz)
+	case config.StepSeconds < 0.5 || config.StepSeconds > 60:
+		return config, fmt.Errorf("step length must be between 0.5 and 60 seconds, got %g", config.StepSeconds)
+	}
+	return config, nil
+}
+
+// benchmarkStream returns the lines a benchmark feeds: simulated waveforms
+// in the format the simulator sends under fault injection
+func benchmarkStream() [][]byte {
+	simulator := newWaveformSimulator()
+	config := defaultSimulatorConfig()
+	simulator.config = config
+	simulator.lastRR = 60 / config.HeartRate
+
+	period := 1 / config.SampleRateHz
+	at := time.Now()
+	lines := make([][]byte, 0, benchmarkLines)
+	for len(lines) < benchmarkLines {
+		at = at.Add(secondsToDuration(period))
+		if sample, ok := simulator.next(period, at); ok {
+			lines = append(lines, encodeSamples([]SensorData{sample}))
+		}
+	}
+	return lines
+}
+
+// drainBenchmark empties the sample buffer as ReadSensorData does and
+// returns how many samples it held
+func (a *App) drainBenchmark() int {
+	a.bufferMutex.Lock()
+	defer a.bufferMutex.Unlock()
+
+	n := len(a.parsedDataBuffer)
+	a.parsedDataBuffer = a.parsedDataBuffer[:0]
+	a.perf.delivered(time.Now())
+	return n
+}
+
+// runBenchmarkStep feeds lines at one rate for the length of a step
+func (a *App) runBenchmarkStep(lines [][]byte, rate float64, length time.Duration) BenchmarkStep {
+	step := BenchmarkStep{RateHz: rate}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	cpuBefore, cpuErr := processCPUTime()
+
+	start := time.Now()
+	lastDrain := start
+	fed, processed, next := 0, 0, 0
+	chunk := make([]byte, 0, benchmarkReadSize)
+	lagging := false
+	for {
+		elapsed := time.Since(start)
+		if elapsed >= length {
+			break
+		}
+		due := int(rate * elapsed.Seconds())
+		if float64(due-fed) > rate*benchmarkMaxLag.Seconds() {
+			lagging = true
+			break
+		}
+		for ; fed < due; fed++ {
+			for _, b := range lines[next] {
+				chunk = append(chunk, b)
+				if len(chunk) == benchmarkReadSize {
+					a.receive(chunk)
+					chunk = chunk[:0]
+				}
+			}
+			next = (next + 1) % len(lines)
+		}
+		if len(chunk) > 0 {
+			a.receive(chunk)
+			chunk = chunk[:0]
+		}
+		if time.Since(lastDrain) >= benchmarkDrainTick {
+			processed += a.drainBenchmark()
+			lastDrain = time.Now()
+		}
+		time.Sleep(benchmarkTick - time.Since(start.Add(elapsed)))
+	}
+	processed += a.drainBenchmark()
+	seconds := time.Since(start).Seconds()
+
+	cpuAfter, err := processCPUTime()
+	if cpuErr == nil && err == nil {
+		step.CPUPercent = 100 * (cpuAfter - cpuBefore).Seconds() / seconds
+	}
+	runtime.ReadMemStats(&after)
+	step.AchievedHz = float64(processed) / seconds
+	step.Sustained = !lagging && step.AchievedHz >= benchmarkSustained*rate
+	step.AllocBytesPerSecond = float64(after.TotalAlloc-before.TotalAlloc) / seconds
+	step.AllocsPerSecond = float64(after.Mallocs-before.Mallocs) / seconds
+	if processed > 0 {
+		step.AllocBytesPerSample = float64(after.TotalAlloc-before.TotalAlloc) / float64(processed)
+	}
+	return step
+}
+
+// RunBenchmark feeds simulated lines through the parser, the processing
+// pipeline and the sample buffer at doubling rates until the app cannot
+// keep up, and reports the highest rate it sustained with the CPU and
+// allocations of each step. Each step is also pushed as a benchmark event.
+// It is meant for checking a computer before a deployment: it holds the
+// connection while it runs, and the samples go through every processor
+// and sink as the simulator's do.
+func (a *App) RunBenchmark(config BenchmarkConfig) (BenchmarkResult, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return BenchmarkResult{}, err
+	}
+	telemetry.count(FeatureBenchmark)
+	config, err := withBenchmarkDefaults(config)
+	if err != nil {
+		return BenchmarkResult{}, err
+	}
+	if a.recorder.status().Active {
+		return BenchmarkResult{}, fmt.Errorf("stop recording before running a benchmark")
+	}
+	a.benchmark.mu.Lock()
+	if a.benchmark.running {
+		a.benchmark.mu.Unlock()
+		return BenchmarkResult{}, fmt.Errorf("a benchmark is already running")
+	}
+	a.benchmark.running = true
+	a.benchmark.mu.Unlock()
+	defer func() {
+		a.benchmark.mu.Lock()
+		a.benchmark.running = false
+		a.benchmark.mu.Unlock()
+	}()
+
+	// Keep devices and the simulator off the pipeline meanwhile
+	if err := a.beginConnection(benchmarkDeviceID, 0); err != nil {
+		return BenchmarkResult{}, err
+	}
+	a.bufferMutex.Lock()
+	parser, lenient, offParse := a.parser, a.lenient, a.offParse
+	a.parser, a.lenient, a.lastRaw, a.offParse = parseDecimalData, decimalFields, nil, false
+	a.dataBuffer, a.parsedDataBuffer = a.dataBuffer[:0], a.parsedDataBuffer[:0]
+	a.bufferMutex.Unlock()
+	defer func() {
+		a.bufferMutex.Lock()
+		a.parser, a.lenient, a.lastRaw, a.offParse = parser, lenient, nil, offParse
+		a.dataBuffer, a.parsedDataBuffer = a.dataBuffer[:0], a.parsedDataBuffer[:0]
+		a.bufferMutex.Unlock()
+		a.latest.reset()
+		a.setConnection(ConnectionDisconnected, "benchmark finished", nil)
+	}()
+
+	appLog.Infof("Benchmark started from %g Hz to %g Hz", config.StartRateHz, config.MaxRateHz)
+	result := BenchmarkResult{
+		CPUs:      runtime.NumCPU(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		StartedAt: time.Now(),
+		Steps:     make([]BenchmarkStep, 0),
+	}
+	lines := benchmarkStream()
+	for rate := config.StartRateHz; rate <= config.MaxRateHz; rate *= 2 {
+		step := a.runBenchmarkStep(lines, rate, secondsToDuration(config.StepSeconds))
+		result.Steps = append(result.Steps, step)
+		a.emit(EventBenchmark, step)
+		appLog.Infof("Benchmark at %g Hz: %.0f Hz achieved, %.0f %% CPU, %.0f B allocated per sample",
+			rate, step.AchievedHz, step.CPUPercent, step.AllocBytesPerSample)
+		if !step.Sustained {
+			break
+		}
+		result.MaxSustainedHz = rate
+	}
+	result.Seconds = time.Since(result.StartedAt).Seconds()
+
+	appLog.Infof("Benchmark finished: %g Hz sustained", result.MaxSustainedHz)
+	return result, nil
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// calibrationFile stores the applied calibrations and their history
+const calibrationFile = "calibration.json"
+
+// calibrationPoints is the number of reference points of a calibration
+const calibrationPoints = 2
+
+// CalibrationPoint is an averaged raw reading captured at a known reference value
+type CalibrationPoint struct {
+	Reference  float64   `json:"reference"`
+	Measured   float64   `json:"measured"` // Mean raw reading over the capture
+	Samples    int       `json:"samples"`
+	CapturedAt time.Time `json:"capturedAt"`
+}
+
+// Calibration maps raw readings to engineering units: value = Gain*raw + Offset
+type Calibration struct {
+	Gain      float64            `json:"gain"`
+	Offset    float64            `json:"offset"`
+	Points    []CalibrationPoint `json:"points"`
+	Note      string             `json:"note,omitempty"`
+	AppliedAt time.Time          `json:"appliedAt"`
+}
+
+// ChannelCalibration is the calibration store entry of a channel
+type ChannelCalibration struct {
+	Current *Calibration  `json:"current,omitempty"` // nil when readings pass through unchanged
+	History []Calibration `json:"history"`           // Previously applied calibrations, oldest first
+}
+
+// CalibrationSession is the state of a calibration procedure in progress
+type CalibrationSession struct {
+	Channel   string             `json:"channel"`
+	Points    []CalibrationPoint `json:"points"`
+	Capturing bool               `json:"capturing"`
+	Progress  float64            `json:"progress"` // Fraction of the current capture completed
+}
+
+// CalibrationPreview shows the effect of the captured points before they are applied
+type CalibrationPreview struct {
+	Channel        string  `json:"channel"`
+	Gain           float64 `json:"gain"`
+	Offset         float64 `json:"offset"`
+	LatestRaw      float64 `json:"latestRaw"`
+	LatestCurrent  float64 `json:"latestCurrent"`  // Latest reading with the calibration in use
+	LatestProposed float64 `json:"latestProposed"` // Latest reading with the proposed calibration
+}
+
+// calibrationCapture averages raw readings for one reference point
+type calibrationCapture struct {
+	reference float64
+	started   time.Time
+	duration  time.Duration
+	sum       float64
+	count     int
+}
+
+// calibrationSession is the procedure in progress on one channel
+type calibrationSession struct {
+	points  []CalibrationPoint
+	capture *calibrationCapture
+}
+
+// calibrationStore applies the persisted calibrations to the raw channels and
+// runs the guided two-point procedure. It is the first pipeline stage, so
+// every later stage works in engineering units.
+type calibrationStore struct {
+	mu        sync.Mutex
+	channels  map[string]*ChannelCalibration
+	sessions  map[string]*calibrationSession
+	latestRaw map[string]float64
+	notify    func(CalibrationSession) // Called when a capture completes, outside the lock
+}
+
+// newCalibrationStore creates the calibration stage and loads the persisted calibrations
+func newCalibrationStore(notify func(CalibrationSession)) *calibrationStore {
+	s := &calibrationStore{
+		channels:  make(map[string]*ChannelCalibration),
+		sessions:  make(map[string]*calibrationSession),
+		latestRaw: make(map[string]float64),
+		notify:    notify,
+	}
+	if err := loadJSONFile(calibrationFile, &s.channels); err != nil {
+		processingLog.Errorf("Error loading calibrations: %v", err)
+	}
+	return s
+}
+
+// reload replaces the calibrations with the persisted ones, e.g. after a
+// backup was restored
+func (s *calibrationStore) reload() {
+	channels := make(map[string]*ChannelCalibration)
+	if err := loadJSONFile(calibrationFile, &channels); err != nil {
+		processingLog.Errorf("Error loading calibrations: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.channels = channels
+}
+
+func (s *calibrationStore) process(sample *SensorData) {
+	s.mu.Lock()
+	var completed []CalibrationSession
+
+	for _, channel := range rawChannels {
+		raw, _ := sample.channelValue(channel)
+		s.latestRaw[channel] = raw
+
+		if session, ok := s.sessions[channel]; ok && session.capture != nil {
+			c := session.capture
+			c.sum += raw
+			c.count++
+			if sample.Timestamp.Sub(c.started) >= c.duration {
+				session.points = append(session.points, CalibrationPoint{
+					Reference:  c.reference,
+					Measured:   c.sum / float64(c.count),
+					Samples:    c.count,
+					CapturedAt: sample.Timestamp,
+				})
+				session.capture = nil
+				completed = append(completed, session.state(channel, sample.Timestamp))
+			}
+		}
+
+		if entry, ok := s.channels[channel]; ok && entry.Current != nil {
+			sample.setChannelValue(channel, entry.Current.Gain*raw+entry.Current.Offset)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, state := range completed {
+		s.notify(state)
+	}
+}
+
+// state returns the public view of a session
+func (s *calibrationSession) state(channel string, now time.Time) CalibrationSession {
+	result := CalibrationSession{
+		Channel: channel,
+		Points:  append([]CalibrationPoint{}, s.points...),
+	}
+	if s.capture != nil {
+		result.Capturing = true
+		result.Progress = float64(now.Sub(s.capture.started)) / float64(s.capture.duration)
+		if result.Progress > 1 {
+			result.Progress = 1
+		}
+	}
+	return result
+}
+
+// fit computes gain and offset from the two captured points
+func (s *calibrationSession) fit() (float64, float64, error) {
+	if len(s.points) < calibrationPoints {
+		return 0, 0, fmt.Errorf("%d of %d calibration points captured", len(s.points), calibrationPoints)
+	}
+
+	p1, p2 := s.points[0], s.points[1]
+	if p1.Measured == p2.Measured {
+		return 0, 0, fmt.Errorf("both points measured the same raw value %.4f", p1.Measured)
+	}
+	if p1.Reference == p2.Reference {
+		return 0, 0, fmt.Errorf("both points use the same reference %.4f", p1.Reference)
+	}
+
+	gain := (p2.Reference - p1.Reference) / (p2.Measured - p1.Measured)
+	return gain, p1.Reference - gain*p1.Measured, nil
+}
+
+// save persists the calibration store; the caller holds the lock
+func (s *calibrationStore) save() error {
+	return saveJSONFile(calibrationFile, s.channels)
+}
+
+// onCalibrationPoint forwards a completed capture to the frontend
+func (a *App) onCalibrationPoint(session CalibrationSession) {
+	processingLog.Infof("Calibration point %d captured on %s", len(session.Points), session.Channel)
+	a.emit(EventCalibration, session)
+}
+
+// StartCalibrationCapture averages the raw readings of a channel for the
+// given duration as the next reference point of its calibration procedure
+func (a *App) StartCalibrationCapture(channel string, reference float64, durationSeconds float64) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+	telemetry.count(FeatureCalibration)
+
+	if !isRawChannel(channel) {
+		return fmt.Errorf("only raw channels can be calibrated, got '%s'", channel)
+	}
+	if durationSeconds <= 0 {
+		return fmt.Errorf("capture duration must be positive, got %.2f s", durationSeconds)
+	}
+
+	a.calibration.mu.Lock()
+	defer a.calibration.mu.Unlock()
+
+	session, ok := a.calibration.sessions[channel]
+	if !ok {
+		session = &calibrationSession{}
+		a.calibration.sessions[channel] = session
+	}
+	if session.capture != nil {
+		return fmt.Errorf("a capture is already running on %s", channel)
+	}
+	if len(session.points) >= calibrationPoints {
+		return fmt.Errorf("all %d points are captured; apply or cancel the calibration", calibrationPoints)
+	}
+
+	session.capture = &calibrationCapture{
+		reference: reference,
+		started:   time.Now(),
+		duration:  secondsToDuration(durationSeconds),
+	}
+
+	processingLog.Infof("Capturing calibration point %d on %s at reference %.4f", len(session.points)+1, channel, reference)
+	return nil
+}
+
+// GetCalibrationSession returns the progress of the calibration procedure of a channel
+func (a *App) GetCalibrationSession(channel string) (CalibrationSession, error) {
+	a.calibration.mu.Lock()
+	defer a.calibration.mu.Unlock()
+
+	session, ok := a.calibration.sessions[channel]
+	if !ok {
+		return CalibrationSession{}, fmt.Errorf("no calibration in progress on '%s'", channel)
+	}
+	return session.state(channel, time.Now()), nil
+}
+
+// PreviewCalibration computes gain and offset from the captured points and
+// shows the latest reading under the current and the proposed calibration
+func (a *App) PreviewCalibration(channel string) (CalibrationPreview, error) {
+	a.calibration.mu.Lock()
+	defer a.calibration.mu.Unlock()
+
+	session, ok := a.calibration.sessions[channel]
+	if !ok {
+		return CalibrationPreview{}, fmt.Errorf("no calibration in progress on '%s'", channel)
+	}
+	gain, offset, err := session.fit()
+	if err != nil {
+		return CalibrationPreview{}, err
+	}
+
+	raw := a.calibration.latestRaw[channel]
+	current := raw
+	if entry, ok := a.calibration.channels[channel]; ok && entry.Current != nil {
+		current = entry.Current.Gain*raw + entry.Current.Offset
+	}
+
+	return CalibrationPreview{
+		Channel:        channel,
+		Gain:           gain,
+		Offset:         offset,
+		LatestRaw:      raw,
+		LatestCurrent:  current,
+		LatestProposed: gain*raw + offset,
+	}, nil
+}
+
+// ApplyCalibration stores the calibration computed from the captured points,
+// moving the previous one to the channel's history
+func (a *App) ApplyCalibration(channel string, note string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.calibration.mu.Lock()
+	defer a.calibration.mu.Unlock()
+
+	session, ok := a.calibration.sessions[channel]
+	if !ok {
+		return fmt.Errorf("no calibration in progress on '%s'", channel)
+	}
+	gain, offset, err := session.fit()
+	if err != nil {
+		return err
+	}
+
+	a.calibration.setCurrent(channel, &Calibration{
+		Gain:      gain,
+		Offset:    offset,
+		Points:    session.points,
+		Note:      note,
+		AppliedAt: time.Now(),
+	})
+	delete(a.calibration.sessions, channel)
+
+	processingLog.Infof("Calibration applied on %s: gain %.6f, offset %.6f", channel, gain, offset)
+	a.audit.record("", AuditCalibration, fmt.Sprintf("Calibration applied on %s: gain %.6f, offset %.6f", channel, gain, offset))
+	return a.calibration.save()
+}
+
+// setCurrent replaces the calibration of a channel, keeping the old one in
+// the history; the caller holds the lock
+func (s *calibrationStore) setCurrent(channel string, calibration *Calibration) {
+	entry, ok := s.channels[channel]
+	if !ok {
+		entry = &ChannelCalibration{History: make([]Calibration, 0)}
+		s.channels[channel] = entry
+	}
+	if entry.Current != nil {
+		entry.History = append(entry.History, *entry.Current)
+	}
+	entry.Current = calibration
+}
+
+// CancelCalibration abandons the calibration procedure of a channel
+func (a *App) CancelCalibration(channel string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.calibration.mu.Lock()
+	defer a.calibration.mu.Unlock()
+
+	if _, ok := a.calibration.sessions[channel]; !ok {
+		return fmt.Errorf("no calibration in progress on '%s'", channel)
+	}
+	delete(a.calibration.sessions, channel)
+
+	processingLog.Infof("Calibration cancelled on %s", channel)
+	a.audit.record("", AuditCalibration, fmt.Sprintf("Calibration cancelled on %s", channel))
+	return nil
+}
+
+// ResetCalibration removes the calibration of a channel so raw readings pass
+// through unchanged; the removed calibration stays in the history
+func (a *App) ResetCalibration(channel string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.calibration.mu.Lock()
+	defer a.calibration.mu.Unlock()
+
+	entry, ok := a.calibration.channels[channel]
+	if !ok || entry.Current == nil {
+		return fmt.Errorf("channel '%s' is not calibrated", channel)
+	}
+	a.calibration.setCurrent(channel, nil)
+
+	processingLog.Infof("Calibration reset on %s", channel)
+	a.audit.record("", AuditCalibration, fmt.Sprintf("Calibration reset on %s", channel))
+	return a.calibration.save()
+}
+
+// GetCalibration returns the current calibration of a channel and its history
+func (a *App) GetCalibration(channel string) ChannelCalibration {
+	a.calibration.mu.Lock()
+	defer a.calibration.mu.Unlock()
+
+	entry, ok := a.calibration.channels[channel]
+	if !ok {
+		return ChannelCalibration{History: make([]Calibration, 0)}
+	}
+	return ChannelCalibration{
+		Current: entry.Current,
+		History: append([]Calibration{}, entry.History...),
+	}
+}
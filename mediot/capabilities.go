@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// capabilitiesCommand asks the device to describe itself. It answers
+// "CAPS=" followed by a JSON object, or by its TLV encoding in hex.
+const capabilitiesCommand = "CAPS?"
+
+// TLV record types of the capabilities reply. Every record is a type byte,
+// a length byte and the value; a channel record nests its own records.
+const (
+	tlvSampleRate   = 0x01 // uint32, samples per second
+	tlvChannel      = 0x02 // Nested channel records
+	tlvChannelIndex = 0x10 // uint8, 1-based raw channel number
+	tlvChannelName  = 0x11 // UTF-8 display name
+	tlvChannelUnit  = 0x12 // UTF-8 engineering unit
+	tlvChannelMin   = 0x13 // float32, lowest value the channel can report
+	tlvChannelMax   = 0x14 // float32, highest value the channel can report
+)
+
+// ChannelCapability describes one channel the device reports
+type ChannelCapability struct {
+	Index int      `json:"index"` // 1-based raw channel number, value1 is 1
+	Name  string   `json:"name"`
+	Unit  string   `json:"unit"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+}
+
+// DeviceCapabilities is the device's description of its channels
+type DeviceCapabilities struct {
+	SampleRateHz float64             `json:"sampleRateHz"`
+	Channels     []ChannelCapability `json:"channels"`
+	DiscoveredAt time.Time           `json:"discoveredAt"`
+}
+
+// channel returns the raw channel a capability describes
+func (c ChannelCapability) channel() (string, bool) {
+	if c.Index < 1 || c.Index > len(rawChannels) {
+		return "", false
+	}
+	return rawChannels[c.Index-1], true
+}
+
+// parseCapabilities decodes the value of a CAPS= reply
+func parseCapabilities(value string) (DeviceCapabilities, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "{") {
+		var caps DeviceCapabilities
+		if err := json.Unmarshal([]byte(value), &caps); err != nil {
+			return DeviceCapabilities{}, fmt.Errorf("invalid capabilities JSON: %v", err)
+		}
+		return caps, nil
+	}
+
+	data, err := hex.DecodeString(value)
+	if err != nil {
+		return DeviceCapabilities{}, fmt.Errorf("capabilities are neither JSON nor hex TLV: %v", err)
+	}
+	return parseCapabilitiesTLV(data)
+}
+
+// parseTLV splits data into its type/value records
+func parseTLV(data []byte, fn func(kind byte, value []byte) error) error {
+	for len(data) > 0 {
+		if len(data) < 2 || len(data) < 2+int(data[1]) {
+			return fmt.Errorf("truncated TLV record")
+		}
+		kind, value := data[0], data[2:2+int(data[1])]
+		if err := fn(kind, value); err != nil {
+			return err
+		}
+		data = data[2+len(value):]
+	}
+	return nil
+}
+
+// parseCapabilitiesTLV decodes the binary form of the capabilities. Unknown
+// records are skipped so newer firmware can add fields.
+func parseCapabilitiesTLV(data []byte) (DeviceCapabilities, error) {
+	var caps DeviceCapabilities
+	err := parseTLV(data, func(kind byte, value []byte) error {
+		switch kind {
+		case tlvSampleRate:
+			if len(value) != 4 {
+				return fmt.Errorf("sample rate record has %d bytes", len(value))
+			}
+			caps.SampleRateHz = float64(binary.BigEndian.Uint32(value))
+		case tlvChannel:
+			var channel ChannelCapability
+			err := parseTLV(value, func(kind byte, value []byte) error {
+				switch kind {
+				case tlvChannelIndex:
+					if len(value) != 1 {
+						return fmt.Errorf("channel index record has %d bytes", len(value))
+					}
+					channel.Index = int(value[0])
+				case tlvChannelName:
+					channel.Name = string(value)
+				case tlvChannelUnit:
+					channel.Unit = string(value)
+				case tlvChannelMin, tlvChannelMax:
+					if len(value) != 4 {
+						return fmt.Errorf("channel range record has %d bytes", len(value))
+					}
+					v := float64(math.Float32frombits(binary.BigEndian.Uint32(value)))
+					if kind == tlvChannelMin {
+						channel.Min = &v
+					} else {
+						channel.Max = &v
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			caps.Channels = append(caps.Channels, channel)
+		}
+		return nil
+	})
+	return caps, err
+}
+
+// queryCapabilities asks the device for its capabilities; the caller owns the port
+func (a *App) queryCapabilities() (DeviceCapabilities, error) {
+	reply, err := a.deviceCommand(capabilitiesCommand)
+	if err != nil {
+		return DeviceCapabilities{}, err
+	}
+	key, value, ok := strings.Cut(reply, "=")
+	if !ok || !strings.EqualFold(strings.TrimSpace(key), "CAPS") {
+		return DeviceCapabilities{}, fmt.Errorf("unexpected reply '%s' to %s", reply, capabilitiesCommand)
+	}
+	caps, err := parseCapabilities(value)
+	if err != nil {
+		return DeviceCapabilities{}, err
+	}
+	caps.DiscoveredAt = time.Now()
+	return caps, nil
+}
+
+// applyCapabilities configures the connected device's channels from its
+// capabilities. Names and units already configured are kept; the channel range
+// becomes a critical alarm rule for channels without one.
+func (a *App) applyCapabilities(caps DeviceCapabilities) error {
+	ruled := make(map[string]bool)
+	for _, rule := range a.GetAlarmRules() {
+		ruled[rule.Channel] = true
+	}
+
+	a.devices.mu.Lock()
+	device, ok := a.devices.devices[a.devices.connected]
+	if !ok {
+		a.devices.mu.Unlock()
+		return fmt.Errorf("no device connected")
+	}
+	device.Capabilities = &caps
+	for _, capability := range caps.Channels {
+		channel, ok := capability.channel()
+		if ok && capability.Name != "" && device.Settings.ChannelNames[channel] == "" {
+			device.Settings.ChannelNames[channel] = capability.Name
+		}
+	}
+	err := a.devices.save()
+	a.devices.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, capability := range caps.Channels {
+		channel, ok := capability.channel()
+		if !ok {
+			deviceLog.Warnf("Ignoring capability of unknown channel %d", capability.Index)
+			continue
+		}
+		if capability.Unit != "" && a.units.unitOf(channel) == "" {
+			if err := a.setChannelUnit(channel, capability.Unit); err != nil {
+				return err
+			}
+		}
+		if !ruled[channel] && (capability.Min != nil || capability.Max != nil) {
+			rule := AlarmRule{Channel: channel, CriticalLow: capability.Min, CriticalHigh: capability.Max}
+			if err := a.setAlarmRule(rule); err != nil {
+				return fmt.Errorf("range rule for %s: %v", channel, err)
+			}
+		}
+	}
+	return nil
+}
+
+// DiscoverDeviceCapabilities queries the connected device for its channels,
+// units, ranges and sample rate and configures the channels from the reply
+func (a *App) DiscoverDeviceCapabilities() (DeviceCapabilities, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return DeviceCapabilities{}, err
+	}
+
+	var caps DeviceCapabilities
+	err := a.withDevicePort(func() error {
+		var err error
+		caps, err = a.queryCapabilities()
+		return err
+	})
+	if err != nil {
+		return DeviceCapabilities{}, err
+	}
+	if err := a.applyCapabilities(caps); err != nil {
+		return DeviceCapabilities{}, err
+	}
+
+	deviceLog.Infof("Device reports %d channels at %g Hz", len(caps.Channels), caps.SampleRateHz)
+	return caps, nil
+}
+
+// discoverOnConnect runs capability discovery for a newly connected device.
+// Devices without the exchange reject the command or stay silent, and keep
+// their manual setup.
+func (a *App) discoverOnConnect() {
+	if _, err := a.DiscoverDeviceCapabilities(); err != nil {
+		deviceLog.Warnf("Capability discovery skipped: %v", err)
+	}
+}
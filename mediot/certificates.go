@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// certificatesFile stores the imported CA bundles and client certificates
+const certificatesFile = "certificates.json"
+
+// certificateExpiryWarning is how long before expiry a certificate is flagged
+const certificateExpiryWarning = 30 * 24 * time.Hour
+
+// Certificate kinds
+const (
+	CertificateCA     = "ca"     // CA bundle trusted in addition to the system roots
+	CertificateClient = "client" // Certificate and key presented to servers that ask for one
+)
+
+// CertificateInfo describes an imported certificate without its key
+type CertificateInfo struct {
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind"`
+	Subject     string    `json:"subject"` // First certificate of a bundle
+	Issuer      string    `json:"issuer"`
+	Count       int       `json:"count"` // Certificates in the bundle or chain
+	NotBefore   time.Time `json:"notBefore"`
+	NotAfter    time.Time `json:"notAfter"` // Earliest expiry in the bundle
+	Expired     bool      `json:"expired"`
+	ExpiresSoon bool      `json:"expiresSoon"` // Expires within 30 days
+	ImportedAt  time.Time `json:"importedAt"`
+}
+
+// storedCertificate is an imported certificate with its PEM data
+type storedCertificate struct {
+	CertificateInfo
+	CertPEM string `json:"certPem"`
+	KeyPEM  string `json:"keyPem,omitempty"` // Client certificates only, in the file only when the keyring failed
+}
+
+// clientKeySecret names the keyring entry of a client certificate's private key
+func clientKeySecret(name string) string {
+	return "client-key-" + name
+}
+
+// certificateStore is the trust and identity configuration of every
+// outbound TLS connection (SMTP, messaging APIs). Sinks take their
+// configuration from clientConfig instead of keeping their own files.
+type certificateStore struct {
+	mu    sync.Mutex
+	certs map[string]*storedCertificate
+}
+
+// certificates is shared by the network sinks, which are not tied to the App
+var certificates = &certificateStore{certs: make(map[string]*storedCertificate)}
+
+// load reads the imported certificates and warns about expiring ones
+func (s *certificateStore) load() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := loadJSONFile(certificatesFile, &s.certs); err != nil {
+		securityLog.Errorf("Error loading certificates: %v", err)
+	}
+	moved := false
+	for name, cert := range s.certs {
+		if cert.Kind == CertificateClient && secrets.restore(clientKeySecret(name), &cert.KeyPEM) {
+			moved = true
+		}
+	}
+	if moved {
+		if err := s.save(); err != nil {
+			securityLog.Errorf("Error saving certificates: %v", err)
+		}
+	}
+	for _, cert := range s.certs {
+		if info := cert.status(); info.Expired {
+			securityLog.Warnf("Certificate %s expired on %s", info.Name, info.NotAfter.Format(time.DateOnly))
+		} else if info.ExpiresSoon {
+			securityLog.Warnf("Certificate %s expires on %s", info.Name, info.NotAfter.Format(time.DateOnly))
+		}
+	}
+}
+
+// status returns the description with the expiry flags as of now
+func (c *storedCertificate) status() CertificateInfo {
+	info := c.CertificateInfo
+	now := time.Now()
+	info.Expired = now.After(info.NotAfter)
+	info.ExpiresSoon = !info.Expired && info.NotAfter.Sub(now) < certificateExpiryWarning
+	return info
+}
+
+// parseCertificates decodes every certificate of a PEM file
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM certificate found")
+	}
+	return certs, nil
+}
+
+// describeCertificates summarizes a bundle and rejects it if any
+// certificate is outside its validity period
+func describeCertificates(name, kind string, certs []*x509.Certificate) (CertificateInfo, error) {
+	now := time.Now()
+	info := CertificateInfo{
+		Name:       name,
+		Kind:       kind,
+		Subject:    certs[0].Subject.String(),
+		Issuer:     certs[0].Issuer.String(),
+		Count:      len(certs),
+		NotBefore:  certs[0].NotBefore,
+		NotAfter:   certs[0].NotAfter,
+		ImportedAt: now,
+	}
+	for _, cert := range certs {
+		if now.After(cert.NotAfter) {
+			return CertificateInfo{}, fmt.Errorf("certificate '%s' expired on %s", cert.Subject, cert.NotAfter.Format(time.DateOnly))
+		}
+		if now.Before(cert.NotBefore) {
+			return CertificateInfo{}, fmt.Errorf("certificate '%s' is not valid before %s", cert.Subject, cert.NotBefore.Format(time.DateOnly))
+		}
+		if cert.NotAfter.Before(info.NotAfter) {
+			info.NotAfter = cert.NotAfter
+		}
+		if cert.NotBefore.After(info.NotBefore) {
+			info.NotBefore = cert.NotBefore
+		}
+	}
+	return info, nil
+}
+
+// save persists the certificates, leaving out the private keys the keyring
+// holds; the caller holds the lock
+func (s *certificateStore) save() error {
+	stored := make(map[string]*storedCertificate, len(s.certs))
+	for name, cert := range s.certs {
+		copied := *cert
+		if copied.Kind == CertificateClient && secrets.keep(clientKeySecret(name), copied.KeyPEM) {
+			copied.KeyPEM = ""
+		}
+		stored[name] = &copied
+	}
+	return saveJSONFile(certificatesFile, stored)
+}
+
+// put stores an imported certificate under its name
+func (s *certificateStore) put(cert *storedCertificate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.certs[cert.Name] = cert
+	return s.save()
+}
+
+// clientConfig returns the TLS configuration for a connection to host: the
+// system roots plus every imported CA, and the imported client certificate
+// the server accepts, if it asks for one
+func (s *certificateStore) clientConfig(host string) *tls.Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config := &tls.Config{ServerName: host}
+	var clients []tls.Certificate
+	for _, stored := range s.certs {
+		switch stored.Kind {
+		case CertificateCA:
+			if config.RootCAs == nil {
+				roots, err := x509.SystemCertPool()
+				if err != nil {
+					roots = x509.NewCertPool()
+				}
+				config.RootCAs = roots
+			}
+			config.RootCAs.AppendCertsFromPEM([]byte(stored.CertPEM))
+		case CertificateClient:
+			if cert, err := tls.X509KeyPair([]byte(stored.CertPEM), []byte(stored.KeyPEM)); err == nil {
+				clients = append(clients, cert)
+			}
+		}
+	}
+	if len(clients) > 0 {
+		config.GetClientCertificate = func(request *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			for i := range clients {
+				if request.SupportsCertificate(&clients[i]) == nil {
+					return &clients[i], nil
+				}
+			}
+			// No certificate; the server decides whether that is acceptable
+			return &tls.Certificate{}, nil
+		}
+	}
+	return config
+}
+
+// httpTransport returns a transport whose TLS connections use the current
+// certificate configuration, so imports apply without restarting the sinks
+func (s *certificateStore) httpTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				host = addr
+			}
+			dialer := &tls.Dialer{Config: s.clientConfig(host)}
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// tlsFailure turns a certificate verification error into an explanation of
+// what to fix, or returns nil for any other error
+func tlsFailure(err error) error {
+	var unknown x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &unknown):
+		issuer := "an unknown authority"
+		if unknown.Cert != nil {
+			issuer = fmt.Sprintf("'%s'", unknown.Cert.Issuer)
+		}
+		return fmt.Errorf("server certificate is signed by %s, which is not trusted; import its CA certificate", issuer)
+	case errors.As(err, &invalid) && invalid.Reason == x509.Expired:
+		return fmt.Errorf("server certificate '%s' has expired or is not yet valid (valid %s to %s)",
+			invalid.Cert.Subject, invalid.Cert.NotBefore.Format(time.DateOnly), invalid.Cert.NotAfter.Format(time.DateOnly))
+	case errors.As(err, &invalid):
+		return fmt.Errorf("server certificate '%s' is not acceptable: %v", invalid.Cert.Subject, invalid)
+	case errors.As(err, &hostname):
+		return fmt.Errorf("server certificate is for %s, not for host '%s'",
+			strings.Join(hostname.Certificate.DNSNames, ", "), hostname.Host)
+	case strings.Contains(err.Error(), "certificate required"):
+		return fmt.Errorf("server requires a client certificate; import one it accepts")
+	}
+	return nil
+}
+
+// GetCertificates returns the imported certificates sorted by name, with their expiry status
+func (a *App) GetCertificates() []CertificateInfo {
+	certificates.mu.Lock()
+	defer certificates.mu.Unlock()
+
+	result := make([]CertificateInfo, 0, len(certificates.certs))
+	for _, cert := range certificates.certs {
+		result = append(result, cert.status())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// ImportCABundle trusts the CA certificates of a PEM file for every outbound
+// connection, in addition to the system roots
+func (a *App) ImportCABundle(name string, path string) (CertificateInfo, error) {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return CertificateInfo{}, err
+	}
+	if name == "" {
+		return CertificateInfo{}, fmt.Errorf("name is required")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return CertificateInfo{}, fmt.Errorf("failed to read CA bundle: %v", err)
+	}
+	certs, err := parseCertificates(data)
+	if err != nil {
+		return CertificateInfo{}, err
+	}
+	for _, cert := range certs {
+		if !cert.IsCA {
+			return CertificateInfo{}, fmt.Errorf("'%s' is not a CA certificate", cert.Subject)
+		}
+	}
+	info, err := describeCertificates(name, CertificateCA, certs)
+	if err != nil {
+		return CertificateInfo{}, err
+	}
+	if err := certificates.put(&storedCertificate{CertificateInfo: info, CertPEM: string(data)}); err != nil {
+		return CertificateInfo{}, err
+	}
+
+	securityLog.Infof("CA bundle %s imported with %d certificate(s)", name, len(certs))
+	a.audit.record("", AuditSecurity, fmt.Sprintf("CA bundle %s imported (%s)", name, info.Subject))
+	return info, nil
+}
+
+// ImportClientCertificate stores a client certificate chain and its private
+// key, both PEM files. It is presented to servers that request a certificate
+// from its issuer.
+func (a *App) ImportClientCertificate(name string, certPath string, keyPath string) (CertificateInfo, error) {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return CertificateInfo{}, err
+	}
+	if name == "" {
+		return CertificateInfo{}, fmt.Errorf("name is required")
+	}
+
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return CertificateInfo{}, fmt.Errorf("failed to read certificate: %v", err)
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return CertificateInfo{}, fmt.Errorf("failed to read private key: %v", err)
+	}
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		return CertificateInfo{}, fmt.Errorf("certificate and key do not form a pair: %v", err)
+	}
+	certs, err := parseCertificates(certPEM)
+	if err != nil {
+		return CertificateInfo{}, err
+	}
+	info, err := describeCertificates(name, CertificateClient, certs)
+	if err != nil {
+		return CertificateInfo{}, err
+	}
+	stored := &storedCertificate{CertificateInfo: info, CertPEM: string(certPEM), KeyPEM: string(keyPEM)}
+	if err := certificates.put(stored); err != nil {
+		return CertificateInfo{}, err
+	}
+
+	securityLog.Infof("Client certificate %s imported", name)
+	a.audit.record("", AuditSecurity, fmt.Sprintf("Client certificate %s imported (%s)", name, info.Subject))
+	return info, nil
+}
+
+// RemoveCertificate deletes an imported certificate
+func (a *App) RemoveCertificate(name string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	certificates.mu.Lock()
+	defer certificates.mu.Unlock()
+
+	cert, ok := certificates.certs[name]
+	if !ok {
+		return fmt.Errorf("unknown certificate '%s'", name)
+	}
+	delete(certificates.certs, name)
+	if cert.Kind == CertificateClient {
+		secrets.keep(clientKeySecret(name), "")
+	}
+
+	securityLog.Infof("Certificate %s removed", name)
+	a.audit.record("", AuditSecurity, fmt.Sprintf("Certificate %s removed", name))
+	return certificates.save()
+}
@@ -0,0 +1,79 @@
+package main
+
+// Raw channel names, matching the JSON fields of SensorData
+const (
+	ChannelValue1 = "value1"
+	ChannelValue2 = "value2"
+	ChannelValue3 = "value3"
+)
+
+// Derived channel names produced by the processing pipeline
+const (
+	ChannelSpO2           = "spo2"
+	ChannelPerfusionIndex = "pi"
+)
+
+// rawChannels lists the channels decoded directly from the serial stream
+var rawChannels = []string{ChannelValue1, ChannelValue2, ChannelValue3}
+
+// isRawChannel reports whether the name is one of the decoded stream channels
+func isRawChannel(name string) bool {
+	return name == ChannelValue1 || name == ChannelValue2 || name == ChannelValue3
+}
+
+// channelValue returns the value of a raw or derived channel in the sample
+func (d *SensorData) channelValue(name string) (float64, bool) {
+	switch name {
+	case ChannelValue1:
+		return d.Value1, true
+	case ChannelValue2:
+		return d.Value2, true
+	case ChannelValue3:
+		return d.Value3, true
+	}
+
+	value, ok := d.Derived[name]
+	return value, ok
+}
+
+// setChannelValue overwrites a raw channel, or attaches a derived one
+func (d *SensorData) setChannelValue(name string, value float64) {
+	switch name {
+	case ChannelValue1:
+		d.Value1 = value
+	case ChannelValue2:
+		d.Value2 = value
+	case ChannelValue3:
+		d.Value3 = value
+	default:
+		d.setDerived(name, value)
+	}
+}
+
+// forEachChannel calls fn for every raw and derived channel in the sample
+func (d *SensorData) forEachChannel(fn func(name string, value float64)) {
+	fn(ChannelValue1, d.Value1)
+	fn(ChannelValue2, d.Value2)
+	fn(ChannelValue3, d.Value3)
+	for name, value := range d.Derived {
+		fn(name, value)
+	}
+}
+
+// isArtifact reports whether the channel reading is marked as an artifact
+func (d *SensorData) isArtifact(name string) bool {
+	for _, channel := range d.Artifacts {
+		if channel == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setDerived attaches a derived channel value to the sample
+func (d *SensorData) setDerived(name string, value float64) {
+	if d.Derived == nil {
+		d.Derived = make(map[string]float64)
+	}
+	d.Derived[name] = value
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Clock synchronisation protocols
+const (
+	ClockProtocolUnixMillis = "unix-ms" // "TIME <ms since epoch>", reply "TIME=<ms>"
+	ClockProtocolISO8601    = "iso8601" // "TIME <RFC 3339 UTC>", reply "TIME=<RFC 3339>"
+)
+
+// clockProtocol encodes the host time for a device and decodes its reply
+type clockProtocol interface {
+	command(host time.Time) string
+	parseReply(reply string) (time.Time, error)
+}
+
+// clockProtocols are the protocols a device can be synchronised with
+var clockProtocols = map[string]clockProtocol{
+	ClockProtocolUnixMillis: unixMillisClock{},
+	ClockProtocolISO8601:    iso8601Clock{},
+}
+
+// ClockSync records one synchronisation of the device clock
+type ClockSync struct {
+	Protocol    string    `json:"protocol"`
+	HostTime    time.Time `json:"hostTime"`   // Time sent to the device
+	DeviceTime  time.Time `json:"deviceTime"` // Time the device reported after setting its clock
+	RoundTripMs float64   `json:"roundTripMs"`
+	OffsetMs    float64   `json:"offsetMs"` // Device clock minus host clock, assuming a symmetric link
+}
+
+// unixMillisClock sends milliseconds since the Unix epoch
+type unixMillisClock struct{}
+
+func (unixMillisClock) command(host time.Time) string {
+	return fmt.Sprintf("TIME %d", host.UnixMilli())
+}
+
+func (unixMillisClock) parseReply(reply string) (time.Time, error) {
+	ms, err := strconv.ParseInt(timeReplyValue(reply), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid device time '%s'", reply)
+	}
+	return time.UnixMilli(ms), nil
+}
+
+// iso8601Clock sends RFC 3339 UTC timestamps with milliseconds
+type iso8601Clock struct{}
+
+func (iso8601Clock) command(host time.Time) string {
+	return "TIME " + host.UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+func (iso8601Clock) parseReply(reply string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, timeReplyValue(reply))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid device time '%s'", reply)
+	}
+	return t, nil
+}
+
+// timeReplyValue returns the value of a "TIME=value" reply
+func timeReplyValue(reply string) string {
+	_, value, _ := strings.Cut(reply, "=")
+	return strings.TrimSpace(value)
+}
+
+// recordClockSync adds a clock synchronisation to the running session
+func (l *sessionLog) recordClockSync(sync ClockSync) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.current == nil {
+		return
+	}
+	l.current.ClockSyncs = append(l.current.ClockSyncs, sync)
+	l.save()
+}
+
+// SyncDeviceClock sets the device clock to the host time with the protocol
+// of the device's settings, measures the round trip to estimate the
+// remaining offset and records both in the session
+func (a *App) SyncDeviceClock() (ClockSync, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return ClockSync{}, err
+	}
+	telemetry.count(FeatureClockSync)
+
+	name := ClockProtocolUnixMillis
+	if device := a.GetConnectedDevice(); device != nil && device.Settings.ClockProtocol != "" {
+		name = device.Settings.ClockProtocol
+	}
+	protocol, ok := clockProtocols[name]
+	if !ok {
+		return ClockSync{}, fmt.Errorf("unknown clock protocol '%s'", name)
+	}
+
+	sync := ClockSync{Protocol: name}
+	err := a.withDevicePort(func() error {
+		sync.HostTime = time.Now()
+		reply, err := a.deviceCommand(protocol.command(sync.HostTime))
+		received := time.Now()
+		if err != nil {
+			return err
+		}
+		if sync.DeviceTime, err = protocol.parseReply(reply); err != nil {
+			return err
+		}
+
+		roundTrip := received.Sub(sync.HostTime)
+		sync.RoundTripMs = float64(roundTrip) / float64(time.Millisecond)
+		sync.OffsetMs = float64(sync.DeviceTime.Sub(sync.HostTime.Add(roundTrip/2))) / float64(time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		return ClockSync{}, err
+	}
+
+	a.sessions.recordClockSync(sync)
+	deviceLog.Infof("Device clock synchronised: round trip %.1f ms, offset %.1f ms", sync.RoundTripMs, sync.OffsetMs)
+	return sync, nil
+}
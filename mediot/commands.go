@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Commands of ExecuteCommand, for buttons and hotkeys
+const (
+	CommandStartRecording   = "start-recording"   // {"dir": optional folder}
+	CommandStopRecording    = "stop-recording"    // {}
+	CommandAddAnnotation    = "add-annotation"    // {"text": note, "user": optional}
+	CommandAcknowledgeAlarm = "acknowledge-alarm" // {"id": optional alarm, every unacknowledged one when 0, "user": optional, "note": optional}
+	CommandSilenceAlarms    = "silence-alarms"    // {"durationSeconds": optional, "user": optional}
+	CommandSnapshot         = "snapshot"          // {}
+)
+
+// defaultSilenceSeconds is how long silence-alarms silences without a duration
+const defaultSilenceSeconds = 120
+
+// CommandResult is what a command did
+type CommandResult struct {
+	Command string      `json:"command"`
+	Result  interface{} `json:"result,omitempty"` // The result of the binding the command runs, if any
+}
+
+// commandArgs are the arguments every command accepts; each uses some
+type commandArgs struct {
+	Dir             string  `json:"dir"`
+	Text            string  `json:"text"`
+	User            string  `json:"user"`
+	ID              int64   `json:"id"`
+	Note            string  `json:"note"`
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+// command runs one command
+type command func(a *App, args commandArgs) (interface{}, error)
+
+// commands holds every command of ExecuteCommand
+var commands = map[string]command{
+	CommandStartRecording: func(a *App, args commandArgs) (interface{}, error) {
+		return a.StartRecording(args.Dir)
+	},
+	CommandStopRecording: func(a *App, args commandArgs) (interface{}, error) {
+		return a.StopRecording()
+	},
+	CommandAddAnnotation: func(a *App, args commandArgs) (interface{}, error) {
+		return a.AddAnnotation(args.Text, args.User)
+	},
+	CommandAcknowledgeAlarm: (*App).acknowledgeCommand,
+	CommandSilenceAlarms: func(a *App, args commandArgs) (interface{}, error) {
+		if args.DurationSeconds == 0 {
+			args.DurationSeconds = defaultSilenceSeconds
+		}
+		if args.User == "" {
+			args.User = a.audit.actor()
+		}
+		return nil, a.SilenceAlarms(args.DurationSeconds, args.User)
+	},
+	CommandSnapshot: func(a *App, args commandArgs) (interface{}, error) {
+		return a.TakeSnapshot()
+	},
+}
+
+// acknowledgeCommand acknowledges one alarm, or every active alarm nobody
+// acknowledged, and returns the IDs acknowledged
+func (a *App) acknowledgeCommand(args commandArgs) (interface{}, error) {
+	if args.User == "" {
+		args.User = a.audit.actor()
+	}
+	ids := []int64{args.ID}
+	if args.ID == 0 {
+		ids = ids[:0]
+		for _, alarm := range a.GetActiveAlarms() {
+			if !alarm.Acknowledged {
+				ids = append(ids, alarm.ID)
+			}
+		}
+		if len(ids) == 0 {
+			return ids, fmt.Errorf("no alarm to acknowledge")
+		}
+	}
+	for _, id := range ids {
+		if err := a.AcknowledgeAlarm(id, args.User, args.Note); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// GetCommands returns the names of the commands ExecuteCommand runs
+func (a *App) GetCommands() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExecuteCommand runs an action by name, so buttons, keyboard shortcuts
+// and global hotkeys trigger them the same way. Each command runs the
+// binding of the action, with its role check and audit entry. An empty
+// user is the user logged in, or else the operator.
+func (a *App) ExecuteCommand(name string, args map[string]interface{}) (CommandResult, error) {
+	run, ok := commands[name]
+	if !ok {
+		return CommandResult{}, fmt.Errorf("unknown command '%s'", name)
+	}
+
+	var parsed commandArgs
+	if len(args) > 0 {
+		data, err := json.Marshal(args)
+		if err != nil {
+			return CommandResult{}, err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&parsed); err != nil {
+			return CommandResult{}, fmt.Errorf("invalid arguments of command '%s': %v", name, err)
+		}
+	}
+
+	result, err := run(a, parsed)
+	if err != nil {
+		appLog.Warnf("Command %s failed: %v", name, err)
+		return CommandResult{}, err
+	}
+	appLog.Debugf("Command %s executed", name)
+	return CommandResult{Command: name, Result: result}, nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// configWatchInterval is how often the configuration files are checked for
+// edits made outside the app
+const configWatchInterval = 2 * time.Second
+
+// ConfigFileChange is pushed to the frontend when a configuration file
+// edited outside the app was applied or rejected
+type ConfigFileChange struct {
+	File    string    `json:"file"`
+	Applied bool      `json:"applied"`
+	Error   string    `json:"error,omitempty"` // Why the file was rejected; the settings in force stay
+	At      time.Time `json:"at"`
+}
+
+// configReloader applies a configuration file edited outside the app. It
+// returns whether the file differs from what is in force, so the app's own
+// saves are not reported as edits, and an error if the file is invalid.
+type configReloader func(a *App) (bool, error)
+
+// watchedConfigs are the files power users and fleet tools edit directly
+var watchedConfigs = map[string]configReloader{
+	settingsFile:      (*App).reloadSettingsFile,
+	devicesFile:       (*App).reloadDevicesFile,
+	alarmProfilesFile: (*App).reloadAlarmProfilesFile,
+	healthRulesFile:   (*App).reloadHealthRulesFile,
+}
+
+// configStamp identifies a version of a file without reading it
+type configStamp struct {
+	modified time.Time
+	size     int64
+}
+
+// stampConfig returns the stamp of a configuration file of the current
+// profile, zero if it does not exist
+func stampConfig(name string) configStamp {
+	path, err := dataFilePath(name)
+	if err != nil {
+		return configStamp{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return configStamp{}
+	}
+	return configStamp{modified: info.ModTime(), size: info.Size()}
+}
+
+// sameJSON reports whether two values are stored the same. Comparing the
+// values themselves would tell apart times that only differ in the
+// monotonic clock reading, which files do not keep.
+func sameJSON(a, b interface{}) bool {
+	x, errX := json.Marshal(a)
+	y, errY := json.Marshal(b)
+	return errX == nil && errY == nil && bytes.Equal(x, y)
+}
+
+// configWatchLoop applies edits of the watched configuration files while
+// the app runs. A deleted file is ignored; the settings in force stay.
+func (a *App) configWatchLoop() {
+	stamps := make(map[string]configStamp, len(watchedConfigs))
+	for name := range watchedConfigs {
+		stamps[name] = stampConfig(name)
+	}
+
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-time.After(configWatchInterval):
+		}
+		if atRest.locked() {
+			continue
+		}
+
+		for name, reload := range watchedConfigs {
+			stamp := stampConfig(name)
+			if stamp == stamps[name] {
+				continue
+			}
+			stamps[name] = stamp
+			if stamp.modified.IsZero() {
+				continue
+			}
+
+			changed, err := reload(a)
+			if err != nil {
+				appLog.Errorf("Edited %s rejected: %v", name, err)
+				a.emit(EventConfigFile, ConfigFileChange{File: name, Error: err.Error(), At: time.Now()})
+				continue
+			}
+			if !changed {
+				continue
+			}
+			appLog.Infof("Edited %s applied", name)
+			a.audit.record("", AuditConfig, fmt.Sprintf("Edited %s applied", name))
+			a.emit(EventConfigFile, ConfigFileChange{File: name, Applied: true, At: time.Now()})
+		}
+	}
+}
+
+// reloadSettingsFile applies an edited settings file
+func (a *App) reloadSettingsFile() (bool, error) {
+	edited := &settingsStore{}
+	if err := edited.load(); err != nil {
+		return false, err
+	}
+	if err := edited.stored.validate(); err != nil {
+		return false, err
+	}
+
+	a.settings.mu.Lock()
+	if sameJSON(a.settings.stored, edited.stored) {
+		a.settings.mu.Unlock()
+		return false, nil
+	}
+	a.settings.stored = edited.stored
+	a.settings.settings = edited.settings
+	a.settings.mu.Unlock()
+
+	messages.set(edited.settings.Language)
+	a.emit(EventSettings, edited.settings)
+	return true, nil
+}
+
+// reloadDevicesFile applies an edited device registry, and the settings of
+// the connected device if they changed
+func (a *App) reloadDevicesFile() (bool, error) {
+	devices := make(map[string]*Device)
+	if err := loadJSONFile(devicesFile, &devices); err != nil {
+		return false, err
+	}
+	for id, device := range devices {
+		if device == nil || device.ID != id {
+			return false, fmt.Errorf("entry '%s' does not match its device ID", id)
+		}
+		if _, ok := a.findParser(device.Settings.Parser); !ok {
+			return false, fmt.Errorf("device %s: unknown parser '%s'", id, device.Settings.Parser)
+		}
+		if device.Settings.Calibrations == nil {
+			device.Settings.Calibrations = make(map[string]Calibration)
+		}
+		if device.Settings.ChannelNames == nil {
+			device.Settings.ChannelNames = make(map[string]string)
+		}
+	}
+
+	a.devices.mu.Lock()
+	if sameJSON(a.devices.devices, devices) {
+		a.devices.mu.Unlock()
+		return false, nil
+	}
+	var apply *Device
+	if previous, ok := a.devices.devices[a.devices.connected]; ok {
+		if device, ok := devices[a.devices.connected]; ok && !sameJSON(previous.Settings, device.Settings) {
+			copied := device.copy()
+			apply = &copied
+		}
+	}
+	a.devices.devices = devices
+	a.devices.mu.Unlock()
+
+	if apply != nil {
+		a.applyDeviceSettings(*apply)
+	}
+	return true, nil
+}
+
+// reloadAlarmProfilesFile applies edited alarm profiles. The rules in force
+// stay until the next session loads its profile.
+func (a *App) reloadAlarmProfilesFile() (bool, error) {
+	file := alarmProfileFile{Profiles: make(map[string][]AlarmProfile)}
+	if err := loadJSONFile(alarmProfilesFile, &file); err != nil {
+		return false, err
+	}
+	if file.Profiles == nil {
+		file.Profiles = make(map[string][]AlarmProfile)
+	}
+	for name, versions := range file.Profiles {
+		if len(versions) == 0 {
+			return false, fmt.Errorf("alarm profile '%s' has no versions", name)
+		}
+		for _, profile := range versions {
+			if profile.Name != name {
+				return false, fmt.Errorf("alarm profile '%s' holds a version named '%s'", name, profile.Name)
+			}
+			for _, rule := range profile.Rules {
+				if rule.Channel == "" {
+					return false, fmt.Errorf("alarm profile '%s' version %d has a rule without a channel", name, profile.Version)
+				}
+			}
+		}
+	}
+	if _, ok := file.Profiles[file.Startup]; file.Startup != "" && !ok {
+		return false, fmt.Errorf("startup alarm profile '%s' does not exist", file.Startup)
+	}
+
+	a.limits.mu.Lock()
+	defer a.limits.mu.Unlock()
+
+	if sameJSON(a.limits.file, file) {
+		return false, nil
+	}
+	a.limits.file = file
+	return true, nil
+}
+
+// reloadHealthRulesFile applies edited device health alarm rules
+func (a *App) reloadHealthRulesFile() (bool, error) {
+	rules := defaultHealthRules()
+	if err := loadJSONFile(healthRulesFile, &rules); err != nil {
+		return false, err
+	}
+	for channel, rule := range rules {
+		if _, ok := statusChannelUnits[channel]; !ok || rule.Channel != channel {
+			return false, fmt.Errorf("'%s' is not a device status channel", channel)
+		}
+		if rule.Hysteresis < 0 {
+			return false, fmt.Errorf("hysteresis of '%s' must not be negative", channel)
+		}
+	}
+
+	a.health.mu.Lock()
+	defer a.health.mu.Unlock()
+
+	if sameJSON(a.health.rules, rules) {
+		return false, nil
+	}
+	a.health.rules = rules
+	return true, nil
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// Connection states
+const (
+	ConnectionDisconnected = "disconnected"
+	ConnectionConnecting   = "connecting"
+	ConnectionConnected    = "connected"
+	ConnectionReconnecting = "reconnecting" // The device went away; the port is reopened while the session continues
+	ConnectionError        = "error"        // The last connection failed or was lost for good
+)
+
+// Reconnection timing
+const (
+	reconnectInterval = time.Second
+	reconnectTimeout  = 30 * time.Second // Give up and end the session after this long
+)
+
+// connectionTransitions lists the states each state may move to
+var connectionTransitions = map[string][]string{
+	ConnectionDisconnected: {ConnectionConnecting},
+	ConnectionConnecting:   {ConnectionConnected, ConnectionError, ConnectionDisconnected},
+	ConnectionConnected:    {ConnectionReconnecting, ConnectionError, ConnectionDisconnected},
+	ConnectionReconnecting: {ConnectionConnected, ConnectionError, ConnectionDisconnected},
+	ConnectionError:        {ConnectionConnecting, ConnectionDisconnected},
+}
+
+// ConnectionStatus is the state of the device connection, pushed as a
+// connection event on every change
+type ConnectionStatus struct {
+	State    string    `json:"state"`
+	Reason   string    `json:"reason,omitempty"` // Why the state last changed
+	Port     string    `json:"port,omitempty"`
+	BaudRate int       `json:"baudRate,omitempty"`
+	Since    time.Time `json:"since"`
+}
+
+// connectionManager owns the device connection: its state, the open port
+// and the subsystem the port is lent to. A single goroutine runs the commands
+// sent on its channel one at a time, the reads of the sensor reader among
+// them, so the port is never closed or replaced under a read and the bindings
+// never see a port and a state that do not belong together. Only the
+// commands touch the fields after the channel.
+type connectionManager struct {
+	commands chan func()
+	status   ConnectionStatus
+	port     serial.Port // Open while connected to a device; nil for the simulator
+	owner    string      // Subsystem the port is lent to, empty while the sensor reader has it
+}
+
+// newConnectionManager starts the command loop, disconnected
+func newConnectionManager() *connectionManager {
+	m := &connectionManager{
+		commands: make(chan func()),
+		status:   ConnectionStatus{State: ConnectionDisconnected, Since: time.Now()},
+	}
+	go m.run()
+	return m
+}
+
+// run executes the commands until the process exits. A command that panics
+// is reported and the loop carries on with the next.
+func (m *connectionManager) run() {
+	for command := range m.commands {
+		safely("connection command", command)
+	}
+}
+
+// do runs a command on the loop and waits for it to finish
+func (m *connectionManager) do(command func()) {
+	done := make(chan struct{})
+	m.commands <- func() {
+		defer close(done)
+		command()
+	}
+	<-done
+}
+
+// connected reports whether a device or the simulator is attached and its
+// session is running, including while a lost device is being reopened
+func (m *connectionManager) connected() bool {
+	var connected bool
+	m.do(func() {
+		connected = m.status.State == ConnectionConnected || m.status.State == ConnectionReconnecting
+	})
+	return connected
+}
+
+// openPort returns the port of a connected device, nil otherwise
+func (m *connectionManager) openPort() serial.Port {
+	var port serial.Port
+	m.do(func() {
+		if m.status.State == ConnectionConnected {
+			port = m.port
+		}
+	})
+	return port
+}
+
+// current returns the status and the port together
+func (m *connectionManager) current() (ConnectionStatus, serial.Port) {
+	var status ConnectionStatus
+	var port serial.Port
+	m.do(func() { status, port = m.status, m.port })
+	return status, port
+}
+
+// read reads from the port for the sensor reader, unless the port was lent
+// to another subsystem or is no longer the open port of the connection
+func (m *connectionManager) read(port serial.Port, buf []byte) (int, error) {
+	var n int
+	var err error
+	m.do(func() {
+		switch {
+		case m.owner != "":
+			err = errPortTaken
+		case m.status.State != ConnectionConnected || m.port != port:
+			err = errPortReplaced
+		default:
+			port.SetReadTimeout(sensorReadTimeout)
+			n, err = port.Read(buf)
+		}
+	})
+	return n, err
+}
+
+// take lends the open port to a subsystem, such as the firmware updater,
+// which then talks to the device directly until it releases it
+func (m *connectionManager) take(owner string) error {
+	var err error
+	m.do(func() {
+		switch {
+		case m.owner != "":
+			err = fmt.Errorf("serial port is in use by the %s", m.owner)
+		case m.status.State != ConnectionConnected || m.port == nil:
+			err = trError(MsgNotConnected)
+		default:
+			m.owner = owner
+		}
+	})
+	if err == nil {
+		serialLog.Infof("Serial port taken over by the %s", owner)
+	}
+	return err
+}
+
+// release hands the port back to the sensor reader
+func (m *connectionManager) release(owner string) {
+	released := false
+	m.do(func() {
+		if m.owner == owner {
+			m.owner = ""
+			released = true
+		}
+	})
+	if released {
+		serialLog.Infof("Serial port released by the %s", owner)
+	}
+}
+
+// ownerName returns the subsystem the port is lent to, empty while the reader has it
+func (m *connectionManager) ownerName() string {
+	var owner string
+	m.do(func() { owner = m.owner })
+	return owner
+}
+
+// move changes the state and the port, returning the state moved from and
+// the port that was open; the caller runs it on the loop
+func (m *connectionManager) move(to, reason string, port serial.Port) (string, serial.Port, error) {
+	from := m.status.State
+	if !connectionAllowed(from, to) {
+		return from, nil, fmt.Errorf("connection cannot go from %s to %s", from, to)
+	}
+	previous := m.port
+	m.status.State = to
+	m.status.Reason = reason
+	m.status.Since = time.Now()
+	m.port = port
+	return from, previous, nil
+}
+
+// connectionAllowed reports whether the state machine may move from one state to another
+func connectionAllowed(from, to string) bool {
+	for _, next := range connectionTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// beginConnection moves to connecting for a port, failing if a connection
+// is already open or being opened
+func (a *App) beginConnection(portName string, baudRate int) error {
+	var from string
+	var status ConnectionStatus
+	var err error
+	a.conn.do(func() {
+		from = a.conn.status.State
+		if !connectionAllowed(from, ConnectionConnecting) {
+			err = trError(MsgAlreadyConnected)
+			return
+		}
+		a.conn.status = ConnectionStatus{State: ConnectionConnecting, Port: portName, BaudRate: baudRate, Since: time.Now()}
+		a.conn.port = nil
+		status = a.conn.status
+	})
+	if err != nil {
+		return err
+	}
+
+	a.connectionChanged(from, status)
+	return nil
+}
+
+// setConnection moves to a new state with the given open port, which is
+// nil for every state but connected
+func (a *App) setConnection(to, reason string, port serial.Port) error {
+	var from string
+	var status ConnectionStatus
+	var err error
+	a.conn.do(func() {
+		from, _, err = a.conn.move(to, reason, port)
+		status = a.conn.status
+	})
+	if err != nil {
+		return err
+	}
+
+	a.connectionChanged(from, status)
+	return nil
+}
+
+// closeConnection moves to a state without a port and closes the port that
+// was open, on the loop so no read is in flight on it. While the port is lent
+// to another subsystem it changes nothing and returns the subsystem.
+func (a *App) closeConnection(to, reason string) (string, error) {
+	var from, owner string
+	var status ConnectionStatus
+	var err error
+	a.conn.do(func() {
+		if owner = a.conn.owner; owner != "" {
+			return
+		}
+		var port serial.Port
+		if from, port, err = a.conn.move(to, reason, nil); err != nil {
+			return
+		}
+		status = a.conn.status
+		if port != nil {
+			if closeErr := port.Close(); closeErr != nil {
+				serialLog.Errorf("Error closing serial port: %v", closeErr)
+			}
+		}
+	})
+	if owner != "" || err != nil {
+		return owner, err
+	}
+
+	a.connectionChanged(from, status)
+	return "", nil
+}
+
+// connectionChanged logs a state change and pushes it to the frontend
+func (a *App) connectionChanged(from string, status ConnectionStatus) {
+	if status.Reason != "" {
+		serialLog.Infof("Connection %s -> %s: %s", from, status.State, status.Reason)
+	} else {
+		serialLog.Infof("Connection %s -> %s", from, status.State)
+	}
+	a.emit(EventConnection, status)
+}
+
+// connectionLost closes a port that failed, or went stale while the system
+// slept, and starts reopening it. The session records a gap from since
+// until the device is back.
+func (a *App) connectionLost(port serial.Port, reason string, since time.Time) {
+	var from string
+	var status ConnectionStatus
+	lost := false
+	a.conn.do(func() {
+		// The port may have been closed, and another opened, since it failed;
+		// a subsystem borrowing it reports its own errors
+		if a.conn.port != port || a.conn.owner != "" {
+			return
+		}
+		var err error
+		if from, _, err = a.conn.move(ConnectionReconnecting, reason, nil); err != nil {
+			return
+		}
+		status = a.conn.status
+		port.Close()
+		lost = true
+	})
+	if lost {
+		a.connectionChanged(from, status)
+		a.sessions.beginGap(since, reason)
+	}
+}
+
+// reconnect tries to reopen the port of a lost device. After
+// reconnectTimeout it gives up, ends the session and reports an error.
+func (a *App) reconnect(status ConnectionStatus) {
+	if time.Since(status.Since) >= reconnectTimeout {
+		reason := fmt.Sprintf("device did not come back within %v", reconnectTimeout)
+		if a.setConnection(ConnectionError, reason, nil) == nil {
+			a.closeSession()
+			a.audit.record(auditSystemUser, AuditDisconnect, fmt.Sprintf("Connection to %s lost", status.Port))
+		}
+		return
+	}
+
+	time.Sleep(reconnectInterval)
+	port, err := openSerialPort(status.Port, serialMode(status.BaudRate))
+	if err != nil {
+		return
+	}
+	port = a.sniffer.wrap(port)
+	if err := a.setConnection(ConnectionConnected, "device reconnected", port); err != nil {
+		// Disconnected by the user meanwhile
+		port.Close()
+		return
+	}
+	a.sessions.endGap(time.Now())
+	a.audit.record(auditSystemUser, AuditConnect, fmt.Sprintf("Reconnected to %s", status.Port))
+}
+
+// GetConnectionStatus returns the state of the device connection
+func (a *App) GetConnectionStatus() ConnectionStatus {
+	status, _ := a.conn.current()
+	return status
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// loopbackDevices opens a loopback for every port the app opens, with a
+// device on the far end that streams lines until the link closes
+type loopbackDevices struct {
+	mu    sync.Mutex
+	ports []*loopbackPort // App ends, in the order opened
+	links []*loopbackPort // Device ends
+}
+
+// open stands in for serial.Open
+func (d *loopbackDevices) open(name string, mode *serial.Mode) (serial.Port, error) {
+	app, device := newLoopbackPair()
+	d.mu.Lock()
+	d.ports = append(d.ports, app)
+	d.links = append(d.links, device)
+	d.mu.Unlock()
+
+	answering := newSelfTestDevice(device)
+	go func() {
+		defer answering.stop()
+		for i := 0; ; i++ {
+			raw := selfTestRaw(i % selfTestLines)
+			line := fmt.Sprintf("0x%08X,0x%08X,0x%08X\r\n", uint32(raw[0]), uint32(raw[1]), uint32(raw[2]))
+			if _, err := device.Write([]byte(line)); err != nil {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	return app, nil
+}
+
+// unplug closes the link opened last, as if the cable was pulled
+func (d *loopbackDevices) unplug() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.links) > 0 {
+		d.links[len(d.links)-1].Close()
+	}
+}
+
+// TestConnectionRace connects, disconnects and loses the device from
+// several goroutines at once, while the serial reader reconnects. Run with
+// -race; afterwards the connection must be in a valid state and every port
+// opened must be closed.
+func TestConnectionRace(t *testing.T) {
+	devices := &loopbackDevices{}
+	defer func(dir string, open func(string, *serial.Mode) (serial.Port, error)) {
+		portableDir, openSerialPort = dir, open
+	}(portableDir, openSerialPort)
+	portableDir = t.TempDir()
+	openSerialPort = devices.open
+
+	a := NewApp()
+	var wg sync.WaitGroup
+	// run repeats an action every interval until stop closes
+	run := func(interval time.Duration, stop chan struct{}, action func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-time.After(interval):
+				}
+				action()
+			}
+		}()
+	}
+	stop, slow := make(chan struct{}), make(chan struct{})
+	run(7*time.Millisecond, stop, func() { a.connect(selfTestPortName, selfTestBaudRate) })
+	run(11*time.Millisecond, stop, func() { a.connect(selfTestPortName, selfTestBaudRate) })
+	run(3*time.Millisecond, stop, func() {
+		a.ReadSensorData()
+		a.GetConnectionStatus()
+		a.IsConnected()
+	})
+	// Disconnects come seldom enough at first for some reconnects to finish
+	// after reconnectInterval, then often, up to the shutdown
+	run(1700*time.Millisecond, slow, func() { a.disconnect() })
+	run(300*time.Millisecond, slow, devices.unplug)
+	time.Sleep(4 * time.Second)
+	close(slow)
+	run(13*time.Millisecond, stop, func() { a.disconnect() })
+	run(50*time.Millisecond, stop, devices.unplug)
+	time.Sleep(4 * time.Second)
+	close(stop)
+	wg.Wait()
+
+	status, port := a.conn.current()
+	if (status.State == ConnectionConnected) != (port != nil) {
+		t.Errorf("connection is %s with port %v", status.State, port)
+	}
+	a.shutdown(context.Background())
+	if status := a.GetConnectionStatus(); status.State == ConnectionConnected || status.State == ConnectionConnecting {
+		t.Errorf("connection is %s after shutdown", status.State)
+	}
+
+	devices.mu.Lock()
+	defer devices.mu.Unlock()
+	if len(devices.ports) < 2 {
+		t.Fatalf("only %d ports opened", len(devices.ports))
+	}
+	for i, port := range devices.ports {
+		port.rx.mu.Lock()
+		closed := port.rx.closed
+		port.rx.mu.Unlock()
+		if !closed {
+			t.Errorf("port %d of %d left open", i+1, len(devices.ports))
+		}
+	}
+	t.Logf("%d ports opened and closed", len(devices.ports))
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// consoleHistoryFile stores the commands typed into the console
+const consoleHistoryFile = "console_history.json"
+
+// Console limits
+const (
+	consoleGateOwner   = "console"
+	maxConsoleHistory  = 200                    // Commands kept for recall
+	maxConsoleLog      = 2000                   // Output entries kept for scrollback
+	consoleLineTimeout = 200 * time.Millisecond // A partial line is shown after this long without data
+)
+
+// Console display modes
+const (
+	ConsoleModeLine = "line" // Text split into lines
+	ConsoleModeHex  = "hex"  // Every chunk as hex bytes; input is parsed as hex too
+)
+
+// Line endings appended to console input in line mode
+var consoleLineEndings = map[string]string{
+	"none": "",
+	"lf":   "\n",
+	"cr":   "\r",
+	"crlf": "\r\n",
+}
+
+// ConsoleOptions configures the device console
+type ConsoleOptions struct {
+	Mode       string `json:"mode"`       // line or hex
+	LineEnding string `json:"lineEnding"` // none, lf, cr or crlf
+}
+
+// ConsoleEntry is a piece of console traffic, pushed to the frontend as it happens
+type ConsoleEntry struct {
+	Direction string    `json:"direction"` // rx from the device, tx to it
+	Text      string    `json:"text"`      // Text or hex bytes, depending on the mode
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// deviceConsole is a raw terminal to the device, bypassing the sensor parser
+type deviceConsole struct {
+	mu      sync.Mutex
+	running bool
+	stop    chan struct{}
+	options ConsoleOptions
+	partial []byte // Received text not yet terminated by a newline
+	log     []ConsoleEntry
+	history []string // Sent commands, oldest first
+}
+
+// newDeviceConsole creates a stopped console and loads the command history
+func newDeviceConsole() *deviceConsole {
+	c := &deviceConsole{history: make([]string, 0)}
+	if err := loadJSONFile(consoleHistoryFile, &c.history); err != nil {
+		deviceLog.Errorf("Error loading console history: %v", err)
+	}
+	return c
+}
+
+// checkConsoleOptions validates the console options
+func checkConsoleOptions(options ConsoleOptions) error {
+	if options.Mode != ConsoleModeLine && options.Mode != ConsoleModeHex {
+		return fmt.Errorf("unknown console mode '%s'", options.Mode)
+	}
+	if _, ok := consoleLineEndings[options.LineEnding]; !ok {
+		return fmt.Errorf("unknown line ending '%s'", options.LineEnding)
+	}
+	return nil
+}
+
+// appendEntry adds an entry to the scrollback; the caller holds the lock
+func (c *deviceConsole) appendEntry(entry ConsoleEntry) {
+	c.log = append(c.log, entry)
+	if len(c.log) > maxConsoleLog {
+		c.log = append(make([]ConsoleEntry, 0, maxConsoleLog), c.log[len(c.log)-maxConsoleLog:]...)
+	}
+}
+
+// received turns incoming bytes into console entries. In line mode complete
+// lines are returned and the rest is held back until the line ends or flush
+// is set.
+func (c *deviceConsole) received(data []byte, flush bool, now time.Time) []ConsoleEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var entries []ConsoleEntry
+	if c.options.Mode == ConsoleModeHex {
+		if len(data) > 0 {
+			entries = append(entries, ConsoleEntry{Direction: "rx", Text: formatHexBytes(data), Timestamp: now})
+		}
+	} else {
+		c.partial = append(c.partial, data...)
+		for {
+			i := strings.IndexByte(string(c.partial), '\n')
+			if i < 0 {
+				break
+			}
+			line := strings.TrimRight(string(c.partial[:i]), "\r")
+			c.partial = c.partial[i+1:]
+			entries = append(entries, ConsoleEntry{Direction: "rx", Text: line, Timestamp: now})
+		}
+		if flush && len(c.partial) > 0 {
+			entries = append(entries, ConsoleEntry{Direction: "rx", Text: string(c.partial), Timestamp: now})
+			c.partial = nil
+		}
+	}
+	for _, entry := range entries {
+		c.appendEntry(entry)
+	}
+	return entries
+}
+
+// formatHexBytes renders bytes as space-separated hex pairs
+func formatHexBytes(data []byte) string {
+	parts := make([]string, len(data))
+	for i, b := range data {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseHexBytes reads space-separated hex bytes, with or without 0x prefixes
+func parseHexBytes(text string) ([]byte, error) {
+	var result []byte
+	for _, field := range strings.Fields(text) {
+		field = strings.TrimPrefix(strings.TrimPrefix(field, "0x"), "0X")
+		if len(field)%2 != 0 {
+			field = "0" + field
+		}
+		b, err := hex.DecodeString(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex bytes '%s'", field)
+		}
+		result = append(result, b...)
+	}
+	return result, nil
+}
+
+// consoleReader forwards device output to the frontend until the console stops
+func (a *App) consoleReader(stop chan struct{}) {
+	port := a.conn.openPort()
+	buf := make([]byte, 256)
+	lastData := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		port.SetReadTimeout(50 * time.Millisecond)
+		n, err := port.Read(buf)
+		if err != nil {
+			deviceLog.Errorf("Error reading console: %v", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		now := time.Now()
+		if n > 0 {
+			lastData = now
+		}
+		for _, entry := range a.console.received(buf[:n], now.Sub(lastData) >= consoleLineTimeout, now) {
+			a.emit(EventConsoleOutput, entry)
+		}
+	}
+}
+
+// StartConsole takes the serial port over for a raw terminal to the device.
+// The sensor stream pauses until StopConsole; device output is pushed with
+// console-output events.
+func (a *App) StartConsole(options ConsoleOptions) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+	telemetry.count(FeatureConsole)
+
+	if err := checkConsoleOptions(options); err != nil {
+		return err
+	}
+	if a.conn.openPort() == nil {
+		return trError(MsgNotConnected)
+	}
+
+	a.console.mu.Lock()
+	defer a.console.mu.Unlock()
+
+	if a.console.running {
+		return fmt.Errorf("console is already running")
+	}
+	if err := a.conn.take(consoleGateOwner); err != nil {
+		return err
+	}
+	a.console.running = true
+	a.console.options = options
+	a.console.partial = nil
+	a.console.stop = make(chan struct{})
+	stop := a.console.stop
+	goSafe("console reader", func() { a.consoleReader(stop) })
+	return nil
+}
+
+// StopConsole ends the terminal and hands the port back to the sensor stream
+func (a *App) StopConsole() error {
+	a.console.mu.Lock()
+	defer a.console.mu.Unlock()
+
+	if !a.console.running {
+		return fmt.Errorf("console is not running")
+	}
+	close(a.console.stop)
+	a.console.running = false
+	a.conn.release(consoleGateOwner)
+	return nil
+}
+
+// SetConsoleOptions changes the display mode and line ending of the running console
+func (a *App) SetConsoleOptions(options ConsoleOptions) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := checkConsoleOptions(options); err != nil {
+		return err
+	}
+
+	a.console.mu.Lock()
+	defer a.console.mu.Unlock()
+
+	a.console.options = options
+	a.console.partial = nil
+	return nil
+}
+
+// ConsoleWrite sends input to the device: text with the configured line
+// ending in line mode, hex bytes in hex mode. It is added to the history.
+func (a *App) ConsoleWrite(input string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.console.mu.Lock()
+	if !a.console.running {
+		a.console.mu.Unlock()
+		return fmt.Errorf("console is not running")
+	}
+	options := a.console.options
+	a.console.mu.Unlock()
+
+	var data []byte
+	if options.Mode == ConsoleModeHex {
+		var err error
+		if data, err = parseHexBytes(input); err != nil {
+			return err
+		}
+	} else {
+		data = []byte(input + consoleLineEndings[options.LineEnding])
+	}
+	port := a.conn.openPort()
+	if port == nil {
+		return trError(MsgNotConnected)
+	}
+	if _, err := port.Write(data); err != nil {
+		return fmt.Errorf("failed to write to device: %v", err)
+	}
+
+	entry := ConsoleEntry{Direction: "tx", Text: input, Timestamp: time.Now()}
+	a.console.mu.Lock()
+	a.console.appendEntry(entry)
+	if input != "" && (len(a.console.history) == 0 || a.console.history[len(a.console.history)-1] != input) {
+		a.console.history = append(a.console.history, input)
+		if len(a.console.history) > maxConsoleHistory {
+			a.console.history = a.console.history[len(a.console.history)-maxConsoleHistory:]
+		}
+		if err := saveJSONFile(consoleHistoryFile, a.console.history); err != nil {
+			deviceLog.Errorf("Error saving console history: %v", err)
+		}
+	}
+	a.console.mu.Unlock()
+
+	a.emit(EventConsoleOutput, entry)
+	return nil
+}
+
+// GetConsoleLog returns the console scrollback, oldest first
+func (a *App) GetConsoleLog() []ConsoleEntry {
+	a.console.mu.Lock()
+	defer a.console.mu.Unlock()
+
+	return append([]ConsoleEntry{}, a.console.log...)
+}
+
+// ClearConsoleLog empties the console scrollback
+func (a *App) ClearConsoleLog() {
+	a.console.mu.Lock()
+	defer a.console.mu.Unlock()
+
+	a.console.log = nil
+}
+
+// GetConsoleHistory returns the commands sent from the console, oldest first
+func (a *App) GetConsoleHistory() []string {
+	a.console.mu.Lock()
+	defer a.console.mu.Unlock()
+
+	return append([]string{}, a.console.history...)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Correlation analysis limits
+const (
+	correlationMaxRate    = 50.0 // Grid rate cap in Hz, bounds the cost of the lag scan
+	correlationMinSamples = 10   // Overlapping readings needed for a meaningful coefficient
+)
+
+// CorrelationResult compares two channels over a time window
+type CorrelationResult struct {
+	ChannelA      string  `json:"channelA"`
+	ChannelB      string  `json:"channelB"`
+	WindowSeconds float64 `json:"windowSeconds"`
+	Samples       int     `json:"samples"`     // Grid points the channels were compared on
+	Pearson       float64 `json:"pearson"`     // Correlation without any shift
+	BestLag       float64 `json:"bestLag"`     // Shift in seconds of the strongest correlation; positive when B follows A
+	BestPearson   float64 `json:"bestPearson"` // Correlation at the best lag
+}
+
+// pearson returns the correlation coefficient of two equally long series,
+// or 0 when either of them is constant
+func pearson(a, b []float64) float64 {
+	n := float64(len(a))
+	if n == 0 {
+		return 0
+	}
+
+	sumA, sumB := 0.0, 0.0
+	for i := range a {
+		sumA += a[i]
+		sumB += b[i]
+	}
+	meanA, meanB := sumA/n, sumB/n
+
+	cov, varA, varB := 0.0, 0.0, 0.0
+	for i := range a {
+		da, db := a[i]-meanA, b[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varA*varB)
+}
+
+// laggedPearson correlates a[i] with b[i+lag] over the part where both exist
+func laggedPearson(a, b []float64, lag int) float64 {
+	if lag >= 0 {
+		return pearson(a[:len(a)-lag], b[lag:])
+	}
+	return pearson(a[-lag:], b[:len(b)+lag])
+}
+
+// gridRate picks the common grid rate from the denser of the two channels
+func gridRate(a, b []timedValue) float64 {
+	rate := 0.0
+	for _, values := range [][]timedValue{a, b} {
+		span := values[len(values)-1].t.Sub(values[0].t).Seconds()
+		if span > 0 {
+			rate = math.Max(rate, float64(len(values)-1)/span)
+		}
+	}
+	return math.Min(rate, correlationMaxRate)
+}
+
+// GetCorrelation computes the Pearson correlation of two channels over the
+// last windowSeconds and the lag within ±maxLagSeconds at which they agree
+// best. Both channels are interpolated onto a common grid first, so
+// channels updated at different rates can be compared.
+func (a *App) GetCorrelation(channelA, channelB string, windowSeconds, maxLagSeconds float64) (CorrelationResult, error) {
+	span := secondsToDuration(windowSeconds)
+	if span <= 0 || span > historyRetention {
+		return CorrelationResult{}, fmt.Errorf("window must be between 0 and %.0f s, got %.2f s",
+			historyRetention.Seconds(), windowSeconds)
+	}
+	if maxLagSeconds < 0 || maxLagSeconds >= windowSeconds/2 {
+		return CorrelationResult{}, fmt.Errorf("maximum lag must be between 0 and half the window, got %.2f s", maxLagSeconds)
+	}
+	for _, channel := range []string{channelA, channelB} {
+		if !a.history.has(channel) {
+			return CorrelationResult{}, fmt.Errorf("unknown channel '%s'", channel)
+		}
+	}
+
+	valuesA := a.history.recent(channelA, span)
+	valuesB := a.history.recent(channelB, span)
+	if len(valuesA) < 2 || len(valuesB) < 2 {
+		return CorrelationResult{}, fmt.Errorf("not enough readings in the last %.0f s", windowSeconds)
+	}
+
+	start, end := valuesA[0].t, valuesA[len(valuesA)-1].t
+	if valuesB[0].t.After(start) {
+		start = valuesB[0].t
+	}
+	if valuesB[len(valuesB)-1].t.Before(end) {
+		end = valuesB[len(valuesB)-1].t
+	}
+	rate := gridRate(valuesA, valuesB)
+	if !end.After(start) || rate == 0 {
+		return CorrelationResult{}, fmt.Errorf("the channels don't overlap in the last %.0f s", windowSeconds)
+	}
+
+	gridA := resampleBetween(valuesA, start, end, rate)
+	gridB := resampleBetween(valuesB, start, end, rate)
+	n := len(gridA)
+	if len(gridB) < n {
+		n = len(gridB)
+	}
+	gridA, gridB = gridA[:n], gridB[:n]
+
+	maxLag := int(maxLagSeconds * rate)
+	if n-maxLag < correlationMinSamples {
+		return CorrelationResult{}, fmt.Errorf("%d overlapping samples are too few for a %.2f s lag", n, maxLagSeconds)
+	}
+
+	result := CorrelationResult{
+		ChannelA:      channelA,
+		ChannelB:      channelB,
+		WindowSeconds: windowSeconds,
+		Samples:       n,
+		Pearson:       pearson(gridA, gridB),
+	}
+	result.BestPearson = result.Pearson
+	for lag := -maxLag; lag <= maxLag; lag++ {
+		r := laggedPearson(gridA, gridB, lag)
+		if math.Abs(r) > math.Abs(result.BestPearson) {
+			result.BestPearson = r
+			result.BestLag = float64(lag) / rate
+		}
+	}
+	return result, nil
+}
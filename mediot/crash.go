@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Crash handling
+const (
+	crashDirName       = "crashes"
+	maxCrashReports    = 20                // Older reports are deleted
+	crashReportPeriod  = time.Minute       // At most one report per goroutine in this time
+	loopRestartDelay   = 1 * time.Second   // Before a crashed background loop is restarted
+	crashTimestampForm = "20060102-150405" // In the report file names
+)
+
+// crashReporter writes crash reports without flooding the disk when the
+// same code panics on every sample
+type crashReporter struct {
+	mu         sync.Mutex
+	last       map[string]time.Time // Last report by goroutine name
+	suppressed map[string]int       // Panics since then that were not reported
+}
+
+// crashes is shared by every recovered goroutine
+var crashes = &crashReporter{last: make(map[string]time.Time), suppressed: make(map[string]int)}
+
+// report logs a recovered panic with its stack and writes a crash report
+// into the crashes directory
+func (c *crashReporter) report(name string, value interface{}, stack []byte) {
+	telemetry.crash()
+
+	c.mu.Lock()
+	if time.Since(c.last[name]) < crashReportPeriod {
+		c.suppressed[name]++
+		c.mu.Unlock()
+		return
+	}
+	suppressed := c.suppressed[name]
+	c.last[name] = time.Now()
+	c.suppressed[name] = 0
+	c.mu.Unlock()
+
+	appLog.Errorf("Panic in %s: %v (%d more since the last report)\n%s", name, value, suppressed, stack)
+
+	dir, err := appDataDir()
+	if err != nil {
+		return
+	}
+	dir = filepath.Join(dir, crashDirName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		appLog.Errorf("Error creating crash report directory: %v", err)
+		return
+	}
+	report := fmt.Sprintf("mediot %s (%s) crash in %s at %s\n\npanic: %v\n\n%s",
+		appVersion, appCommit, name, time.Now().Format(time.RFC3339), value, stack)
+	path := filepath.Join(dir, fmt.Sprintf("crash-%s-%s.txt", time.Now().Format(crashTimestampForm), strings.ReplaceAll(name, " ", "-")))
+	if err := os.WriteFile(path, []byte(report), 0600); err != nil {
+		appLog.Errorf("Error writing crash report: %v", err)
+		return
+	}
+	pruneCrashReports(dir)
+}
+
+// pruneCrashReports deletes the oldest reports beyond maxCrashReports
+func pruneCrashReports(dir string) {
+	paths, err := filepath.Glob(filepath.Join(dir, "crash-*.txt"))
+	if err != nil || len(paths) <= maxCrashReports {
+		return
+	}
+	// The timestamp in the names sorts them oldest first
+	for _, path := range paths[:len(paths)-maxCrashReports] {
+		os.Remove(path)
+	}
+}
+
+// safely runs fn and recovers a panic in it, reporting it under the given
+// name. It returns whether fn panicked.
+func safely(name string, fn func()) (panicked bool) {
+	defer func() {
+		if value := recover(); value != nil {
+			panicked = true
+			crashes.report(name, value, debug.Stack())
+		}
+	}()
+	fn()
+	return false
+}
+
+// goSafe runs fn in a goroutine whose panic is reported instead of
+// terminating the app
+func goSafe(name string, fn func()) {
+	go safely(name, fn)
+}
+
+// goLoop runs a background loop in a goroutine and restarts it after a
+// panic, until the app shuts down. The loop returns once quit is closed.
+func (a *App) goLoop(name string, loop func()) {
+	a.loops.Add(1)
+	go func() {
+		defer a.loops.Done()
+		for safely(name, loop) {
+			select {
+			case <-a.quit:
+				return
+			case <-time.After(loopRestartDelay):
+			}
+			appLog.Warnf("Restarting %s after a panic", name)
+		}
+	}()
+}
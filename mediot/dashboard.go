@@ -0,0 +1,138 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// dashboardInterval is how often the overview is pushed to the frontend
+const dashboardInterval = time.Second
+
+// Device states shown on the overview
+const (
+	DeviceStatusStreaming = "streaming" // Samples are arriving
+	DeviceStatusNoData    = "no-data"   // Connected but the watchdog alarm is raised
+	DeviceStatusBusy      = "busy"      // The port is taken over, e.g. by a firmware update
+)
+
+// DeviceOverview is the central-station tile of one connected device
+type DeviceOverview struct {
+	Device         string             `json:"device"` // Device registry ID
+	Name           string             `json:"name"`
+	Port           string             `json:"port"`
+	SessionID      int64              `json:"sessionId"`
+	Status         string             `json:"status"`
+	LastSampleAt   time.Time          `json:"lastSampleAt"`
+	Values         map[string]float64 `json:"values"`         // Latest value of every channel
+	AlarmSeverity  string             `json:"alarmSeverity"`  // Highest active alarm severity
+	ActiveAlarms   int                `json:"activeAlarms"`   // Including acknowledged ones
+	Unacknowledged int                `json:"unacknowledged"` // Active alarms nobody acknowledged
+	Health         []HealthReading    `json:"health"`
+}
+
+// DashboardSnapshot is the overview of every connected device
+type DashboardSnapshot struct {
+	Devices   []DeviceOverview `json:"devices"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+// latestSample keeps a copy of the last sample for the overview. It runs
+// last in the pipeline so the copy includes every derived channel.
+type latestSample struct {
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// newLatestSample creates the stage with no sample seen
+func newLatestSample() *latestSample {
+	return &latestSample{values: make(map[string]float64)}
+}
+
+func (l *latestSample) process(sample *SensorData) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	sample.forEachChannel(func(name string, value float64) {
+		l.values[name] = value
+	})
+}
+
+// reset forgets the values of a disconnected device
+func (l *latestSample) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.values = make(map[string]float64)
+}
+
+// snapshot returns a copy of the latest values
+func (l *latestSample) snapshot() map[string]float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make(map[string]float64, len(l.values))
+	for name, value := range l.values {
+		result[name] = value
+	}
+	return result
+}
+
+// GetDashboard returns the latest values, status and alarm state of every
+// connected device in one call. The same snapshot is pushed every second
+// with dashboard events.
+func (a *App) GetDashboard() DashboardSnapshot {
+	snapshot := DashboardSnapshot{Devices: make([]DeviceOverview, 0, 1), UpdatedAt: time.Now()}
+	session := a.currentSession()
+	if !a.conn.connected() || session == nil {
+		return snapshot
+	}
+
+	overview := DeviceOverview{
+		Device:        session.Device,
+		Name:          session.Device,
+		Port:          session.Port,
+		SessionID:     session.ID,
+		Status:        DeviceStatusStreaming,
+		LastSampleAt:  a.clock.lastSample(),
+		Values:        a.latest.snapshot(),
+		AlarmSeverity: SeverityNormal,
+		Health:        a.GetDeviceHealth(),
+	}
+	if device := a.GetConnectedDevice(); device != nil {
+		overview.Name = device.Name
+	}
+
+	for _, alarm := range a.GetActiveAlarms() {
+		overview.ActiveAlarms++
+		if !alarm.Acknowledged {
+			overview.Unacknowledged++
+		}
+		if severityRank[alarm.Severity] > severityRank[overview.AlarmSeverity] {
+			overview.AlarmSeverity = alarm.Severity
+		}
+		if alarm.Channel == ChannelNoData {
+			overview.Status = DeviceStatusNoData
+		}
+	}
+	if a.conn.ownerName() != "" {
+		overview.Status = DeviceStatusBusy
+	}
+
+	snapshot.Devices = append(snapshot.Devices, overview)
+	return snapshot
+}
+
+// dashboardLoop pushes the overview to the frontend while a device is connected
+func (a *App) dashboardLoop() {
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-time.After(dashboardInterval):
+		}
+
+		if a.conn.connected() {
+			a.emit(EventDashboard, a.GetDashboard())
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// dataKind tells what a file of the data directory holds, which decides
+// whether it is backed up and encrypted
+type dataKind int
+
+// Kinds of data files
+const (
+	dataConfig    dataKind = iota // Settings, in every backup
+	dataPatient                   // Patient data, in backups on request
+	dataLocal                     // State and keys of this computer, never backed up
+	dataRecording                 // Patient data in the recording folder, by name pattern
+)
+
+// dataFiles registers every file of the data directory, and the patterns
+// of the files written to the recording folder. A feature storing a new
+// file adds it here; loadJSONFile and saveJSONFile refuse names that are
+// missing, backups are built from this list, and patient data is
+// encrypted at rest.
+var dataFiles = map[string]dataKind{
+	alarmProfilesFile:      dataConfig,
+	appLockFile:            dataConfig,
+	audioFile:              dataConfig,
+	calibrationFile:        dataConfig,
+	certificatesFile:       dataConfig,
+	devicesFile:            dataConfig,
+	emailFile:              dataConfig,
+	escalationFile:         dataConfig,
+	healthRulesFile:        dataConfig,
+	loggingFile:            dataConfig,
+	messagingFile:          dataConfig,
+	mqttFile:               dataConfig,
+	notificationsFile:      dataConfig,
+	pluginsFile:            dataConfig,
+	profileFile:            dataConfig,
+	recordingSchedulesFile: dataConfig,
+	settingsFile:           dataConfig,
+	unitsFile:              dataConfig,
+	usersFile:              dataConfig,
+	watchdogFile:           dataConfig,
+
+	alarmLogFile:   dataPatient,
+	pseudonymsFile: dataPatient,
+	sessionsFile:   dataPatient,
+
+	auditFile:          dataLocal,
+	consoleHistoryFile: dataLocal,
+	encryptionFile:     dataLocal, // Backed up with encrypted patient data, see CreateBackup
+	lastConnectionFile: dataLocal,
+	sealKeyFile:        dataLocal,
+	telemetryFile:      dataLocal,
+
+	recordingFilePattern: dataRecording,
+	snapshotFilePattern:  dataRecording,
+}
+
+// dataFileNames returns the registered files of a kind, sorted
+func dataFileNames(kind dataKind) []string {
+	var names []string
+	for name, k := range dataFiles {
+		if k == kind {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// patientDataFile reports whether a file holds patient data, by its name
+// without the folder
+func patientDataFile(name string) bool {
+	for pattern, kind := range dataFiles {
+		if kind != dataPatient && kind != dataRecording {
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
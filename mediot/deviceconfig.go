@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Device command protocol. Commands are text lines; the device answers a
+// GET with "NAME=value", a SET with "OK" and a failure with "ERR reason".
+const (
+	deviceConfigGateOwner = "device configuration"
+	deviceReplyTimeout    = time.Second
+)
+
+// Configuration registers of the sensor
+const (
+	registerSampleRate = "SR"   // Samples per second
+	registerGain       = "GAIN" // Analog gain, suffixed with the channel number
+	registerEnable     = "EN"   // Channel enable, 0 or 1, suffixed with the channel number
+)
+
+// deviceGains are the gains the sensor's front end supports
+var deviceGains = []float64{1, 2, 3, 4, 6, 8, 12}
+
+// DeviceConfig is the typed view of the sensor's configuration registers
+type DeviceConfig struct {
+	SampleRateHz   int                `json:"sampleRateHz"`
+	Gain           map[string]float64 `json:"gain"`           // Analog gain per raw channel
+	ChannelEnabled map[string]bool    `json:"channelEnabled"` // Disabled raw channels read 0
+}
+
+// channelRegister returns the per-channel register of a raw channel, e.g. GAIN2 for value2
+func channelRegister(register, channel string) string {
+	return register + strings.TrimPrefix(channel, "value")
+}
+
+// deviceCommand sends one command line and returns the device's reply; the
+// caller owns the port
+func (a *App) deviceCommand(command string) (string, error) {
+	port := a.conn.openPort()
+	port.ResetInputBuffer()
+	if _, err := port.Write([]byte(command + "\r\n")); err != nil {
+		return "", fmt.Errorf("failed to send '%s': %v", command, err)
+	}
+
+	var reply []byte
+	buf := make([]byte, 128)
+	deadline := time.Now().Add(deviceReplyTimeout)
+	for time.Now().Before(deadline) {
+		port.SetReadTimeout(50 * time.Millisecond)
+		n, err := port.Read(buf)
+		if err != nil {
+			return "", fmt.Errorf("failed to read reply to '%s': %v", command, err)
+		}
+		reply = append(reply, buf[:n]...)
+
+		// Skip sensor data lines still in flight until the reply arrives
+		for {
+			i := strings.IndexByte(string(reply), '\n')
+			if i < 0 {
+				break
+			}
+			line := strings.TrimSpace(string(reply[:i]))
+			reply = reply[i+1:]
+			if strings.HasPrefix(line, "ERR") {
+				return "", fmt.Errorf("device rejected '%s': %s", command, strings.TrimSpace(strings.TrimPrefix(line, "ERR")))
+			}
+			if line == "OK" || strings.Contains(line, "=") {
+				return line, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no reply to '%s' within %s", command, deviceReplyTimeout)
+}
+
+// getRegister reads a register; the caller owns the port
+func (a *App) getRegister(name string) (string, error) {
+	reply, err := a.deviceCommand("GET " + name)
+	if err != nil {
+		return "", err
+	}
+	key, value, ok := strings.Cut(reply, "=")
+	if !ok || !strings.EqualFold(strings.TrimSpace(key), name) {
+		return "", fmt.Errorf("unexpected reply '%s' to GET %s", reply, name)
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// setRegister writes a register; the caller owns the port
+func (a *App) setRegister(name, value string) error {
+	reply, err := a.deviceCommand(fmt.Sprintf("SET %s %s", name, value))
+	if err != nil {
+		return err
+	}
+	if reply != "OK" {
+		return fmt.Errorf("unexpected reply '%s' to SET %s", reply, name)
+	}
+	return nil
+}
+
+// withDevicePort pauses the sensor stream while fn talks to the device
+func (a *App) withDevicePort(fn func() error) error {
+	port := a.conn.openPort()
+	if port == nil {
+		return trError(MsgNotConnected)
+	}
+	if err := a.conn.take(deviceConfigGateOwner); err != nil {
+		return err
+	}
+	defer a.conn.release(deviceConfigGateOwner)
+	defer port.ResetInputBuffer()
+
+	return fn()
+}
+
+// checkRegisterName rejects names that would break the line protocol
+func checkRegisterName(name string) error {
+	if name == "" || strings.ContainsAny(name, " =\r\n") {
+		return fmt.Errorf("invalid register name '%s'", name)
+	}
+	return nil
+}
+
+// GetDeviceRegister reads a raw configuration register of the connected device
+func (a *App) GetDeviceRegister(name string) (string, error) {
+	if err := checkRegisterName(name); err != nil {
+		return "", err
+	}
+	var value string
+	err := a.withDevicePort(func() error {
+		var err error
+		value, err = a.getRegister(name)
+		return err
+	})
+	return value, err
+}
+
+// SetDeviceRegister writes a raw configuration register of the connected device
+func (a *App) SetDeviceRegister(name string, value string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := checkRegisterName(name); err != nil {
+		return err
+	}
+	if value == "" || strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("invalid register value '%s'", value)
+	}
+	err := a.withDevicePort(func() error { return a.setRegister(name, value) })
+	if err == nil {
+		deviceLog.Infof("Device register %s set to %s", name, value)
+	}
+	return err
+}
+
+// readDeviceConfig reads every configuration register; the caller owns the port
+func (a *App) readDeviceConfig() (DeviceConfig, error) {
+	config := DeviceConfig{
+		Gain:           make(map[string]float64),
+		ChannelEnabled: make(map[string]bool),
+	}
+
+	value, err := a.getRegister(registerSampleRate)
+	if err != nil {
+		return config, err
+	}
+	if config.SampleRateHz, err = strconv.Atoi(value); err != nil {
+		return config, fmt.Errorf("invalid sample rate '%s'", value)
+	}
+
+	for _, channel := range rawChannels {
+		value, err := a.getRegister(channelRegister(registerGain, channel))
+		if err != nil {
+			return config, err
+		}
+		if config.Gain[channel], err = strconv.ParseFloat(value, 64); err != nil {
+			return config, fmt.Errorf("invalid gain '%s' of %s", value, channel)
+		}
+
+		if value, err = a.getRegister(channelRegister(registerEnable, channel)); err != nil {
+			return config, err
+		}
+		config.ChannelEnabled[channel] = value == "1"
+	}
+	return config, nil
+}
+
+// ReadDeviceConfig reads the configuration of the connected device
+func (a *App) ReadDeviceConfig() (DeviceConfig, error) {
+	var config DeviceConfig
+	err := a.withDevicePort(func() error {
+		var err error
+		config, err = a.readDeviceConfig()
+		return err
+	})
+	return config, err
+}
+
+// WriteDeviceConfig writes the configuration of the connected device and
+// reads it back, returning what the device actually applied
+func (a *App) WriteDeviceConfig(config DeviceConfig) (DeviceConfig, error) {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return DeviceConfig{}, err
+	}
+
+	if config.SampleRateHz <= 0 {
+		return DeviceConfig{}, fmt.Errorf("sample rate must be positive, got %d", config.SampleRateHz)
+	}
+	for channel, gain := range config.Gain {
+		if !isRawChannel(channel) {
+			return DeviceConfig{}, fmt.Errorf("unknown raw channel '%s'", channel)
+		}
+		supported := false
+		for _, g := range deviceGains {
+			supported = supported || g == gain
+		}
+		if !supported {
+			return DeviceConfig{}, fmt.Errorf("gain %g of %s is not supported, use one of %v", gain, channel, deviceGains)
+		}
+	}
+	for channel := range config.ChannelEnabled {
+		if !isRawChannel(channel) {
+			return DeviceConfig{}, fmt.Errorf("unknown raw channel '%s'", channel)
+		}
+	}
+
+	var applied DeviceConfig
+	err := a.withDevicePort(func() error {
+		if err := a.setRegister(registerSampleRate, strconv.Itoa(config.SampleRateHz)); err != nil {
+			return err
+		}
+		for channel, gain := range config.Gain {
+			if err := a.setRegister(channelRegister(registerGain, channel), strconv.FormatFloat(gain, 'g', -1, 64)); err != nil {
+				return err
+			}
+		}
+		for channel, enabled := range config.ChannelEnabled {
+			value := "0"
+			if enabled {
+				value = "1"
+			}
+			if err := a.setRegister(channelRegister(registerEnable, channel), value); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		applied, err = a.readDeviceConfig()
+		return err
+	})
+	if err != nil {
+		return DeviceConfig{}, err
+	}
+
+	deviceLog.Infof("Device configuration written: %d Hz", applied.SampleRateHz)
+	return applied, nil
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.bug.st/serial/enumerator"
+)
+
+// devicesFile stores the device registry
+const devicesFile = "devices.json"
+
+// DeviceSettings are applied automatically whenever the device is connected
+type DeviceSettings struct {
+	Parser        string                 `json:"parser"`        // Line format of the device
+	Calibrations  map[string]Calibration `json:"calibrations"`  // Raw channel calibrations of this device
+	ChannelNames  map[string]string      `json:"channelNames"`  // Display names of the raw channels
+	AlarmProfile  string                 `json:"alarmProfile"`  // Loaded when a session starts, empty for the startup profile
+	ClockProtocol string                 `json:"clockProtocol"` // How SyncDeviceClock talks to the device, empty for unix-ms
+}
+
+// Device is a device registry entry
+type Device struct {
+	ID           string         `json:"id"`   // USB identity, or the port name for devices without one
+	Name         string         `json:"name"` // User-given name
+	VID          string         `json:"vid,omitempty"`
+	PID          string         `json:"pid,omitempty"`
+	SerialNumber string         `json:"serialNumber,omitempty"`
+	Product      string         `json:"product,omitempty"`
+	Location     string         `json:"location"`
+	AssetID      string         `json:"assetId,omitempty"`     // ID written to the device at provisioning
+	Provisioned  *time.Time     `json:"provisioned,omitempty"` // Nil until the device is provisioned
+	LastPort     string         `json:"lastPort"`
+	FirstSeen    time.Time      `json:"firstSeen"`
+	LastSeen     time.Time      `json:"lastSeen"`
+	Settings     DeviceSettings `json:"settings"`
+
+	// The device's own description of its channels, from the last discovery
+	Capabilities *DeviceCapabilities `json:"capabilities,omitempty"`
+}
+
+// defaultDeviceSettings parses hex lines and leaves the channels as they are
+func defaultDeviceSettings() DeviceSettings {
+	return DeviceSettings{
+		Parser:       ParserHex,
+		Calibrations: make(map[string]Calibration),
+		ChannelNames: make(map[string]string),
+	}
+}
+
+// deviceRegistry keeps the known devices and which one is connected
+type deviceRegistry struct {
+	mu        sync.Mutex
+	devices   map[string]*Device
+	connected string // ID of the connected device, empty while disconnected
+}
+
+// newDeviceRegistry creates the registry and loads the known devices
+func newDeviceRegistry() *deviceRegistry {
+	r := &deviceRegistry{devices: make(map[string]*Device)}
+	if err := loadJSONFile(devicesFile, &r.devices); err != nil {
+		deviceLog.Errorf("Error loading device registry: %v", err)
+	}
+	return r
+}
+
+// save persists the registry; the caller holds the lock
+func (r *deviceRegistry) save() error {
+	return saveJSONFile(devicesFile, r.devices)
+}
+
+// identifyPort returns the registry entry describing the device on a port.
+// USB devices are identified by VID, PID and serial number so they are
+// recognised on any port; other devices by their port name.
+func identifyPort(portName string) Device {
+	device := Device{ID: "port:" + portName, Name: portName, LastPort: portName}
+
+	ports, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		deviceLog.Errorf("Error enumerating serial ports: %v", err)
+		return device
+	}
+	for _, port := range ports {
+		if port.Name != portName || !port.IsUSB {
+			continue
+		}
+		device.VID = strings.ToUpper(port.VID)
+		device.PID = strings.ToUpper(port.PID)
+		device.SerialNumber = port.SerialNumber
+		device.Product = port.Product
+		if port.SerialNumber != "" {
+			device.ID = usbDeviceID(port)
+		}
+		if port.Product != "" {
+			device.Name = port.Product
+		}
+	}
+	return device
+}
+
+// usbDeviceID is the registry ID of a USB device with a serial number
+func usbDeviceID(port *enumerator.PortDetails) string {
+	return fmt.Sprintf("usb:%s:%s:%s", strings.ToUpper(port.VID), strings.ToUpper(port.PID), port.SerialNumber)
+}
+
+// attach registers the device on a newly opened port, or updates its entry
+// if it is known, and returns a copy of the entry
+func (r *deviceRegistry) attach(portName string, at time.Time) Device {
+	identity := identifyPort(portName)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	device, ok := r.devices[identity.ID]
+	if !ok {
+		identity.FirstSeen = at
+		identity.Settings = defaultDeviceSettings()
+		device = &identity
+		r.devices[identity.ID] = device
+		deviceLog.Infof("New device %s registered", identity.ID)
+	}
+	device.LastPort = portName
+	device.LastSeen = at
+	r.connected = device.ID
+	if err := r.save(); err != nil {
+		deviceLog.Errorf("Error saving device registry: %v", err)
+	}
+	return device.copy()
+}
+
+// detach forgets the connected device
+func (r *deviceRegistry) detach() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.connected = ""
+}
+
+// connectedID returns the ID of the connected device, empty while disconnected
+func (r *deviceRegistry) connectedID() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.connected
+}
+
+// copy returns a deep copy of a registry entry
+func (d *Device) copy() Device {
+	result := *d
+	result.Settings.Calibrations = make(map[string]Calibration, len(d.Settings.Calibrations))
+	for channel, calibration := range d.Settings.Calibrations {
+		result.Settings.Calibrations[channel] = calibration
+	}
+	result.Settings.ChannelNames = make(map[string]string, len(d.Settings.ChannelNames))
+	for channel, name := range d.Settings.ChannelNames {
+		result.Settings.ChannelNames[channel] = name
+	}
+	if d.Capabilities != nil {
+		caps := *d.Capabilities
+		caps.Channels = append([]ChannelCapability{}, caps.Channels...)
+		result.Capabilities = &caps
+	}
+	return result
+}
+
+// applyDeviceSettings installs the parser and calibrations of a newly
+// connected device. Its alarm profile is loaded when the session starts.
+func (a *App) applyDeviceSettings(device Device) {
+	format := device.Settings.Parser
+	parser, ok := a.findParser(format)
+	if !ok {
+		deviceLog.Warnf("Unknown parser '%s' of device %s, using %s", format, device.ID, ParserHex)
+		format, parser = ParserHex, lineParsers[ParserHex]
+	}
+	_, builtin := lineParsers[format]
+	a.bufferMutex.Lock()
+	a.parser = parser
+	a.lenient = fieldParsers[format]
+	a.lastRaw = nil
+	a.offParse = !builtin
+	a.dropPluginLines()
+	a.bufferMutex.Unlock()
+
+	a.calibration.mu.Lock()
+	changed := false
+	for channel, calibration := range device.Settings.Calibrations {
+		entry, ok := a.calibration.channels[channel]
+		if ok && entry.Current != nil &&
+			entry.Current.Gain == calibration.Gain && entry.Current.Offset == calibration.Offset {
+			continue
+		}
+		calibration := calibration
+		a.calibration.setCurrent(channel, &calibration)
+		changed = true
+	}
+	if changed {
+		if err := a.calibration.save(); err != nil {
+			deviceLog.Errorf("Error saving calibrations: %v", err)
+		}
+	}
+	a.calibration.mu.Unlock()
+
+	deviceLog.Infof("Settings of device %s applied", device.ID)
+}
+
+// GetDevices returns the known devices, most recently seen first
+func (a *App) GetDevices() []Device {
+	a.devices.mu.Lock()
+	defer a.devices.mu.Unlock()
+
+	result := make([]Device, 0, len(a.devices.devices))
+	for _, device := range a.devices.devices {
+		result = append(result, device.copy())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].LastSeen.After(result[j].LastSeen) })
+	return result
+}
+
+// GetConnectedDevice returns the registry entry of the connected device, or nil while disconnected
+func (a *App) GetConnectedDevice() *Device {
+	a.devices.mu.Lock()
+	defer a.devices.mu.Unlock()
+
+	device, ok := a.devices.devices[a.devices.connected]
+	if !ok {
+		return nil
+	}
+	result := device.copy()
+	return &result
+}
+
+// SetDeviceSettings replaces and persists the settings of a known device.
+// They take effect the next time it is connected.
+func (a *App) SetDeviceSettings(id string, name string, settings DeviceSettings) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if _, ok := a.findParser(settings.Parser); !ok {
+		return fmt.Errorf("unknown parser '%s'", settings.Parser)
+	}
+	if _, ok := clockProtocols[settings.ClockProtocol]; settings.ClockProtocol != "" && !ok {
+		return fmt.Errorf("unknown clock protocol '%s'", settings.ClockProtocol)
+	}
+	for channel := range settings.Calibrations {
+		if !isRawChannel(channel) {
+			return fmt.Errorf("only raw channels can be calibrated, got '%s'", channel)
+		}
+	}
+	for channel := range settings.ChannelNames {
+		if !isRawChannel(channel) {
+			return fmt.Errorf("only raw channels can be renamed, got '%s'", channel)
+		}
+	}
+	if settings.AlarmProfile != "" {
+		a.limits.mu.Lock()
+		_, err := a.limits.find(settings.AlarmProfile, 0)
+		a.limits.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	if settings.Calibrations == nil {
+		settings.Calibrations = make(map[string]Calibration)
+	}
+	if settings.ChannelNames == nil {
+		settings.ChannelNames = make(map[string]string)
+	}
+
+	a.devices.mu.Lock()
+	defer a.devices.mu.Unlock()
+
+	device, ok := a.devices.devices[id]
+	if !ok {
+		return fmt.Errorf("unknown device '%s'", id)
+	}
+	if name != "" {
+		device.Name = name
+	}
+	device.Settings = settings
+
+	deviceLog.Infof("Settings of device %s updated", id)
+	a.audit.record("", AuditDeviceSettings, fmt.Sprintf("Settings of device %s updated", id))
+	return a.devices.save()
+}
+
+// SaveDeviceCalibrations stores the current raw channel calibrations in the
+// settings of the connected device
+func (a *App) SaveDeviceCalibrations() error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	calibrations := make(map[string]Calibration)
+	a.calibration.mu.Lock()
+	for _, channel := range rawChannels {
+		if entry, ok := a.calibration.channels[channel]; ok && entry.Current != nil {
+			calibrations[channel] = *entry.Current
+		}
+	}
+	a.calibration.mu.Unlock()
+
+	a.devices.mu.Lock()
+	defer a.devices.mu.Unlock()
+
+	device, ok := a.devices.devices[a.devices.connected]
+	if !ok {
+		return fmt.Errorf("no device connected")
+	}
+	device.Settings.Calibrations = calibrations
+
+	deviceLog.Infof("Calibrations saved for device %s", device.ID)
+	a.audit.record("", AuditCalibration, fmt.Sprintf("Calibrations saved for device %s", device.ID))
+	return a.devices.save()
+}
+
+// RemoveDevice forgets a device and its settings
+func (a *App) RemoveDevice(id string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.devices.mu.Lock()
+	defer a.devices.mu.Unlock()
+
+	if _, ok := a.devices.devices[id]; !ok {
+		return fmt.Errorf("unknown device '%s'", id)
+	}
+	if id == a.devices.connected {
+		return fmt.Errorf("device '%s' is connected", id)
+	}
+	delete(a.devices.devices, id)
+
+	deviceLog.Infof("Device %s removed", id)
+	a.audit.record("", AuditDeviceSettings, fmt.Sprintf("Device %s removed", id))
+	return a.devices.save()
+}
+
+// GetChannelNames returns the display names the connected device gives its
+// raw channels; channels without one keep their own name
+func (a *App) GetChannelNames() map[string]string {
+	a.devices.mu.Lock()
+	defer a.devices.mu.Unlock()
+
+	result := make(map[string]string, len(rawChannels))
+	for _, channel := range rawChannels {
+		result[channel] = channel
+	}
+	if device, ok := a.devices.devices[a.devices.connected]; ok {
+		for channel, name := range device.Settings.ChannelNames {
+			if name != "" {
+				result[channel] = name
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// Nordic Secure DFU opcodes. The object protocol is the same over BLE and
+// the serial (UART) transport.
+const (
+	dfuOpCreate       = 0x01
+	dfuOpSetPRN       = 0x02 // Packet receipt notification interval, 0 disables
+	dfuOpCalcChecksum = 0x03
+	dfuOpExecute      = 0x04
+	dfuOpSelect       = 0x06
+	dfuOpGetMTU       = 0x07 // Serial transport only
+	dfuOpWrite        = 0x08 // Serial transport only, carries object data
+	dfuOpResponse     = 0x60
+)
+
+// Secure DFU object types
+const (
+	dfuObjectCommand = 0x01 // The signed init packet
+	dfuObjectData    = 0x02 // The firmware image
+)
+
+// dfuResultSuccess is the result code of a successful request
+const dfuResultSuccess = 0x01
+
+// dfuResults describes the error result codes of the bootloader
+var dfuResults = map[byte]string{
+	0x00: "invalid opcode",
+	0x02: "opcode not supported",
+	0x03: "invalid parameter",
+	0x04: "insufficient resources",
+	0x05: "invalid object",
+	0x07: "unsupported type",
+	0x08: "operation not permitted",
+	0x0A: "operation failed",
+	0x0B: "extended error",
+}
+
+// SLIP framing of the serial transport
+const (
+	slipEnd    = 0xC0
+	slipEsc    = 0xDB
+	slipEscEnd = 0xDC
+	slipEscEsc = 0xDD
+)
+
+// dfuReplyTimeout bounds the wait for a response; executing an object can
+// erase flash, which takes a while
+const dfuReplyTimeout = 10 * time.Second
+
+// dfuPackage is the content of a DFU .zip package
+type dfuPackage struct {
+	init  []byte // Signed init packet (.dat)
+	image []byte // Firmware image (.bin)
+}
+
+// dfuManifest is the manifest.json of a DFU package. Only application
+// updates are supported; bootloader and SoftDevice updates need an extra
+// reset in between.
+type dfuManifest struct {
+	Manifest struct {
+		Application *struct {
+			BinFile string `json:"bin_file"`
+			DatFile string `json:"dat_file"`
+		} `json:"application"`
+	} `json:"manifest"`
+}
+
+// readDFUPackage loads the init packet and image of a DFU package
+func readDFUPackage(path string) (dfuPackage, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return dfuPackage{}, fmt.Errorf("failed to open DFU package: %v", err)
+	}
+	defer archive.Close()
+
+	readFile := func(name string) ([]byte, error) {
+		file, err := archive.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("DFU package has no '%s'", name)
+		}
+		defer file.Close()
+		return io.ReadAll(io.LimitReader(file, maxFirmwareSize+1))
+	}
+
+	data, err := readFile("manifest.json")
+	if err != nil {
+		return dfuPackage{}, err
+	}
+	var manifest dfuManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return dfuPackage{}, fmt.Errorf("invalid DFU manifest: %v", err)
+	}
+	application := manifest.Manifest.Application
+	if application == nil {
+		return dfuPackage{}, fmt.Errorf("DFU package contains no application update")
+	}
+
+	var pkg dfuPackage
+	if pkg.init, err = readFile(application.DatFile); err != nil {
+		return dfuPackage{}, err
+	}
+	if pkg.image, err = readFile(application.BinFile); err != nil {
+		return dfuPackage{}, err
+	}
+	if len(pkg.image) == 0 || len(pkg.image) > maxFirmwareSize {
+		return dfuPackage{}, fmt.Errorf("firmware image of %d bytes is empty or exceeds the %d byte limit", len(pkg.image), maxFirmwareSize)
+	}
+	return pkg, nil
+}
+
+// dfuTransport carries Secure DFU requests to the bootloader
+type dfuTransport interface {
+	request(opcode byte, params ...byte) ([]byte, error) // Returns the response payload after the result code
+	writeData(data []byte) error
+	chunkSize() int // Largest data write
+}
+
+// dfuResponsePayload checks that a response answers the request with
+// success and returns the payload after the result code. Responses are laid
+// out the same on every transport.
+func dfuResponsePayload(opcode byte, response []byte) ([]byte, error) {
+	if len(response) < 3 || response[0] != dfuOpResponse || response[1] != opcode {
+		return nil, fmt.Errorf("unexpected response % x to DFU request %02x", response, opcode)
+	}
+	if response[2] != dfuResultSuccess {
+		reason, ok := dfuResults[response[2]]
+		if !ok {
+			reason = fmt.Sprintf("result %02x", response[2])
+		}
+		return nil, fmt.Errorf("DFU request %02x failed: %s", opcode, reason)
+	}
+	return response[3:], nil
+}
+
+// serialDFUTransport speaks Secure DFU over a serial port with SLIP framing
+type serialDFUTransport struct {
+	port      xmodemPort
+	mtu       int
+	cancelled func() bool
+}
+
+// slipEncode frames a packet for the serial transport
+func slipEncode(packet []byte) []byte {
+	frame := make([]byte, 0, len(packet)+2)
+	for _, b := range packet {
+		switch b {
+		case slipEnd:
+			frame = append(frame, slipEsc, slipEscEnd)
+		case slipEsc:
+			frame = append(frame, slipEsc, slipEscEsc)
+		default:
+			frame = append(frame, b)
+		}
+	}
+	return append(frame, slipEnd)
+}
+
+// readFrame reads one SLIP frame from the bootloader
+func (t *serialDFUTransport) readFrame() ([]byte, error) {
+	var packet []byte
+	escaped := false
+	buf := make([]byte, 1)
+	deadline := time.Now().Add(dfuReplyTimeout)
+	for time.Now().Before(deadline) {
+		if t.cancelled() {
+			return nil, errTransferCancelled
+		}
+		t.port.SetReadTimeout(100 * time.Millisecond)
+		n, err := t.port.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			continue
+		}
+		switch b := buf[0]; {
+		case b == slipEnd && len(packet) > 0:
+			return packet, nil
+		case b == slipEnd:
+		case b == slipEsc:
+			escaped = true
+		case escaped && b == slipEscEnd:
+			packet, escaped = append(packet, slipEnd), false
+		case escaped && b == slipEscEsc:
+			packet, escaped = append(packet, slipEsc), false
+		default:
+			packet, escaped = append(packet, b), false
+		}
+	}
+	return nil, fmt.Errorf("bootloader did not answer within %s", dfuReplyTimeout)
+}
+
+func (t *serialDFUTransport) request(opcode byte, params ...byte) ([]byte, error) {
+	if _, err := t.port.Write(slipEncode(append([]byte{opcode}, params...))); err != nil {
+		return nil, fmt.Errorf("failed to send DFU request %02x: %v", opcode, err)
+	}
+	response, err := t.readFrame()
+	if err != nil {
+		return nil, err
+	}
+	return dfuResponsePayload(opcode, response)
+}
+
+func (t *serialDFUTransport) writeData(data []byte) error {
+	if _, err := t.port.Write(slipEncode(append([]byte{dfuOpWrite}, data...))); err != nil {
+		return fmt.Errorf("failed to send DFU data: %v", err)
+	}
+	return nil
+}
+
+// chunkSize fits a write, its opcode and worst-case SLIP escaping in the MTU
+func (t *serialDFUTransport) chunkSize() int {
+	return (t.mtu-1)/2 - 1
+}
+
+// newSerialDFUTransport asks the bootloader for its MTU
+func newSerialDFUTransport(port xmodemPort, cancelled func() bool) (*serialDFUTransport, error) {
+	t := &serialDFUTransport{port: port, mtu: 64, cancelled: cancelled}
+	response, err := t.request(dfuOpGetMTU)
+	if err != nil {
+		return nil, err
+	}
+	if len(response) < 2 {
+		return nil, fmt.Errorf("malformed MTU response")
+	}
+	t.mtu = int(binary.LittleEndian.Uint16(response))
+	if t.chunkSize() < 1 {
+		return nil, fmt.Errorf("bootloader MTU %d is too small", t.mtu)
+	}
+	return t, nil
+}
+
+// dfuSender transfers a DFU package object by object, verifying the CRC-32
+// the bootloader computes after every object before executing it
+type dfuSender struct {
+	transport dfuTransport
+	progress  func(sent int) // Called after every verified data object
+	cancelled func() bool
+}
+
+// sendObjects transfers data as objects of one type
+func (s *dfuSender) sendObjects(objectType byte, data []byte, report bool) error {
+	response, err := s.transport.request(dfuOpSelect, objectType)
+	if err != nil {
+		return err
+	}
+	if len(response) < 12 {
+		return fmt.Errorf("malformed select response")
+	}
+	maxSize := int(binary.LittleEndian.Uint32(response))
+	if maxSize == 0 {
+		return fmt.Errorf("bootloader reports an object size of 0")
+	}
+
+	for offset := 0; offset < len(data); offset += maxSize {
+		if s.cancelled() {
+			return errTransferCancelled
+		}
+		object := data[offset:min(offset+maxSize, len(data))]
+		size := make([]byte, 4)
+		binary.LittleEndian.PutUint32(size, uint32(len(object)))
+		if _, err := s.transport.request(dfuOpCreate, append([]byte{objectType}, size...)...); err != nil {
+			return err
+		}
+		for i := 0; i < len(object); i += s.transport.chunkSize() {
+			if err := s.transport.writeData(object[i:min(i+s.transport.chunkSize(), len(object))]); err != nil {
+				return err
+			}
+		}
+
+		response, err := s.transport.request(dfuOpCalcChecksum)
+		if err != nil {
+			return err
+		}
+		if len(response) < 8 {
+			return fmt.Errorf("malformed checksum response")
+		}
+		end := offset + len(object)
+		received := int(binary.LittleEndian.Uint32(response))
+		crc := binary.LittleEndian.Uint32(response[4:])
+		if received != end || crc != crc32.ChecksumIEEE(data[:end]) {
+			return fmt.Errorf("bootloader received %d bytes with CRC-32 %08x, sent %d bytes with %08x",
+				received, crc, end, crc32.ChecksumIEEE(data[:end]))
+		}
+
+		if _, err := s.transport.request(dfuOpExecute); err != nil {
+			return err
+		}
+		if report {
+			s.progress(end)
+		}
+	}
+	return nil
+}
+
+// send transfers the init packet, then the image. Executing the last data
+// object makes the bootloader validate and activate the new firmware.
+func (s *dfuSender) send(pkg dfuPackage) error {
+	if _, err := s.transport.request(dfuOpSetPRN, 0, 0); err != nil {
+		return err
+	}
+	if err := s.sendObjects(dfuObjectCommand, pkg.init, false); err != nil {
+		return fmt.Errorf("init packet: %v", err)
+	}
+	if err := s.sendObjects(dfuObjectData, pkg.image, true); err != nil {
+		return fmt.Errorf("firmware image: %v", err)
+	}
+	return nil
+}
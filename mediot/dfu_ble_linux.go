@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// bleSupported reports whether firmware can be sent over Bluetooth LE here
+const bleSupported = true
+
+// BlueZ D-Bus names used by the BLE transport
+const (
+	bluezService        = "org.bluez"
+	bluezAdapter        = "org.bluez.Adapter1"
+	bluezDevice         = "org.bluez.Device1"
+	bluezCharacteristic = "org.bluez.GattCharacteristic1"
+)
+
+// Characteristics of the Nordic Secure DFU service (0xFE59)
+const (
+	dfuControlPointUUID = "8ec90001-f315-4f60-9fb8-838830daea50"
+	dfuPacketUUID       = "8ec90002-f315-4f60-9fb8-838830daea50"
+)
+
+// BLE transport limits
+const (
+	bleTimeout      = 15 * time.Second // Bounds discovery, connecting and resolving the services
+	bleDefaultChunk = 20               // Default ATT MTU of 23 bytes less the write header
+)
+
+// bleDFUTransport speaks Secure DFU over Bluetooth LE through BlueZ.
+// Requests are written to the control point and answered with
+// notifications; object data is written to the packet characteristic
+// without response.
+type bleDFUTransport struct {
+	conn      *dbus.Conn
+	device    dbus.BusObject
+	control   dbus.BusObject
+	packet    dbus.BusObject
+	signals   chan *dbus.Signal
+	match     []dbus.MatchOption
+	chunk     int
+	cancelled func() bool
+}
+
+// newBLEDFUTransport connects to a unit waiting in its DFU bootloader and
+// returns the transport and the function that disconnects it
+func newBLEDFUTransport(address string, cancelled func() bool) (dfuTransport, func(), error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to reach BlueZ: %v", err)
+	}
+	t := &bleDFUTransport{conn: conn, chunk: bleDefaultChunk, cancelled: cancelled}
+	if err := t.open(address); err != nil {
+		t.close()
+		return nil, nil, err
+	}
+	return t, t.close, nil
+}
+
+// managedObjects lists the adapters, devices and GATT objects BlueZ knows
+func (t *bleDFUTransport) managedObjects() (map[dbus.ObjectPath]map[string]map[string]dbus.Variant, error) {
+	var objects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+	err := t.conn.Object(bluezService, "/").Call("org.freedesktop.DBus.ObjectManager.GetManagedObjects", 0).Store(&objects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Bluetooth devices: %v", err)
+	}
+	return objects, nil
+}
+
+// findDevice returns the object of the device with an address, scanning
+// for it on every adapter if BlueZ has not seen it yet
+func (t *bleDFUTransport) findDevice(address string) (dbus.ObjectPath, error) {
+	scanning := false
+	deadline := time.Now().Add(bleTimeout)
+	for time.Now().Before(deadline) {
+		if t.cancelled() {
+			return "", errTransferCancelled
+		}
+		objects, err := t.managedObjects()
+		if err != nil {
+			return "", err
+		}
+		for path, interfaces := range objects {
+			if found, _ := interfaces[bluezDevice]["Address"].Value().(string); strings.EqualFold(found, address) {
+				return path, nil
+			}
+		}
+		if !scanning {
+			scanning = true
+			for path, interfaces := range objects {
+				if _, ok := interfaces[bluezAdapter]; !ok {
+					continue
+				}
+				adapter := t.conn.Object(bluezService, path)
+				// Fails if another program is scanning already, which is as good
+				if adapter.Call(bluezAdapter+".StartDiscovery", 0).Err == nil {
+					defer adapter.Call(bluezAdapter+".StopDiscovery", 0)
+				}
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return "", fmt.Errorf("Bluetooth device %s not found, is it in DFU mode?", address)
+}
+
+// open connects to the device, finds the DFU characteristics and subscribes
+// to the control point
+func (t *bleDFUTransport) open(address string) error {
+	path, err := t.findDevice(address)
+	if err != nil {
+		return err
+	}
+	t.device = t.conn.Object(bluezService, path)
+	if err := t.device.Call(bluezDevice+".Connect", 0).Err; err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", address, err)
+	}
+	for deadline := time.Now().Add(bleTimeout); ; time.Sleep(100 * time.Millisecond) {
+		if t.cancelled() {
+			return errTransferCancelled
+		}
+		resolved, err := t.device.GetProperty(bluezDevice + ".ServicesResolved")
+		if err != nil {
+			return fmt.Errorf("failed to read the services of %s: %v", address, err)
+		}
+		if done, _ := resolved.Value().(bool); done {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s did not list its services within %s", address, bleTimeout)
+		}
+	}
+
+	objects, err := t.managedObjects()
+	if err != nil {
+		return err
+	}
+	var controlPath dbus.ObjectPath
+	for object, interfaces := range objects {
+		characteristic, ok := interfaces[bluezCharacteristic]
+		if !ok || !strings.HasPrefix(string(object), string(path)+"/") {
+			continue
+		}
+		uuid, _ := characteristic["UUID"].Value().(string)
+		switch strings.ToLower(uuid) {
+		case dfuControlPointUUID:
+			controlPath = object
+			t.control = t.conn.Object(bluezService, object)
+		case dfuPacketUUID:
+			t.packet = t.conn.Object(bluezService, object)
+			// BlueZ 5.62 and later report the negotiated MTU
+			if mtu, ok := characteristic["MTU"].Value().(uint16); ok && mtu > 3 {
+				t.chunk = int(mtu) - 3
+			}
+		}
+	}
+	if t.control == nil || t.packet == nil {
+		return fmt.Errorf("%s has no Secure DFU service, is it in DFU mode?", address)
+	}
+
+	t.match = []dbus.MatchOption{
+		dbus.WithMatchObjectPath(controlPath),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+	}
+	if err := t.conn.AddMatchSignal(t.match...); err != nil {
+		return fmt.Errorf("failed to watch the DFU control point: %v", err)
+	}
+	t.signals = make(chan *dbus.Signal, 16)
+	t.conn.Signal(t.signals)
+	if err := t.control.Call(bluezCharacteristic+".StartNotify", 0).Err; err != nil {
+		return fmt.Errorf("failed to enable DFU notifications: %v", err)
+	}
+	return nil
+}
+
+// close disconnects from the device. After the last object the bootloader
+// resets on its own, so errors are of no interest.
+func (t *bleDFUTransport) close() {
+	if t.signals != nil {
+		t.control.Call(bluezCharacteristic+".StopNotify", 0)
+		t.conn.RemoveSignal(t.signals)
+		t.conn.RemoveMatchSignal(t.match...)
+	}
+	if t.device != nil {
+		t.device.Call(bluezDevice+".Disconnect", 0)
+	}
+	t.conn.Close()
+}
+
+// notification waits for the next value the control point notifies
+func (t *bleDFUTransport) notification() ([]byte, error) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(dfuReplyTimeout)
+	for {
+		select {
+		case signal := <-t.signals:
+			if len(signal.Body) < 2 || signal.Path != t.control.Path() {
+				continue
+			}
+			if name, _ := signal.Body[0].(string); name != bluezCharacteristic {
+				continue
+			}
+			changed, _ := signal.Body[1].(map[string]dbus.Variant)
+			if value, ok := changed["Value"].Value().([]byte); ok {
+				return value, nil
+			}
+		case <-ticker.C:
+			if t.cancelled() {
+				return nil, errTransferCancelled
+			}
+		case <-timeout:
+			return nil, fmt.Errorf("bootloader did not answer within %s", dfuReplyTimeout)
+		}
+	}
+}
+
+func (t *bleDFUTransport) request(opcode byte, params ...byte) ([]byte, error) {
+	// Drop notifications left over from an earlier request
+	for len(t.signals) > 0 {
+		<-t.signals
+	}
+	options := map[string]dbus.Variant{"type": dbus.MakeVariant("request")}
+	if err := t.control.Call(bluezCharacteristic+".WriteValue", 0, append([]byte{opcode}, params...), options).Err; err != nil {
+		return nil, fmt.Errorf("failed to send DFU request %02x: %v", opcode, err)
+	}
+	response, err := t.notification()
+	if err != nil {
+		return nil, err
+	}
+	return dfuResponsePayload(opcode, response)
+}
+
+func (t *bleDFUTransport) writeData(data []byte) error {
+	options := map[string]dbus.Variant{"type": dbus.MakeVariant("command")}
+	if err := t.packet.Call(bluezCharacteristic+".WriteValue", 0, data, options).Err; err != nil {
+		return fmt.Errorf("failed to send DFU data: %v", err)
+	}
+	return nil
+}
+
+// chunkSize fits a write in the ATT MTU
+func (t *bleDFUTransport) chunkSize() int {
+	return t.chunk
+}
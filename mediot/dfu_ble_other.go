@@ -0,0 +1,11 @@
+//go:build !linux
+
+package main
+
+// bleSupported reports whether firmware can be sent over Bluetooth LE here
+const bleSupported = false
+
+// newBLEDFUTransport is not available on this platform
+func newBLEDFUTransport(address string, cancelled func() bool) (dfuTransport, func(), error) {
+	return nil, nil, errNoBLE
+}
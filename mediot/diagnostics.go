@@ -0,0 +1,232 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// rawCaptureSize is how many of the most recent bytes from the device are kept
+const rawCaptureSize = 64 << 10
+
+// diagnosticConfigFiles are the configuration files added to a diagnostic
+// bundle. Files with patient data, accounts or keys are left out.
+var diagnosticConfigFiles = []string{
+	settingsFile, loggingFile, alarmProfilesFile, appLockFile, audioFile, calibrationFile,
+	consoleHistoryFile, devicesFile, emailFile, encryptionFile, escalationFile, healthRulesFile,
+	messagingFile, mqttFile, notificationsFile, unitsFile, watchdogFile,
+}
+
+// redactedKeys are JSON keys whose values are replaced in the bundle when
+// their name contains one of these words
+var redactedKeys = []string{"password", "token", "secret", "key", "hash", "salt"}
+
+// rawCapture keeps the most recent bytes read from the device in a ring
+type rawCapture struct {
+	mu   sync.Mutex
+	data []byte
+	next int // Ring position of the next byte once full
+}
+
+// newRawCapture creates an empty capture
+func newRawCapture() *rawCapture {
+	return &rawCapture{data: make([]byte, 0, rawCaptureSize)}
+}
+
+// write appends bytes read from the device, overwriting the oldest
+func (c *rawCapture) write(p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, b := range p {
+		if len(c.data) < rawCaptureSize {
+			c.data = append(c.data, b)
+			continue
+		}
+		c.data[c.next] = b
+		c.next = (c.next + 1) % rawCaptureSize
+	}
+}
+
+// bytes returns the captured bytes oldest first
+func (c *rawCapture) bytes() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append(append([]byte{}, c.data[c.next:]...), c.data[:c.next]...)
+}
+
+// redactJSON replaces the values of secret-looking keys at any depth
+func redactJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			secret := false
+			for _, word := range redactedKeys {
+				secret = secret || strings.Contains(strings.ToLower(key), word)
+			}
+			if secret && item != nil && item != "" {
+				v[key] = "[redacted]"
+			} else {
+				v[key] = redactJSON(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactJSON(item)
+		}
+	}
+	return value
+}
+
+// addBundleFile writes one file into the bundle
+func addBundleFile(w *zip.Writer, name string, data []byte) error {
+	f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// addBundleConfig adds a configuration file, decrypted and redacted; those
+// of the profiles come from the current one. Missing files are skipped.
+func addBundleConfig(w *zip.Writer, name string) error {
+	path, err := dataFilePath(name)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if data, err = atRest.decrypt(data); err != nil {
+		return addBundleFile(w, "config/"+name+".unavailable", []byte(err.Error()))
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return addBundleFile(w, "config/"+name+".unavailable", []byte(err.Error()))
+	}
+	if data, err = json.MarshalIndent(redactJSON(value), "", "  "); err != nil {
+		return err
+	}
+	return addBundleFile(w, "config/"+name, data)
+}
+
+// addBundleDir adds every file of a data subdirectory matching a pattern
+func addBundleDir(w *zip.Writer, dir, subdir, pattern string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, subdir, pattern))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := addBundleFile(w, subdir+"/"+filepath.Base(path), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateDiagnosticBundle asks where to save a zip for a support ticket and
+// writes the app and build information, the connection status, the log
+// files, the crash reports, the configuration with its secrets redacted and
+// the last 64 KB received from the device. Patient data, accounts and keys
+// are left out. It returns the path, empty if the dialog was cancelled.
+func (a *App) CreateDiagnosticBundle() (string, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return "", err
+	}
+	telemetry.count(FeatureDiagnostics)
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:                "Save diagnostic bundle",
+		DefaultDirectory:     a.exportDir(),
+		DefaultFilename:      fmt.Sprintf("mediot-diagnostics-%s.zip", time.Now().Format(crashTimestampForm)),
+		Filters:              []runtime.FileFilter{{DisplayName: "Zip archives (*.zip)", Pattern: "*.zip"}},
+		CanCreateDirectories: true,
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	a.rememberExportDir(filepath.Dir(path))
+
+	dir, err := appDataDir()
+	if err != nil {
+		return "", err
+	}
+	if logFile != nil {
+		logFile.sync()
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	w := zip.NewWriter(file)
+
+	info, err := json.MarshalIndent(struct {
+		App        AppInfo          `json:"app"`
+		Connection ConnectionStatus `json:"connection"`
+		Logging    LogSettings      `json:"logging"`
+		Language   string           `json:"language"`
+		Profile    string           `json:"profile"`
+		CreatedAt  time.Time        `json:"createdAt"`
+	}{a.GetAppInfo(), a.GetConnectionStatus(), a.GetLogSettings(), messages.current(), profiles.current(), time.Now()}, "", "  ")
+	if err == nil {
+		err = addBundleFile(w, "info.json", info)
+	}
+	if err == nil {
+		err = addBundleDir(w, dir, logDirName, "*.log")
+	}
+	if err == nil {
+		err = addBundleDir(w, dir, crashDirName, "*.txt")
+	}
+	for _, name := range diagnosticConfigFiles {
+		if err == nil {
+			err = addBundleConfig(w, name)
+		}
+	}
+	if err == nil {
+		err = addBundleFile(w, "raw_capture.bin", a.capture.bytes())
+	}
+	if err == nil {
+		var data []byte
+		if data, err = json.MarshalIndent(a.uiErrors.list(), "", "  "); err == nil {
+			err = addBundleFile(w, "frontend_errors.json", data)
+		}
+	}
+	if err == nil {
+		var data []byte
+		if data, err = json.MarshalIndent(backendErrors.list(), "", "  "); err == nil {
+			err = addBundleFile(w, "backend_errors.json", data)
+		}
+	}
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to write diagnostic bundle: %v", err)
+	}
+
+	appLog.Infof("Diagnostic bundle written to %s", path)
+	a.audit.record("", AuditExport, fmt.Sprintf("Diagnostic bundle exported to %s", filepath.Base(path)))
+	return path, nil
+}
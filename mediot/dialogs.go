@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Kinds of export SelectExportFile asks a file for
+const (
+	ExportKindSessions  = "sessions"  // ExportSessions
+	ExportKindAuditLog  = "audit-log" // ExportAuditLog
+	ExportKindBackup    = "backup"    // CreateBackup
+	ExportKindRecording = "recording" // ExportRecording
+)
+
+// exportDialog describes the save dialog of a kind of export
+type exportDialog struct {
+	title  string
+	name   string // Default file name, formatted with the time
+	filter runtime.FileFilter
+}
+
+// exportDialogs holds the save dialog of every kind of export
+var exportDialogs = map[string]exportDialog{
+	ExportKindSessions:  {"Export sessions", "mediot-sessions-%s.json", runtime.FileFilter{DisplayName: "JSON files (*.json)", Pattern: "*.json"}},
+	ExportKindAuditLog:  {"Export audit log", "mediot-audit-%s.csv", runtime.FileFilter{DisplayName: "CSV files (*.csv)", Pattern: "*.csv"}},
+	ExportKindBackup:    {"Save backup", "mediot-backup-%s.zip", backupFileFilter},
+	ExportKindRecording: {"Export recording", "mediot-recording-%s.jsonl", recordingFileFilter},
+}
+
+// backupFileFilter matches the archives of CreateBackup
+var backupFileFilter = runtime.FileFilter{DisplayName: "Backups (*.zip)", Pattern: "*.zip"}
+
+// recordingFileFilter matches the files written while recording
+var recordingFileFilter = runtime.FileFilter{DisplayName: "Recordings (*.jsonl)", Pattern: "*.jsonl"}
+
+// dialogDir returns the first of dirs that is an existing folder, or the
+// home folder. Some platforms refuse to open a dialog in a missing folder.
+func dialogDir(dirs ...string) string {
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); dir != "" && err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// exportDir returns the folder the export dialogs open in
+func (a *App) exportDir() string {
+	return dialogDir(a.GetSettings().ExportDir)
+}
+
+// rememberExportDir makes the export dialogs open in dir from now on
+func (a *App) rememberExportDir(dir string) {
+	a.settings.mu.Lock()
+	if a.settings.readOnly || a.settings.stored.ExportDir == dir {
+		a.settings.mu.Unlock()
+		return
+	}
+	stored := a.settings.stored
+	stored.ExportDir = dir
+	if err := saveJSONFile(settingsFile, stored); err != nil {
+		a.settings.mu.Unlock()
+		appLog.Errorf("Error saving export folder: %v", err)
+		return
+	}
+	a.settings.stored = stored
+	settings := overrides.apply(stored)
+	a.settings.settings = settings
+	a.settings.mu.Unlock()
+
+	a.emit(EventSettings, settings)
+}
+
+// SelectExportFile asks where to save an export of the given kind and
+// returns the path, empty if the dialog was cancelled. The dialog opens in
+// the folder of the last export.
+func (a *App) SelectExportFile(kind string) (string, error) {
+	dialog, ok := exportDialogs[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown export '%s'", kind)
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:                dialog.title,
+		DefaultDirectory:     a.exportDir(),
+		DefaultFilename:      fmt.Sprintf(dialog.name, time.Now().Format(crashTimestampForm)),
+		Filters:              []runtime.FileFilter{dialog.filter},
+		CanCreateDirectories: true,
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	a.rememberExportDir(filepath.Dir(path))
+	return path, nil
+}
+
+// SelectRecordingFolder asks for a folder to record to and returns it,
+// empty if the dialog was cancelled. The dialog opens in the recording
+// folder of the settings; the choice is kept by passing it to
+// UpdateSettings, or used once with StartRecording.
+func (a *App) SelectRecordingFolder() (string, error) {
+	current, err := a.recordingDir()
+	if err != nil {
+		return "", err
+	}
+	return runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                "Select recording folder",
+		DefaultDirectory:     dialogDir(current, filepath.Dir(current)),
+		CanCreateDirectories: true,
+	})
+}
+
+// OpenRecordingFile asks for a recording file and returns its path, empty
+// if the dialog was cancelled. The dialog opens in the recording folder,
+// or in the folder being recorded to.
+func (a *App) OpenRecordingFile() (string, error) {
+	dir, err := a.recordingDir()
+	if err != nil {
+		return "", err
+	}
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:            "Open recording",
+		DefaultDirectory: dialogDir(a.recorder.status().Dir, dir),
+		Filters:          []runtime.FileFilter{recordingFileFilter},
+	})
+}
+
+// OpenBackupFile asks for a backup archive to restore and returns its
+// path, empty if the dialog was cancelled
+func (a *App) OpenBackupFile() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:            "Restore backup",
+		DefaultDirectory: a.exportDir(),
+		Filters:          []runtime.FileFilter{backupFileFilter},
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// resampleUniform interpolates irregular readings onto a grid of the given
+// rate in Hz, starting at the first reading
+func resampleUniform(values []timedValue, rate float64) []float64 {
+	if len(values) < 2 {
+		return nil
+	}
+	return resampleBetween(values, values[0].t, values[len(values)-1].t, rate)
+}
+
+// resampleBetween interpolates irregular readings onto a grid of the given
+// rate in Hz from start to end, which must lie within the readings
+func resampleBetween(values []timedValue, start, end time.Time, rate float64) []float64 {
+	if len(values) < 2 {
+		return nil
+	}
+
+	period := time.Duration(float64(time.Second) / rate)
+	result := make([]float64, 0, int(end.Sub(start).Seconds()*rate)+1)
+
+	j := 0
+	for at := start; !at.After(end); at = at.Add(period) {
+		for j < len(values)-2 && values[j+1].t.Before(at) {
+			j++
+		}
+		a, b := values[j], values[j+1]
+		span := b.t.Sub(a.t).Seconds()
+		if span <= 0 {
+			result = append(result, b.v)
+			continue
+		}
+		frac := at.Sub(a.t).Seconds() / span
+		result = append(result, a.v+(b.v-a.v)*frac)
+	}
+	return result
+}
+
+// detrend removes the mean of a signal in place
+func detrend(signal []float64) {
+	if len(signal) == 0 {
+		return
+	}
+	mean := 0.0
+	for _, v := range signal {
+		mean += v
+	}
+	mean /= float64(len(signal))
+	for i := range signal {
+		signal[i] -= mean
+	}
+}
+
+// applyHann tapers a signal in place with a Hann window to limit spectral leakage
+func applyHann(signal []float64) {
+	n := len(signal)
+	if n < 2 {
+		return
+	}
+	for i := range signal {
+		signal[i] *= 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(n-1)))
+	}
+}
+
+// powerAt returns the spectral power of a uniformly sampled signal at a
+// single frequency, evaluated directly with the Goertzel recurrence
+func powerAt(signal []float64, rate, freq float64) float64 {
+	w := 2 * math.Pi * freq / rate
+	coeff := 2 * math.Cos(w)
+	s1, s2 := 0.0, 0.0
+	for _, x := range signal {
+		s0 := x + coeff*s1 - s2
+		s2, s1 = s1, s0
+	}
+	return (s1*s1 + s2*s2 - coeff*s1*s2) / float64(len(signal))
+}
+
+// dominantFrequency returns the frequency with the most power between low
+// and high Hz, scanned in the given step
+func dominantFrequency(signal []float64, rate, low, high, step float64) (float64, float64) {
+	bestFreq, bestPower := 0.0, 0.0
+	for f := low; f <= high; f += step {
+		if p := powerAt(signal, rate, f); p > bestPower {
+			bestFreq, bestPower = f, p
+		}
+	}
+	return bestFreq, bestPower
+}
+
+// bandPower integrates the spectral power of a signal between low and high Hz
+func bandPower(signal []float64, rate, low, high float64) float64 {
+	const step = 0.005
+	total := 0.0
+	for f := low; f < high; f += step {
+		total += powerAt(signal, rate, f) * step
+	}
+	return total
+}
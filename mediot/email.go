@@ -0,0 +1,300 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// emailFile stores the SMTP notifier settings
+const emailFile = "email.json"
+
+// emailCheckInterval is how often the notifier looks for pending alerts and a lost stream
+const emailCheckInterval = 10 * time.Second
+
+// smtpTimeout bounds connecting to the SMTP server, and then the whole
+// exchange, so a stalled server cannot hold up alerts or quitting
+const smtpTimeout = 30 * time.Second
+
+// SMTP connection security modes
+const (
+	SMTPSecurityNone     = "none"     // Plain connection, for relays on a trusted network only
+	SMTPSecuritySTARTTLS = "starttls" // Upgrade a plain connection, usually port 587
+	SMTPSecurityTLS      = "tls"      // Implicit TLS, usually port 465
+)
+
+// EmailConfig configures the SMTP notifier
+type EmailConfig struct {
+	Enabled              bool     `json:"enabled"`
+	Host                 string   `json:"host"`
+	Port                 int      `json:"port"`
+	Security             string   `json:"security"` // none, starttls or tls
+	Username             string   `json:"username,omitempty"`
+	Password             string   `json:"password,omitempty"` // Never returned to the frontend; empty keeps the stored one
+	From                 string   `json:"from"`
+	Recipients           []string `json:"recipients"`
+	MinIntervalMinutes   float64  `json:"minIntervalMinutes"`   // Minimum time between two emails; alerts in between are batched
+	StreamLossMinutes    float64  `json:"streamLossMinutes"`    // Report a lost data stream after this long, 0 disables
+	IncludeWarningAlarms bool     `json:"includeWarningAlarms"` // Also report warning alarms, not only critical ones
+}
+
+// defaultEmailConfig is disabled and batches alerts into at most one email every 5 minutes
+func defaultEmailConfig() EmailConfig {
+	return EmailConfig{
+		Port:               587,
+		Security:           SMTPSecuritySTARTTLS,
+		Recipients:         make([]string, 0),
+		MinIntervalMinutes: 5,
+		StreamLossMinutes:  5,
+	}
+}
+
+// emailNotifier batches alarm alerts into rate-limited summary emails
+type emailNotifier struct {
+	mu         sync.Mutex
+	config     EmailConfig
+	pending    []string // Alert lines waiting for the next email
+	lastSent   time.Time
+	streamLost bool // The current outage was already reported
+}
+
+// newEmailNotifier creates the notifier and loads the persisted settings
+func newEmailNotifier() *emailNotifier {
+	n := &emailNotifier{config: defaultEmailConfig()}
+	if err := loadJSONFile(emailFile, &n.config); err != nil {
+		notifyLog.Errorf("Error loading email settings: %v", err)
+	}
+	if secrets.restore(secretEmailPassword, &n.config.Password) {
+		if err := n.save(); err != nil {
+			notifyLog.Errorf("Error saving email settings: %v", err)
+		}
+	}
+	return n
+}
+
+// save persists the settings, leaving out the password if the keyring holds
+// it; the caller holds the lock
+func (n *emailNotifier) save() error {
+	stored := n.config
+	if secrets.keep(secretEmailPassword, stored.Password) {
+		stored.Password = ""
+	}
+	return saveJSONFile(emailFile, stored)
+}
+
+// queueAlarm adds an alarm escalation to the next summary email
+func (n *emailNotifier) queueAlarm(event AlarmEvent) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.config.Enabled || severityRank[event.Severity] <= severityRank[event.Previous] {
+		return
+	}
+	if event.Severity != SeverityCritical && !n.config.IncludeWarningAlarms {
+		return
+	}
+	n.pending = append(n.pending, fmt.Sprintf("%s  Alarm #%d [%s] %s",
+		event.Timestamp.Format(time.RFC3339), event.ID, event.Severity, event.Message))
+}
+
+// emailLoop sends the batched alerts and reports a lost data stream
+func (a *App) emailLoop() {
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-time.After(emailCheckInterval):
+		}
+
+		a.email.mu.Lock()
+		config := a.email.config
+		if !config.Enabled {
+			a.email.pending = nil
+			a.email.mu.Unlock()
+			continue
+		}
+
+		if config.StreamLossMinutes > 0 && a.conn.connected() {
+			silent := time.Since(a.clock.lastSample())
+			if silent >= secondsToDuration(config.StreamLossMinutes*60) && !a.email.streamLost {
+				a.email.streamLost = true
+				a.email.pending = append(a.email.pending, fmt.Sprintf("%s  No data received for %.0f minutes",
+					time.Now().Format(time.RFC3339), silent.Minutes()))
+			} else if silent < secondsToDuration(config.StreamLossMinutes*60) {
+				a.email.streamLost = false
+			}
+		}
+
+		if len(a.email.pending) == 0 || time.Since(a.email.lastSent) < secondsToDuration(config.MinIntervalMinutes*60) {
+			a.email.mu.Unlock()
+			continue
+		}
+		lines := a.email.pending
+		a.email.pending = nil
+		a.email.lastSent = time.Now()
+		a.email.mu.Unlock()
+
+		sendAlertSummary(config, lines)
+	}
+}
+
+// flushEmail sends the pending alerts at once, ignoring the minimum
+// interval, so they are not lost when the app quits
+func (a *App) flushEmail() {
+	a.email.mu.Lock()
+	config := a.email.config
+	lines := a.email.pending
+	a.email.pending = nil
+	a.email.mu.Unlock()
+
+	if config.Enabled && len(lines) > 0 {
+		sendAlertSummary(config, lines)
+	}
+}
+
+// sendAlertSummary emails the batched alert lines and logs the outcome
+func sendAlertSummary(config EmailConfig, lines []string) {
+	subject := fmt.Sprintf("mediot: %d new alert(s)", len(lines))
+	body := "The following alerts were raised:\r\n\r\n" + strings.Join(lines, "\r\n") + "\r\n"
+	if err := sendEmail(config, subject, body); err != nil {
+		notifyLog.Errorf("Error sending alert email: %v", err)
+	} else {
+		notifyLog.Infof("Alert email with %d alert(s) sent to %d recipient(s)", len(lines), len(config.Recipients))
+	}
+}
+
+// sendEmail delivers a plain-text message to the configured recipients
+func sendEmail(config EmailConfig, subject, body string) error {
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	tlsConfig := certificates.clientConfig(config.Host)
+
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	var conn net.Conn
+	var err error
+	if config.Security == SMTPSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+		if tlsErr := tlsFailure(err); tlsErr != nil {
+			return fmt.Errorf("failed to connect to %s: %v", addr, tlsErr)
+		}
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", addr, err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to %s: %v", addr, err)
+	}
+	defer client.Close()
+
+	if config.Security == SMTPSecuritySTARTTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			if tlsErr := tlsFailure(err); tlsErr != nil {
+				err = tlsErr
+			}
+			return fmt.Errorf("STARTTLS failed: %v", err)
+		}
+	}
+	if config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", config.Username, config.Password, config.Host)); err != nil {
+			return fmt.Errorf("authentication failed: %v", err)
+		}
+	}
+
+	if err := client.Mail(config.From); err != nil {
+		return err
+	}
+	for _, to := range config.Recipients {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s rejected: %v", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n\r\n%s",
+		config.From, strings.Join(config.Recipients, ", "), subject, time.Now().Format(time.RFC1123Z), body)
+	if _, err := w.Write([]byte(message)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// GetEmailConfig returns the SMTP notifier settings without the password
+func (a *App) GetEmailConfig() EmailConfig {
+	a.email.mu.Lock()
+	defer a.email.mu.Unlock()
+
+	config := a.email.config
+	config.Password = ""
+	config.Recipients = append([]string{}, config.Recipients...)
+	return config
+}
+
+// SetEmailConfig replaces and persists the SMTP notifier settings. An empty
+// password keeps the stored one.
+func (a *App) SetEmailConfig(config EmailConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	switch config.Security {
+	case SMTPSecurityNone, SMTPSecuritySTARTTLS, SMTPSecurityTLS:
+	default:
+		return fmt.Errorf("unknown SMTP security '%s'", config.Security)
+	}
+	if config.Enabled {
+		if config.Host == "" || config.From == "" || len(config.Recipients) == 0 {
+			return fmt.Errorf("host, sender and at least one recipient are required")
+		}
+	}
+	if config.Port <= 0 || config.Port > 65535 {
+		return fmt.Errorf("invalid port %d", config.Port)
+	}
+	if config.MinIntervalMinutes < 0 || config.StreamLossMinutes < 0 {
+		return fmt.Errorf("intervals must not be negative")
+	}
+
+	a.email.mu.Lock()
+	defer a.email.mu.Unlock()
+
+	if config.Password == "" {
+		config.Password = a.email.config.Password
+	}
+	a.email.config = config
+	return a.email.save()
+}
+
+// SendTestEmail sends a test message with the stored settings and reports
+// any delivery error
+func (a *App) SendTestEmail() error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.email.mu.Lock()
+	config := a.email.config
+	a.email.mu.Unlock()
+
+	if config.Host == "" || len(config.Recipients) == 0 {
+		return fmt.Errorf("email alerts are not configured")
+	}
+	return sendEmail(config, "mediot test email", "Email alerts are working.\r\n")
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// encryptionFile stores the key derivation salt and passphrase check. It
+// holds no key material.
+const encryptionFile = "encryption.json"
+
+// secretDataKey is the keyring entry holding the data key when the app
+// unlocks the patient data by itself
+const secretDataKey = "data-key"
+
+// minPassphraseLength is the shortest passphrase accepted for the data key
+const minPassphraseLength = 8
+
+// encryptedHeader starts every encrypted file, followed by the GCM nonce and the ciphertext
+var encryptedHeader = []byte("MEDIOT-AES256-GCM\n")
+
+// passphraseCheck is encrypted with the key to recognise the right passphrase
+var passphraseCheck = []byte("mediot passphrase check")
+
+// errStorageLocked is returned for patient data while the passphrase has not been entered
+var errStorageLocked = errors.New("patient data is encrypted; enter the passphrase to unlock it")
+
+// EncryptionStatus describes the at-rest encryption of the patient data
+type EncryptionStatus struct {
+	Enabled  bool     `json:"enabled"`
+	Unlocked bool     `json:"unlocked"` // The key is available, always true while disabled
+	Keyring  bool     `json:"keyring"`  // The OS keyring holds the key, so no passphrase is asked at startup
+	Files    []string `json:"files"`    // Files and recording patterns encrypted while enabled
+}
+
+// encryptionConfig is the persisted part of the at-rest encryption
+type encryptionConfig struct {
+	Enabled bool   `json:"enabled"`
+	Salt    []byte `json:"salt"`
+	Check   []byte `json:"check"`             // passphraseCheck sealed with the key
+	Keyring bool   `json:"keyring,omitempty"` // The key is also kept in the OS keyring
+}
+
+// atRestEncryption seals the patient data files of dataFiles with
+// AES-256-GCM. The key is derived from the passphrase with Argon2id and
+// kept in memory, and in the OS keyring when an admin chose to.
+type atRestEncryption struct {
+	mu     sync.Mutex
+	config encryptionConfig
+	key    []byte // nil until unlocked
+}
+
+// atRest is shared by the storage helpers, which are not tied to the App
+var atRest = &atRestEncryption{}
+
+// load reads the encryption settings, and the key when the keyring holds
+// it. It runs before any protected file is loaded.
+func (e *atRestEncryption) load() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := loadJSONFile(encryptionFile, &e.config); err != nil {
+		securityLog.Errorf("Error loading encryption settings: %v", err)
+	}
+	if !e.config.Enabled || !e.config.Keyring || portableDir != "" {
+		return
+	}
+	key, err := e.keyringKey()
+	if err != nil {
+		securityLog.Errorf("Patient data stays locked, the key could not be read from the keyring: %v", err)
+		return
+	}
+	e.key = key
+	securityLog.Infof("Patient data unlocked with the key from the keyring")
+}
+
+// keyringKey reads the key from the keyring and verifies it against the
+// passphrase check; the caller holds the lock
+func (e *atRestEncryption) keyringKey() ([]byte, error) {
+	encoded, err := keyringGet(secretDataKey)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key in keyring: %v", err)
+	}
+	if plain, err := open(key, e.config.Check); err != nil || !bytes.Equal(plain, passphraseCheck) {
+		return nil, fmt.Errorf("the key in the keyring does not match the passphrase")
+	}
+	return key, nil
+}
+
+// protectedFile reports whether a file is encrypted while encryption is
+// enabled: the patient data files and recording patterns of dataFiles
+func protectedFile(name string) bool {
+	return patientDataFile(filepath.Base(name))
+}
+
+// protectedFileNames returns the files and patterns encrypted while enabled
+func protectedFileNames() []string {
+	names := append(dataFileNames(dataPatient), dataFileNames(dataRecording)...)
+	sort.Strings(names)
+	return names
+}
+
+// deriveKey turns a passphrase into an AES-256 key
+func deriveKey(passphrase string, salt []byte) []byte {
+	return argon2.IDKey([]byte(passphrase), salt, 1, 64*1024, 4, 32)
+}
+
+// seal encrypts data with key, prefixed with the header and a random nonce
+func seal(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	sealed := append(append([]byte{}, encryptedHeader...), nonce...)
+	return gcm.Seal(sealed, nonce, data, encryptedHeader), nil
+}
+
+// open decrypts data sealed with key
+func open(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, encryptedHeader)
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is truncated")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], encryptedHeader)
+	if err != nil {
+		return nil, fmt.Errorf("wrong key or corrupted data")
+	}
+	return plain, nil
+}
+
+// isEncrypted reports whether file contents were written sealed
+func isEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedHeader)
+}
+
+// decrypt returns the plaintext of a file read from disk. Plain files pass
+// through so data written before encryption was enabled stays readable.
+func (e *atRestEncryption) decrypt(data []byte) ([]byte, error) {
+	if !isEncrypted(data) {
+		return data, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.key == nil {
+		return nil, errStorageLocked
+	}
+	return open(e.key, data)
+}
+
+// encrypt returns the contents to write for a file: sealed for protected
+// files while encryption is enabled, unchanged otherwise
+func (e *atRestEncryption) encrypt(name string, data []byte) ([]byte, error) {
+	if !protectedFile(name) {
+		return data, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.config.Enabled {
+		return data, nil
+	}
+	if e.key == nil {
+		return nil, errStorageLocked
+	}
+	return seal(e.key, data)
+}
+
+// locked reports whether patient data cannot be read or written yet
+func (e *atRestEncryption) locked() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.config.Enabled && e.key == nil
+}
+
+// checkPassphrase derives the key of an enabled configuration and verifies it; the caller holds the lock
+func (e *atRestEncryption) checkPassphrase(passphrase string) ([]byte, error) {
+	key := deriveKey(passphrase, e.config.Salt)
+	if plain, err := open(key, e.config.Check); err != nil || !bytes.Equal(plain, passphraseCheck) {
+		return nil, fmt.Errorf("wrong passphrase")
+	}
+	return key, nil
+}
+
+// saveProtected rewrites the protected files from memory, sealing or
+// unsealing them according to the current settings
+func (a *App) saveProtected() {
+	a.sessions.mu.Lock()
+	a.sessions.save()
+	a.sessions.mu.Unlock()
+
+	a.alarmLog.mu.Lock()
+	if err := saveJSONFile(alarmLogFile, a.alarmLog.records); err != nil {
+		securityLog.Errorf("Error saving alarm history: %v", err)
+	}
+	a.alarmLog.mu.Unlock()
+
+	a.pseudonyms.mu.Lock()
+	if err := a.pseudonyms.save(); err != nil {
+		securityLog.Errorf("Error saving pseudonyms: %v", err)
+	}
+	a.pseudonyms.mu.Unlock()
+}
+
+// GetEncryptionStatus returns whether patient data is encrypted on disk and unlocked
+func (a *App) GetEncryptionStatus() EncryptionStatus {
+	atRest.mu.Lock()
+	defer atRest.mu.Unlock()
+
+	return EncryptionStatus{
+		Enabled:  atRest.config.Enabled,
+		Unlocked: !atRest.config.Enabled || atRest.key != nil,
+		Keyring:  atRest.config.Enabled && atRest.config.Keyring,
+		Files:    protectedFileNames(),
+	}
+}
+
+// EnableEncryption encrypts the patient data on disk with a key derived
+// from the passphrase. The passphrase cannot be recovered; without it the
+// data is lost.
+func (a *App) EnableEncryption(passphrase string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if len(passphrase) < minPassphraseLength {
+		return fmt.Errorf("passphrase must have at least %d characters", minPassphraseLength)
+	}
+
+	atRest.mu.Lock()
+	if atRest.config.Enabled {
+		atRest.mu.Unlock()
+		return fmt.Errorf("encryption is already enabled")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		atRest.mu.Unlock()
+		return fmt.Errorf("failed to generate salt: %v", err)
+	}
+	key := deriveKey(passphrase, salt)
+	check, err := seal(key, passphraseCheck)
+	if err != nil {
+		atRest.mu.Unlock()
+		return err
+	}
+	config := encryptionConfig{Enabled: true, Salt: salt, Check: check}
+	if err := saveJSONFile(encryptionFile, config); err != nil {
+		atRest.mu.Unlock()
+		return err
+	}
+	atRest.config, atRest.key = config, key
+	atRest.mu.Unlock()
+
+	a.saveProtected()
+	securityLog.Infof("Patient data encryption enabled")
+	a.audit.record("", AuditSecurity, "Patient data encryption enabled")
+	return nil
+}
+
+// UnlockEncryption enters the passphrase after startup and loads the
+// encrypted patient data. Devices cannot be connected until then.
+func (a *App) UnlockEncryption(passphrase string) error {
+	atRest.mu.Lock()
+	if !atRest.config.Enabled {
+		atRest.mu.Unlock()
+		return fmt.Errorf("encryption is not enabled")
+	}
+	key, err := atRest.checkPassphrase(passphrase)
+	if err != nil {
+		atRest.mu.Unlock()
+		return err
+	}
+	atRest.key = key
+	atRest.mu.Unlock()
+
+	a.sessions.reload()
+	a.alarmLog.reload()
+	a.pseudonyms.reload()
+	a.alarms.mu.Lock()
+	a.alarms.nextID = a.alarmLog.lastID() + 1
+	a.alarms.mu.Unlock()
+
+	securityLog.Infof("Patient data unlocked")
+	a.audit.record("", AuditSecurity, "Patient data unlocked")
+	return nil
+}
+
+// DisableEncryption stores the patient data unencrypted again
+func (a *App) DisableEncryption(passphrase string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	atRest.mu.Lock()
+	if !atRest.config.Enabled {
+		atRest.mu.Unlock()
+		return fmt.Errorf("encryption is not enabled")
+	}
+	if _, err := atRest.checkPassphrase(passphrase); err != nil {
+		atRest.mu.Unlock()
+		return err
+	}
+	if atRest.key == nil {
+		atRest.mu.Unlock()
+		return fmt.Errorf("unlock the patient data before disabling encryption")
+	}
+	if err := saveJSONFile(encryptionFile, encryptionConfig{}); err != nil {
+		atRest.mu.Unlock()
+		return err
+	}
+	if atRest.config.Keyring {
+		if err := keyringDelete(secretDataKey); err != nil && !errors.Is(err, errSecretNotFound) {
+			securityLog.Warnf("Error removing the data key from the keyring: %v", err)
+		}
+	}
+	atRest.config, atRest.key = encryptionConfig{}, nil
+	atRest.mu.Unlock()
+
+	a.saveProtected()
+	securityLog.Infof("Patient data encryption disabled")
+	a.audit.record("", AuditSecurity, "Patient data encryption disabled")
+	return nil
+}
+
+// SetEncryptionKeyring keeps the data key in the OS keyring, so the app
+// unlocks the patient data at startup without asking for the passphrase,
+// or removes it from the keyring again. The passphrase is still needed to
+// restore a backup on another computer. Portable mode has no keyring.
+func (a *App) SetEncryptionKeyring(passphrase string, enabled bool) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+	if enabled && portableDir != "" {
+		return fmt.Errorf("portable mode cannot keep the key in the keyring of this computer")
+	}
+	if enabled && keyringBackend == "" {
+		return fmt.Errorf("no OS keyring is supported on this platform")
+	}
+
+	atRest.mu.Lock()
+	defer atRest.mu.Unlock()
+
+	if !atRest.config.Enabled {
+		return fmt.Errorf("encryption is not enabled")
+	}
+	key, err := atRest.checkPassphrase(passphrase)
+	if err != nil {
+		return err
+	}
+	if enabled {
+		err = keyringSet(secretDataKey, base64.StdEncoding.EncodeToString(key))
+	} else if err = keyringDelete(secretDataKey); errors.Is(err, errSecretNotFound) {
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("keyring: %v", err)
+	}
+	config := atRest.config
+	config.Keyring = enabled
+	if err := saveJSONFile(encryptionFile, config); err != nil {
+		return err
+	}
+	atRest.config = config
+
+	securityLog.Infof("Data key in keyring: %t", enabled)
+	a.audit.record("", AuditSecurity, fmt.Sprintf("Data key kept in the OS keyring: %t", enabled))
+	return nil
+}
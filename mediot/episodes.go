@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Episode rule directions
+const (
+	DirectionBelow = "below" // The episode runs while the channel is low
+	DirectionAbove = "above" // The episode runs while the channel is high
+)
+
+// Episode event phases
+const (
+	EpisodeStarted = "started"
+	EpisodeEnded   = "ended"
+)
+
+// maxRecentEpisodes is how many finished episodes are kept per rule
+const maxRecentEpisodes = 200
+
+// EpisodeRule defines a named event detected on a channel, such as an
+// apnea-like episode where the respiration channel stays low for over 10 s
+type EpisodeRule struct {
+	Name            string  `json:"name"`
+	Channel         string  `json:"channel"`
+	Direction       string  `json:"direction"`       // below or above
+	EnterThreshold  float64 `json:"enterThreshold"`  // Level the channel must cross to start an episode
+	ExitThreshold   float64 `json:"exitThreshold"`   // Level the channel must cross back to end it, for hysteresis
+	DebounceSeconds float64 `json:"debounceSeconds"` // Time the enter condition must hold before the episode starts
+	CooldownSeconds float64 `json:"cooldownSeconds"` // Minimum time after an episode ends before the next can start
+}
+
+// Episode is an occurrence of a named event. StartedAt is when the enter
+// condition was first met, not when the debounce confirmed it.
+type Episode struct {
+	Name            string    `json:"name"`
+	Channel         string    `json:"channel"`
+	Phase           string    `json:"phase"` // started or ended
+	StartedAt       time.Time `json:"startedAt"`
+	EndedAt         time.Time `json:"endedAt"` // Zero while the episode is running
+	DurationSeconds float64   `json:"durationSeconds"`
+	Extreme         float64   `json:"extreme"` // Lowest (below) or highest (above) reading of the episode
+}
+
+// EpisodeSummary counts the episodes of a rule since it was set
+type EpisodeSummary struct {
+	Name                 string  `json:"name"`
+	Count                int     `json:"count"` // Finished episodes
+	Active               bool    `json:"active"`
+	TotalDurationSeconds float64 `json:"totalDurationSeconds"`
+	LongestSeconds       float64 `json:"longestSeconds"`
+}
+
+// episodeTracker runs the enter/debounce/exit/cooldown state machine of one rule
+type episodeTracker struct {
+	rule     EpisodeRule
+	entering time.Time // When the enter condition started holding, zero if it doesn't
+	active   *Episode
+	lastEnd  time.Time
+	recent   []Episode
+	summary  EpisodeSummary
+}
+
+// entered reports whether the value meets the enter condition
+func (t *episodeTracker) entered(value float64) bool {
+	if t.rule.Direction == DirectionAbove {
+		return value > t.rule.EnterThreshold
+	}
+	return value < t.rule.EnterThreshold
+}
+
+// exited reports whether the value crossed back past the exit threshold
+func (t *episodeTracker) exited(value float64) bool {
+	if t.rule.Direction == DirectionAbove {
+		return value < t.rule.ExitThreshold
+	}
+	return value > t.rule.ExitThreshold
+}
+
+// push feeds a reading and returns the episode event it caused, if any
+func (t *episodeTracker) push(at time.Time, value float64) *Episode {
+	if t.active != nil {
+		if t.rule.Direction == DirectionAbove && value > t.active.Extreme ||
+			t.rule.Direction == DirectionBelow && value < t.active.Extreme {
+			t.active.Extreme = value
+		}
+		if !t.exited(value) {
+			return nil
+		}
+
+		ended := *t.active
+		ended.Phase = EpisodeEnded
+		ended.EndedAt = at
+		ended.DurationSeconds = at.Sub(ended.StartedAt).Seconds()
+		t.active = nil
+		t.lastEnd = at
+		t.summary.Active = false
+		t.summary.Count++
+		t.summary.TotalDurationSeconds += ended.DurationSeconds
+		if ended.DurationSeconds > t.summary.LongestSeconds {
+			t.summary.LongestSeconds = ended.DurationSeconds
+		}
+		t.recent = append(t.recent, ended)
+		if len(t.recent) > maxRecentEpisodes {
+			t.recent = t.recent[len(t.recent)-maxRecentEpisodes:]
+		}
+		return &ended
+	}
+
+	if !t.entered(value) {
+		t.entering = time.Time{}
+		return nil
+	}
+	if !t.lastEnd.IsZero() && at.Sub(t.lastEnd) < secondsToDuration(t.rule.CooldownSeconds) {
+		return nil
+	}
+	if t.entering.IsZero() {
+		t.entering = at
+	}
+	if at.Sub(t.entering) < secondsToDuration(t.rule.DebounceSeconds) {
+		return nil
+	}
+
+	t.active = &Episode{
+		Name:            t.rule.Name,
+		Channel:         t.rule.Channel,
+		Phase:           EpisodeStarted,
+		StartedAt:       t.entering,
+		DurationSeconds: at.Sub(t.entering).Seconds(),
+		Extreme:         value,
+	}
+	t.entering = time.Time{}
+	t.summary.Active = true
+	started := *t.active
+	return &started
+}
+
+// episodeDetector runs the user-defined episode rules on every sample
+type episodeDetector struct {
+	mu       sync.Mutex
+	trackers map[string]*episodeTracker
+	notify   func(Episode) // Called when an episode starts or ends, outside the lock
+}
+
+// newEpisodeDetector creates the episode stage without rules
+func newEpisodeDetector(notify func(Episode)) *episodeDetector {
+	return &episodeDetector{
+		trackers: make(map[string]*episodeTracker),
+		notify:   notify,
+	}
+}
+
+func (d *episodeDetector) process(sample *SensorData) {
+	d.mu.Lock()
+	var events []Episode
+	for _, tracker := range d.trackers {
+		value, ok := sample.channelValue(tracker.rule.Channel)
+		if !ok || sample.isArtifact(tracker.rule.Channel) {
+			continue
+		}
+		if event := tracker.push(sample.Timestamp, value); event != nil {
+			events = append(events, *event)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, event := range events {
+		d.notify(event)
+	}
+}
+
+// onEpisode logs an episode change and forwards it to the frontend
+func (a *App) onEpisode(episode Episode) {
+	if episode.Phase == EpisodeEnded {
+		processingLog.Infof("Episode %s on %s ended after %.1f s", episode.Name, episode.Channel, episode.DurationSeconds)
+	} else {
+		processingLog.Infof("Episode %s on %s started", episode.Name, episode.Channel)
+	}
+	a.emit(EventEpisode, episode)
+}
+
+// SetEpisodeRule adds or replaces a named episode rule, restarting its counts
+func (a *App) SetEpisodeRule(rule EpisodeRule) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if rule.Name == "" || rule.Channel == "" {
+		return fmt.Errorf("name and channel are required")
+	}
+	switch rule.Direction {
+	case DirectionBelow:
+		if rule.ExitThreshold < rule.EnterThreshold {
+			return fmt.Errorf("exit threshold must not be below the enter threshold for a 'below' rule")
+		}
+	case DirectionAbove:
+		if rule.ExitThreshold > rule.EnterThreshold {
+			return fmt.Errorf("exit threshold must not be above the enter threshold for an 'above' rule")
+		}
+	default:
+		return fmt.Errorf("unknown direction '%s'", rule.Direction)
+	}
+	if rule.DebounceSeconds < 0 || rule.CooldownSeconds < 0 {
+		return fmt.Errorf("debounce and cooldown must not be negative")
+	}
+
+	a.episodes.mu.Lock()
+	defer a.episodes.mu.Unlock()
+
+	a.episodes.trackers[rule.Name] = &episodeTracker{
+		rule:    rule,
+		summary: EpisodeSummary{Name: rule.Name},
+	}
+
+	processingLog.Infof("Episode rule %s set on channel %s", rule.Name, rule.Channel)
+	return nil
+}
+
+// RemoveEpisodeRule deletes an episode rule and its recorded episodes
+func (a *App) RemoveEpisodeRule(name string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.episodes.mu.Lock()
+	defer a.episodes.mu.Unlock()
+
+	if _, ok := a.episodes.trackers[name]; !ok {
+		return fmt.Errorf("no episode rule named '%s'", name)
+	}
+	delete(a.episodes.trackers, name)
+	return nil
+}
+
+// GetEpisodeRules returns the configured episode rules sorted by name
+func (a *App) GetEpisodeRules() []EpisodeRule {
+	a.episodes.mu.Lock()
+	defer a.episodes.mu.Unlock()
+
+	result := make([]EpisodeRule, 0, len(a.episodes.trackers))
+	for _, tracker := range a.episodes.trackers {
+		result = append(result, tracker.rule)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// GetEpisodeSummaries returns the episode counts of every rule sorted by name
+func (a *App) GetEpisodeSummaries() []EpisodeSummary {
+	a.episodes.mu.Lock()
+	defer a.episodes.mu.Unlock()
+
+	result := make([]EpisodeSummary, 0, len(a.episodes.trackers))
+	for _, tracker := range a.episodes.trackers {
+		result = append(result, tracker.summary)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// GetRecentEpisodes returns up to limit of the latest finished episodes of a rule, oldest first
+func (a *App) GetRecentEpisodes(name string, limit int) ([]Episode, error) {
+	if err := a.requireReadRole(RoleOperator); err != nil {
+		return nil, err
+	}
+
+	a.episodes.mu.Lock()
+	defer a.episodes.mu.Unlock()
+
+	tracker, ok := a.episodes.trackers[name]
+	if !ok {
+		return nil, fmt.Errorf("no episode rule named '%s'", name)
+	}
+
+	recent := tracker.recent
+	if limit > 0 && len(recent) > limit {
+		recent = recent[len(recent)-limit:]
+	}
+	result := make([]Episode, len(recent))
+	copy(result, recent)
+	return result, nil
+}
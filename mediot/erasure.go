@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ErasureResult summarizes what DeletePatientData removed
+type ErasureResult struct {
+	Sessions     int `json:"sessions"`
+	Alarms       int `json:"alarms"`       // Alarms raised during the patient's sessions
+	Pseudonyms   int `json:"pseudonyms"`   // Export pseudonyms that can no longer be resolved
+	AuditEntries int `json:"auditEntries"` // Audit entries of the patient whose detail was redacted
+	Recordings   int `json:"recordings"`   // Recording files of the patient's sessions
+	Snapshots    int `json:"snapshots"`    // Data snapshots taken during the patient's sessions
+}
+
+// inPatientSession reports whether an alarm was raised during one of the sessions
+func inPatientSession(alarm AlarmRecord, sessions []SessionInfo) bool {
+	for _, session := range sessions {
+		if !alarm.RaisedAt.Before(session.StartedAt) &&
+			(session.EndedAt.IsZero() || alarm.RaisedAt.Before(session.EndedAt)) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeletePatientData erases everything stored about a patient for a
+// right-to-erasure request: the sessions recorded for the patient, the
+// alarms raised during them, the recording files and data snapshots of
+// those sessions, the pseudonyms of earlier exports and the details of the
+// audit entries recorded for the patient. The alias is the patient
+// identifier or one of its export pseudonyms. The user confirms in a dialog,
+// and the erasure is audited without naming the patient.
+//
+// Recordings and snapshots are looked for in the recording folder of the
+// settings and the one being recorded to. Files moved elsewhere, and export
+// files written outside the app, are not tracked and must be deleted by
+// hand; pseudonymized ones can no longer be linked to the patient.
+func (a *App) DeletePatientData(patientAlias string) (ErasureResult, error) {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return ErasureResult{}, err
+	}
+	patientAlias = strings.TrimSpace(patientAlias)
+	if patientAlias == "" {
+		return ErasureResult{}, fmt.Errorf("patient is required")
+	}
+	if atRest.locked() {
+		return ErasureResult{}, errStorageLocked
+	}
+
+	patient := patientAlias
+	a.pseudonyms.mu.Lock()
+	if resolved, ok := a.pseudonyms.table.Patients[patientAlias]; ok {
+		patient = resolved
+	}
+	pseudonyms := make([]string, 0)
+	for pseudonym, owner := range a.pseudonyms.table.Patients {
+		if owner == patient {
+			pseudonyms = append(pseudonyms, pseudonym)
+		}
+	}
+	a.pseudonyms.mu.Unlock()
+
+	a.sessions.mu.Lock()
+	if a.sessions.current != nil && a.sessions.current.Patient == patient {
+		a.sessions.mu.Unlock()
+		return ErasureResult{}, fmt.Errorf("the running session monitors this patient; disconnect first")
+	}
+	sessions := make([]SessionInfo, 0)
+	for _, session := range a.sessions.sessions {
+		if session.Patient == patient {
+			sessions = append(sessions, session)
+		}
+	}
+	a.sessions.mu.Unlock()
+
+	a.alarmLog.mu.Lock()
+	alarms := 0
+	for _, record := range a.alarmLog.records {
+		if inPatientSession(record, sessions) {
+			alarms++
+		}
+	}
+	a.alarmLog.mu.Unlock()
+
+	if len(sessions) == 0 && len(pseudonyms) == 0 {
+		return ErasureResult{}, fmt.Errorf("no data found for patient '%s'", patientAlias)
+	}
+
+	choice, err := runtime.MessageDialog(a.ctx, runtime.MessageDialogOptions{
+		Type:  runtime.QuestionDialog,
+		Title: "Erase patient data",
+		Message: fmt.Sprintf("Permanently delete %d session(s) and %d alarm(s) of patient %s? This cannot be undone.",
+			len(sessions), alarms, patientAlias),
+		Buttons:       []string{"Yes", "No"},
+		DefaultButton: "No",
+	})
+	if err != nil {
+		return ErasureResult{}, fmt.Errorf("confirmation failed: %v", err)
+	}
+	if choice != "Yes" {
+		return ErasureResult{}, fmt.Errorf("erasure cancelled")
+	}
+
+	result := ErasureResult{Pseudonyms: len(pseudonyms)}
+
+	a.sessions.mu.Lock()
+	currentID := int64(-1)
+	if a.sessions.current != nil {
+		currentID = a.sessions.current.ID
+	}
+	kept := make([]SessionInfo, 0, len(a.sessions.sessions))
+	for _, session := range a.sessions.sessions {
+		if session.Patient == patient && session.ID != currentID {
+			result.Sessions++
+			continue
+		}
+		kept = append(kept, session)
+	}
+	a.sessions.sessions = kept
+	if a.sessions.current != nil {
+		a.sessions.current = &a.sessions.sessions[len(a.sessions.sessions)-1]
+	}
+	a.sessions.save()
+	a.sessions.mu.Unlock()
+
+	a.alarmLog.mu.Lock()
+	records := make([]AlarmRecord, 0, len(a.alarmLog.records))
+	for _, record := range a.alarmLog.records {
+		// Alarms still active stay until they clear
+		if inPatientSession(record, sessions) && !record.ClearedAt.IsZero() {
+			result.Alarms++
+			continue
+		}
+		records = append(records, record)
+	}
+	a.alarmLog.records = records
+	err = saveJSONFile(alarmLogFile, records)
+	a.alarmLog.mu.Unlock()
+	if err != nil {
+		return result, fmt.Errorf("failed to save alarm history: %v", err)
+	}
+
+	a.pseudonyms.mu.Lock()
+	for _, pseudonym := range pseudonyms {
+		delete(a.pseudonyms.table.Patients, pseudonym)
+	}
+	err = a.pseudonyms.save()
+	a.pseudonyms.mu.Unlock()
+	if err != nil {
+		return result, fmt.Errorf("failed to save pseudonyms: %v", err)
+	}
+
+	if result.Recordings, result.Snapshots, err = a.deleteSessionFiles(sessions); err != nil {
+		return result, err
+	}
+
+	if result.AuditEntries, err = a.audit.redact(append(pseudonyms, patient)); err != nil {
+		return result, fmt.Errorf("failed to redact audit log: %v", err)
+	}
+
+	detail := fmt.Sprintf("Patient data erased: %d sessions, %d alarms, %d recordings, %d snapshots, %d pseudonyms, %d audit entries redacted",
+		result.Sessions, result.Alarms, result.Recordings, result.Snapshots, result.Pseudonyms, result.AuditEntries)
+	securityLog.Infof("%s", detail)
+	a.audit.record("", AuditErasure, detail)
+	return result, nil
+}
+
+// deleteSessionFiles deletes the recording files and data snapshots of the
+// sessions from the recording folders, and returns how many of each
+func (a *App) deleteSessionFiles(sessions []SessionInfo) (int, int, error) {
+	ids := make(map[int64]bool, len(sessions))
+	for _, session := range sessions {
+		ids[session.ID] = true
+	}
+	var files []string
+	recordings := 0
+	for _, dir := range a.recordingDirs() {
+		for id := range ids {
+			matched, err := sessionRecordingFiles(dir, id)
+			if err != nil {
+				return 0, 0, err
+			}
+			files = append(files, matched...)
+			recordings += len(matched)
+		}
+		matched, err := sessionSnapshotFiles(dir, ids)
+		if err != nil {
+			return 0, 0, err
+		}
+		files = append(files, matched...)
+	}
+	for _, path := range files {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return 0, 0, fmt.Errorf("failed to delete %s: %v", path, err)
+		}
+	}
+	return recordings, len(files) - recordings, nil
+}
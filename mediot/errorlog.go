@@ -0,0 +1,114 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Categories of backend errors
+const (
+	ErrorRead    = "read"    // Reading from the serial port failed
+	ErrorParse   = "parse"   // A line from the device could not be decoded
+	ErrorSink    = "sink"    // Samples could not be recorded, published or passed to a plugin
+	ErrorStorage = "storage" // A data file could not be written
+)
+
+// Backend error intake limits
+const (
+	maxBackendErrors   = 50          // Most recent distinct errors kept
+	backendErrorPeriod = time.Second // Repeats of an error are pushed at most this often
+)
+
+// BackendError is a problem of the data path the UI can show in a banner.
+// Repeats of the same failure update one entry instead of adding one each.
+type BackendError struct {
+	Category string    `json:"category"`
+	Message  string    `json:"message"`          // What failed, the same for every repeat
+	Detail   string    `json:"detail,omitempty"` // The error of the latest repeat
+	First    time.Time `json:"first"`
+	Last     time.Time `json:"last"`
+	Count    int       `json:"count"`
+	pushed   time.Time // Last push to the frontend
+}
+
+// backendErrorLog keeps the recent errors of the background work, which the
+// bindings cannot return to the UI
+type backendErrorLog struct {
+	mu       sync.Mutex
+	recent   []BackendError // Oldest latest repeat first
+	listener func(BackendError)
+}
+
+// backendErrors is shared by every subsystem, including those without the app
+var backendErrors = &backendErrorLog{recent: make([]BackendError, 0)}
+
+// listen sets the function new errors and repeats are pushed to
+func (l *backendErrorLog) listen(fn func(BackendError)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.listener = fn
+}
+
+// report records an error of a category. It does not log; callers log as
+// before.
+func (l *backendErrorLog) report(category, message string, err error) {
+	now := time.Now()
+	entry := BackendError{Category: category, Message: message, First: now, Last: now, Count: 1}
+	if err != nil {
+		entry.Detail = err.Error()
+	}
+
+	l.mu.Lock()
+	for i, e := range l.recent {
+		if e.Category == category && e.Message == message {
+			entry.First, entry.Count, entry.pushed = e.First, e.Count+1, e.pushed
+			l.recent = append(l.recent[:i], l.recent[i+1:]...)
+			break
+		}
+	}
+	push := now.Sub(entry.pushed) >= backendErrorPeriod
+	if push {
+		entry.pushed = now
+	}
+	if len(l.recent) == maxBackendErrors {
+		l.recent = l.recent[1:]
+	}
+	l.recent = append(l.recent, entry)
+	listener := l.listener
+	l.mu.Unlock()
+
+	if push && listener != nil {
+		listener(entry)
+	}
+}
+
+// list returns the recent errors, the one repeated last at the end
+func (l *backendErrorLog) list() []BackendError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]BackendError{}, l.recent...)
+}
+
+// clear forgets the recent errors
+func (l *backendErrorLog) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.recent = make([]BackendError, 0)
+}
+
+// GetLastErrors returns the recent errors of reading, decoding, recording
+// or publishing samples and of saving files, the one repeated last at the
+// end. New errors and repeats are also pushed as app-error events, at most
+// once a second per error.
+func (a *App) GetLastErrors() []BackendError {
+	return backendErrors.list()
+}
+
+// ClearLastErrors forgets the recent errors, e.g. when the banner showing
+// them is dismissed
+func (a *App) ClearLastErrors() {
+	backendErrors.clear()
+}
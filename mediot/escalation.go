@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// escalationFile stores the escalation chain
+const escalationFile = "escalation.json"
+
+// escalationCheckInterval is how often unacknowledged alarms are checked
+const escalationCheckInterval = 5 * time.Second
+
+// Notification tiers an escalation step can use
+const (
+	TierDesktop  = "desktop"
+	TierEmail    = "email"
+	TierTelegram = "telegram"
+	TierSMS      = "sms"
+)
+
+// EscalationStep notifies further tiers once an alarm has been unacknowledged for a while
+type EscalationStep struct {
+	AfterMinutes float64  `json:"afterMinutes"` // Time since the alarm was raised
+	Tiers        []string `json:"tiers"`
+}
+
+// EscalationConfig is the chain of steps applied to unacknowledged alarms
+type EscalationConfig struct {
+	Enabled     bool             `json:"enabled"`
+	MinSeverity string           `json:"minSeverity"` // Alarms below this severity never escalate
+	Steps       []EscalationStep `json:"steps"`       // Sorted by AfterMinutes
+}
+
+// defaultEscalationConfig escalates critical alarms to the desktop, email and SMS tiers
+func defaultEscalationConfig() EscalationConfig {
+	return EscalationConfig{
+		MinSeverity: SeverityCritical,
+		Steps: []EscalationStep{
+			{AfterMinutes: 2, Tiers: []string{TierDesktop}},
+			{AfterMinutes: 5, Tiers: []string{TierEmail}},
+			{AfterMinutes: 10, Tiers: []string{TierSMS}},
+		},
+	}
+}
+
+// escalationTracker remembers how far each active alarm has escalated
+type escalationTracker struct {
+	mu     sync.Mutex
+	config EscalationConfig
+	level  map[int64]int // Steps already fired per alarm ID
+}
+
+// newEscalationTracker creates the tracker and loads the persisted chain
+func newEscalationTracker() *escalationTracker {
+	t := &escalationTracker{
+		config: defaultEscalationConfig(),
+		level:  make(map[int64]int),
+	}
+	if err := loadJSONFile(escalationFile, &t.config); err != nil {
+		alarmsLog.Errorf("Error loading escalation settings: %v", err)
+	}
+	return t
+}
+
+// escalationLoop fires the escalation steps of alarms nobody acknowledges
+func (a *App) escalationLoop() {
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-time.After(escalationCheckInterval):
+		}
+
+		active := a.GetActiveAlarms()
+		silence, silenced := a.alarms.globalSilence()
+		now := time.Now()
+
+		a.escalation.mu.Lock()
+		config := a.escalation.config
+		type firing struct {
+			alarm Alarm
+			step  int
+		}
+		var fire []firing
+		seen := make(map[int64]bool, len(active))
+		for _, alarm := range active {
+			seen[alarm.ID] = true
+			// Silenced alarms resume escalating once they re-arm
+			if (silenced && now.Before(silence.Until)) || now.Before(alarm.SnoozedUntil) {
+				continue
+			}
+			if !config.Enabled || alarm.Acknowledged ||
+				severityRank[alarm.Severity] < severityRank[config.MinSeverity] {
+				continue
+			}
+			for step := a.escalation.level[alarm.ID]; step < len(config.Steps); step++ {
+				if now.Sub(alarm.RaisedAt) < secondsToDuration(config.Steps[step].AfterMinutes*60) {
+					break
+				}
+				fire = append(fire, firing{alarm: alarm, step: step})
+				a.escalation.level[alarm.ID] = step + 1
+			}
+		}
+		// Forget alarms that cleared
+		for id := range a.escalation.level {
+			if !seen[id] {
+				delete(a.escalation.level, id)
+			}
+		}
+		a.escalation.mu.Unlock()
+
+		for _, f := range fire {
+			a.escalate(f.alarm, f.step+1, config.Steps[f.step])
+		}
+	}
+}
+
+// tierUnavailable returns why a tier cannot send with the current
+// settings, or an empty string if it can
+func tierUnavailable(tier string, email EmailConfig, messaging MessagingConfig) string {
+	switch tier {
+	case TierEmail:
+		if !email.Enabled || email.Host == "" || len(email.Recipients) == 0 {
+			return "email alerts are disabled or have no recipients"
+		}
+	case TierTelegram:
+		if !messaging.Telegram.Enabled || len(messaging.Telegram.ChatIDs) == 0 {
+			return "Telegram alerts are disabled or have no chats"
+		}
+	case TierSMS:
+		if !messaging.SMS.Enabled || len(messaging.SMS.To) == 0 {
+			return "SMS alerts are disabled or have no recipients"
+		}
+	}
+	return ""
+}
+
+// alertChannels returns the email and messaging settings in force
+func (a *App) alertChannels() (EmailConfig, MessagingConfig) {
+	a.email.mu.Lock()
+	email := a.email.config
+	a.email.mu.Unlock()
+	a.messaging.mu.Lock()
+	messaging := a.messaging.config
+	a.messaging.mu.Unlock()
+	return email, messaging
+}
+
+// escalate sends an unacknowledged alarm to the tiers of an escalation
+// step. Tiers that cannot send are skipped; the others send in the
+// background, so a slow server holds up neither the other tiers nor the
+// next escalation.
+func (a *App) escalate(alarm Alarm, level int, step EscalationStep) {
+	minutes := time.Since(alarm.RaisedAt).Minutes()
+	title := fmt.Sprintf("mediot: unacknowledged %s alarm", alarm.Severity)
+	text := fmt.Sprintf("Alarm #%d on %s (%.2f beyond limit %.2f) has not been acknowledged for %.0f minutes",
+		alarm.ID, alarm.Channel, alarm.Value, alarm.Limit, minutes)
+	a.alarmLog.escalated(alarm.ID, level)
+
+	email, messaging := a.alertChannels()
+	tiers := make([]string, 0, len(step.Tiers))
+	for _, tier := range step.Tiers {
+		if reason := tierUnavailable(tier, email, messaging); reason != "" {
+			alarmsLog.Warnf("Not escalating alarm #%d through %s: %s", alarm.ID, tier, reason)
+			continue
+		}
+		tiers = append(tiers, tier)
+	}
+	alarmsLog.Infof("Escalating alarm #%d to level %d: %v", alarm.ID, level, tiers)
+
+	for _, tier := range tiers {
+		if tier == TierDesktop {
+			a.sendNotification(title, text, true)
+			continue
+		}
+		goSafe("escalation "+tier, func() {
+			var err error
+			switch tier {
+			case TierEmail:
+				err = sendEmail(email, title, text+"\r\n")
+			case TierTelegram:
+				err = a.messaging.sendTelegram(messaging.Telegram, title+": "+text)
+			case TierSMS:
+				err = a.messaging.sendSMS(messaging.SMS, title+": "+text)
+			}
+			if err != nil {
+				alarmsLog.Errorf("Error escalating alarm #%d through %s: %v", alarm.ID, tier, err)
+			}
+		})
+	}
+}
+
+// GetEscalationConfig returns the escalation chain
+func (a *App) GetEscalationConfig() EscalationConfig {
+	a.escalation.mu.Lock()
+	defer a.escalation.mu.Unlock()
+
+	config := a.escalation.config
+	config.Steps = append([]EscalationStep{}, config.Steps...)
+	return config
+}
+
+// SetEscalationConfig replaces and persists the escalation chain. Alarms
+// already escalated keep their level. An enabled chain may only use tiers
+// whose alerts are enabled and have recipients.
+func (a *App) SetEscalationConfig(config EscalationConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	email, messaging := a.alertChannels()
+	if config.MinSeverity != SeverityWarning && config.MinSeverity != SeverityCritical {
+		return fmt.Errorf("unknown severity '%s'", config.MinSeverity)
+	}
+	for i, step := range config.Steps {
+		if step.AfterMinutes < 0 {
+			return fmt.Errorf("step %d: delay must not be negative", i+1)
+		}
+		if len(step.Tiers) == 0 {
+			return fmt.Errorf("step %d: at least one tier is required", i+1)
+		}
+		for _, tier := range step.Tiers {
+			switch tier {
+			case TierDesktop, TierEmail, TierTelegram, TierSMS:
+			default:
+				return fmt.Errorf("step %d: unknown tier '%s'", i+1, tier)
+			}
+			if reason := tierUnavailable(tier, email, messaging); config.Enabled && reason != "" {
+				return fmt.Errorf("step %d: %s", i+1, reason)
+			}
+		}
+	}
+	sort.SliceStable(config.Steps, func(i, j int) bool { return config.Steps[i].AfterMinutes < config.Steps[j].AfterMinutes })
+
+	a.escalation.mu.Lock()
+	defer a.escalation.mu.Unlock()
+
+	a.escalation.config = config
+	return saveJSONFile(escalationFile, config)
+}
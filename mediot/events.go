@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Event names pushed to the frontend
+const (
+	EventAlarm             = "alarm"
+	EventAlarmAcknowledged = "alarm-acknowledged"
+	EventAlarmSilence      = "alarm-silence"
+	EventAnomaly           = "anomaly"
+	EventPeak              = "peak"
+	EventSignalQuality     = "signal-quality"
+	EventCalibration       = "calibration"
+	EventEpisode           = "episode"
+	EventFirmwareProgress  = "firmware-progress"
+	EventConsoleOutput     = "console-output"
+	EventDeviceHealth      = "device-health"
+	EventDashboard         = "dashboard"
+	EventNewDevice         = "new-device"
+	EventAppLock           = "app-lock"
+	EventSettings          = "settings"
+	EventLog               = "log"
+	EventUpdateProgress    = "update-progress"
+	EventConnection        = "connection"
+	EventRecording         = "recording"
+	EventPlugin            = "plugin"
+	EventProfile           = "profile"
+	EventConfigFile        = "config-file"
+	EventRecordingSchedule = "recording-schedule"
+	EventAnnotation        = "annotation"
+	EventSerialSniffer     = "serial-sniffer"
+	EventAppError          = "app-error"
+	EventBenchmark         = "benchmark"
+)
+
+// emit pushes an event to the frontend once the Wails runtime is available
+func (a *App) emit(name string, data ...interface{}) {
+	if a.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(a.ctx, name, data...)
+}
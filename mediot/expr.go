@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// exprNode is a node of a parsed channel expression
+type exprNode interface {
+	// eval computes the node; ok is false when a referenced channel is missing
+	eval(lookup func(string) (float64, bool)) (value float64, ok bool)
+}
+
+type exprNumber float64
+
+type exprChannel string
+
+type exprUnary struct {
+	operand exprNode
+}
+
+type exprBinary struct {
+	op          byte
+	left, right exprNode
+}
+
+type exprCall struct {
+	name string
+	args []exprNode
+}
+
+func (n exprNumber) eval(func(string) (float64, bool)) (float64, bool) {
+	return float64(n), true
+}
+
+func (n exprChannel) eval(lookup func(string) (float64, bool)) (float64, bool) {
+	return lookup(string(n))
+}
+
+func (n exprUnary) eval(lookup func(string) (float64, bool)) (float64, bool) {
+	v, ok := n.operand.eval(lookup)
+	return -v, ok
+}
+
+func (n exprBinary) eval(lookup func(string) (float64, bool)) (float64, bool) {
+	l, ok := n.left.eval(lookup)
+	if !ok {
+		return 0, false
+	}
+	r, ok := n.right.eval(lookup)
+	if !ok {
+		return 0, false
+	}
+
+	switch n.op {
+	case '+':
+		return l + r, true
+	case '-':
+		return l - r, true
+	case '*':
+		return l * r, true
+	case '/':
+		return l / r, true
+	default: // '^'
+		return math.Pow(l, r), true
+	}
+}
+
+// exprFunctions lists the supported functions and their argument counts
+var exprFunctions = map[string]int{
+	"abs":  1,
+	"sqrt": 1,
+	"log":  1,
+	"exp":  1,
+	"min":  2,
+	"max":  2,
+}
+
+func (n exprCall) eval(lookup func(string) (float64, bool)) (float64, bool) {
+	args := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		v, ok := arg.eval(lookup)
+		if !ok {
+			return 0, false
+		}
+		args[i] = v
+	}
+
+	switch n.name {
+	case "abs":
+		return math.Abs(args[0]), true
+	case "sqrt":
+		return math.Sqrt(args[0]), true
+	case "log":
+		return math.Log(args[0]), true
+	case "exp":
+		return math.Exp(args[0]), true
+	case "min":
+		return math.Min(args[0], args[1]), true
+	default: // "max"
+		return math.Max(args[0], args[1]), true
+	}
+}
+
+// exprParser is a recursive descent parser for channel expressions:
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = unary { ("*" | "/") unary }
+//	unary  = "-" unary | power
+//	power  = atom [ "^" unary ]
+//	atom   = number | channel | function "(" expr { "," expr } ")" | "(" expr ")"
+type exprParser struct {
+	input string
+	pos   int
+}
+
+// parseExpression compiles an expression such as "dia + (sys - dia)/3"
+func parseExpression(input string) (exprNode, error) {
+	p := &exprParser{input: input}
+	node, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected '%c' at position %d", p.input[p.pos], p.pos+1)
+	}
+	return node, nil
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// accept consumes the next character if it is one of the given ones
+func (p *exprParser) accept(chars string) (byte, bool) {
+	p.skipSpaces()
+	if p.pos < len(p.input) && strings.IndexByte(chars, p.input[p.pos]) >= 0 {
+		p.pos++
+		return p.input[p.pos-1], true
+	}
+	return 0, false
+}
+
+func (p *exprParser) parseSum() (exprNode, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("+-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = exprBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseTerm() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("*/")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = exprBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return exprUnary{operand: operand}, nil
+	}
+	return p.parsePower()
+}
+
+func (p *exprParser) parsePower() (exprNode, error) {
+	base, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("^"); !ok {
+		return base, nil
+	}
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return exprBinary{op: '^', left: base, right: exponent}, nil
+}
+
+func (p *exprParser) parseAtom() (exprNode, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	if _, ok := p.accept("("); ok {
+		node, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("missing ')' at position %d", p.pos+1)
+		}
+		return node, nil
+	}
+
+	start := p.pos
+	c := rune(p.input[p.pos])
+	switch {
+	case unicode.IsDigit(c) || c == '.':
+		for p.pos < len(p.input) && (unicode.IsDigit(rune(p.input[p.pos])) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		value, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", p.input[start:p.pos])
+		}
+		return exprNumber(value), nil
+
+	case isIdentifierStart(c):
+		for p.pos < len(p.input) && isIdentifierPart(rune(p.input[p.pos])) {
+			p.pos++
+		}
+		name := p.input[start:p.pos]
+		if _, ok := p.accept("("); !ok {
+			return exprChannel(name), nil
+		}
+		return p.parseCall(name)
+	}
+
+	return nil, fmt.Errorf("unexpected '%c' at position %d", c, p.pos+1)
+}
+
+// parseCall parses the arguments of a function whose opening parenthesis was consumed
+func (p *exprParser) parseCall(name string) (exprNode, error) {
+	arity, ok := exprFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function '%s'", name)
+	}
+
+	var args []exprNode
+	for {
+		arg, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if _, ok := p.accept(","); !ok {
+			break
+		}
+	}
+	if _, ok := p.accept(")"); !ok {
+		return nil, fmt.Errorf("missing ')' after arguments of '%s'", name)
+	}
+	if len(args) != arity {
+		return nil, fmt.Errorf("function '%s' takes %d argument(s), got %d", name, arity, len(args))
+	}
+	return exprCall{name: name, args: args}, nil
+}
+
+func isIdentifierStart(c rune) bool {
+	return unicode.IsLetter(c) || c == '_'
+}
+
+func isIdentifierPart(c rune) bool {
+	return isIdentifierStart(c) || unicode.IsDigit(c)
+}
+
+// isValidChannelName reports whether a name can be referenced from an expression
+func isValidChannelName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if (i == 0 && !isIdentifierStart(c)) || !isIdentifierPart(c) {
+			return false
+		}
+	}
+	return true
+}
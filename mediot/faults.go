@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxFaultHeld is the most data held back by splits and stalls; the
+// overflow is dropped as a device's full buffer would
+const maxFaultHeld = 64 << 10
+
+// FaultConfig describes the faults injected into the stream from the device
+// or the simulator
+type FaultConfig struct {
+	DropRate        float64 `json:"dropRate"`        // Chance each byte is lost, 0..1
+	CorruptRate     float64 `json:"corruptRate"`     // Chance each byte is replaced by a random character, 0..1
+	SplitReads      bool    `json:"splitReads"`      // Cut each read at a random point, splitting lines across reads
+	StallsPerMinute float64 `json:"stallsPerMinute"` // Average rate of stalls during which nothing arrives
+	StallSeconds    float64 `json:"stallSeconds"`    // Length of each stall; what the device sent meanwhile arrives at once after it
+}
+
+// FaultStatus describes the fault injector and what it did since enabled
+type FaultStatus struct {
+	Enabled   bool        `json:"enabled"`
+	Config    FaultConfig `json:"config"`
+	Dropped   int64       `json:"dropped"` // Bytes lost, including the overflow of held data
+	Corrupted int64       `json:"corrupted"`
+	Splits    int64       `json:"splits"`
+	Stalls    int64       `json:"stalls"`
+	Stalled   bool        `json:"stalled"` // A stall is in progress
+}
+
+// faultInjector damages the received bytes before they are buffered and
+// parsed, so the resync, watchdog and reconnect logic can be checked
+// against a bad link. It sits behind the sniffer, which shows the bytes as
+// received, and only affects the sensor stream, not the console or
+// firmware updates.
+type faultInjector struct {
+	mu         sync.Mutex
+	status     FaultStatus
+	random     *rand.Rand
+	held       []byte // Bytes of split reads and stalls, delivered first next time
+	last       time.Time
+	stallUntil time.Time
+}
+
+// newFaultInjector creates a disabled injector
+func newFaultInjector() *faultInjector {
+	return &faultInjector{random: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// checkFaultConfig validates the fault settings
+func checkFaultConfig(config FaultConfig) error {
+	switch {
+	case config.DropRate < 0 || config.DropRate > 1:
+		return fmt.Errorf("drop rate must be between 0 and 1, got %g", config.DropRate)
+	case config.CorruptRate < 0 || config.CorruptRate > 1:
+		return fmt.Errorf("corrupt rate must be between 0 and 1, got %g", config.CorruptRate)
+	case config.StallsPerMinute < 0 || config.StallSeconds < 0:
+		return fmt.Errorf("stall settings must not be negative")
+	}
+	return nil
+}
+
+// enabled reports whether faults are injected
+func (f *faultInjector) enabled() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.status.Enabled
+}
+
+// apply returns the bytes that arrive of data under the configured faults,
+// at most max of them
+func (f *faultInjector) apply(data []byte, max int) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.status.Enabled {
+		return data
+	}
+	config := f.status.Config
+	now := time.Now()
+	elapsed := now.Sub(f.last).Seconds()
+	if f.last.IsZero() {
+		elapsed = 0
+	}
+	f.last = now
+
+	for _, b := range data {
+		if f.random.Float64() < config.DropRate {
+			f.status.Dropped++
+			continue
+		}
+		if f.random.Float64() < config.CorruptRate {
+			b = byte(0x20 + f.random.Intn(0x5f))
+			f.status.Corrupted++
+		}
+		f.held = append(f.held, b)
+	}
+	if overflow := len(f.held) - maxFaultHeld; overflow > 0 {
+		f.held = f.held[overflow:]
+		f.status.Dropped += int64(overflow)
+	}
+
+	if config.StallsPerMinute > 0 && !now.Before(f.stallUntil) &&
+		f.random.Float64() < elapsed*config.StallsPerMinute/60 {
+		f.stallUntil = now.Add(secondsToDuration(config.StallSeconds))
+		f.status.Stalls++
+		serialLog.Infof("Fault injection: stream stalled for %gs", config.StallSeconds)
+	}
+	f.status.Stalled = now.Before(f.stallUntil)
+	if f.status.Stalled || len(f.held) == 0 {
+		return nil
+	}
+
+	n := min(len(f.held), max)
+	if config.SplitReads && n > 1 {
+		n = 1 + f.random.Intn(n-1)
+		f.status.Splits++
+	}
+	result := append([]byte{}, f.held[:n]...)
+	f.held = append(f.held[:0], f.held[n:]...)
+	return result
+}
+
+// encodeSamples writes samples as decimal lines, the format the simulator
+// sends while faults are injected
+func encodeSamples(samples []SensorData) []byte {
+	var data []byte
+	for _, sample := range samples {
+		for i, channel := range rawChannels {
+			if i > 0 {
+				data = append(data, ',')
+			}
+			value, _ := sample.channelValue(channel)
+			data = strconv.AppendFloat(data, value, 'g', -1, 64)
+		}
+		data = append(data, '\n')
+	}
+	return data
+}
+
+// SetFaultInjection starts or stops injecting faults into the stream of
+// the device or the simulator: lost and corrupted bytes, lines split
+// across reads and stalls. It is not persisted. Developer mode only.
+func (a *App) SetFaultInjection(enabled bool, config FaultConfig) (FaultStatus, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return FaultStatus{}, err
+	}
+	if enabled && !a.GetSettings().DeveloperMode {
+		return FaultStatus{}, fmt.Errorf("faults can only be injected in developer mode")
+	}
+	if err := checkFaultConfig(config); err != nil {
+		return FaultStatus{}, err
+	}
+
+	a.faults.mu.Lock()
+	changed := a.faults.status.Enabled != enabled
+	if changed {
+		a.faults.status = FaultStatus{}
+		a.faults.held, a.faults.last, a.faults.stallUntil = nil, time.Time{}, time.Time{}
+	}
+	a.faults.status.Enabled = enabled
+	a.faults.status.Config = config
+	status := a.faults.status
+	a.faults.mu.Unlock()
+
+	if changed && enabled {
+		serialLog.Warnf("Fault injection started: %+v", config)
+		a.audit.record("", AuditSecurity, "Fault injection started")
+	} else if changed {
+		serialLog.Infof("Fault injection stopped")
+		a.audit.record("", AuditSecurity, "Fault injection stopped")
+	}
+	return status, nil
+}
+
+// GetFaultInjection returns the fault injector settings and counts
+func (a *App) GetFaultInjection() FaultStatus {
+	a.faults.mu.Lock()
+	defer a.faults.mu.Unlock()
+
+	return a.faults.status
+}
@@ -0,0 +1,480 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Firmware transfer protocols
+const (
+	FirmwareXModem1K  = "xmodem1k"
+	FirmwareYModem    = "ymodem"
+	FirmwareNordicDFU = "nordic-dfu" // Nordic Secure DFU package (.zip) over the serial or BLE transport
+)
+
+// Ways of putting the device into its bootloader
+const (
+	BootloaderNone    = "none"    // The device is already waiting for the file
+	BootloaderDTR     = "dtr"     // Pulse DTR low, which resets most USB-serial boards
+	BootloaderBreak   = "break"   // Send a break condition
+	BootloaderCommand = "command" // Send a text command to the running firmware
+)
+
+// Firmware update phases reported in the progress events
+const (
+	FirmwarePhaseBootloader = "bootloader"
+	FirmwarePhaseTransfer   = "transfer"
+	FirmwarePhaseVerify     = "verify"
+	FirmwarePhaseDone       = "done"
+	FirmwarePhaseFailed     = "failed"
+	FirmwarePhaseCancelled  = "cancelled"
+)
+
+// Firmware update limits
+const (
+	maxFirmwareSize       = 16 << 20 // Larger images are most likely the wrong file
+	firmwareVerifyTimeout = 10 * time.Second
+	firmwareGateOwner     = "firmware updater"
+)
+
+// errNoBLE is returned for Bluetooth updates on platforms without a supported Bluetooth stack
+var errNoBLE = errors.New("firmware updates over Bluetooth are only supported on Linux (BlueZ)")
+
+// FirmwareCapabilities tells the UI which firmware update options this
+// platform offers
+type FirmwareCapabilities struct {
+	Protocols []string `json:"protocols"`
+	BLE       bool     `json:"ble"` // Nordic DFU over Bluetooth LE, Linux (BlueZ) only
+}
+
+// FirmwareUpdateOptions configures a firmware update
+type FirmwareUpdateOptions struct {
+	Path              string `json:"path"`     // .bin image or Intel .hex file, .zip package for nordic-dfu
+	Protocol          string `json:"protocol"` // xmodem1k, ymodem or nordic-dfu
+	Bootloader        string `json:"bootloader"`
+	BootloaderCommand string `json:"bootloaderCommand,omitempty"` // Sent with a line ending for the command method
+	BootloaderDelayMs int    `json:"bootloaderDelayMs"`           // Time the bootloader needs to start
+	VerifyCommand     string `json:"verifyCommand,omitempty"`     // Asks the device for the CRC-32 of the flashed image; empty skips verification
+	BLEAddress        string `json:"bleAddress,omitempty"`        // Bluetooth address of a nordic-dfu unit in DFU mode; empty sends over the serial port
+}
+
+// FirmwareProgress is pushed to the frontend while a firmware update runs
+type FirmwareProgress struct {
+	Phase      string  `json:"phase"`
+	File       string  `json:"file"`
+	BytesSent  int     `json:"bytesSent"`
+	TotalBytes int     `json:"totalBytes"`
+	Progress   float64 `json:"progress"` // Fraction of the image acknowledged by the device
+	CRC32      string  `json:"crc32"`    // Of the image as sent, for comparison with the device
+	Message    string  `json:"message,omitempty"`
+}
+
+// firmwareUpdater runs at most one firmware update at a time
+type firmwareUpdater struct {
+	mu        sync.Mutex
+	running   bool
+	cancelled bool
+	progress  FirmwareProgress
+}
+
+// newFirmwareUpdater creates an idle updater
+func newFirmwareUpdater() *firmwareUpdater {
+	return &firmwareUpdater{}
+}
+
+// isCancelled reports whether the user cancelled the running update
+func (u *firmwareUpdater) isCancelled() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.cancelled
+}
+
+// readFirmwareImage loads a firmware image, converting Intel HEX files to
+// the binary image they describe
+func readFirmwareImage(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read firmware: %v", err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".hex") {
+		if data, err = parseIntelHex(data); err != nil {
+			return nil, err
+		}
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("firmware image is empty")
+	}
+	if len(data) > maxFirmwareSize {
+		return nil, fmt.Errorf("firmware image of %d bytes exceeds the %d byte limit", len(data), maxFirmwareSize)
+	}
+	return data, nil
+}
+
+// parseIntelHex converts Intel HEX records to a binary image starting at the
+// lowest address. Gaps are filled with 0xFF, the erased flash value. Every
+// record checksum is verified.
+func parseIntelHex(data []byte) ([]byte, error) {
+	memory := make(map[uint32]byte)
+	var base uint32
+	low, high := ^uint32(0), uint32(0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if !strings.HasPrefix(text, ":") {
+			return nil, fmt.Errorf("hex line %d: missing ':'", line)
+		}
+		record, err := hex.DecodeString(text[1:])
+		if err != nil || len(record) < 5 || len(record) != int(record[0])+5 {
+			return nil, fmt.Errorf("hex line %d: malformed record", line)
+		}
+		var sum byte
+		for _, b := range record {
+			sum += b
+		}
+		if sum != 0 {
+			return nil, fmt.Errorf("hex line %d: checksum mismatch", line)
+		}
+
+		payload := record[4 : len(record)-1]
+		offset := uint32(record[1])<<8 | uint32(record[2])
+		switch record[3] {
+		case 0x00: // Data
+			for i, b := range payload {
+				address := base + offset + uint32(i)
+				memory[address] = b
+				if address < low {
+					low = address
+				}
+				if address > high {
+					high = address
+				}
+			}
+		case 0x01: // End of file
+			if len(memory) == 0 {
+				return nil, fmt.Errorf("hex file has no data")
+			}
+			image := bytes.Repeat([]byte{0xFF}, int(high-low+1))
+			for address, b := range memory {
+				image[address-low] = b
+			}
+			return image, nil
+		case 0x02: // Extended segment address
+			if len(payload) != 2 {
+				return nil, fmt.Errorf("hex line %d: malformed segment address", line)
+			}
+			base = (uint32(payload[0])<<8 | uint32(payload[1])) << 4
+		case 0x04: // Extended linear address
+			if len(payload) != 2 {
+				return nil, fmt.Errorf("hex line %d: malformed linear address", line)
+			}
+			base = (uint32(payload[0])<<8 | uint32(payload[1])) << 16
+		case 0x03, 0x05: // Start address, irrelevant for the image
+		default:
+			return nil, fmt.Errorf("hex line %d: unknown record type %02x", line, record[3])
+		}
+	}
+	return nil, fmt.Errorf("hex file has no end-of-file record")
+}
+
+// SelectFirmwareFile asks the user for a firmware image and returns its path,
+// empty if the dialog was cancelled
+func (a *App) SelectFirmwareFile() (string, error) {
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select firmware image",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Firmware images (*.bin, *.hex)", Pattern: "*.bin;*.hex"},
+			{DisplayName: "DFU packages (*.zip)", Pattern: "*.zip"},
+		},
+	})
+}
+
+// StartFirmwareUpdate flashes a firmware image to the connected device in the
+// background. Progress is reported with firmware-progress events; the sensor
+// stream pauses until the update ends.
+//
+// Nordic DFU packages are sent with the Secure DFU object protocol over the
+// serial port, or over Bluetooth LE to the unit at BLEAddress. Bluetooth is
+// only supported on Linux, through BlueZ; GetFirmwareCapabilities reports it.
+// A BLE unit must already advertise in DFU mode, as it has no serial line to
+// enter the bootloader or verify the image with.
+func (a *App) StartFirmwareUpdate(options FirmwareUpdateOptions) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+	telemetry.count(FeatureFirmware)
+
+	switch options.Protocol {
+	case FirmwareXModem1K, FirmwareYModem, FirmwareNordicDFU:
+	default:
+		return fmt.Errorf("unknown transfer protocol '%s'", options.Protocol)
+	}
+	switch options.Bootloader {
+	case BootloaderNone, BootloaderDTR, BootloaderBreak:
+	case BootloaderCommand:
+		if options.BootloaderCommand == "" {
+			return fmt.Errorf("bootloader command is required")
+		}
+	default:
+		return fmt.Errorf("unknown bootloader method '%s'", options.Bootloader)
+	}
+	if options.BootloaderDelayMs < 0 {
+		return fmt.Errorf("bootloader delay must not be negative")
+	}
+	if options.BLEAddress != "" {
+		if !bleSupported {
+			return errNoBLE
+		}
+		address, err := net.ParseMAC(options.BLEAddress)
+		if err != nil || len(address) != 6 {
+			return fmt.Errorf("invalid Bluetooth address '%s'", options.BLEAddress)
+		}
+		options.BLEAddress = strings.ToUpper(address.String())
+		if options.Protocol != FirmwareNordicDFU {
+			return fmt.Errorf("only %s updates can be sent over Bluetooth", FirmwareNordicDFU)
+		}
+		if options.Bootloader != BootloaderNone || options.VerifyCommand != "" {
+			return fmt.Errorf("bootloader methods and verify commands need the serial port")
+		}
+	} else if a.conn.openPort() == nil {
+		return trError(MsgNotConnected)
+	}
+
+	var pkg dfuPackage
+	var err error
+	if options.Protocol == FirmwareNordicDFU {
+		pkg, err = readDFUPackage(options.Path)
+	} else {
+		pkg.image, err = readFirmwareImage(options.Path)
+	}
+	if err != nil {
+		return err
+	}
+	image := pkg.image
+
+	a.firmware.mu.Lock()
+	if a.firmware.running {
+		a.firmware.mu.Unlock()
+		return fmt.Errorf("a firmware update is already running")
+	}
+	// A BLE update leaves the serial stream alone
+	if options.BLEAddress == "" {
+		if err := a.conn.take(firmwareGateOwner); err != nil {
+			a.firmware.mu.Unlock()
+			return err
+		}
+	}
+	a.firmware.running = true
+	a.firmware.cancelled = false
+	a.firmware.progress = FirmwareProgress{
+		Phase:      FirmwarePhaseBootloader,
+		File:       filepath.Base(options.Path),
+		TotalBytes: len(image),
+		CRC32:      fmt.Sprintf("%08x", crc32.ChecksumIEEE(image)),
+	}
+	a.firmware.mu.Unlock()
+
+	goSafe("firmware update", func() { a.runFirmwareUpdate(options, pkg) })
+	return nil
+}
+
+// reportFirmware updates the progress of the running update and pushes it to the frontend
+func (a *App) reportFirmware(update func(p *FirmwareProgress)) {
+	a.firmware.mu.Lock()
+	update(&a.firmware.progress)
+	progress := a.firmware.progress
+	a.firmware.mu.Unlock()
+
+	a.emit(EventFirmwareProgress, progress)
+}
+
+// runFirmwareUpdate performs an update started by StartFirmwareUpdate
+func (a *App) runFirmwareUpdate(options FirmwareUpdateOptions, pkg dfuPackage) {
+	port := a.conn.openPort()
+	var err error
+	if options.BLEAddress != "" {
+		err = a.flashFirmwareBLE(options, pkg)
+	} else {
+		err = a.flashFirmware(options, pkg)
+	}
+
+	if err != nil {
+		phase := FirmwarePhaseFailed
+		if err == errTransferCancelled {
+			phase = FirmwarePhaseCancelled
+		}
+		deviceLog.Warnf("Firmware update %s: %v", phase, err)
+		a.audit.record("", AuditFirmware, fmt.Sprintf("Firmware update with %s %s: %v", filepath.Base(options.Path), phase, err))
+		a.reportFirmware(func(p *FirmwareProgress) { p.Phase, p.Message = phase, err.Error() })
+	} else {
+		deviceLog.Infof("Firmware %s flashed (%d bytes)", filepath.Base(options.Path), len(pkg.image))
+		a.audit.record("", AuditFirmware, fmt.Sprintf("Firmware %s flashed", filepath.Base(options.Path)))
+		a.reportFirmware(func(p *FirmwareProgress) { p.Phase, p.Message = FirmwarePhaseDone, "Firmware updated" })
+	}
+
+	a.firmware.mu.Lock()
+	a.firmware.running = false
+	a.firmware.mu.Unlock()
+	if options.BLEAddress == "" {
+		port.ResetInputBuffer()
+		a.conn.release(firmwareGateOwner)
+	}
+}
+
+// reportSent returns the progress callback of a transfer of total bytes
+func (a *App) reportSent(total int) func(sent int) {
+	return func(sent int) {
+		a.reportFirmware(func(p *FirmwareProgress) {
+			p.BytesSent = sent
+			p.Progress = float64(sent) / float64(total)
+		})
+	}
+}
+
+// flashFirmwareBLE sends a DFU package to a unit waiting in its bootloader
+// over Bluetooth LE
+func (a *App) flashFirmwareBLE(options FirmwareUpdateOptions, pkg dfuPackage) error {
+	transport, disconnect, err := newBLEDFUTransport(options.BLEAddress, a.firmware.isCancelled)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	a.reportFirmware(func(p *FirmwareProgress) { p.Phase = FirmwarePhaseTransfer })
+	sender := &dfuSender{transport: transport, progress: a.reportSent(len(pkg.image)), cancelled: a.firmware.isCancelled}
+	return sender.send(pkg)
+}
+
+// flashFirmware enters the bootloader, transfers the image and verifies it.
+// Only Nordic DFU uses the init packet of the package.
+func (a *App) flashFirmware(options FirmwareUpdateOptions, pkg dfuPackage) error {
+	port := a.conn.openPort()
+	image := pkg.image
+
+	switch options.Bootloader {
+	case BootloaderDTR:
+		if err := port.SetDTR(false); err != nil {
+			return fmt.Errorf("failed to pulse DTR: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		if err := port.SetDTR(true); err != nil {
+			return fmt.Errorf("failed to pulse DTR: %v", err)
+		}
+	case BootloaderBreak:
+		if err := port.Break(250 * time.Millisecond); err != nil {
+			return fmt.Errorf("failed to send break: %v", err)
+		}
+	case BootloaderCommand:
+		if _, err := port.Write([]byte(options.BootloaderCommand + "\r\n")); err != nil {
+			return fmt.Errorf("failed to send bootloader command: %v", err)
+		}
+	}
+	time.Sleep(time.Duration(options.BootloaderDelayMs) * time.Millisecond)
+	// Drop whatever the firmware sent before the bootloader took over
+	port.ResetInputBuffer()
+
+	a.reportFirmware(func(p *FirmwareProgress) { p.Phase = FirmwarePhaseTransfer })
+	progress := a.reportSent(len(image))
+	if options.Protocol == FirmwareNordicDFU {
+		transport, err := newSerialDFUTransport(port, a.firmware.isCancelled)
+		if err != nil {
+			return err
+		}
+		sender := &dfuSender{transport: transport, progress: progress, cancelled: a.firmware.isCancelled}
+		if err := sender.send(pkg); err != nil {
+			return err
+		}
+	} else {
+		sender := &xmodemSender{port: port, cancelled: a.firmware.isCancelled, progress: progress}
+		var err error
+		if options.Protocol == FirmwareYModem {
+			err = sender.sendYModem(options.Path, image)
+		} else {
+			err = sender.sendXModem1K(image)
+		}
+		if err != nil {
+			sender.abort()
+			return err
+		}
+	}
+
+	if options.VerifyCommand == "" {
+		return nil
+	}
+	a.reportFirmware(func(p *FirmwareProgress) { p.Phase = FirmwarePhaseVerify })
+	return verifyFirmware(port, options.VerifyCommand, crc32.ChecksumIEEE(image))
+}
+
+// verifyFirmware asks the device for the CRC-32 of its image and compares it
+// with the one sent. The reply must contain the CRC as 8 hex digits.
+func verifyFirmware(port xmodemPort, command string, crc uint32) error {
+	if _, err := port.Write([]byte(command + "\r\n")); err != nil {
+		return fmt.Errorf("failed to send verify command: %v", err)
+	}
+
+	expected := fmt.Sprintf("%08x", crc)
+	var reply []byte
+	buf := make([]byte, 256)
+	deadline := time.Now().Add(firmwareVerifyTimeout)
+	for time.Now().Before(deadline) {
+		port.SetReadTimeout(100 * time.Millisecond)
+		n, err := port.Read(buf)
+		if err != nil {
+			return fmt.Errorf("failed to read verify reply: %v", err)
+		}
+		reply = append(reply, buf[:n]...)
+		if strings.Contains(strings.ToLower(string(reply)), expected) {
+			return nil
+		}
+	}
+	return fmt.Errorf("device did not confirm CRC-32 %s, replied '%s'", expected, strings.TrimSpace(string(reply)))
+}
+
+// GetFirmwareCapabilities returns the transfer protocols and transports
+// StartFirmwareUpdate accepts on this platform
+func (a *App) GetFirmwareCapabilities() FirmwareCapabilities {
+	return FirmwareCapabilities{
+		Protocols: []string{FirmwareXModem1K, FirmwareYModem, FirmwareNordicDFU},
+		BLE:       bleSupported,
+	}
+}
+
+// CancelFirmwareUpdate aborts the running firmware update
+func (a *App) CancelFirmwareUpdate() error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.firmware.mu.Lock()
+	defer a.firmware.mu.Unlock()
+
+	if !a.firmware.running {
+		return fmt.Errorf("no firmware update is running")
+	}
+	a.firmware.cancelled = true
+	return nil
+}
+
+// GetFirmwareUpdateStatus returns the progress of the running or last firmware update
+func (a *App) GetFirmwareUpdateStatus() FirmwareProgress {
+	a.firmware.mu.Lock()
+	defer a.firmware.mu.Unlock()
+
+	return a.firmware.progress
+}
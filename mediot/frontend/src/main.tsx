@@ -2,6 +2,30 @@ import React from 'react'
 import {createRoot} from 'react-dom/client'
 import './style.css'
 import App from './App'
+import {ReportFrontendError} from '../wailsjs/go/main/App'
+import {main} from '../wailsjs/go/models'
+
+// Send uncaught exceptions to the backend log and diagnostic bundles
+function reportError(error: unknown, source?: string, line?: number, column?: number) {
+    const err = error instanceof Error ? error : new Error(String(error))
+    ReportFrontendError(main.FrontendError.createFrom({
+        name: err.name,
+        message: err.message,
+        stack: err.stack,
+        source,
+        line,
+        column,
+        url: window.location.hash || window.location.pathname,
+        userAgent: navigator.userAgent,
+    })).catch(() => {})
+}
+
+window.addEventListener('error', (event) => {
+    reportError(event.error ?? event.message, event.filename, event.lineno, event.colno)
+})
+window.addEventListener('unhandledrejection', (event) => {
+    reportError(event.reason)
+})
 
 const container = document.getElementById('root')
 
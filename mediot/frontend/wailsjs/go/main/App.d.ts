@@ -2,14 +2,470 @@
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
 
+export function AcknowledgeAlarm(arg1:number,arg2:string,arg3:string):Promise<void>;
+
+export function AddAnnotation(arg1:string,arg2:string):Promise<main.SessionAnnotation>;
+
+export function ApplyAlarmPreset(arg1:string):Promise<void>;
+
+export function ApplyCalibration(arg1:string,arg2:string):Promise<void>;
+
+export function ApplyUpdate():Promise<void>;
+
+export function CancelCalibration(arg1:string):Promise<void>;
+
+export function CancelFirmwareUpdate():Promise<void>;
+
+export function CheckForUpdate():Promise<main.UpdateInfo>;
+
+export function ClearAlarmPreset():Promise<void>;
+
+export function ClearAnomalyOverride(arg1:string):Promise<void>;
+
+export function ClearArtifactDetection(arg1:string):Promise<void>;
+
+export function ClearBaselineCorrection(arg1:string):Promise<void>;
+
+export function ClearConsoleLog():Promise<void>;
+
+export function ClearLastErrors():Promise<void>;
+
+export function ClearQuarantine():Promise<void>;
+
+export function ClearRollupOverride(arg1:string):Promise<void>;
+
+export function ClearSniffer():Promise<void>;
+
 export function ConnectToSerialPort(arg1:string,arg2:number):Promise<main.ConnectionResult>;
 
+export function ConsoleWrite(arg1:string):Promise<void>;
+
+export function CreateBackup(arg1:string,arg2:boolean):Promise<main.BackupManifest>;
+
+export function CreateDiagnosticBundle():Promise<string>;
+
+export function CreateProfile(arg1:string,arg2:string):Promise<main.ProfileInfo>;
+
+export function DeleteAlarmProfile(arg1:string):Promise<void>;
+
+export function DeletePatientData(arg1:string):Promise<main.ErasureResult>;
+
+export function DeleteProfile(arg1:string):Promise<void>;
+
+export function DeleteRecordingSchedule(arg1:number):Promise<void>;
+
+export function DisableEncryption(arg1:string):Promise<void>;
+
 export function DisconnectFromSerialPort():Promise<main.ConnectionResult>;
 
+export function DiscoverDeviceCapabilities():Promise<main.DeviceCapabilities>;
+
+export function EnableEncryption(arg1:string):Promise<void>;
+
+export function ExecuteCommand(arg1:string,arg2:Record<string, any>):Promise<main.CommandResult>;
+
+export function ExportAuditLog(arg1:string,arg2:main.AuditQuery):Promise<number>;
+
+export function ExportRecording(arg1:string,arg2:string):Promise<number>;
+
+export function ExportSessions(arg1:string,arg2:main.ExportOptions):Promise<number>;
+
+export function GetActiveAlarmPreset():Promise<string>;
+
+export function GetActiveAlarms():Promise<Array<main.Alarm>>;
+
+export function GetAlarmHistory(arg1:main.AlarmHistoryFilter):Promise<Array<main.AlarmRecord>>;
+
+export function GetAlarmPresets():Promise<Array<main.AlarmPreset>>;
+
+export function GetAlarmProfileVersions(arg1:string):Promise<Array<main.AlarmProfile>>;
+
+export function GetAlarmProfiles():Promise<Array<main.AlarmProfile>>;
+
+export function GetAlarmRules():Promise<Array<main.AlarmRule>>;
+
+export function GetAlarmSilence():Promise<main.AlarmSilence>;
+
+export function GetAnomalySettings():Promise<main.AnomalySettings>;
+
+export function GetAppInfo():Promise<main.AppInfo>;
+
+export function GetArtifactDetection():Promise<Array<main.ArtifactConfig>>;
+
+export function GetAudioConfig():Promise<main.AudioConfig>;
+
+export function GetAuditLog(arg1:main.AuditQuery):Promise<Array<main.AuditEntry>>;
+
+export function GetBaselineCorrections():Promise<Array<main.BaselineConfig>>;
+
+export function GetCalculusChannels():Promise<Array<main.CalculusChannel>>;
+
+export function GetCalibration(arg1:string):Promise<main.ChannelCalibration>;
+
+export function GetCalibrationSession(arg1:string):Promise<main.CalibrationSession>;
+
+export function GetCertificates():Promise<Array<main.CertificateInfo>>;
+
+export function GetChannelNames():Promise<Record<string, string>>;
+
+export function GetChannelStats(arg1:string,arg2:number):Promise<main.ChannelStats>;
+
+export function GetChannelUnits():Promise<Record<string, string>>;
+
+export function GetCommands():Promise<Array<string>>;
+
+export function GetConnectedDevice():Promise<main.Device>;
+
+export function GetConnectionStatus():Promise<main.ConnectionStatus>;
+
+export function GetConsoleHistory():Promise<Array<string>>;
+
+export function GetConsoleLog():Promise<Array<main.ConsoleEntry>>;
+
+export function GetCorrelation(arg1:string,arg2:string,arg3:number,arg4:number):Promise<main.CorrelationResult>;
+
+export function GetCurrentProfile():Promise<string>;
+
+export function GetCurrentSession():Promise<main.SessionInfo>;
+
+export function GetCurrentUser():Promise<main.UserInfo>;
+
+export function GetDashboard():Promise<main.DashboardSnapshot>;
+
+export function GetDerivedChannels():Promise<Array<main.DerivedChannel>>;
+
+export function GetDeviceHealth():Promise<Array<main.HealthReading>>;
+
+export function GetDeviceRegister(arg1:string):Promise<string>;
+
+export function GetDevices():Promise<Array<main.Device>>;
+
+export function GetEmailConfig():Promise<main.EmailConfig>;
+
+export function GetEncryptionStatus():Promise<main.EncryptionStatus>;
+
+export function GetEpisodeRules():Promise<Array<main.EpisodeRule>>;
+
+export function GetEpisodeSummaries():Promise<Array<main.EpisodeSummary>>;
+
+export function GetEscalationConfig():Promise<main.EscalationConfig>;
+
+export function GetFaultInjection():Promise<main.FaultStatus>;
+
+export function GetFirmwareCapabilities():Promise<main.FirmwareCapabilities>;
+
+export function GetFirmwareUpdateStatus():Promise<main.FirmwareProgress>;
+
+export function GetFrontendErrors():Promise<Array<main.FrontendError>>;
+
+export function GetHRVConfig():Promise<main.HRVConfig>;
+
+export function GetHRVMetrics():Promise<main.HRVMetrics>;
+
+export function GetHealthAlarmRules():Promise<Array<main.AlarmRule>>;
+
+export function GetHistogram(arg1:string,arg2:number,arg3:number,arg4:number,arg5:number):Promise<main.Histogram>;
+
+export function GetLanguages():Promise<Array<string>>;
+
+export function GetLastConnection():Promise<main.LastConnection>;
+
+export function GetLastErrors():Promise<Array<main.BackendError>>;
+
+export function GetLoadedAlarmProfile():Promise<main.AlarmProfileLoad>;
+
+export function GetLockStatus():Promise<main.LockStatus>;
+
+export function GetLogSettings():Promise<main.LogSettings>;
+
+export function GetMQTTConfig():Promise<main.MQTTConfig>;
+
+export function GetMQTTStatus():Promise<main.MQTTStatus>;
+
+export function GetMessagingConfig():Promise<main.MessagingConfig>;
+
+export function GetNotificationConfig():Promise<main.NotificationConfig>;
+
+export function GetParsers():Promise<Array<string>>;
+
+export function GetPeakDetectors():Promise<Array<main.PeakDetectorConfig>>;
+
+export function GetPercentileTracking():Promise<Array<main.PercentileConfig>>;
+
+export function GetPerformanceMetrics():Promise<main.PerformanceMetrics>;
+
+export function GetPluginDirectory():Promise<string>;
+
+export function GetPlugins():Promise<Array<main.PluginInfo>>;
+
+export function GetProfiles():Promise<Array<main.ProfileInfo>>;
+
+export function GetQuarantineStatus():Promise<main.QuarantineStatus>;
+
+export function GetQuarantinedLines(arg1:number):Promise<Array<main.QuarantinedLine>>;
+
+export function GetRecentEpisodes(arg1:string,arg2:number):Promise<Array<main.Episode>>;
+
+export function GetRecentLogs(arg1:string,arg2:string,arg3:number):Promise<Array<main.LogEntry>>;
+
+export function GetRecentPeaks(arg1:string,arg2:number):Promise<Array<main.PeakEvent>>;
+
+export function GetRecordingSchedules():Promise<Array<main.RecordingScheduleStatus>>;
+
+export function GetRecordingStatus():Promise<main.RecordingStatus>;
+
+export function GetResampleRate():Promise<number>;
+
+export function GetRespirationConfig():Promise<main.RespirationConfig>;
+
+export function GetRollupSettings():Promise<main.RollupSettings>;
+
+export function GetRollups(arg1:string,arg2:number,arg3:number):Promise<Array<main.RollupBucket>>;
+
+export function GetSecretStorage():Promise<main.SecretStorage>;
+
 export function GetSerialPorts():Promise<Array<main.SerialPortInfo>>;
 
+export function GetSessions(arg1:number):Promise<Array<main.SessionInfo>>;
+
+export function GetSettingOverrides():Promise<Array<main.SettingOverride>>;
+
+export function GetSettings():Promise<main.Settings>;
+
+export function GetSignalQuality():Promise<Array<main.SignalQuality>>;
+
+export function GetSimulatorConfig():Promise<main.SimulatorConfig>;
+
+export function GetSnifferRecords(arg1:number):Promise<Array<main.SnifferRecord>>;
+
+export function GetSnifferStatus():Promise<main.SnifferStatus>;
+
+export function GetSpO2Config():Promise<main.SpO2Config>;
+
+export function GetStartupAlarmProfile():Promise<string>;
+
+export function GetTelemetry():Promise<main.TelemetryStatus>;
+
+export function GetTrends():Promise<Array<main.TrendConfig>>;
+
+export function GetUnits():Promise<Record<string, Array<string>>>;
+
+export function GetUnprovisionedDevices():Promise<Array<main.Device>>;
+
+export function GetUsers():Promise<Array<main.UserInfo>>;
+
+export function GetWatchdogConfig():Promise<main.WatchdogConfig>;
+
 export function Greet(arg1:string):Promise<string>;
 
+export function ImportCABundle(arg1:string,arg2:string):Promise<main.CertificateInfo>;
+
+export function ImportClientCertificate(arg1:string,arg2:string,arg3:string):Promise<main.CertificateInfo>;
+
+export function InjectLine(arg1:string):Promise<void>;
+
+export function InjectRawData(arg1:Array<number>):Promise<void>;
+
 export function IsConnected():Promise<boolean>;
 
+export function IsSimulatorRunning():Promise<boolean>;
+
+export function LoadAlarmProfile(arg1:string,arg2:number):Promise<void>;
+
+export function LoadRecording(arg1:string):Promise<main.LoadedRecording>;
+
+export function LockApp():Promise<void>;
+
+export function Login(arg1:string,arg2:string):Promise<main.UserInfo>;
+
+export function Logout():Promise<void>;
+
+export function OpenBackupFile():Promise<string>;
+
+export function OpenRecordingFile():Promise<string>;
+
+export function PreviewCalibration(arg1:string):Promise<main.CalibrationPreview>;
+
+export function ProvisionDevice(arg1:string,arg2:main.DeviceProvisioning):Promise<main.ProvisioningResult>;
+
+export function ReadDeviceConfig():Promise<main.DeviceConfig>;
+
+export function ReadResampledData():Promise<Array<main.SensorData>>;
+
 export function ReadSensorData():Promise<Array<main.SensorData>>;
+
+export function ReloadPlugins():Promise<Array<main.PluginInfo>>;
+
+export function RemoveAlarmRule(arg1:string):Promise<void>;
+
+export function RemoveCalculusChannel(arg1:string):Promise<void>;
+
+export function RemoveCertificate(arg1:string):Promise<void>;
+
+export function RemoveDerivedChannel(arg1:string):Promise<void>;
+
+export function RemoveDevice(arg1:string):Promise<void>;
+
+export function RemoveEpisodeRule(arg1:string):Promise<void>;
+
+export function RemovePeakDetector(arg1:string):Promise<void>;
+
+export function RemovePercentileTracking(arg1:string):Promise<void>;
+
+export function RemoveTrend(arg1:string):Promise<void>;
+
+export function RemoveUser(arg1:string):Promise<void>;
+
+export function ReportActivity():Promise<void>;
+
+export function ReportFrontendError(arg1:main.FrontendError):Promise<void>;
+
+export function ResetCalculusChannel(arg1:string):Promise<void>;
+
+export function ResetCalibration(arg1:string):Promise<void>;
+
+export function ResetPerformanceMetrics():Promise<void>;
+
+export function ResolvePseudonym(arg1:string):Promise<string>;
+
+export function RestoreBackup(arg1:string):Promise<main.BackupManifest>;
+
+export function ResumeAlarms():Promise<void>;
+
+export function RunBenchmark(arg1:main.BenchmarkConfig):Promise<main.BenchmarkResult>;
+
+export function RunHealthCheck():Promise<main.HealthCheckReport>;
+
+export function SaveAlarmProfile(arg1:string,arg2:string):Promise<main.AlarmProfile>;
+
+export function SaveDeviceCalibrations():Promise<void>;
+
+export function SaveRecordingSchedule(arg1:main.RecordingSchedule):Promise<main.RecordingSchedule>;
+
+export function SaveUser(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function SelectExportFile(arg1:string):Promise<string>;
+
+export function SelectFirmwareFile():Promise<string>;
+
+export function SelectRecordingFolder():Promise<string>;
+
+export function SendTestEmail():Promise<void>;
+
+export function SendTestMessage(arg1:string):Promise<void>;
+
+export function SendTestNotification():Promise<void>;
+
+export function SetAlarmRule(arg1:main.AlarmRule):Promise<void>;
+
+export function SetAnomalyConfig(arg1:string,arg2:main.AnomalyConfig):Promise<void>;
+
+export function SetArtifactDetection(arg1:main.ArtifactConfig):Promise<void>;
+
+export function SetAudioConfig(arg1:main.AudioConfig):Promise<void>;
+
+export function SetBaselineCorrection(arg1:main.BaselineConfig):Promise<void>;
+
+export function SetCalculusChannel(arg1:main.CalculusChannel):Promise<void>;
+
+export function SetChannelUnit(arg1:string,arg2:string):Promise<void>;
+
+export function SetConsoleOptions(arg1:main.ConsoleOptions):Promise<void>;
+
+export function SetDerivedChannel(arg1:string,arg2:string):Promise<void>;
+
+export function SetDeviceRegister(arg1:string,arg2:string):Promise<void>;
+
+export function SetDeviceSettings(arg1:string,arg2:string,arg3:main.DeviceSettings):Promise<void>;
+
+export function SetEmailConfig(arg1:main.EmailConfig):Promise<void>;
+
+export function SetEncryptionKeyring(arg1:string,arg2:boolean):Promise<void>;
+
+export function SetEpisodeRule(arg1:main.EpisodeRule):Promise<void>;
+
+export function SetEscalationConfig(arg1:main.EscalationConfig):Promise<void>;
+
+export function SetFaultInjection(arg1:boolean,arg2:main.FaultConfig):Promise<main.FaultStatus>;
+
+export function SetHRVConfig(arg1:main.HRVConfig):Promise<void>;
+
+export function SetHealthAlarmRule(arg1:main.AlarmRule):Promise<void>;
+
+export function SetLockPIN(arg1:string,arg2:number):Promise<void>;
+
+export function SetLogLevel(arg1:string,arg2:string):Promise<void>;
+
+export function SetMQTTConfig(arg1:main.MQTTConfig):Promise<void>;
+
+export function SetMessagingConfig(arg1:main.MessagingConfig):Promise<void>;
+
+export function SetNotificationConfig(arg1:main.NotificationConfig):Promise<void>;
+
+export function SetPeakDetector(arg1:main.PeakDetectorConfig):Promise<void>;
+
+export function SetPercentileTracking(arg1:main.PercentileConfig):Promise<void>;
+
+export function SetPluginEnabled(arg1:string,arg2:boolean):Promise<void>;
+
+export function SetResampleRate(arg1:number):Promise<void>;
+
+export function SetRespirationConfig(arg1:main.RespirationConfig):Promise<void>;
+
+export function SetRollupConfig(arg1:string,arg2:main.RollupConfig):Promise<void>;
+
+export function SetSessionPatient(arg1:string):Promise<void>;
+
+export function SetSimulatorConfig(arg1:main.SimulatorConfig):Promise<void>;
+
+export function SetSnifferEnabled(arg1:boolean):Promise<main.SnifferStatus>;
+
+export function SetSpO2Config(arg1:main.SpO2Config):Promise<void>;
+
+export function SetStartupAlarmProfile(arg1:string):Promise<void>;
+
+export function SetTelemetryEnabled(arg1:boolean):Promise<main.TelemetryStatus>;
+
+export function SetTrend(arg1:main.TrendConfig):Promise<void>;
+
+export function SetWatchdogConfig(arg1:main.WatchdogConfig):Promise<void>;
+
+export function SilenceAlarms(arg1:number,arg2:string):Promise<void>;
+
+export function SnoozeAlarm(arg1:number,arg2:number,arg3:string):Promise<void>;
+
+export function StartCalibrationCapture(arg1:string,arg2:number,arg3:number):Promise<void>;
+
+export function StartConsole(arg1:main.ConsoleOptions):Promise<void>;
+
+export function StartFirmwareUpdate(arg1:main.FirmwareUpdateOptions):Promise<void>;
+
+export function StartRecording(arg1:string):Promise<main.RecordingStatus>;
+
+export function StartSimulator(arg1:main.SimulatorConfig):Promise<void>;
+
+export function StopConsole():Promise<void>;
+
+export function StopRecording():Promise<main.RecordingStatus>;
+
+export function StopSimulator():Promise<void>;
+
+export function SwitchProfile(arg1:string):Promise<main.Settings>;
+
+export function SyncDeviceClock():Promise<main.ClockSync>;
+
+export function TakeSnapshot():Promise<string>;
+
+export function TestAlarmSound(arg1:string):Promise<void>;
+
+export function UnlockApp(arg1:string):Promise<void>;
+
+export function UnlockEncryption(arg1:string):Promise<void>;
+
+export function UnsnoozeAlarm(arg1:number):Promise<void>;
+
+export function UpdateSettings(arg1:main.SettingsUpdate):Promise<main.Settings>;
+
+export function VerifySession(arg1:number):Promise<main.SessionVerification>;
+
+export function WriteDeviceConfig(arg1:main.DeviceConfig):Promise<main.DeviceConfig>;
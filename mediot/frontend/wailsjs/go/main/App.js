@@ -2,26 +2,938 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AcknowledgeAlarm(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AcknowledgeAlarm'](arg1, arg2, arg3);
+}
+
+export function AddAnnotation(arg1, arg2) {
+  return window['go']['main']['App']['AddAnnotation'](arg1, arg2);
+}
+
+export function ApplyAlarmPreset(arg1) {
+  return window['go']['main']['App']['ApplyAlarmPreset'](arg1);
+}
+
+export function ApplyCalibration(arg1, arg2) {
+  return window['go']['main']['App']['ApplyCalibration'](arg1, arg2);
+}
+
+export function ApplyUpdate() {
+  return window['go']['main']['App']['ApplyUpdate']();
+}
+
+export function CancelCalibration(arg1) {
+  return window['go']['main']['App']['CancelCalibration'](arg1);
+}
+
+export function CancelFirmwareUpdate() {
+  return window['go']['main']['App']['CancelFirmwareUpdate']();
+}
+
+export function CheckForUpdate() {
+  return window['go']['main']['App']['CheckForUpdate']();
+}
+
+export function ClearAlarmPreset() {
+  return window['go']['main']['App']['ClearAlarmPreset']();
+}
+
+export function ClearAnomalyOverride(arg1) {
+  return window['go']['main']['App']['ClearAnomalyOverride'](arg1);
+}
+
+export function ClearArtifactDetection(arg1) {
+  return window['go']['main']['App']['ClearArtifactDetection'](arg1);
+}
+
+export function ClearBaselineCorrection(arg1) {
+  return window['go']['main']['App']['ClearBaselineCorrection'](arg1);
+}
+
+export function ClearConsoleLog() {
+  return window['go']['main']['App']['ClearConsoleLog']();
+}
+
+export function ClearLastErrors() {
+  return window['go']['main']['App']['ClearLastErrors']();
+}
+
+export function ClearQuarantine() {
+  return window['go']['main']['App']['ClearQuarantine']();
+}
+
+export function ClearRollupOverride(arg1) {
+  return window['go']['main']['App']['ClearRollupOverride'](arg1);
+}
+
+export function ClearSniffer() {
+  return window['go']['main']['App']['ClearSniffer']();
+}
+
 export function ConnectToSerialPort(arg1, arg2) {
   return window['go']['main']['App']['ConnectToSerialPort'](arg1, arg2);
 }
 
+export function ConsoleWrite(arg1) {
+  return window['go']['main']['App']['ConsoleWrite'](arg1);
+}
+
+export function CreateBackup(arg1, arg2) {
+  return window['go']['main']['App']['CreateBackup'](arg1, arg2);
+}
+
+export function CreateDiagnosticBundle() {
+  return window['go']['main']['App']['CreateDiagnosticBundle']();
+}
+
+export function CreateProfile(arg1, arg2) {
+  return window['go']['main']['App']['CreateProfile'](arg1, arg2);
+}
+
+export function DeleteAlarmProfile(arg1) {
+  return window['go']['main']['App']['DeleteAlarmProfile'](arg1);
+}
+
+export function DeletePatientData(arg1) {
+  return window['go']['main']['App']['DeletePatientData'](arg1);
+}
+
+export function DeleteProfile(arg1) {
+  return window['go']['main']['App']['DeleteProfile'](arg1);
+}
+
+export function DeleteRecordingSchedule(arg1) {
+  return window['go']['main']['App']['DeleteRecordingSchedule'](arg1);
+}
+
+export function DisableEncryption(arg1) {
+  return window['go']['main']['App']['DisableEncryption'](arg1);
+}
+
 export function DisconnectFromSerialPort() {
   return window['go']['main']['App']['DisconnectFromSerialPort']();
 }
 
+export function DiscoverDeviceCapabilities() {
+  return window['go']['main']['App']['DiscoverDeviceCapabilities']();
+}
+
+export function EnableEncryption(arg1) {
+  return window['go']['main']['App']['EnableEncryption'](arg1);
+}
+
+export function ExecuteCommand(arg1, arg2) {
+  return window['go']['main']['App']['ExecuteCommand'](arg1, arg2);
+}
+
+export function ExportAuditLog(arg1, arg2) {
+  return window['go']['main']['App']['ExportAuditLog'](arg1, arg2);
+}
+
+export function ExportRecording(arg1, arg2) {
+  return window['go']['main']['App']['ExportRecording'](arg1, arg2);
+}
+
+export function ExportSessions(arg1, arg2) {
+  return window['go']['main']['App']['ExportSessions'](arg1, arg2);
+}
+
+export function GetActiveAlarmPreset() {
+  return window['go']['main']['App']['GetActiveAlarmPreset']();
+}
+
+export function GetActiveAlarms() {
+  return window['go']['main']['App']['GetActiveAlarms']();
+}
+
+export function GetAlarmHistory(arg1) {
+  return window['go']['main']['App']['GetAlarmHistory'](arg1);
+}
+
+export function GetAlarmPresets() {
+  return window['go']['main']['App']['GetAlarmPresets']();
+}
+
+export function GetAlarmProfileVersions(arg1) {
+  return window['go']['main']['App']['GetAlarmProfileVersions'](arg1);
+}
+
+export function GetAlarmProfiles() {
+  return window['go']['main']['App']['GetAlarmProfiles']();
+}
+
+export function GetAlarmRules() {
+  return window['go']['main']['App']['GetAlarmRules']();
+}
+
+export function GetAlarmSilence() {
+  return window['go']['main']['App']['GetAlarmSilence']();
+}
+
+export function GetAnomalySettings() {
+  return window['go']['main']['App']['GetAnomalySettings']();
+}
+
+export function GetAppInfo() {
+  return window['go']['main']['App']['GetAppInfo']();
+}
+
+export function GetArtifactDetection() {
+  return window['go']['main']['App']['GetArtifactDetection']();
+}
+
+export function GetAudioConfig() {
+  return window['go']['main']['App']['GetAudioConfig']();
+}
+
+export function GetAuditLog(arg1) {
+  return window['go']['main']['App']['GetAuditLog'](arg1);
+}
+
+export function GetBaselineCorrections() {
+  return window['go']['main']['App']['GetBaselineCorrections']();
+}
+
+export function GetCalculusChannels() {
+  return window['go']['main']['App']['GetCalculusChannels']();
+}
+
+export function GetCalibration(arg1) {
+  return window['go']['main']['App']['GetCalibration'](arg1);
+}
+
+export function GetCalibrationSession(arg1) {
+  return window['go']['main']['App']['GetCalibrationSession'](arg1);
+}
+
+export function GetCertificates() {
+  return window['go']['main']['App']['GetCertificates']();
+}
+
+export function GetChannelNames() {
+  return window['go']['main']['App']['GetChannelNames']();
+}
+
+export function GetChannelStats(arg1, arg2) {
+  return window['go']['main']['App']['GetChannelStats'](arg1, arg2);
+}
+
+export function GetChannelUnits() {
+  return window['go']['main']['App']['GetChannelUnits']();
+}
+
+export function GetCommands() {
+  return window['go']['main']['App']['GetCommands']();
+}
+
+export function GetConnectedDevice() {
+  return window['go']['main']['App']['GetConnectedDevice']();
+}
+
+export function GetConnectionStatus() {
+  return window['go']['main']['App']['GetConnectionStatus']();
+}
+
+export function GetConsoleHistory() {
+  return window['go']['main']['App']['GetConsoleHistory']();
+}
+
+export function GetConsoleLog() {
+  return window['go']['main']['App']['GetConsoleLog']();
+}
+
+export function GetCorrelation(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['GetCorrelation'](arg1, arg2, arg3, arg4);
+}
+
+export function GetCurrentProfile() {
+  return window['go']['main']['App']['GetCurrentProfile']();
+}
+
+export function GetCurrentSession() {
+  return window['go']['main']['App']['GetCurrentSession']();
+}
+
+export function GetCurrentUser() {
+  return window['go']['main']['App']['GetCurrentUser']();
+}
+
+export function GetDashboard() {
+  return window['go']['main']['App']['GetDashboard']();
+}
+
+export function GetDerivedChannels() {
+  return window['go']['main']['App']['GetDerivedChannels']();
+}
+
+export function GetDeviceHealth() {
+  return window['go']['main']['App']['GetDeviceHealth']();
+}
+
+export function GetDeviceRegister(arg1) {
+  return window['go']['main']['App']['GetDeviceRegister'](arg1);
+}
+
+export function GetDevices() {
+  return window['go']['main']['App']['GetDevices']();
+}
+
+export function GetEmailConfig() {
+  return window['go']['main']['App']['GetEmailConfig']();
+}
+
+export function GetEncryptionStatus() {
+  return window['go']['main']['App']['GetEncryptionStatus']();
+}
+
+export function GetEpisodeRules() {
+  return window['go']['main']['App']['GetEpisodeRules']();
+}
+
+export function GetEpisodeSummaries() {
+  return window['go']['main']['App']['GetEpisodeSummaries']();
+}
+
+export function GetEscalationConfig() {
+  return window['go']['main']['App']['GetEscalationConfig']();
+}
+
+export function GetFaultInjection() {
+  return window['go']['main']['App']['GetFaultInjection']();
+}
+
+export function GetFirmwareCapabilities() {
+  return window['go']['main']['App']['GetFirmwareCapabilities']();
+}
+
+export function GetFirmwareUpdateStatus() {
+  return window['go']['main']['App']['GetFirmwareUpdateStatus']();
+}
+
+export function GetFrontendErrors() {
+  return window['go']['main']['App']['GetFrontendErrors']();
+}
+
+export function GetHRVConfig() {
+  return window['go']['main']['App']['GetHRVConfig']();
+}
+
+export function GetHRVMetrics() {
+  return window['go']['main']['App']['GetHRVMetrics']();
+}
+
+export function GetHealthAlarmRules() {
+  return window['go']['main']['App']['GetHealthAlarmRules']();
+}
+
+export function GetHistogram(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['GetHistogram'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function GetLanguages() {
+  return window['go']['main']['App']['GetLanguages']();
+}
+
+export function GetLastConnection() {
+  return window['go']['main']['App']['GetLastConnection']();
+}
+
+export function GetLastErrors() {
+  return window['go']['main']['App']['GetLastErrors']();
+}
+
+export function GetLoadedAlarmProfile() {
+  return window['go']['main']['App']['GetLoadedAlarmProfile']();
+}
+
+export function GetLockStatus() {
+  return window['go']['main']['App']['GetLockStatus']();
+}
+
+export function GetLogSettings() {
+  return window['go']['main']['App']['GetLogSettings']();
+}
+
+export function GetMQTTConfig() {
+  return window['go']['main']['App']['GetMQTTConfig']();
+}
+
+export function GetMQTTStatus() {
+  return window['go']['main']['App']['GetMQTTStatus']();
+}
+
+export function GetMessagingConfig() {
+  return window['go']['main']['App']['GetMessagingConfig']();
+}
+
+export function GetNotificationConfig() {
+  return window['go']['main']['App']['GetNotificationConfig']();
+}
+
+export function GetParsers() {
+  return window['go']['main']['App']['GetParsers']();
+}
+
+export function GetPeakDetectors() {
+  return window['go']['main']['App']['GetPeakDetectors']();
+}
+
+export function GetPercentileTracking() {
+  return window['go']['main']['App']['GetPercentileTracking']();
+}
+
+export function GetPerformanceMetrics() {
+  return window['go']['main']['App']['GetPerformanceMetrics']();
+}
+
+export function GetPluginDirectory() {
+  return window['go']['main']['App']['GetPluginDirectory']();
+}
+
+export function GetPlugins() {
+  return window['go']['main']['App']['GetPlugins']();
+}
+
+export function GetProfiles() {
+  return window['go']['main']['App']['GetProfiles']();
+}
+
+export function GetQuarantineStatus() {
+  return window['go']['main']['App']['GetQuarantineStatus']();
+}
+
+export function GetQuarantinedLines(arg1) {
+  return window['go']['main']['App']['GetQuarantinedLines'](arg1);
+}
+
+export function GetRecentEpisodes(arg1, arg2) {
+  return window['go']['main']['App']['GetRecentEpisodes'](arg1, arg2);
+}
+
+export function GetRecentLogs(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GetRecentLogs'](arg1, arg2, arg3);
+}
+
+export function GetRecentPeaks(arg1, arg2) {
+  return window['go']['main']['App']['GetRecentPeaks'](arg1, arg2);
+}
+
+export function GetRecordingSchedules() {
+  return window['go']['main']['App']['GetRecordingSchedules']();
+}
+
+export function GetRecordingStatus() {
+  return window['go']['main']['App']['GetRecordingStatus']();
+}
+
+export function GetResampleRate() {
+  return window['go']['main']['App']['GetResampleRate']();
+}
+
+export function GetRespirationConfig() {
+  return window['go']['main']['App']['GetRespirationConfig']();
+}
+
+export function GetRollupSettings() {
+  return window['go']['main']['App']['GetRollupSettings']();
+}
+
+export function GetRollups(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GetRollups'](arg1, arg2, arg3);
+}
+
+export function GetSecretStorage() {
+  return window['go']['main']['App']['GetSecretStorage']();
+}
+
 export function GetSerialPorts() {
   return window['go']['main']['App']['GetSerialPorts']();
 }
 
+export function GetSessions(arg1) {
+  return window['go']['main']['App']['GetSessions'](arg1);
+}
+
+export function GetSettingOverrides() {
+  return window['go']['main']['App']['GetSettingOverrides']();
+}
+
+export function GetSettings() {
+  return window['go']['main']['App']['GetSettings']();
+}
+
+export function GetSignalQuality() {
+  return window['go']['main']['App']['GetSignalQuality']();
+}
+
+export function GetSimulatorConfig() {
+  return window['go']['main']['App']['GetSimulatorConfig']();
+}
+
+export function GetSnifferRecords(arg1) {
+  return window['go']['main']['App']['GetSnifferRecords'](arg1);
+}
+
+export function GetSnifferStatus() {
+  return window['go']['main']['App']['GetSnifferStatus']();
+}
+
+export function GetSpO2Config() {
+  return window['go']['main']['App']['GetSpO2Config']();
+}
+
+export function GetStartupAlarmProfile() {
+  return window['go']['main']['App']['GetStartupAlarmProfile']();
+}
+
+export function GetTelemetry() {
+  return window['go']['main']['App']['GetTelemetry']();
+}
+
+export function GetTrends() {
+  return window['go']['main']['App']['GetTrends']();
+}
+
+export function GetUnits() {
+  return window['go']['main']['App']['GetUnits']();
+}
+
+export function GetUnprovisionedDevices() {
+  return window['go']['main']['App']['GetUnprovisionedDevices']();
+}
+
+export function GetUsers() {
+  return window['go']['main']['App']['GetUsers']();
+}
+
+export function GetWatchdogConfig() {
+  return window['go']['main']['App']['GetWatchdogConfig']();
+}
+
 export function Greet(arg1) {
   return window['go']['main']['App']['Greet'](arg1);
 }
 
+export function ImportCABundle(arg1, arg2) {
+  return window['go']['main']['App']['ImportCABundle'](arg1, arg2);
+}
+
+export function ImportClientCertificate(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ImportClientCertificate'](arg1, arg2, arg3);
+}
+
+export function InjectLine(arg1) {
+  return window['go']['main']['App']['InjectLine'](arg1);
+}
+
+export function InjectRawData(arg1) {
+  return window['go']['main']['App']['InjectRawData'](arg1);
+}
+
 export function IsConnected() {
   return window['go']['main']['App']['IsConnected']();
 }
 
+export function IsSimulatorRunning() {
+  return window['go']['main']['App']['IsSimulatorRunning']();
+}
+
+export function LoadAlarmProfile(arg1, arg2) {
+  return window['go']['main']['App']['LoadAlarmProfile'](arg1, arg2);
+}
+
+export function LoadRecording(arg1) {
+  return window['go']['main']['App']['LoadRecording'](arg1);
+}
+
+export function LockApp() {
+  return window['go']['main']['App']['LockApp']();
+}
+
+export function Login(arg1, arg2) {
+  return window['go']['main']['App']['Login'](arg1, arg2);
+}
+
+export function Logout() {
+  return window['go']['main']['App']['Logout']();
+}
+
+export function OpenBackupFile() {
+  return window['go']['main']['App']['OpenBackupFile']();
+}
+
+export function OpenRecordingFile() {
+  return window['go']['main']['App']['OpenRecordingFile']();
+}
+
+export function PreviewCalibration(arg1) {
+  return window['go']['main']['App']['PreviewCalibration'](arg1);
+}
+
+export function ProvisionDevice(arg1, arg2) {
+  return window['go']['main']['App']['ProvisionDevice'](arg1, arg2);
+}
+
+export function ReadDeviceConfig() {
+  return window['go']['main']['App']['ReadDeviceConfig']();
+}
+
+export function ReadResampledData() {
+  return window['go']['main']['App']['ReadResampledData']();
+}
+
 export function ReadSensorData() {
   return window['go']['main']['App']['ReadSensorData']();
 }
+
+export function ReloadPlugins() {
+  return window['go']['main']['App']['ReloadPlugins']();
+}
+
+export function RemoveAlarmRule(arg1) {
+  return window['go']['main']['App']['RemoveAlarmRule'](arg1);
+}
+
+export function RemoveCalculusChannel(arg1) {
+  return window['go']['main']['App']['RemoveCalculusChannel'](arg1);
+}
+
+export function RemoveCertificate(arg1) {
+  return window['go']['main']['App']['RemoveCertificate'](arg1);
+}
+
+export function RemoveDerivedChannel(arg1) {
+  return window['go']['main']['App']['RemoveDerivedChannel'](arg1);
+}
+
+export function RemoveDevice(arg1) {
+  return window['go']['main']['App']['RemoveDevice'](arg1);
+}
+
+export function RemoveEpisodeRule(arg1) {
+  return window['go']['main']['App']['RemoveEpisodeRule'](arg1);
+}
+
+export function RemovePeakDetector(arg1) {
+  return window['go']['main']['App']['RemovePeakDetector'](arg1);
+}
+
+export function RemovePercentileTracking(arg1) {
+  return window['go']['main']['App']['RemovePercentileTracking'](arg1);
+}
+
+export function RemoveTrend(arg1) {
+  return window['go']['main']['App']['RemoveTrend'](arg1);
+}
+
+export function RemoveUser(arg1) {
+  return window['go']['main']['App']['RemoveUser'](arg1);
+}
+
+export function ReportActivity() {
+  return window['go']['main']['App']['ReportActivity']();
+}
+
+export function ReportFrontendError(arg1) {
+  return window['go']['main']['App']['ReportFrontendError'](arg1);
+}
+
+export function ResetCalculusChannel(arg1) {
+  return window['go']['main']['App']['ResetCalculusChannel'](arg1);
+}
+
+export function ResetCalibration(arg1) {
+  return window['go']['main']['App']['ResetCalibration'](arg1);
+}
+
+export function ResetPerformanceMetrics() {
+  return window['go']['main']['App']['ResetPerformanceMetrics']();
+}
+
+export function ResolvePseudonym(arg1) {
+  return window['go']['main']['App']['ResolvePseudonym'](arg1);
+}
+
+export function RestoreBackup(arg1) {
+  return window['go']['main']['App']['RestoreBackup'](arg1);
+}
+
+export function ResumeAlarms() {
+  return window['go']['main']['App']['ResumeAlarms']();
+}
+
+export function RunBenchmark(arg1) {
+  return window['go']['main']['App']['RunBenchmark'](arg1);
+}
+
+export function RunHealthCheck() {
+  return window['go']['main']['App']['RunHealthCheck']();
+}
+
+export function SaveAlarmProfile(arg1, arg2) {
+  return window['go']['main']['App']['SaveAlarmProfile'](arg1, arg2);
+}
+
+export function SaveDeviceCalibrations() {
+  return window['go']['main']['App']['SaveDeviceCalibrations']();
+}
+
+export function SaveRecordingSchedule(arg1) {
+  return window['go']['main']['App']['SaveRecordingSchedule'](arg1);
+}
+
+export function SaveUser(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SaveUser'](arg1, arg2, arg3);
+}
+
+export function SelectExportFile(arg1) {
+  return window['go']['main']['App']['SelectExportFile'](arg1);
+}
+
+export function SelectFirmwareFile() {
+  return window['go']['main']['App']['SelectFirmwareFile']();
+}
+
+export function SelectRecordingFolder() {
+  return window['go']['main']['App']['SelectRecordingFolder']();
+}
+
+export function SendTestEmail() {
+  return window['go']['main']['App']['SendTestEmail']();
+}
+
+export function SendTestMessage(arg1) {
+  return window['go']['main']['App']['SendTestMessage'](arg1);
+}
+
+export function SendTestNotification() {
+  return window['go']['main']['App']['SendTestNotification']();
+}
+
+export function SetAlarmRule(arg1) {
+  return window['go']['main']['App']['SetAlarmRule'](arg1);
+}
+
+export function SetAnomalyConfig(arg1, arg2) {
+  return window['go']['main']['App']['SetAnomalyConfig'](arg1, arg2);
+}
+
+export function SetArtifactDetection(arg1) {
+  return window['go']['main']['App']['SetArtifactDetection'](arg1);
+}
+
+export function SetAudioConfig(arg1) {
+  return window['go']['main']['App']['SetAudioConfig'](arg1);
+}
+
+export function SetBaselineCorrection(arg1) {
+  return window['go']['main']['App']['SetBaselineCorrection'](arg1);
+}
+
+export function SetCalculusChannel(arg1) {
+  return window['go']['main']['App']['SetCalculusChannel'](arg1);
+}
+
+export function SetChannelUnit(arg1, arg2) {
+  return window['go']['main']['App']['SetChannelUnit'](arg1, arg2);
+}
+
+export function SetConsoleOptions(arg1) {
+  return window['go']['main']['App']['SetConsoleOptions'](arg1);
+}
+
+export function SetDerivedChannel(arg1, arg2) {
+  return window['go']['main']['App']['SetDerivedChannel'](arg1, arg2);
+}
+
+export function SetDeviceRegister(arg1, arg2) {
+  return window['go']['main']['App']['SetDeviceRegister'](arg1, arg2);
+}
+
+export function SetDeviceSettings(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetDeviceSettings'](arg1, arg2, arg3);
+}
+
+export function SetEmailConfig(arg1) {
+  return window['go']['main']['App']['SetEmailConfig'](arg1);
+}
+
+export function SetEncryptionKeyring(arg1, arg2) {
+  return window['go']['main']['App']['SetEncryptionKeyring'](arg1, arg2);
+}
+
+export function SetEpisodeRule(arg1) {
+  return window['go']['main']['App']['SetEpisodeRule'](arg1);
+}
+
+export function SetEscalationConfig(arg1) {
+  return window['go']['main']['App']['SetEscalationConfig'](arg1);
+}
+
+export function SetFaultInjection(arg1, arg2) {
+  return window['go']['main']['App']['SetFaultInjection'](arg1, arg2);
+}
+
+export function SetHRVConfig(arg1) {
+  return window['go']['main']['App']['SetHRVConfig'](arg1);
+}
+
+export function SetHealthAlarmRule(arg1) {
+  return window['go']['main']['App']['SetHealthAlarmRule'](arg1);
+}
+
+export function SetLockPIN(arg1, arg2) {
+  return window['go']['main']['App']['SetLockPIN'](arg1, arg2);
+}
+
+export function SetLogLevel(arg1, arg2) {
+  return window['go']['main']['App']['SetLogLevel'](arg1, arg2);
+}
+
+export function SetMQTTConfig(arg1) {
+  return window['go']['main']['App']['SetMQTTConfig'](arg1);
+}
+
+export function SetMessagingConfig(arg1) {
+  return window['go']['main']['App']['SetMessagingConfig'](arg1);
+}
+
+export function SetNotificationConfig(arg1) {
+  return window['go']['main']['App']['SetNotificationConfig'](arg1);
+}
+
+export function SetPeakDetector(arg1) {
+  return window['go']['main']['App']['SetPeakDetector'](arg1);
+}
+
+export function SetPercentileTracking(arg1) {
+  return window['go']['main']['App']['SetPercentileTracking'](arg1);
+}
+
+export function SetPluginEnabled(arg1, arg2) {
+  return window['go']['main']['App']['SetPluginEnabled'](arg1, arg2);
+}
+
+export function SetResampleRate(arg1) {
+  return window['go']['main']['App']['SetResampleRate'](arg1);
+}
+
+export function SetRespirationConfig(arg1) {
+  return window['go']['main']['App']['SetRespirationConfig'](arg1);
+}
+
+export function SetRollupConfig(arg1, arg2) {
+  return window['go']['main']['App']['SetRollupConfig'](arg1, arg2);
+}
+
+export function SetSessionPatient(arg1) {
+  return window['go']['main']['App']['SetSessionPatient'](arg1);
+}
+
+export function SetSimulatorConfig(arg1) {
+  return window['go']['main']['App']['SetSimulatorConfig'](arg1);
+}
+
+export function SetSnifferEnabled(arg1) {
+  return window['go']['main']['App']['SetSnifferEnabled'](arg1);
+}
+
+export function SetSpO2Config(arg1) {
+  return window['go']['main']['App']['SetSpO2Config'](arg1);
+}
+
+export function SetStartupAlarmProfile(arg1) {
+  return window['go']['main']['App']['SetStartupAlarmProfile'](arg1);
+}
+
+export function SetTelemetryEnabled(arg1) {
+  return window['go']['main']['App']['SetTelemetryEnabled'](arg1);
+}
+
+export function SetTrend(arg1) {
+  return window['go']['main']['App']['SetTrend'](arg1);
+}
+
+export function SetWatchdogConfig(arg1) {
+  return window['go']['main']['App']['SetWatchdogConfig'](arg1);
+}
+
+export function SilenceAlarms(arg1, arg2) {
+  return window['go']['main']['App']['SilenceAlarms'](arg1, arg2);
+}
+
+export function SnoozeAlarm(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SnoozeAlarm'](arg1, arg2, arg3);
+}
+
+export function StartCalibrationCapture(arg1, arg2, arg3) {
+  return window['go']['main']['App']['StartCalibrationCapture'](arg1, arg2, arg3);
+}
+
+export function StartConsole(arg1) {
+  return window['go']['main']['App']['StartConsole'](arg1);
+}
+
+export function StartFirmwareUpdate(arg1) {
+  return window['go']['main']['App']['StartFirmwareUpdate'](arg1);
+}
+
+export function StartRecording(arg1) {
+  return window['go']['main']['App']['StartRecording'](arg1);
+}
+
+export function StartSimulator(arg1) {
+  return window['go']['main']['App']['StartSimulator'](arg1);
+}
+
+export function StopConsole() {
+  return window['go']['main']['App']['StopConsole']();
+}
+
+export function StopRecording() {
+  return window['go']['main']['App']['StopRecording']();
+}
+
+export function StopSimulator() {
+  return window['go']['main']['App']['StopSimulator']();
+}
+
+export function SwitchProfile(arg1) {
+  return window['go']['main']['App']['SwitchProfile'](arg1);
+}
+
+export function SyncDeviceClock() {
+  return window['go']['main']['App']['SyncDeviceClock']();
+}
+
+export function TakeSnapshot() {
+  return window['go']['main']['App']['TakeSnapshot']();
+}
+
+export function TestAlarmSound(arg1) {
+  return window['go']['main']['App']['TestAlarmSound'](arg1);
+}
+
+export function UnlockApp(arg1) {
+  return window['go']['main']['App']['UnlockApp'](arg1);
+}
+
+export function UnlockEncryption(arg1) {
+  return window['go']['main']['App']['UnlockEncryption'](arg1);
+}
+
+export function UnsnoozeAlarm(arg1) {
+  return window['go']['main']['App']['UnsnoozeAlarm'](arg1);
+}
+
+export function UpdateSettings(arg1) {
+  return window['go']['main']['App']['UpdateSettings'](arg1);
+}
+
+export function VerifySession(arg1) {
+  return window['go']['main']['App']['VerifySession'](arg1);
+}
+
+export function WriteDeviceConfig(arg1) {
+  return window['go']['main']['App']['WriteDeviceConfig'](arg1);
+}
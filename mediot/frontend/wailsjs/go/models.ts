@@ -1,36 +1,3377 @@
 export namespace main {
 	
-	export class ConnectionResult {
-	    success: boolean;
+	export class AdaptiveLimits {
+	    severity: string;
+	    lowPercentile: number;
+	    highPercentile: number;
+	    margin: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AdaptiveLimits(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.severity = source["severity"];
+	        this.lowPercentile = source["lowPercentile"];
+	        this.highPercentile = source["highPercentile"];
+	        this.margin = source["margin"];
+	    }
+	}
+	export class Alarm {
+	    id: number;
+	    channel: string;
+	    severity: string;
+	    value: number;
+	    limit: number;
+	    // Go type: time
+	    raisedAt: any;
+	    acknowledged: boolean;
+	    // Go type: time
+	    snoozedUntil: any;
+	    latched: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Alarm(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.channel = source["channel"];
+	        this.severity = source["severity"];
+	        this.value = source["value"];
+	        this.limit = source["limit"];
+	        this.raisedAt = this.convertValues(source["raisedAt"], null);
+	        this.acknowledged = source["acknowledged"];
+	        this.snoozedUntil = this.convertValues(source["snoozedUntil"], null);
+	        this.latched = source["latched"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AlarmHistoryFilter {
+	    channel?: string;
+	    severity?: string;
+	    // Go type: time
+	    since: any;
+	    // Go type: time
+	    until: any;
+	    unacknowledgedOnly: boolean;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlarmHistoryFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.severity = source["severity"];
+	        this.since = this.convertValues(source["since"], null);
+	        this.until = this.convertValues(source["until"], null);
+	        this.unacknowledgedOnly = source["unacknowledgedOnly"];
+	        this.limit = source["limit"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AlarmRule {
+	    channel: string;
+	    warningLow?: number;
+	    warningHigh?: number;
+	    criticalLow?: number;
+	    criticalHigh?: number;
+	    hysteresis: number;
+	    minDurationSeconds: number;
+	    minQuality: number;
+	    qualityChannel?: string;
+	    adaptive?: AdaptiveLimits;
+	    unit?: string;
+	    latching: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlarmRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.warningLow = source["warningLow"];
+	        this.warningHigh = source["warningHigh"];
+	        this.criticalLow = source["criticalLow"];
+	        this.criticalHigh = source["criticalHigh"];
+	        this.hysteresis = source["hysteresis"];
+	        this.minDurationSeconds = source["minDurationSeconds"];
+	        this.minQuality = source["minQuality"];
+	        this.qualityChannel = source["qualityChannel"];
+	        this.adaptive = this.convertValues(source["adaptive"], AdaptiveLimits);
+	        this.unit = source["unit"];
+	        this.latching = source["latching"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AlarmPreset {
+	    name: string;
+	    description: string;
+	    rules: AlarmRule[];
+	
+	    static createFrom(source: any = {}) {
+	        return new AlarmPreset(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.rules = this.convertValues(source["rules"], AlarmRule);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AlarmProfile {
+	    name: string;
+	    description: string;
+	    version: number;
+	    // Go type: time
+	    savedAt: any;
+	    rules: AlarmRule[];
+	
+	    static createFrom(source: any = {}) {
+	        return new AlarmProfile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.version = source["version"];
+	        this.savedAt = this.convertValues(source["savedAt"], null);
+	        this.rules = this.convertValues(source["rules"], AlarmRule);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AlarmProfileLoad {
+	    profile: string;
+	    version: number;
+	    // Go type: time
+	    loadedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlarmProfileLoad(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.profile = source["profile"];
+	        this.version = source["version"];
+	        this.loadedAt = this.convertValues(source["loadedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AlarmSilence {
+	    // Go type: time
+	    start: any;
+	    // Go type: time
+	    until: any;
+	    // Go type: time
+	    endedAt: any;
+	    user: string;
+	    global: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new AlarmSilence(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.start = this.convertValues(source["start"], null);
+	        this.until = this.convertValues(source["until"], null);
+	        this.endedAt = this.convertValues(source["endedAt"], null);
+	        this.user = source["user"];
+	        this.global = source["global"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AlarmRecord {
+	    id: number;
+	    channel: string;
+	    severity: string;
+	    value: number;
+	    limit: number;
 	    message: string;
+	    // Go type: time
+	    raisedAt: any;
+	    // Go type: time
+	    clearedAt: any;
+	    // Go type: time
+	    acknowledgedAt: any;
+	    acknowledgedBy?: string;
+	    note?: string;
+	    escalation: number;
+	    silences?: AlarmSilence[];
+	
+	    static createFrom(source: any = {}) {
+	        return new AlarmRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.channel = source["channel"];
+	        this.severity = source["severity"];
+	        this.value = source["value"];
+	        this.limit = source["limit"];
+	        this.message = source["message"];
+	        this.raisedAt = this.convertValues(source["raisedAt"], null);
+	        this.clearedAt = this.convertValues(source["clearedAt"], null);
+	        this.acknowledgedAt = this.convertValues(source["acknowledgedAt"], null);
+	        this.acknowledgedBy = source["acknowledgedBy"];
+	        this.note = source["note"];
+	        this.escalation = source["escalation"];
+	        this.silences = this.convertValues(source["silences"], AlarmSilence);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class AnomalyConfig {
+	    enabled: boolean;
+	    method: string;
+	    windowSeconds: number;
+	    threshold: number;
+	    minSamples: number;
+	    cooldownSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AnomalyConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.method = source["method"];
+	        this.windowSeconds = source["windowSeconds"];
+	        this.threshold = source["threshold"];
+	        this.minSamples = source["minSamples"];
+	        this.cooldownSeconds = source["cooldownSeconds"];
+	    }
+	}
+	export class AnomalySettings {
+	    default: AnomalyConfig;
+	    overrides: Record<string, AnomalyConfig>;
+	
+	    static createFrom(source: any = {}) {
+	        return new AnomalySettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.default = this.convertValues(source["default"], AnomalyConfig);
+	        this.overrides = this.convertValues(source["overrides"], AnomalyConfig, true);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AppInfo {
+	    version: string;
+	    commit: string;
+	    modified: boolean;
+	    buildDate: string;
+	    goVersion: string;
+	    os: string;
+	    arch: string;
+	    serialVersion: string;
+	    wailsVersion: string;
+	    dataDir: string;
+	    portable: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new AppInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.commit = source["commit"];
+	        this.modified = source["modified"];
+	        this.buildDate = source["buildDate"];
+	        this.goVersion = source["goVersion"];
+	        this.os = source["os"];
+	        this.arch = source["arch"];
+	        this.serialVersion = source["serialVersion"];
+	        this.wailsVersion = source["wailsVersion"];
+	        this.dataDir = source["dataDir"];
+	        this.portable = source["portable"];
+	    }
+	}
+	export class ArtifactConfig {
+	    channel: string;
+	    maxJump: number;
+	    maxSlope: number;
+	    holdSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ArtifactConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.maxJump = source["maxJump"];
+	        this.maxSlope = source["maxSlope"];
+	        this.holdSeconds = source["holdSeconds"];
+	    }
+	}
+	export class AudioConfig {
+	    enabled: boolean;
+	    volume: number;
+	    warningRepeatSeconds: number;
+	    criticalRepeatSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AudioConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.volume = source["volume"];
+	        this.warningRepeatSeconds = source["warningRepeatSeconds"];
+	        this.criticalRepeatSeconds = source["criticalRepeatSeconds"];
+	    }
+	}
+	export class AuditEntry {
+	    id: number;
+	    // Go type: time
+	    time: any;
+	    user: string;
+	    action: string;
+	    detail: string;
+	    patient?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuditEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.time = this.convertValues(source["time"], null);
+	        this.user = source["user"];
+	        this.action = source["action"];
+	        this.detail = source["detail"];
+	        this.patient = source["patient"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AuditQuery {
+	    action?: string;
+	    user?: string;
+	    // Go type: time
+	    since: any;
+	    // Go type: time
+	    until: any;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuditQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.action = source["action"];
+	        this.user = source["user"];
+	        this.since = this.convertValues(source["since"], null);
+	        this.until = this.convertValues(source["until"], null);
+	        this.limit = source["limit"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class BackendError {
+	    category: string;
+	    message: string;
+	    detail?: string;
+	    // Go type: time
+	    first: any;
+	    // Go type: time
+	    last: any;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackendError(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.category = source["category"];
+	        this.message = source["message"];
+	        this.detail = source["detail"];
+	        this.first = this.convertValues(source["first"], null);
+	        this.last = this.convertValues(source["last"], null);
+	        this.count = source["count"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class BackupManifest {
+	    format: number;
+	    appVersion: string;
+	    // Go type: time
+	    createdAt: any;
+	    profiles: string[];
+	    sessions: boolean;
+	    encrypted: boolean;
+	    files: string[];
+	    secrets: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new BackupManifest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.format = source["format"];
+	        this.appVersion = source["appVersion"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.profiles = source["profiles"];
+	        this.sessions = source["sessions"];
+	        this.encrypted = source["encrypted"];
+	        this.files = source["files"];
+	        this.secrets = source["secrets"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class BaselineConfig {
+	    channel: string;
+	    mode: string;
+	    cutoffHz: number;
+	    windowSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BaselineConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.mode = source["mode"];
+	        this.cutoffHz = source["cutoffHz"];
+	        this.windowSeconds = source["windowSeconds"];
+	    }
+	}
+	export class BenchmarkConfig {
+	    startRateHz: number;
+	    maxRateHz: number;
+	    stepSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BenchmarkConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.startRateHz = source["startRateHz"];
+	        this.maxRateHz = source["maxRateHz"];
+	        this.stepSeconds = source["stepSeconds"];
+	    }
+	}
+	export class BenchmarkStep {
+	    rateHz: number;
+	    achievedHz: number;
+	    sustained: boolean;
+	    cpuPercent: number;
+	    allocBytesPerSecond: number;
+	    allocsPerSecond: number;
+	    allocBytesPerSample: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BenchmarkStep(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.rateHz = source["rateHz"];
+	        this.achievedHz = source["achievedHz"];
+	        this.sustained = source["sustained"];
+	        this.cpuPercent = source["cpuPercent"];
+	        this.allocBytesPerSecond = source["allocBytesPerSecond"];
+	        this.allocsPerSecond = source["allocsPerSecond"];
+	        this.allocBytesPerSample = source["allocBytesPerSample"];
+	    }
+	}
+	export class BenchmarkResult {
+	    maxSustainedHz: number;
+	    cpus: number;
+	    os: string;
+	    arch: string;
+	    // Go type: time
+	    startedAt: any;
+	    seconds: number;
+	    steps: BenchmarkStep[];
+	
+	    static createFrom(source: any = {}) {
+	        return new BenchmarkResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.maxSustainedHz = source["maxSustainedHz"];
+	        this.cpus = source["cpus"];
+	        this.os = source["os"];
+	        this.arch = source["arch"];
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	        this.seconds = source["seconds"];
+	        this.steps = this.convertValues(source["steps"], BenchmarkStep);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class CalculusChannel {
+	    name: string;
+	    channel: string;
+	    operator: string;
+	    timeUnitSeconds: number;
+	    smoothingSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CalculusChannel(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.channel = source["channel"];
+	        this.operator = source["operator"];
+	        this.timeUnitSeconds = source["timeUnitSeconds"];
+	        this.smoothingSeconds = source["smoothingSeconds"];
+	    }
+	}
+	export class CalibrationPoint {
+	    reference: number;
+	    measured: number;
+	    samples: number;
+	    // Go type: time
+	    capturedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new CalibrationPoint(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.reference = source["reference"];
+	        this.measured = source["measured"];
+	        this.samples = source["samples"];
+	        this.capturedAt = this.convertValues(source["capturedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Calibration {
+	    gain: number;
+	    offset: number;
+	    points: CalibrationPoint[];
+	    note?: string;
+	    // Go type: time
+	    appliedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Calibration(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.gain = source["gain"];
+	        this.offset = source["offset"];
+	        this.points = this.convertValues(source["points"], CalibrationPoint);
+	        this.note = source["note"];
+	        this.appliedAt = this.convertValues(source["appliedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class CalibrationPreview {
+	    channel: string;
+	    gain: number;
+	    offset: number;
+	    latestRaw: number;
+	    latestCurrent: number;
+	    latestProposed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CalibrationPreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.gain = source["gain"];
+	        this.offset = source["offset"];
+	        this.latestRaw = source["latestRaw"];
+	        this.latestCurrent = source["latestCurrent"];
+	        this.latestProposed = source["latestProposed"];
+	    }
+	}
+	export class CalibrationSession {
+	    channel: string;
+	    points: CalibrationPoint[];
+	    capturing: boolean;
+	    progress: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CalibrationSession(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.points = this.convertValues(source["points"], CalibrationPoint);
+	        this.capturing = source["capturing"];
+	        this.progress = source["progress"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CertificateInfo {
+	    name: string;
+	    kind: string;
+	    subject: string;
+	    issuer: string;
+	    count: number;
+	    // Go type: time
+	    notBefore: any;
+	    // Go type: time
+	    notAfter: any;
+	    expired: boolean;
+	    expiresSoon: boolean;
+	    // Go type: time
+	    importedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new CertificateInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.kind = source["kind"];
+	        this.subject = source["subject"];
+	        this.issuer = source["issuer"];
+	        this.count = source["count"];
+	        this.notBefore = this.convertValues(source["notBefore"], null);
+	        this.notAfter = this.convertValues(source["notAfter"], null);
+	        this.expired = source["expired"];
+	        this.expiresSoon = source["expiresSoon"];
+	        this.importedAt = this.convertValues(source["importedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ChannelCalibration {
+	    current?: Calibration;
+	    history: Calibration[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ChannelCalibration(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.current = this.convertValues(source["current"], Calibration);
+	        this.history = this.convertValues(source["history"], Calibration);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ChannelCapability {
+	    index: number;
+	    name: string;
+	    unit: string;
+	    min?: number;
+	    max?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChannelCapability(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.name = source["name"];
+	        this.unit = source["unit"];
+	        this.min = source["min"];
+	        this.max = source["max"];
+	    }
+	}
+	export class ChannelStats {
+	    channel: string;
+	    windowSeconds: number;
+	    count: number;
+	    min: number;
+	    max: number;
+	    mean: number;
+	    median: number;
+	    rms: number;
+	    stdDev: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChannelStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.windowSeconds = source["windowSeconds"];
+	        this.count = source["count"];
+	        this.min = source["min"];
+	        this.max = source["max"];
+	        this.mean = source["mean"];
+	        this.median = source["median"];
+	        this.rms = source["rms"];
+	        this.stdDev = source["stdDev"];
+	    }
+	}
+	export class ClockSync {
+	    protocol: string;
+	    // Go type: time
+	    hostTime: any;
+	    // Go type: time
+	    deviceTime: any;
+	    roundTripMs: number;
+	    offsetMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ClockSync(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.protocol = source["protocol"];
+	        this.hostTime = this.convertValues(source["hostTime"], null);
+	        this.deviceTime = this.convertValues(source["deviceTime"], null);
+	        this.roundTripMs = source["roundTripMs"];
+	        this.offsetMs = source["offsetMs"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CommandResult {
+	    command: string;
+	    result?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new CommandResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.command = source["command"];
+	        this.result = source["result"];
+	    }
+	}
+	export class ConnectionResult {
+	    success: boolean;
+	    code?: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConnectionResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.success = source["success"];
+	        this.code = source["code"];
+	        this.message = source["message"];
+	    }
+	}
+	export class ConnectionStatus {
+	    state: string;
+	    reason?: string;
+	    port?: string;
+	    baudRate?: number;
+	    // Go type: time
+	    since: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConnectionStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.state = source["state"];
+	        this.reason = source["reason"];
+	        this.port = source["port"];
+	        this.baudRate = source["baudRate"];
+	        this.since = this.convertValues(source["since"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ConsoleEntry {
+	    direction: string;
+	    text: string;
+	    // Go type: time
+	    timestamp: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConsoleEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.direction = source["direction"];
+	        this.text = source["text"];
+	        this.timestamp = this.convertValues(source["timestamp"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ConsoleOptions {
+	    mode: string;
+	    lineEnding: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConsoleOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.lineEnding = source["lineEnding"];
+	    }
+	}
+	export class CorrelationResult {
+	    channelA: string;
+	    channelB: string;
+	    windowSeconds: number;
+	    samples: number;
+	    pearson: number;
+	    bestLag: number;
+	    bestPearson: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CorrelationResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channelA = source["channelA"];
+	        this.channelB = source["channelB"];
+	        this.windowSeconds = source["windowSeconds"];
+	        this.samples = source["samples"];
+	        this.pearson = source["pearson"];
+	        this.bestLag = source["bestLag"];
+	        this.bestPearson = source["bestPearson"];
+	    }
+	}
+	export class HealthReading {
+	    channel: string;
+	    value: number;
+	    unit: string;
+	    // Go type: time
+	    updatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new HealthReading(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.value = source["value"];
+	        this.unit = source["unit"];
+	        this.updatedAt = this.convertValues(source["updatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DeviceOverview {
+	    device: string;
+	    name: string;
+	    port: string;
+	    sessionId: number;
+	    status: string;
+	    // Go type: time
+	    lastSampleAt: any;
+	    values: Record<string, number>;
+	    alarmSeverity: string;
+	    activeAlarms: number;
+	    unacknowledged: number;
+	    health: HealthReading[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DeviceOverview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.device = source["device"];
+	        this.name = source["name"];
+	        this.port = source["port"];
+	        this.sessionId = source["sessionId"];
+	        this.status = source["status"];
+	        this.lastSampleAt = this.convertValues(source["lastSampleAt"], null);
+	        this.values = source["values"];
+	        this.alarmSeverity = source["alarmSeverity"];
+	        this.activeAlarms = source["activeAlarms"];
+	        this.unacknowledged = source["unacknowledged"];
+	        this.health = this.convertValues(source["health"], HealthReading);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DashboardSnapshot {
+	    devices: DeviceOverview[];
+	    // Go type: time
+	    updatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new DashboardSnapshot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.devices = this.convertValues(source["devices"], DeviceOverview);
+	        this.updatedAt = this.convertValues(source["updatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DerivedChannel {
+	    name: string;
+	    expression: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DerivedChannel(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.expression = source["expression"];
+	    }
+	}
+	export class DeviceCapabilities {
+	    sampleRateHz: number;
+	    channels: ChannelCapability[];
+	    // Go type: time
+	    discoveredAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeviceCapabilities(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sampleRateHz = source["sampleRateHz"];
+	        this.channels = this.convertValues(source["channels"], ChannelCapability);
+	        this.discoveredAt = this.convertValues(source["discoveredAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DeviceSettings {
+	    parser: string;
+	    calibrations: Record<string, Calibration>;
+	    channelNames: Record<string, string>;
+	    alarmProfile: string;
+	    clockProtocol: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeviceSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.parser = source["parser"];
+	        this.calibrations = this.convertValues(source["calibrations"], Calibration, true);
+	        this.channelNames = source["channelNames"];
+	        this.alarmProfile = source["alarmProfile"];
+	        this.clockProtocol = source["clockProtocol"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Device {
+	    id: string;
+	    name: string;
+	    vid?: string;
+	    pid?: string;
+	    serialNumber?: string;
+	    product?: string;
+	    location: string;
+	    assetId?: string;
+	    // Go type: time
+	    provisioned?: any;
+	    lastPort: string;
+	    // Go type: time
+	    firstSeen: any;
+	    // Go type: time
+	    lastSeen: any;
+	    settings: DeviceSettings;
+	    capabilities?: DeviceCapabilities;
+	
+	    static createFrom(source: any = {}) {
+	        return new Device(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.vid = source["vid"];
+	        this.pid = source["pid"];
+	        this.serialNumber = source["serialNumber"];
+	        this.product = source["product"];
+	        this.location = source["location"];
+	        this.assetId = source["assetId"];
+	        this.provisioned = this.convertValues(source["provisioned"], null);
+	        this.lastPort = source["lastPort"];
+	        this.firstSeen = this.convertValues(source["firstSeen"], null);
+	        this.lastSeen = this.convertValues(source["lastSeen"], null);
+	        this.settings = this.convertValues(source["settings"], DeviceSettings);
+	        this.capabilities = this.convertValues(source["capabilities"], DeviceCapabilities);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class DeviceConfig {
+	    sampleRateHz: number;
+	    gain: Record<string, number>;
+	    channelEnabled: Record<string, boolean>;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeviceConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sampleRateHz = source["sampleRateHz"];
+	        this.gain = source["gain"];
+	        this.channelEnabled = source["channelEnabled"];
+	    }
+	}
+	
+	export class DeviceProvisioning {
+	    name: string;
+	    location: string;
+	    assetId: string;
+	    writeId: boolean;
+	    parser: string;
+	    alarmProfile: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeviceProvisioning(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.location = source["location"];
+	        this.assetId = source["assetId"];
+	        this.writeId = source["writeId"];
+	        this.parser = source["parser"];
+	        this.alarmProfile = source["alarmProfile"];
+	    }
+	}
+	
+	export class EmailConfig {
+	    enabled: boolean;
+	    host: string;
+	    port: number;
+	    security: string;
+	    username?: string;
+	    password?: string;
+	    from: string;
+	    recipients: string[];
+	    minIntervalMinutes: number;
+	    streamLossMinutes: number;
+	    includeWarningAlarms: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new EmailConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.security = source["security"];
+	        this.username = source["username"];
+	        this.password = source["password"];
+	        this.from = source["from"];
+	        this.recipients = source["recipients"];
+	        this.minIntervalMinutes = source["minIntervalMinutes"];
+	        this.streamLossMinutes = source["streamLossMinutes"];
+	        this.includeWarningAlarms = source["includeWarningAlarms"];
+	    }
+	}
+	export class EncryptionStatus {
+	    enabled: boolean;
+	    unlocked: boolean;
+	    keyring: boolean;
+	    files: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new EncryptionStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.unlocked = source["unlocked"];
+	        this.keyring = source["keyring"];
+	        this.files = source["files"];
+	    }
+	}
+	export class Episode {
+	    name: string;
+	    channel: string;
+	    phase: string;
+	    // Go type: time
+	    startedAt: any;
+	    // Go type: time
+	    endedAt: any;
+	    durationSeconds: number;
+	    extreme: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Episode(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.channel = source["channel"];
+	        this.phase = source["phase"];
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	        this.endedAt = this.convertValues(source["endedAt"], null);
+	        this.durationSeconds = source["durationSeconds"];
+	        this.extreme = source["extreme"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class EpisodeRule {
+	    name: string;
+	    channel: string;
+	    direction: string;
+	    enterThreshold: number;
+	    exitThreshold: number;
+	    debounceSeconds: number;
+	    cooldownSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new EpisodeRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.channel = source["channel"];
+	        this.direction = source["direction"];
+	        this.enterThreshold = source["enterThreshold"];
+	        this.exitThreshold = source["exitThreshold"];
+	        this.debounceSeconds = source["debounceSeconds"];
+	        this.cooldownSeconds = source["cooldownSeconds"];
+	    }
+	}
+	export class EpisodeSummary {
+	    name: string;
+	    count: number;
+	    active: boolean;
+	    totalDurationSeconds: number;
+	    longestSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new EpisodeSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.count = source["count"];
+	        this.active = source["active"];
+	        this.totalDurationSeconds = source["totalDurationSeconds"];
+	        this.longestSeconds = source["longestSeconds"];
+	    }
+	}
+	export class ErasureResult {
+	    sessions: number;
+	    alarms: number;
+	    pseudonyms: number;
+	    auditEntries: number;
+	    recordings: number;
+	    snapshots: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ErasureResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sessions = source["sessions"];
+	        this.alarms = source["alarms"];
+	        this.pseudonyms = source["pseudonyms"];
+	        this.auditEntries = source["auditEntries"];
+	        this.recordings = source["recordings"];
+	        this.snapshots = source["snapshots"];
+	    }
+	}
+	export class EscalationStep {
+	    afterMinutes: number;
+	    tiers: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new EscalationStep(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.afterMinutes = source["afterMinutes"];
+	        this.tiers = source["tiers"];
+	    }
+	}
+	export class EscalationConfig {
+	    enabled: boolean;
+	    minSeverity: string;
+	    steps: EscalationStep[];
+	
+	    static createFrom(source: any = {}) {
+	        return new EscalationConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.minSeverity = source["minSeverity"];
+	        this.steps = this.convertValues(source["steps"], EscalationStep);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class ExportOptions {
+	    pseudonymize: boolean;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pseudonymize = source["pseudonymize"];
+	        this.limit = source["limit"];
+	    }
+	}
+	export class FaultConfig {
+	    dropRate: number;
+	    corruptRate: number;
+	    splitReads: boolean;
+	    stallsPerMinute: number;
+	    stallSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FaultConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dropRate = source["dropRate"];
+	        this.corruptRate = source["corruptRate"];
+	        this.splitReads = source["splitReads"];
+	        this.stallsPerMinute = source["stallsPerMinute"];
+	        this.stallSeconds = source["stallSeconds"];
+	    }
+	}
+	export class FaultStatus {
+	    enabled: boolean;
+	    config: FaultConfig;
+	    dropped: number;
+	    corrupted: number;
+	    splits: number;
+	    stalls: number;
+	    stalled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FaultStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.config = this.convertValues(source["config"], FaultConfig);
+	        this.dropped = source["dropped"];
+	        this.corrupted = source["corrupted"];
+	        this.splits = source["splits"];
+	        this.stalls = source["stalls"];
+	        this.stalled = source["stalled"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FirmwareCapabilities {
+	    protocols: string[];
+	    ble: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FirmwareCapabilities(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.protocols = source["protocols"];
+	        this.ble = source["ble"];
+	    }
+	}
+	export class FirmwareProgress {
+	    phase: string;
+	    file: string;
+	    bytesSent: number;
+	    totalBytes: number;
+	    progress: number;
+	    crc32: string;
+	    message?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FirmwareProgress(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.phase = source["phase"];
+	        this.file = source["file"];
+	        this.bytesSent = source["bytesSent"];
+	        this.totalBytes = source["totalBytes"];
+	        this.progress = source["progress"];
+	        this.crc32 = source["crc32"];
+	        this.message = source["message"];
+	    }
+	}
+	export class FirmwareUpdateOptions {
+	    path: string;
+	    protocol: string;
+	    bootloader: string;
+	    bootloaderCommand?: string;
+	    bootloaderDelayMs: number;
+	    verifyCommand?: string;
+	    bleAddress?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FirmwareUpdateOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.protocol = source["protocol"];
+	        this.bootloader = source["bootloader"];
+	        this.bootloaderCommand = source["bootloaderCommand"];
+	        this.bootloaderDelayMs = source["bootloaderDelayMs"];
+	        this.verifyCommand = source["verifyCommand"];
+	        this.bleAddress = source["bleAddress"];
+	    }
+	}
+	export class FrontendError {
+	    name: string;
+	    message: string;
+	    stack?: string;
+	    componentStack?: string;
+	    source?: string;
+	    line?: number;
+	    column?: number;
+	    url?: string;
+	    userAgent?: string;
+	    // Go type: time
+	    timestamp: any;
+	    repeats?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FrontendError(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.message = source["message"];
+	        this.stack = source["stack"];
+	        this.componentStack = source["componentStack"];
+	        this.source = source["source"];
+	        this.line = source["line"];
+	        this.column = source["column"];
+	        this.url = source["url"];
+	        this.userAgent = source["userAgent"];
+	        this.timestamp = this.convertValues(source["timestamp"], null);
+	        this.repeats = source["repeats"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HRVConfig {
+	    enabled: boolean;
+	    channel: string;
+	    minProminence: number;
+	    windowSeconds: number;
+	    updateIntervalSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HRVConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.channel = source["channel"];
+	        this.minProminence = source["minProminence"];
+	        this.windowSeconds = source["windowSeconds"];
+	        this.updateIntervalSeconds = source["updateIntervalSeconds"];
+	    }
+	}
+	export class HRVMetrics {
+	    beats: number;
+	    heartRate: number;
+	    sdnn: number;
+	    rmssd: number;
+	    pnn50: number;
+	    lf: number;
+	    hf: number;
+	    lfhf: number;
+	    // Go type: time
+	    updated: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new HRVMetrics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.beats = source["beats"];
+	        this.heartRate = source["heartRate"];
+	        this.sdnn = source["sdnn"];
+	        this.rmssd = source["rmssd"];
+	        this.pnn50 = source["pnn50"];
+	        this.lf = source["lf"];
+	        this.hf = source["hf"];
+	        this.lfhf = source["lfhf"];
+	        this.updated = this.convertValues(source["updated"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HealthCheck {
+	    name: string;
+	    status: string;
+	    detail: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new HealthCheck(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.status = source["status"];
+	        this.detail = source["detail"];
+	    }
+	}
+	export class HealthCheckReport {
+	    status: string;
+	    checks: HealthCheck[];
+	    // Go type: time
+	    at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new HealthCheckReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.status = source["status"];
+	        this.checks = this.convertValues(source["checks"], HealthCheck);
+	        this.at = this.convertValues(source["at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class Histogram {
+	    channel: string;
+	    windowSeconds: number;
+	    min: number;
+	    max: number;
+	    edges: number[];
+	    counts: number[];
+	    total: number;
+	    underflow: number;
+	    overflow: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Histogram(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.windowSeconds = source["windowSeconds"];
+	        this.min = source["min"];
+	        this.max = source["max"];
+	        this.edges = source["edges"];
+	        this.counts = source["counts"];
+	        this.total = source["total"];
+	        this.underflow = source["underflow"];
+	        this.overflow = source["overflow"];
+	    }
+	}
+	export class LastConnection {
+	    port: string;
+	    baudRate: number;
+	    deviceId: string;
+	    recording: boolean;
+	    recordingDir?: string;
+	    // Go type: time
+	    at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new LastConnection(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.port = source["port"];
+	        this.baudRate = source["baudRate"];
+	        this.deviceId = source["deviceId"];
+	        this.recording = source["recording"];
+	        this.recordingDir = source["recordingDir"];
+	        this.at = this.convertValues(source["at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LatencyStats {
+	    count: number;
+	    meanMs: number;
+	    p50Ms: number;
+	    p95Ms: number;
+	    p99Ms: number;
+	    maxMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LatencyStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.count = source["count"];
+	        this.meanMs = source["meanMs"];
+	        this.p50Ms = source["p50Ms"];
+	        this.p95Ms = source["p95Ms"];
+	        this.p99Ms = source["p99Ms"];
+	        this.maxMs = source["maxMs"];
+	    }
+	}
+	export class SensorData {
+	    value1: number;
+	    value2: number;
+	    value3: number;
+	    derived?: Record<string, number>;
+	    artifacts?: string[];
+	    // Go type: time
+	    timestamp: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new SensorData(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.value1 = source["value1"];
+	        this.value2 = source["value2"];
+	        this.value3 = source["value3"];
+	        this.derived = source["derived"];
+	        this.artifacts = source["artifacts"];
+	        this.timestamp = this.convertValues(source["timestamp"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LoadedRecording {
+	    samples: SensorData[];
+	    cut: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LoadedRecording(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.samples = this.convertValues(source["samples"], SensorData);
+	        this.cut = source["cut"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LockStatus {
+	    enabled: boolean;
+	    locked: boolean;
+	    idleMinutes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LockStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.locked = source["locked"];
+	        this.idleMinutes = source["idleMinutes"];
+	    }
+	}
+	export class LogEntry {
+	    // Go type: time
+	    time: any;
+	    level: string;
+	    component: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LogEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = this.convertValues(source["time"], null);
+	        this.level = source["level"];
+	        this.component = source["component"];
+	        this.message = source["message"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LogSettings {
+	    directory: string;
+	    components: string[];
+	    levels: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new LogSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.directory = source["directory"];
+	        this.components = source["components"];
+	        this.levels = source["levels"];
+	    }
+	}
+	export class MQTTConfig {
+	    enabled: boolean;
+	    broker: string;
+	    topic: string;
+	    clientId: string;
+	    username: string;
+	    password?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MQTTConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.broker = source["broker"];
+	        this.topic = source["topic"];
+	        this.clientId = source["clientId"];
+	        this.username = source["username"];
+	        this.password = source["password"];
+	    }
+	}
+	export class MQTTStatus {
+	    connected: boolean;
+	    published: number;
+	    dropped: number;
+	    lastError?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MQTTStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.connected = source["connected"];
+	        this.published = source["published"];
+	        this.dropped = source["dropped"];
+	        this.lastError = source["lastError"];
+	    }
+	}
+	export class SMSConfig {
+	    enabled: boolean;
+	    accountSid: string;
+	    authToken?: string;
+	    from: string;
+	    to: string[];
+	    minSeverity: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SMSConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.accountSid = source["accountSid"];
+	        this.authToken = source["authToken"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.minSeverity = source["minSeverity"];
+	    }
+	}
+	export class TelegramConfig {
+	    enabled: boolean;
+	    botToken?: string;
+	    chatIds: string[];
+	    minSeverity: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TelegramConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.botToken = source["botToken"];
+	        this.chatIds = source["chatIds"];
+	        this.minSeverity = source["minSeverity"];
+	    }
+	}
+	export class MessagingConfig {
+	    telegram: TelegramConfig;
+	    sms: SMSConfig;
+	
+	    static createFrom(source: any = {}) {
+	        return new MessagingConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.telegram = this.convertValues(source["telegram"], TelegramConfig);
+	        this.sms = this.convertValues(source["sms"], SMSConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class NotificationConfig {
+	    enabled: boolean;
+	    minSeverity: string;
+	    onlyWhenMinimized: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new NotificationConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.minSeverity = source["minSeverity"];
+	        this.onlyWhenMinimized = source["onlyWhenMinimized"];
+	    }
+	}
+	export class PeakDetectorConfig {
+	    channel: string;
+	    minProminence: number;
+	    minDistanceSeconds: number;
+	    troughs: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PeakDetectorConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.minProminence = source["minProminence"];
+	        this.minDistanceSeconds = source["minDistanceSeconds"];
+	        this.troughs = source["troughs"];
+	    }
+	}
+	export class PeakEvent {
+	    channel: string;
+	    type: string;
+	    // Go type: time
+	    timestamp: any;
+	    amplitude: number;
+	    prominence: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PeakEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.type = source["type"];
+	        this.timestamp = this.convertValues(source["timestamp"], null);
+	        this.amplitude = source["amplitude"];
+	        this.prominence = source["prominence"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PercentileConfig {
+	    channel: string;
+	    windowSeconds: number;
+	    percentiles: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PercentileConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.windowSeconds = source["windowSeconds"];
+	        this.percentiles = source["percentiles"];
+	    }
+	}
+	export class PerformanceMetrics {
+	    windowSeconds: number;
+	    bytesPerSecond: number;
+	    linesPerSecond: number;
+	    samplesPerSecond: number;
+	    totalBytes: number;
+	    totalLines: number;
+	    totalSamples: number;
+	    pipeline: LatencyStats;
+	    delivery: LatencyStats;
+	    endToEnd: LatencyStats;
+	    pending: number;
+	    // Go type: time
+	    since: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new PerformanceMetrics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.windowSeconds = source["windowSeconds"];
+	        this.bytesPerSecond = source["bytesPerSecond"];
+	        this.linesPerSecond = source["linesPerSecond"];
+	        this.samplesPerSecond = source["samplesPerSecond"];
+	        this.totalBytes = source["totalBytes"];
+	        this.totalLines = source["totalLines"];
+	        this.totalSamples = source["totalSamples"];
+	        this.pipeline = this.convertValues(source["pipeline"], LatencyStats);
+	        this.delivery = this.convertValues(source["delivery"], LatencyStats);
+	        this.endToEnd = this.convertValues(source["endToEnd"], LatencyStats);
+	        this.pending = source["pending"];
+	        this.since = this.convertValues(source["since"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PluginManifest {
+	    name: string;
+	    version: string;
+	    description: string;
+	    command: string;
+	    args: string[];
+	    parser?: string;
+	    sink?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PluginManifest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.version = source["version"];
+	        this.description = source["description"];
+	        this.command = source["command"];
+	        this.args = source["args"];
+	        this.parser = source["parser"];
+	        this.sink = source["sink"];
+	    }
+	}
+	export class PluginInfo {
+	    manifest: PluginManifest;
+	    dir: string;
+	    enabled: boolean;
+	    state: string;
+	    error?: string;
+	    restarts: number;
+	    dropped: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PluginInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.manifest = this.convertValues(source["manifest"], PluginManifest);
+	        this.dir = source["dir"];
+	        this.enabled = source["enabled"];
+	        this.state = source["state"];
+	        this.error = source["error"];
+	        this.restarts = source["restarts"];
+	        this.dropped = source["dropped"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class ProfileInfo {
+	    name: string;
+	    dir: string;
+	    current: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProfileInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.dir = source["dir"];
+	        this.current = source["current"];
+	    }
+	}
+	export class ProvisioningResult {
+	    device: Device;
+	    idWritten: boolean;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProvisioningResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.device = this.convertValues(source["device"], Device);
+	        this.idWritten = source["idWritten"];
+	        this.message = source["message"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class QuarantineStatus {
+	    mode: string;
+	    quarantined: number;
+	    recovered: number;
+	    lines: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new QuarantineStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mode = source["mode"];
+	        this.quarantined = source["quarantined"];
+	        this.recovered = source["recovered"];
+	        this.lines = source["lines"];
+	    }
+	}
+	export class QuarantinedLine {
+	    seq: number;
+	    // Go type: time
+	    timestamp: any;
+	    hex: string;
+	    text: string;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new QuarantinedLine(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seq = source["seq"];
+	        this.timestamp = this.convertValues(source["timestamp"], null);
+	        this.hex = source["hex"];
+	        this.text = source["text"];
+	        this.reason = source["reason"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RecordingSchedule {
+	    id: number;
+	    name: string;
+	    enabled: boolean;
+	    start: string;
+	    startTime?: string;
+	    stop: string;
+	    durationMinutes?: number;
+	    stopTime?: string;
+	    daily: boolean;
+	    dir?: string;
+	    // Go type: time
+	    lastRun: any;
+	    // Go type: time
+	    updatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordingSchedule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.enabled = source["enabled"];
+	        this.start = source["start"];
+	        this.startTime = source["startTime"];
+	        this.stop = source["stop"];
+	        this.durationMinutes = source["durationMinutes"];
+	        this.stopTime = source["stopTime"];
+	        this.daily = source["daily"];
+	        this.dir = source["dir"];
+	        this.lastRun = this.convertValues(source["lastRun"], null);
+	        this.updatedAt = this.convertValues(source["updatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RecordingScheduleStatus {
+	    schedule: RecordingSchedule;
+	    running: boolean;
+	    // Go type: time
+	    stopAt: any;
+	    // Go type: time
+	    nextStart: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordingScheduleStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.schedule = this.convertValues(source["schedule"], RecordingSchedule);
+	        this.running = source["running"];
+	        this.stopAt = this.convertValues(source["stopAt"], null);
+	        this.nextStart = this.convertValues(source["nextStart"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RecordingStatus {
+	    active: boolean;
+	    dir?: string;
+	    file?: string;
+	    samples: number;
+	    // Go type: time
+	    startedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecordingStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.active = source["active"];
+	        this.dir = source["dir"];
+	        this.file = source["file"];
+	        this.samples = source["samples"];
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RespirationConfig {
+	    enabled: boolean;
+	    channel: string;
+	    method: string;
+	    windowSeconds: number;
+	    updateIntervalSeconds: number;
+	    minProminence: number;
+	    minRate: number;
+	    maxRate: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RespirationConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.channel = source["channel"];
+	        this.method = source["method"];
+	        this.windowSeconds = source["windowSeconds"];
+	        this.updateIntervalSeconds = source["updateIntervalSeconds"];
+	        this.minProminence = source["minProminence"];
+	        this.minRate = source["minRate"];
+	        this.maxRate = source["maxRate"];
+	    }
+	}
+	export class RollupBucket {
+	    // Go type: time
+	    start: any;
+	    min: number;
+	    mean: number;
+	    max: number;
+	    count: number;
+	    partial: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new RollupBucket(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.start = this.convertValues(source["start"], null);
+	        this.min = source["min"];
+	        this.mean = source["mean"];
+	        this.max = source["max"];
+	        this.count = source["count"];
+	        this.partial = source["partial"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RollupConfig {
+	    enabled: boolean;
+	    resolutions: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RollupConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.resolutions = source["resolutions"];
+	    }
+	}
+	export class RollupSettings {
+	    default: RollupConfig;
+	    overrides: Record<string, RollupConfig>;
+	
+	    static createFrom(source: any = {}) {
+	        return new RollupSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.default = this.convertValues(source["default"], RollupConfig);
+	        this.overrides = this.convertValues(source["overrides"], RollupConfig, true);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class SecretStorage {
+	    backend: string;
+	    inKeyring: string[];
+	    inConfig: string[];
+	    lastError?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SecretStorage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.backend = source["backend"];
+	        this.inKeyring = source["inKeyring"];
+	        this.inConfig = source["inConfig"];
+	        this.lastError = source["lastError"];
+	    }
+	}
+	
+	export class SerialPortInfo {
+	    name: string;
+	    description?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SerialPortInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.description = source["description"];
+	    }
+	}
+	export class SessionAnnotation {
+	    // Go type: time
+	    time: any;
+	    user: string;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionAnnotation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = this.convertValues(source["time"], null);
+	        this.user = source["user"];
+	        this.text = source["text"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SessionGap {
+	    // Go type: time
+	    from: any;
+	    // Go type: time
+	    to: any;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionGap(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = this.convertValues(source["from"], null);
+	        this.to = this.convertValues(source["to"], null);
+	        this.reason = source["reason"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SessionSeal {
+	    // Go type: time
+	    sealedAt: any;
+	    samples: number;
+	    alarms: number;
+	    hash: string;
+	    signature: string;
+	    publicKey: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionSeal(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sealedAt = this.convertValues(source["sealedAt"], null);
+	        this.samples = source["samples"];
+	        this.alarms = source["alarms"];
+	        this.hash = source["hash"];
+	        this.signature = source["signature"];
+	        this.publicKey = source["publicKey"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SessionInfo {
+	    id: number;
+	    device: string;
+	    port: string;
+	    patient?: string;
+	    baudRate: number;
+	    // Go type: time
+	    startedAt: any;
+	    // Go type: time
+	    endedAt: any;
+	    alarmProfiles: AlarmProfileLoad[];
+	    clockSyncs: ClockSync[];
+	    gaps?: SessionGap[];
+	    annotations?: SessionAnnotation[];
+	    seal?: SessionSeal;
 	
 	    static createFrom(source: any = {}) {
-	        return new ConnectionResult(source);
+	        return new SessionInfo(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.success = source["success"];
-	        this.message = source["message"];
+	        this.id = source["id"];
+	        this.device = source["device"];
+	        this.port = source["port"];
+	        this.patient = source["patient"];
+	        this.baudRate = source["baudRate"];
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	        this.endedAt = this.convertValues(source["endedAt"], null);
+	        this.alarmProfiles = this.convertValues(source["alarmProfiles"], AlarmProfileLoad);
+	        this.clockSyncs = this.convertValues(source["clockSyncs"], ClockSync);
+	        this.gaps = this.convertValues(source["gaps"], SessionGap);
+	        this.annotations = this.convertValues(source["annotations"], SessionAnnotation);
+	        this.seal = this.convertValues(source["seal"], SessionSeal);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
-	export class SensorData {
-	    value1: number;
-	    value2: number;
-	    value3: number;
+	
+	export class SessionVerification {
+	    id: number;
+	    sealed: boolean;
+	    valid: boolean;
+	    problem?: string;
+	    // Go type: time
+	    sealedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionVerification(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.sealed = source["sealed"];
+	        this.valid = source["valid"];
+	        this.problem = source["problem"];
+	        this.sealedAt = this.convertValues(source["sealedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SettingOverride {
+	    setting: string;
+	    source: string;
+	    value: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SettingOverride(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.setting = source["setting"];
+	        this.source = source["source"];
+	        this.value = source["value"];
+	    }
+	}
+	export class Settings {
+	    version: number;
+	    theme: string;
+	    language: string;
+	    defaultPort: string;
+	    defaultBaudRate: number;
+	    chartWindowSeconds: number;
+	    confirmOnExit: boolean;
+	    minimizeToTray: boolean;
+	    recordingDir: string;
+	    autoConnect: boolean;
+	    exportDir: string;
+	    developerMode: boolean;
+	    parserMode: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.theme = source["theme"];
+	        this.language = source["language"];
+	        this.defaultPort = source["defaultPort"];
+	        this.defaultBaudRate = source["defaultBaudRate"];
+	        this.chartWindowSeconds = source["chartWindowSeconds"];
+	        this.confirmOnExit = source["confirmOnExit"];
+	        this.minimizeToTray = source["minimizeToTray"];
+	        this.recordingDir = source["recordingDir"];
+	        this.autoConnect = source["autoConnect"];
+	        this.exportDir = source["exportDir"];
+	        this.developerMode = source["developerMode"];
+	        this.parserMode = source["parserMode"];
+	    }
+	}
+	export class SettingsUpdate {
+	    theme?: string;
+	    language?: string;
+	    defaultPort?: string;
+	    defaultBaudRate?: number;
+	    chartWindowSeconds?: number;
+	    confirmOnExit?: boolean;
+	    minimizeToTray?: boolean;
+	    recordingDir?: string;
+	    autoConnect?: boolean;
+	    exportDir?: string;
+	    developerMode?: boolean;
+	    parserMode?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SettingsUpdate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.theme = source["theme"];
+	        this.language = source["language"];
+	        this.defaultPort = source["defaultPort"];
+	        this.defaultBaudRate = source["defaultBaudRate"];
+	        this.chartWindowSeconds = source["chartWindowSeconds"];
+	        this.confirmOnExit = source["confirmOnExit"];
+	        this.minimizeToTray = source["minimizeToTray"];
+	        this.recordingDir = source["recordingDir"];
+	        this.autoConnect = source["autoConnect"];
+	        this.exportDir = source["exportDir"];
+	        this.developerMode = source["developerMode"];
+	        this.parserMode = source["parserMode"];
+	    }
+	}
+	export class SignalQuality {
+	    channel: string;
+	    quality: number;
+	    level: string;
+	    noise: number;
+	    clipping: number;
+	    dropout: number;
+	    // Go type: time
+	    updated: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new SignalQuality(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.quality = source["quality"];
+	        this.level = source["level"];
+	        this.noise = source["noise"];
+	        this.clipping = source["clipping"];
+	        this.dropout = source["dropout"];
+	        this.updated = this.convertValues(source["updated"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SimulatorConfig {
+	    sampleRateHz: number;
+	    heartRate: number;
+	    respiratoryRate: number;
+	    spo2: number;
+	    noiseLevel: number;
+	    dropoutsPerMinute: number;
+	    dropoutSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SimulatorConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sampleRateHz = source["sampleRateHz"];
+	        this.heartRate = source["heartRate"];
+	        this.respiratoryRate = source["respiratoryRate"];
+	        this.spo2 = source["spo2"];
+	        this.noiseLevel = source["noiseLevel"];
+	        this.dropoutsPerMinute = source["dropoutsPerMinute"];
+	        this.dropoutSeconds = source["dropoutSeconds"];
+	    }
+	}
+	export class SnifferRecord {
+	    seq: number;
 	    // Go type: time
 	    timestamp: any;
+	    direction: string;
+	    hex: string;
+	    ascii: string;
+	    length: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new SensorData(source);
+	        return new SnifferRecord(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.value1 = source["value1"];
-	        this.value2 = source["value2"];
-	        this.value3 = source["value3"];
+	        this.seq = source["seq"];
 	        this.timestamp = this.convertValues(source["timestamp"], null);
+	        this.direction = source["direction"];
+	        this.hex = source["hex"];
+	        this.ascii = source["ascii"];
+	        this.length = source["length"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -51,18 +3392,248 @@ export namespace main {
 		    return a;
 		}
 	}
-	export class SerialPortInfo {
+	export class SnifferStatus {
+	    enabled: boolean;
+	    records: number;
+	    dropped: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SnifferStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.records = source["records"];
+	        this.dropped = source["dropped"];
+	    }
+	}
+	export class SpO2Config {
+	    enabled: boolean;
+	    redChannel: string;
+	    irChannel: string;
+	    windowSeconds: number;
+	    calibrationA: number;
+	    calibrationB: number;
+	    calibrationC: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SpO2Config(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.redChannel = source["redChannel"];
+	        this.irChannel = source["irChannel"];
+	        this.windowSeconds = source["windowSeconds"];
+	        this.calibrationA = source["calibrationA"];
+	        this.calibrationB = source["calibrationB"];
+	        this.calibrationC = source["calibrationC"];
+	    }
+	}
+	
+	export class TelemetryReport {
+	    installId: string;
+	    version: string;
+	    os: string;
+	    arch: string;
+	    features: Record<string, number>;
+	    crashes: number;
+	    // Go type: time
+	    from: any;
+	    // Go type: time
+	    to: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new TelemetryReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.installId = source["installId"];
+	        this.version = source["version"];
+	        this.os = source["os"];
+	        this.arch = source["arch"];
+	        this.features = source["features"];
+	        this.crashes = source["crashes"];
+	        this.from = this.convertValues(source["from"], null);
+	        this.to = this.convertValues(source["to"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TelemetryStatus {
+	    enabled: boolean;
+	    available: boolean;
+	    // Go type: time
+	    lastSent: any;
+	    pending: TelemetryReport;
+	
+	    static createFrom(source: any = {}) {
+	        return new TelemetryStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.available = source["available"];
+	        this.lastSent = this.convertValues(source["lastSent"], null);
+	        this.pending = this.convertValues(source["pending"], TelemetryReport);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TrendConfig {
+	    channel: string;
+	    windowSeconds: number;
+	    fallingLimit?: number;
+	    risingLimit?: number;
+	    severity: string;
+	    minDurationSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TrendConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.channel = source["channel"];
+	        this.windowSeconds = source["windowSeconds"];
+	        this.fallingLimit = source["fallingLimit"];
+	        this.risingLimit = source["risingLimit"];
+	        this.severity = source["severity"];
+	        this.minDurationSeconds = source["minDurationSeconds"];
+	    }
+	}
+	export class UpdateInfo {
+	    currentVersion: string;
+	    latestVersion: string;
+	    available: boolean;
+	    notes: string;
+	    // Go type: time
+	    publishedAt: any;
+	    installer: string;
+	    size: number;
+	    phase?: string;
+	    message?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UpdateInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.currentVersion = source["currentVersion"];
+	        this.latestVersion = source["latestVersion"];
+	        this.available = source["available"];
+	        this.notes = source["notes"];
+	        this.publishedAt = this.convertValues(source["publishedAt"], null);
+	        this.installer = source["installer"];
+	        this.size = source["size"];
+	        this.phase = source["phase"];
+	        this.message = source["message"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UserInfo {
 	    name: string;
-	    description?: string;
+	    role: string;
+	    // Go type: time
+	    createdAt: any;
 	
 	    static createFrom(source: any = {}) {
-	        return new SerialPortInfo(source);
+	        return new UserInfo(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.name = source["name"];
-	        this.description = source["description"];
+	        this.role = source["role"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class WatchdogConfig {
+	    enabled: boolean;
+	    timeoutSeconds: number;
+	    severity: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new WatchdogConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.timeoutSeconds = source["timeoutSeconds"];
+	        this.severity = source["severity"];
 	    }
 	}
 
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Frontend error intake limits
+const (
+	maxFrontendErrors      = 50          // Most recent reports kept for diagnostic bundles
+	frontendErrorPeriod    = time.Minute // Repeats of the same error in this time are only counted
+	maxFrontendMessageSize = 2 << 10
+	maxFrontendStackSize   = 16 << 10
+)
+
+// FrontendError is an exception caught by the UI, such as an error thrown
+// while drawing a chart
+type FrontendError struct {
+	Name           string    `json:"name"` // Error class, e.g. TypeError
+	Message        string    `json:"message"`
+	Stack          string    `json:"stack,omitempty"`
+	ComponentStack string    `json:"componentStack,omitempty"` // React components the error was thrown in
+	Source         string    `json:"source,omitempty"`         // Script file
+	Line           int       `json:"line,omitempty"`
+	Column         int       `json:"column,omitempty"`
+	URL            string    `json:"url,omitempty"` // Route of the UI
+	UserAgent      string    `json:"userAgent,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+	Repeats        int       `json:"repeats,omitempty"` // Identical reports since this one that were not logged
+}
+
+// frontendErrors keeps the recent UI exceptions. A render loop may throw
+// on every frame, so repeats of an error are counted instead of logged.
+type frontendErrors struct {
+	mu     sync.Mutex
+	recent []FrontendError
+	last   map[string]int // Index in recent of the last logged report of an error
+}
+
+// newFrontendErrors creates an empty intake
+func newFrontendErrors() *frontendErrors {
+	return &frontendErrors{recent: make([]FrontendError, 0), last: make(map[string]int)}
+}
+
+// truncate shortens s to at most max bytes
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "…"
+}
+
+// add records a report and returns whether it should be logged
+func (f *frontendErrors) add(report FrontendError) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := fmt.Sprintf("%s|%s|%s|%d|%d", report.Name, report.Message, report.Source, report.Line, report.Column)
+	if i, ok := f.last[key]; ok && report.Timestamp.Sub(f.recent[i].Timestamp) < frontendErrorPeriod {
+		f.recent[i].Repeats++
+		return false
+	}
+
+	if len(f.recent) == maxFrontendErrors {
+		f.recent = f.recent[1:]
+		for k, i := range f.last {
+			if i == 0 {
+				delete(f.last, k)
+			} else {
+				f.last[k] = i - 1
+			}
+		}
+	}
+	f.recent = append(f.recent, report)
+	f.last[key] = len(f.recent) - 1
+	return true
+}
+
+// list returns the recent reports, oldest first
+func (f *frontendErrors) list() []FrontendError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]FrontendError{}, f.recent...)
+}
+
+// ReportFrontendError records an exception caught by the UI in the log and
+// in the diagnostic bundles, so UI crashes can be read next to the backend
+// log. It works while logged out or locked, as errors happen there too.
+func (a *App) ReportFrontendError(report FrontendError) {
+	report.Name = truncate(strings.TrimSpace(report.Name), maxFrontendMessageSize)
+	report.Message = truncate(strings.TrimSpace(report.Message), maxFrontendMessageSize)
+	report.Stack = truncate(report.Stack, maxFrontendStackSize)
+	report.ComponentStack = truncate(report.ComponentStack, maxFrontendStackSize)
+	report.Source = truncate(report.Source, maxFrontendMessageSize)
+	report.URL = truncate(report.URL, maxFrontendMessageSize)
+	report.UserAgent = truncate(report.UserAgent, maxFrontendMessageSize)
+	report.Timestamp = time.Now()
+	report.Repeats = 0
+	if report.Name == "" {
+		report.Name = "Error"
+	}
+
+	if !a.uiErrors.add(report) {
+		return
+	}
+	location := ""
+	if report.Source != "" {
+		location = fmt.Sprintf(" at %s:%d:%d", report.Source, report.Line, report.Column)
+	}
+	frontendLog.Errorf("%s: %s%s (%s)\n%s%s", report.Name, report.Message, location, report.URL, report.Stack, report.ComponentStack)
+}
+
+// GetFrontendErrors returns the exceptions the UI reported since the app
+// started, oldest first
+func (a *App) GetFrontendErrors() []FrontendError {
+	return a.uiErrors.list()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// headlessRetryInterval is how often headless mode retries a failed connection
+const headlessRetryInterval = 5 * time.Second
+
+// passphraseEnv names the environment variable holding the encryption
+// passphrase for headless mode
+const passphraseEnv = "MEDIOT_PASSPHRASE"
+
+// headlessRequested reports whether the command line asks for headless mode
+func headlessRequested(args []string) bool {
+	return flagRequested(args, "headless")
+}
+
+// runHeadless runs acquisition, alarms, recording and the MQTT sink without
+// the window, for unattended bedside PCs and gateways:
+//
+//	mediot --headless --port COM3 --baud 115200 --record ./data --mqtt tcp://broker:1883
+//
+// It keeps the device connected, reconnecting whenever it is lost, until it
+// receives an interrupt or SIGTERM, and returns the process exit code. User
+// accounts and the app lock do not apply; whoever can start the process
+// controls the acquisition.
+//
+// When the patient data is encrypted and the keyring does not hold its key,
+// the passphrase is read from --passphrase-file or MEDIOT_PASSPHRASE; without
+// either, or with a wrong one, it exits with code 2 instead of retrying a
+// connection that cannot store anything.
+func runHeadless(args []string) int {
+	flags := flag.NewFlagSet("mediot", flag.ContinueOnError)
+	flags.Bool("headless", false, "run without the window")
+	port := flags.String("port", "", "serial port of the device (default: the default port of the settings)")
+	baud := flags.Int("baud", 0, "baud rate (default: the default baud rate of the settings)")
+	record := flags.String("record", "", "record the samples of every session into this folder")
+	broker := flags.String("mqtt", "", "publish every sample to this MQTT broker, e.g. tcp://host:1883")
+	passphraseFile := flags.String("passphrase-file", "", "read the passphrase of the encrypted patient data from this file (default: $"+passphraseEnv+")")
+	addOverrideFlags(flags)
+	addPortableFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if err := overrides.load(flags); err != nil {
+		fmt.Fprintf(os.Stderr, "mediot: %v\n", err)
+		return 2
+	}
+
+	app := NewApp()
+	telemetry.count(FeatureHeadless)
+	if err := unlockHeadless(app, *passphraseFile); err != nil {
+		fmt.Fprintf(os.Stderr, "mediot: %v\n", err)
+		app.shutdown(context.Background())
+		return 2
+	}
+	settings := app.GetSettings()
+	if *port == "" {
+		*port = settings.DefaultPort
+	}
+	if *baud == 0 {
+		*baud = settings.DefaultBaudRate
+	}
+	if *port == "" {
+		fmt.Fprintln(os.Stderr, "mediot: no serial port; use --port or set a default port in the settings")
+		return 2
+	}
+	if *broker != "" {
+		if err := app.mqtt.useBroker(*broker); err != nil {
+			fmt.Fprintf(os.Stderr, "mediot: %v\n", err)
+			return 2
+		}
+	}
+	if *record != "" {
+		if _, err := app.startRecording(*record); err != nil {
+			fmt.Fprintf(os.Stderr, "mediot: %v\n", err)
+			return 1
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	appLog.Infof("Running headless on %s at %d baud", *port, *baud)
+	for {
+		switch app.GetConnectionStatus().State {
+		case ConnectionDisconnected, ConnectionError:
+			if result := app.connect(*port, *baud); !result.Success {
+				serialLog.Warnf("Connecting to %s failed, retrying in %v: %s", *port, headlessRetryInterval, result.Message)
+			}
+		}
+
+		select {
+		case sig := <-signals:
+			appLog.Infof("Received %v", sig)
+			app.shutdown(context.Background())
+			return 0
+		case <-time.After(headlessRetryInterval):
+		}
+	}
+}
+
+// unlockHeadless unlocks the encrypted patient data with the passphrase from
+// the file, or else from the environment, when the keyring did not unlock it
+func unlockHeadless(app *App, passphraseFile string) error {
+	if !atRest.locked() {
+		return nil
+	}
+
+	passphrase, ok := os.LookupEnv(passphraseEnv)
+	if passphraseFile != "" {
+		data, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the passphrase: %w", err)
+		}
+		passphrase, ok = strings.TrimRight(string(data), "\r\n"), true
+	}
+	if !ok {
+		return fmt.Errorf("the patient data is encrypted and its key is not in the keyring; pass the passphrase with --passphrase-file or %s", passphraseEnv)
+	}
+	if err := app.UnlockEncryption(passphrase); err != nil {
+		return fmt.Errorf("failed to unlock the patient data: %w", err)
+	}
+	return nil
+}
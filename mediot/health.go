@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthRulesFile stores the alarm rules of the device status channels
+const healthRulesFile = "health_alarms.json"
+
+// housekeepingPrefix starts the device's housekeeping lines, e.g.
+// "HK,battery=87,temp=36.5,rssi=-61"
+const housekeepingPrefix = "HK,"
+
+// Device status channels. They describe the device, not the patient, so
+// they stay out of the sample pipeline.
+const (
+	ChannelBattery     = "status_battery"
+	ChannelTemperature = "status_temperature"
+	ChannelRSSI        = "status_rssi"
+)
+
+// housekeepingKeys maps the keys of a housekeeping line to status channels
+var housekeepingKeys = map[string]string{
+	"battery": ChannelBattery,
+	"bat":     ChannelBattery,
+	"temp":    ChannelTemperature,
+	"rssi":    ChannelRSSI,
+}
+
+// statusChannelUnits are the units of the status channels
+var statusChannelUnits = map[string]string{
+	ChannelBattery:     "%",
+	ChannelTemperature: "°C",
+	ChannelRSSI:        "dBm",
+}
+
+// HealthReading is the latest value of a device status channel
+type HealthReading struct {
+	Channel   string    `json:"channel"`
+	Value     float64   `json:"value"`
+	Unit      string    `json:"unit"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// defaultHealthRules warn about a low battery, a hot board and a weak radio link
+func defaultHealthRules() map[string]AlarmRule {
+	return map[string]AlarmRule{
+		ChannelBattery:     {Channel: ChannelBattery, WarningLow: limit(20), CriticalLow: limit(10), Hysteresis: 2},
+		ChannelTemperature: {Channel: ChannelTemperature, WarningHigh: limit(45), CriticalHigh: limit(55), Hysteresis: 1},
+		ChannelRSSI:        {Channel: ChannelRSSI, WarningLow: limit(-85), Hysteresis: 3},
+	}
+}
+
+// deviceHealth keeps the device status readings and their alarm rules
+type deviceHealth struct {
+	mu       sync.Mutex
+	readings map[string]HealthReading
+	rules    map[string]AlarmRule
+}
+
+// newDeviceHealth creates the health monitor and loads the persisted rules
+func newDeviceHealth() *deviceHealth {
+	h := &deviceHealth{
+		readings: make(map[string]HealthReading),
+		rules:    defaultHealthRules(),
+	}
+	if err := loadJSONFile(healthRulesFile, &h.rules); err != nil {
+		deviceLog.Errorf("Error loading device health rules: %v", err)
+	}
+	return h
+}
+
+// isHousekeepingLine reports whether a line carries device housekeeping data
+func isHousekeepingLine(line string) bool {
+	return strings.HasPrefix(line, housekeepingPrefix)
+}
+
+// parseHousekeeping reads the key=value pairs of a housekeeping line. Unknown
+// keys are ignored so newer firmware can add fields.
+func parseHousekeeping(line string, at time.Time) ([]HealthReading, error) {
+	var readings []HealthReading
+	for _, field := range strings.Split(strings.TrimPrefix(line, housekeepingPrefix), ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return nil, fmt.Errorf("housekeeping field '%s' is not key=value", field)
+		}
+		channel, known := housekeepingKeys[strings.ToLower(key)]
+		if !known {
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("housekeeping value '%s' of %s is not a number", value, key)
+		}
+		readings = append(readings, HealthReading{Channel: channel, Value: v, Unit: statusChannelUnits[channel], UpdatedAt: at})
+	}
+	return readings, nil
+}
+
+// conditionSeverity returns the severity of a condition alarm, normal if it isn't raised
+func (e *alarmEngine) conditionSeverity(channel string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if state, ok := e.conditions[channel]; ok && state.active != nil {
+		return state.active.Severity
+	}
+	return SeverityNormal
+}
+
+// recordHousekeeping stores the readings of a housekeeping line and checks
+// them against the status alarm rules
+func (a *App) recordHousekeeping(line string) {
+	readings, err := parseHousekeeping(line, time.Now())
+	if err != nil {
+		deviceLog.Debugf("Error parsing housekeeping line '%s': %v", line, err)
+		return
+	}
+
+	a.health.mu.Lock()
+	rules := make(map[string]AlarmRule, len(readings))
+	for _, reading := range readings {
+		a.health.readings[reading.Channel] = reading
+		if rule, ok := a.health.rules[reading.Channel]; ok {
+			rules[reading.Channel] = rule
+		}
+	}
+	a.health.mu.Unlock()
+
+	for _, reading := range readings {
+		rule, ok := rules[reading.Channel]
+		if !ok {
+			continue
+		}
+		severity, limit := classify(rule, reading.Value, a.alarms.conditionSeverity(reading.Channel))
+		if severity == SeverityNormal {
+			a.alarms.setCondition(reading.Channel, severity, reading.Value, limit, reading.UpdatedAt,
+				MsgStatusAlarmCleared, reading.Channel, reading.Value, reading.Unit)
+		} else {
+			a.alarms.setCondition(reading.Channel, severity, reading.Value, limit, reading.UpdatedAt,
+				MsgStatusAlarmRaised, reading.Channel, tr(severity), reading.Value, reading.Unit, limit)
+		}
+	}
+	a.emit(EventDeviceHealth, readings)
+}
+
+// clearDeviceHealth forgets the readings of a disconnected device and clears its status alarms
+func (a *App) clearDeviceHealth() {
+	a.health.mu.Lock()
+	channels := make([]string, 0, len(a.health.readings))
+	for channel := range a.health.readings {
+		channels = append(channels, channel)
+	}
+	a.health.readings = make(map[string]HealthReading)
+	a.health.mu.Unlock()
+
+	for _, channel := range channels {
+		a.alarms.setCondition(channel, SeverityNormal, 0, 0, time.Now(), MsgStatusDisconnected, channel)
+	}
+}
+
+// GetDeviceHealth returns the latest status readings of the connected device sorted by channel
+func (a *App) GetDeviceHealth() []HealthReading {
+	a.health.mu.Lock()
+	defer a.health.mu.Unlock()
+
+	result := make([]HealthReading, 0, len(a.health.readings))
+	for _, reading := range a.health.readings {
+		result = append(result, reading)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Channel < result[j].Channel })
+	return result
+}
+
+// GetHealthAlarmRules returns the alarm rules of the status channels sorted by channel
+func (a *App) GetHealthAlarmRules() []AlarmRule {
+	a.health.mu.Lock()
+	defer a.health.mu.Unlock()
+
+	result := make([]AlarmRule, 0, len(a.health.rules))
+	for _, rule := range a.health.rules {
+		result = append(result, rule)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Channel < result[j].Channel })
+	return result
+}
+
+// SetHealthAlarmRule replaces and persists the alarm rule of a status channel.
+// Only its fixed limits and hysteresis are used.
+func (a *App) SetHealthAlarmRule(rule AlarmRule) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if _, ok := statusChannelUnits[rule.Channel]; !ok {
+		return fmt.Errorf("'%s' is not a device status channel", rule.Channel)
+	}
+	if rule.Hysteresis < 0 {
+		return fmt.Errorf("hysteresis must not be negative")
+	}
+
+	a.health.mu.Lock()
+	defer a.health.mu.Unlock()
+
+	a.health.rules[rule.Channel] = rule
+
+	deviceLog.Infof("Health alarm rule set for %s", rule.Channel)
+	return saveJSONFile(healthRulesFile, a.health.rules)
+}
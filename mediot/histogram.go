@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxHistogramBins bounds the size of a histogram returned to the frontend
+const maxHistogramBins = 1000
+
+// Histogram is the distribution of a channel's readings over a time window
+type Histogram struct {
+	Channel       string    `json:"channel"`
+	WindowSeconds float64   `json:"windowSeconds"`
+	Min           float64   `json:"min"`   // Lower edge of the first bin
+	Max           float64   `json:"max"`   // Upper edge of the last bin
+	Edges         []float64 `json:"edges"` // Bin edges, one more than the counts
+	Counts        []int     `json:"counts"`
+	Total         int       `json:"total"`     // Readings in the window, including those out of range
+	Underflow     int       `json:"underflow"` // Readings below Min
+	Overflow      int       `json:"overflow"`  // Readings above Max
+}
+
+// buildHistogram counts the readings into bins equal-width bins between min and max.
+// The last bin includes its upper edge.
+func buildHistogram(values []timedValue, min, max float64, bins int) Histogram {
+	result := Histogram{
+		Min:    min,
+		Max:    max,
+		Edges:  make([]float64, bins+1),
+		Counts: make([]int, bins),
+		Total:  len(values),
+	}
+
+	width := (max - min) / float64(bins)
+	for i := range result.Edges {
+		result.Edges[i] = min + width*float64(i)
+	}
+	result.Edges[bins] = max
+
+	for _, tv := range values {
+		switch {
+		case tv.v < min:
+			result.Underflow++
+		case tv.v > max:
+			result.Overflow++
+		default:
+			bin := int((tv.v - min) / width)
+			if bin >= bins {
+				bin = bins - 1
+			}
+			result.Counts[bin]++
+		}
+	}
+	return result
+}
+
+// GetHistogram returns the distribution of a channel over the last
+// windowSeconds in the given number of bins. When rangeMin equals rangeMax
+// the range is taken from the readings themselves.
+func (a *App) GetHistogram(channel string, rangeMin, rangeMax float64, bins int, windowSeconds float64) (Histogram, error) {
+	span := secondsToDuration(windowSeconds)
+	if span <= 0 || span > historyRetention {
+		return Histogram{}, fmt.Errorf("window must be between 0 and %.0f s, got %.2f s",
+			historyRetention.Seconds(), windowSeconds)
+	}
+	if bins <= 0 || bins > maxHistogramBins {
+		return Histogram{}, fmt.Errorf("bins must be between 1 and %d, got %d", maxHistogramBins, bins)
+	}
+	if rangeMin > rangeMax {
+		return Histogram{}, fmt.Errorf("range minimum %.4f is above maximum %.4f", rangeMin, rangeMax)
+	}
+	if !a.history.has(channel) {
+		return Histogram{}, fmt.Errorf("unknown channel '%s'", channel)
+	}
+
+	values := a.history.recent(channel, span)
+	if rangeMin == rangeMax {
+		if len(values) == 0 {
+			return Histogram{}, fmt.Errorf("no readings of '%s' in the last %.0f s", channel, windowSeconds)
+		}
+		rangeMin, rangeMax = math.Inf(1), math.Inf(-1)
+		for _, tv := range values {
+			rangeMin = math.Min(rangeMin, tv.v)
+			rangeMax = math.Max(rangeMax, tv.v)
+		}
+		// A constant signal still needs a non-empty range to bin into
+		if rangeMin == rangeMax {
+			rangeMin, rangeMax = rangeMin-0.5, rangeMax+0.5
+		}
+	}
+
+	result := buildHistogram(values, rangeMin, rangeMax, bins)
+	result.Channel = channel
+	result.WindowSeconds = windowSeconds
+	return result, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// historyRetention is how far back per-channel readings are kept for analysis queries
+const historyRetention = 10 * time.Minute
+
+// channelHistory keeps the recent readings of every channel so analysis
+// bindings can look back over a window without the frontend resending data.
+// Readings marked as artifacts are left out.
+type channelHistory struct {
+	mu       sync.RWMutex
+	channels map[string]*timedWindow
+}
+
+// newChannelHistory creates an empty history store
+func newChannelHistory() *channelHistory {
+	return &channelHistory{
+		channels: make(map[string]*timedWindow),
+	}
+}
+
+func (h *channelHistory) process(sample *SensorData) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sample.forEachChannel(func(name string, value float64) {
+		if sample.isArtifact(name) {
+			return
+		}
+		w, ok := h.channels[name]
+		if !ok {
+			w = newTimedWindow(historyRetention)
+			h.channels[name] = w
+		}
+		w.push(sample.Timestamp, value)
+	})
+}
+
+// recent returns a copy of the channel readings from the last span
+func (h *channelHistory) recent(channel string, span time.Duration) []timedValue {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	w, ok := h.channels[channel]
+	if !ok {
+		return nil
+	}
+	return w.since(time.Now().Add(-span))
+}
+
+// has reports whether any reading was ever recorded for the channel
+func (h *channelHistory) has(channel string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	_, ok := h.channels[channel]
+	return ok
+}
+
+// names returns the channels with readings
+func (h *channelHistory) names() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	names := make([]string, 0, len(h.channels))
+	for name := range h.channels {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Derived channels published by the HRV stage
+const (
+	ChannelHeartRate = "hr"
+	ChannelSDNN      = "hrv_sdnn"
+	ChannelRMSSD     = "hrv_rmssd"
+	ChannelPNN50     = "hrv_pnn50"
+	ChannelLFHF      = "hrv_lfhf"
+)
+
+// HRV analysis parameters
+const (
+	hrvMinInterval    = 300 * time.Millisecond  // Shortest plausible beat interval (200 bpm)
+	hrvMaxInterval    = 2000 * time.Millisecond // Longest plausible beat interval (30 bpm)
+	hrvMaxChange      = 0.2                     // Intervals changing more than this fraction are treated as ectopic
+	hrvTachogramRate  = 4.0                     // Rate in Hz the interval series is resampled to for LF/HF
+	hrvMinSpectral    = time.Minute             // Shortest window the LF/HF ratio is computed over
+	hrvThresholdSpan  = 5 * time.Second         // Window the adaptive beat threshold is derived from
+	hrvThresholdScale = 1.5                     // Beat threshold in standard deviations of the ECG
+)
+
+// HRVConfig configures beat detection and heart rate variability analysis
+type HRVConfig struct {
+	Enabled               bool    `json:"enabled"`
+	Channel               string  `json:"channel"`               // ECG or pulse channel the beats are detected on
+	MinProminence         float64 `json:"minProminence"`         // Beat detection threshold, 0 adapts to the signal
+	WindowSeconds         float64 `json:"windowSeconds"`         // Analysis window, 300 s for standard short-term HRV
+	UpdateIntervalSeconds float64 `json:"updateIntervalSeconds"` // How often the metrics are refreshed
+}
+
+// HRVMetrics are the heart rate variability metrics over the analysis window
+type HRVMetrics struct {
+	Beats     int       `json:"beats"`     // Normal-to-normal intervals in the window
+	HeartRate float64   `json:"heartRate"` // Mean heart rate in bpm
+	SDNN      float64   `json:"sdnn"`      // Standard deviation of the intervals, ms
+	RMSSD     float64   `json:"rmssd"`     // Root mean square of successive differences, ms
+	PNN50     float64   `json:"pnn50"`     // Percentage of successive differences above 50 ms
+	LF        float64   `json:"lf"`        // Power in 0.04-0.15 Hz, ms²
+	HF        float64   `json:"hf"`        // Power in 0.15-0.4 Hz, ms²
+	LFHF      float64   `json:"lfhf"`      // LF/HF ratio, 0 until the window is long enough
+	Updated   time.Time `json:"updated"`
+}
+
+// defaultHRVConfig analyses 5 minutes of beats on the ECG channel every 10 s
+func defaultHRVConfig() HRVConfig {
+	return HRVConfig{
+		Enabled:               true,
+		Channel:               ChannelValue1,
+		WindowSeconds:         300,
+		UpdateIntervalSeconds: 10,
+	}
+}
+
+// hrvAnalyzer detects beats and maintains the HRV metrics
+type hrvAnalyzer struct {
+	mu         sync.Mutex
+	config     HRVConfig
+	signal     *statsTracker // Recent ECG readings for the adaptive threshold
+	peaks      *peakTracker
+	lastBeat   time.Time
+	lastRR     float64      // Previous raw interval in ms, for ectopic beat rejection
+	intervals  *timedWindow // Normal-to-normal intervals in ms, stamped with the beat that ends them
+	lastUpdate time.Time
+	metrics    HRVMetrics
+}
+
+// newHRVAnalyzer creates the HRV stage with the default configuration
+func newHRVAnalyzer() *hrvAnalyzer {
+	h := &hrvAnalyzer{}
+	h.configure(defaultHRVConfig())
+	return h
+}
+
+// configure applies a configuration and restarts the analysis
+func (h *hrvAnalyzer) configure(config HRVConfig) {
+	h.config = config
+	h.signal = newStatsTracker(hrvThresholdSpan, nil)
+	h.peaks = newPeakTracker(PeakDetectorConfig{
+		Channel:            config.Channel,
+		MinProminence:      config.MinProminence,
+		MinDistanceSeconds: hrvMinInterval.Seconds(),
+	})
+	h.lastBeat = time.Time{}
+	h.lastRR = 0
+	h.intervals = newTimedWindow(secondsToDuration(config.WindowSeconds))
+	h.lastUpdate = time.Time{}
+	h.metrics = HRVMetrics{}
+}
+
+func (h *hrvAnalyzer) process(sample *SensorData) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.config.Enabled {
+		return
+	}
+
+	value, ok := sample.channelValue(h.config.Channel)
+	if ok && !sample.isArtifact(h.config.Channel) {
+		h.signal.add(sample.Timestamp, value)
+		if h.config.MinProminence == 0 {
+			h.peaks.config.MinProminence = h.signal.snapshot().StdDev * hrvThresholdScale
+		}
+		if h.peaks.config.MinProminence > 0 {
+			if beat := h.peaks.push(sample.Timestamp, value); beat != nil {
+				h.addBeat(beat.Timestamp)
+			}
+		}
+	} else if ok {
+		// An artifact breaks the beat sequence; don't bridge it with one long interval
+		h.lastBeat = time.Time{}
+		h.lastRR = 0
+	}
+
+	if sample.Timestamp.Sub(h.lastUpdate) >= secondsToDuration(h.config.UpdateIntervalSeconds) {
+		h.lastUpdate = sample.Timestamp
+		h.update(sample.Timestamp)
+	}
+
+	if h.metrics.Beats > 0 {
+		sample.setDerived(ChannelHeartRate, h.metrics.HeartRate)
+		sample.setDerived(ChannelSDNN, h.metrics.SDNN)
+		sample.setDerived(ChannelRMSSD, h.metrics.RMSSD)
+		sample.setDerived(ChannelPNN50, h.metrics.PNN50)
+		if h.metrics.LFHF > 0 {
+			sample.setDerived(ChannelLFHF, h.metrics.LFHF)
+		}
+	}
+}
+
+// addBeat records the interval ending at a beat if it is a plausible normal beat
+func (h *hrvAnalyzer) addBeat(at time.Time) {
+	previous := h.lastBeat
+	h.lastBeat = at
+	if previous.IsZero() {
+		return
+	}
+
+	interval := at.Sub(previous)
+	if interval < hrvMinInterval || interval > hrvMaxInterval {
+		return
+	}
+	ms := float64(interval) / float64(time.Millisecond)
+	previousRR := h.lastRR
+	h.lastRR = ms
+	if previousRR > 0 && math.Abs(ms-previousRR) > hrvMaxChange*previousRR {
+		return
+	}
+	h.intervals.push(at, ms)
+}
+
+// update recomputes the metrics over the interval window
+func (h *hrvAnalyzer) update(now time.Time) {
+	h.intervals.evict(now)
+	intervals := h.intervals.values
+	n := len(intervals)
+	if n < 2 {
+		h.metrics = HRVMetrics{}
+		return
+	}
+
+	sum, sumSquares := 0.0, 0.0
+	for _, rr := range intervals {
+		sum += rr.v
+		sumSquares += rr.v * rr.v
+	}
+	mean := sum / float64(n)
+
+	diffSquares, over50 := 0.0, 0
+	for i := 1; i < n; i++ {
+		d := intervals[i].v - intervals[i-1].v
+		diffSquares += d * d
+		if math.Abs(d) > 50 {
+			over50++
+		}
+	}
+
+	metrics := HRVMetrics{
+		Beats:     n,
+		HeartRate: 60000 / mean,
+		SDNN:      math.Sqrt(math.Max(0, sumSquares/float64(n)-mean*mean)),
+		RMSSD:     math.Sqrt(diffSquares / float64(n-1)),
+		PNN50:     float64(over50) / float64(n-1) * 100,
+		Updated:   now,
+	}
+
+	if h.intervals.coverage() >= hrvMinSpectral {
+		tachogram := resampleUniform(intervals, hrvTachogramRate)
+		detrend(tachogram)
+		applyHann(tachogram)
+		metrics.LF = bandPower(tachogram, hrvTachogramRate, 0.04, 0.15)
+		metrics.HF = bandPower(tachogram, hrvTachogramRate, 0.15, 0.4)
+		if metrics.HF > 0 {
+			metrics.LFHF = metrics.LF / metrics.HF
+		}
+	}
+
+	h.metrics = metrics
+}
+
+// GetHRVConfig returns the HRV analysis settings
+func (a *App) GetHRVConfig() HRVConfig {
+	a.hrv.mu.Lock()
+	defer a.hrv.mu.Unlock()
+
+	return a.hrv.config
+}
+
+// SetHRVConfig replaces the HRV analysis settings and restarts the analysis
+func (a *App) SetHRVConfig(config HRVConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if config.Channel == "" {
+		return fmt.Errorf("channel is required")
+	}
+	if config.WindowSeconds <= 0 || config.UpdateIntervalSeconds <= 0 {
+		return fmt.Errorf("window and update interval must be positive")
+	}
+	if config.MinProminence < 0 {
+		return fmt.Errorf("minimum prominence must not be negative")
+	}
+
+	a.hrv.mu.Lock()
+	defer a.hrv.mu.Unlock()
+
+	a.hrv.configure(config)
+
+	processingLog.Infof("HRV analysis on channel %s over %.0f s", config.Channel, config.WindowSeconds)
+	return nil
+}
+
+// GetHRVMetrics returns the latest heart rate variability metrics
+func (a *App) GetHRVMetrics() (HRVMetrics, error) {
+	a.hrv.mu.Lock()
+	defer a.hrv.mu.Unlock()
+
+	if !a.hrv.config.Enabled {
+		return HRVMetrics{}, fmt.Errorf("HRV analysis is disabled")
+	}
+	return a.hrv.metrics, nil
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// defaultLanguage is used when neither the settings nor the OS name a supported language
+const defaultLanguage = "en"
+
+// Message codes of the localized backend messages. The frontend may match
+// on the code; the text is already in the user's language.
+const (
+	MsgAlreadyConnected = "connection.already-connected"
+	MsgPortOpenFailed   = "connection.open-failed"
+	MsgConnected        = "connection.connected"
+	MsgSimulatorStopped = "connection.simulator-stopped"
+	MsgNoConnection     = "connection.none"
+	MsgPortInUse        = "connection.port-in-use"
+	MsgDisconnected     = "connection.disconnected"
+	MsgNotConnected     = "connection.not-connected"
+
+	MsgParseHexCount     = "parser.hex-count"
+	MsgParseHexValue     = "parser.hex-value"
+	MsgParseHexLength    = "parser.hex-length"
+	MsgParseHexValues    = "parser.hex-values"
+	MsgParseDecimalCount = "parser.decimal-count"
+	MsgParseDecimalValue = "parser.decimal-value"
+
+	MsgAlarmRaised        = "alarm.raised"
+	MsgAlarmCleared       = "alarm.cleared"
+	MsgAlarmChanged       = "alarm.changed"
+	MsgNoData             = "alarm.no-data"
+	MsgNoDataInactive     = "alarm.no-data-inactive"
+	MsgStreamResumed      = "alarm.stream-resumed"
+	MsgStatusAlarmRaised  = "alarm.status-raised"
+	MsgStatusAlarmCleared = "alarm.status-cleared"
+	MsgStatusDisconnected = "alarm.status-disconnected"
+)
+
+// messageCatalogs holds the format string of every message code by language.
+// Arguments keep their order in every language.
+var messageCatalogs = map[string]map[string]string{
+	"en": {
+		MsgAlreadyConnected: "Already connected to a port",
+		MsgPortOpenFailed:   "Failed to open port: %v",
+		MsgConnected:        "Connected to %s at %d baud",
+		MsgSimulatorStopped: "Simulator stopped",
+		MsgNoConnection:     "No active connection",
+		MsgPortInUse:        "Serial port is in use by the %s",
+		MsgDisconnected:     "Disconnected successfully",
+		MsgNotConnected:     "not connected to serial port",
+
+		MsgParseHexCount:     "invalid format: expected 3 hex values, got %d in '%s'",
+		MsgParseHexValue:     "part %d '%s' is not valid hex format",
+		MsgParseHexLength:    "part %d '%s' has invalid hex length",
+		MsgParseHexValues:    "error parsing hex values: %v, %v, %v",
+		MsgParseDecimalCount: "invalid format: expected %d decimal values, got %d in '%s'",
+		MsgParseDecimalValue: "part %d '%s' is not a decimal number",
+
+		MsgAlarmRaised:        "%s %s alarm: %.2f beyond limit %.2f",
+		MsgAlarmCleared:       "%s alarm cleared at %.2f",
+		MsgAlarmChanged:       "%s alarm changed from %s to %s: %.2f beyond limit %.2f",
+		MsgNoData:             "No valid data received for %.1f s",
+		MsgNoDataInactive:     "No-data alarm cleared: watchdog inactive",
+		MsgStreamResumed:      "Data stream resumed",
+		MsgStatusAlarmRaised:  "%s %s alarm: %.1f %s beyond limit %.1f",
+		MsgStatusAlarmCleared: "%s alarm cleared at %.1f %s",
+		MsgStatusDisconnected: "%s alarm cleared: device disconnected",
+
+		SeverityNormal:   "normal",
+		SeverityWarning:  "warning",
+		SeverityCritical: "critical",
+	},
+	"fr": {
+		MsgAlreadyConnected: "Déjà connecté à un port",
+		MsgPortOpenFailed:   "Impossible d'ouvrir le port : %v",
+		MsgConnected:        "Connecté à %s à %d bauds",
+		MsgSimulatorStopped: "Simulateur arrêté",
+		MsgNoConnection:     "Aucune connexion active",
+		MsgPortInUse:        "Le port série est utilisé par : %s",
+		MsgDisconnected:     "Déconnexion réussie",
+		MsgNotConnected:     "non connecté au port série",
+
+		MsgParseHexCount:     "format invalide : 3 valeurs hexadécimales attendues, %d reçues dans '%s'",
+		MsgParseHexValue:     "la partie %d '%s' n'est pas au format hexadécimal",
+		MsgParseHexLength:    "la partie %d '%s' a une longueur hexadécimale invalide",
+		MsgParseHexValues:    "erreur de lecture des valeurs hexadécimales : %v, %v, %v",
+		MsgParseDecimalCount: "format invalide : %d valeurs décimales attendues, %d reçues dans '%s'",
+		MsgParseDecimalValue: "la partie %d '%s' n'est pas un nombre décimal",
+
+		MsgAlarmRaised:        "%s : alarme de niveau %s, %.2f au-delà de la limite %.2f",
+		MsgAlarmCleared:       "%s : fin d'alarme à %.2f",
+		MsgAlarmChanged:       "%s : alarme passée du niveau %s au niveau %s, %.2f au-delà de la limite %.2f",
+		MsgNoData:             "Aucune donnée valide reçue depuis %.1f s",
+		MsgNoDataInactive:     "Fin de l'alarme d'absence de données : surveillance inactive",
+		MsgStreamResumed:      "Flux de données rétabli",
+		MsgStatusAlarmRaised:  "%s : alarme de niveau %s, %.1f %s au-delà de la limite %.1f",
+		MsgStatusAlarmCleared: "%s : fin d'alarme à %.1f %s",
+		MsgStatusDisconnected: "%s : fin d'alarme, appareil déconnecté",
+
+		SeverityNormal:   "normal",
+		SeverityWarning:  "avertissement",
+		SeverityCritical: "critique",
+	},
+	"ar": {
+		MsgAlreadyConnected: "متصل بالفعل بمنفذ",
+		MsgPortOpenFailed:   "تعذر فتح المنفذ: %v",
+		MsgConnected:        "تم الاتصال بـ %s بسرعة %d باود",
+		MsgSimulatorStopped: "تم إيقاف المحاكي",
+		MsgNoConnection:     "لا يوجد اتصال نشط",
+		MsgPortInUse:        "المنفذ التسلسلي قيد الاستخدام من قبل: %s",
+		MsgDisconnected:     "تم قطع الاتصال بنجاح",
+		MsgNotConnected:     "غير متصل بالمنفذ التسلسلي",
+
+		MsgParseHexCount:     "تنسيق غير صالح: يُتوقع 3 قيم ست عشرية، وُجد %d في '%s'",
+		MsgParseHexValue:     "الجزء %d '%s' ليس بتنسيق ست عشري صالح",
+		MsgParseHexLength:    "الجزء %d '%s' طوله الست عشري غير صالح",
+		MsgParseHexValues:    "خطأ في قراءة القيم الست عشرية: %v، %v، %v",
+		MsgParseDecimalCount: "تنسيق غير صالح: يُتوقع %d قيم عشرية، وُجد %d في '%s'",
+		MsgParseDecimalValue: "الجزء %d '%s' ليس عددًا عشريًا",
+
+		MsgAlarmRaised:        "%s: إنذار بمستوى %s، القيمة %.2f تتجاوز الحد %.2f",
+		MsgAlarmCleared:       "%s: زال الإنذار عند %.2f",
+		MsgAlarmChanged:       "%s: تغير مستوى الإنذار من %s إلى %s، القيمة %.2f تتجاوز الحد %.2f",
+		MsgNoData:             "لم تُستقبل بيانات صالحة منذ %.1f ث",
+		MsgNoDataInactive:     "زال إنذار انقطاع البيانات: المراقبة غير نشطة",
+		MsgStreamResumed:      "استؤنف تدفق البيانات",
+		MsgStatusAlarmRaised:  "%s: إنذار بمستوى %s، القيمة %.1f %s تتجاوز الحد %.1f",
+		MsgStatusAlarmCleared: "%s: زال الإنذار عند %.1f %s",
+		MsgStatusDisconnected: "%s: زال الإنذار، تم فصل الجهاز",
+
+		SeverityNormal:   "عادي",
+		SeverityWarning:  "تحذير",
+		SeverityCritical: "حرج",
+	},
+}
+
+// messageLanguage is the language of the backend messages, shared by the
+// code that has no App at hand, such as the line parsers
+type messageLanguage struct {
+	mu       sync.Mutex
+	language string
+}
+
+// messages holds the current message language
+var messages = &messageLanguage{language: defaultLanguage}
+
+// set switches to a language tag like fr or ar-MA. An empty tag follows the
+// OS; unsupported languages fall back to English.
+func (m *messageLanguage) set(tag string) {
+	language := supportedLanguage(tag)
+	if tag == "" {
+		language = systemLanguage()
+	}
+
+	m.mu.Lock()
+	changed := m.language != language
+	m.language = language
+	m.mu.Unlock()
+
+	if changed {
+		appLog.Infof("Message language set to %s", language)
+	}
+}
+
+// current returns the language of the backend messages
+func (m *messageLanguage) current() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.language
+}
+
+// primaryLanguage returns the primary subtag of a language tag or POSIX
+// locale, such as fr for fr_FR.UTF-8
+func primaryLanguage(tag string) string {
+	primary := strings.ToLower(tag)
+	if i := strings.IndexAny(primary, "-_."); i >= 0 {
+		primary = primary[:i]
+	}
+	return primary
+}
+
+// supportedLanguage returns the catalog matching a language tag, or the
+// default language
+func supportedLanguage(tag string) string {
+	primary := primaryLanguage(tag)
+	if _, ok := messageCatalogs[primary]; ok {
+		return primary
+	}
+	return defaultLanguage
+}
+
+// systemLanguage reads the user's language from the POSIX locale
+// variables, which macOS and most Linux desktops set
+func systemLanguage() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" && value != "C" && value != "POSIX" {
+			return supportedLanguage(value)
+		}
+	}
+	return defaultLanguage
+}
+
+// tr formats a message in the current language, falling back to English
+// for codes a catalog lacks
+func tr(code string, args ...interface{}) string {
+	format, ok := messageCatalogs[messages.current()][code]
+	if !ok {
+		if format, ok = messageCatalogs[defaultLanguage][code]; !ok {
+			format = code
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// trError returns a localized message as an error
+func trError(code string, args ...interface{}) error {
+	return fmt.Errorf("%s", tr(code, args...))
+}
+
+// GetLanguages returns the languages the backend messages are available in
+func (a *App) GetLanguages() []string {
+	result := make([]string, 0, len(messageCatalogs))
+	for language := range messageCatalogs {
+		result = append(result, language)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxInjectSize is the most data one injection accepts
+const maxInjectSize = 64 << 10
+
+// checkInjection refuses injecting data unless developer mode is on and no
+// device is connected, so injected lines never end up in the recording,
+// alarms or seal of a real session
+func (a *App) checkInjection(size int) error {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return err
+	}
+	if !a.GetSettings().DeveloperMode {
+		return fmt.Errorf("data can only be injected in developer mode")
+	}
+	if a.conn.openPort() != nil {
+		return fmt.Errorf("data cannot be injected while a device is connected")
+	}
+	if size > maxInjectSize {
+		return fmt.Errorf("at most %d bytes can be injected at once", maxInjectSize)
+	}
+	return nil
+}
+
+// InjectRawData processes bytes exactly as if the serial port had
+// received them: they are captured, buffered until a line ends and parsed
+// with the parser of the device. Frontend tests and support staff use it to
+// reproduce parsing problems without the device. Developer mode only.
+func (a *App) InjectRawData(data []byte) error {
+	if err := a.checkInjection(len(data)); err != nil {
+		return err
+	}
+	serialLog.Infof("Injected %d bytes", len(data))
+	a.sniffer.record(SnifferInjected, data)
+	a.receive(data)
+	return nil
+}
+
+// InjectLine processes one line as if the serial port had received it,
+// adding the line end if it has none. Developer mode only.
+func (a *App) InjectLine(line string) error {
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+	if err := a.checkInjection(len(line)); err != nil {
+		return err
+	}
+	serialLog.Infof("Injected line %q", strings.TrimSpace(line))
+	a.sniffer.record(SnifferInjected, []byte(line))
+	a.receive([]byte(line))
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keyringBackend names the secret store used on this platform
+const keyringBackend = "macOS Keychain"
+
+// securityNotFound is the exit status of security when no item matches
+const securityNotFound = 44
+
+// keyringSet stores a secret in the login keychain. The command goes to
+// security's interactive mode on stdin with the secret hex-encoded, so it
+// never shows up in the process list and needs no quoting.
+func keyringSet(account, secret string) error {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+		secretService, account, hex.EncodeToString([]byte(secret))))
+	if out, err := cmd.CombinedOutput(); err != nil || strings.Contains(strings.ToLower(string(out)), "error") {
+		return fmt.Errorf("failed to store keychain item: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// keyringGet reads a secret, returning errSecretNotFound if there is none
+func keyringGet(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password",
+		"-s", secretService, "-a", account, "-w").Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == securityNotFound {
+		return "", errSecretNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read keychain item: %v", err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// keyringDelete removes a secret; a missing one is not an error
+func keyringDelete(account string) error {
+	err := exec.Command("security", "delete-generic-password",
+		"-s", secretService, "-a", account).Run()
+	var exitErr *exec.ExitError
+	if err == nil || errors.As(err, &exitErr) && exitErr.ExitCode() == securityNotFound {
+		return nil
+	}
+	return fmt.Errorf("failed to delete keychain item: %v", err)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// keyringBackend names the secret store used on this platform
+const keyringBackend = "Secret Service (secret-tool)"
+
+// keyringSet stores a secret in the Secret Service keyring through
+// libsecret's secret-tool. The secret is passed on stdin so it never shows
+// up in the process list.
+func keyringSet(account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label=mediot "+account,
+		"service", secretService, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return keyringError(err, out)
+	}
+	return nil
+}
+
+// keyringGet reads a secret, returning errSecretNotFound if there is none
+func keyringGet(account string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", secretService, "account", account).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) == 0 {
+		// secret-tool exits with status 1 and no message when nothing matches
+		return "", errSecretNotFound
+	}
+	if err != nil {
+		return "", keyringError(err, nil)
+	}
+	return string(out), nil
+}
+
+// keyringDelete removes a secret; a missing one is not an error
+func keyringDelete(account string) error {
+	if out, err := exec.Command("secret-tool", "clear", "service", secretService, "account", account).CombinedOutput(); err != nil {
+		return keyringError(err, out)
+	}
+	return nil
+}
+
+// keyringError includes the tool's message in a failure
+func keyringError(err error, out []byte) error {
+	if message := strings.TrimSpace(string(out)); message != "" {
+		return errors.New(message)
+	}
+	return err
+}
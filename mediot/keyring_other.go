@@ -0,0 +1,26 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import "errors"
+
+// keyringBackend names the secret store used on this platform
+const keyringBackend = ""
+
+// errNoKeyring is returned on platforms without a supported keyring
+var errNoKeyring = errors.New("no OS keyring is supported on this platform")
+
+// keyringSet is not available on this platform
+func keyringSet(account, secret string) error {
+	return errNoKeyring
+}
+
+// keyringGet is not available on this platform
+func keyringGet(account string) (string, error) {
+	return "", errNoKeyring
+}
+
+// keyringDelete is not available on this platform
+func keyringDelete(account string) error {
+	return errNoKeyring
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Log files. The current file is mediot.log; rotated ones are mediot.1.log
+// (newest) to mediot.5.log (oldest).
+const (
+	logDirName     = "logs"
+	logFileName    = "mediot.log"
+	maxLogFileSize = 5 << 20
+	maxLogFiles    = 5
+	loggingFile    = "logging.json"
+)
+
+// Log components
+const (
+	LogSerial     = "serial"     // Port, reader and line parsing
+	LogDevice     = "device"     // Device registry, configuration, firmware and simulator
+	LogProcessing = "processing" // Signal processing pipeline
+	LogAlarms     = "alarms"     // Alarm engine, history and alarm settings
+	LogNotify     = "notify"     // Desktop, email and phone notifications
+	LogSecurity   = "security"   // Users, audit, encryption, certificates and secrets
+	LogPlugins    = "plugins"    // Plugin processes and what they write to stderr
+	LogFrontend   = "frontend"   // Exceptions reported by the UI
+	LogApp        = "app"        // Sessions, settings and everything else
+)
+
+// logComponents lists the components in display order
+var logComponents = []string{LogSerial, LogDevice, LogProcessing, LogAlarms, LogNotify, LogSecurity, LogPlugins, LogFrontend, LogApp}
+
+// Component loggers
+var (
+	serialLog     = logger{LogSerial}
+	deviceLog     = logger{LogDevice}
+	processingLog = logger{LogProcessing}
+	alarmsLog     = logger{LogAlarms}
+	notifyLog     = logger{LogNotify}
+	securityLog   = logger{LogSecurity}
+	pluginLog     = logger{LogPlugins}
+	frontendLog   = logger{LogFrontend}
+	appLog        = logger{LogApp}
+)
+
+// LogSettings describes the log output and the level of each component
+type LogSettings struct {
+	Directory  string            `json:"directory"`
+	Components []string          `json:"components"`
+	Levels     map[string]string `json:"levels"` // debug, info, warn or error by component
+}
+
+// logLevels holds the minimum level of each component, changeable at runtime
+type logLevels struct {
+	mu     sync.Mutex
+	levels map[string]slog.Level
+}
+
+// levels is shared by every component logger
+var levels = &logLevels{levels: make(map[string]slog.Level)}
+
+// of returns the minimum level of a component, info unless changed
+func (l *logLevels) of(component string) slog.Level {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if level, ok := l.levels[component]; ok {
+		return level
+	}
+	return slog.LevelInfo
+}
+
+// logger writes the messages of one component
+type logger struct {
+	component string
+}
+
+// logf formats and writes a message if the component's level allows it
+func (l logger) logf(level slog.Level, format string, args ...interface{}) {
+	if level < levels.of(l.component) {
+		return
+	}
+	slog.Log(context.Background(), level, fmt.Sprintf(format, args...), "component", l.component)
+}
+
+// Debugf logs detail that is only useful while troubleshooting, such as every parsed line
+func (l logger) Debugf(format string, args ...interface{}) {
+	l.logf(slog.LevelDebug, format, args...)
+}
+
+// Infof logs a normal event
+func (l logger) Infof(format string, args ...interface{}) {
+	l.logf(slog.LevelInfo, format, args...)
+}
+
+// Warnf logs a problem the app works around
+func (l logger) Warnf(format string, args ...interface{}) {
+	l.logf(slog.LevelWarn, format, args...)
+}
+
+// Errorf logs a failed operation
+func (l logger) Errorf(format string, args ...interface{}) {
+	l.logf(slog.LevelError, format, args...)
+}
+
+// rotatingFile is a log file that is rotated once it reaches maxLogFileSize
+type rotatingFile struct {
+	mu   sync.Mutex
+	dir  string
+	file *os.File
+	size int64
+}
+
+// openRotatingFile opens the current log file for appending
+func openRotatingFile(dir string) (*rotatingFile, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	r := &rotatingFile{dir: dir}
+	return r, r.open()
+}
+
+// open opens the current log file; the caller holds the lock
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(filepath.Join(r.dir, logFileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file, r.size = file, info.Size()
+	return nil
+}
+
+// rotatedLogName returns the name of the nth rotated log file
+func rotatedLogName(n int) string {
+	return fmt.Sprintf("%s.%d.log", strings.TrimSuffix(logFileName, ".log"), n)
+}
+
+// Write appends to the current file, rotating it first when it is full
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size+int64(len(p)) > maxLogFileSize && r.size > 0 {
+		r.file.Close()
+		os.Remove(filepath.Join(r.dir, rotatedLogName(maxLogFiles)))
+		for n := maxLogFiles - 1; n >= 1; n-- {
+			os.Rename(filepath.Join(r.dir, rotatedLogName(n)), filepath.Join(r.dir, rotatedLogName(n+1)))
+		}
+		os.Rename(filepath.Join(r.dir, logFileName), filepath.Join(r.dir, rotatedLogName(1)))
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// sync flushes the current file to disk
+func (r *rotatingFile) sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Sync()
+}
+
+// logFile is the open log file, nil when logging to the console only
+var logFile *rotatingFile
+
+// fanoutHandler passes every record to several handlers
+type fanoutHandler []slog.Handler
+
+// Enabled reports whether any handler takes the level
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle passes the record to each handler that takes its level
+func (h fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	for _, handler := range h {
+		if handler.Enabled(ctx, record.Level) {
+			handler.Handle(ctx, record.Clone())
+		}
+	}
+	return nil
+}
+
+// WithAttrs adds the attributes to every handler
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	result := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		result[i] = handler.WithAttrs(attrs)
+	}
+	return result
+}
+
+// WithGroup opens the group in every handler
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	result := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		result[i] = handler.WithGroup(name)
+	}
+	return result
+}
+
+// logDir returns the directory of the log files
+func logDir() (string, error) {
+	dir, err := appDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, logDirName), nil
+}
+
+// setupLogging sends all logging, including the standard log package, to
+// the console as text, to the rotating log file as JSON lines and to the
+// in-memory log viewer, and restores the component levels
+func setupLogging() {
+	options := &slog.HandlerOptions{Level: slog.LevelDebug}
+	handlers := fanoutHandler{slog.NewTextHandler(os.Stderr, options), recentLogs}
+
+	dir, err := logDir()
+	if err == nil {
+		if logFile, err = openRotatingFile(dir); err == nil {
+			handlers = append(handlers, slog.NewJSONHandler(logFile, options))
+		}
+	}
+	slog.SetDefault(slog.New(handlers))
+	if err != nil {
+		appLog.Errorf("Error opening log file, logging to the console only: %v", err)
+	}
+
+	stored := make(map[string]string)
+	if err := loadJSONFile(loggingFile, &stored); err != nil {
+		appLog.Errorf("Error loading log levels: %v", err)
+	}
+	levels.mu.Lock()
+	for component, name := range stored {
+		var level slog.Level
+		if level.UnmarshalText([]byte(name)) == nil {
+			levels.levels[component] = level
+		}
+	}
+	levels.mu.Unlock()
+}
+
+// GetLogSettings returns the log directory and the level of each component
+func (a *App) GetLogSettings() LogSettings {
+	dir, _ := logDir()
+	settings := LogSettings{
+		Directory:  dir,
+		Components: append([]string{}, logComponents...),
+		Levels:     make(map[string]string),
+	}
+	for _, component := range logComponents {
+		settings.Levels[component] = strings.ToLower(levels.of(component).String())
+	}
+	return settings
+}
+
+// SetLogLevel changes the minimum level of a component at runtime: debug,
+// info, warn or error. An empty component changes all of them.
+func (a *App) SetLogLevel(component string, level string) error {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return err
+	}
+
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("unknown log level '%s'", level)
+	}
+	known := component == ""
+	for _, name := range logComponents {
+		known = known || name == component
+	}
+	if !known {
+		return fmt.Errorf("unknown log component '%s'", component)
+	}
+	targets := []string{component}
+	if component == "" {
+		targets = logComponents
+	}
+
+	levels.mu.Lock()
+	for _, target := range targets {
+		levels.levels[target] = parsed
+	}
+	stored := make(map[string]string)
+	for name, value := range levels.levels {
+		stored[name] = strings.ToLower(value.String())
+	}
+	levels.mu.Unlock()
+
+	appLog.Infof("Log level of %s set to %s", strings.Join(targets, ", "), strings.ToLower(parsed.String()))
+	return saveJSONFile(loggingFile, stored)
+}
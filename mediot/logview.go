@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log viewer limits
+const (
+	recentLogCapacity = 2000 // Entries kept in memory for the diagnostics panel
+	defaultLogLimit   = 200
+	logStreamBuffer   = 256 // Entries waiting to be pushed; more are dropped from the stream
+)
+
+// LogEntry is one backend log message
+type LogEntry struct {
+	Time      time.Time `json:"time"`
+	Level     string    `json:"level"` // debug, info, warn or error
+	Component string    `json:"component"`
+	Message   string    `json:"message"`
+}
+
+// logBuffer is a log handler keeping the most recent entries in memory and
+// queueing them for the live log event
+type logBuffer struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int // Ring position of the next entry once full
+	stream  chan LogEntry
+}
+
+// recentLogs receives every log record that passes the component levels
+var recentLogs = &logBuffer{
+	entries: make([]LogEntry, 0, recentLogCapacity),
+	stream:  make(chan LogEntry, logStreamBuffer),
+}
+
+// Enabled takes every level; the component loggers filter beforehand
+func (b *logBuffer) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+// Handle stores the record and queues it for the frontend without blocking
+func (b *logBuffer) Handle(ctx context.Context, record slog.Record) error {
+	entry := LogEntry{
+		Time:      record.Time,
+		Level:     strings.ToLower(record.Level.String()),
+		Component: LogApp, // Messages of the standard log package
+		Message:   record.Message,
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		if attr.Key == "component" {
+			entry.Component = attr.Value.String()
+			return false
+		}
+		return true
+	})
+
+	b.mu.Lock()
+	if len(b.entries) < recentLogCapacity {
+		b.entries = append(b.entries, entry)
+	} else {
+		b.entries[b.next] = entry
+		b.next = (b.next + 1) % recentLogCapacity
+	}
+	b.mu.Unlock()
+
+	select {
+	case b.stream <- entry:
+	default:
+	}
+	return nil
+}
+
+// WithAttrs returns the buffer itself; the component is read from each record
+func (b *logBuffer) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return b
+}
+
+// WithGroup returns the buffer itself
+func (b *logBuffer) WithGroup(name string) slog.Handler {
+	return b
+}
+
+// logStreamLoop pushes new log entries to the frontend as log events
+func (a *App) logStreamLoop() {
+	for {
+		select {
+		case <-a.quit:
+			return
+		case entry := <-recentLogs.stream:
+			a.emit(EventLog, entry)
+		}
+	}
+}
+
+// GetRecentLogs returns up to limit of the most recent backend log entries,
+// oldest first, at or above the given level (debug, info, warn or error) and
+// of the given component. Empty filters match everything, and debug entries
+// are only kept while a component logs at debug level. New entries follow
+// as log events.
+func (a *App) GetRecentLogs(level string, component string, limit int) ([]LogEntry, error) {
+	minimum := slog.LevelDebug
+	if level != "" {
+		if err := minimum.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("unknown log level '%s'", level)
+		}
+	}
+	if limit <= 0 {
+		limit = defaultLogLimit
+	}
+
+	recentLogs.mu.Lock()
+	ordered := append(append([]LogEntry{}, recentLogs.entries[recentLogs.next:]...), recentLogs.entries[:recentLogs.next]...)
+	recentLogs.mu.Unlock()
+
+	result := make([]LogEntry, 0, limit)
+	for i := len(ordered) - 1; i >= 0 && len(result) < limit; i-- {
+		entry := ordered[i]
+		var entryLevel slog.Level
+		entryLevel.UnmarshalText([]byte(entry.Level))
+		if entryLevel < minimum || (component != "" && entry.Component != component) {
+			continue
+		}
+		result = append(result, entry)
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result, nil
+}
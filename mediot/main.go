@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"os"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -12,6 +13,19 @@ import (
 var assets embed.FS
 
 func main() {
+	setupPortable(os.Args[1:])
+	if headlessRequested(os.Args[1:]) {
+		os.Exit(runHeadless(os.Args[1:]))
+	}
+	if replayRequested(os.Args[1:]) {
+		os.Exit(runReplay(os.Args[1:]))
+	}
+	if selfTestRequested(os.Args[1:]) {
+		os.Exit(runSelfTest(os.Args[1:]))
+	}
+
+	parseOverrides(os.Args[1:])
+
 	// Create an instance of the app structure
 	app := NewApp()
 
@@ -25,6 +39,8 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.startup,
+		OnBeforeClose:    app.beforeClose,
+		OnShutdown:       app.shutdown,
 		Bind: []interface{}{
 			app,
 		},
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// messagingFile stores the Telegram and SMS alert settings
+const messagingFile = "messaging.json"
+
+// messagingTimeout bounds every request to the messaging APIs
+const messagingTimeout = 15 * time.Second
+
+// Messaging API endpoints
+const (
+	telegramAPI = "https://api.telegram.org"
+	twilioAPI   = "https://api.twilio.com/2010-04-01"
+)
+
+// TelegramConfig configures alerts sent by a Telegram bot
+type TelegramConfig struct {
+	Enabled     bool     `json:"enabled"`
+	BotToken    string   `json:"botToken,omitempty"` // Never returned to the frontend; empty keeps the stored one
+	ChatIDs     []string `json:"chatIds"`
+	MinSeverity string   `json:"minSeverity"` // Lowest alarm severity that is sent
+}
+
+// SMSConfig configures alerts sent as SMS through Twilio
+type SMSConfig struct {
+	Enabled     bool     `json:"enabled"`
+	AccountSID  string   `json:"accountSid"`
+	AuthToken   string   `json:"authToken,omitempty"` // Never returned to the frontend; empty keeps the stored one
+	From        string   `json:"from"`                // Twilio sender number
+	To          []string `json:"to"`
+	MinSeverity string   `json:"minSeverity"`
+}
+
+// MessagingConfig configures the phone alert channels
+type MessagingConfig struct {
+	Telegram TelegramConfig `json:"telegram"`
+	SMS      SMSConfig      `json:"sms"`
+}
+
+// defaultMessagingConfig has both channels disabled and limited to critical alarms
+func defaultMessagingConfig() MessagingConfig {
+	return MessagingConfig{
+		Telegram: TelegramConfig{ChatIDs: make([]string, 0), MinSeverity: SeverityCritical},
+		SMS:      SMSConfig{To: make([]string, 0), MinSeverity: SeverityCritical},
+	}
+}
+
+// messagingNotifier sends alarm alerts to phones
+type messagingNotifier struct {
+	mu     sync.Mutex
+	config MessagingConfig
+	client *http.Client
+}
+
+// newMessagingNotifier creates the notifier and loads the persisted settings
+func newMessagingNotifier() *messagingNotifier {
+	n := &messagingNotifier{
+		config: defaultMessagingConfig(),
+		client: &http.Client{Timeout: messagingTimeout, Transport: certificates.httpTransport()},
+	}
+	if err := loadJSONFile(messagingFile, &n.config); err != nil {
+		notifyLog.Errorf("Error loading messaging settings: %v", err)
+	}
+	telegramMoved := secrets.restore(secretTelegramToken, &n.config.Telegram.BotToken)
+	twilioMoved := secrets.restore(secretTwilioToken, &n.config.SMS.AuthToken)
+	if telegramMoved || twilioMoved {
+		if err := n.save(); err != nil {
+			notifyLog.Errorf("Error saving messaging settings: %v", err)
+		}
+	}
+	return n
+}
+
+// save persists the settings, leaving out the tokens the keyring holds; the
+// caller holds the lock
+func (n *messagingNotifier) save() error {
+	stored := n.config
+	if secrets.keep(secretTelegramToken, stored.Telegram.BotToken) {
+		stored.Telegram.BotToken = ""
+	}
+	if secrets.keep(secretTwilioToken, stored.SMS.AuthToken) {
+		stored.SMS.AuthToken = ""
+	}
+	return saveJSONFile(messagingFile, stored)
+}
+
+// notifyAlarm sends an alarm escalation to the channels whose minimum severity it reaches
+func (n *messagingNotifier) notifyAlarm(event AlarmEvent) {
+	if severityRank[event.Severity] <= severityRank[event.Previous] {
+		return
+	}
+
+	n.mu.Lock()
+	config := n.config
+	n.mu.Unlock()
+
+	text := fmt.Sprintf("mediot alarm #%d [%s]: %s", event.ID, event.Severity, event.Message)
+	if config.Telegram.Enabled && severityRank[event.Severity] >= severityRank[config.Telegram.MinSeverity] {
+		goSafe("Telegram sink", func() { n.logFailure("Telegram", n.sendTelegram(config.Telegram, text)) })
+	}
+	if config.SMS.Enabled && severityRank[event.Severity] >= severityRank[config.SMS.MinSeverity] {
+		goSafe("SMS sink", func() { n.logFailure("SMS", n.sendSMS(config.SMS, text)) })
+	}
+}
+
+// logFailure logs the error of an asynchronous send
+func (n *messagingNotifier) logFailure(channel string, err error) {
+	if err != nil {
+		notifyLog.Errorf("Error sending %s alert: %v", channel, err)
+	}
+}
+
+// sendTelegram posts a message to every configured chat
+func (n *messagingNotifier) sendTelegram(config TelegramConfig, text string) error {
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", telegramAPI, config.BotToken)
+	for _, chat := range config.ChatIDs {
+		resp, err := n.client.PostForm(endpoint, url.Values{"chat_id": {chat}, "text": {text}})
+		if tlsErr := tlsFailure(err); tlsErr != nil {
+			return fmt.Errorf("request to chat %s failed: %v", chat, tlsErr)
+		}
+		if err != nil {
+			// The error text contains the URL and with it the bot token
+			return fmt.Errorf("request to chat %s failed", chat)
+		}
+		if err := checkResponse(resp); err != nil {
+			return fmt.Errorf("chat %s: %v", chat, err)
+		}
+	}
+	return nil
+}
+
+// sendSMS sends a text message to every configured number through Twilio
+func (n *messagingNotifier) sendSMS(config SMSConfig, text string) error {
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", twilioAPI, url.PathEscape(config.AccountSID))
+	for _, to := range config.To {
+		form := url.Values{"From": {config.From}, "To": {to}, "Body": {text}}
+		req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(config.AccountSID, config.AuthToken)
+
+		resp, err := n.client.Do(req)
+		if tlsErr := tlsFailure(err); tlsErr != nil {
+			return fmt.Errorf("request for %s failed: %v", to, tlsErr)
+		}
+		if err != nil {
+			return fmt.Errorf("request for %s failed: %v", to, err)
+		}
+		if err := checkResponse(resp); err != nil {
+			return fmt.Errorf("number %s: %v", to, err)
+		}
+	}
+	return nil
+}
+
+// checkResponse closes a response and turns an error status into an error
+// carrying the API's message
+func checkResponse(resp *http.Response) error {
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var apiError struct {
+		Description string `json:"description"` // Telegram
+		Message     string `json:"message"`     // Twilio
+	}
+	if json.Unmarshal(body, &apiError) == nil && apiError.Description+apiError.Message != "" {
+		return fmt.Errorf("%s: %s%s", resp.Status, apiError.Description, apiError.Message)
+	}
+	return fmt.Errorf("%s", resp.Status)
+}
+
+// GetMessagingConfig returns the Telegram and SMS settings without their secrets
+func (a *App) GetMessagingConfig() MessagingConfig {
+	a.messaging.mu.Lock()
+	defer a.messaging.mu.Unlock()
+
+	config := a.messaging.config
+	config.Telegram.BotToken = ""
+	config.Telegram.ChatIDs = append([]string{}, config.Telegram.ChatIDs...)
+	config.SMS.AuthToken = ""
+	config.SMS.To = append([]string{}, config.SMS.To...)
+	return config
+}
+
+// SetMessagingConfig replaces and persists the Telegram and SMS settings.
+// Empty secrets keep the stored ones.
+func (a *App) SetMessagingConfig(config MessagingConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	for _, severity := range []string{config.Telegram.MinSeverity, config.SMS.MinSeverity} {
+		if severity != SeverityWarning && severity != SeverityCritical {
+			return fmt.Errorf("unknown severity '%s'", severity)
+		}
+	}
+
+	a.messaging.mu.Lock()
+	defer a.messaging.mu.Unlock()
+
+	if config.Telegram.BotToken == "" {
+		config.Telegram.BotToken = a.messaging.config.Telegram.BotToken
+	}
+	if config.SMS.AuthToken == "" {
+		config.SMS.AuthToken = a.messaging.config.SMS.AuthToken
+	}
+	if config.Telegram.Enabled && (config.Telegram.BotToken == "" || len(config.Telegram.ChatIDs) == 0) {
+		return fmt.Errorf("telegram needs a bot token and at least one chat")
+	}
+	if config.SMS.Enabled && (config.SMS.AccountSID == "" || config.SMS.AuthToken == "" ||
+		config.SMS.From == "" || len(config.SMS.To) == 0) {
+		return fmt.Errorf("SMS needs the Twilio account, token, sender and at least one recipient")
+	}
+
+	a.messaging.config = config
+	return a.messaging.save()
+}
+
+// SendTestMessage sends a test alert through "telegram" or "sms" and
+// reports any delivery error
+func (a *App) SendTestMessage(channel string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.messaging.mu.Lock()
+	config := a.messaging.config
+	a.messaging.mu.Unlock()
+
+	const text = "mediot test alert: phone alerts are working"
+	switch channel {
+	case "telegram":
+		return a.messaging.sendTelegram(config.Telegram, text)
+	case "sms":
+		return a.messaging.sendSMS(config.SMS, text)
+	}
+	return fmt.Errorf("unknown messaging channel '%s'", channel)
+}
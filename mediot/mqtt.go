@@ -0,0 +1,482 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mqttFile stores the MQTT sink settings
+const mqttFile = "mqtt.json"
+
+// MQTT sink timing and limits
+const (
+	mqttQueueSize     = 4096 // Samples waiting for the broker; more are dropped
+	mqttKeepAlive     = 60 * time.Second
+	mqttDialTimeout   = 10 * time.Second
+	mqttWriteTimeout  = 10 * time.Second
+	mqttRetryInterval = 5 * time.Second
+	defaultMQTTTopic  = "mediot/{device}/samples"
+)
+
+// MQTT control packet types, shifted into the first header byte
+const (
+	mqttConnect    = 0x10
+	mqttConnack    = 0x20
+	mqttPublish    = 0x30
+	mqttPingreq    = 0xC0
+	mqttDisconnect = 0xE0
+)
+
+// mqttConnackErrors explain the return codes of a refused connection
+var mqttConnackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client ID rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// MQTTConfig configures the sink publishing every sample to an MQTT broker
+type MQTTConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Broker   string `json:"broker"`   // tcp://host:1883, or ssl://host:8883 for TLS with the imported certificates
+	Topic    string `json:"topic"`    // {device} is replaced by the ID of the connected device
+	ClientID string `json:"clientId"` // Empty for mediot-<host name>
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"` // Never returned to the frontend; empty keeps the stored one
+}
+
+// MQTTStatus describes the connection of the MQTT sink
+type MQTTStatus struct {
+	Connected bool   `json:"connected"`
+	Published int64  `json:"published"`
+	Dropped   int64  `json:"dropped"` // Samples not sent because the broker was unreachable or too slow
+	LastError string `json:"lastError,omitempty"`
+}
+
+// mqttMessage is a sample waiting to be published
+type mqttMessage struct {
+	topic   string
+	payload []byte
+}
+
+// defaultMQTTConfig is disabled with the default topic
+func defaultMQTTConfig() MQTTConfig {
+	return MQTTConfig{Topic: defaultMQTTTopic}
+}
+
+// mqttSink is a pipeline stage queueing every sample for the broker. The
+// MQTT loop publishes them with QoS 0, so a slow or unreachable broker
+// never holds up the pipeline; samples beyond the queue are dropped.
+type mqttSink struct {
+	mu      sync.Mutex
+	config  MQTTConfig
+	queue   chan mqttMessage
+	changed chan struct{} // Tells the loop to reconnect with the new settings
+	device  func() string // ID of the connected device
+	status  MQTTStatus
+}
+
+// newMQTTSink creates the sink and loads the persisted settings
+func newMQTTSink(device func() string) *mqttSink {
+	s := &mqttSink{
+		config:  defaultMQTTConfig(),
+		queue:   make(chan mqttMessage, mqttQueueSize),
+		changed: make(chan struct{}, 1),
+		device:  device,
+	}
+	if err := loadJSONFile(mqttFile, &s.config); err != nil {
+		appLog.Errorf("Error loading MQTT settings: %v", err)
+	}
+	if secrets.restore(secretMQTTPassword, &s.config.Password) {
+		if err := s.save(); err != nil {
+			appLog.Errorf("Error saving MQTT settings: %v", err)
+		}
+	}
+	return s
+}
+
+// save persists the settings, leaving out the password the keyring holds;
+// the caller holds the lock
+func (s *mqttSink) save() error {
+	stored := s.config
+	if secrets.keep(secretMQTTPassword, stored.Password) {
+		stored.Password = ""
+	}
+	return saveJSONFile(mqttFile, stored)
+}
+
+func (s *mqttSink) process(sample *SensorData) {
+	s.mu.Lock()
+	enabled, topic := s.config.Enabled, s.config.Topic
+	s.mu.Unlock()
+	if !enabled {
+		return
+	}
+
+	payload, err := json.Marshal(sample)
+	if err != nil {
+		return
+	}
+	select {
+	case s.queue <- mqttMessage{topic: strings.ReplaceAll(topic, "{device}", s.device()), payload: payload}:
+	default:
+		s.mu.Lock()
+		s.status.Dropped++
+		s.mu.Unlock()
+	}
+}
+
+// reconfigure replaces the settings and makes the loop reconnect
+func (s *mqttSink) reconfigure(config MQTTConfig) {
+	s.mu.Lock()
+	s.config = config
+	s.mu.Unlock()
+
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// useBroker publishes to a broker given on the command line for this run,
+// keeping the stored credentials; the settings file is left alone
+func (s *mqttSink) useBroker(broker string) error {
+	if err := checkMQTTBroker(broker); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	config := s.config
+	s.mu.Unlock()
+
+	config.Enabled = true
+	config.Broker = broker
+	s.reconfigure(config)
+	return nil
+}
+
+// applyOverrides publishes to the broker and topic given by flags or
+// environment variables, for this run only; the settings file is left alone
+func (s *mqttSink) applyOverrides() {
+	broker, hasBroker := overrides.value(overrideMQTTBroker)
+	topic, hasTopic := overrides.value(overrideMQTTTopic)
+	if !hasBroker && !hasTopic {
+		return
+	}
+
+	s.mu.Lock()
+	config := s.config
+	s.mu.Unlock()
+
+	if hasBroker {
+		if err := checkMQTTBroker(broker); err != nil {
+			appLog.Errorf("MQTT broker override ignored: %v", err)
+		} else {
+			config.Enabled = true
+			config.Broker = broker
+		}
+	}
+	if hasTopic {
+		if topic == "" || strings.ContainsAny(topic, "+#") {
+			appLog.Errorf("MQTT topic override '%s' ignored", topic)
+		} else {
+			config.Topic = topic
+		}
+	}
+	s.reconfigure(config)
+}
+
+// setStatus records the outcome of a connection attempt or session
+func (s *mqttSink) setStatus(connected bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.Connected = connected
+	if err != nil {
+		s.status.LastError = err.Error()
+	} else if connected {
+		s.status.LastError = ""
+	}
+}
+
+// checkMQTTBroker validates a broker URL
+func checkMQTTBroker(broker string) error {
+	u, err := url.Parse(broker)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("invalid MQTT broker '%s'; use tcp://host:1883 or ssl://host:8883", broker)
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt", "ssl", "tls", "mqtts":
+		return nil
+	}
+	return fmt.Errorf("unsupported MQTT broker scheme '%s'", u.Scheme)
+}
+
+// mqttPacket frames a control packet with its remaining length
+func mqttPacket(header byte, body []byte) []byte {
+	packet := []byte{header}
+	n := len(body)
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if n == 0 {
+			break
+		}
+	}
+	return append(packet, body...)
+}
+
+// mqttString appends a length-prefixed UTF-8 string
+func mqttString(b []byte, s string) []byte {
+	return append(append(b, byte(len(s)>>8), byte(len(s))), s...)
+}
+
+// mqttAddress returns the host:port of a broker URL and whether it uses TLS
+func mqttAddress(broker string) (string, bool, error) {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return "", false, err
+	}
+	secure := u.Scheme == "ssl" || u.Scheme == "tls" || u.Scheme == "mqtts"
+	if u.Port() != "" {
+		return u.Host, secure, nil
+	}
+	if secure {
+		return net.JoinHostPort(u.Hostname(), "8883"), true, nil
+	}
+	return net.JoinHostPort(u.Hostname(), "1883"), false, nil
+}
+
+// mqttDial connects and logs in to the broker
+func mqttDial(config MQTTConfig) (net.Conn, error) {
+	addr, secure, err := mqttAddress(config.Broker)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: mqttDialTimeout}
+	var conn net.Conn
+	if secure {
+		host, _, _ := net.SplitHostPort(addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, certificates.clientConfig(host))
+		if tlsErr := tlsFailure(err); tlsErr != nil {
+			err = tlsErr
+		}
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	clientID := config.ClientID
+	if clientID == "" {
+		host, _ := os.Hostname()
+		clientID = "mediot-" + host
+	}
+	flags := byte(0x02) // Clean session
+	keepAlive := int(mqttKeepAlive / time.Second)
+	body := mqttString(nil, "MQTT")
+	body = append(body, 4, 0, byte(keepAlive>>8), byte(keepAlive)) // Protocol level 4 is MQTT 3.1.1; flags follow
+	body = mqttString(body, clientID)
+	if config.Username != "" {
+		flags |= 0x80
+		body = mqttString(body, config.Username)
+		if config.Password != "" {
+			flags |= 0x40
+			body = mqttString(body, config.Password)
+		}
+	}
+	body[7] = flags
+
+	conn.SetDeadline(time.Now().Add(mqttDialTimeout))
+	ack := make([]byte, 4)
+	if _, err = conn.Write(mqttPacket(mqttConnect, body)); err == nil {
+		_, err = io.ReadFull(conn, ack)
+	}
+	if err == nil && (ack[0] != mqttConnack || ack[1] != 2) {
+		err = fmt.Errorf("unexpected reply from the broker")
+	}
+	if err == nil && ack[3] != 0 {
+		err = fmt.Errorf("connection refused: %s", mqttConnackErrors[ack[3]])
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// mqttLoop keeps the broker connection while the sink is enabled and
+// publishes the queued samples
+func (a *App) mqttLoop() {
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-a.mqtt.changed:
+			// Already reflected in the settings read below
+		default:
+		}
+
+		a.mqtt.mu.Lock()
+		config := a.mqtt.config
+		a.mqtt.mu.Unlock()
+		if !config.Enabled {
+			select {
+			case <-a.quit:
+				return
+			case <-a.mqtt.changed:
+			}
+			continue
+		}
+
+		conn, err := mqttDial(config)
+		if err == nil {
+			appLog.Infof("Connected to MQTT broker %s", config.Broker)
+			a.mqtt.setStatus(true, nil)
+			err = a.mqttPublish(conn)
+			conn.Close()
+		}
+		a.mqtt.setStatus(false, err)
+		if err == nil {
+			// The settings changed or the app is quitting
+			continue
+		}
+
+		appLog.Warnf("MQTT broker %s: %v", config.Broker, err)
+		backendErrors.report(ErrorSink, "Publishing to the MQTT broker failed", err)
+		select {
+		case <-a.quit:
+			return
+		case <-a.mqtt.changed:
+		case <-time.After(mqttRetryInterval):
+		}
+	}
+}
+
+// mqttPublish sends the queued samples on a connection until it fails, the
+// settings change or the app quits; only a failure is returned
+func (a *App) mqttPublish(conn net.Conn) error {
+	// Replies are only ping responses; reading them detects a dead connection
+	closed := make(chan error, 1)
+	goSafe("MQTT reader", func() {
+		_, err := io.Copy(io.Discard, conn)
+		if err == nil {
+			err = io.EOF
+		}
+		closed <- err
+	})
+
+	write := func(packet []byte) error {
+		conn.SetWriteDeadline(time.Now().Add(mqttWriteTimeout))
+		_, err := conn.Write(packet)
+		return err
+	}
+	ping := time.NewTicker(mqttKeepAlive / 2)
+	defer ping.Stop()
+	for {
+		select {
+		case <-a.quit:
+			write(mqttPacket(mqttDisconnect, nil))
+			return nil
+		case <-a.mqtt.changed:
+			write(mqttPacket(mqttDisconnect, nil))
+			return nil
+		case err := <-closed:
+			return fmt.Errorf("connection closed: %v", err)
+		case <-ping.C:
+			if err := write(mqttPacket(mqttPingreq, nil)); err != nil {
+				return err
+			}
+		case message := <-a.mqtt.queue:
+			if err := write(mqttPacket(mqttPublish, append(mqttString(nil, message.topic), message.payload...))); err != nil {
+				return err
+			}
+			a.mqtt.mu.Lock()
+			a.mqtt.status.Published++
+			a.mqtt.mu.Unlock()
+		}
+	}
+}
+
+// GetMQTTConfig returns the MQTT sink settings without the password
+func (a *App) GetMQTTConfig() MQTTConfig {
+	a.mqtt.mu.Lock()
+	defer a.mqtt.mu.Unlock()
+
+	config := a.mqtt.config
+	config.Password = ""
+	return config
+}
+
+// SetMQTTConfig replaces and persists the MQTT sink settings and reconnects.
+// An empty password keeps the stored one.
+func (a *App) SetMQTTConfig(config MQTTConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+	for _, setting := range []string{overrideMQTTBroker, overrideMQTTTopic} {
+		if source := overrides.source(setting); source != "" {
+			return fmt.Errorf("the MQTT settings are set by %s for this run and cannot be changed", source)
+		}
+	}
+
+	if config.Topic == "" {
+		config.Topic = defaultMQTTTopic
+	}
+	if strings.ContainsAny(config.Topic, "+#") {
+		return fmt.Errorf("MQTT topic cannot contain wildcards")
+	}
+	if config.Enabled {
+		if err := checkMQTTBroker(config.Broker); err != nil {
+			return err
+		}
+	}
+
+	a.mqtt.mu.Lock()
+	if config.Password == "" {
+		config.Password = a.mqtt.config.Password
+	}
+	if config.Password != "" && config.Username == "" {
+		a.mqtt.mu.Unlock()
+		return fmt.Errorf("an MQTT password needs a user name")
+	}
+	previous := a.mqtt.config
+	a.mqtt.config = config
+	if err := a.mqtt.save(); err != nil {
+		a.mqtt.config = previous
+		a.mqtt.mu.Unlock()
+		return err
+	}
+	a.mqtt.mu.Unlock()
+
+	if config.Enabled {
+		telemetry.count(FeatureMQTT)
+	}
+	a.mqtt.reconfigure(config)
+	return nil
+}
+
+// GetMQTTStatus returns whether the MQTT sink is connected and how many
+// samples it published and dropped
+func (a *App) GetMQTTStatus() MQTTStatus {
+	a.mqtt.mu.Lock()
+	defer a.mqtt.mu.Unlock()
+
+	return a.mqtt.status
+}
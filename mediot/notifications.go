@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// notificationsFile stores the desktop notification settings
+const notificationsFile = "notifications.json"
+
+// NotificationConfig configures the OS-level notifications of alarm events
+type NotificationConfig struct {
+	Enabled           bool   `json:"enabled"`
+	MinSeverity       string `json:"minSeverity"`       // Lowest severity that raises a notification
+	OnlyWhenMinimized bool   `json:"onlyWhenMinimized"` // Skip notifications while the window is visible
+}
+
+// defaultNotificationConfig notifies about warning and critical alarms while the window is minimized
+func defaultNotificationConfig() NotificationConfig {
+	return NotificationConfig{
+		Enabled:           true,
+		MinSeverity:       SeverityWarning,
+		OnlyWhenMinimized: true,
+	}
+}
+
+// desktopNotifier holds the notification settings
+type desktopNotifier struct {
+	mu     sync.Mutex
+	config NotificationConfig
+}
+
+// newDesktopNotifier creates the notifier and loads the persisted settings
+func newDesktopNotifier() *desktopNotifier {
+	n := &desktopNotifier{config: defaultNotificationConfig()}
+	if err := loadJSONFile(notificationsFile, &n.config); err != nil {
+		notifyLog.Errorf("Error loading notification settings: %v", err)
+	}
+	return n
+}
+
+// notifyAlarm raises a desktop notification for an alarm that escalated to a
+// severity at or above the configured minimum. Clicking it brings the window back.
+func (a *App) notifyAlarm(event AlarmEvent) {
+	a.notifier.mu.Lock()
+	config := a.notifier.config
+	a.notifier.mu.Unlock()
+
+	if !config.Enabled || a.ctx == nil {
+		return
+	}
+	if severityRank[event.Severity] < severityRank[config.MinSeverity] ||
+		severityRank[event.Severity] <= severityRank[event.Previous] {
+		return
+	}
+	if config.OnlyWhenMinimized && !runtime.WindowIsMinimised(a.ctx) {
+		return
+	}
+
+	title := fmt.Sprintf("mediot %s alarm", event.Severity)
+	a.sendNotification(title, event.Message, event.Severity == SeverityCritical)
+}
+
+// sendNotification shows a notification without blocking the caller
+func (a *App) sendNotification(title, body string, urgent bool) {
+	goSafe("desktop notification", func() {
+		if err := sendDesktopNotification(title, body, urgent, a.focusWindow); err != nil {
+			notifyLog.Errorf("Error sending desktop notification: %v", err)
+		}
+	})
+}
+
+// focusWindow restores and shows the main window
+func (a *App) focusWindow() {
+	if a.ctx == nil {
+		return
+	}
+	runtime.WindowUnminimise(a.ctx)
+	runtime.WindowShow(a.ctx)
+}
+
+// GetNotificationConfig returns the desktop notification settings
+func (a *App) GetNotificationConfig() NotificationConfig {
+	a.notifier.mu.Lock()
+	defer a.notifier.mu.Unlock()
+
+	return a.notifier.config
+}
+
+// SetNotificationConfig replaces and persists the desktop notification settings
+func (a *App) SetNotificationConfig(config NotificationConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if config.MinSeverity != SeverityWarning && config.MinSeverity != SeverityCritical {
+		return fmt.Errorf("unknown severity '%s'", config.MinSeverity)
+	}
+
+	a.notifier.mu.Lock()
+	defer a.notifier.mu.Unlock()
+
+	a.notifier.config = config
+	return saveJSONFile(notificationsFile, config)
+}
+
+// SendTestNotification shows a sample notification so the operator can
+// check that the OS lets the app through
+func (a *App) SendTestNotification() {
+	a.sendNotification("mediot test notification", "Alarm notifications are working", false)
+}
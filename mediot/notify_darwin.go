@@ -0,0 +1,20 @@
+package main
+
+import (
+	"os/exec"
+)
+
+// sendDesktopNotification shows a Notification Center banner through
+// osascript. AppleScript notifications don't report clicks, so onClick is
+// never called; the title and body are passed as arguments to avoid quoting issues.
+func sendDesktopNotification(title, body string, urgent bool, onClick func()) error {
+	script := []string{
+		"-e", "on run argv",
+		"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+		"-e", "end run",
+	}
+	if urgent {
+		script[3] = "display notification (item 2 of argv) with title (item 1 of argv) sound name \"Sosumi\""
+	}
+	return exec.Command("osascript", append(script, title, body)...).Run()
+}
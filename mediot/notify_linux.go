@@ -0,0 +1,26 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// sendDesktopNotification shows a notification through libnotify's
+// notify-send and waits for it to close. Versions with action support call
+// onClick when the notification is clicked; older ones just show it.
+func sendDesktopNotification(title, body string, urgent bool, onClick func()) error {
+	urgency := "--urgency=normal"
+	if urgent {
+		urgency = "--urgency=critical"
+	}
+
+	out, err := exec.Command("notify-send", "--app-name=mediot", urgency,
+		"--action=default=Open", "--wait", title, body).Output()
+	if err != nil {
+		return exec.Command("notify-send", "--app-name=mediot", urgency, title, body).Run()
+	}
+	if strings.TrimSpace(string(out)) == "default" {
+		onClick()
+	}
+	return nil
+}
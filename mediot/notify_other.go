@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import "errors"
+
+// sendDesktopNotification is not available on this platform
+func sendDesktopNotification(title, body string, urgent bool, onClick func()) error {
+	return errors.New("desktop notifications are not supported on this platform")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// toastScript shows a toast through the WinRT notification API and prints
+// "activated" if the user clicks it before it times out. The text comes from
+// environment variables so it never has to be escaped into the script.
+const toastScript = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:MEDIOT_TOAST_TITLE)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode($env:MEDIOT_TOAST_BODY)) > $null
+if ($env:MEDIOT_TOAST_URGENT -eq '1') { $template.DocumentElement.SetAttribute('scenario', 'alarm') }
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+Register-ObjectEvent -InputObject $toast -EventName Activated -SourceIdentifier toastClick > $null
+$appId = '{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe'
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($appId).Show($toast)
+if (Wait-Event -SourceIdentifier toastClick -Timeout 30) { 'activated' }
+`
+
+// createNoWindow keeps PowerShell from flashing a console window
+const createNoWindow = 0x08000000
+
+// sendDesktopNotification shows a Windows toast and calls onClick when it is clicked
+func sendDesktopNotification(title, body string, urgent bool, onClick func()) error {
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", toastScript)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true, CreationFlags: createNoWindow}
+
+	flag := "0"
+	if urgent {
+		flag = "1"
+	}
+	cmd.Env = append(os.Environ(),
+		"MEDIOT_TOAST_TITLE="+title,
+		"MEDIOT_TOAST_BODY="+body,
+		"MEDIOT_TOAST_URGENT="+flag,
+	)
+
+	out, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+	if strings.Contains(string(out), "activated") {
+		onClick()
+	}
+	return nil
+}
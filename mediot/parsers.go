@@ -0,0 +1,111 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Line formats a device can send
+const (
+	ParserHex     = "hex"     // Comma-separated 32-bit hex values, scaled to the raw channels
+	ParserDecimal = "decimal" // Comma- or semicolon-separated decimal values already in channel units
+)
+
+// Parser modes, for lines a parser rejects
+const (
+	ParserModeStrict  = "strict"  // The line is dropped and quarantined
+	ParserModeLenient = "lenient" // The values that decode are kept; the others carry over from the last line
+)
+
+// hexScales divide the raw hex values into the units of each raw channel.
+// These scaling factors may need adjustment based on your specific sensor.
+var hexScales = [3]float64{
+	100.0,  // Scale ECG to reasonable mV range
+	200.0,  // Scale respiratory signal
+	1000.0, // Scale SpO2 signal
+}
+
+// lineParser decodes one line of the serial stream into a sample
+type lineParser func(line string) (*SensorData, error)
+
+// lineParsers are the line formats a device can be bound to
+var lineParsers = map[string]lineParser{
+	ParserHex:     parseHexData,
+	ParserDecimal: parseDecimalData,
+}
+
+// fieldParser decodes what it can of a line a lineParser rejected: the
+// value of each raw channel and whether it decoded
+type fieldParser func(line string) ([]float64, []bool)
+
+// fieldParsers are the lenient decoders of the built-in formats. Plugin
+// parsers have none, so their malformed lines are always quarantined.
+var fieldParsers = map[string]fieldParser{
+	ParserHex:     hexFields,
+	ParserDecimal: decimalFields,
+}
+
+// hexFields decodes the hex values of a line one by one, with or without
+// their 0x prefix
+func hexFields(line string) ([]float64, []bool) {
+	parts := strings.Split(line, ",")
+	values := make([]float64, len(rawChannels))
+	valid := make([]bool, len(rawChannels))
+	for i := range rawChannels {
+		if i >= len(parts) {
+			break
+		}
+		if value, err := parseHexToInt32(strings.TrimSpace(parts[i])); err == nil {
+			values[i], valid[i] = float64(value)/hexScales[i], true
+		}
+	}
+	return values, valid
+}
+
+// decimalFields decodes the decimal values of a line one by one
+func decimalFields(line string) ([]float64, []bool) {
+	parts := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ';' })
+	values := make([]float64, len(rawChannels))
+	valid := make([]bool, len(rawChannels))
+	for i := range rawChannels {
+		if i >= len(parts) {
+			break
+		}
+		if value, err := strconv.ParseFloat(strings.TrimSpace(parts[i]), 64); err == nil {
+			values[i], valid[i] = value, true
+		}
+	}
+	return values, valid
+}
+
+// parseDecimalData parses decimal values (e.g., "0.82,1.4,97.5"). Values are
+// taken as they are; scaling is left to the device's calibrations.
+func parseDecimalData(line string) (*SensorData, error) {
+	parts := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ';' })
+	if len(parts) < len(rawChannels) {
+		return nil, trError(MsgParseDecimalCount, len(rawChannels), len(parts), line)
+	}
+
+	sample := &SensorData{Timestamp: time.Now()}
+	for i, channel := range rawChannels {
+		value, err := strconv.ParseFloat(strings.TrimSpace(parts[i]), 64)
+		if err != nil {
+			return nil, trError(MsgParseDecimalValue, i+1, parts[i])
+		}
+		sample.setChannelValue(channel, value)
+	}
+	return sample, nil
+}
+
+// GetParsers returns the names of the line formats a device can be bound
+// to, the built-in ones and those of the enabled plugins
+func (a *App) GetParsers() []string {
+	result := a.plugins.parserNames()
+	for name := range lineParsers {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
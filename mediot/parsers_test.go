@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"math"
+	"os"
+	"testing"
+)
+
+// parserSeeds are lines devices send, and lines they garble
+var parserSeeds = []string{
+	"0x0000012C,0x00000190,0x00017A2C",
+	"0x215c,0x3711,0xffffa4d9",
+	"0X1,0x2,0x3,0x4",
+	"0x,0x1,0x2",
+	"0x123456789,0x1,0x2",
+	"12C,0x190,0x17A2C",
+	"0x12C,,0x17A2C",
+	"0.82,1.4,97.5",
+	"0.82;1.4;97.5",
+	"1e400,NaN,-Inf",
+	"1,2",
+	",,,",
+	"",
+	"\x00\xff,\x80",
+}
+
+// FuzzParseHexData checks that the hex parser never panics, and that what
+// it decodes the lenient hex decoder decodes to the same values
+func FuzzParseHexData(f *testing.F) {
+	for _, seed := range parserSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		sample, err := parseHexData(line)
+		if err != nil {
+			return
+		}
+		values, valid := hexFields(line)
+		for i, channel := range rawChannels {
+			got, _ := sample.channelValue(channel)
+			if math.IsNaN(got) || math.IsInf(got, 0) {
+				t.Fatalf("%q decoded %s to %v", line, channel, got)
+			}
+			if !valid[i] || values[i] != got {
+				t.Fatalf("%q decoded %s to %v, lenient decoder to %v (valid %t)", line, channel, got, values[i], valid[i])
+			}
+		}
+	})
+}
+
+// FuzzParseDecimal checks that the decimal parser never panics, and that
+// what it decodes the lenient decimal decoder decodes to the same values
+func FuzzParseDecimal(f *testing.F) {
+	for _, seed := range parserSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		sample, err := parseDecimalData(line)
+		if err != nil {
+			return
+		}
+		values, valid := decimalFields(line)
+		for i, channel := range rawChannels {
+			got, _ := sample.channelValue(channel)
+			if !valid[i] || (values[i] != got && !(math.IsNaN(got) && math.IsNaN(values[i]))) {
+				t.Fatalf("%q decoded %s to %v, lenient decoder to %v (valid %t)", line, channel, got, values[i], valid[i])
+			}
+		}
+	})
+}
+
+// setParserMode switches the parser mode in force, without saving it
+func setParserMode(a *App, mode string) {
+	a.settings.mu.Lock()
+	a.settings.settings.ParserMode = mode
+	a.settings.mu.Unlock()
+}
+
+// FuzzLenientLines feeds each line through the pipeline in strict and in
+// lenient mode, for both built-in formats. Strict mode must reject every
+// line lenient mode quarantines, a line strict mode accepts must never be
+// quarantined, and the quarantine must stay within its bound.
+func FuzzLenientLines(f *testing.F) {
+	for _, seed := range parserSeeds {
+		f.Add(seed)
+	}
+	dir, err := os.MkdirTemp("", "mediot-fuzz")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Cleanup(func() { os.RemoveAll(dir) })
+	portableDir = dir
+	f.Cleanup(func() { portableDir = "" })
+	a := NewApp()
+	f.Cleanup(func() { a.shutdown(context.Background()) })
+
+	formats := []string{ParserHex, ParserDecimal}
+	// quarantined feeds a line in a mode and reports whether it was quarantined
+	quarantined := func(format, mode, line string) bool {
+		setParserMode(a, mode)
+		a.bufferMutex.Lock()
+		defer a.bufferMutex.Unlock()
+
+		a.parser, a.lenient = lineParsers[format], fieldParsers[format]
+		a.quarantine.mu.Lock()
+		before := a.quarantine.quarantined
+		a.quarantine.mu.Unlock()
+		a.processLine(line)
+		a.parsedDataBuffer = a.parsedDataBuffer[:0]
+
+		a.quarantine.mu.Lock()
+		defer a.quarantine.mu.Unlock()
+		return a.quarantine.quarantined > before
+	}
+
+	f.Fuzz(func(t *testing.T, line string) {
+		for _, format := range formats {
+			lenient := quarantined(format, ParserModeLenient, line)
+			strict := quarantined(format, ParserModeStrict, line)
+			if lenient && !strict {
+				t.Fatalf("%s line %q quarantined in lenient mode but accepted in strict mode", format, line)
+			}
+			if _, err := lineParsers[format](line); err == nil && strict {
+				t.Fatalf("%s line %q decodes but was quarantined", format, line)
+			}
+		}
+		a.quarantine.mu.Lock()
+		kept := len(a.quarantine.lines)
+		a.quarantine.mu.Unlock()
+		if kept > maxQuarantinedLines {
+			t.Fatalf("%d quarantined lines kept, bound is %d", kept, maxQuarantinedLines)
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Peak event types
+const (
+	PeakTypePeak   = "peak"
+	PeakTypeTrough = "trough"
+)
+
+// maxRecentPeaks is how many events are kept per channel for GetRecentPeaks
+const maxRecentPeaks = 200
+
+// PeakDetectorConfig configures peak detection on a channel
+type PeakDetectorConfig struct {
+	Channel            string  `json:"channel"`
+	MinProminence      float64 `json:"minProminence"`      // How far the signal must fall back before a maximum counts as a peak
+	MinDistanceSeconds float64 `json:"minDistanceSeconds"` // Minimum time between two peaks (or two troughs)
+	Troughs            bool    `json:"troughs"`            // Also report minima
+}
+
+// PeakEvent is a detected local extremum of a channel
+type PeakEvent struct {
+	Channel    string    `json:"channel"`
+	Type       string    `json:"type"`
+	Timestamp  time.Time `json:"timestamp"` // Time of the extremum itself, not of its confirmation
+	Amplitude  float64   `json:"amplitude"`
+	Prominence float64   `json:"prominence"` // Height above the preceding trough (or depth below the preceding peak)
+}
+
+// peakTracker finds peaks and troughs online: a running maximum becomes a
+// peak once the signal drops by the minimum prominence below it, and the
+// search then switches to the next trough, and so on
+type peakTracker struct {
+	config     PeakDetectorConfig
+	rising     bool // Searching for a peak rather than a trough
+	started    bool
+	extreme    timedValue // Candidate extremum of the current search
+	lastPeak   *timedValue
+	lastTrough *timedValue
+}
+
+// newPeakTracker creates a tracker for a single channel
+func newPeakTracker(config PeakDetectorConfig) *peakTracker {
+	return &peakTracker{config: config, rising: true}
+}
+
+// push feeds a reading and returns the extremum it confirmed, if any
+func (t *peakTracker) push(at time.Time, v float64) *PeakEvent {
+	if !t.started {
+		t.started = true
+		t.extreme = timedValue{t: at, v: v}
+		return nil
+	}
+
+	if t.rising {
+		if v > t.extreme.v {
+			t.extreme = timedValue{t: at, v: v}
+			return nil
+		}
+		if t.extreme.v-v < t.config.MinProminence {
+			return nil
+		}
+		peak := t.extreme
+		t.rising = false
+		t.extreme = timedValue{t: at, v: v}
+		if t.lastPeak != nil && peak.t.Sub(t.lastPeak.t) < secondsToDuration(t.config.MinDistanceSeconds) {
+			return nil
+		}
+		t.lastPeak = &peak
+		return t.event(PeakTypePeak, peak, t.lastTrough)
+	}
+
+	if v < t.extreme.v {
+		t.extreme = timedValue{t: at, v: v}
+		return nil
+	}
+	if v-t.extreme.v < t.config.MinProminence {
+		return nil
+	}
+	trough := t.extreme
+	t.rising = true
+	t.extreme = timedValue{t: at, v: v}
+	if t.lastTrough != nil && trough.t.Sub(t.lastTrough.t) < secondsToDuration(t.config.MinDistanceSeconds) {
+		return nil
+	}
+	t.lastTrough = &trough
+	if !t.config.Troughs {
+		return nil
+	}
+	return t.event(PeakTypeTrough, trough, t.lastPeak)
+}
+
+// event builds the event of a confirmed extremum relative to the opposite one before it
+func (t *peakTracker) event(kind string, at timedValue, opposite *timedValue) *PeakEvent {
+	prominence := t.config.MinProminence
+	if opposite != nil {
+		prominence = at.v - opposite.v
+		if prominence < 0 {
+			prominence = -prominence
+		}
+	}
+	return &PeakEvent{
+		Channel:    t.config.Channel,
+		Type:       kind,
+		Timestamp:  at.t,
+		Amplitude:  at.v,
+		Prominence: prominence,
+	}
+}
+
+// peakDetector runs the user-configured peak trackers and keeps their recent events
+type peakDetector struct {
+	mu       sync.Mutex
+	trackers map[string]*peakTracker
+	recent   map[string][]PeakEvent
+	notify   func(PeakEvent) // Called for every detected extremum, outside the lock
+}
+
+// newPeakDetector creates the peak detection stage without configured channels
+func newPeakDetector(notify func(PeakEvent)) *peakDetector {
+	return &peakDetector{
+		trackers: make(map[string]*peakTracker),
+		recent:   make(map[string][]PeakEvent),
+		notify:   notify,
+	}
+}
+
+func (d *peakDetector) process(sample *SensorData) {
+	d.mu.Lock()
+	var events []PeakEvent
+	for channel, tracker := range d.trackers {
+		value, ok := sample.channelValue(channel)
+		if !ok {
+			continue
+		}
+		if event := tracker.push(sample.Timestamp, value); event != nil {
+			recent := append(d.recent[channel], *event)
+			if len(recent) > maxRecentPeaks {
+				recent = recent[len(recent)-maxRecentPeaks:]
+			}
+			d.recent[channel] = recent
+			events = append(events, *event)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, event := range events {
+		d.notify(event)
+	}
+}
+
+// onPeakEvent forwards a detected extremum to the frontend
+func (a *App) onPeakEvent(event PeakEvent) {
+	a.emit(EventPeak, event)
+}
+
+// SetPeakDetector starts or reconfigures peak detection on a channel
+func (a *App) SetPeakDetector(config PeakDetectorConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if config.Channel == "" {
+		return fmt.Errorf("channel is required")
+	}
+	if config.MinProminence <= 0 {
+		return fmt.Errorf("minimum prominence must be positive, got %.3f", config.MinProminence)
+	}
+	if config.MinDistanceSeconds < 0 {
+		return fmt.Errorf("minimum distance must not be negative")
+	}
+
+	a.peaks.mu.Lock()
+	defer a.peaks.mu.Unlock()
+
+	a.peaks.trackers[config.Channel] = newPeakTracker(config)
+	delete(a.peaks.recent, config.Channel)
+
+	processingLog.Infof("Peak detection set for channel %s (prominence %.3f, distance %.2f s)",
+		config.Channel, config.MinProminence, config.MinDistanceSeconds)
+	return nil
+}
+
+// RemovePeakDetector stops peak detection on a channel
+func (a *App) RemovePeakDetector(channel string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.peaks.mu.Lock()
+	defer a.peaks.mu.Unlock()
+
+	if _, ok := a.peaks.trackers[channel]; !ok {
+		return fmt.Errorf("no peak detector for channel '%s'", channel)
+	}
+	delete(a.peaks.trackers, channel)
+	delete(a.peaks.recent, channel)
+	return nil
+}
+
+// GetPeakDetectors returns the configured peak detectors sorted by channel
+func (a *App) GetPeakDetectors() []PeakDetectorConfig {
+	a.peaks.mu.Lock()
+	defer a.peaks.mu.Unlock()
+
+	result := make([]PeakDetectorConfig, 0, len(a.peaks.trackers))
+	for _, tracker := range a.peaks.trackers {
+		result = append(result, tracker.config)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Channel < result[j].Channel })
+	return result
+}
+
+// GetRecentPeaks returns up to limit of the latest extrema of a channel, oldest first
+func (a *App) GetRecentPeaks(channel string, limit int) ([]PeakEvent, error) {
+	a.peaks.mu.Lock()
+	defer a.peaks.mu.Unlock()
+
+	if _, ok := a.peaks.trackers[channel]; !ok {
+		return nil, fmt.Errorf("no peak detector for channel '%s'", channel)
+	}
+
+	recent := a.peaks.recent[channel]
+	if limit > 0 && len(recent) > limit {
+		recent = recent[len(recent)-limit:]
+	}
+	result := make([]PeakEvent, len(recent))
+	copy(result, recent)
+	return result, nil
+}
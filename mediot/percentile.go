@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// defaultPercentiles are tracked when a configuration doesn't list any
+var defaultPercentiles = []float64{5, 50, 95}
+
+// PercentileConfig configures rolling percentile tracking of a channel
+type PercentileConfig struct {
+	Channel       string    `json:"channel"`
+	WindowSeconds float64   `json:"windowSeconds"` // Length of the rolling window
+	Percentiles   []float64 `json:"percentiles"`   // Percentiles (0..100) to publish, defaults to 5, 50 and 95
+}
+
+// percentileChannel returns the name of the derived channel carrying a
+// percentile of a channel, e.g. "value3_p95"
+func percentileChannel(channel string, percentile float64) string {
+	return channel + "_p" + strconv.FormatFloat(percentile, 'f', -1, 64)
+}
+
+// percentileTracker keeps the sorted readings of one channel's window
+type percentileTracker struct {
+	config PercentileConfig
+	window *statsTracker
+}
+
+// percentileProcessor publishes rolling percentiles of the configured channels
+// as derived channels. It runs before the alarm engine so adaptive alarm
+// limits see the percentiles of the current sample.
+type percentileProcessor struct {
+	mu       sync.Mutex
+	trackers map[string]*percentileTracker
+}
+
+// newPercentileProcessor creates the percentile stage without configured channels
+func newPercentileProcessor() *percentileProcessor {
+	return &percentileProcessor{
+		trackers: make(map[string]*percentileTracker),
+	}
+}
+
+func (p *percentileProcessor) process(sample *SensorData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for channel, tracker := range p.trackers {
+		value, ok := sample.channelValue(channel)
+		if !ok || sample.isArtifact(channel) {
+			continue
+		}
+		tracker.window.add(sample.Timestamp, value)
+
+		// Wait for half a window so the tails aren't estimated from a handful of readings
+		if tracker.window.window.coverage() < secondsToDuration(tracker.config.WindowSeconds)/2 {
+			continue
+		}
+		for _, percentile := range tracker.config.Percentiles {
+			sample.setDerived(percentileChannel(channel, percentile), tracker.window.sorted.quantile(percentile/100))
+		}
+	}
+}
+
+// tracks reports whether a percentile of a channel is being published
+func (p *percentileProcessor) tracks(channel string, percentile float64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	tracker, ok := p.trackers[channel]
+	if !ok {
+		return false
+	}
+	for _, tracked := range tracker.config.Percentiles {
+		if tracked == percentile {
+			return true
+		}
+	}
+	return false
+}
+
+// adaptivePercentiles returns the percentiles of a channel its adaptive
+// alarm limits are derived from
+func (a *App) adaptivePercentiles(channel string) []float64 {
+	a.alarms.mu.Lock()
+	defer a.alarms.mu.Unlock()
+
+	state, ok := a.alarms.rules[channel]
+	if !ok || state.rule.Adaptive == nil {
+		return nil
+	}
+	var used []float64
+	for _, percentile := range []float64{state.rule.Adaptive.LowPercentile, state.rule.Adaptive.HighPercentile} {
+		if percentile != 0 {
+			used = append(used, percentile)
+		}
+	}
+	return used
+}
+
+// SetPercentileTracking starts or reconfigures rolling percentile tracking of
+// a channel. Each percentile is published as the "<channel>_p<percentile>"
+// derived channel once half a window of readings is available.
+func (a *App) SetPercentileTracking(config PercentileConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if config.Channel == "" {
+		return fmt.Errorf("channel is required")
+	}
+	span := secondsToDuration(config.WindowSeconds)
+	if span <= 0 || span > historyRetention {
+		return fmt.Errorf("window must be between 0 and %.0f s, got %.2f s",
+			historyRetention.Seconds(), config.WindowSeconds)
+	}
+	if len(config.Percentiles) == 0 {
+		config.Percentiles = append([]float64{}, defaultPercentiles...)
+	}
+	for _, percentile := range config.Percentiles {
+		if percentile < 0 || percentile > 100 {
+			return fmt.Errorf("percentile must be between 0 and 100, got %.2f", percentile)
+		}
+	}
+	sort.Float64s(config.Percentiles)
+	for _, used := range a.adaptivePercentiles(config.Channel) {
+		kept := false
+		for _, percentile := range config.Percentiles {
+			kept = kept || percentile == used
+		}
+		if !kept {
+			return fmt.Errorf("the alarm rule of '%s' has adaptive limits from percentile %.4g", config.Channel, used)
+		}
+	}
+
+	a.percentiles.mu.Lock()
+	defer a.percentiles.mu.Unlock()
+
+	a.percentiles.trackers[config.Channel] = &percentileTracker{
+		config: config,
+		window: newStatsTracker(span, a.history.recent(config.Channel, span)),
+	}
+
+	processingLog.Infof("Percentile tracking set for channel %s over %.0f s", config.Channel, config.WindowSeconds)
+	return nil
+}
+
+// RemovePercentileTracking stops the percentile tracking of a channel. It is
+// refused while adaptive alarm limits are derived from it, which would
+// otherwise go unchecked.
+func (a *App) RemovePercentileTracking(channel string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+	if used := a.adaptivePercentiles(channel); len(used) > 0 {
+		return fmt.Errorf("the alarm rule of '%s' has adaptive limits from percentile %.4g, remove them first", channel, used[0])
+	}
+
+	a.percentiles.mu.Lock()
+	defer a.percentiles.mu.Unlock()
+
+	if _, ok := a.percentiles.trackers[channel]; !ok {
+		return fmt.Errorf("no percentile tracking for channel '%s'", channel)
+	}
+	delete(a.percentiles.trackers, channel)
+	return nil
+}
+
+// GetPercentileTracking returns the configured percentile trackers sorted by channel
+func (a *App) GetPercentileTracking() []PercentileConfig {
+	a.percentiles.mu.Lock()
+	defer a.percentiles.mu.Unlock()
+
+	result := make([]PercentileConfig, 0, len(a.percentiles.trackers))
+	for _, tracker := range a.percentiles.trackers {
+		result = append(result, tracker.config)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Channel < result[j].Channel })
+	return result
+}
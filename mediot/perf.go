@@ -0,0 +1,201 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Performance metrics
+const (
+	perfWindow      = 10 * time.Second // Rates and latencies cover this much recent time
+	maxPerfPending  = 100000           // Samples tracked until the frontend reads them
+	perfMillisecond = float64(time.Millisecond)
+)
+
+// LatencyStats summarises the latencies of the samples of the window, in
+// milliseconds
+type LatencyStats struct {
+	Count  int     `json:"count"`
+	MeanMs float64 `json:"meanMs"`
+	P50Ms  float64 `json:"p50Ms"`
+	P95Ms  float64 `json:"p95Ms"`
+	P99Ms  float64 `json:"p99Ms"`
+	MaxMs  float64 `json:"maxMs"`
+}
+
+// PerformanceMetrics measures how fast data moves from the serial port to
+// the frontend
+type PerformanceMetrics struct {
+	WindowSeconds    float64      `json:"windowSeconds"` // Rates and latencies cover this recent time
+	BytesPerSecond   float64      `json:"bytesPerSecond"`
+	LinesPerSecond   float64      `json:"linesPerSecond"`
+	SamplesPerSecond float64      `json:"samplesPerSecond"` // Lines that parsed into samples, and simulated samples
+	TotalBytes       int64        `json:"totalBytes"`
+	TotalLines       int64        `json:"totalLines"`
+	TotalSamples     int64        `json:"totalSamples"`
+	Pipeline         LatencyStats `json:"pipeline"` // Byte arrival to the sample processed, its alarms and events emitted
+	Delivery         LatencyStats `json:"delivery"` // Sample processed to read by the frontend
+	EndToEnd         LatencyStats `json:"endToEnd"` // Byte arrival to read by the frontend
+	Pending          int          `json:"pending"`  // Samples processed but not read by the frontend yet
+	Since            time.Time    `json:"since"`    // Start of the totals
+}
+
+// perfSample is a processed sample waiting to be read by the frontend
+type perfSample struct {
+	arrived   time.Time
+	processed time.Time
+}
+
+// perfMetrics collects the throughput and latency of the data path
+type perfMetrics struct {
+	mu       sync.Mutex
+	bytes    *timedWindow // Bytes of each read
+	lines    *timedWindow // Lines of each read
+	samples  *timedWindow // 1 per sample
+	pipeline *timedWindow // Latencies in nanoseconds
+	delivery *timedWindow
+	endToEnd *timedWindow
+	pending  []perfSample // Oldest first, in the order of the sample buffer
+	totals   [3]int64     // Bytes, lines and samples
+	since    time.Time
+}
+
+// newPerfMetrics creates empty metrics
+func newPerfMetrics() *perfMetrics {
+	m := &perfMetrics{}
+	m.reset()
+	return m
+}
+
+// reset starts the metrics over
+func (m *perfMetrics) reset() {
+	m.bytes = newTimedWindow(perfWindow)
+	m.lines = newTimedWindow(perfWindow)
+	m.samples = newTimedWindow(perfWindow)
+	m.pipeline = newTimedWindow(perfWindow)
+	m.delivery = newTimedWindow(perfWindow)
+	m.endToEnd = newTimedWindow(perfWindow)
+	m.pending = nil
+	m.totals = [3]int64{}
+	m.since = time.Now()
+}
+
+// received records a read of the serial port and the lines it completed
+func (m *perfMetrics) received(at time.Time, bytes, lines int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.bytes.push(at, float64(bytes))
+	m.lines.push(at, float64(lines))
+	m.totals[0] += int64(bytes)
+	m.totals[1] += int64(lines)
+}
+
+// processed records a sample that went through the pipeline into the
+// buffer the frontend reads
+func (m *perfMetrics) processed(arrived, processed time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.samples.push(processed, 1)
+	m.pipeline.push(processed, float64(processed.Sub(arrived)))
+	m.totals[2]++
+	m.pending = append(m.pending, perfSample{arrived: arrived, processed: processed})
+	if len(m.pending) > maxPerfPending {
+		m.pending = m.pending[len(m.pending)-maxPerfPending:]
+	}
+}
+
+// delivered records that the frontend read every buffered sample
+func (m *perfMetrics) delivered(at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, sample := range m.pending {
+		m.delivery.push(at, float64(at.Sub(sample.processed)))
+		m.endToEnd.push(at, float64(at.Sub(sample.arrived)))
+	}
+	m.pending = m.pending[:0]
+}
+
+// windowSum adds up the values of a window
+func windowSum(w *timedWindow) float64 {
+	total := 0.0
+	for _, value := range w.values {
+		total += value.v
+	}
+	return total
+}
+
+// latencyStats summarises a window of latencies
+func latencyStats(w *timedWindow) LatencyStats {
+	n := len(w.values)
+	if n == 0 {
+		return LatencyStats{}
+	}
+	values := make([]float64, n)
+	for i, value := range w.values {
+		values[i] = value.v / perfMillisecond
+	}
+	sort.Float64s(values)
+	percentile := func(p float64) float64 {
+		return values[int(p*float64(n-1)+0.5)]
+	}
+	return LatencyStats{
+		Count:  n,
+		MeanMs: windowSum(w) / perfMillisecond / float64(n),
+		P50Ms:  percentile(0.50),
+		P95Ms:  percentile(0.95),
+		P99Ms:  percentile(0.99),
+		MaxMs:  values[n-1],
+	}
+}
+
+// snapshot returns the metrics of the recent window
+func (m *perfMetrics) snapshot(now time.Time) PerformanceMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, w := range []*timedWindow{m.bytes, m.lines, m.samples, m.pipeline, m.delivery, m.endToEnd} {
+		w.evict(now)
+	}
+	// Rates over the window, or over the time since the start while shorter
+	span := min(now.Sub(m.since), perfWindow).Seconds()
+	rate := func(w *timedWindow) float64 {
+		if span <= 0 {
+			return 0
+		}
+		return windowSum(w) / span
+	}
+	return PerformanceMetrics{
+		WindowSeconds:    perfWindow.Seconds(),
+		BytesPerSecond:   rate(m.bytes),
+		LinesPerSecond:   rate(m.lines),
+		SamplesPerSecond: rate(m.samples),
+		TotalBytes:       m.totals[0],
+		TotalLines:       m.totals[1],
+		TotalSamples:     m.totals[2],
+		Pipeline:         latencyStats(m.pipeline),
+		Delivery:         latencyStats(m.delivery),
+		EndToEnd:         latencyStats(m.endToEnd),
+		Pending:          len(m.pending),
+		Since:            m.since,
+	}
+}
+
+// GetPerformanceMetrics returns the throughput of the serial data and how
+// long samples take from the arrival of their bytes to the pipeline's end
+// and to the frontend, over the last seconds
+func (a *App) GetPerformanceMetrics() PerformanceMetrics {
+	return a.perf.snapshot(time.Now())
+}
+
+// ResetPerformanceMetrics starts the totals and windows over, e.g. before
+// a measurement at a new sample rate
+func (a *App) ResetPerformanceMetrics() {
+	a.perf.mu.Lock()
+	defer a.perf.mu.Unlock()
+
+	a.perf.reset()
+}
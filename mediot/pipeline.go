@@ -0,0 +1,44 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// processor is a stage of the sample processing pipeline. Stages run in
+// registration order on every parsed sample, so a stage can read the
+// derived channels attached by the stages before it.
+type processor interface {
+	process(sample *SensorData)
+}
+
+// sampleClock remembers when the last valid sample arrived, so monitors can
+// tell a dead stream from a quiet signal
+type sampleClock struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+// mark records the arrival of a sample
+func (c *sampleClock) mark(at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.last = at
+}
+
+// lastSample returns the arrival time of the last sample, zero if none arrived yet
+func (c *sampleClock) lastSample() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.last
+}
+
+// processSample runs a freshly parsed sample through the processing pipeline
+func (a *App) processSample(sample *SensorData) {
+	a.clock.mark(sample.Timestamp)
+	for _, p := range a.processors {
+		p.process(sample)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package main
+
+import "os/exec"
+
+// hidePluginWindow does nothing; plugins have no window outside Windows
+func hidePluginWindow(cmd *exec.Cmd) {}
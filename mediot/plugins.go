@@ -0,0 +1,825 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Plugins are executables in their own folder under plugins/ in the data
+// directory, described by a plugin.json manifest. The app runs each enabled
+// plugin as a child process and talks to it with JSON messages, one per
+// line, on its stdin and stdout; stderr goes to the log. Protocol version 1:
+//
+//	app → plugin  {"type":"init","protocol":1,"parser":"acme","sink":true}
+//	plugin → app  {"type":"ready"}                         within 5 s
+//	app → plugin  {"type":"parse","id":7,"line":"..."}     parser plugins
+//	plugin → app  {"type":"parsed","id":7,"sample":{...}}  or {"type":"parsed","id":7,"error":"..."}
+//	app → plugin  {"type":"sample","sample":{...}}         sink plugins, no reply
+//	plugin → app  {"type":"log","level":"info","message":"..."}
+//	app → plugin  {"type":"shutdown"}                      then stdin is closed
+//
+// Samples have the JSON form of SensorData. A parsed sample without a
+// timestamp is stamped on arrival. Unknown message types are ignored, so
+// both sides can add messages without a new protocol version.
+
+// pluginProtocol is the version of the plugin protocol spoken by this build
+const pluginProtocol = 1
+
+// Plugin files
+const (
+	pluginsDirName   = "plugins"
+	pluginManifest   = "plugin.json"
+	pluginsFile      = "plugins.json" // Names of the enabled plugins
+	pluginOutboxSize = 1024           // Messages waiting to be written to a plugin; sink samples beyond it are dropped
+	pluginLineLimit  = 1 << 20        // Longest message a plugin may send
+	pluginLineQueue  = 1024           // Lines waiting for a plugin parser; lines beyond it are dropped
+)
+
+// Plugin timing
+const (
+	pluginStartTimeout    = 5 * time.Second
+	pluginParseTimeout    = 500 * time.Millisecond
+	pluginStopTimeout     = 2 * time.Second
+	pluginRestartDelay    = time.Second // Doubled after every crash, up to pluginMaxRestartDelay
+	pluginMaxRestartDelay = time.Minute
+	pluginStableAfter     = time.Minute // A plugin running this long restarts quickly again
+)
+
+// Plugin states
+const (
+	PluginStopped  = "stopped"
+	PluginStarting = "starting"
+	PluginRunning  = "running"
+	PluginCrashed  = "crashed" // Exited or failed to start; restarted after a delay
+	PluginInvalid  = "invalid" // The manifest cannot be used
+)
+
+// pluginNamePattern restricts plugin names to what is safe in file names and logs
+var pluginNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// PluginManifest is the plugin.json file describing a plugin
+type PluginManifest struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Description string   `json:"description"`
+	Command     string   `json:"command"` // Executable, relative to the plugin folder
+	Args        []string `json:"args"`
+	Parser      string   `json:"parser,omitempty"` // Line format the plugin parses, offered next to the built-in ones
+	Sink        bool     `json:"sink,omitempty"`   // The plugin receives every processed sample
+}
+
+// PluginInfo describes a discovered plugin
+type PluginInfo struct {
+	Manifest PluginManifest `json:"manifest"`
+	Dir      string         `json:"dir"`
+	Enabled  bool           `json:"enabled"`
+	State    string         `json:"state"`
+	Error    string         `json:"error,omitempty"` // Why the plugin is invalid or last crashed
+	Restarts int            `json:"restarts"`
+	Dropped  int64          `json:"dropped"` // Samples not delivered because the plugin was too slow
+}
+
+// pluginMessage is one line of the plugin protocol
+type pluginMessage struct {
+	Type     string      `json:"type"`
+	Protocol int         `json:"protocol,omitempty"`
+	ID       int64       `json:"id,omitempty"`
+	Line     string      `json:"line,omitempty"`
+	Sample   *SensorData `json:"sample,omitempty"`
+	Parser   string      `json:"parser,omitempty"`
+	Sink     bool        `json:"sink,omitempty"`
+	Level    string      `json:"level,omitempty"`
+	Message  string      `json:"message,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// plugin is a discovered plugin and its supervisor
+type plugin struct {
+	manifest PluginManifest
+	dir      string
+	invalid  string // Why the manifest cannot be used, empty if it can
+	state    string
+	err      string
+	restarts int
+	proc     *pluginProcess // Set while running
+	stop     chan struct{}  // Closed to stop the supervisor; nil while stopped
+	done     chan struct{}  // Closed when the supervisor has stopped the process
+}
+
+// pluginProcess is a running plugin
+type pluginProcess struct {
+	name    string
+	cmd     *exec.Cmd
+	outbox  chan []byte // Messages for stdin; closed to stop the process
+	ready   chan error
+	exited  chan struct{} // Closed once the process has exited
+	waitErr error         // Exit status, set before exited is closed
+	dropped int64         // Sink samples dropped, accessed atomically
+
+	mu      sync.Mutex
+	closed  bool // The outbox is closed
+	nextID  int64
+	pending map[int64]chan pluginMessage // Parse requests by ID
+}
+
+// pluginManager discovers the plugins and keeps the enabled ones running.
+// It is the pipeline stage feeding the sink plugins.
+type pluginManager struct {
+	mu       sync.Mutex
+	plugins  map[string]*plugin
+	enabled  map[string]bool
+	onChange func(PluginInfo)
+}
+
+// newPluginManager discovers the plugins; the enabled ones are started by startAll
+func newPluginManager(onChange func(PluginInfo)) *pluginManager {
+	m := &pluginManager{plugins: make(map[string]*plugin), enabled: make(map[string]bool), onChange: onChange}
+	var enabled []string
+	if err := loadJSONFile(pluginsFile, &enabled); err != nil {
+		pluginLog.Errorf("Error loading plugin settings: %v", err)
+	}
+	for _, name := range enabled {
+		m.enabled[name] = true
+	}
+	m.discover()
+	return m
+}
+
+// pluginsDir returns the folder holding the plugins
+func pluginsDir() (string, error) {
+	dir, err := appDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, pluginsDirName), nil
+}
+
+// readPluginManifest loads and checks the manifest of a plugin folder
+func readPluginManifest(dir string) (PluginManifest, error) {
+	var manifest PluginManifest
+	data, err := os.ReadFile(filepath.Join(dir, pluginManifest))
+	if err != nil {
+		return manifest, err
+	}
+	err = json.Unmarshal(data, &manifest)
+	if manifest.Name == "" {
+		manifest.Name = filepath.Base(dir)
+	}
+	if err != nil {
+		return manifest, fmt.Errorf("failed to parse %s: %v", pluginManifest, err)
+	}
+	switch {
+	case !pluginNamePattern.MatchString(manifest.Name):
+		return manifest, fmt.Errorf("invalid plugin name '%s'", manifest.Name)
+	case manifest.Command == "":
+		return manifest, fmt.Errorf("no command")
+	case manifest.Parser == "" && !manifest.Sink:
+		return manifest, fmt.Errorf("the plugin provides neither a parser nor a sink")
+	}
+	if _, ok := lineParsers[manifest.Parser]; ok {
+		return manifest, fmt.Errorf("parser '%s' is built in", manifest.Parser)
+	}
+	return manifest, nil
+}
+
+// discover reads the manifests of the plugins folder, replacing the known
+// plugins. The caller makes sure none is running.
+func (m *pluginManager) discover() {
+	plugins := make(map[string]*plugin)
+	dir, err := pluginsDir()
+	if err != nil {
+		pluginLog.Errorf("Error finding plugins: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		pluginLog.Errorf("Error reading plugins folder: %v", err)
+	}
+
+	parsers := make(map[string]string)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		manifest, err := readPluginManifest(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		p := &plugin{manifest: manifest, dir: path, state: PluginStopped}
+		if _, ok := plugins[manifest.Name]; ok && err == nil {
+			err = fmt.Errorf("another plugin is named '%s'", manifest.Name)
+		}
+		if other, ok := parsers[manifest.Parser]; ok && manifest.Parser != "" && err == nil {
+			err = fmt.Errorf("plugin %s already provides parser '%s'", other, manifest.Parser)
+		}
+		if err != nil {
+			p.invalid = err.Error()
+			p.state = PluginInvalid
+			pluginLog.Warnf("Plugin in %s cannot be used: %v", path, err)
+			if _, ok := plugins[manifest.Name]; ok {
+				continue
+			}
+		} else if manifest.Parser != "" {
+			parsers[manifest.Parser] = manifest.Name
+		}
+		plugins[manifest.Name] = p
+	}
+
+	m.mu.Lock()
+	m.plugins = plugins
+	m.mu.Unlock()
+	pluginLog.Infof("Found %d plugins in %s", len(plugins), dir)
+}
+
+// info describes a plugin; the caller holds the lock
+func (m *pluginManager) info(p *plugin) PluginInfo {
+	info := PluginInfo{
+		Manifest: p.manifest,
+		Dir:      p.dir,
+		Enabled:  m.enabled[p.manifest.Name],
+		State:    p.state,
+		Error:    p.err,
+		Restarts: p.restarts,
+	}
+	info.Manifest.Args = append([]string{}, p.manifest.Args...)
+	if p.invalid != "" {
+		info.Error = p.invalid
+	}
+	if p.proc != nil {
+		info.Dropped = atomic.LoadInt64(&p.proc.dropped)
+	}
+	return info
+}
+
+// setState records a state change of a plugin and reports it
+func (m *pluginManager) setState(p *plugin, state string, proc *pluginProcess, err error) {
+	m.mu.Lock()
+	p.state = state
+	p.proc = proc
+	if err != nil {
+		p.err = err.Error()
+	} else if state == PluginRunning {
+		p.err = ""
+	}
+	if state == PluginCrashed {
+		p.restarts++
+	}
+	info := m.info(p)
+	m.mu.Unlock()
+
+	m.onChange(info)
+}
+
+// start runs the supervisor of a valid plugin; the caller holds the lock
+func (m *pluginManager) start(p *plugin) {
+	if p.invalid != "" || p.stop != nil {
+		return
+	}
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	stop, done := p.stop, p.done
+	goSafe("plugin "+p.manifest.Name, func() {
+		defer close(done)
+		m.supervise(p, stop)
+	})
+}
+
+// stop ends the supervisor of a plugin and waits until its process has
+// exited; the caller must not hold the lock
+func (m *pluginManager) stop(p *plugin) {
+	m.mu.Lock()
+	stop, done := p.stop, p.done
+	p.stop, p.done = nil, nil
+	m.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// startAll starts every enabled plugin
+func (m *pluginManager) startAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, p := range m.plugins {
+		if m.enabled[name] {
+			m.start(p)
+		}
+	}
+}
+
+// stopAll stops every plugin, for shutdown and rediscovery
+func (m *pluginManager) stopAll() {
+	m.mu.Lock()
+	plugins := make([]*plugin, 0, len(m.plugins))
+	for _, p := range m.plugins {
+		plugins = append(plugins, p)
+	}
+	m.mu.Unlock()
+
+	for _, p := range plugins {
+		m.stop(p)
+	}
+}
+
+// supervise keeps a plugin running until stop is closed, restarting it
+// with a growing delay when it crashes
+func (m *pluginManager) supervise(p *plugin, stop chan struct{}) {
+	delay := pluginRestartDelay
+	for {
+		m.setState(p, PluginStarting, nil, nil)
+		proc, err := startPluginProcess(p.manifest, p.dir)
+		if err == nil {
+			pluginLog.Infof("Plugin %s %s started", p.manifest.Name, p.manifest.Version)
+			m.setState(p, PluginRunning, proc, nil)
+			started := time.Now()
+			select {
+			case <-stop:
+				// No more samples are queued once the state has changed
+				m.setState(p, PluginStopped, nil, nil)
+				proc.shutdown()
+				pluginLog.Infof("Plugin %s stopped", p.manifest.Name)
+				return
+			case <-proc.exited:
+				err = fmt.Errorf("exited: %v", proc.waitErr)
+				proc.shutdown()
+			}
+			if time.Since(started) >= pluginStableAfter {
+				delay = pluginRestartDelay
+			}
+		}
+
+		pluginLog.Errorf("Plugin %s failed, restarting in %v: %v", p.manifest.Name, delay, err)
+		if p.manifest.Sink {
+			backendErrors.report(ErrorSink, fmt.Sprintf("Plugin %s failed", p.manifest.Name), err)
+		}
+		m.setState(p, PluginCrashed, nil, err)
+		select {
+		case <-stop:
+			m.setState(p, PluginStopped, nil, nil)
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > pluginMaxRestartDelay {
+			delay = pluginMaxRestartDelay
+		}
+	}
+}
+
+// startPluginProcess runs a plugin and waits for its ready message
+func startPluginProcess(manifest PluginManifest, dir string) (*pluginProcess, error) {
+	command := manifest.Command
+	if !filepath.IsAbs(command) {
+		command = filepath.Join(dir, command)
+	}
+	cmd := exec.Command(command, manifest.Args...)
+	cmd.Dir = dir
+	hidePluginWindow(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	proc := &pluginProcess{
+		name:    manifest.Name,
+		cmd:     cmd,
+		outbox:  make(chan []byte, pluginOutboxSize),
+		ready:   make(chan error, 1),
+		exited:  make(chan struct{}),
+		pending: make(map[int64]chan pluginMessage),
+	}
+	logged := make(chan struct{})
+	goSafe("plugin stderr", func() {
+		defer close(logged)
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			pluginLog.Infof("[%s] %s", manifest.Name, scanner.Text())
+		}
+	})
+	read := make(chan struct{})
+	goSafe("plugin reader", func() {
+		defer close(read)
+		proc.read(stdout)
+	})
+	goSafe("plugin writer", func() { proc.write(stdin) })
+	goSafe("plugin wait", func() {
+		<-read
+		<-logged
+		proc.waitErr = cmd.Wait()
+		close(proc.exited)
+	})
+
+	proc.send(pluginMessage{Type: "init", Protocol: pluginProtocol, Parser: manifest.Parser, Sink: manifest.Sink})
+	select {
+	case err = <-proc.ready:
+	case <-proc.exited:
+		err = fmt.Errorf("exited before it was ready: %v", proc.waitErr)
+	case <-time.After(pluginStartTimeout):
+		err = fmt.Errorf("not ready within %v", pluginStartTimeout)
+	}
+	if err != nil {
+		proc.shutdown()
+		return nil, err
+	}
+	return proc, nil
+}
+
+// read dispatches the messages of the plugin until its stdout closes
+func (p *pluginProcess) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64<<10), pluginLineLimit)
+	for scanner.Scan() {
+		var message pluginMessage
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			pluginLog.Warnf("[%s] Malformed message: %v", p.name, err)
+			continue
+		}
+		switch message.Type {
+		case "ready":
+			select {
+			case p.ready <- nil:
+			default:
+			}
+		case "error":
+			select {
+			case p.ready <- errors.New(message.Error):
+			default:
+				pluginLog.Errorf("[%s] %s", p.name, message.Error)
+			}
+		case "parsed":
+			p.mu.Lock()
+			reply, ok := p.pending[message.ID]
+			delete(p.pending, message.ID)
+			p.mu.Unlock()
+			if ok {
+				reply <- message
+			}
+		case "log":
+			switch message.Level {
+			case "debug":
+				pluginLog.Debugf("[%s] %s", p.name, message.Message)
+			case "warn", "warning":
+				pluginLog.Warnf("[%s] %s", p.name, message.Message)
+			case "error":
+				pluginLog.Errorf("[%s] %s", p.name, message.Message)
+			default:
+				pluginLog.Infof("[%s] %s", p.name, message.Message)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		pluginLog.Errorf("[%s] Error reading output: %v", p.name, err)
+		// Stop the plugin rather than leave it blocked on a full pipe
+		p.cmd.Process.Kill()
+	}
+}
+
+// write sends the queued messages to the plugin; closing the outbox closes stdin
+func (p *pluginProcess) write(stdin io.WriteCloser) {
+	defer stdin.Close()
+	for data := range p.outbox {
+		if _, err := stdin.Write(data); err != nil {
+			// The plugin exited; drain until the outbox is closed
+			for range p.outbox {
+			}
+			return
+		}
+	}
+}
+
+// send queues a message, reporting false if the outbox is full or closed
+func (p *pluginProcess) send(message pluginMessage) bool {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return false
+	}
+	return p.queue(append(data, '\n'))
+}
+
+// queue adds an encoded message to the outbox without waiting
+func (p *pluginProcess) queue(data []byte) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return false
+	}
+	select {
+	case p.outbox <- data:
+		return true
+	default:
+		return false
+	}
+}
+
+// parse asks the plugin to parse a line and waits for the answer
+func (p *pluginProcess) parse(line string) (*SensorData, error) {
+	reply := make(chan pluginMessage, 1)
+	p.mu.Lock()
+	p.nextID++
+	id := p.nextID
+	p.pending[id] = reply
+	p.mu.Unlock()
+	forget := func() {
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+	}
+
+	if !p.send(pluginMessage{Type: "parse", ID: id, Line: line}) {
+		forget()
+		return nil, fmt.Errorf("plugin %s is busy", p.name)
+	}
+	select {
+	case message := <-reply:
+		if message.Error != "" {
+			return nil, errors.New(message.Error)
+		}
+		if message.Sample == nil {
+			return nil, fmt.Errorf("plugin %s returned no sample", p.name)
+		}
+		if message.Sample.Timestamp.IsZero() {
+			message.Sample.Timestamp = time.Now()
+		}
+		return message.Sample, nil
+	case <-p.exited:
+		forget()
+		return nil, fmt.Errorf("plugin %s exited", p.name)
+	case <-time.After(pluginParseTimeout):
+		forget()
+		return nil, fmt.Errorf("plugin %s did not answer within %v", p.name, pluginParseTimeout)
+	}
+}
+
+// shutdown asks the plugin to exit, killing it if it does not
+func (p *pluginProcess) shutdown() {
+	p.send(pluginMessage{Type: "shutdown"})
+	p.mu.Lock()
+	p.closed = true
+	close(p.outbox)
+	p.mu.Unlock()
+	select {
+	case <-p.exited:
+		return
+	case <-time.After(pluginStopTimeout):
+	}
+	pluginLog.Warnf("Plugin %s did not exit within %v, killing it", p.name, pluginStopTimeout)
+	p.cmd.Process.Kill()
+	<-p.exited
+}
+
+// running returns the process of a running plugin; the caller holds the lock
+func (m *pluginManager) running(name string) *pluginProcess {
+	p, ok := m.plugins[name]
+	if !ok || p.state != PluginRunning {
+		return nil
+	}
+	return p.proc
+}
+
+// parser returns the line parser of an enabled plugin providing the format
+func (m *pluginManager) parser(format string) (lineParser, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, p := range m.plugins {
+		if p.invalid != "" || p.manifest.Parser != format || !m.enabled[name] {
+			continue
+		}
+		return func(line string) (*SensorData, error) {
+			m.mu.Lock()
+			proc := m.running(name)
+			m.mu.Unlock()
+			if proc == nil {
+				return nil, fmt.Errorf("plugin %s is not running", name)
+			}
+			return proc.parse(line)
+		}, true
+	}
+	return nil, false
+}
+
+// pluginLine is a line of the stream waiting for a plugin parser
+type pluginLine struct {
+	raw     string
+	arrived time.Time
+}
+
+// queuePluginLine hands a line to the plugin parse loop. A plugin may take
+// up to pluginParseTimeout per line, which must hold up neither the serial
+// reader nor ReadSensorData, so lines beyond the queue are dropped. The
+// caller holds bufferMutex.
+func (a *App) queuePluginLine(raw string) {
+	select {
+	case a.pluginLines <- pluginLine{raw: raw, arrived: time.Now()}:
+	default:
+		a.quality.recordParseError()
+		backendErrors.report(ErrorParse, "The plugin parser fell behind; lines were dropped", nil)
+	}
+}
+
+// dropPluginLines forgets the queued lines, when the parser changes; the
+// caller holds bufferMutex
+func (a *App) dropPluginLines() {
+	for {
+		select {
+		case <-a.pluginLines:
+		default:
+			return
+		}
+	}
+}
+
+// pluginParseLoop parses the queued lines with the plugin parser outside
+// bufferMutex, which it only takes to run the samples through the pipeline
+func (a *App) pluginParseLoop() {
+	for {
+		var next pluginLine
+		select {
+		case <-a.quit:
+			return
+		case next = <-a.pluginLines:
+		}
+
+		a.bufferMutex.RLock()
+		parser, offParse := a.parser, a.offParse
+		a.bufferMutex.RUnlock()
+		if !offParse {
+			continue // Queued before a built-in parser took over
+		}
+		line := strings.TrimSpace(next.raw)
+		var sample *SensorData
+		var err error
+		if safely("plugin parser", func() { sample, err = parser(line) }) {
+			err = fmt.Errorf("parser panicked")
+		}
+
+		a.bufferMutex.Lock()
+		buffered := len(a.parsedDataBuffer)
+		if safely("line processing", func() { a.acceptLine(next.raw, line, sample, err) }) {
+			a.quality.recordParseError()
+			backendErrors.report(ErrorParse, "Processing a line from the device crashed", nil)
+		}
+		if len(a.parsedDataBuffer) > buffered {
+			a.perf.processed(next.arrived, time.Now())
+		}
+		a.bufferMutex.Unlock()
+	}
+}
+
+// parserNames returns the formats of the enabled parser plugins
+func (m *pluginManager) parserNames() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0)
+	for name, p := range m.plugins {
+		if p.invalid == "" && p.manifest.Parser != "" && m.enabled[name] {
+			names = append(names, p.manifest.Parser)
+		}
+	}
+	return names
+}
+
+func (m *pluginManager) process(sample *SensorData) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var data []byte
+	for _, p := range m.plugins {
+		if !p.manifest.Sink || p.state != PluginRunning {
+			continue
+		}
+		if data == nil {
+			var err error
+			if data, err = json.Marshal(pluginMessage{Type: "sample", Sample: sample}); err != nil {
+				return
+			}
+			data = append(data, '\n')
+		}
+		if !p.proc.queue(data) {
+			atomic.AddInt64(&p.proc.dropped, 1)
+		}
+	}
+}
+
+// findParser returns a built-in line parser or one of an enabled plugin
+func (a *App) findParser(format string) (lineParser, bool) {
+	if parser, ok := lineParsers[format]; ok {
+		return parser, true
+	}
+	return a.plugins.parser(format)
+}
+
+// onPluginChange pushes a plugin state change to the frontend
+func (a *App) onPluginChange(info PluginInfo) {
+	a.emit(EventPlugin, info)
+}
+
+// GetPlugins returns the plugins found in the plugins folder
+func (a *App) GetPlugins() []PluginInfo {
+	a.plugins.mu.Lock()
+	defer a.plugins.mu.Unlock()
+
+	result := make([]PluginInfo, 0, len(a.plugins.plugins))
+	for _, p := range a.plugins.plugins {
+		result = append(result, a.plugins.info(p))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Manifest.Name < result[j].Manifest.Name })
+	return result
+}
+
+// GetPluginDirectory returns the folder plugins are installed in, one
+// subfolder with a plugin.json manifest each
+func (a *App) GetPluginDirectory() (string, error) {
+	return pluginsDir()
+}
+
+// SetPluginEnabled starts or stops a plugin and remembers the choice.
+// Plugins run with the rights of the app, so only an admin may enable them.
+func (a *App) SetPluginEnabled(name string, enabled bool) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.plugins.mu.Lock()
+	p, ok := a.plugins.plugins[name]
+	if !ok {
+		a.plugins.mu.Unlock()
+		return fmt.Errorf("unknown plugin '%s'", name)
+	}
+	if enabled && p.invalid != "" {
+		a.plugins.mu.Unlock()
+		return fmt.Errorf("plugin %s cannot be used: %s", name, p.invalid)
+	}
+	if enabled {
+		a.plugins.enabled[name] = true
+	} else {
+		delete(a.plugins.enabled, name)
+	}
+	names := make([]string, 0, len(a.plugins.enabled))
+	for name := range a.plugins.enabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	err := saveJSONFile(pluginsFile, names)
+	if enabled {
+		a.plugins.start(p)
+	}
+	a.plugins.mu.Unlock()
+
+	if !enabled {
+		a.plugins.stop(p)
+	}
+	if err != nil {
+		return err
+	}
+
+	action := "disabled"
+	if enabled {
+		action = "enabled"
+		telemetry.count(FeaturePlugins)
+	}
+	pluginLog.Infof("Plugin %s %s", name, action)
+	a.audit.record("", AuditPlugin, fmt.Sprintf("Plugin %s %s %s", name, p.manifest.Version, action))
+	return nil
+}
+
+// ReloadPlugins stops the plugins, reads the plugins folder again and
+// starts the enabled ones, picking up installed, removed and updated plugins
+func (a *App) ReloadPlugins() ([]PluginInfo, error) {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	a.plugins.stopAll()
+	a.plugins.discover()
+	a.plugins.startAll()
+	return a.GetPlugins(), nil
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// sensorReadTimeout bounds each read of the sensor reader so it can give up the port quickly
+const sensorReadTimeout = 10 * time.Millisecond
+
+// openSerialPort opens the sensor port; the self-test replaces it to reach
+// its in-process loopback
+var openSerialPort = serial.Open
+
+// errPortTaken is returned to the sensor reader while another subsystem owns the port
+var errPortTaken = errors.New("serial port taken over")
+
+// errPortReplaced is returned to the sensor reader when the port it was given
+// was closed or replaced before its read, e.g. by a disconnect
+var errPortReplaced = errors.New("serial port closed")
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Built-in patient categories
+const (
+	PresetAdult     = "adult"
+	PresetPediatric = "pediatric"
+	PresetNeonatal  = "neonatal"
+)
+
+// AlarmPreset is a bundle of alarm rules for a patient category
+type AlarmPreset struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Rules       []AlarmRule `json:"rules"`
+}
+
+// limit returns a pointer to an alarm limit value
+func limit(v float64) *float64 {
+	return &v
+}
+
+// vitalSignRules builds the heart rate, SpO2 and respiratory rate rules of a preset
+func vitalSignRules(hr, hrCritical, rr, rrCritical [2]float64, spo2Low, spo2CriticalLow float64) []AlarmRule {
+	return []AlarmRule{
+		{
+			Channel:            ChannelHeartRate,
+			Unit:               "bpm",
+			WarningLow:         limit(hr[0]),
+			WarningHigh:        limit(hr[1]),
+			CriticalLow:        limit(hrCritical[0]),
+			CriticalHigh:       limit(hrCritical[1]),
+			Hysteresis:         2,
+			MinDurationSeconds: 5,
+		},
+		{
+			Channel:            ChannelSpO2,
+			Unit:               "%",
+			WarningLow:         limit(spo2Low),
+			CriticalLow:        limit(spo2CriticalLow),
+			Hysteresis:         1,
+			MinDurationSeconds: 10,
+		},
+		{
+			Channel:            ChannelRespiratoryRate,
+			Unit:               "breaths/min",
+			WarningLow:         limit(rr[0]),
+			WarningHigh:        limit(rr[1]),
+			CriticalLow:        limit(rrCritical[0]),
+			CriticalHigh:       limit(rrCritical[1]),
+			Hysteresis:         1,
+			MinDurationSeconds: 10,
+		},
+	}
+}
+
+// builtinAlarmPresets are the default limit bundles of the patient categories
+var builtinAlarmPresets = []AlarmPreset{
+	{
+		Name:        PresetAdult,
+		Description: "Adult patients",
+		Rules:       vitalSignRules([2]float64{50, 120}, [2]float64{40, 150}, [2]float64{8, 25}, [2]float64{5, 30}, 92, 88),
+	},
+	{
+		Name:        PresetPediatric,
+		Description: "Children from 1 to 12 years",
+		Rules:       vitalSignRules([2]float64{70, 140}, [2]float64{60, 170}, [2]float64{15, 35}, [2]float64{10, 45}, 92, 88),
+	},
+	{
+		Name:        PresetNeonatal,
+		Description: "Newborns up to 28 days",
+		Rules:       vitalSignRules([2]float64{100, 180}, [2]float64{80, 200}, [2]float64{30, 60}, [2]float64{20, 70}, 90, 85),
+	},
+}
+
+// alarmPresets tracks which preset is applied to the current session
+type alarmPresets struct {
+	mu       sync.Mutex
+	active   string
+	channels []string // Channels whose rules were installed by the active preset
+}
+
+// newAlarmPresets creates the preset tracker with no preset applied
+func newAlarmPresets() *alarmPresets {
+	return &alarmPresets{}
+}
+
+// findAlarmPreset returns the built-in preset with the given name
+func findAlarmPreset(name string) (AlarmPreset, bool) {
+	for _, preset := range builtinAlarmPresets {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+	return AlarmPreset{}, false
+}
+
+// GetAlarmPresets returns the available alarm presets
+func (a *App) GetAlarmPresets() []AlarmPreset {
+	result := make([]AlarmPreset, len(builtinAlarmPresets))
+	for i, preset := range builtinAlarmPresets {
+		result[i] = preset
+		result[i].Rules = append([]AlarmRule{}, preset.Rules...)
+	}
+	return result
+}
+
+// GetActiveAlarmPreset returns the name of the preset applied to the session, empty if none
+func (a *App) GetActiveAlarmPreset() string {
+	a.presets.mu.Lock()
+	defer a.presets.mu.Unlock()
+
+	return a.presets.active
+}
+
+// ApplyAlarmPreset installs the alarm rules of a patient category for the
+// current session. Rules installed by a previously applied preset that the
+// new one doesn't cover are removed; other rules are left alone.
+func (a *App) ApplyAlarmPreset(name string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	preset, ok := findAlarmPreset(name)
+	if !ok {
+		return fmt.Errorf("unknown alarm preset '%s'", name)
+	}
+
+	a.presets.mu.Lock()
+	defer a.presets.mu.Unlock()
+
+	channels := make([]string, 0, len(preset.Rules))
+	for _, rule := range preset.Rules {
+		if err := a.setAlarmRule(rule); err != nil {
+			return fmt.Errorf("preset rule for %s: %v", rule.Channel, err)
+		}
+		channels = append(channels, rule.Channel)
+	}
+	sort.Strings(channels)
+
+	for _, channel := range a.presets.channels {
+		if i := sort.SearchStrings(channels, channel); i == len(channels) || channels[i] != channel {
+			a.removeAlarmRule(channel)
+		}
+	}
+	a.presets.active = name
+	a.presets.channels = channels
+
+	// The preset changed the limits of any loaded profile
+	a.limits.mu.Lock()
+	a.limits.loaded = nil
+	a.limits.mu.Unlock()
+
+	alarmsLog.Infof("Alarm preset %s applied", name)
+	a.audit.record("", AuditAlarmLimits, fmt.Sprintf("Alarm preset %s applied", name))
+	return nil
+}
+
+// ClearAlarmPreset removes the rules installed by the applied preset
+func (a *App) ClearAlarmPreset() error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.presets.mu.Lock()
+	defer a.presets.mu.Unlock()
+
+	for _, channel := range a.presets.channels {
+		a.removeAlarmRule(channel)
+	}
+	a.presets.active = ""
+	a.presets.channels = nil
+	return nil
+}
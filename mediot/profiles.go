@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// Profile storage
+const (
+	profileFile     = "profile.json" // Name of the current profile, shared by all profiles
+	profilesDirName = "profiles"
+	defaultProfile  = "default" // Keeps its files in the data directory itself, as before profiles existed
+)
+
+// profileFiles are the configuration files each profile has its own copy
+// of; everything else, such as accounts, sessions and the audit log, is
+// shared by the profiles
+var profileFiles = map[string]bool{
+	settingsFile:      true,
+	devicesFile:       true,
+	alarmProfilesFile: true,
+}
+
+// profileNamePattern restricts profile names to what is safe as a folder name
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 ._-]{0,63}$`)
+
+// ProfileInfo describes a configuration profile
+type ProfileInfo struct {
+	Name    string `json:"name"`
+	Dir     string `json:"dir"`
+	Current bool   `json:"current"`
+}
+
+// profileState holds the current profile, shared by the storage helpers,
+// which are not tied to the App
+type profileState struct {
+	mu      sync.Mutex
+	profile string
+}
+
+// profiles holds the current profile
+var profiles = &profileState{profile: defaultProfile}
+
+// load reads the current profile. It runs before any profile file is loaded.
+func (p *profileState) load() {
+	var stored struct {
+		Current string `json:"current"`
+	}
+	if err := loadJSONFile(profileFile, &stored); err != nil {
+		appLog.Errorf("Error loading profile: %v", err)
+	}
+	if stored.Current == "" {
+		return
+	}
+	if !profileExists(stored.Current) {
+		appLog.Warnf("Profile %s not found, using %s", stored.Current, defaultProfile)
+		return
+	}
+
+	p.mu.Lock()
+	p.profile = stored.Current
+	p.mu.Unlock()
+	appLog.Infof("Using profile %s", stored.Current)
+}
+
+// current returns the name of the current profile
+func (p *profileState) current() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.profile
+}
+
+// set makes a profile current and remembers it for the next start
+func (p *profileState) set(name string) error {
+	err := saveJSONFile(profileFile, struct {
+		Current string `json:"current"`
+	}{name})
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.profile = name
+	p.mu.Unlock()
+	return nil
+}
+
+// profilePath returns the folder of a profile without creating it, empty
+// if the data directory is unavailable
+func profilePath(name string) string {
+	dir, err := appDataDir()
+	if err != nil {
+		return ""
+	}
+	if name == defaultProfile {
+		return dir
+	}
+	return filepath.Join(dir, profilesDirName, name)
+}
+
+// profileDir returns the folder of a profile, creating it if needed
+func profileDir(name string) (string, error) {
+	dir, err := appDataDir()
+	if err != nil {
+		return "", err
+	}
+	if name == defaultProfile {
+		return dir, nil
+	}
+	dir = filepath.Join(dir, profilesDirName, name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create profile folder: %v", err)
+	}
+	return dir, nil
+}
+
+// profileExists reports whether a profile has been created
+func profileExists(name string) bool {
+	if name == defaultProfile {
+		return true
+	}
+	if !profileNamePattern.MatchString(name) {
+		return false
+	}
+	info, err := os.Stat(profilePath(name))
+	return err == nil && info.IsDir()
+}
+
+// reload replaces the settings with those of the current profile
+func (s *settingsStore) reload() {
+	s.mu.Lock()
+	if err := s.load(); err != nil {
+		appLog.Errorf("Error loading settings: %v", err)
+	}
+	language := s.settings.Language
+	s.mu.Unlock()
+
+	messages.set(language)
+}
+
+// reload replaces the known devices with those of the current profile. No
+// device is connected while profiles are switched.
+func (r *deviceRegistry) reload() {
+	devices := make(map[string]*Device)
+	if err := loadJSONFile(devicesFile, &devices); err != nil {
+		deviceLog.Errorf("Error loading device registry: %v", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.devices = devices
+}
+
+// reload replaces the saved alarm profiles with those of the current
+// profile. The rules in force stay until the next session loads its own.
+func (s *alarmProfileStore) reload() {
+	file := alarmProfileFile{Profiles: make(map[string][]AlarmProfile)}
+	if err := loadJSONFile(alarmProfilesFile, &file); err != nil {
+		alarmsLog.Errorf("Error loading alarm profiles: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.file = file
+}
+
+// GetProfiles returns the configuration profiles, the default one first
+func (a *App) GetProfiles() ([]ProfileInfo, error) {
+	dir, err := appDataDir()
+	if err != nil {
+		return nil, err
+	}
+	current := profiles.current()
+	result := []ProfileInfo{{Name: defaultProfile, Dir: dir, Current: current == defaultProfile}}
+
+	entries, err := os.ReadDir(filepath.Join(dir, profilesDirName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() && profileNamePattern.MatchString(entry.Name()) && entry.Name() != defaultProfile {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result = append(result, ProfileInfo{Name: name, Dir: profilePath(name), Current: name == current})
+	}
+	return result, nil
+}
+
+// GetCurrentProfile returns the name of the profile in use
+func (a *App) GetCurrentProfile() string {
+	return profiles.current()
+}
+
+// CreateProfile creates a profile with its own settings, device registry
+// and alarm profiles, copied from another profile or, when copyFrom is
+// empty, starting from the defaults
+func (a *App) CreateProfile(name string, copyFrom string) (ProfileInfo, error) {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return ProfileInfo{}, err
+	}
+
+	if !profileNamePattern.MatchString(name) {
+		return ProfileInfo{}, fmt.Errorf("invalid profile name '%s'", name)
+	}
+	if profileExists(name) {
+		return ProfileInfo{}, fmt.Errorf("profile '%s' already exists", name)
+	}
+	if copyFrom != "" && !profileExists(copyFrom) {
+		return ProfileInfo{}, fmt.Errorf("no profile '%s'", copyFrom)
+	}
+
+	dir, err := profileDir(name)
+	if err != nil {
+		return ProfileInfo{}, err
+	}
+	if copyFrom != "" {
+		// Copied as stored, so the files need no decoding
+		from := profilePath(copyFrom)
+		for file := range profileFiles {
+			data, err := os.ReadFile(filepath.Join(from, file))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err == nil {
+				err = os.WriteFile(filepath.Join(dir, file), data, 0600)
+			}
+			if err != nil {
+				os.RemoveAll(dir)
+				return ProfileInfo{}, fmt.Errorf("failed to copy %s: %v", file, err)
+			}
+		}
+	}
+
+	appLog.Infof("Profile %s created", name)
+	a.audit.record("", AuditProfile, fmt.Sprintf("Profile %s created", name))
+	return ProfileInfo{Name: name, Dir: dir}, nil
+}
+
+// DeleteProfile deletes a profile and its files. The default profile and
+// the current one cannot be deleted.
+func (a *App) DeleteProfile(name string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if name == defaultProfile {
+		return fmt.Errorf("the default profile cannot be deleted")
+	}
+	if name == profiles.current() {
+		return fmt.Errorf("switch to another profile before deleting '%s'", name)
+	}
+	if !profileExists(name) {
+		return fmt.Errorf("no profile '%s'", name)
+	}
+	if err := os.RemoveAll(profilePath(name)); err != nil {
+		return fmt.Errorf("failed to delete profile: %v", err)
+	}
+
+	appLog.Infof("Profile %s deleted", name)
+	a.audit.record("", AuditProfile, fmt.Sprintf("Profile %s deleted", name))
+	return nil
+}
+
+// SwitchProfile makes another profile current: its settings, device
+// registry and alarm profiles replace those in use. The device must be
+// disconnected, so no session runs with a mix of both.
+func (a *App) SwitchProfile(name string) (Settings, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return Settings{}, err
+	}
+	telemetry.count(FeatureProfiles)
+
+	if !profileExists(name) {
+		return Settings{}, fmt.Errorf("no profile '%s'", name)
+	}
+	if a.conn.connected() {
+		return Settings{}, fmt.Errorf("disconnect the device before switching profiles")
+	}
+	previous := profiles.current()
+	if name == previous {
+		return a.GetSettings(), nil
+	}
+	if err := profiles.set(name); err != nil {
+		return Settings{}, err
+	}
+	a.settings.reload()
+	a.devices.reload()
+	a.limits.reload()
+
+	appLog.Infof("Switched from profile %s to %s", previous, name)
+	a.audit.record("", AuditProfile, fmt.Sprintf("Switched from profile %s to %s", previous, name))
+	settings := a.GetSettings()
+	a.emit(EventProfile, name)
+	a.emit(EventSettings, settings)
+	return settings, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// registerDeviceID holds the asset ID of devices that can store one
+const registerDeviceID = "ID"
+
+// DeviceProvisioning describes a new device, filled in on its first connect
+type DeviceProvisioning struct {
+	Name         string `json:"name"`
+	Location     string `json:"location"`     // Ward, bed or room the device belongs to
+	AssetID      string `json:"assetId"`      // Written to the device if WriteID is set
+	WriteID      bool   `json:"writeId"`      // Store the asset ID in the device's ID register
+	Parser       string `json:"parser"`       // Empty for the hex parser
+	AlarmProfile string `json:"alarmProfile"` // Empty for the startup profile
+}
+
+// ProvisioningResult reports a provisioned device and whether it accepted its ID
+type ProvisioningResult struct {
+	Device    Device `json:"device"`
+	IDWritten bool   `json:"idWritten"`
+	Message   string `json:"message"`
+}
+
+// errNotSupported marks devices that reject an optional command
+var errNotSupported = errors.New("not supported by the device")
+
+// writeDeviceID stores the asset ID in the device; the caller owns the port
+func (a *App) writeDeviceID(assetID string) error {
+	if err := a.setRegister(registerDeviceID, assetID); err != nil {
+		if strings.Contains(err.Error(), "device rejected") {
+			return fmt.Errorf("%w: %v", errNotSupported, err)
+		}
+		return err
+	}
+	value, err := a.getRegister(registerDeviceID)
+	if err != nil {
+		return err
+	}
+	if value != assetID {
+		return fmt.Errorf("device reports ID '%s' after writing '%s'", value, assetID)
+	}
+	return nil
+}
+
+// GetUnprovisionedDevices returns the known devices nobody has provisioned yet
+func (a *App) GetUnprovisionedDevices() []Device {
+	result := make([]Device, 0)
+	for _, device := range a.GetDevices() {
+		if device.Provisioned == nil {
+			result = append(result, device)
+		}
+	}
+	return result
+}
+
+// ProvisionDevice names and locates a registered device, selects its parser
+// and alarm profile and, for the connected device, writes its asset ID to
+// the device if asked to. Devices without an ID register are provisioned
+// without it. Provisioning again overwrites the previous values.
+func (a *App) ProvisionDevice(id string, provisioning DeviceProvisioning) (ProvisioningResult, error) {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return ProvisioningResult{}, err
+	}
+
+	provisioning.Name = strings.TrimSpace(provisioning.Name)
+	provisioning.AssetID = strings.TrimSpace(provisioning.AssetID)
+	if provisioning.Name == "" {
+		return ProvisioningResult{}, fmt.Errorf("device name must not be empty")
+	}
+	if provisioning.Parser == "" {
+		provisioning.Parser = ParserHex
+	}
+	if _, ok := a.findParser(provisioning.Parser); !ok {
+		return ProvisioningResult{}, fmt.Errorf("unknown parser '%s'", provisioning.Parser)
+	}
+	if provisioning.AlarmProfile != "" {
+		a.limits.mu.Lock()
+		_, err := a.limits.find(provisioning.AlarmProfile, 0)
+		a.limits.mu.Unlock()
+		if err != nil {
+			return ProvisioningResult{}, err
+		}
+	}
+
+	a.devices.mu.Lock()
+	_, known := a.devices.devices[id]
+	connected := id == a.devices.connected
+	a.devices.mu.Unlock()
+	if !known {
+		return ProvisioningResult{}, fmt.Errorf("unknown device '%s'", id)
+	}
+
+	result := ProvisioningResult{Message: "Device provisioned"}
+	if provisioning.WriteID {
+		if provisioning.AssetID == "" {
+			return ProvisioningResult{}, fmt.Errorf("asset ID must not be empty")
+		}
+		if strings.ContainsAny(provisioning.AssetID, " =\r\n") {
+			return ProvisioningResult{}, fmt.Errorf("asset ID '%s' must not contain spaces or '='", provisioning.AssetID)
+		}
+		if !connected {
+			return ProvisioningResult{}, fmt.Errorf("device '%s' must be connected to write its ID", id)
+		}
+		err := a.withDevicePort(func() error { return a.writeDeviceID(provisioning.AssetID) })
+		switch {
+		case errors.Is(err, errNotSupported):
+			result.Message = "Device provisioned; it cannot store an ID"
+		case err != nil:
+			return ProvisioningResult{}, err
+		default:
+			result.IDWritten = true
+		}
+	}
+
+	now := time.Now()
+	a.devices.mu.Lock()
+	device, ok := a.devices.devices[id]
+	if !ok {
+		a.devices.mu.Unlock()
+		return ProvisioningResult{}, fmt.Errorf("unknown device '%s'", id)
+	}
+	device.Name = provisioning.Name
+	device.Location = strings.TrimSpace(provisioning.Location)
+	device.AssetID = provisioning.AssetID
+	device.Provisioned = &now
+	device.Settings.Parser = provisioning.Parser
+	device.Settings.AlarmProfile = provisioning.AlarmProfile
+	err := a.devices.save()
+	result.Device = device.copy()
+	a.devices.mu.Unlock()
+	if err != nil {
+		return ProvisioningResult{}, err
+	}
+
+	// A device provisioned on its first connect gets its limits right away
+	if connected && provisioning.AlarmProfile != "" {
+		if err := a.loadAlarmProfile(provisioning.AlarmProfile, 0); err != nil {
+			deviceLog.Errorf("Error loading alarm profile %s: %v", provisioning.AlarmProfile, err)
+		}
+	}
+
+	deviceLog.Infof("Device %s provisioned as '%s' at '%s'", id, result.Device.Name, result.Device.Location)
+	a.audit.record("", AuditDeviceSettings, fmt.Sprintf("Device %s provisioned as '%s' at '%s'", id, result.Device.Name, result.Device.Location))
+	return result, nil
+}
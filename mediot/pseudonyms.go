@@ -0,0 +1,185 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// pseudonymsFile stores the pseudonym salt and the mapping back to patient
+// identifiers. It is one of the protected files, encrypted with the patient data.
+const pseudonymsFile = "pseudonyms.json"
+
+// pseudonymPrefix marks exported identifiers as pseudonyms
+const pseudonymPrefix = "P-"
+
+// pseudonymTable is the persisted salt and re-identification mapping
+type pseudonymTable struct {
+	Salt     []byte            `json:"salt"`
+	Patients map[string]string `json:"patients"` // Patient identifier by pseudonym
+}
+
+// pseudonymStore derives stable pseudonyms for patient identifiers. The
+// same patient always gets the same pseudonym, and only this store can map
+// it back.
+type pseudonymStore struct {
+	mu    sync.Mutex
+	table pseudonymTable
+}
+
+// newPseudonymStore loads the pseudonym table
+func newPseudonymStore() *pseudonymStore {
+	s := &pseudonymStore{table: pseudonymTable{Patients: make(map[string]string)}}
+	if err := loadJSONFile(pseudonymsFile, &s.table); err != nil {
+		securityLog.Errorf("Error loading pseudonyms: %v", err)
+	}
+	return s
+}
+
+// reload reads the pseudonym table once the patient data is unlocked
+func (s *pseudonymStore) reload() {
+	table := pseudonymTable{Patients: make(map[string]string)}
+	if err := loadJSONFile(pseudonymsFile, &table); err != nil {
+		securityLog.Errorf("Error loading pseudonyms: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.table = table
+}
+
+// save persists the pseudonym table; the caller holds the lock
+func (s *pseudonymStore) save() error {
+	return saveJSONFile(pseudonymsFile, s.table)
+}
+
+// pseudonym returns the pseudonym of a patient, creating the salt on first use.
+// The caller holds the lock and saves the table.
+func (s *pseudonymStore) pseudonym(patient string) (string, error) {
+	if len(s.table.Salt) == 0 {
+		salt := make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("failed to generate pseudonym salt: %v", err)
+		}
+		s.table.Salt = salt
+	}
+	if s.table.Patients == nil {
+		s.table.Patients = make(map[string]string)
+	}
+
+	mac := hmac.New(sha256.New, s.table.Salt)
+	mac.Write([]byte(patient))
+	pseudonym := pseudonymPrefix + hex.EncodeToString(mac.Sum(nil))[:16]
+	s.table.Patients[pseudonym] = patient
+	return pseudonym, nil
+}
+
+// ExportOptions configures a session export
+type ExportOptions struct {
+	Pseudonymize bool `json:"pseudonymize"` // Replace patient identifiers with pseudonyms
+	Limit        int  `json:"limit"`        // Most recent sessions to export, 0 for all
+}
+
+// ExportedSession is a session with the alarms raised during it
+type ExportedSession struct {
+	SessionInfo
+	Alarms []AlarmRecord `json:"alarms"`
+}
+
+// SessionExport is the file written by ExportSessions
+type SessionExport struct {
+	ExportedAt    time.Time         `json:"exportedAt"`
+	Pseudonymized bool              `json:"pseudonymized"`
+	Sessions      []ExportedSession `json:"sessions"`
+}
+
+// ExportSessions writes the session metadata and their alarms to a JSON
+// file. With pseudonymization, patient identifiers are replaced by stable
+// pseudonyms so the dataset can be shared; the mapping stays in the app.
+func (a *App) ExportSessions(path string, options ExportOptions) (int, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return 0, err
+	}
+	telemetry.count(FeatureExport)
+
+	sessions := a.sessionList(options.Limit)
+	alarms := a.alarmHistory(AlarmHistoryFilter{})
+
+	export := SessionExport{
+		ExportedAt:    time.Now(),
+		Pseudonymized: options.Pseudonymize,
+		Sessions:      make([]ExportedSession, 0, len(sessions)),
+	}
+	for _, session := range sessions {
+		exported := ExportedSession{SessionInfo: session, Alarms: make([]AlarmRecord, 0)}
+		for _, alarm := range alarms {
+			if !alarm.RaisedAt.Before(session.StartedAt) &&
+				(session.EndedAt.IsZero() || alarm.RaisedAt.Before(session.EndedAt)) {
+				exported.Alarms = append(exported.Alarms, alarm)
+			}
+		}
+		export.Sessions = append(export.Sessions, exported)
+	}
+
+	if options.Pseudonymize {
+		a.pseudonyms.mu.Lock()
+		for i := range export.Sessions {
+			if export.Sessions[i].Patient == "" {
+				continue
+			}
+			pseudonym, err := a.pseudonyms.pseudonym(export.Sessions[i].Patient)
+			if err != nil {
+				a.pseudonyms.mu.Unlock()
+				return 0, err
+			}
+			export.Sessions[i].Patient = pseudonym
+		}
+		err := a.pseudonyms.save()
+		a.pseudonyms.mu.Unlock()
+		if err != nil {
+			return 0, fmt.Errorf("failed to save pseudonyms: %v", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode export: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return 0, fmt.Errorf("failed to write export: %v", err)
+	}
+
+	detail := fmt.Sprintf("%d sessions exported to %s", len(export.Sessions), path)
+	if options.Pseudonymize {
+		detail += " with pseudonyms"
+	}
+	securityLog.Infof("%s", detail)
+	a.audit.record("", AuditExport, detail)
+	return len(export.Sessions), nil
+}
+
+// ResolvePseudonym returns the patient identifier behind a pseudonym of an
+// earlier export, for re-identification by authorized staff
+func (a *App) ResolvePseudonym(pseudonym string) (string, error) {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return "", err
+	}
+
+	a.pseudonyms.mu.Lock()
+	defer a.pseudonyms.mu.Unlock()
+
+	patient, ok := a.pseudonyms.table.Patients[pseudonym]
+	if !ok {
+		return "", fmt.Errorf("unknown pseudonym '%s'", pseudonym)
+	}
+	a.audit.recordPatient("", AuditSecurity, patient, fmt.Sprintf("Pseudonym %s resolved", pseudonym))
+	return patient, nil
+}
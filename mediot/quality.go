@@ -0,0 +1,219 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Signal quality levels
+const (
+	QualityGood = "good"
+	QualityFair = "fair"
+	QualityPoor = "poor"
+)
+
+// Signal quality tuning
+const (
+	qualityWindow         = 5 * time.Second // Readings the metrics are computed over
+	qualityUpdateInterval = time.Second     // How often the metrics are recomputed
+	qualityNoiseLimit     = 0.25            // Noise ratio at which the noise score reaches zero
+	qualityClipRun        = 3               // Repeated readings at the window extreme that count as clipping
+	qualityGapFactor      = 3.0             // Gaps longer than this many sample periods count as dropouts
+)
+
+// SignalQuality is the quality index of a channel, from 0 (unusable) to 1 (clean)
+type SignalQuality struct {
+	Channel  string    `json:"channel"`
+	Quality  float64   `json:"quality"`  // Worst of the individual scores
+	Level    string    `json:"level"`    // good, fair or poor
+	Noise    float64   `json:"noise"`    // High-frequency content relative to the signal range
+	Clipping float64   `json:"clipping"` // Fraction of readings stuck at the window extremes
+	Dropout  float64   `json:"dropout"`  // Fraction of expected readings that never arrived
+	Updated  time.Time `json:"updated"`
+}
+
+// qualityTracker accumulates the quality metrics of one channel
+type qualityTracker struct {
+	window   *timedWindow
+	interval float64 // Smoothed sample period in seconds
+	received int
+	missing  float64
+	result   SignalQuality
+}
+
+// qualityMonitor computes the signal quality of the raw channels. Derived
+// channels inherit the quality of the raw channels they are computed from.
+type qualityMonitor struct {
+	mu          sync.Mutex
+	trackers    map[string]*qualityTracker
+	lastUpdate  time.Time
+	parseErrors int // Unparseable lines since the last update, counted as dropouts
+	notify      func([]SignalQuality)
+}
+
+// newQualityMonitor creates the quality stage
+func newQualityMonitor(notify func([]SignalQuality)) *qualityMonitor {
+	return &qualityMonitor{
+		trackers: make(map[string]*qualityTracker),
+		notify:   notify,
+	}
+}
+
+// recordParseError counts a line that could not be decoded
+func (m *qualityMonitor) recordParseError() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.parseErrors++
+}
+
+func (m *qualityMonitor) process(sample *SensorData) {
+	m.mu.Lock()
+
+	for _, channel := range rawChannels {
+		value, _ := sample.channelValue(channel)
+		tracker, ok := m.trackers[channel]
+		if !ok {
+			tracker = &qualityTracker{window: newTimedWindow(qualityWindow)}
+			m.trackers[channel] = tracker
+		}
+		tracker.add(sample.Timestamp, value)
+	}
+
+	if sample.Timestamp.Sub(m.lastUpdate) < qualityUpdateInterval {
+		m.mu.Unlock()
+		return
+	}
+	m.lastUpdate = sample.Timestamp
+
+	result := make([]SignalQuality, 0, len(m.trackers))
+	for channel, tracker := range m.trackers {
+		tracker.missing += float64(m.parseErrors)
+		tracker.update(channel, sample.Timestamp)
+		result = append(result, tracker.result)
+	}
+	m.parseErrors = 0
+	m.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Channel < result[j].Channel })
+	m.notify(result)
+}
+
+// add records a reading and detects gaps in the stream
+func (t *qualityTracker) add(at time.Time, v float64) {
+	if n := t.window.len(); n > 0 {
+		dt := at.Sub(t.window.values[n-1].t).Seconds()
+		if t.interval > 0 && dt > qualityGapFactor*t.interval {
+			t.missing += dt/t.interval - 1
+		} else if t.interval == 0 {
+			t.interval = dt
+		} else {
+			t.interval = 0.95*t.interval + 0.05*dt
+		}
+	}
+	t.window.push(at, v)
+	t.received++
+}
+
+// update recomputes the quality scores from the readings in the window
+func (t *qualityTracker) update(channel string, now time.Time) {
+	values := t.window.values
+	noise, clipping := 0.0, 0.0
+
+	if len(values) >= 3 {
+		min, max, _ := t.window.minMaxMean()
+		span := max - min
+
+		// Noise: mean absolute second difference against the signal range
+		if span > 0 {
+			sum := 0.0
+			for i := 2; i < len(values); i++ {
+				sum += math.Abs(values[i].v - 2*values[i-1].v + values[i-2].v)
+			}
+			noise = sum / float64(len(values)-2) / span
+		}
+
+		// Clipping: runs of identical readings sitting at the window extremes
+		clipped, run := 0, 1
+		for i := 1; i <= len(values); i++ {
+			if i < len(values) && values[i].v == values[i-1].v {
+				run++
+				continue
+			}
+			if run >= qualityClipRun && (values[i-1].v == min || values[i-1].v == max) {
+				clipped += run
+			}
+			run = 1
+		}
+		clipping = float64(clipped) / float64(len(values))
+	}
+
+	dropout := 0.0
+	if expected := float64(t.received) + t.missing; expected > 0 {
+		dropout = t.missing / expected
+	}
+	t.received, t.missing = 0, 0
+
+	noiseScore := math.Max(0, 1-noise/qualityNoiseLimit)
+	quality := math.Min(noiseScore, math.Min(1-clipping, 1-dropout))
+
+	level := QualityPoor
+	switch {
+	case quality >= 0.7:
+		level = QualityGood
+	case quality >= 0.4:
+		level = QualityFair
+	}
+
+	t.result = SignalQuality{
+		Channel:  channel,
+		Quality:  quality,
+		Level:    level,
+		Noise:    noise,
+		Clipping: clipping,
+		Dropout:  dropout,
+		Updated:  now,
+	}
+}
+
+// qualityOf returns the current quality of a channel. Channels that aren't
+// tracked directly get the worst quality of the raw channels.
+func (m *qualityMonitor) qualityOf(channel string) (float64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if tracker, ok := m.trackers[channel]; ok {
+		return tracker.result.Quality, !tracker.result.Updated.IsZero()
+	}
+
+	worst, known := 1.0, false
+	for _, tracker := range m.trackers {
+		if !tracker.result.Updated.IsZero() {
+			worst = math.Min(worst, tracker.result.Quality)
+			known = true
+		}
+	}
+	return worst, known
+}
+
+// onSignalQuality forwards the periodic quality update to the frontend
+func (a *App) onSignalQuality(quality []SignalQuality) {
+	a.emit(EventSignalQuality, quality)
+}
+
+// GetSignalQuality returns the latest quality index of every raw channel
+func (a *App) GetSignalQuality() []SignalQuality {
+	a.quality.mu.Lock()
+	defer a.quality.mu.Unlock()
+
+	result := make([]SignalQuality, 0, len(a.quality.trackers))
+	for _, tracker := range a.quality.trackers {
+		if !tracker.result.Updated.IsZero() {
+			result = append(result, tracker.result)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Channel < result[j].Channel })
+	return result
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxQuarantinedLines is how many malformed lines are kept for review
+const maxQuarantinedLines = 1000
+
+// QuarantinedLine is a line the parser rejected and nothing was recovered from
+type QuarantinedLine struct {
+	Seq       int64     `json:"seq"`
+	Timestamp time.Time `json:"timestamp"`
+	Hex       string    `json:"hex"`  // Bytes of the line as received, without the line feed
+	Text      string    `json:"text"` // The line with its surrounding whitespace trimmed
+	Reason    string    `json:"reason"`
+}
+
+// QuarantineStatus counts the malformed lines since the quarantine was cleared
+type QuarantineStatus struct {
+	Mode        string `json:"mode"` // Parser mode in force
+	Quarantined int64  `json:"quarantined"`
+	Recovered   int64  `json:"recovered"` // Malformed lines kept in lenient mode
+	Lines       int    `json:"lines"`     // Quarantined lines kept for review
+}
+
+// lineQuarantine keeps the malformed lines for review
+type lineQuarantine struct {
+	mu          sync.Mutex
+	lines       []QuarantinedLine // Most recent, oldest first
+	nextSeq     int64
+	quarantined int64
+	recovered   int64
+}
+
+// newLineQuarantine creates an empty quarantine
+func newLineQuarantine() *lineQuarantine {
+	return &lineQuarantine{lines: make([]QuarantinedLine, 0), nextSeq: 1}
+}
+
+// add quarantines a malformed line
+func (q *lineQuarantine) add(raw string, reason error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.lines = append(q.lines, QuarantinedLine{
+		Seq:       q.nextSeq,
+		Timestamp: time.Now(),
+		Hex:       hex.EncodeToString([]byte(raw)),
+		Text:      strings.TrimSpace(raw),
+		Reason:    reason.Error(),
+	})
+	q.nextSeq++
+	q.quarantined++
+	if len(q.lines) > maxQuarantinedLines {
+		q.lines = q.lines[1:]
+	}
+}
+
+// addRecovered counts a malformed line kept in lenient mode
+func (q *lineQuarantine) addRecovered() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.recovered++
+}
+
+// recoverLine decodes what it can of a line the parser rejected, in lenient
+// mode. Channels that did not decode keep the value of the last decoded
+// line and are marked as artifacts, so statistics and alarms skip them. It
+// returns nil when nothing can be recovered; the caller holds bufferMutex.
+func (a *App) recoverLine(line string) *SensorData {
+	if a.lenient == nil || a.GetSettings().ParserMode != ParserModeLenient {
+		return nil
+	}
+	values, valid := a.lenient(line)
+	sample := &SensorData{Timestamp: time.Now()}
+	decoded := 0
+	for i, channel := range rawChannels {
+		switch {
+		case valid[i]:
+			sample.setChannelValue(channel, values[i])
+			decoded++
+		case a.lastRaw != nil:
+			sample.setChannelValue(channel, a.lastRaw[i])
+			sample.Artifacts = append(sample.Artifacts, channel)
+		default:
+			return nil
+		}
+	}
+	if decoded == 0 {
+		return nil
+	}
+	a.quarantine.addRecovered()
+	return sample
+}
+
+// GetQuarantineStatus counts the lines rejected by the parser since the
+// quarantine was cleared
+func (a *App) GetQuarantineStatus() QuarantineStatus {
+	mode := a.GetSettings().ParserMode
+
+	a.quarantine.mu.Lock()
+	defer a.quarantine.mu.Unlock()
+
+	return QuarantineStatus{
+		Mode:        mode,
+		Quarantined: a.quarantine.quarantined,
+		Recovered:   a.quarantine.recovered,
+		Lines:       len(a.quarantine.lines),
+	}
+}
+
+// GetQuarantinedLines returns the most recent malformed lines, oldest
+// first; limit 0 returns all that are kept
+func (a *App) GetQuarantinedLines(limit int) ([]QuarantinedLine, error) {
+	if err := a.requireReadRole(RoleOperator); err != nil {
+		return nil, err
+	}
+
+	a.quarantine.mu.Lock()
+	defer a.quarantine.mu.Unlock()
+
+	lines := a.quarantine.lines
+	if limit > 0 && len(lines) > limit {
+		lines = lines[len(lines)-limit:]
+	}
+	return append([]QuarantinedLine{}, lines...), nil
+}
+
+// ClearQuarantine forgets the quarantined lines and resets the counts
+func (a *App) ClearQuarantine() {
+	a.quarantine.mu.Lock()
+	defer a.quarantine.mu.Unlock()
+
+	a.quarantine.lines = make([]QuarantinedLine, 0)
+	a.quarantine.quarantined = 0
+	a.quarantine.recovered = 0
+}
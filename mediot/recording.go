@@ -0,0 +1,422 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Recording files
+const (
+	recordingDirName       = "recordings" // In the data directory, unless the settings name another folder
+	recordingFilePattern   = "mediot-session*.jsonl"
+	recordingFlushInterval = time.Second // Samples lost at most on a crash
+)
+
+// RecordingStatus describes the sample recording
+type RecordingStatus struct {
+	Active    bool      `json:"active"`         // Samples are written whenever a session runs
+	Dir       string    `json:"dir,omitempty"`  // Folder of the recording files
+	File      string    `json:"file,omitempty"` // File of the running session, empty between sessions
+	Samples   int64     `json:"samples"`        // Written to the current file
+	StartedAt time.Time `json:"startedAt"`      // When recording was started, zero while inactive
+}
+
+// recorder is the last pipeline stage while recording: it writes every
+// processed sample as a JSON line, one file per session. While encryption
+// at rest is enabled, the lines gathered between flushes are sealed
+// together and written as one base64 line instead; readRecordingFile reads
+// both.
+type recorder struct {
+	mu      sync.Mutex
+	dir     string // Empty while not recording
+	started time.Time
+	file    *os.File // Open while a session is recorded
+	pending []byte   // Lines not written yet
+	path    string
+	samples int64
+	flushed time.Time
+	session func() int64 // ID of the running session
+}
+
+// newRecorder creates a recorder that is not recording
+func newRecorder(session func() int64) *recorder {
+	return &recorder{session: session}
+}
+
+func (r *recorder) process(sample *SensorData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.dir == "" {
+		return
+	}
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			appLog.Errorf("Error starting recording file, recording stopped: %v", err)
+			backendErrors.report(ErrorSink, "Recording stopped: the file could not be created", err)
+			r.dir, r.started = "", time.Time{}
+			return
+		}
+	}
+
+	data, err := json.Marshal(sample)
+	if err == nil {
+		r.pending = append(append(r.pending, data...), '\n')
+		if time.Since(r.flushed) >= recordingFlushInterval {
+			err = r.flush()
+		}
+	}
+	if err != nil {
+		appLog.Errorf("Error writing %s, recording stopped: %v", r.path, err)
+		backendErrors.report(ErrorSink, "Recording stopped: the file could not be written", err)
+		r.closeFile()
+		r.dir, r.started = "", time.Time{}
+		return
+	}
+	r.samples++
+}
+
+// open creates the file of the running session; the caller holds the lock
+func (r *recorder) open() error {
+	name := fmt.Sprintf("mediot-session%d-%s.jsonl", r.session(), time.Now().Format(crashTimestampForm))
+	path := filepath.Join(r.dir, name)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	r.file = file
+	r.pending = r.pending[:0]
+	r.path = path
+	r.samples = 0
+	r.flushed = time.Now()
+	appLog.Infof("Recording to %s", path)
+	return nil
+}
+
+// flush writes the pending lines, sealed when encryption at rest is
+// enabled; the caller holds the lock
+func (r *recorder) flush() error {
+	r.flushed = time.Now()
+	if len(r.pending) == 0 {
+		return nil
+	}
+	data, err := atRest.encrypt(filepath.Base(r.path), r.pending)
+	if err != nil {
+		return err
+	}
+	if isEncrypted(data) {
+		data = append([]byte(base64.StdEncoding.EncodeToString(data)), '\n')
+	}
+	_, err = r.file.Write(data)
+	r.pending = r.pending[:0]
+	return err
+}
+
+// closeFile flushes and closes the current file; the caller holds the lock
+func (r *recorder) closeFile() {
+	if r.file == nil {
+		return
+	}
+	if err := r.flush(); err != nil {
+		appLog.Errorf("Error writing %s: %v", r.path, err)
+	}
+	if err := r.file.Close(); err != nil {
+		appLog.Errorf("Error closing %s: %v", r.path, err)
+	}
+	appLog.Infof("Recording file %s closed after %d samples", r.path, r.samples)
+	r.file = nil
+	r.pending = nil
+	r.path = ""
+}
+
+// endFile closes the file of a session that ended; recording continues
+// with a new file when the next session starts
+func (r *recorder) endFile() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closeFile()
+}
+
+// status returns the recording state
+func (r *recorder) status() RecordingStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return RecordingStatus{Active: r.dir != "", Dir: r.dir, File: r.path, Samples: r.samples, StartedAt: r.started}
+}
+
+// sealedRecordingPrefix starts the lines of a recording that were sealed:
+// the base64 of encryptedHeader
+var sealedRecordingPrefix = []byte(base64.StdEncoding.EncodeToString(encryptedHeader)[:16])
+
+// scanRecordingFile calls fn with each JSON line of a recording file,
+// decrypting the sealed ones, until fn returns an error
+func scanRecordingFile(path string, fn func(line []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !bytes.HasPrefix(line, sealedRecordingPrefix) {
+			if err := fn(line); err != nil {
+				return err
+			}
+			continue
+		}
+		sealed, err := base64.StdEncoding.DecodeString(string(line))
+		if err != nil {
+			return fmt.Errorf("damaged line in %s: %v", path, err)
+		}
+		plain, err := atRest.decrypt(sealed)
+		if err != nil {
+			return err
+		}
+		for _, line := range bytes.Split(bytes.TrimSuffix(plain, []byte{'\n'}), []byte{'\n'}) {
+			if err := fn(line); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// readRecordingFile returns the JSON lines of a recording file, decrypting
+// the sealed ones
+func readRecordingFile(path string) ([]byte, error) {
+	var out []byte
+	err := scanRecordingFile(path, func(line []byte) error {
+		out = append(append(out, line...), '\n')
+		return nil
+	})
+	return out, err
+}
+
+// sessionRecordingFiles returns the recording files of a session in a folder
+func sessionRecordingFiles(dir string, session int64) ([]string, error) {
+	return filepath.Glob(filepath.Join(dir, fmt.Sprintf("mediot-session%d-*.jsonl", session)))
+}
+
+// recordingDirs returns the folders recordings may be in: the one from the
+// settings, and the one being recorded to if it differs
+func (a *App) recordingDirs() []string {
+	dirs := make([]string, 0, 2)
+	if dir, err := a.recordingDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	if dir := a.recorder.status().Dir; dir != "" && (len(dirs) == 0 || filepath.Clean(dir) != filepath.Clean(dirs[0])) {
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// sessionRecordings returns the recording files of a session in every
+// recording folder, sorted by name, which sorts them by time
+func (a *App) sessionRecordings(session int64) ([]string, error) {
+	var files []string
+	for _, dir := range a.recordingDirs() {
+		matched, err := sessionRecordingFiles(dir, session)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matched...)
+	}
+	sort.Slice(files, func(i, j int) bool { return filepath.Base(files[i]) < filepath.Base(files[j]) })
+	return files, nil
+}
+
+// recordingDir returns the folder recordings go to: the one from the
+// settings, or recordings in the data directory
+func (a *App) recordingDir() (string, error) {
+	if dir := a.GetSettings().RecordingDir; dir != "" {
+		return dir, nil
+	}
+	dir, err := appDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, recordingDirName), nil
+}
+
+// StartRecording writes every sample to a file in dir, or in the recording
+// folder of the settings when dir is empty, starting a new file for each
+// session until StopRecording
+func (a *App) StartRecording(dir string) (RecordingStatus, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return RecordingStatus{}, err
+	}
+	telemetry.count(FeatureRecording)
+	status, err := a.startRecording(dir)
+	if err == nil {
+		a.resume.recording(true, status.Dir)
+	}
+	return status, err
+}
+
+// startRecording is StartRecording without the role check, for headless mode
+func (a *App) startRecording(dir string) (RecordingStatus, error) {
+	if dir == "" {
+		var err error
+		if dir, err = a.recordingDir(); err != nil {
+			return RecordingStatus{}, err
+		}
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return RecordingStatus{}, err
+	}
+	if atRest.locked() {
+		return RecordingStatus{}, errStorageLocked
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return RecordingStatus{}, fmt.Errorf("failed to create recording folder: %v", err)
+	}
+
+	a.recorder.mu.Lock()
+	if a.recorder.dir != "" {
+		a.recorder.mu.Unlock()
+		return RecordingStatus{}, fmt.Errorf("already recording to %s", a.recorder.dir)
+	}
+	a.recorder.dir = dir
+	a.recorder.started = time.Now()
+	a.recorder.mu.Unlock()
+
+	appLog.Infof("Recording started in %s", dir)
+	a.audit.record("", AuditRecording, fmt.Sprintf("Recording started in %s", dir))
+	status := a.recorder.status()
+	a.emit(EventRecording, status)
+	return status, nil
+}
+
+// StopRecording closes the current recording file and stops recording
+func (a *App) StopRecording() (RecordingStatus, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return RecordingStatus{}, err
+	}
+	status, err := a.stopRecording()
+	if err == nil {
+		a.resume.recording(false, "")
+	}
+	return status, err
+}
+
+// stopRecording is StopRecording without the role check, for the recording
+// schedules
+func (a *App) stopRecording() (RecordingStatus, error) {
+	a.recorder.mu.Lock()
+	if a.recorder.dir == "" {
+		a.recorder.mu.Unlock()
+		return RecordingStatus{}, fmt.Errorf("not recording")
+	}
+	a.recorder.closeFile()
+	a.recorder.dir = ""
+	a.recorder.started = time.Time{}
+	a.recorder.mu.Unlock()
+
+	appLog.Infof("Recording stopped")
+	a.audit.record("", AuditRecording, "Recording stopped")
+	status := a.recorder.status()
+	a.emit(EventRecording, status)
+	return status, nil
+}
+
+// GetRecordingStatus returns whether samples are being recorded and where
+func (a *App) GetRecordingStatus() RecordingStatus {
+	return a.recorder.status()
+}
+
+// maxLoadedSamples caps the samples LoadRecording returns, an hour at 100 Hz
+const maxLoadedSamples = 360000
+
+// errRecordingCut stops reading a recording at maxLoadedSamples
+var errRecordingCut = errors.New("recording cut")
+
+// LoadedRecording is a recording file read for review
+type LoadedRecording struct {
+	Samples []SensorData `json:"samples"`
+	Cut     bool         `json:"cut"` // The file has more than maxLoadedSamples samples; export it to get them all
+}
+
+// LoadRecording reads a recording file, such as one picked with
+// OpenRecordingFile, for review. Lines sealed by encryption at rest are
+// decrypted, which needs the data unlocked.
+func (a *App) LoadRecording(path string) (LoadedRecording, error) {
+	if err := a.requireReadRole(RoleOperator); err != nil {
+		return LoadedRecording{}, err
+	}
+
+	loaded := LoadedRecording{Samples: make([]SensorData, 0)}
+	err := scanRecordingFile(path, func(line []byte) error {
+		if len(loaded.Samples) == maxLoadedSamples {
+			return errRecordingCut
+		}
+		var sample SensorData
+		if err := json.Unmarshal(line, &sample); err != nil {
+			return fmt.Errorf("damaged sample: %v", err)
+		}
+		loaded.Samples = append(loaded.Samples, sample)
+		return nil
+	})
+	if err == errRecordingCut {
+		loaded.Cut = true
+	} else if err != nil {
+		return LoadedRecording{}, fmt.Errorf("failed to read recording %s: %v", filepath.Base(path), err)
+	}
+
+	a.audit.record("", AuditRecording, fmt.Sprintf("Recording %s opened (%d samples)", path, len(loaded.Samples)))
+	return loaded, nil
+}
+
+// ExportRecording writes a recording file as plain JSON lines to path,
+// decrypting the lines sealed by encryption at rest, for tools outside the
+// app. It returns the number of samples written.
+func (a *App) ExportRecording(recording string, path string) (int, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return 0, err
+	}
+	telemetry.count(FeatureExport)
+
+	if filepath.Clean(recording) == filepath.Clean(path) {
+		return 0, fmt.Errorf("export would overwrite the recording")
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write export: %v", err)
+	}
+	w := bufio.NewWriter(file)
+	samples := 0
+	err = scanRecordingFile(recording, func(line []byte) error {
+		samples++
+		w.Write(line)
+		return w.WriteByte('\n')
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, fmt.Errorf("failed to export recording %s: %v", filepath.Base(recording), err)
+	}
+
+	detail := fmt.Sprintf("Recording %s exported to %s (%d samples)", recording, path, samples)
+	securityLog.Infof("%s", detail)
+	a.audit.record("", AuditExport, detail)
+	return samples, nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Parser replay
+const (
+	replayCaptureExt    = ".raw"         // Bytes as read from the serial port
+	replayGoldenExt     = ".golden.json" // Expected decoding of the capture of the same name
+	replayPluginWait    = 5 * time.Second
+	maxReplayMismatches = 20 // Mismatches reported per capture
+)
+
+// ReplayLine is the decoding of one line of a capture
+type ReplayLine struct {
+	Line     string  `json:"line"`
+	Value1   float64 `json:"value1"`
+	Value2   float64 `json:"value2"`
+	Value3   float64 `json:"value3"`
+	Rejected bool    `json:"rejected,omitempty"`
+	Error    string  `json:"error,omitempty"` // For reading only; messages follow the language, so only Rejected is compared
+}
+
+// ReplayGolden is the golden file of a capture
+type ReplayGolden struct {
+	Parser string       `json:"parser"`
+	Lines  []ReplayLine `json:"lines"`
+}
+
+// ReplayResult is the outcome of replaying one capture
+type ReplayResult struct {
+	Capture    string   `json:"capture"`
+	Parser     string   `json:"parser"`
+	Lines      int      `json:"lines"`
+	Passed     bool     `json:"passed"`
+	Updated    bool     `json:"updated"`              // The golden file was written from this run
+	Mismatches []string `json:"mismatches,omitempty"` // The first differences from the golden file
+}
+
+// replayLines splits a capture into lines the way the serial reader does:
+// a trailing incomplete line is never processed, and blank and
+// housekeeping lines never reach the parser
+func replayLines(data []byte) []string {
+	parts := strings.Split(string(data), "\n")
+	lines := make([]string, 0, len(parts))
+	for _, part := range parts[:len(parts)-1] {
+		line := strings.TrimSpace(part)
+		if line == "" || isHousekeepingLine(line) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// decodeCapture runs every line of a capture through a parser. The
+// timestamps parsers set are left out, so the decoding depends only on the
+// bytes.
+func decodeCapture(parser lineParser, data []byte) []ReplayLine {
+	lines := replayLines(data)
+	result := make([]ReplayLine, 0, len(lines))
+	for _, line := range lines {
+		decoded := ReplayLine{Line: line}
+		var sample *SensorData
+		var err error
+		if safely("replay", func() { sample, err = parser(line) }) {
+			err = fmt.Errorf("parser panicked")
+		}
+		if err != nil {
+			decoded.Rejected, decoded.Error = true, err.Error()
+		} else {
+			decoded.Value1, decoded.Value2, decoded.Value3 = sample.Value1, sample.Value2, sample.Value3
+		}
+		result = append(result, decoded)
+	}
+	return result
+}
+
+// compareReplay lists the differences of a decoding from the golden one.
+// Values must match exactly: the same bytes through the same parser give
+// the same floats.
+func compareReplay(want, got []ReplayLine) []string {
+	var mismatches []string
+	add := func(format string, args ...interface{}) {
+		if len(mismatches) < maxReplayMismatches {
+			mismatches = append(mismatches, fmt.Sprintf(format, args...))
+		}
+	}
+	if len(want) != len(got) {
+		add("%d lines, want %d", len(got), len(want))
+	}
+	for i := 0; i < min(len(want), len(got)); i++ {
+		w, g := want[i], got[i]
+		switch {
+		case w.Line != g.Line:
+			add("line %d is '%s', want '%s'", i+1, g.Line, w.Line)
+		case w.Rejected != g.Rejected && g.Rejected:
+			add("line %d '%s' rejected: %s", i+1, g.Line, g.Error)
+		case w.Rejected != g.Rejected:
+			add("line %d '%s' accepted, want rejected", i+1, g.Line)
+		case w.Value1 != g.Value1 || w.Value2 != g.Value2 || w.Value3 != g.Value3:
+			add("line %d '%s' decoded to %v, %v, %v, want %v, %v, %v", i+1, g.Line,
+				g.Value1, g.Value2, g.Value3, w.Value1, w.Value2, w.Value3)
+		}
+	}
+	return mismatches
+}
+
+// replayCapture replays one capture against its golden file. With update,
+// the golden file is written from the decoding instead; parser is the
+// parser of a capture without one.
+func replayCapture(capture string, find func(format string) (lineParser, bool), parser string, update bool) (ReplayResult, error) {
+	result := ReplayResult{Capture: filepath.Base(capture)}
+	data, err := os.ReadFile(capture)
+	if err != nil {
+		return result, fmt.Errorf("failed to read %s: %v", capture, err)
+	}
+
+	goldenPath := strings.TrimSuffix(capture, replayCaptureExt) + replayGoldenExt
+	var golden ReplayGolden
+	goldenData, err := os.ReadFile(goldenPath)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(goldenData, &golden); err != nil {
+			return result, fmt.Errorf("invalid golden file %s: %v", goldenPath, err)
+		}
+	case !os.IsNotExist(err):
+		return result, fmt.Errorf("failed to read %s: %v", goldenPath, err)
+	case !update:
+		return result, fmt.Errorf("%s has no golden file; replay with --update-golden to create it", result.Capture)
+	}
+	if golden.Parser == "" {
+		golden.Parser = parser
+	}
+	if golden.Parser == "" {
+		return result, fmt.Errorf("no parser for %s; pass --parser", result.Capture)
+	}
+	result.Parser = golden.Parser
+
+	parse, ok := find(golden.Parser)
+	if !ok {
+		return result, fmt.Errorf("unknown parser '%s'", golden.Parser)
+	}
+	decoded := decodeCapture(parse, data)
+	result.Lines = len(decoded)
+
+	if update {
+		golden.Lines = decoded
+		out, err := json.MarshalIndent(golden, "", "  ")
+		if err != nil {
+			return result, err
+		}
+		if err := os.WriteFile(goldenPath, append(out, '\n'), 0644); err != nil {
+			return result, fmt.Errorf("failed to write %s: %v", goldenPath, err)
+		}
+		result.Passed, result.Updated = true, true
+		return result, nil
+	}
+	result.Mismatches = compareReplay(golden.Lines, decoded)
+	result.Passed = len(result.Mismatches) == 0
+	return result, nil
+}
+
+// replayCaptures replays every capture of a folder, in name order. It
+// stops at the first capture that cannot be replayed; mismatches are
+// reported in the results.
+func replayCaptures(dir string, find func(format string) (lineParser, bool), parser string, update bool) ([]ReplayResult, error) {
+	captures, err := filepath.Glob(filepath.Join(dir, "*"+replayCaptureExt))
+	if err != nil {
+		return nil, err
+	}
+	if len(captures) == 0 {
+		return nil, fmt.Errorf("no %s captures in %s", replayCaptureExt, dir)
+	}
+	sort.Strings(captures)
+
+	results := make([]ReplayResult, 0, len(captures))
+	for _, capture := range captures {
+		result, err := replayCapture(capture, find, parser, update)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// builtinParser finds a built-in line parser, for replays without the app
+func builtinParser(format string) (lineParser, bool) {
+	parser, ok := lineParsers[format]
+	return parser, ok
+}
+
+// waitPluginParser returns the parser of an enabled plugin once the plugin
+// runs, for replays through plugin parsers
+func (a *App) waitPluginParser(format string) (lineParser, bool) {
+	deadline := time.Now().Add(replayPluginWait)
+	for time.Now().Before(deadline) {
+		for _, info := range a.GetPlugins() {
+			if info.Manifest.Parser == format && info.Enabled && info.State == PluginRunning {
+				return a.plugins.parser(format)
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return a.plugins.parser(format)
+}
+
+// replayRequested reports whether the command line asks for a parser replay
+func replayRequested(args []string) bool {
+	return flagRequested(args, "replay")
+}
+
+// runReplay replays raw captures through the parsers and compares the
+// decoded values with golden files, so parser changes cannot silently
+// alter them:
+//
+//	mediot --replay testdata/replay [--update-golden] [--parser hex]
+//
+// Each name.raw capture is checked against name.golden.json, which names
+// the parser. --update-golden writes the golden files from the current
+// parsers instead; --parser sets the parser of new ones. Built-in parsers
+// run without the app; the app is started only to reach plugin parsers.
+// It returns the process exit code.
+func runReplay(args []string) int {
+	flags := flag.NewFlagSet("mediot", flag.ContinueOnError)
+	dir := flags.String("replay", "", "folder of raw captures and their golden files")
+	update := flags.Bool("update-golden", false, "write the golden files from the current parsers")
+	parser := flags.String("parser", "", "parser of the captures without a golden file")
+	addPortableFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var app *App
+	find := func(format string) (lineParser, bool) {
+		if parse, ok := builtinParser(format); ok {
+			return parse, true
+		}
+		if app == nil {
+			app = NewApp()
+		}
+		return app.waitPluginParser(format)
+	}
+	results, err := replayCaptures(*dir, find, *parser, *update)
+	if app != nil {
+		app.shutdown(context.Background())
+	}
+
+	failed := 0
+	for _, result := range results {
+		switch {
+		case result.Updated:
+			fmt.Printf("updated %s (%s, %d lines)\n", result.Capture, result.Parser, result.Lines)
+		case result.Passed:
+			fmt.Printf("ok      %s (%s, %d lines)\n", result.Capture, result.Parser, result.Lines)
+		default:
+			failed++
+			fmt.Printf("FAIL    %s (%s, %d lines)\n", result.Capture, result.Parser, result.Lines)
+			for _, mismatch := range result.Mismatches {
+				fmt.Printf("        %s\n", mismatch)
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mediot: %v\n", err)
+		return 2
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
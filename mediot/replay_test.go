@@ -0,0 +1,21 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestReplayCaptures decodes the checked-in captures and compares them
+// with their golden files, like mediot --replay testdata/replay
+func TestReplayCaptures(t *testing.T) {
+	results, err := replayCaptures("testdata/replay", builtinParser, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if !result.Passed {
+			t.Errorf("%s (%s parser, %d lines) differs from its golden file:\n%s",
+				result.Capture, result.Parser, result.Lines, strings.Join(result.Mismatches, "\n"))
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Resampler limits
+const (
+	maxResampleRate     = 2000        // Highest supported output rate in Hz
+	maxResampleGap      = time.Second // Longer input gaps restart the grid instead of interpolating
+	maxResampledSamples = 20000       // Output samples kept until the frontend reads them
+)
+
+// resampler converts the irregular parse-rate stream to a fixed output rate
+// by linear interpolation between consecutive input samples
+type resampler struct {
+	rate     float64
+	period   time.Duration
+	previous *SensorData
+	next     time.Time // Timestamp of the next output sample on the grid
+}
+
+// newResampler creates a resampler for the given output rate in Hz
+func newResampler(rate float64) *resampler {
+	return &resampler{
+		rate:   rate,
+		period: time.Duration(float64(time.Second) / rate),
+	}
+}
+
+// push feeds an input sample and returns the output samples that became available
+func (r *resampler) push(sample *SensorData) []SensorData {
+	prev := r.previous
+	r.previous = sample
+
+	if prev == nil || sample.Timestamp.Sub(prev.Timestamp) > maxResampleGap || !sample.Timestamp.After(prev.Timestamp) {
+		// Start a fresh grid on the first sample, after a dropout or a clock step
+		out := *sample
+		out.Derived = copyDerived(sample.Derived)
+		r.next = sample.Timestamp.Add(r.period)
+		return []SensorData{out}
+	}
+
+	var result []SensorData
+	span := sample.Timestamp.Sub(prev.Timestamp).Seconds()
+	for !r.next.After(sample.Timestamp) {
+		frac := r.next.Sub(prev.Timestamp).Seconds() / span
+		result = append(result, interpolateSamples(prev, sample, frac, r.next))
+		r.next = r.next.Add(r.period)
+	}
+	return result
+}
+
+// interpolateSamples blends two samples; derived channels missing from either side are left out
+func interpolateSamples(a, b *SensorData, frac float64, at time.Time) SensorData {
+	lerp := func(x, y float64) float64 { return x + (y-x)*frac }
+
+	out := SensorData{
+		Value1:    lerp(a.Value1, b.Value1),
+		Value2:    lerp(a.Value2, b.Value2),
+		Value3:    lerp(a.Value3, b.Value3),
+		Timestamp: at,
+	}
+	for name, x := range a.Derived {
+		if y, ok := b.Derived[name]; ok {
+			out.setDerived(name, lerp(x, y))
+		}
+	}
+	return out
+}
+
+// copyDerived returns an independent copy of a derived channel map
+func copyDerived(derived map[string]float64) map[string]float64 {
+	if derived == nil {
+		return nil
+	}
+	result := make(map[string]float64, len(derived))
+	for name, value := range derived {
+		result[name] = value
+	}
+	return result
+}
+
+// resampleProcessor buffers the fixed-rate output for consumers that need uniform sampling
+type resampleProcessor struct {
+	mu        sync.Mutex
+	resampler *resampler // nil while resampling is disabled
+	output    []SensorData
+}
+
+// newResampleProcessor creates the resampling stage, disabled until a rate is set
+func newResampleProcessor() *resampleProcessor {
+	return &resampleProcessor{
+		output: make([]SensorData, 0),
+	}
+}
+
+func (p *resampleProcessor) process(sample *SensorData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.resampler == nil {
+		return
+	}
+
+	p.output = append(p.output, p.resampler.push(sample)...)
+	if overflow := len(p.output) - maxResampledSamples; overflow > 0 {
+		p.output = p.output[overflow:]
+	}
+}
+
+// SetResampleRate sets the fixed output rate in Hz; 0 disables resampling
+func (a *App) SetResampleRate(rate float64) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if rate < 0 || rate > maxResampleRate {
+		return fmt.Errorf("rate must be between 0 and %d Hz, got %.2f", maxResampleRate, rate)
+	}
+
+	a.resample.mu.Lock()
+	defer a.resample.mu.Unlock()
+
+	a.resample.output = a.resample.output[:0]
+	if rate == 0 {
+		a.resample.resampler = nil
+		processingLog.Infof("Resampling disabled")
+		return nil
+	}
+	a.resample.resampler = newResampler(rate)
+
+	processingLog.Infof("Resampling to %.1f Hz", rate)
+	return nil
+}
+
+// GetResampleRate returns the fixed output rate in Hz, 0 when disabled
+func (a *App) GetResampleRate() float64 {
+	a.resample.mu.Lock()
+	defer a.resample.mu.Unlock()
+
+	if a.resample.resampler == nil {
+		return 0
+	}
+	return a.resample.resampler.rate
+}
+
+// ReadResampledData returns all buffered fixed-rate samples and clears the buffer
+func (a *App) ReadResampledData() ([]SensorData, error) {
+	a.resample.mu.Lock()
+	defer a.resample.mu.Unlock()
+
+	if a.resample.resampler == nil {
+		return nil, fmt.Errorf("resampling is disabled")
+	}
+
+	result := make([]SensorData, len(a.resample.output))
+	copy(result, a.resample.output)
+	a.resample.output = a.resample.output[:0]
+	return result, nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Respiratory rate estimation methods
+const (
+	RespirationMethodPeaks    = "peaks"    // Count breaths with the peak detector
+	RespirationMethodSpectral = "spectral" // Dominant frequency of the breathing band
+)
+
+// ChannelRespiratoryRate is the derived channel with the estimated breaths per minute
+const ChannelRespiratoryRate = "rr"
+
+// respirationSpectralRate is the rate in Hz the window is resampled to for the spectral method
+const respirationSpectralRate = 10.0
+
+// RespirationConfig configures respiratory rate estimation
+type RespirationConfig struct {
+	Enabled               bool    `json:"enabled"`
+	Channel               string  `json:"channel"` // Impedance or PPG-derived respiration channel
+	Method                string  `json:"method"`
+	WindowSeconds         float64 `json:"windowSeconds"`         // Analysis window length
+	UpdateIntervalSeconds float64 `json:"updateIntervalSeconds"` // How often the estimate is refreshed
+	MinProminence         float64 `json:"minProminence"`         // Breath detection threshold for the peaks method, 0 adapts to the signal
+	MinRate               float64 `json:"minRate"`               // Lowest plausible rate in breaths/min
+	MaxRate               float64 `json:"maxRate"`               // Highest plausible rate in breaths/min
+}
+
+// defaultRespirationConfig estimates the rate every 5 s over 30 s of the respiration channel
+func defaultRespirationConfig() RespirationConfig {
+	return RespirationConfig{
+		Enabled:               true,
+		Channel:               ChannelValue2,
+		Method:                RespirationMethodPeaks,
+		WindowSeconds:         30,
+		UpdateIntervalSeconds: 5,
+		MinRate:               4,
+		MaxRate:               60,
+	}
+}
+
+// respirationEstimator publishes the respiratory rate as a derived channel,
+// holding the last estimate between updates
+type respirationEstimator struct {
+	mu         sync.Mutex
+	config     RespirationConfig
+	window     *statsTracker // Recent readings, also used for the adaptive threshold
+	peaks      *peakTracker
+	breaths    *timedWindow // Times of the detected breaths
+	lastUpdate time.Time
+	rate       float64
+	hasRate    bool
+}
+
+// newRespirationEstimator creates the respiration stage with the default configuration
+func newRespirationEstimator() *respirationEstimator {
+	e := &respirationEstimator{}
+	e.configure(defaultRespirationConfig())
+	return e
+}
+
+// configure applies a configuration and restarts the estimate
+func (e *respirationEstimator) configure(config RespirationConfig) {
+	span := secondsToDuration(config.WindowSeconds)
+	e.config = config
+	e.window = newStatsTracker(span, nil)
+	e.breaths = newTimedWindow(span)
+	e.peaks = newPeakTracker(PeakDetectorConfig{
+		Channel:            config.Channel,
+		MinProminence:      config.MinProminence,
+		MinDistanceSeconds: 60 / config.MaxRate,
+	})
+	e.lastUpdate = time.Time{}
+	e.hasRate = false
+}
+
+func (e *respirationEstimator) process(sample *SensorData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.config.Enabled {
+		return
+	}
+
+	value, ok := sample.channelValue(e.config.Channel)
+	if ok && !sample.isArtifact(e.config.Channel) {
+		e.window.add(sample.Timestamp, value)
+		if e.config.Method == RespirationMethodPeaks && e.peaks.config.MinProminence > 0 {
+			if peak := e.peaks.push(sample.Timestamp, value); peak != nil {
+				e.breaths.push(peak.Timestamp, peak.Amplitude)
+			}
+		}
+	}
+
+	if sample.Timestamp.Sub(e.lastUpdate) >= secondsToDuration(e.config.UpdateIntervalSeconds) {
+		e.lastUpdate = sample.Timestamp
+		e.update(sample.Timestamp)
+	}
+
+	if e.hasRate {
+		sample.setDerived(ChannelRespiratoryRate, e.rate)
+	}
+}
+
+// update refreshes the rate estimate from the current window
+func (e *respirationEstimator) update(now time.Time) {
+	span := secondsToDuration(e.config.WindowSeconds)
+	if e.window.window.coverage() < span/2 {
+		return
+	}
+
+	var rate float64
+	switch e.config.Method {
+	case RespirationMethodSpectral:
+		signal := resampleUniform(e.window.window.values, respirationSpectralRate)
+		detrend(signal)
+		applyHann(signal)
+		freq, power := dominantFrequency(signal, respirationSpectralRate, e.config.MinRate/60, e.config.MaxRate/60, 0.005)
+		if power == 0 {
+			return
+		}
+		rate = freq * 60
+
+	default:
+		// Adapt the breath threshold to the current amplitude
+		if e.config.MinProminence == 0 {
+			e.peaks.config.MinProminence = e.window.snapshot().StdDev
+		}
+		e.breaths.evict(now)
+		if e.breaths.len() < 2 {
+			return
+		}
+		first, last := e.breaths.values[0].t, e.breaths.values[e.breaths.len()-1].t
+		rate = float64(e.breaths.len()-1) / last.Sub(first).Minutes()
+	}
+
+	if rate < e.config.MinRate || rate > e.config.MaxRate {
+		return
+	}
+	e.rate = rate
+	e.hasRate = true
+}
+
+// GetRespirationConfig returns the respiratory rate estimation settings
+func (a *App) GetRespirationConfig() RespirationConfig {
+	a.respiration.mu.Lock()
+	defer a.respiration.mu.Unlock()
+
+	return a.respiration.config
+}
+
+// SetRespirationConfig replaces the respiratory rate estimation settings
+func (a *App) SetRespirationConfig(config RespirationConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if config.Channel == "" {
+		return fmt.Errorf("channel is required")
+	}
+	if config.Method != RespirationMethodPeaks && config.Method != RespirationMethodSpectral {
+		return fmt.Errorf("unknown respiration method '%s'", config.Method)
+	}
+	if config.WindowSeconds <= 0 || config.UpdateIntervalSeconds <= 0 {
+		return fmt.Errorf("window and update interval must be positive")
+	}
+	if config.MinRate <= 0 || config.MaxRate <= config.MinRate {
+		return fmt.Errorf("invalid rate range %.1f-%.1f breaths/min", config.MinRate, config.MaxRate)
+	}
+	if config.MinProminence < 0 {
+		return fmt.Errorf("minimum prominence must not be negative")
+	}
+
+	a.respiration.mu.Lock()
+	defer a.respiration.mu.Unlock()
+
+	a.respiration.configure(config)
+
+	processingLog.Infof("Respiratory rate estimation: %s method on channel %s", config.Method, config.Channel)
+	return nil
+}
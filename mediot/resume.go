@@ -0,0 +1,150 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"go.bug.st/serial/enumerator"
+)
+
+// Resuming after a restart
+const (
+	lastConnectionFile  = "last_connection.json"
+	autoConnectInterval = 5 * time.Second // USB devices may enumerate well after login
+	autoConnectTimeout  = 2 * time.Minute
+)
+
+// LastConnection is the last successful connection and whether it was
+// being recorded, restored at startup when auto-connect is on
+type LastConnection struct {
+	Port         string    `json:"port"`
+	BaudRate     int       `json:"baudRate"`
+	DeviceID     string    `json:"deviceId"`               // Found on whatever port it enumerates on, for USB devices
+	Recording    bool      `json:"recording"`              // Recording was running when the app last stopped
+	RecordingDir string    `json:"recordingDir,omitempty"` // Folder it was recording to
+	At           time.Time `json:"at"`
+}
+
+// resumeState remembers the last connection and the recording state
+type resumeState struct {
+	mu   sync.Mutex
+	last LastConnection
+}
+
+// newResumeState loads the last connection
+func newResumeState() *resumeState {
+	r := &resumeState{}
+	if err := loadJSONFile(lastConnectionFile, &r.last); err != nil {
+		appLog.Errorf("Error loading last connection: %v", err)
+	}
+	return r
+}
+
+// update changes the last connection and persists it
+func (r *resumeState) update(change func(last *LastConnection)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	change(&r.last)
+	if err := saveJSONFile(lastConnectionFile, r.last); err != nil {
+		appLog.Errorf("Error saving last connection: %v", err)
+	}
+}
+
+// get returns the last connection
+func (r *resumeState) get() LastConnection {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.last
+}
+
+// connected records a successful connection
+func (r *resumeState) connected(port string, baudRate int, deviceID string) {
+	r.update(func(last *LastConnection) {
+		last.Port = port
+		last.BaudRate = baudRate
+		last.DeviceID = deviceID
+		last.At = time.Now()
+	})
+}
+
+// recording records whether samples are being recorded, and where
+func (r *resumeState) recording(active bool, dir string) {
+	r.update(func(last *LastConnection) {
+		last.Recording = active
+		last.RecordingDir = dir
+	})
+}
+
+// devicePort returns the port a USB device is enumerated on now, empty if
+// it is not plugged in or has no USB identity
+func devicePort(deviceID string) string {
+	ports, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		deviceLog.Errorf("Error enumerating serial ports: %v", err)
+		return ""
+	}
+	for _, port := range ports {
+		if port.IsUSB && port.SerialNumber != "" && usbDeviceID(port) == deviceID {
+			return port.Name
+		}
+	}
+	return ""
+}
+
+// autoConnectLoop restores the last connection at startup when the settings
+// ask for it: recording resumes first, so the first session is recorded,
+// then the last device is connected, retrying until it shows up. The MQTT
+// sink and the plugins resume on their own from their configuration.
+func (a *App) autoConnectLoop() {
+	if !a.GetSettings().AutoConnect {
+		return
+	}
+	last := a.resume.get()
+	if last.Port == "" && last.DeviceID == "" {
+		appLog.Infof("Auto-connect is on but no device was connected yet")
+		return
+	}
+
+	if last.Recording {
+		if _, err := a.startRecording(last.RecordingDir); err != nil {
+			appLog.Errorf("Error resuming recording: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(autoConnectTimeout)
+	for {
+		// A connection made meanwhile, by the user or the simulator, wins
+		if state := a.GetConnectionStatus().State; state != ConnectionDisconnected && state != ConnectionError {
+			return
+		}
+
+		port := last.Port
+		if found := devicePort(last.DeviceID); found != "" {
+			port = found
+		}
+		result := a.connect(port, last.BaudRate)
+		if result.Success {
+			appLog.Infof("Auto-connected to %s", port)
+			return
+		}
+		if time.Now().After(deadline) {
+			serialLog.Errorf("Auto-connect to %s gave up after %v: %s", port, autoConnectTimeout, result.Message)
+			return
+		}
+		serialLog.Warnf("Auto-connect to %s failed, retrying in %v: %s", port, autoConnectInterval, result.Message)
+
+		select {
+		case <-a.quit:
+			return
+		case <-time.After(autoConnectInterval):
+		}
+	}
+}
+
+// GetLastConnection returns the connection restored at startup when
+// auto-connect is on
+func (a *App) GetLastConnection() LastConnection {
+	return a.resume.get()
+}
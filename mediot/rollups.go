@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// rollupLevel is a supported rollup resolution and how many buckets of it are kept
+type rollupLevel struct {
+	resolution time.Duration
+	retention  int
+}
+
+// rollupLevels are the supported resolutions: an hour of 1 s buckets, six
+// hours of 10 s buckets and a day of 1 min buckets
+var rollupLevels = []rollupLevel{
+	{resolution: time.Second, retention: 3600},
+	{resolution: 10 * time.Second, retention: 2160},
+	{resolution: time.Minute, retention: 1440},
+}
+
+// RollupConfig selects the rollup resolutions maintained for a channel
+type RollupConfig struct {
+	Enabled     bool      `json:"enabled"`
+	Resolutions []float64 `json:"resolutions"` // Bucket lengths in seconds, each one of 1, 10 or 60
+}
+
+// RollupSettings holds the default rollup configuration and per-channel overrides
+type RollupSettings struct {
+	Default   RollupConfig            `json:"default"`
+	Overrides map[string]RollupConfig `json:"overrides"`
+}
+
+// RollupBucket aggregates the readings of a channel over one bucket of time
+type RollupBucket struct {
+	Start   time.Time `json:"start"`
+	Min     float64   `json:"min"`
+	Mean    float64   `json:"mean"`
+	Max     float64   `json:"max"`
+	Count   int       `json:"count"`
+	Partial bool      `json:"partial"` // The bucket is still collecting readings
+}
+
+// defaultRollupConfig maintains every resolution
+func defaultRollupConfig() RollupConfig {
+	return RollupConfig{Enabled: true, Resolutions: []float64{1, 10, 60}}
+}
+
+// rollupSeries accumulates the buckets of one channel at one resolution
+type rollupSeries struct {
+	level   rollupLevel
+	buckets []RollupBucket // Closed buckets, oldest first
+	current *RollupBucket
+	sum     float64
+}
+
+// add accounts for a reading, closing the current bucket when the reading belongs to a later one
+func (s *rollupSeries) add(at time.Time, v float64) {
+	start := at.Truncate(s.level.resolution)
+	if s.current != nil && !start.Equal(s.current.Start) {
+		s.close()
+	}
+	if s.current == nil {
+		s.current = &RollupBucket{Start: start, Min: v, Max: v}
+		s.sum = 0
+	}
+
+	c := s.current
+	c.Min = math.Min(c.Min, v)
+	c.Max = math.Max(c.Max, v)
+	c.Count++
+	s.sum += v
+	c.Mean = s.sum / float64(c.Count)
+}
+
+// close moves the current bucket to the closed ones, dropping the oldest beyond the retention
+func (s *rollupSeries) close() {
+	s.buckets = append(s.buckets, *s.current)
+	if len(s.buckets) > s.level.retention {
+		// Reallocate instead of reslicing so the backing array doesn't grow forever
+		s.buckets = append(make([]RollupBucket, 0, s.level.retention), s.buckets[len(s.buckets)-s.level.retention:]...)
+	}
+	s.current = nil
+}
+
+// since returns the buckets starting at or after the cutoff, the current one included
+func (s *rollupSeries) since(cutoff time.Time) []RollupBucket {
+	i := len(s.buckets)
+	for i > 0 && !s.buckets[i-1].Start.Before(cutoff) {
+		i--
+	}
+	result := make([]RollupBucket, 0, len(s.buckets)-i+1)
+	result = append(result, s.buckets[i:]...)
+	if s.current != nil && !s.current.Start.Before(cutoff) {
+		current := *s.current
+		current.Partial = true
+		result = append(result, current)
+	}
+	return result
+}
+
+// rollupProcessor maintains min/mean/max rollups of every channel so long
+// trend charts don't need the raw samples
+type rollupProcessor struct {
+	mu       sync.Mutex
+	settings RollupSettings
+	series   map[string]map[time.Duration]*rollupSeries
+}
+
+// newRollupProcessor creates the rollup stage applying the default config to every channel
+func newRollupProcessor() *rollupProcessor {
+	return &rollupProcessor{
+		settings: RollupSettings{
+			Default:   defaultRollupConfig(),
+			Overrides: make(map[string]RollupConfig),
+		},
+		series: make(map[string]map[time.Duration]*rollupSeries),
+	}
+}
+
+// configFor returns the effective configuration of a channel
+func (p *rollupProcessor) configFor(channel string) RollupConfig {
+	if config, ok := p.settings.Overrides[channel]; ok {
+		return config
+	}
+	return p.settings.Default
+}
+
+func (p *rollupProcessor) process(sample *SensorData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sample.forEachChannel(func(name string, value float64) {
+		if sample.isArtifact(name) {
+			return
+		}
+		series, ok := p.series[name]
+		if !ok {
+			config := p.configFor(name)
+			if !config.Enabled {
+				return
+			}
+			series = make(map[time.Duration]*rollupSeries)
+			for _, seconds := range config.Resolutions {
+				level, _ := findRollupLevel(seconds)
+				series[level.resolution] = &rollupSeries{level: level}
+			}
+			p.series[name] = series
+		}
+		for _, s := range series {
+			s.add(sample.Timestamp, value)
+		}
+	})
+}
+
+// findRollupLevel returns the supported level with the given resolution in seconds
+func findRollupLevel(seconds float64) (rollupLevel, bool) {
+	for _, level := range rollupLevels {
+		if level.resolution == secondsToDuration(seconds) {
+			return level, true
+		}
+	}
+	return rollupLevel{}, false
+}
+
+// validateRollupConfig checks that every resolution is supported
+func validateRollupConfig(config RollupConfig) error {
+	if config.Enabled && len(config.Resolutions) == 0 {
+		return fmt.Errorf("at least one resolution is required")
+	}
+	for _, seconds := range config.Resolutions {
+		if _, ok := findRollupLevel(seconds); !ok {
+			return fmt.Errorf("unsupported rollup resolution %.2f s, use 1, 10 or 60", seconds)
+		}
+	}
+	return nil
+}
+
+// GetRollupSettings returns the default rollup config and per-channel overrides
+func (a *App) GetRollupSettings() RollupSettings {
+	a.rollups.mu.Lock()
+	defer a.rollups.mu.Unlock()
+
+	overrides := make(map[string]RollupConfig, len(a.rollups.settings.Overrides))
+	for channel, config := range a.rollups.settings.Overrides {
+		overrides[channel] = config
+	}
+	return RollupSettings{Default: a.rollups.settings.Default, Overrides: overrides}
+}
+
+// SetRollupConfig configures the rollups of a channel, or the default for
+// all channels without an override when channel is empty. The affected
+// channels restart their rollups.
+func (a *App) SetRollupConfig(channel string, config RollupConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := validateRollupConfig(config); err != nil {
+		return err
+	}
+
+	a.rollups.mu.Lock()
+	defer a.rollups.mu.Unlock()
+
+	if channel == "" {
+		a.rollups.settings.Default = config
+		for name := range a.rollups.series {
+			if _, ok := a.rollups.settings.Overrides[name]; !ok {
+				delete(a.rollups.series, name)
+			}
+		}
+	} else {
+		a.rollups.settings.Overrides[channel] = config
+		delete(a.rollups.series, channel)
+	}
+	return nil
+}
+
+// ClearRollupOverride makes a channel follow the default rollup config again
+func (a *App) ClearRollupOverride(channel string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.rollups.mu.Lock()
+	defer a.rollups.mu.Unlock()
+
+	delete(a.rollups.settings.Overrides, channel)
+	delete(a.rollups.series, channel)
+	return nil
+}
+
+// GetRollups returns the min/mean/max buckets of a channel at the given
+// resolution covering the last windowSeconds, oldest first. The last bucket
+// is marked partial while it is still collecting readings.
+func (a *App) GetRollups(channel string, resolutionSeconds float64, windowSeconds float64) ([]RollupBucket, error) {
+	level, ok := findRollupLevel(resolutionSeconds)
+	if !ok {
+		return nil, fmt.Errorf("unsupported rollup resolution %.2f s, use 1, 10 or 60", resolutionSeconds)
+	}
+	if windowSeconds <= 0 {
+		return nil, fmt.Errorf("window must be positive, got %.2f s", windowSeconds)
+	}
+
+	a.rollups.mu.Lock()
+	defer a.rollups.mu.Unlock()
+
+	series, ok := a.rollups.series[channel][level.resolution]
+	if !ok {
+		return nil, fmt.Errorf("no %.0f s rollups for channel '%s'", resolutionSeconds, channel)
+	}
+	cutoff := time.Now().Add(-secondsToDuration(windowSeconds)).Truncate(level.resolution)
+	return series.since(cutoff), nil
+}
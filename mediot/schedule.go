@@ -0,0 +1,457 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recording schedules
+const (
+	recordingSchedulesFile = "recording_schedules.json"
+	scheduleCheckInterval  = 10 * time.Second
+	scheduleClockForm      = "15:04"
+	maxScheduleDuration    = 7 * 24 * time.Hour
+)
+
+// How a scheduled recording starts
+const (
+	ScheduleStartTime    = "time"    // At StartTime
+	ScheduleStartConnect = "connect" // When a session starts, from a device or the simulator
+)
+
+// How a scheduled recording stops
+const (
+	ScheduleStopDuration = "duration" // After DurationMinutes
+	ScheduleStopTime     = "time"     // At StopTime, the next day if it is not after the start
+)
+
+// RecordingSchedule starts and stops a recording unattended, e.g. every
+// night from 22:00 to 06:00 for a sleep study. Times are local.
+type RecordingSchedule struct {
+	ID              int64     `json:"id"`
+	Name            string    `json:"name"`
+	Enabled         bool      `json:"enabled"`
+	Start           string    `json:"start"`
+	StartTime       string    `json:"startTime,omitempty"` // HH:MM
+	Stop            string    `json:"stop"`
+	DurationMinutes int       `json:"durationMinutes,omitempty"`
+	StopTime        string    `json:"stopTime,omitempty"` // HH:MM
+	Daily           bool      `json:"daily"`              // Repeat every day; otherwise the schedule disables itself after one recording
+	Dir             string    `json:"dir,omitempty"`      // Empty for the recording folder of the settings
+	LastRun         time.Time `json:"lastRun"`            // When it last started a recording
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// RecordingScheduleStatus is a schedule and what it is doing now
+type RecordingScheduleStatus struct {
+	Schedule  RecordingSchedule `json:"schedule"`
+	Running   bool              `json:"running"`   // The current recording was started by this schedule
+	StopAt    time.Time         `json:"stopAt"`    // When the current recording stops, while running
+	NextStart time.Time         `json:"nextStart"` // Zero for schedules started on connect, or that will not run again
+}
+
+// recordingScheduler runs the recording schedules. It only stops the
+// recordings it started itself: a recording the user started, or restarted
+// after stopping a scheduled one, is left alone.
+type recordingScheduler struct {
+	mu        sync.Mutex
+	schedules []RecordingSchedule
+	handled   map[int64]time.Time // Start of the last time window each schedule acted on in this run
+	owner     int64               // Schedule that started the running recording, 0 if none
+	started   time.Time           // Start of that recording, to tell it from one started later
+	stopAt    time.Time
+}
+
+// newRecordingScheduler loads the recording schedules
+func newRecordingScheduler() *recordingScheduler {
+	s := &recordingScheduler{schedules: make([]RecordingSchedule, 0), handled: make(map[int64]time.Time)}
+	if err := loadJSONFile(recordingSchedulesFile, &s.schedules); err != nil {
+		appLog.Errorf("Error loading recording schedules: %v", err)
+	}
+	if s.schedules == nil {
+		s.schedules = make([]RecordingSchedule, 0)
+	}
+	return s
+}
+
+// save persists the schedules; the caller holds the lock
+func (s *recordingScheduler) save() error {
+	return saveJSONFile(recordingSchedulesFile, s.schedules)
+}
+
+// clockAt returns the time of day clock, in HH:MM, on the day of t
+func clockAt(t time.Time, clock string) time.Time {
+	parsed, err := time.Parse(scheduleClockForm, clock)
+	if err != nil {
+		return time.Time{}
+	}
+	year, month, day := t.Date()
+	return time.Date(year, month, day, parsed.Hour(), parsed.Minute(), 0, 0, t.Location())
+}
+
+// sameDay reports whether two times fall on the same local day
+func sameDay(a, b time.Time) bool {
+	ya, ma, da := a.Local().Date()
+	yb, mb, db := b.Local().Date()
+	return ya == yb && ma == mb && da == db
+}
+
+// validate checks a schedule before it is saved
+func (r RecordingSchedule) validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("schedule name must not be empty")
+	}
+	switch r.Start {
+	case ScheduleStartTime:
+		if _, err := time.Parse(scheduleClockForm, r.StartTime); err != nil {
+			return fmt.Errorf("invalid start time '%s', expected HH:MM", r.StartTime)
+		}
+	case ScheduleStartConnect:
+	default:
+		return fmt.Errorf("unknown schedule start '%s'", r.Start)
+	}
+	switch r.Stop {
+	case ScheduleStopDuration:
+		if d := time.Duration(r.DurationMinutes) * time.Minute; d <= 0 || d > maxScheduleDuration {
+			return fmt.Errorf("duration must be between 1 minute and %v", maxScheduleDuration)
+		}
+	case ScheduleStopTime:
+		if _, err := time.Parse(scheduleClockForm, r.StopTime); err != nil {
+			return fmt.Errorf("invalid stop time '%s', expected HH:MM", r.StopTime)
+		}
+		if r.Start == ScheduleStartTime && r.StartTime == r.StopTime {
+			return fmt.Errorf("start and stop time must differ")
+		}
+	default:
+		return fmt.Errorf("unknown schedule stop '%s'", r.Stop)
+	}
+	return nil
+}
+
+// stopAfter returns when a recording the schedule started at start stops
+func (r RecordingSchedule) stopAfter(start time.Time) time.Time {
+	if r.Stop == ScheduleStopDuration {
+		return start.Add(time.Duration(r.DurationMinutes) * time.Minute)
+	}
+	stop := clockAt(start, r.StopTime)
+	if !stop.After(start) {
+		stop = stop.AddDate(0, 0, 1)
+	}
+	return stop
+}
+
+// window returns the time window of a schedule started at a time that now
+// falls in, if any
+func (r RecordingSchedule) window(now time.Time) (time.Time, time.Time, bool) {
+	start := clockAt(now, r.StartTime)
+	if start.After(now) {
+		start = start.AddDate(0, 0, -1)
+	}
+	end := r.stopAfter(start)
+	return start, end, now.Before(end)
+}
+
+// due reports whether a schedule started at a time should start a
+// recording now, and the window it is for; the caller holds the lock. A
+// window that opened while the app was not running is caught up, unless
+// it opened before the schedule was saved.
+func (s *recordingScheduler) due(r RecordingSchedule, now time.Time) (time.Time, time.Time, bool) {
+	if !r.Enabled || r.Start != ScheduleStartTime {
+		return time.Time{}, time.Time{}, false
+	}
+	start, end, ok := r.window(now)
+	if !ok || start.Before(r.UpdatedAt.Truncate(time.Minute)) || s.handled[r.ID].Equal(start) {
+		return time.Time{}, time.Time{}, false
+	}
+	if !r.Daily && !r.LastRun.IsZero() && !r.LastRun.Equal(start) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// nextStart returns when a schedule started at a time starts its next
+// recording; the caller holds the lock
+func (s *recordingScheduler) nextStart(r RecordingSchedule, now time.Time) time.Time {
+	if !r.Enabled || r.Start != ScheduleStartTime || s.owner == r.ID {
+		return time.Time{}
+	}
+	if start, _, ok := s.due(r, now); ok {
+		return start
+	}
+	if !r.Daily && !r.LastRun.IsZero() {
+		return time.Time{}
+	}
+	next := clockAt(now, r.StartTime)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// list returns the schedules and what they are doing
+func (s *recordingScheduler) list(now time.Time) []RecordingScheduleStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]RecordingScheduleStatus, 0, len(s.schedules))
+	for _, r := range s.schedules {
+		status := RecordingScheduleStatus{Schedule: r, NextStart: s.nextStart(r, now)}
+		if s.owner == r.ID {
+			status.Running = true
+			status.StopAt = s.stopAt
+		}
+		result = append(result, status)
+	}
+	return result
+}
+
+// find returns the index of a schedule; the caller holds the lock
+func (s *recordingScheduler) find(id int64) int {
+	for i, r := range s.schedules {
+		if r.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// put adds or replaces a schedule. Saving a schedule arms it again, so a
+// one-time schedule can be reused by saving it enabled.
+func (s *recordingScheduler) put(r RecordingSchedule) (RecordingSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.Name = strings.TrimSpace(r.Name)
+	r.LastRun = time.Time{}
+	r.UpdatedAt = time.Now()
+	schedules := append([]RecordingSchedule{}, s.schedules...)
+	if r.ID == 0 {
+		for _, existing := range schedules {
+			if existing.ID > r.ID {
+				r.ID = existing.ID
+			}
+		}
+		r.ID++
+		schedules = append(schedules, r)
+	} else if i := s.find(r.ID); i >= 0 {
+		schedules[i] = r
+	} else {
+		return RecordingSchedule{}, fmt.Errorf("recording schedule %d not found", r.ID)
+	}
+
+	previous := s.schedules
+	s.schedules = schedules
+	if err := s.save(); err != nil {
+		s.schedules = previous
+		return RecordingSchedule{}, err
+	}
+	delete(s.handled, r.ID)
+	return r, nil
+}
+
+// remove deletes a schedule. A recording it started keeps running until
+// the user stops it.
+func (s *recordingScheduler) remove(id int64) (RecordingSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.find(id)
+	if i < 0 {
+		return RecordingSchedule{}, fmt.Errorf("recording schedule %d not found", id)
+	}
+	removed := s.schedules[i]
+	previous := s.schedules
+	s.schedules = append(append([]RecordingSchedule{}, s.schedules[:i]...), s.schedules[i+1:]...)
+	if err := s.save(); err != nil {
+		s.schedules = previous
+		return RecordingSchedule{}, err
+	}
+	delete(s.handled, id)
+	if s.owner == id {
+		s.owner = 0
+	}
+	return removed, nil
+}
+
+// claim marks a schedule as having started a recording in the window
+// from start; the caller holds the lock
+func (s *recordingScheduler) claim(i int, start time.Time) {
+	s.handled[s.schedules[i].ID] = start
+	s.schedules[i].LastRun = start
+	if err := s.save(); err != nil {
+		appLog.Errorf("Error saving recording schedules: %v", err)
+	}
+}
+
+// runSchedules starts and stops the scheduled recordings that are due
+func (a *App) runSchedules(now time.Time) {
+	recording := a.recorder.status()
+
+	s := a.schedules
+	s.mu.Lock()
+	if s.owner != 0 && (!recording.Active || !recording.StartedAt.Equal(s.started)) {
+		// Stopped by the user, or by an error
+		s.owner = 0
+	}
+	if s.owner != 0 {
+		owner, stopAt := s.owner, s.stopAt
+		s.mu.Unlock()
+		if now.Before(stopAt) {
+			return
+		}
+		a.stopScheduledRecording(owner)
+		return
+	}
+
+	changed := false
+	for i, r := range s.schedules {
+		// One-time schedules are disabled once their recording is over
+		if r.Enabled && !r.Daily && !r.LastRun.IsZero() && !now.Before(r.stopAfter(r.LastRun)) {
+			s.schedules[i].Enabled = false
+			changed = true
+		}
+	}
+	if changed {
+		if err := s.save(); err != nil {
+			appLog.Errorf("Error saving recording schedules: %v", err)
+		}
+	}
+
+	for i, r := range s.schedules {
+		start, end, ok := s.due(r, now)
+		if !ok {
+			continue
+		}
+		s.claim(i, start)
+		s.mu.Unlock()
+		a.startScheduledRecording(r, end)
+		return
+	}
+	s.mu.Unlock()
+}
+
+// scheduleOnConnect starts the recording of a schedule that starts when a
+// session starts. A daily one starts on the first session of each day.
+func (a *App) scheduleOnConnect() {
+	// A recording already running, scheduled or not, is left alone and
+	// the schedule waits for a later session
+	if a.recorder.status().Active {
+		return
+	}
+
+	now := time.Now()
+	s := a.schedules
+	s.mu.Lock()
+	for i, r := range s.schedules {
+		if !r.Enabled || r.Start != ScheduleStartConnect || !r.LastRun.IsZero() && (!r.Daily || sameDay(r.LastRun, now)) {
+			continue
+		}
+		s.claim(i, now)
+		s.mu.Unlock()
+		a.startScheduledRecording(r, r.stopAfter(now))
+		return
+	}
+	s.mu.Unlock()
+}
+
+// startScheduledRecording starts the recording of a schedule, which stops
+// at stopAt
+func (a *App) startScheduledRecording(r RecordingSchedule, stopAt time.Time) {
+	status, err := a.startRecording(r.Dir)
+	if err != nil {
+		appLog.Warnf("Recording schedule %s not started: %v", r.Name, err)
+		return
+	}
+
+	a.schedules.mu.Lock()
+	a.schedules.owner = r.ID
+	a.schedules.started = status.StartedAt
+	a.schedules.stopAt = stopAt
+	a.schedules.mu.Unlock()
+
+	appLog.Infof("Recording schedule %s started, stopping at %s", r.Name, stopAt.Format(time.RFC3339))
+	a.audit.record("", AuditRecording, fmt.Sprintf("Recording schedule %s started recording until %s", r.Name, stopAt.Format(time.RFC3339)))
+	a.emit(EventRecordingSchedule, a.schedules.list(time.Now()))
+}
+
+// stopScheduledRecording stops the recording a schedule started
+func (a *App) stopScheduledRecording(id int64) {
+	a.schedules.mu.Lock()
+	name := ""
+	if i := a.schedules.find(id); i >= 0 {
+		name = a.schedules.schedules[i].Name
+	}
+	a.schedules.owner = 0
+	a.schedules.mu.Unlock()
+
+	if _, err := a.stopRecording(); err != nil {
+		appLog.Errorf("Error stopping recording of schedule %s: %v", name, err)
+	} else {
+		appLog.Infof("Recording schedule %s stopped", name)
+		a.audit.record("", AuditRecording, fmt.Sprintf("Recording schedule %s stopped recording", name))
+	}
+	a.emit(EventRecordingSchedule, a.schedules.list(time.Now()))
+}
+
+// recordingScheduleLoop runs the recording schedules while the app runs
+func (a *App) recordingScheduleLoop() {
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-time.After(scheduleCheckInterval):
+		}
+		if atRest.locked() {
+			continue
+		}
+		a.runSchedules(time.Now())
+	}
+}
+
+// GetRecordingSchedules returns the recording schedules and what they are
+// doing
+func (a *App) GetRecordingSchedules() []RecordingScheduleStatus {
+	return a.schedules.list(time.Now())
+}
+
+// SaveRecordingSchedule adds a recording schedule, when its ID is 0, or
+// replaces the one with its ID
+func (a *App) SaveRecordingSchedule(schedule RecordingSchedule) (RecordingSchedule, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return RecordingSchedule{}, err
+	}
+	if err := schedule.validate(); err != nil {
+		return RecordingSchedule{}, err
+	}
+
+	saved, err := a.schedules.put(schedule)
+	if err != nil {
+		return RecordingSchedule{}, err
+	}
+	if saved.Enabled {
+		telemetry.count(FeatureSchedule)
+	}
+	appLog.Infof("Recording schedule %s saved", saved.Name)
+	a.audit.record("", AuditRecording, fmt.Sprintf("Recording schedule %s saved", saved.Name))
+	a.emit(EventRecordingSchedule, a.schedules.list(time.Now()))
+	return saved, nil
+}
+
+// DeleteRecordingSchedule removes a recording schedule. A recording it
+// started keeps running.
+func (a *App) DeleteRecordingSchedule(id int64) error {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return err
+	}
+	removed, err := a.schedules.remove(id)
+	if err != nil {
+		return err
+	}
+
+	appLog.Infof("Recording schedule %s deleted", removed.Name)
+	a.audit.record("", AuditRecording, fmt.Sprintf("Recording schedule %s deleted", removed.Name))
+	a.emit(EventRecordingSchedule, a.schedules.list(time.Now()))
+	return nil
+}
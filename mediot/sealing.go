@@ -0,0 +1,336 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// sealKeyFile stores the session signing key. The private half is kept in
+// the OS keyring when one is available.
+const sealKeyFile = "seal_key.json"
+
+// secretSealKey is the keyring entry of the private signing key
+const secretSealKey = "session-seal-key"
+
+// SessionSeal makes a finished session tamper-evident. Hash ends a chain
+// over the session metadata with its annotations, the samples recorded
+// during it and the alarms raised during it, and Signature signs it with
+// the installation's Ed25519 key.
+type SessionSeal struct {
+	SealedAt  time.Time `json:"sealedAt"`
+	Samples   int64     `json:"samples"` // Recorded samples in the chain
+	Alarms    int       `json:"alarms"`  // Alarms in the chain
+	Hash      string    `json:"hash"`
+	Signature string    `json:"signature"`
+	PublicKey string    `json:"publicKey"` // Key the seal was signed with
+}
+
+// SessionVerification is the result of checking a session against its seal
+type SessionVerification struct {
+	ID       int64     `json:"id"`
+	Sealed   bool      `json:"sealed"`
+	Valid    bool      `json:"valid"`
+	Problem  string    `json:"problem,omitempty"` // Why verification failed
+	SealedAt time.Time `json:"sealedAt"`
+}
+
+// sealedAlarm is the part of an alarm record fixed when it is raised.
+// Acknowledgments and silences may follow after the session and are not sealed.
+type sealedAlarm struct {
+	ID       int64     `json:"id"`
+	Channel  string    `json:"channel"`
+	Value    float64   `json:"value"`
+	Limit    float64   `json:"limit"`
+	RaisedAt time.Time `json:"raisedAt"`
+}
+
+// sealKey is the persisted signing key, hex-encoded
+type sealKey struct {
+	PublicKey  string `json:"publicKey"`
+	PrivateKey string `json:"privateKey,omitempty"`
+}
+
+// sessionSealer signs finished sessions with a key generated on first use
+type sessionSealer struct {
+	mu  sync.Mutex
+	key ed25519.PrivateKey
+}
+
+// newSessionSealer creates the sealer; the key is loaded on first use
+func newSessionSealer() *sessionSealer {
+	return &sessionSealer{}
+}
+
+// signingKey returns the installation's signing key, loading or creating it
+func (s *sessionSealer) signingKey() (ed25519.PrivateKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.key != nil {
+		return s.key, nil
+	}
+
+	var stored sealKey
+	if err := loadJSONFile(sealKeyFile, &stored); err != nil {
+		return nil, err
+	}
+	moved := secrets.restore(secretSealKey, &stored.PrivateKey)
+	if stored.PrivateKey == "" && stored.PublicKey != "" {
+		// A new key would make every earlier seal look foreign
+		return nil, fmt.Errorf("the private session signing key could not be read from the keyring; "+
+			"sessions stay unsealed until it can, or until %s is deleted to start a new key", sealKeyFile)
+	}
+	if stored.PrivateKey != "" {
+		key, err := hex.DecodeString(stored.PrivateKey)
+		if err != nil || len(key) != ed25519.PrivateKeySize {
+			return nil, fmt.Errorf("invalid session signing key")
+		}
+		s.key = key
+		if moved {
+			stored.PrivateKey = ""
+			if err := saveJSONFile(sealKeyFile, stored); err != nil {
+				securityLog.Errorf("Error saving session signing key: %v", err)
+			}
+		}
+		return s.key, nil
+	}
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %v", err)
+	}
+	stored = sealKey{PublicKey: hex.EncodeToString(public), PrivateKey: hex.EncodeToString(private)}
+	if secrets.keep(secretSealKey, stored.PrivateKey) {
+		stored.PrivateKey = ""
+	}
+	if err := saveJSONFile(sealKeyFile, stored); err != nil {
+		return nil, err
+	}
+	s.key = private
+	securityLog.Infof("Session signing key created")
+	return s.key, nil
+}
+
+// publicKey returns the public half of the installation's signing key,
+// empty if none was created yet. Verifying a seal needs no more.
+func (s *sessionSealer) publicKey() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.key != nil {
+		return hex.EncodeToString(s.key.Public().(ed25519.PublicKey)), nil
+	}
+	var stored sealKey
+	if err := loadJSONFile(sealKeyFile, &stored); err != nil {
+		return "", err
+	}
+	return stored.PublicKey, nil
+}
+
+// recordedSamples hashes the JSON lines of a session's recording files in
+// order and counts them. Sealed lines are hashed decrypted, so the hash
+// does not depend on encryption at rest.
+func recordedSamples(files []string) ([]byte, int64, error) {
+	hash := sha256.New()
+	var samples int64
+	for _, path := range files {
+		err := scanRecordingFile(path, func(line []byte) error {
+			hash.Write(line)
+			hash.Write([]byte{'\n'})
+			samples++
+			return nil
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read recording %s: %v", filepath.Base(path), err)
+		}
+	}
+	return hash.Sum(nil), samples, nil
+}
+
+// sessionHash computes the hash chain of a session: the metadata without
+// the seal, then the hash of its recorded samples, then each alarm in ID
+// order, each chained onto the previous link
+func sessionHash(session SessionInfo, samples []byte, alarms []AlarmRecord) ([]byte, error) {
+	session.Seal = nil
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+	link := sha256.Sum256(data)
+	link = sha256.Sum256(append(link[:], samples...))
+
+	sort.Slice(alarms, func(i, j int) bool { return alarms[i].ID < alarms[j].ID })
+	for _, alarm := range alarms {
+		data, err := json.Marshal(sealedAlarm{
+			ID:       alarm.ID,
+			Channel:  alarm.Channel,
+			Value:    alarm.Value,
+			Limit:    alarm.Limit,
+			RaisedAt: alarm.RaisedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		link = sha256.Sum256(append(link[:], data...))
+	}
+	return link[:], nil
+}
+
+// sessionAlarms returns the alarms raised during a session
+func (a *App) sessionAlarms(session SessionInfo) []AlarmRecord {
+	a.alarmLog.mu.Lock()
+	defer a.alarmLog.mu.Unlock()
+
+	alarms := make([]AlarmRecord, 0)
+	for _, record := range a.alarmLog.records {
+		if inPatientSession(record, []SessionInfo{session}) {
+			alarms = append(alarms, record)
+		}
+	}
+	return alarms
+}
+
+// findSession returns a session by ID; the caller holds the lock
+func (l *sessionLog) findSession(id int64) (*SessionInfo, bool) {
+	for i := range l.sessions {
+		if l.sessions[i].ID == id {
+			return &l.sessions[i], true
+		}
+	}
+	return nil, false
+}
+
+// sealSession signs a finished session and stores the seal with it
+func (a *App) sealSession(id int64) error {
+	key, err := a.sealer.signingKey()
+	if err != nil {
+		return err
+	}
+
+	a.sessions.mu.Lock()
+	session, ok := a.sessions.findSession(id)
+	if !ok {
+		a.sessions.mu.Unlock()
+		return fmt.Errorf("unknown session %d", id)
+	}
+	snapshot := *session
+	a.sessions.mu.Unlock()
+
+	files, err := a.sessionRecordings(id)
+	if err != nil {
+		return err
+	}
+	recorded, samples, err := recordedSamples(files)
+	if err != nil {
+		return err
+	}
+	alarms := a.sessionAlarms(snapshot)
+	hash, err := sessionHash(snapshot, recorded, alarms)
+	if err != nil {
+		return err
+	}
+	seal := &SessionSeal{
+		SealedAt:  time.Now(),
+		Samples:   samples,
+		Alarms:    len(alarms),
+		Hash:      hex.EncodeToString(hash),
+		Signature: hex.EncodeToString(ed25519.Sign(key, hash)),
+		PublicKey: hex.EncodeToString(key.Public().(ed25519.PublicKey)),
+	}
+
+	a.sessions.mu.Lock()
+	defer a.sessions.mu.Unlock()
+
+	if session, ok := a.sessions.findSession(id); ok {
+		session.Seal = seal
+		a.sessions.save()
+	}
+	securityLog.Infof("Session %d sealed with %d recorded sample(s) and %d alarm(s)", id, samples, len(alarms))
+	return nil
+}
+
+// VerifySession checks a finished session against its seal and reports
+// whether its metadata, recordings or alarms were modified, added or
+// removed since
+func (a *App) VerifySession(id int64) (SessionVerification, error) {
+	if err := a.requireReadRole(RoleOperator); err != nil {
+		return SessionVerification{}, err
+	}
+
+	a.sessions.mu.Lock()
+	session, ok := a.sessions.findSession(id)
+	if !ok {
+		a.sessions.mu.Unlock()
+		return SessionVerification{}, fmt.Errorf("unknown session %d", id)
+	}
+	snapshot := *session
+	a.sessions.mu.Unlock()
+
+	result := SessionVerification{ID: id, Sealed: snapshot.Seal != nil}
+	if snapshot.Seal == nil {
+		result.Problem = "session is not sealed"
+		if snapshot.EndedAt.IsZero() {
+			result.Problem = "session is still running"
+		}
+		return result, nil
+	}
+	seal := *snapshot.Seal
+	result.SealedAt = seal.SealedAt
+
+	own, err := a.sealer.publicKey()
+	if err != nil {
+		return result, err
+	}
+	public, err := hex.DecodeString(seal.PublicKey)
+	if err != nil || len(public) != ed25519.PublicKeySize {
+		result.Problem = "seal has an invalid public key"
+		return result, nil
+	}
+	if own != seal.PublicKey {
+		result.Problem = "seal was signed by another installation"
+		return result, nil
+	}
+	hash, err := hex.DecodeString(seal.Hash)
+	if err != nil {
+		result.Problem = "seal has an invalid hash"
+		return result, nil
+	}
+	signature, err := hex.DecodeString(seal.Signature)
+	if err != nil || !ed25519.Verify(public, hash, signature) {
+		result.Problem = "seal signature is invalid; the seal itself was modified"
+		return result, nil
+	}
+
+	files, err := a.sessionRecordings(id)
+	if err != nil {
+		return result, err
+	}
+	recorded, samples, err := recordedSamples(files)
+	if err != nil {
+		return result, err
+	}
+	alarms := a.sessionAlarms(snapshot)
+	current, err := sessionHash(snapshot, recorded, alarms)
+	if err != nil {
+		return result, err
+	}
+	switch {
+	case samples != seal.Samples:
+		result.Problem = fmt.Sprintf("session had %d recorded sample(s) when sealed, %d now", seal.Samples, samples)
+	case len(alarms) != seal.Alarms:
+		result.Problem = fmt.Sprintf("session had %d alarm(s) when sealed, %d now", seal.Alarms, len(alarms))
+	case hex.EncodeToString(current) != seal.Hash:
+		result.Problem = "session metadata, recordings or alarms were modified after sealing"
+	default:
+		result.Valid = true
+	}
+	return result, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// secretService groups the app's entries in the OS keyring
+const secretService = "mediot"
+
+// Keyring entries of the integration secrets
+const (
+	secretEmailPassword = "email-password"
+	secretTelegramToken = "telegram-bot-token"
+	secretTwilioToken   = "twilio-auth-token"
+	secretMQTTPassword  = "mqtt-password"
+)
+
+// errSecretNotFound is returned by keyringGet when the keyring has no such entry
+var errSecretNotFound = errors.New("secret not found in keyring")
+
+// SecretStorage describes where the integration secrets are kept
+type SecretStorage struct {
+	Backend   string   `json:"backend"`   // OS keyring of this platform, empty if none is supported
+	InKeyring []string `json:"inKeyring"` // Secrets held by the keyring
+	InConfig  []string `json:"inConfig"`  // Secrets left in the settings files because the keyring failed
+	LastError string   `json:"lastError,omitempty"`
+}
+
+// secretStore keeps integration secrets in the OS keyring instead of the
+// plaintext settings files. Settings are stored with the secret blanked and
+// filled in from the keyring on load. Without a working keyring the secret
+// stays in the file as before, so alerts keep working.
+type secretStore struct {
+	mu        sync.Mutex
+	inKeyring map[string]bool // Where each secret seen so far is kept
+	lastError string
+}
+
+// secrets is shared by the settings of the notifiers and servers, which are not tied to the App
+var secrets = &secretStore{inKeyring: make(map[string]bool)}
+
+// keep stores a secret in the keyring, or removes it when empty, and
+// reports whether the settings file can leave it out
+func (s *secretStore) keep(name, value string) bool {
+	if portableDir != "" {
+		// The keyring stays on this machine; the files travel with the app
+		s.mu.Lock()
+		if value == "" {
+			delete(s.inKeyring, name)
+		} else {
+			s.inKeyring[name] = false
+		}
+		s.mu.Unlock()
+		return false
+	}
+
+	var err error
+	if value == "" {
+		err = keyringDelete(name)
+	} else {
+		err = keyringSet(name, value)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil && value == "" {
+		// Nothing to protect; a stale keyring entry is harmless
+		return false
+	}
+	if err != nil {
+		securityLog.Warnf("Keyring unavailable, %s stays in the settings file: %v", name, err)
+		s.lastError = err.Error()
+		s.inKeyring[name] = false
+		return false
+	}
+	if value == "" {
+		delete(s.inKeyring, name)
+	} else {
+		s.inKeyring[name] = true
+	}
+	return true
+}
+
+// restore fills a secret loaded from a settings file. A secret still in the
+// file, written by an older version, is moved to the keyring; the result
+// reports whether the file should be saved again without it.
+func (s *secretStore) restore(name string, value *string) bool {
+	if portableDir != "" {
+		return false
+	}
+	if *value != "" {
+		if !s.keep(name, *value) {
+			return false
+		}
+		securityLog.Infof("Moved %s from the settings file to the keyring", name)
+		return true
+	}
+
+	secret, err := keyringGet(name)
+	if errors.Is(err, errSecretNotFound) {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		securityLog.Errorf("Error reading %s from the keyring: %v", name, err)
+		s.lastError = err.Error()
+		return false
+	}
+	*value = secret
+	s.inKeyring[name] = true
+	return false
+}
+
+// GetSecretStorage returns which keyring holds the integration secrets and
+// which secrets could not be moved out of the settings files
+func (a *App) GetSecretStorage() SecretStorage {
+	secrets.mu.Lock()
+	defer secrets.mu.Unlock()
+
+	storage := SecretStorage{
+		Backend:   keyringBackend,
+		InKeyring: make([]string, 0),
+		InConfig:  make([]string, 0),
+		LastError: secrets.lastError,
+	}
+	for name, inKeyring := range secrets.inKeyring {
+		if inKeyring {
+			storage.InKeyring = append(storage.InKeyring, name)
+		} else {
+			storage.InConfig = append(storage.InConfig, name)
+		}
+	}
+	sort.Strings(storage.InKeyring)
+	sort.Strings(storage.InConfig)
+	return storage
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// Health check results
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+// Health check thresholds
+const (
+	checkDialTimeout   = 3 * time.Second
+	checkDiskWarnBytes = 1 << 30   // Less free space than this is a warning
+	checkDiskFailBytes = 100 << 20 // and less than this a failure
+	checkClockOffsetMs = 1000      // Largest device clock offset that passes
+)
+
+// checkEarliestTime is a date the host clock cannot be before; builds
+// stamped with their build date use that instead
+var checkEarliestTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// HealthCheck is the result of one self-diagnostic check
+type HealthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // pass, warn or fail
+	Detail string `json:"detail"`
+}
+
+// HealthCheckReport is the result of RunHealthCheck; its status is the
+// worst of its checks
+type HealthCheckReport struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks"`
+	At     time.Time     `json:"at"`
+}
+
+// checkRank orders the results from best to worst
+var checkRank = map[string]int{CheckPass: 0, CheckWarn: 1, CheckFail: 2}
+
+// add appends a check and keeps the report status at the worst result
+func (r *HealthCheckReport) add(name, status, format string, args ...interface{}) {
+	r.Checks = append(r.Checks, HealthCheck{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)})
+	if checkRank[status] > checkRank[r.Status] {
+		r.Status = status
+	}
+}
+
+// RunHealthCheck verifies that the app can work on this PC: serial ports
+// can be listed, the configuration can be read and written, storage has
+// free space, the enabled sinks and alert channels reach their servers and
+// the clocks are sane. The sinks are tried one after the other, so the
+// check may take a few seconds.
+func (a *App) RunHealthCheck() HealthCheckReport {
+	report := HealthCheckReport{Status: CheckPass, Checks: make([]HealthCheck, 0), At: time.Now()}
+	a.checkSerial(&report)
+	a.checkConfig(&report)
+	a.checkStorage(&report)
+	a.checkSinks(&report)
+	a.checkClocks(&report)
+
+	appLog.Infof("Health check: %s", report.Status)
+	for _, check := range report.Checks {
+		if check.Status != CheckPass {
+			appLog.Warnf("Health check %s: %s: %s", check.Name, check.Status, check.Detail)
+		}
+	}
+	return report
+}
+
+// checkSerial lists the serial ports
+func (a *App) checkSerial(report *HealthCheckReport) {
+	ports, err := serial.GetPortsList()
+	switch {
+	case err != nil:
+		report.add("Serial ports", CheckFail, "Listing serial ports failed: %v", err)
+	case len(ports) == 0:
+		report.add("Serial ports", CheckWarn, "No serial port found; is the device plugged in?")
+	default:
+		report.add("Serial ports", CheckPass, "%d serial ports found", len(ports))
+	}
+}
+
+// checkConfig writes and reads back a file in the configuration folder of
+// the current profile, and reports settings that cannot be saved
+func (a *App) checkConfig(report *HealthCheckReport) {
+	if atRest.locked() {
+		report.add("Configuration", CheckFail, "%v", errStorageLocked)
+		return
+	}
+	dir, err := profileDir(profiles.current())
+	if err != nil {
+		report.add("Configuration", CheckFail, "%v", err)
+		return
+	}
+	probe := filepath.Join(dir, ".healthcheck")
+	want := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+	err = os.WriteFile(probe, want, 0600)
+	if err == nil {
+		var got []byte
+		if got, err = os.ReadFile(probe); err == nil && string(got) != string(want) {
+			err = fmt.Errorf("read back different content")
+		}
+		os.Remove(probe)
+	}
+	if err != nil {
+		report.add("Configuration", CheckFail, "Configuration folder %s is not writable: %v", dir, err)
+		return
+	}
+
+	a.settings.mu.Lock()
+	readOnly := a.settings.readOnly
+	a.settings.mu.Unlock()
+	if readOnly {
+		report.add("Configuration", CheckWarn, "Settings were written by a newer version and cannot be changed")
+		return
+	}
+	report.add("Configuration", CheckPass, "%s is readable and writable", dir)
+}
+
+// checkStorage checks the free space of the data directory and, while
+// recording, of the recording folder
+func (a *App) checkStorage(report *HealthCheckReport) {
+	dirs := make([]string, 0, 2)
+	if dir, err := appDataDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	if status := a.recorder.status(); status.Active {
+		dirs = append(dirs, status.Dir)
+	}
+
+	for _, dir := range dirs {
+		free, err := diskFree(dir)
+		switch {
+		case err != nil:
+			report.add("Storage", CheckWarn, "Free space of %s unknown: %v", dir, err)
+		case free < checkDiskFailBytes:
+			report.add("Storage", CheckFail, "Only %d MB free for %s", free>>20, dir)
+		case free < checkDiskWarnBytes:
+			report.add("Storage", CheckWarn, "Only %d MB free for %s", free>>20, dir)
+		default:
+			report.add("Storage", CheckPass, "%.1f GB free for %s", float64(free)/(1<<30), dir)
+		}
+	}
+}
+
+// checkReachable opens a TCP connection to a server
+func checkReachable(report *HealthCheckReport, name, addr string) {
+	conn, err := net.DialTimeout("tcp", addr, checkDialTimeout)
+	if err != nil {
+		report.add(name, CheckFail, "%s is unreachable: %v", addr, err)
+		return
+	}
+	conn.Close()
+	report.add(name, CheckPass, "%s is reachable", addr)
+}
+
+// apiAddress returns the host:port of an HTTPS API endpoint
+func apiAddress(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return net.JoinHostPort(u.Hostname(), "443")
+}
+
+// checkSinks checks that the enabled sinks and alert channels reach their
+// servers. A connected MQTT sink is not dialled again: a second login with
+// its client ID would make the broker drop the first.
+func (a *App) checkSinks(report *HealthCheckReport) {
+	a.mqtt.mu.Lock()
+	mqttConfig, mqttStatus := a.mqtt.config, a.mqtt.status
+	a.mqtt.mu.Unlock()
+	if mqttConfig.Enabled {
+		if mqttStatus.Connected {
+			report.add("MQTT", CheckPass, "Connected to %s", mqttConfig.Broker)
+		} else if addr, _, err := mqttAddress(mqttConfig.Broker); err != nil {
+			report.add("MQTT", CheckFail, "Invalid broker '%s': %v", mqttConfig.Broker, err)
+		} else {
+			checkReachable(report, "MQTT", addr)
+		}
+	}
+
+	a.email.mu.Lock()
+	emailConfig := a.email.config
+	a.email.mu.Unlock()
+	if emailConfig.Enabled {
+		checkReachable(report, "Email", net.JoinHostPort(emailConfig.Host, strconv.Itoa(emailConfig.Port)))
+	}
+
+	a.messaging.mu.Lock()
+	messagingConfig := a.messaging.config
+	a.messaging.mu.Unlock()
+	if messagingConfig.Telegram.Enabled {
+		checkReachable(report, "Telegram", apiAddress(telegramAPI))
+	}
+	if messagingConfig.SMS.Enabled {
+		checkReachable(report, "SMS", apiAddress(twilioAPI))
+	}
+
+	if status := a.recorder.status(); status.Active {
+		report.add("Recording", CheckPass, "Recording to %s", status.Dir)
+	}
+
+	for _, plugin := range a.GetPlugins() {
+		if !plugin.Enabled {
+			continue
+		}
+		name := "Plugin " + plugin.Manifest.Name
+		switch plugin.State {
+		case PluginRunning:
+			report.add(name, CheckPass, "Running")
+		case PluginStarting:
+			report.add(name, CheckWarn, "Starting")
+		default:
+			report.add(name, CheckFail, "%s: %s", plugin.State, plugin.Error)
+		}
+	}
+}
+
+// checkClocks checks that the host clock is not set in the past and that
+// the device clock, if it was synchronised in the running session, is
+// close to it
+func (a *App) checkClocks(report *HealthCheckReport) {
+	earliest := checkEarliestTime
+	if built, err := time.Parse(time.RFC3339, appBuildDate); err == nil {
+		earliest = built
+	}
+	now := time.Now()
+	if now.Before(earliest) {
+		report.add("Host clock", CheckFail, "Host clock reads %s, before this build", now.Format(time.RFC3339))
+	} else {
+		report.add("Host clock", CheckPass, "Host clock reads %s", now.Format(time.RFC3339))
+	}
+
+	session := a.currentSession()
+	if session == nil || len(session.ClockSyncs) == 0 {
+		return
+	}
+	last := session.ClockSyncs[len(session.ClockSyncs)-1]
+	if math.Abs(last.OffsetMs) > checkClockOffsetMs {
+		report.add("Device clock", CheckWarn, "Device clock was %.0f ms off after the last synchronisation", last.OffsetMs)
+	} else {
+		report.add("Device clock", CheckPass, "Device clock within %.0f ms of the host", math.Abs(last.OffsetMs))
+	}
+}
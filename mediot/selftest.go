@@ -0,0 +1,513 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// Self-test
+const (
+	selfTestPortName   = "loopback" // Port of the in-process loopback
+	selfTestBaudRate   = 115200
+	selfTestLines      = 1000
+	selfTestAlarmFrom  = 400 // Lines from here to selfTestAlarmTo carry a low SpO2
+	selfTestAlarmTo    = 600
+	selfTestAlarmLimit = 85.0
+	selfTestMaxChunk   = 64 // Largest write of the simulated device, so lines are split across reads
+	selfTestTimeout    = 10 * time.Second
+	selfTestSettle     = 500 * time.Millisecond // Wait for samples after the stream ended
+)
+
+// errLoopbackClosed is returned by a closed loopback port, as a serial port
+// returns an error once closed
+var errLoopbackClosed = errors.New("loopback port closed")
+
+// byteQueue carries the bytes of one direction of a loopback
+type byteQueue struct {
+	mu     sync.Mutex
+	data   []byte
+	closed bool
+	ready  chan struct{} // Signalled when data arrives or the queue closes
+}
+
+// newByteQueue creates an empty queue
+func newByteQueue() *byteQueue {
+	return &byteQueue{ready: make(chan struct{}, 1)}
+}
+
+// signal wakes a waiting reader
+func (q *byteQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// write queues bytes for the other end
+func (q *byteQueue) write(p []byte) (int, error) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return 0, errLoopbackClosed
+	}
+	q.data = append(q.data, p...)
+	q.mu.Unlock()
+	q.signal()
+	return len(p), nil
+}
+
+// read takes the queued bytes, waiting at most timeout for some to arrive;
+// it returns 0 bytes on a timeout, as a serial port does
+func (q *byteQueue) read(p []byte, timeout time.Duration) (int, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return 0, errLoopbackClosed
+		}
+		if len(q.data) > 0 {
+			n := copy(p, q.data)
+			q.data = q.data[n:]
+			q.mu.Unlock()
+			return n, nil
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.ready:
+		case <-timer.C:
+			return 0, nil
+		}
+	}
+}
+
+// reset drops the queued bytes
+func (q *byteQueue) reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.data = nil
+}
+
+// close makes both ends fail
+func (q *byteQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.signal()
+}
+
+// loopbackPort is one end of an in-process serial link: what one end
+// writes, the other reads. It stands in for a virtual port pair in the
+// self-test.
+type loopbackPort struct {
+	rx, tx  *byteQueue
+	mu      sync.Mutex
+	timeout time.Duration
+}
+
+// newLoopbackPair creates the two connected ends of a loopback
+func newLoopbackPair() (*loopbackPort, *loopbackPort) {
+	forward, backward := newByteQueue(), newByteQueue()
+	return &loopbackPort{rx: forward, tx: backward, timeout: time.Hour},
+		&loopbackPort{rx: backward, tx: forward, timeout: time.Hour}
+}
+
+func (p *loopbackPort) SetMode(mode *serial.Mode) error { return nil }
+func (p *loopbackPort) Write(b []byte) (int, error)     { return p.tx.write(b) }
+func (p *loopbackPort) Drain() error                    { return nil }
+func (p *loopbackPort) ResetInputBuffer() error         { p.rx.reset(); return nil }
+func (p *loopbackPort) ResetOutputBuffer() error        { return nil }
+func (p *loopbackPort) SetDTR(dtr bool) error           { return nil }
+func (p *loopbackPort) SetRTS(rts bool) error           { return nil }
+func (p *loopbackPort) Break(time.Duration) error       { return nil }
+
+func (p *loopbackPort) GetModemStatusBits() (*serial.ModemStatusBits, error) {
+	return &serial.ModemStatusBits{CTS: true, DSR: true}, nil
+}
+
+func (p *loopbackPort) SetReadTimeout(t time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.timeout = t
+	return nil
+}
+
+func (p *loopbackPort) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	timeout := p.timeout
+	p.mu.Unlock()
+	return p.rx.read(b, timeout)
+}
+
+// Close closes the link in both directions
+func (p *loopbackPort) Close() error {
+	p.rx.close()
+	p.tx.close()
+	return nil
+}
+
+// selfTestDevice plays the sensor on the far end of the link: it rejects
+// every command, so capability discovery ends at once, and streams hex lines
+type selfTestDevice struct {
+	port     serial.Port
+	answered chan struct{} // Closed once the first command was rejected
+	done     chan struct{}
+}
+
+// newSelfTestDevice starts answering commands on the device end of a link
+func newSelfTestDevice(port serial.Port) *selfTestDevice {
+	d := &selfTestDevice{port: port, answered: make(chan struct{}), done: make(chan struct{})}
+	goSafe("self-test device", d.answer)
+	return d
+}
+
+// answer rejects the commands the app sends until the device stops
+func (d *selfTestDevice) answer() {
+	d.port.SetReadTimeout(50 * time.Millisecond)
+	var pending []byte
+	buf := make([]byte, 128)
+	for {
+		select {
+		case <-d.done:
+			return
+		default:
+		}
+		n, err := d.port.Read(buf)
+		if err != nil {
+			return
+		}
+		pending = append(pending, buf[:n]...)
+		for {
+			i := strings.IndexByte(string(pending), '\n')
+			if i < 0 {
+				break
+			}
+			command := strings.TrimSpace(string(pending[:i]))
+			pending = pending[i+1:]
+			if command == "" {
+				continue
+			}
+			d.port.Write([]byte("ERR unknown command\r\n"))
+			select {
+			case <-d.answered:
+			default:
+				close(d.answered)
+			}
+		}
+	}
+}
+
+// stop ends the answering
+func (d *selfTestDevice) stop() {
+	close(d.done)
+}
+
+// selfTestRaw returns the raw hex values of a line of the self-test stream
+func selfTestRaw(i int) [3]int32 {
+	spo2 := int32(97000)
+	if i >= selfTestAlarmFrom && i < selfTestAlarmTo {
+		spo2 = 80000
+	}
+	return [3]int32{int32(i * 10), int32(200 * (i % 50)), spo2}
+}
+
+// selfTestExpected returns the sample the parser must make of a line
+func selfTestExpected(i int) [3]float64 {
+	raw := selfTestRaw(i)
+	var values [3]float64
+	for c := range values {
+		values[c] = float64(raw[c]) / hexScales[c]
+	}
+	return values
+}
+
+// stream sends the self-test lines in writes of random length
+func (d *selfTestDevice) stream() error {
+	var data []byte
+	for i := 0; i < selfTestLines; i++ {
+		raw := selfTestRaw(i)
+		data = append(data, fmt.Sprintf("0x%08X,0x%08X,0x%08X\r\n", uint32(raw[0]), uint32(raw[1]), uint32(raw[2]))...)
+	}
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for len(data) > 0 {
+		n := min(len(data), 1+random.Intn(selfTestMaxChunk))
+		if _, err := d.port.Write(data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+		time.Sleep(time.Millisecond)
+	}
+	return nil
+}
+
+// checkSamples compares the samples read with the self-test stream
+func checkSamples(samples []SensorData) error {
+	if len(samples) != selfTestLines {
+		return fmt.Errorf("%d samples, want %d", len(samples), selfTestLines)
+	}
+	for i, sample := range samples {
+		want := selfTestExpected(i)
+		if sample.Value1 != want[0] || sample.Value2 != want[1] || sample.Value3 != want[2] {
+			return fmt.Errorf("sample %d is %v, %v, %v, want %v, %v, %v",
+				i+1, sample.Value1, sample.Value2, sample.Value3, want[0], want[1], want[2])
+		}
+	}
+	return nil
+}
+
+// readRecording decodes the samples of the recording files of a folder
+func readRecording(dir string) ([]SensorData, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) != 1 {
+		return nil, fmt.Errorf("%d recording files, want 1", len(files))
+	}
+	data, err := readRecordingFile(files[0])
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]SensorData, 0, selfTestLines)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var sample SensorData
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			return nil, fmt.Errorf("invalid recording line %d: %v", len(samples)+1, err)
+		}
+		samples = append(samples, sample)
+	}
+	return samples, scanner.Err()
+}
+
+// selfTest runs the whole data path against a device on the far end of a
+// link: connecting, streaming, parsing, alarms, recording and export. The
+// app must be fresh, with its data in a scratch folder; dir receives the
+// recording and the export. The report says which steps passed; a step
+// failing skips those that depend on it.
+func (a *App) selfTest(device serial.Port, portName string, baudRate int, dir string) HealthCheckReport {
+	report := HealthCheckReport{Status: CheckPass, Checks: make([]HealthCheck, 0), At: time.Now()}
+	recordDir := filepath.Join(dir, "recording")
+	if _, err := a.startRecording(recordDir); err != nil {
+		report.add("record", CheckFail, "%v", err)
+		return report
+	}
+
+	simulated := newSelfTestDevice(device)
+	defer simulated.stop()
+	if result := a.connect(portName, baudRate); !result.Success {
+		report.add("connect", CheckFail, "%s", result.Message)
+		return report
+	}
+	// Stream only once capability discovery has given the port back
+	select {
+	case <-simulated.answered:
+	case <-time.After(2 * deviceReplyTimeout):
+	}
+	for deadline := time.Now().Add(2 * deviceReplyTimeout); a.conn.ownerName() != "" && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	report.add("connect", CheckPass, "connected to %s at %d baud", portName, baudRate)
+
+	limit := selfTestAlarmLimit
+	if err := a.setAlarmRule(AlarmRule{Channel: ChannelValue3, CriticalLow: &limit}); err != nil {
+		report.add("alarm", CheckFail, "%v", err)
+		return report
+	}
+
+	streamed := make(chan error, 1)
+	goSafe("self-test stream", func() { streamed <- simulated.stream() })
+	samples := make([]SensorData, 0, selfTestLines)
+	var streamErr error
+	// Once the stream ended, lines lost to faults are not waited for
+	ended, settle := false, time.Time{}
+	for deadline := time.Now().Add(selfTestTimeout); len(samples) < selfTestLines && time.Now().Before(deadline); {
+		time.Sleep(20 * time.Millisecond)
+		if !ended {
+			select {
+			case streamErr = <-streamed:
+				ended, settle = true, time.Now().Add(selfTestSettle)
+			default:
+			}
+		}
+		read, err := a.ReadSensorData()
+		if err != nil {
+			report.add("stream", CheckFail, "%v", err)
+			return report
+		}
+		samples = append(samples, read...)
+		if ended && len(read) > 0 {
+			settle = time.Now().Add(selfTestSettle)
+		}
+		if ended && time.Now().After(settle) {
+			break
+		}
+	}
+	if !ended {
+		streamErr = <-streamed
+	}
+	if err := streamErr; err != nil {
+		report.add("stream", CheckFail, "writing to the device end failed: %v", err)
+		return report
+	}
+	if err := checkSamples(samples); err != nil {
+		report.add("parse", CheckFail, "%v", err)
+	} else {
+		report.add("parse", CheckPass, "%d samples decoded as sent", len(samples))
+	}
+
+	critical := 0
+	for _, record := range a.alarmHistory(AlarmHistoryFilter{Channel: ChannelValue3}) {
+		if record.Severity == SeverityCritical {
+			critical++
+		}
+	}
+	if critical == 0 {
+		report.add("alarm", CheckFail, "no critical alarm on %s below %g", ChannelValue3, limit)
+	} else {
+		report.add("alarm", CheckPass, "%d critical alarms on %s", critical, ChannelValue3)
+	}
+
+	if result := a.disconnect(); !result.Success {
+		report.add("disconnect", CheckFail, "%s", result.Message)
+		return report
+	}
+	if _, err := a.stopRecording(); err != nil {
+		report.add("record", CheckFail, "%v", err)
+	} else if recorded, err := readRecording(recordDir); err != nil {
+		report.add("record", CheckFail, "%v", err)
+	} else if err := checkSamples(recorded); err != nil {
+		report.add("record", CheckFail, "recording: %v", err)
+	} else {
+		report.add("record", CheckPass, "%d samples recorded", len(recorded))
+	}
+
+	sessions := a.sessionList(1)
+	if len(sessions) == 0 || sessions[0].EndedAt.IsZero() {
+		report.add("session", CheckFail, "the session did not end with the connection")
+	} else {
+		report.add("session", CheckPass, "session %d ended", sessions[0].ID)
+	}
+
+	exportPath := filepath.Join(dir, "export.json")
+	var export SessionExport
+	if n, err := a.ExportSessions(exportPath, ExportOptions{Limit: 1}); err != nil {
+		report.add("export", CheckFail, "%v", err)
+	} else if data, err := os.ReadFile(exportPath); err != nil {
+		report.add("export", CheckFail, "%v", err)
+	} else if err := json.Unmarshal(data, &export); err != nil {
+		report.add("export", CheckFail, "invalid export: %v", err)
+	} else if n != 1 || len(export.Sessions) != 1 || len(export.Sessions[0].Alarms) == 0 {
+		report.add("export", CheckFail, "%d sessions exported, want 1 with its alarms", n)
+	} else {
+		report.add("export", CheckPass, "session exported with %d alarms", len(export.Sessions[0].Alarms))
+	}
+	return report
+}
+
+// selfTestRequested reports whether the command line asks for the self-test
+func selfTestRequested(args []string) bool {
+	return flagRequested(args, "selftest")
+}
+
+// runSelfTest runs the whole stack end to end against a simulated device,
+// for release validation:
+//
+//	mediot --selftest [--selftest-port COM5 --selftest-peer COM6]
+//	                  [--selftest-drop-rate 0.01] [--selftest-corrupt-rate 0.01]
+//
+// By default the device is on an in-process loopback. With a virtual port
+// pair, such as com0com or socat, the app connects to one port and the
+// simulated device sends on its peer, so the serial driver is exercised
+// too. The fault rates inject lost or corrupted bytes, to see the checks
+// that depend on the data fail. The app keeps its data in a scratch folder that is removed
+// afterwards, so the user's settings and sessions are untouched. It returns
+// the process exit code.
+func runSelfTest(args []string) int {
+	flags := flag.NewFlagSet("mediot", flag.ContinueOnError)
+	flags.Bool("selftest", false, "run the end-to-end self-test")
+	portName := flags.String("selftest-port", "", "port of a virtual pair the app connects to (default: an in-process loopback)")
+	peerName := flags.String("selftest-peer", "", "the other port of the pair, where the simulated device sends")
+	var faults FaultConfig
+	flags.Float64Var(&faults.DropRate, "selftest-drop-rate", 0, "chance each byte from the device is lost, 0..1")
+	flags.Float64Var(&faults.CorruptRate, "selftest-corrupt-rate", 0, "chance each byte from the device is corrupted, 0..1")
+	addPortableFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if (*portName == "") != (*peerName == "") {
+		fmt.Fprintln(os.Stderr, "mediot: --selftest-port and --selftest-peer go together")
+		return 2
+	}
+	if err := checkFaultConfig(faults); err != nil {
+		fmt.Fprintf(os.Stderr, "mediot: %v\n", err)
+		return 2
+	}
+
+	dir, err := os.MkdirTemp("", "mediot-selftest-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mediot: %v\n", err)
+		return 2
+	}
+	defer os.RemoveAll(dir)
+	defer func(dir string, open func(string, *serial.Mode) (serial.Port, error)) {
+		portableDir, openSerialPort = dir, open
+	}(portableDir, openSerialPort)
+	portableDir = filepath.Join(dir, "data")
+
+	var device serial.Port
+	if *portName == "" {
+		app, loopback := newLoopbackPair()
+		*portName, device = selfTestPortName, loopback
+		openSerialPort = func(name string, mode *serial.Mode) (serial.Port, error) {
+			if name == selfTestPortName {
+				return app, nil
+			}
+			return serial.Open(name, mode)
+		}
+	} else if device, err = serial.Open(*peerName, serialMode(selfTestBaudRate)); err != nil {
+		fmt.Fprintf(os.Stderr, "mediot: cannot open %s: %v\n", *peerName, err)
+		return 2
+	}
+	defer device.Close()
+
+	app := NewApp()
+	if faults.DropRate > 0 || faults.CorruptRate > 0 {
+		// The scratch app is not in developer mode, which SetFaultInjection requires
+		app.faults.mu.Lock()
+		app.faults.status = FaultStatus{Enabled: true, Config: faults}
+		app.faults.mu.Unlock()
+	}
+	report := app.selfTest(device, *portName, selfTestBaudRate, dir)
+	app.shutdown(context.Background())
+
+	for _, check := range report.Checks {
+		if check.Status == CheckPass {
+			fmt.Printf("ok      %s: %s\n", check.Name, check.Detail)
+		} else {
+			fmt.Printf("FAIL    %s: %s\n", check.Name, check.Detail)
+		}
+	}
+	if report.Status != CheckPass {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// selfTestOutput runs the self-test with args and returns its exit code and
+// the status of each check it printed
+func selfTestOutput(t *testing.T, args ...string) (int, map[string]string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	printed := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		printed <- data
+	}()
+	code := runSelfTest(append([]string{"--selftest"}, args...))
+	os.Stdout = stdout
+	w.Close()
+	out := <-printed
+
+	checks := make(map[string]string)
+	for _, line := range strings.Split(string(bytes.TrimSpace(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		checks[strings.TrimSuffix(fields[1], ":")] = fields[0]
+	}
+	t.Logf("self-test %v:\n%s", args, out)
+	return code, checks
+}
+
+// TestSelfTest runs the self-test on the loopback without faults, where
+// every check passes, and with lost and corrupted bytes, where only the
+// checks of the data fail
+func TestSelfTest(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		failed []string
+	}{
+		{"clean", nil, 0, nil},
+		{"dropped bytes", []string{"--selftest-drop-rate", "0.02"}, 1, []string{"parse", "record"}},
+		{"corrupted bytes", []string{"--selftest-corrupt-rate", "0.02"}, 1, []string{"parse", "record"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, checks := selfTestOutput(t, test.args...)
+			if code != test.code {
+				t.Errorf("exit code %d, want %d", code, test.code)
+			}
+			failed := make(map[string]bool)
+			for _, name := range test.failed {
+				failed[name] = true
+			}
+			for _, name := range []string{"connect", "parse", "record", "session", "export"} {
+				want := "ok"
+				if failed[name] {
+					want = "FAIL"
+				}
+				if checks[name] != want {
+					t.Errorf("%s check is %q, want %q", name, checks[name], want)
+				}
+			}
+		})
+	}
+}
+
+// TestSelfTestUsage checks that invalid fault rates are refused
+func TestSelfTestUsage(t *testing.T) {
+	if code := runSelfTest([]string{"--selftest", "--selftest-drop-rate", "2"}); code != 2 {
+		t.Errorf("exit code %d for a drop rate of 2, want 2", code)
+	}
+}
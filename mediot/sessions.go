@@ -0,0 +1,359 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session metadata persistence
+const (
+	sessionsFile = "sessions.json"
+	maxSessions  = 1000 // Oldest sessions are dropped beyond this
+)
+
+// AlarmProfileLoad records which version of an alarm profile was loaded when
+type AlarmProfileLoad struct {
+	Profile  string    `json:"profile"`
+	Version  int       `json:"version"`
+	LoadedAt time.Time `json:"loadedAt"`
+}
+
+// SessionInfo is the metadata of one monitoring session, from connect to disconnect
+type SessionInfo struct {
+	ID            int64               `json:"id"`
+	Device        string              `json:"device"` // Device registry ID
+	Port          string              `json:"port"`
+	Patient       string              `json:"patient,omitempty"` // Identifier of the monitored patient, entered by the user
+	BaudRate      int                 `json:"baudRate"`
+	StartedAt     time.Time           `json:"startedAt"`
+	EndedAt       time.Time           `json:"endedAt"`               // Zero while the session runs
+	AlarmProfiles []AlarmProfileLoad  `json:"alarmProfiles"`         // Limits in force, in the order they were loaded
+	ClockSyncs    []ClockSync         `json:"clockSyncs"`            // Device clock synchronisations
+	Gaps          []SessionGap        `json:"gaps,omitempty"`        // Periods without data; omitted when empty so older seals verify
+	Annotations   []SessionAnnotation `json:"annotations,omitempty"` // Notes added while the session ran; omitted when empty so older seals verify
+	Seal          *SessionSeal        `json:"seal"`                  // Set when the session ends, nil before
+}
+
+// SessionGap is a period of a session during which no data could be
+// received, because the device was lost or the system was asleep
+type SessionGap struct {
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"` // Zero while the gap lasts
+	Reason string    `json:"reason"`
+}
+
+// maxAnnotationLength is the longest annotation text accepted
+const maxAnnotationLength = 1000
+
+// SessionAnnotation is a note marking a moment of a session, e.g. "patient
+// turned over" or "sensor repositioned"
+type SessionAnnotation struct {
+	Time time.Time `json:"time"`
+	User string    `json:"user"`
+	Text string    `json:"text"`
+}
+
+// sessionLog keeps the persistent session metadata, oldest first
+type sessionLog struct {
+	mu       sync.Mutex
+	sessions []SessionInfo
+	current  *SessionInfo // Last element of sessions while connected
+}
+
+// newSessionLog creates the session log and loads the persisted metadata
+func newSessionLog() *sessionLog {
+	l := &sessionLog{sessions: make([]SessionInfo, 0)}
+	if err := loadJSONFile(sessionsFile, &l.sessions); err != nil {
+		appLog.Errorf("Error loading sessions: %v", err)
+	}
+	return l
+}
+
+// reload replaces the sessions with the persisted ones once the patient
+// data is unlocked. No session runs while it is locked.
+func (l *sessionLog) reload() {
+	sessions := make([]SessionInfo, 0)
+	if err := loadJSONFile(sessionsFile, &sessions); err != nil {
+		appLog.Errorf("Error loading sessions: %v", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sessions = sessions
+	l.current = nil
+}
+
+// save persists the sessions; the caller holds the lock
+func (l *sessionLog) save() {
+	if err := saveJSONFile(sessionsFile, l.sessions); err != nil {
+		appLog.Errorf("Error saving sessions: %v", err)
+	}
+}
+
+// begin starts a session. The alarm profile in force, if any, is recorded
+// as loaded at the start.
+func (l *sessionLog) begin(device Device, baudRate int, profile *AlarmProfileLoad, at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	session := SessionInfo{
+		ID:            1,
+		Device:        device.ID,
+		Port:          device.LastPort,
+		BaudRate:      baudRate,
+		StartedAt:     at,
+		AlarmProfiles: make([]AlarmProfileLoad, 0),
+		ClockSyncs:    make([]ClockSync, 0),
+	}
+	if n := len(l.sessions); n > 0 {
+		session.ID = l.sessions[n-1].ID + 1
+	}
+	if profile != nil {
+		load := *profile
+		load.LoadedAt = at
+		session.AlarmProfiles = append(session.AlarmProfiles, load)
+	}
+
+	l.sessions = append(l.sessions, session)
+	if len(l.sessions) > maxSessions {
+		l.sessions = append(make([]SessionInfo, 0, maxSessions), l.sessions[len(l.sessions)-maxSessions:]...)
+	}
+	l.current = &l.sessions[len(l.sessions)-1]
+	l.save()
+}
+
+// end closes the running session and returns its ID, 0 if none was running
+func (l *sessionLog) end(at time.Time) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.current == nil {
+		return 0
+	}
+	id := l.current.ID
+	l.current.EndedAt = at
+	if n := len(l.current.Gaps); n > 0 && l.current.Gaps[n-1].To.IsZero() {
+		l.current.Gaps[n-1].To = at
+	}
+	l.current = nil
+	l.save()
+	return id
+}
+
+// recordProfile adds an alarm profile load to the running session
+func (l *sessionLog) recordProfile(load AlarmProfileLoad) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.current == nil {
+		return
+	}
+	l.current.AlarmProfiles = append(l.current.AlarmProfiles, load)
+	l.save()
+}
+
+// currentID returns the ID of the running session, 0 while disconnected
+func (l *sessionLog) currentID() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.current == nil {
+		return 0
+	}
+	return l.current.ID
+}
+
+// beginGap opens a gap in the running session. If one is already open, it
+// is moved back to start at from when that is earlier.
+func (l *sessionLog) beginGap(from time.Time, reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.current == nil {
+		return
+	}
+	if n := len(l.current.Gaps); n > 0 && l.current.Gaps[n-1].To.IsZero() {
+		if from.Before(l.current.Gaps[n-1].From) {
+			l.current.Gaps[n-1].From = from
+			l.current.Gaps[n-1].Reason = reason
+			l.save()
+		}
+		return
+	}
+	l.current.Gaps = append(l.current.Gaps, SessionGap{From: from, Reason: reason})
+	l.save()
+}
+
+// endGap closes the open gap of the running session, if any
+func (l *sessionLog) endGap(at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.current == nil {
+		return
+	}
+	if n := len(l.current.Gaps); n > 0 && l.current.Gaps[n-1].To.IsZero() {
+		l.current.Gaps[n-1].To = at
+		l.save()
+	}
+}
+
+// annotate adds an annotation to the running session
+func (l *sessionLog) annotate(annotation SessionAnnotation) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.current == nil {
+		return fmt.Errorf("no session is running")
+	}
+	l.current.Annotations = append(l.current.Annotations, annotation)
+	l.save()
+	return nil
+}
+
+// startSession records a new connection and loads the alarm profile of the
+// device, or else the startup alarm profile
+func (a *App) startSession(device Device, baudRate int) {
+	defer a.scheduleOnConnect()
+
+	profile := device.Settings.AlarmProfile
+	if profile == "" {
+		profile = a.limits.startupProfile()
+	}
+	if profile == "" {
+		a.sessions.begin(device, baudRate, a.limits.loadedProfile(), time.Now())
+		return
+	}
+
+	a.sessions.begin(device, baudRate, nil, time.Now())
+	if err := a.loadAlarmProfile(profile, 0); err != nil {
+		appLog.Errorf("Error loading alarm profile %s: %v", profile, err)
+	}
+}
+
+// endSession closes the running session and its recording file, and seals
+// both against later modification
+func (a *App) endSession() {
+	id := a.sessions.end(time.Now())
+	a.recorder.endFile()
+	if id == 0 {
+		return
+	}
+	if err := a.sealSession(id); err != nil {
+		appLog.Errorf("Error sealing session %d: %v", id, err)
+	}
+}
+
+// GetSessions returns the metadata of the most recent sessions, oldest
+// first; limit 0 returns all of them
+func (a *App) GetSessions(limit int) ([]SessionInfo, error) {
+	if err := a.requireReadRole(RoleOperator); err != nil {
+		return nil, err
+	}
+	return a.sessionList(limit), nil
+}
+
+// sessionList is GetSessions without the role check
+func (a *App) sessionList(limit int) []SessionInfo {
+	a.sessions.mu.Lock()
+	defer a.sessions.mu.Unlock()
+
+	sessions := a.sessions.sessions
+	if limit > 0 && len(sessions) > limit {
+		sessions = sessions[len(sessions)-limit:]
+	}
+	result := make([]SessionInfo, len(sessions))
+	for i, session := range sessions {
+		result[i] = session
+		result[i].AlarmProfiles = append([]AlarmProfileLoad{}, session.AlarmProfiles...)
+		result[i].ClockSyncs = append([]ClockSync{}, session.ClockSyncs...)
+		result[i].Gaps = append([]SessionGap{}, session.Gaps...)
+		result[i].Annotations = append([]SessionAnnotation{}, session.Annotations...)
+	}
+	return result
+}
+
+// SetSessionPatient records which patient the running session monitors
+func (a *App) SetSessionPatient(patient string) error {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return err
+	}
+
+	patient = strings.TrimSpace(patient)
+
+	a.sessions.mu.Lock()
+	defer a.sessions.mu.Unlock()
+
+	if a.sessions.current == nil {
+		return fmt.Errorf("no session is running")
+	}
+	a.sessions.current.Patient = patient
+	a.sessions.save()
+	return nil
+}
+
+// GetCurrentSession returns the metadata of the running session, or nil while disconnected
+func (a *App) GetCurrentSession() (*SessionInfo, error) {
+	if err := a.requireReadRole(RoleOperator); err != nil {
+		return nil, err
+	}
+	return a.currentSession(), nil
+}
+
+// currentSession is GetCurrentSession without the role check
+func (a *App) currentSession() *SessionInfo {
+	a.sessions.mu.Lock()
+	defer a.sessions.mu.Unlock()
+
+	if a.sessions.current == nil {
+		return nil
+	}
+	session := *a.sessions.current
+	session.AlarmProfiles = append([]AlarmProfileLoad{}, session.AlarmProfiles...)
+	session.ClockSyncs = append([]ClockSync{}, session.ClockSyncs...)
+	session.Gaps = append([]SessionGap{}, session.Gaps...)
+	session.Annotations = append([]SessionAnnotation{}, session.Annotations...)
+	return &session
+}
+
+// AddAnnotation adds a note at the current time to the running session.
+// With accounts, the note is by the user logged in; without, an empty user
+// is the operator logged in to the computer.
+func (a *App) AddAnnotation(text string, user string) (SessionAnnotation, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return SessionAnnotation{}, err
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return SessionAnnotation{}, fmt.Errorf("annotation text is required")
+	}
+	if len(text) > maxAnnotationLength {
+		return SessionAnnotation{}, fmt.Errorf("annotation is longer than %d characters", maxAnnotationLength)
+	}
+	user, err := a.actingUser(user)
+	if err != nil {
+		return SessionAnnotation{}, err
+	}
+	if user == "" {
+		user = a.audit.actor()
+	}
+
+	annotation := SessionAnnotation{Time: time.Now(), User: user, Text: text}
+	if err := a.sessions.annotate(annotation); err != nil {
+		return SessionAnnotation{}, err
+	}
+
+	appLog.Infof("Annotation added by %s: %s", user, text)
+	patient := ""
+	if session := a.currentSession(); session != nil {
+		patient = session.Patient
+	}
+	a.audit.recordPatient(user, AuditAnnotation, patient, fmt.Sprintf("Session %d annotated: %s", a.sessions.currentID(), text))
+	a.emit(EventAnnotation, annotation)
+	return annotation, nil
+}
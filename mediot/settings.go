@@ -0,0 +1,511 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// settingsFile stores the general application settings. Subsystems with
+// their own configuration keep their own files.
+const settingsFile = "settings.json"
+
+// settingsVersion is the schema version written by this build
+const settingsVersion = 1
+
+// UI themes
+const (
+	ThemeSystem = "system"
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+)
+
+// Settings are the general application preferences
+type Settings struct {
+	Version            int     `json:"version"` // Schema version, managed by the backend
+	Theme              string  `json:"theme"`
+	Language           string  `json:"language"` // UI and message language tag (en, fr, ar), empty to follow the OS
+	DefaultPort        string  `json:"defaultPort"`
+	DefaultBaudRate    int     `json:"defaultBaudRate"`
+	ChartWindowSeconds float64 `json:"chartWindowSeconds"` // History shown in the live charts
+	ConfirmOnExit      bool    `json:"confirmOnExit"`
+	MinimizeToTray     bool    `json:"minimizeToTray"` // Closing the window hides it; acquisition and alarms continue
+	RecordingDir       string  `json:"recordingDir"`   // Folder of the recording files, empty for recordings in the data directory
+	AutoConnect        bool    `json:"autoConnect"`    // Reconnect the last device and resume recording at startup
+	ExportDir          string  `json:"exportDir"`      // Folder the export dialogs open in, the last one exported to
+	DeveloperMode      bool    `json:"developerMode"`  // Allows injecting test data as if it came from the device
+	ParserMode         string  `json:"parserMode"`     // How malformed lines are handled: strict or lenient
+}
+
+// SettingsUpdate changes some settings; nil fields keep their value
+type SettingsUpdate struct {
+	Theme              *string  `json:"theme,omitempty"`
+	Language           *string  `json:"language,omitempty"`
+	DefaultPort        *string  `json:"defaultPort,omitempty"`
+	DefaultBaudRate    *int     `json:"defaultBaudRate,omitempty"`
+	ChartWindowSeconds *float64 `json:"chartWindowSeconds,omitempty"`
+	ConfirmOnExit      *bool    `json:"confirmOnExit,omitempty"`
+	MinimizeToTray     *bool    `json:"minimizeToTray,omitempty"`
+	RecordingDir       *string  `json:"recordingDir,omitempty"`
+	AutoConnect        *bool    `json:"autoConnect,omitempty"`
+	ExportDir          *string  `json:"exportDir,omitempty"`
+	DeveloperMode      *bool    `json:"developerMode,omitempty"` // Only admins may change it
+	ParserMode         *string  `json:"parserMode,omitempty"`
+}
+
+// defaultSettings follow the OS theme and language
+func defaultSettings() Settings {
+	return Settings{
+		Version:            settingsVersion,
+		Theme:              ThemeSystem,
+		DefaultBaudRate:    115200,
+		ChartWindowSeconds: 30,
+		ConfirmOnExit:      true,
+		ParserMode:         ParserModeStrict,
+	}
+}
+
+// settingsMigrations upgrade the raw settings of one schema version to the
+// next, indexed by the version they upgrade from. Version 0 is a file
+// written before the schema was versioned.
+var settingsMigrations = []func(raw map[string]interface{}){
+	0: func(raw map[string]interface{}) {},
+}
+
+// settingsStore keeps the application settings
+type settingsStore struct {
+	mu       sync.Mutex
+	settings Settings // In force: the stored settings with the overrides applied
+	stored   Settings // As in the settings file
+	readOnly bool     // The file is from a newer version and must not be overwritten
+}
+
+// newSettingsStore loads the settings, migrating older schema versions
+func newSettingsStore() *settingsStore {
+	s := &settingsStore{}
+	if err := s.load(); err != nil {
+		appLog.Errorf("Error loading settings: %v", err)
+	}
+	overrides.mu.Lock()
+	list := overrides.list
+	overrides.mu.Unlock()
+	for _, override := range list {
+		appLog.Infof("Setting %s overridden by %s", override.Setting, override.Source)
+	}
+	messages.set(s.settings.Language)
+	return s
+}
+
+// load reads the settings file, starting from the defaults, and applies
+// the overrides
+func (s *settingsStore) load() error {
+	s.stored = defaultSettings()
+	s.readOnly = false
+	err := s.read()
+	s.settings = overrides.apply(s.stored)
+	return err
+}
+
+// read reads the settings file and upgrades it to the current schema.
+// Fields missing from the file keep their defaults.
+func (s *settingsStore) read() error {
+	path, err := dataFilePath(settingsFile)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	raw := make(map[string]interface{})
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", settingsFile, err)
+	}
+	version := 0
+	if v, ok := raw["version"].(float64); ok {
+		version = int(v)
+	}
+	if version > settingsVersion {
+		s.readOnly = true
+		return fmt.Errorf("settings were written by a newer version (schema %d); using defaults", version)
+	}
+	migrated := version < settingsVersion
+	for ; version < settingsVersion; version++ {
+		settingsMigrations[version](raw)
+		appLog.Infof("Settings migrated from schema %d to %d", version, version+1)
+	}
+	raw["version"] = settingsVersion
+
+	if data, err = json.Marshal(raw); err != nil {
+		return err
+	}
+	settings := defaultSettings()
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", settingsFile, err)
+	}
+	s.stored = settings
+	if !migrated {
+		return nil
+	}
+	return saveJSONFile(settingsFile, s.stored)
+}
+
+// apply validates an update and applies it to a copy of the settings
+func (u SettingsUpdate) apply(settings Settings) (Settings, error) {
+	if u.Theme != nil {
+		switch *u.Theme {
+		case ThemeSystem, ThemeLight, ThemeDark:
+			settings.Theme = *u.Theme
+		default:
+			return settings, fmt.Errorf("unknown theme '%s'", *u.Theme)
+		}
+	}
+	if u.Language != nil {
+		if _, ok := messageCatalogs[primaryLanguage(*u.Language)]; *u.Language != "" && !ok {
+			return settings, fmt.Errorf("unsupported language '%s'", *u.Language)
+		}
+		settings.Language = *u.Language
+	}
+	if u.DefaultPort != nil {
+		settings.DefaultPort = *u.DefaultPort
+	}
+	if u.DefaultBaudRate != nil {
+		if *u.DefaultBaudRate <= 0 {
+			return settings, fmt.Errorf("invalid baud rate %d", *u.DefaultBaudRate)
+		}
+		settings.DefaultBaudRate = *u.DefaultBaudRate
+	}
+	if u.ChartWindowSeconds != nil {
+		if *u.ChartWindowSeconds < 1 || *u.ChartWindowSeconds > 3600 {
+			return settings, fmt.Errorf("chart window must be between 1 and 3600 seconds")
+		}
+		settings.ChartWindowSeconds = *u.ChartWindowSeconds
+	}
+	if u.ConfirmOnExit != nil {
+		settings.ConfirmOnExit = *u.ConfirmOnExit
+	}
+	if u.MinimizeToTray != nil {
+		settings.MinimizeToTray = *u.MinimizeToTray
+	}
+	if u.RecordingDir != nil {
+		if *u.RecordingDir != "" && !filepath.IsAbs(*u.RecordingDir) {
+			return settings, fmt.Errorf("recording folder must be an absolute path")
+		}
+		settings.RecordingDir = *u.RecordingDir
+	}
+	if u.AutoConnect != nil {
+		settings.AutoConnect = *u.AutoConnect
+	}
+	if u.ExportDir != nil {
+		if *u.ExportDir != "" && !filepath.IsAbs(*u.ExportDir) {
+			return settings, fmt.Errorf("export folder must be an absolute path")
+		}
+		settings.ExportDir = *u.ExportDir
+	}
+	if u.DeveloperMode != nil {
+		settings.DeveloperMode = *u.DeveloperMode
+	}
+	if u.ParserMode != nil {
+		switch *u.ParserMode {
+		case ParserModeStrict, ParserModeLenient:
+			settings.ParserMode = *u.ParserMode
+		default:
+			return settings, fmt.Errorf("unknown parser mode '%s'", *u.ParserMode)
+		}
+	}
+	return settings, nil
+}
+
+// Settings are taken, highest precedence first, from
+//
+//  1. command-line flags, e.g. --record-dir /data/recordings
+//  2. environment variables, e.g. MEDIOT_RECORD_DIR=/data/recordings
+//  3. the settings file of the current profile
+//  4. the defaults
+//
+// Flags and environment variables override settings for the run only: they
+// are never saved, and the UI cannot change an overridden setting.
+
+// settingOverrideSpec names the flag and environment variable of a setting
+type settingOverrideSpec struct {
+	setting string // JSON name in Settings, or one of the MQTT overrides
+	flag    string
+	env     string
+	usage   string
+}
+
+// MQTT settings that can be overridden; the MQTT settings keep their own file
+const (
+	overrideMQTTBroker = "mqttBroker"
+	overrideMQTTTopic  = "mqttTopic"
+)
+
+// settingOverrideSpecs lists every setting that can be overridden
+var settingOverrideSpecs = []settingOverrideSpec{
+	{"theme", "theme", "MEDIOT_THEME", "UI theme: system, light or dark"},
+	{"language", "language", "MEDIOT_LANGUAGE", "UI and message language (en, fr, ar)"},
+	{"defaultPort", "default-port", "MEDIOT_DEFAULT_PORT", "default serial port"},
+	{"defaultBaudRate", "default-baud", "MEDIOT_DEFAULT_BAUD", "default baud rate"},
+	{"chartWindowSeconds", "chart-window", "MEDIOT_CHART_WINDOW", "history shown in the live charts, in seconds"},
+	{"confirmOnExit", "confirm-on-exit", "MEDIOT_CONFIRM_ON_EXIT", "ask before quitting while monitoring (true or false)"},
+	{"minimizeToTray", "minimize-to-tray", "MEDIOT_MINIMIZE_TO_TRAY", "closing the window hides it to the tray (true or false)"},
+	{"recordingDir", "record-dir", "MEDIOT_RECORD_DIR", "folder of the recording files"},
+	{"autoConnect", "auto-connect", "MEDIOT_AUTO_CONNECT", "reconnect the last device at startup (true or false)"},
+	{"developerMode", "developer-mode", "MEDIOT_DEVELOPER_MODE", "allow injecting test data as if it came from the device (true or false)"},
+	{"parserMode", "parser-mode", "MEDIOT_PARSER_MODE", "malformed lines: strict quarantines them, lenient keeps their valid values"},
+	{overrideMQTTBroker, "mqtt-broker", "MEDIOT_MQTT_BROKER", "publish every sample to this MQTT broker, e.g. tcp://host:1883"},
+	{overrideMQTTTopic, "mqtt-topic", "MEDIOT_MQTT_TOPIC", "MQTT topic; {device} is replaced by the device ID"},
+}
+
+// SettingOverride is a setting pinned by a flag or an environment variable
+type SettingOverride struct {
+	Setting string `json:"setting"` // JSON name of the setting
+	Source  string `json:"source"`  // Flag or environment variable, e.g. --record-dir
+	Value   string `json:"value"`
+}
+
+// settingOverrides holds the overrides of this run
+type settingOverrides struct {
+	mu     sync.Mutex
+	list   []SettingOverride
+	update SettingsUpdate // The overrides of Settings fields
+}
+
+// overrides is shared because the settings are loaded before the App exists
+var overrides = &settingOverrides{}
+
+// addOverrideFlags adds a flag for every setting that can be overridden
+func addOverrideFlags(flags *flag.FlagSet) {
+	for _, spec := range settingOverrideSpecs {
+		flags.String(spec.flag, "", spec.usage+" (overrides the settings)")
+	}
+}
+
+// parseOverrides reads the overrides of the GUI from the command line and
+// the environment. A bad flag is reported on stderr; the app starts anyway.
+func parseOverrides(args []string) {
+	flags := flag.NewFlagSet("mediot", flag.ContinueOnError)
+	addOverrideFlags(flags)
+	addPortableFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "mediot: %v\n", err)
+	}
+	if err := overrides.load(flags); err != nil {
+		fmt.Fprintf(os.Stderr, "mediot: %v\n", err)
+	}
+}
+
+// load collects the overrides from the environment and the parsed flags,
+// flags winning. An invalid value is reported and ignored.
+func (o *settingOverrides) load(flags *flag.FlagSet) error {
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	var list []SettingOverride
+	var update SettingsUpdate
+	var problems []string
+	for _, spec := range settingOverrideSpecs {
+		override := SettingOverride{Setting: spec.setting}
+		if set[spec.flag] {
+			override.Source = "--" + spec.flag
+			override.Value = flags.Lookup(spec.flag).Value.String()
+		} else if value, ok := os.LookupEnv(spec.env); ok {
+			override.Source = spec.env
+			override.Value = value
+		} else {
+			continue
+		}
+
+		if spec.setting != overrideMQTTBroker && spec.setting != overrideMQTTTopic {
+			if err := update.set(spec.setting, override.Value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", override.Source, err))
+				continue
+			}
+		}
+		list = append(list, override)
+	}
+	if _, err := update.apply(defaultSettings()); err != nil {
+		// Each value decoded, but one is out of range
+		problems = append(problems, err.Error())
+		list, update = nil, SettingsUpdate{}
+	}
+
+	o.mu.Lock()
+	o.list = list
+	o.update = update
+	o.mu.Unlock()
+
+	if len(problems) > 0 {
+		return fmt.Errorf("setting overrides ignored: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// set decodes the override of one Settings field into the update, as JSON
+// for numbers and booleans and as a string otherwise
+func (u *SettingsUpdate) set(setting, value string) error {
+	quoted, _ := json.Marshal(value)
+	for _, raw := range []string{value, string(quoted)} {
+		// A failed decode may have set the field already
+		next := *u
+		if err := json.Unmarshal([]byte(fmt.Sprintf("{%q:%s}", setting, raw)), &next); err == nil {
+			*u = next
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid value '%s'", value)
+}
+
+// apply returns the settings with the overrides applied
+func (o *settingOverrides) apply(settings Settings) Settings {
+	o.mu.Lock()
+	update := o.update
+	o.mu.Unlock()
+
+	result, err := update.apply(settings)
+	if err != nil {
+		// Checked when the overrides were loaded
+		return settings
+	}
+	return result
+}
+
+// source returns the flag or environment variable overriding a setting,
+// empty if it is not overridden
+func (o *settingOverrides) source(setting string) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, override := range o.list {
+		if override.Setting == setting {
+			return override.Source
+		}
+	}
+	return ""
+}
+
+// value returns the value of an override and whether it is set
+func (o *settingOverrides) value(setting string) (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, override := range o.list {
+		if override.Setting == setting {
+			return override.Value, true
+		}
+	}
+	return "", false
+}
+
+// check refuses an update of an overridden setting
+func (o *settingOverrides) check(update SettingsUpdate) error {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	changed := make(map[string]interface{})
+	if err := json.Unmarshal(data, &changed); err != nil {
+		return err
+	}
+	for setting := range changed {
+		if source := o.source(setting); source != "" {
+			return fmt.Errorf("setting '%s' is set by %s for this run and cannot be changed", setting, source)
+		}
+	}
+	return nil
+}
+
+// GetSettingOverrides returns the settings pinned by flags or environment
+// variables for this run, which the UI shows as read-only
+func (a *App) GetSettingOverrides() []SettingOverride {
+	overrides.mu.Lock()
+	defer overrides.mu.Unlock()
+
+	return append([]SettingOverride{}, overrides.list...)
+}
+
+// validate checks settings read from a file as UpdateSettings checks an update
+func (s Settings) validate() error {
+	update := SettingsUpdate{
+		Theme:              &s.Theme,
+		Language:           &s.Language,
+		DefaultPort:        &s.DefaultPort,
+		DefaultBaudRate:    &s.DefaultBaudRate,
+		ChartWindowSeconds: &s.ChartWindowSeconds,
+		ConfirmOnExit:      &s.ConfirmOnExit,
+		MinimizeToTray:     &s.MinimizeToTray,
+		RecordingDir:       &s.RecordingDir,
+		AutoConnect:        &s.AutoConnect,
+		ExportDir:          &s.ExportDir,
+		DeveloperMode:      &s.DeveloperMode,
+		ParserMode:         &s.ParserMode,
+	}
+	_, err := update.apply(defaultSettings())
+	return err
+}
+
+// GetSettings returns the application settings
+func (a *App) GetSettings() Settings {
+	a.settings.mu.Lock()
+	defer a.settings.mu.Unlock()
+
+	return a.settings.settings
+}
+
+// UpdateSettings changes the given settings, persists them and pushes the
+// result as a settings event
+func (a *App) UpdateSettings(update SettingsUpdate) (Settings, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return Settings{}, err
+	}
+	if update.DeveloperMode != nil {
+		if err := a.requireRole(RoleAdmin); err != nil {
+			return Settings{}, err
+		}
+	}
+
+	a.settings.mu.Lock()
+	if a.settings.readOnly {
+		a.settings.mu.Unlock()
+		return Settings{}, fmt.Errorf("settings were written by a newer version of the app and cannot be changed")
+	}
+	if err := overrides.check(update); err != nil {
+		a.settings.mu.Unlock()
+		return Settings{}, err
+	}
+	stored, err := update.apply(a.settings.stored)
+	if err != nil {
+		a.settings.mu.Unlock()
+		return Settings{}, err
+	}
+	if err := saveJSONFile(settingsFile, stored); err != nil {
+		a.settings.mu.Unlock()
+		return Settings{}, err
+	}
+	developer := a.settings.settings.DeveloperMode
+	a.settings.stored = stored
+	settings := overrides.apply(stored)
+	a.settings.settings = settings
+	a.settings.mu.Unlock()
+
+	if settings.DeveloperMode != developer {
+		if settings.DeveloperMode {
+			appLog.Warnf("Developer mode enabled")
+			a.audit.record("", AuditSecurity, "Developer mode enabled")
+		} else {
+			appLog.Infof("Developer mode disabled")
+			a.audit.record("", AuditSecurity, "Developer mode disabled")
+		}
+	}
+	messages.set(settings.Language)
+	a.emit(EventSettings, settings)
+	return settings, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Shutdown waits
+const (
+	firmwareStopTimeout = 5 * time.Second // For a cancelled firmware update
+	loopStopTimeout     = 2 * time.Second // For the background loops to return
+	portReleaseTimeout  = 5 * time.Second // For capability discovery to give the port back
+	emailFlushTimeout   = 5 * time.Second // For the pending alerts to be emailed
+)
+
+// beforeClose hides the window to the tray if the settings ask for it, and
+// otherwise asks before quitting while monitoring or flashing firmware.
+// Returning true keeps the app open.
+func (a *App) beforeClose(ctx context.Context) bool {
+	if a.hideToTray() {
+		return true
+	}
+
+	a.firmware.mu.Lock()
+	flashing := a.firmware.running
+	a.firmware.mu.Unlock()
+
+	a.settings.mu.Lock()
+	confirm := a.settings.settings.ConfirmOnExit
+	a.settings.mu.Unlock()
+
+	var message string
+	switch {
+	case flashing:
+		message = "A firmware update is running. Quitting now cancels it and may leave the device unusable. Quit anyway?"
+	case confirm && a.conn.connected():
+		message = "Monitoring is running. Quit and end the session?"
+	default:
+		return false
+	}
+
+	choice, err := runtime.MessageDialog(ctx, runtime.MessageDialogOptions{
+		Type:          runtime.QuestionDialog,
+		Title:         "Quit mediot",
+		Message:       message,
+		Buttons:       []string{"Yes", "No"},
+		DefaultButton: "No",
+	})
+	if err != nil {
+		appLog.Errorf("Error asking to quit: %v", err)
+		return false
+	}
+	return choice != "Yes"
+}
+
+// shutdown runs when the app quits. It stops the serial reader, cancels a
+// firmware update, closes the console and the port, which ends and seals
+// the running session, stops the plugins, sends the pending alert emails
+// and flushes the log.
+func (a *App) shutdown(ctx context.Context) {
+	appLog.Infof("Shutting down")
+	close(a.quit)
+
+	a.firmware.mu.Lock()
+	flashing := a.firmware.running
+	a.firmware.cancelled = true
+	a.firmware.mu.Unlock()
+	for deadline := time.Now().Add(firmwareStopTimeout); flashing && time.Now().Before(deadline); {
+		time.Sleep(100 * time.Millisecond)
+		a.firmware.mu.Lock()
+		flashing = a.firmware.running
+		a.firmware.mu.Unlock()
+	}
+	if flashing {
+		deviceLog.Warnf("Firmware update did not stop within %v", firmwareStopTimeout)
+	}
+
+	a.console.mu.Lock()
+	console := a.console.running
+	a.console.mu.Unlock()
+	if console {
+		a.StopConsole()
+	}
+
+	// A device that just connected may still be answering capability discovery
+	for deadline := time.Now().Add(portReleaseTimeout); a.conn.ownerName() != "" && time.Now().Before(deadline); {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if a.conn.connected() {
+		if result := a.disconnect(); !result.Success {
+			serialLog.Errorf("Error disconnecting on shutdown: %s", result.Message)
+		}
+	}
+
+	a.plugins.stopAll()
+	flushed := make(chan struct{})
+	go func() {
+		a.flushEmail()
+		close(flushed)
+	}()
+	select {
+	case <-flushed:
+	case <-time.After(emailFlushTimeout):
+		notifyLog.Warnf("Pending alerts not emailed within %v of quitting", emailFlushTimeout)
+	}
+	a.stopTray()
+
+	// Nothing may write to the data directory once shut down
+	stopped := make(chan struct{})
+	go func() {
+		a.loops.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(loopStopTimeout):
+		appLog.Warnf("Background loops did not stop within %v", loopStopTimeout)
+	}
+	appLog.Infof("Shutdown complete")
+	if logFile != nil {
+		logFile.sync()
+	}
+}
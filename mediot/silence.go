@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Alarm silencing limits
+const (
+	maxSilenceDuration = time.Hour   // Longest a single silence or snooze may last
+	silenceCheckPeriod = time.Second // How often expired silences are re-armed
+)
+
+// AlarmSilence is a period during which an alarm made no sound and sent no
+// notifications. Its limits were still evaluated and it was still shown.
+type AlarmSilence struct {
+	Start   time.Time `json:"start"`
+	Until   time.Time `json:"until"`
+	EndedAt time.Time `json:"endedAt"` // When the alarm re-armed, zero while silenced
+	User    string    `json:"user"`
+	Global  bool      `json:"global"` // Silenced together with all alarms rather than snoozed alone
+}
+
+// AlarmSilenceEvent is pushed to the frontend when alarms are silenced or re-armed.
+// AlarmID is 0 for the global silence.
+type AlarmSilenceEvent struct {
+	AlarmID int64     `json:"alarmId"`
+	Until   time.Time `json:"until"` // Zero once re-armed
+	User    string    `json:"user,omitempty"`
+}
+
+// checkSilenceDuration validates a silence duration and returns it
+func checkSilenceDuration(durationSeconds float64) (time.Duration, error) {
+	duration := secondsToDuration(durationSeconds)
+	if duration <= 0 || duration > maxSilenceDuration {
+		return 0, fmt.Errorf("duration must be between 0 and %.0f minutes, got %.0f seconds",
+			maxSilenceDuration.Minutes(), durationSeconds)
+	}
+	return duration, nil
+}
+
+// quiet reports whether an alarm is silenced or snoozed; the caller holds the lock
+func (e *alarmEngine) quiet(alarm *Alarm, now time.Time) bool {
+	return (e.silence != nil && now.Before(e.silence.Until)) || now.Before(alarm.SnoozedUntil)
+}
+
+// globalSilence returns the silence covering all alarms, if any
+func (e *alarmEngine) globalSilence() (AlarmSilence, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.silence == nil {
+		return AlarmSilence{}, false
+	}
+	return *e.silence, true
+}
+
+// activeIDs returns the IDs of the active alarms; the caller holds the lock
+func (e *alarmEngine) activeIDs() []int64 {
+	var ids []int64
+	for _, state := range e.states() {
+		if state.active != nil {
+			ids = append(ids, state.active.ID)
+		}
+	}
+	return ids
+}
+
+// expireSilences re-arms the snoozes and the global silence that ran out.
+// It returns the re-armed alarms and whether the global silence ended, in
+// which case every active alarm is in rearmed.
+func (e *alarmEngine) expireSilences(now time.Time) (rearmed []int64, global bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, state := range e.states() {
+		if alarm := state.active; alarm != nil && !alarm.SnoozedUntil.IsZero() && !now.Before(alarm.SnoozedUntil) {
+			alarm.SnoozedUntil = time.Time{}
+			rearmed = append(rearmed, alarm.ID)
+		}
+	}
+	if e.silence != nil && !now.Before(e.silence.Until) {
+		e.silence = nil
+		return e.activeIDs(), true
+	}
+	return rearmed, false
+}
+
+// silenceLoop re-arms silenced and snoozed alarms once their time is up
+func (a *App) silenceLoop() {
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-time.After(silenceCheckPeriod):
+		}
+
+		now := time.Now()
+		rearmed, global := a.alarms.expireSilences(now)
+		for _, id := range rearmed {
+			a.alarmLog.rearm(id, global, now)
+			if !global {
+				alarmsLog.Infof("Alarm #%d re-armed", id)
+				a.emit(EventAlarmSilence, AlarmSilenceEvent{AlarmID: id})
+			}
+		}
+		if global {
+			alarmsLog.Infof("Alarms re-armed")
+			a.emit(EventAlarmSilence, AlarmSilenceEvent{})
+		}
+	}
+}
+
+// silenceNewAlarm records that an alarm raised during the global silence is silenced too
+func (a *App) silenceNewAlarm(event AlarmEvent) {
+	if event.Previous != SeverityNormal || !event.Silenced {
+		return
+	}
+	if silence, ok := a.alarms.globalSilence(); ok {
+		silence.Start = event.Timestamp
+		a.alarmLog.silence(event.ID, silence)
+	}
+}
+
+// SilenceAlarms mutes the sound and notifications of all alarms, including
+// ones raised meanwhile, for a number of seconds. The limits are still
+// evaluated and the alarms re-arm automatically.
+func (a *App) SilenceAlarms(durationSeconds float64, user string) error {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return err
+	}
+
+	user, err := a.actingUser(user)
+	if err != nil {
+		return err
+	}
+	if user == "" {
+		return fmt.Errorf("user is required")
+	}
+	duration, err := checkSilenceDuration(durationSeconds)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	silence := AlarmSilence{Start: now, Until: now.Add(duration), User: user, Global: true}
+
+	a.alarms.mu.Lock()
+	a.alarms.silence = &silence
+	ids := a.alarms.activeIDs()
+	a.alarms.mu.Unlock()
+
+	for _, id := range ids {
+		a.alarmLog.silence(id, silence)
+	}
+
+	alarmsLog.Infof("Alarms silenced by %s for %s", user, duration)
+	a.audit.record(user, AuditAlarmSilence, fmt.Sprintf("All alarms silenced for %s", duration))
+	a.emit(EventAlarmSilence, AlarmSilenceEvent{Until: silence.Until, User: user})
+	return nil
+}
+
+// ResumeAlarms ends the global silence early
+func (a *App) ResumeAlarms() error {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return err
+	}
+
+	a.alarms.mu.Lock()
+	if a.alarms.silence == nil {
+		a.alarms.mu.Unlock()
+		return fmt.Errorf("alarms are not silenced")
+	}
+	a.alarms.silence = nil
+	ids := a.alarms.activeIDs()
+	a.alarms.mu.Unlock()
+
+	now := time.Now()
+	for _, id := range ids {
+		a.alarmLog.rearm(id, true, now)
+	}
+
+	alarmsLog.Infof("Alarms re-armed")
+	a.audit.record("", AuditAlarmSilence, "All alarms re-armed")
+	a.emit(EventAlarmSilence, AlarmSilenceEvent{})
+	return nil
+}
+
+// GetAlarmSilence returns the silence covering all alarms, or nil if they are armed
+func (a *App) GetAlarmSilence() *AlarmSilence {
+	if silence, ok := a.alarms.globalSilence(); ok {
+		return &silence
+	}
+	return nil
+}
+
+// SnoozeAlarm mutes one active alarm for a number of seconds. The snooze
+// ends early if the alarm escalates.
+func (a *App) SnoozeAlarm(id int64, durationSeconds float64, user string) error {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return err
+	}
+
+	user, err := a.actingUser(user)
+	if err != nil {
+		return err
+	}
+	if user == "" {
+		return fmt.Errorf("user is required")
+	}
+	duration, err := checkSilenceDuration(durationSeconds)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	snooze := AlarmSilence{Start: now, Until: now.Add(duration), User: user}
+
+	a.alarms.mu.Lock()
+	var alarm *Alarm
+	for _, state := range a.alarms.states() {
+		if state.active != nil && state.active.ID == id {
+			alarm = state.active
+		}
+	}
+	if alarm == nil {
+		a.alarms.mu.Unlock()
+		return fmt.Errorf("alarm #%d is not active", id)
+	}
+	alarm.SnoozedUntil = snooze.Until
+	a.alarms.mu.Unlock()
+
+	// A snooze replacing an earlier one closes it first
+	a.alarmLog.rearm(id, false, now)
+	a.alarmLog.silence(id, snooze)
+
+	alarmsLog.Infof("Alarm #%d snoozed by %s for %s", id, user, duration)
+	a.audit.record(user, AuditAlarmSilence, fmt.Sprintf("Alarm #%d snoozed for %s", id, duration))
+	a.emit(EventAlarmSilence, AlarmSilenceEvent{AlarmID: id, Until: snooze.Until, User: user})
+	return nil
+}
+
+// UnsnoozeAlarm re-arms a snoozed alarm early
+func (a *App) UnsnoozeAlarm(id int64) error {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return err
+	}
+
+	a.alarms.mu.Lock()
+	found := false
+	for _, state := range a.alarms.states() {
+		if state.active != nil && state.active.ID == id && !state.active.SnoozedUntil.IsZero() {
+			state.active.SnoozedUntil = time.Time{}
+			found = true
+		}
+	}
+	a.alarms.mu.Unlock()
+	if !found {
+		return fmt.Errorf("alarm #%d is not snoozed", id)
+	}
+
+	a.alarmLog.rearm(id, false, time.Now())
+
+	alarmsLog.Infof("Alarm #%d re-armed", id)
+	a.audit.record("", AuditAlarmSilence, fmt.Sprintf("Alarm #%d re-armed", id))
+	a.emit(EventAlarmSilence, AlarmSilenceEvent{AlarmID: id})
+	return nil
+}
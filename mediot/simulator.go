@@ -0,0 +1,306 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Simulator timing
+const (
+	simulatorTick     = 20 * time.Millisecond // Samples are generated in batches at this interval
+	simulatorDeviceID = "simulator"
+)
+
+// SimulatorConfig configures the built-in waveform simulator. Changes apply
+// while it runs.
+type SimulatorConfig struct {
+	SampleRateHz      float64 `json:"sampleRateHz"`
+	HeartRate         float64 `json:"heartRate"`         // Beats per minute of the ECG and PPG
+	RespiratoryRate   float64 `json:"respiratoryRate"`   // Breaths per minute modulating the PPG baseline
+	SpO2              float64 `json:"spo2"`              // Saturation encoded in the red/IR ratio
+	NoiseLevel        float64 `json:"noiseLevel"`        // Gaussian noise relative to each waveform's amplitude, 0..1
+	DropoutsPerMinute float64 `json:"dropoutsPerMinute"` // Average rate of gaps in the stream
+	DropoutSeconds    float64 `json:"dropoutSeconds"`    // Length of each gap
+}
+
+// defaultSimulatorConfig is a resting adult at 250 Hz without noise or dropouts
+func defaultSimulatorConfig() SimulatorConfig {
+	return SimulatorConfig{
+		SampleRateHz:    250,
+		HeartRate:       72,
+		RespiratoryRate: 15,
+		SpO2:            97,
+	}
+}
+
+// ecgWave is one Gaussian component of the synthetic PQRST complex
+type ecgWave struct {
+	offset    float64 // Seconds from the R peak; P and T scale with the beat length
+	amplitude float64 // mV
+	width     float64 // Seconds
+	scales    bool
+}
+
+// ecgWaves shape a normal sinus beat
+var ecgWaves = []ecgWave{
+	{offset: -0.2, amplitude: 0.15, width: 0.025, scales: true}, // P
+	{offset: -0.03, amplitude: -0.1, width: 0.01},               // Q
+	{offset: 0, amplitude: 1.2, width: 0.012},                   // R
+	{offset: 0.03, amplitude: -0.25, width: 0.01},               // S
+	{offset: 0.3, amplitude: 0.3, width: 0.05, scales: true},    // T
+}
+
+// waveformSimulator generates physiological waveforms as if a device sent them
+type waveformSimulator struct {
+	mu      sync.Mutex
+	config  SimulatorConfig
+	running bool
+	stop    chan struct{}
+	random  *rand.Rand
+
+	beatPhase    float64 // Fraction of the current beat elapsed
+	breathPhase  float64
+	sinceR       float64 // Seconds since the last R peak
+	lastRR       float64 // Length of the previous beat in seconds
+	dropoutUntil time.Time
+}
+
+// newWaveformSimulator creates a stopped simulator
+func newWaveformSimulator() *waveformSimulator {
+	return &waveformSimulator{
+		config: defaultSimulatorConfig(),
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// checkSimulatorConfig validates the simulator settings
+func checkSimulatorConfig(config SimulatorConfig) error {
+	switch {
+	case config.SampleRateHz < 10 || config.SampleRateHz > 2000:
+		return fmt.Errorf("sample rate must be between 10 and 2000 Hz, got %g", config.SampleRateHz)
+	case config.HeartRate < 20 || config.HeartRate > 300:
+		return fmt.Errorf("heart rate must be between 20 and 300 bpm, got %g", config.HeartRate)
+	case config.RespiratoryRate < 2 || config.RespiratoryRate > 120:
+		return fmt.Errorf("respiratory rate must be between 2 and 120 breaths/min, got %g", config.RespiratoryRate)
+	case config.SpO2 < 50 || config.SpO2 > 100:
+		return fmt.Errorf("SpO2 must be between 50 and 100 %%, got %g", config.SpO2)
+	case config.NoiseLevel < 0 || config.NoiseLevel > 1:
+		return fmt.Errorf("noise level must be between 0 and 1, got %g", config.NoiseLevel)
+	case config.DropoutsPerMinute < 0 || config.DropoutSeconds < 0:
+		return fmt.Errorf("dropout settings must not be negative")
+	}
+	return nil
+}
+
+// ppgPulse is the normalised shape of a PPG pulse at a fraction of the beat:
+// a systolic peak followed by a smaller diastolic wave
+func ppgPulse(phase float64) float64 {
+	systolic := math.Exp(-math.Pow((phase-0.15)/0.07, 2))
+	diastolic := 0.35 * math.Exp(-math.Pow((phase-0.45)/0.1, 2))
+	return systolic + diastolic
+}
+
+// next advances the simulation by dt and returns the sample at the new time,
+// or false while a dropout is in progress; the caller holds the lock
+func (s *waveformSimulator) next(dt float64, at time.Time) (SensorData, bool) {
+	config := s.config
+	rr := 60 / config.HeartRate
+
+	s.sinceR += dt
+	s.beatPhase += dt / rr
+	if s.beatPhase >= 1 {
+		s.beatPhase -= 1
+		s.lastRR = s.sinceR
+		s.sinceR = s.beatPhase * rr
+	}
+	s.breathPhase = math.Mod(s.breathPhase+dt*config.RespiratoryRate/60, 1)
+
+	if config.DropoutsPerMinute > 0 && at.After(s.dropoutUntil) &&
+		s.random.Float64() < dt*config.DropoutsPerMinute/60 {
+		s.dropoutUntil = at.Add(secondsToDuration(config.DropoutSeconds))
+	}
+	if at.Before(s.dropoutUntil) {
+		return SensorData{}, false
+	}
+
+	// ECG: the PQRST waves around the current R peak, and the T wave of the
+	// previous beat reaching into this one
+	ecg := 0.0
+	for _, w := range ecgWaves {
+		offset := w.offset
+		if w.scales {
+			offset *= math.Sqrt(rr)
+		}
+		for _, t := range []float64{s.sinceR, s.sinceR + s.lastRR, s.sinceR - rr} {
+			ecg += w.amplitude * math.Exp(-math.Pow((t-offset)/w.width, 2))
+		}
+	}
+
+	// PPG: the IR pulse has a 2 % perfusion index; the red one is scaled by
+	// the ratio of ratios of the empirical curve SpO2 = 110 - 25R. Breathing
+	// modulates both baselines.
+	ratio := (110 - config.SpO2) / 25
+	baseline := 1 + 0.01*math.Sin(2*math.Pi*s.breathPhase)
+	pulse := ppgPulse(s.beatPhase)
+	ir := 100 * baseline * (1 + 0.02*pulse)
+	red := 100 * baseline * (1 + 0.02*ratio*pulse)
+
+	noise := config.NoiseLevel
+	return SensorData{
+		Value1:    ecg + noise*1.2*s.random.NormFloat64(),
+		Value2:    red + noise*2*ratio*s.random.NormFloat64(),
+		Value3:    ir + noise*2*s.random.NormFloat64(),
+		Timestamp: at,
+	}, true
+}
+
+// simulatorLoop feeds simulated samples through the pipeline at the configured rate
+func (a *App) simulatorLoop(stop chan struct{}) {
+	ticker := time.NewTicker(simulatorTick)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			a.simulator.mu.Lock()
+			period := 1 / a.simulator.config.SampleRateHz
+			var samples []SensorData
+			for t := last.Add(secondsToDuration(period)); !t.After(now); t = t.Add(secondsToDuration(period)) {
+				if sample, ok := a.simulator.next(period, t); ok {
+					samples = append(samples, sample)
+				}
+				last = t
+			}
+			a.simulator.mu.Unlock()
+
+			if a.faults.enabled() {
+				// Sent as lines so the faults hit the line handling too;
+				// split reads arrive one after the other
+				data := a.faults.apply(encodeSamples(samples), maxFaultHeld)
+				for len(data) > 0 {
+					a.receive(data)
+					data = a.faults.apply(nil, maxFaultHeld)
+				}
+				continue
+			}
+			a.bufferMutex.Lock()
+			for i := range samples {
+				a.processSample(&samples[i])
+				a.parsedDataBuffer = append(a.parsedDataBuffer, samples[i])
+				a.perf.processed(samples[i].Timestamp, time.Now())
+			}
+			a.bufferMutex.Unlock()
+		}
+	}
+}
+
+// StartSimulator connects the built-in waveform simulator in place of a
+// serial device. Its samples run through the full pipeline.
+func (a *App) StartSimulator(config SimulatorConfig) error {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return err
+	}
+	telemetry.count(FeatureSimulator)
+
+	if err := checkSimulatorConfig(config); err != nil {
+		return err
+	}
+	if atRest.locked() {
+		return errStorageLocked
+	}
+	if err := a.beginConnection(simulatorDeviceID, 0); err != nil {
+		return err
+	}
+
+	a.simulator.mu.Lock()
+	a.simulator.config = config
+	a.simulator.running = true
+	a.simulator.beatPhase, a.simulator.breathPhase = 0, 0
+	a.simulator.sinceR, a.simulator.lastRR = 0, 60/config.HeartRate
+	a.simulator.dropoutUntil = time.Time{}
+	a.simulator.stop = make(chan struct{})
+	stop := a.simulator.stop
+	a.simulator.mu.Unlock()
+
+	// Its lines are decimal when faults are injected
+	a.bufferMutex.Lock()
+	a.parser, a.lenient, a.lastRaw, a.offParse = parseDecimalData, decimalFields, nil, false
+	a.dataBuffer = a.dataBuffer[:0]
+	a.bufferMutex.Unlock()
+
+	a.clock.mark(time.Now())
+	a.startSession(Device{ID: simulatorDeviceID, Name: "Simulator", LastPort: simulatorDeviceID}, 0)
+	a.setConnection(ConnectionConnected, "simulator started", nil)
+	goSafe("simulator", func() { a.simulatorLoop(stop) })
+
+	deviceLog.Infof("Simulator started at %g Hz, %g bpm", config.SampleRateHz, config.HeartRate)
+	a.audit.record("", AuditConnect, "Simulator started")
+	return nil
+}
+
+// SetSimulatorConfig changes the waveforms of the running simulator
+func (a *App) SetSimulatorConfig(config SimulatorConfig) error {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return err
+	}
+
+	if err := checkSimulatorConfig(config); err != nil {
+		return err
+	}
+
+	a.simulator.mu.Lock()
+	defer a.simulator.mu.Unlock()
+
+	a.simulator.config = config
+	return nil
+}
+
+// GetSimulatorConfig returns the simulator settings
+func (a *App) GetSimulatorConfig() SimulatorConfig {
+	a.simulator.mu.Lock()
+	defer a.simulator.mu.Unlock()
+
+	return a.simulator.config
+}
+
+// IsSimulatorRunning reports whether the simulator is standing in for a device
+func (a *App) IsSimulatorRunning() bool {
+	a.simulator.mu.Lock()
+	defer a.simulator.mu.Unlock()
+
+	return a.simulator.running
+}
+
+// StopSimulator disconnects the simulator
+func (a *App) StopSimulator() error {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return err
+	}
+	return a.stopSimulator()
+}
+
+// stopSimulator is StopSimulator without the role check, for shutdown
+func (a *App) stopSimulator() error {
+	a.simulator.mu.Lock()
+	if !a.simulator.running {
+		a.simulator.mu.Unlock()
+		return fmt.Errorf("simulator is not running")
+	}
+	close(a.simulator.stop)
+	a.simulator.running = false
+	a.simulator.mu.Unlock()
+
+	a.setConnection(ConnectionDisconnected, "simulator stopped", nil)
+	a.endSession()
+	a.latest.reset()
+
+	deviceLog.Infof("Simulator stopped")
+	a.audit.record("", AuditDisconnect, "Simulator stopped")
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Sleep detection
+const (
+	sleepCheckInterval = 2 * time.Second
+	sleepThreshold     = 10 * time.Second // Lateness of a check that counts as the system having slept
+)
+
+// sleepWatchLoop detects that the system was suspended. The wall clock
+// keeps running while the machine sleeps on every OS, so a check that
+// comes much later than scheduled means it slept, or that the clock was
+// set forward, which is handled the same way.
+func (a *App) sleepWatchLoop() {
+	ticker := time.NewTicker(sleepCheckInterval)
+	defer ticker.Stop()
+
+	last := time.Now().Round(0)
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-ticker.C:
+		}
+		now := time.Now().Round(0)
+		if now.Sub(last)-sleepCheckInterval >= sleepThreshold {
+			a.systemResumed(last, now)
+		}
+		last = now
+	}
+}
+
+// systemResumed handles a resume after the system slept from about from
+// until to. The serial handle does not survive a suspend, so a device
+// connection is treated as lost and reopened; the session records a gap.
+func (a *App) systemResumed(from, to time.Time) {
+	appLog.Infof("System resumed after about %v asleep", to.Sub(from).Round(time.Second))
+
+	reason := fmt.Sprintf("system asleep for about %v", to.Sub(from).Round(time.Second))
+	status, port := a.conn.current()
+	switch {
+	case status.State == ConnectionConnected && port != nil:
+		a.connectionLost(port, reason, from)
+	case status.State == ConnectionConnected:
+		// The simulator keeps running; only the missing data is recorded
+		a.sessions.beginGap(from, reason)
+		a.sessions.endGap(to)
+	case status.State == ConnectionReconnecting:
+		// The device was lost around the suspend; the gap starts at the suspend
+		a.sessions.beginGap(from, reason)
+	}
+}
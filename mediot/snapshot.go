@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// snapshotFilePattern matches the snapshot files in the recording folder
+const snapshotFilePattern = "mediot-snapshot-*.json"
+
+// SnapshotPoint is one reading of a channel in a snapshot
+type SnapshotPoint struct {
+	Time  time.Time `json:"t"`
+	Value float64   `json:"v"`
+}
+
+// DataSnapshot is what the charts showed at one moment, kept as data
+// rather than as a screenshot so it can be analysed later
+type DataSnapshot struct {
+	TakenAt     time.Time                  `json:"takenAt"`
+	SessionID   int64                      `json:"sessionId"` // 0 while disconnected
+	Device      string                     `json:"device,omitempty"`
+	Values      map[string]float64         `json:"values"`   // Latest value of every channel
+	Channels    map[string][]SnapshotPoint `json:"channels"` // Readings of the chart window of the settings
+	Units       map[string]string          `json:"units"`
+	Alarms      []Alarm                    `json:"alarms"` // Active alarms
+	Annotations []SessionAnnotation        `json:"annotations"`
+}
+
+// TakeSnapshot writes the readings of the chart window, the latest values
+// and the active alarms to a JSON file in the recording folder, and
+// returns its path. The file is encrypted while encryption at rest is
+// enabled.
+func (a *App) TakeSnapshot() (string, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	window := time.Duration(a.GetSettings().ChartWindowSeconds * float64(time.Second))
+	snapshot := DataSnapshot{
+		TakenAt:     now,
+		Values:      a.latest.snapshot(),
+		Channels:    make(map[string][]SnapshotPoint),
+		Units:       a.GetChannelUnits(),
+		Alarms:      a.GetActiveAlarms(),
+		Annotations: make([]SessionAnnotation, 0),
+	}
+	if session := a.currentSession(); session != nil {
+		snapshot.SessionID = session.ID
+		snapshot.Device = session.Device
+		snapshot.Annotations = session.Annotations
+	}
+	names := a.history.names()
+	sort.Strings(names)
+	for _, name := range names {
+		values := a.history.recent(name, window)
+		points := make([]SnapshotPoint, len(values))
+		for i, value := range values {
+			points[i] = SnapshotPoint{Time: value.t, Value: value.v}
+		}
+		snapshot.Channels[name] = points
+	}
+
+	dir, err := a.recordingDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create recording folder: %v", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("mediot-snapshot-%s.json", now.Format(crashTimestampForm)))
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err == nil {
+		data, err = atRest.encrypt(filepath.Base(path), data)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write snapshot: %v", err)
+	}
+
+	appLog.Infof("Data snapshot saved to %s", path)
+	a.audit.record("", AuditExport, fmt.Sprintf("Data snapshot saved to %s", path))
+	return path, nil
+}
+
+// sessionSnapshotFiles returns the snapshot files of a folder taken during
+// one of the sessions. Snapshots that cannot be read are skipped.
+func sessionSnapshotFiles(dir string, sessions map[int64]bool) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, snapshotFilePattern))
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]string, 0)
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			data, err = atRest.decrypt(data)
+		}
+		var snapshot struct {
+			SessionID int64 `json:"sessionId"`
+		}
+		if err == nil {
+			err = json.Unmarshal(data, &snapshot)
+		}
+		if err != nil {
+			appLog.Warnf("Skipping snapshot %s: %v", path, err)
+			continue
+		}
+		if sessions[snapshot.SessionID] {
+			matched = append(matched, path)
+		}
+	}
+	return matched, nil
+}
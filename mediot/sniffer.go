@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// Serial sniffer
+const (
+	snifferRowSize       = 16 // Bytes per dump row, as in terminal hex dumps
+	maxSnifferRecords    = 4096
+	snifferFlushInterval = 100 * time.Millisecond // New rows are pushed in batches at most this often
+)
+
+// Directions of sniffed bytes
+const (
+	SnifferRx       = "rx"       // Received from the device
+	SnifferTx       = "tx"       // Sent to the device
+	SnifferInjected = "injected" // Injected in developer mode, never on the wire
+)
+
+// SnifferRecord is one row of the raw dump of the serial traffic
+type SnifferRecord struct {
+	Seq       int64     `json:"seq"`
+	Timestamp time.Time `json:"timestamp"`
+	Direction string    `json:"direction"`
+	Hex       string    `json:"hex"`   // Bytes as hex pairs separated by spaces
+	ASCII     string    `json:"ascii"` // Printable bytes, others as '.'
+	Length    int       `json:"length"`
+}
+
+// SnifferStatus describes the serial sniffer
+type SnifferStatus struct {
+	Enabled bool  `json:"enabled"`
+	Records int   `json:"records"` // Rows in the ring
+	Dropped int64 `json:"dropped"` // Rows pushed out of the ring since it was cleared
+}
+
+// serialSniffer dumps the bytes read from and written to the serial port
+// while enabled. It sees the traffic of every subsystem using the port,
+// including the console and firmware updates.
+type serialSniffer struct {
+	mu      sync.Mutex
+	enabled bool
+	records []SnifferRecord // Ring of the most recent rows, oldest first
+	pending []SnifferRecord // Rows not pushed to the frontend yet
+	nextSeq int64
+	dropped int64
+}
+
+// newSerialSniffer creates a disabled sniffer
+func newSerialSniffer() *serialSniffer {
+	return &serialSniffer{records: make([]SnifferRecord, 0), nextSeq: 1}
+}
+
+// dumpRow formats bytes as a hex dump row
+func dumpRow(data []byte) (string, string) {
+	pairs := make([]string, len(data))
+	ascii := make([]byte, len(data))
+	for i, b := range data {
+		pairs[i] = hex.EncodeToString([]byte{b})
+		if b >= 0x20 && b < 0x7f {
+			ascii[i] = b
+		} else {
+			ascii[i] = '.'
+		}
+	}
+	return strings.Join(pairs, " "), string(ascii)
+}
+
+// record adds bytes to the dump while enabled
+func (s *serialSniffer) record(direction string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.enabled || len(data) == 0 {
+		return
+	}
+	now := time.Now()
+	for start := 0; start < len(data); start += snifferRowSize {
+		row := data[start:min(start+snifferRowSize, len(data))]
+		hexText, ascii := dumpRow(row)
+		record := SnifferRecord{Seq: s.nextSeq, Timestamp: now, Direction: direction, Hex: hexText, ASCII: ascii, Length: len(row)}
+		s.nextSeq++
+
+		s.records = append(s.records, record)
+		if len(s.records) > maxSnifferRecords {
+			s.records = s.records[1:]
+			s.dropped++
+		}
+		s.pending = append(s.pending, record)
+		if len(s.pending) > maxSnifferRecords {
+			s.pending = s.pending[1:]
+		}
+	}
+}
+
+// takePending returns the rows not pushed yet
+func (s *serialSniffer) takePending() []SnifferRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := s.pending
+	s.pending = nil
+	return pending
+}
+
+// status returns the state of the sniffer
+func (s *serialSniffer) status() SnifferStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return SnifferStatus{Enabled: s.enabled, Records: len(s.records), Dropped: s.dropped}
+}
+
+// sniffedPort is a serial port whose traffic goes through the sniffer
+type sniffedPort struct {
+	serial.Port
+	sniffer *serialSniffer
+}
+
+// wrap returns a port whose traffic is recorded while the sniffer is enabled
+func (s *serialSniffer) wrap(port serial.Port) serial.Port {
+	return sniffedPort{Port: port, sniffer: s}
+}
+
+func (p sniffedPort) Read(buf []byte) (int, error) {
+	n, err := p.Port.Read(buf)
+	if n > 0 {
+		p.sniffer.record(SnifferRx, buf[:n])
+	}
+	return n, err
+}
+
+func (p sniffedPort) Write(data []byte) (int, error) {
+	n, err := p.Port.Write(data)
+	if n > 0 {
+		p.sniffer.record(SnifferTx, data[:n])
+	}
+	return n, err
+}
+
+// snifferLoop pushes the new dump rows to the frontend in batches
+func (a *App) snifferLoop() {
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-time.After(snifferFlushInterval):
+		}
+		if pending := a.sniffer.takePending(); len(pending) > 0 {
+			a.emit(EventSerialSniffer, pending)
+		}
+	}
+}
+
+// SetSnifferEnabled starts or stops dumping the raw serial traffic. Rows
+// are pushed with serial-sniffer events and kept in a ring for
+// GetSnifferRecords. It is not persisted.
+func (a *App) SetSnifferEnabled(enabled bool) (SnifferStatus, error) {
+	if err := a.requireRole(RoleOperator); err != nil {
+		return SnifferStatus{}, err
+	}
+
+	a.sniffer.mu.Lock()
+	changed := a.sniffer.enabled != enabled
+	a.sniffer.enabled = enabled
+	a.sniffer.mu.Unlock()
+
+	if changed && enabled {
+		serialLog.Infof("Serial sniffer started")
+	} else if changed {
+		serialLog.Infof("Serial sniffer stopped")
+	}
+	return a.sniffer.status(), nil
+}
+
+// GetSnifferStatus returns whether the serial traffic is dumped
+func (a *App) GetSnifferStatus() SnifferStatus {
+	return a.sniffer.status()
+}
+
+// GetSnifferRecords returns the most recent dump rows, oldest first;
+// limit 0 returns all of the ring
+func (a *App) GetSnifferRecords(limit int) ([]SnifferRecord, error) {
+	if err := a.requireReadRole(RoleOperator); err != nil {
+		return nil, err
+	}
+
+	a.sniffer.mu.Lock()
+	defer a.sniffer.mu.Unlock()
+
+	records := a.sniffer.records
+	if limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+	return append([]SnifferRecord{}, records...), nil
+}
+
+// ClearSniffer empties the dump ring
+func (a *App) ClearSniffer() {
+	a.sniffer.mu.Lock()
+	defer a.sniffer.mu.Unlock()
+
+	a.sniffer.records = make([]SnifferRecord, 0)
+	a.sniffer.pending = nil
+	a.sniffer.dropped = 0
+}
@@ -0,0 +1,8 @@
+package main
+
+import "os/exec"
+
+// playSoundFile plays a WAV file with the built-in afplay
+func playSoundFile(path string) error {
+	return exec.Command("afplay", path).Run()
+}
@@ -0,0 +1,11 @@
+package main
+
+import "os/exec"
+
+// playSoundFile plays a WAV file through PulseAudio, falling back to ALSA
+func playSoundFile(path string) error {
+	if err := exec.Command("paplay", path).Run(); err == nil {
+		return nil
+	}
+	return exec.Command("aplay", "-q", path).Run()
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import "errors"
+
+// playSoundFile is not available on this platform
+func playSoundFile(path string) error {
+	return errors.New("audible alarms are not supported on this platform")
+}
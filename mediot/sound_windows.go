@@ -0,0 +1,16 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// playSoundFile plays a WAV file through System.Media.SoundPlayer
+func playSoundFile(path string) error {
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command",
+		"(New-Object Media.SoundPlayer $env:MEDIOT_SOUND_FILE).PlaySync()")
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true, CreationFlags: createNoWindow}
+	cmd.Env = append(os.Environ(), "MEDIOT_SOUND_FILE="+path)
+	return cmd.Run()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// SpO2Config configures the SpO2 computation from the red/IR PPG channels
+type SpO2Config struct {
+	Enabled       bool    `json:"enabled"`
+	RedChannel    string  `json:"redChannel"`
+	IRChannel     string  `json:"irChannel"`
+	WindowSeconds float64 `json:"windowSeconds"` // Length of the AC/DC analysis window
+	// Calibration curve SpO2 = A + B*R + C*R^2, where R is the ratio of ratios
+	CalibrationA float64 `json:"calibrationA"`
+	CalibrationB float64 `json:"calibrationB"`
+	CalibrationC float64 `json:"calibrationC"`
+}
+
+// defaultSpO2Config uses the common empirical linear curve SpO2 = 110 - 25R
+func defaultSpO2Config() SpO2Config {
+	return SpO2Config{
+		Enabled:       true,
+		RedChannel:    ChannelValue2,
+		IRChannel:     ChannelValue3,
+		WindowSeconds: 4,
+		CalibrationA:  110,
+		CalibrationB:  -25,
+		CalibrationC:  0,
+	}
+}
+
+// minSpO2Samples is the minimum number of readings needed per window
+const minSpO2Samples = 10
+
+// spo2Processor derives SpO2 and perfusion index from the red/IR channels
+type spo2Processor struct {
+	mu     sync.Mutex
+	config SpO2Config
+	red    *timedWindow
+	ir     *timedWindow
+}
+
+// newSpO2Processor creates the SpO2 stage with the default configuration
+func newSpO2Processor() *spo2Processor {
+	config := defaultSpO2Config()
+	span := secondsToDuration(config.WindowSeconds)
+	return &spo2Processor{
+		config: config,
+		red:    newTimedWindow(span),
+		ir:     newTimedWindow(span),
+	}
+}
+
+func (p *spo2Processor) process(sample *SensorData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.config.Enabled {
+		return
+	}
+
+	red, okRed := sample.channelValue(p.config.RedChannel)
+	ir, okIR := sample.channelValue(p.config.IRChannel)
+	if !okRed || !okIR || sample.isArtifact(p.config.RedChannel) || sample.isArtifact(p.config.IRChannel) {
+		return
+	}
+
+	p.red.push(sample.Timestamp, red)
+	p.ir.push(sample.Timestamp, ir)
+
+	// Wait until at least half a window has been collected
+	span := secondsToDuration(p.config.WindowSeconds)
+	if p.ir.len() < minSpO2Samples || p.ir.coverage() < span/2 {
+		return
+	}
+
+	redMin, redMax, redDC := p.red.minMaxMean()
+	irMin, irMax, irDC := p.ir.minMaxMean()
+	redAC := redMax - redMin
+	irAC := irMax - irMin
+
+	// PPG counts are positive; anything else means the channel isn't a PPG
+	if redDC <= 0 || irDC <= 0 || irAC == 0 {
+		return
+	}
+
+	ratio := (redAC / redDC) / (irAC / irDC)
+	spo2 := p.config.CalibrationA + p.config.CalibrationB*ratio + p.config.CalibrationC*ratio*ratio
+
+	sample.setDerived(ChannelSpO2, math.Max(0, math.Min(100, spo2)))
+	sample.setDerived(ChannelPerfusionIndex, irAC/irDC*100)
+}
+
+// GetSpO2Config returns the current SpO2 computation settings
+func (a *App) GetSpO2Config() SpO2Config {
+	a.spo2.mu.Lock()
+	defer a.spo2.mu.Unlock()
+
+	return a.spo2.config
+}
+
+// SetSpO2Config replaces the SpO2 computation settings and restarts the analysis window
+func (a *App) SetSpO2Config(config SpO2Config) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if config.RedChannel == "" || config.IRChannel == "" {
+		return fmt.Errorf("red and IR channels are required")
+	}
+	if config.WindowSeconds <= 0 {
+		return fmt.Errorf("window must be positive, got %.2f s", config.WindowSeconds)
+	}
+
+	a.spo2.mu.Lock()
+	defer a.spo2.mu.Unlock()
+
+	span := secondsToDuration(config.WindowSeconds)
+	a.spo2.config = config
+	a.spo2.red.reset(span)
+	a.spo2.ir.reset(span)
+	return nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ChannelStats summarizes the readings of a channel over a time window
+type ChannelStats struct {
+	Channel       string  `json:"channel"`
+	WindowSeconds float64 `json:"windowSeconds"`
+	Count         int     `json:"count"`
+	Min           float64 `json:"min"`
+	Max           float64 `json:"max"`
+	Mean          float64 `json:"mean"`
+	Median        float64 `json:"median"`
+	RMS           float64 `json:"rms"`
+	StdDev        float64 `json:"stdDev"`
+}
+
+// statsIdleTimeout drops trackers that no dashboard tile has asked for recently
+const statsIdleTimeout = time.Minute
+
+// sortedValues is a multiset of readings kept in ascending order
+type sortedValues []float64
+
+// insert adds a value while keeping the order
+func (s *sortedValues) insert(v float64) {
+	i := sort.SearchFloat64s(*s, v)
+	*s = append(*s, 0)
+	copy((*s)[i+1:], (*s)[i:])
+	(*s)[i] = v
+}
+
+// remove deletes one occurrence of the value
+func (s *sortedValues) remove(v float64) {
+	i := sort.SearchFloat64s(*s, v)
+	if i < len(*s) && (*s)[i] == v {
+		*s = append((*s)[:i], (*s)[i+1:]...)
+	}
+}
+
+// quantile returns the q-quantile (0..1) with linear interpolation
+func (s sortedValues) quantile(q float64) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	pos := q * float64(len(s)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	frac := pos - float64(lower)
+	return s[lower] + (s[upper]-s[lower])*frac
+}
+
+// statsTracker maintains the statistics of one channel/window pair incrementally
+type statsTracker struct {
+	window     *timedWindow
+	sorted     sortedValues
+	sum        float64
+	sumSquares float64
+	lastQuery  time.Time
+}
+
+// newStatsTracker creates a tracker seeded with the readings already in the window
+func newStatsTracker(span time.Duration, seed []timedValue) *statsTracker {
+	t := &statsTracker{
+		window:    newTimedWindow(span),
+		sorted:    make(sortedValues, 0, len(seed)),
+		lastQuery: time.Now(),
+	}
+	for _, tv := range seed {
+		t.add(tv.t, tv.v)
+	}
+	return t
+}
+
+// add accounts for a new reading and forgets the ones that left the window
+func (t *statsTracker) add(at time.Time, v float64) {
+	t.sorted.insert(v)
+	t.sum += v
+	t.sumSquares += v * v
+	t.forget(t.window.push(at, v))
+}
+
+// expire forgets the readings older than one window before now
+func (t *statsTracker) expire(now time.Time) {
+	t.forget(t.window.evict(now))
+}
+
+// forget removes evicted readings from the running aggregates
+func (t *statsTracker) forget(evicted []timedValue) {
+	for _, old := range evicted {
+		t.sorted.remove(old.v)
+		t.sum -= old.v
+		t.sumSquares -= old.v * old.v
+	}
+}
+
+// snapshot computes the statistics from the running aggregates
+func (t *statsTracker) snapshot() ChannelStats {
+	n := len(t.sorted)
+	if n == 0 {
+		return ChannelStats{}
+	}
+
+	mean := t.sum / float64(n)
+	meanSquare := t.sumSquares / float64(n)
+	// Running sums can drift slightly negative for constant signals
+	variance := math.Max(0, meanSquare-mean*mean)
+
+	return ChannelStats{
+		Count:  n,
+		Min:    t.sorted[0],
+		Max:    t.sorted[n-1],
+		Mean:   mean,
+		Median: t.sorted.quantile(0.5),
+		RMS:    math.Sqrt(meanSquare),
+		StdDev: math.Sqrt(variance),
+	}
+}
+
+// statsKey identifies a tracker by channel and window length
+type statsKey struct {
+	channel string
+	span    time.Duration
+}
+
+// statsProcessor feeds every sample into the trackers requested by the frontend
+type statsProcessor struct {
+	mu       sync.Mutex
+	history  *channelHistory
+	trackers map[statsKey]*statsTracker
+}
+
+// newStatsProcessor creates the statistics stage backed by the channel history
+func newStatsProcessor(history *channelHistory) *statsProcessor {
+	return &statsProcessor{
+		history:  history,
+		trackers: make(map[statsKey]*statsTracker),
+	}
+}
+
+func (p *statsProcessor) process(sample *SensorData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, tracker := range p.trackers {
+		if sample.Timestamp.Sub(tracker.lastQuery) > statsIdleTimeout {
+			delete(p.trackers, key)
+			continue
+		}
+		// Skip samples already picked up when the tracker was seeded from the history
+		if n := tracker.window.len(); n > 0 && !sample.Timestamp.After(tracker.window.values[n-1].t) {
+			continue
+		}
+		if value, ok := sample.channelValue(key.channel); ok && !sample.isArtifact(key.channel) {
+			tracker.add(sample.Timestamp, value)
+		}
+	}
+}
+
+// GetChannelStats returns min, max, mean, median, RMS, std-dev and sample
+// count of a channel over the last windowSeconds. The first request for a
+// channel/window pair seeds a tracker from the history; later requests are
+// served from the incrementally maintained aggregates.
+func (a *App) GetChannelStats(channel string, windowSeconds float64) (ChannelStats, error) {
+	span := secondsToDuration(windowSeconds)
+	if span <= 0 || span > historyRetention {
+		return ChannelStats{}, fmt.Errorf("window must be between 0 and %.0f s, got %.2f s",
+			historyRetention.Seconds(), windowSeconds)
+	}
+	if !a.history.has(channel) {
+		return ChannelStats{}, fmt.Errorf("unknown channel '%s'", channel)
+	}
+
+	a.stats.mu.Lock()
+	defer a.stats.mu.Unlock()
+
+	key := statsKey{channel: channel, span: span}
+	tracker, ok := a.stats.trackers[key]
+	if !ok {
+		tracker = newStatsTracker(span, a.history.recent(channel, span))
+		a.stats.trackers[key] = tracker
+	}
+
+	now := time.Now()
+	tracker.lastQuery = now
+	tracker.expire(now)
+
+	result := tracker.snapshot()
+	result.Channel = channel
+	result.WindowSeconds = windowSeconds
+	return result, nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Portable mode keeps every file beside the executable, e.g. on a USB stick
+// a technician carries between machines
+const (
+	portableMarker  = "portable.txt" // Beside the executable, turns portable mode on
+	portableDirName = "mediot-data"
+)
+
+// portableDir is the data directory in portable mode, empty otherwise
+var portableDir string
+
+// addPortableFlag adds the flag turning portable mode on
+func addPortableFlag(flags *flag.FlagSet) {
+	flags.Bool("portable", false, "keep settings, sessions and logs beside the executable")
+}
+
+// setupPortable turns portable mode on when the command line asks for it
+// or portable.txt is beside the executable. It runs before any file is
+// read.
+func setupPortable(args []string) {
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mediot: cannot locate the executable, portable mode unavailable: %v\n", err)
+		return
+	}
+	dir := filepath.Dir(exe)
+	// In a macOS app bundle the executable is in mediot.app/Contents/MacOS
+	if bundle := filepath.Dir(filepath.Dir(dir)); strings.HasSuffix(bundle, ".app") {
+		dir = filepath.Dir(bundle)
+	}
+
+	if !flagRequested(args, "portable") {
+		if _, err := os.Stat(filepath.Join(dir, portableMarker)); err != nil {
+			return
+		}
+	}
+	portableDir = filepath.Join(dir, portableDirName)
+}
+
+// flagRequested reports whether the command line has a boolean flag, in
+// any of the forms the flag package accepts
+func flagRequested(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == "--"+name || arg == "-"+name || strings.HasPrefix(arg, "--"+name+"=") || strings.HasPrefix(arg, "-"+name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// appDataDir returns the directory holding the persistent files, creating it if needed
+func appDataDir() (string, error) {
+	dir := portableDir
+	if dir == "" {
+		base, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("no user config directory: %v", err)
+		}
+		dir = filepath.Join(base, "mediot")
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create data directory: %v", err)
+	}
+	return dir, nil
+}
+
+// dataFilePath returns where a file of the data directory is kept: in the
+// folder of the current profile for the files each profile has its own
+// copy of, in the data directory itself otherwise. Files missing from
+// dataFiles are refused.
+func dataFilePath(name string) (string, error) {
+	if kind, ok := dataFiles[name]; !ok || kind == dataRecording {
+		return "", fmt.Errorf("'%s' is not a registered data file", name)
+	}
+	if profileFiles[name] {
+		dir, err := profileDir(profiles.current())
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, name), nil
+	}
+
+	dir, err := appDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// loadJSONFile decodes a file of the data directory into v. A missing file
+// is not an error and leaves v untouched.
+func loadJSONFile(name string, v interface{}) error {
+	path, err := dataFilePath(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	if data, err = atRest.decrypt(data); err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", name, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", name, err)
+	}
+	return nil
+}
+
+// saveJSONFile writes v as JSON to a file of the data directory. The file is
+// replaced atomically so a crash mid-write never leaves it truncated.
+// Failures are also reported as storage errors for the UI.
+func saveJSONFile(name string, v interface{}) error {
+	err := writeJSONFile(name, v)
+	if err != nil {
+		backendErrors.report(ErrorStorage, fmt.Sprintf("Saving %s failed", name), err)
+	}
+	return err
+}
+
+// writeJSONFile is saveJSONFile without the error report
+func writeJSONFile(name string, v interface{}) error {
+	path, err := dataFilePath(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %v", name, err)
+	}
+	if data, err = atRest.encrypt(name, data); err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", name, err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %v", name, err)
+	}
+	return nil
+}
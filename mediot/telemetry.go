@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	goruntime "runtime"
+	"sync"
+	"time"
+)
+
+// Usage telemetry
+const (
+	telemetryFile          = "telemetry.json"
+	telemetryCheckInterval = time.Hour
+	telemetryReportPeriod  = 24 * time.Hour // A report covers at least this long
+	telemetryTimeout       = 30 * time.Second
+	telemetryIDBytes       = 16
+)
+
+// telemetryURL receives the usage reports, set at build time with
+// -ldflags "-X main.telemetryURL=https://...". Builds without it collect
+// nothing even when telemetry is enabled, and never send.
+var telemetryURL = ""
+
+// Features counted by the telemetry, each time the user starts one
+const (
+	FeatureSerial      = "serial"
+	FeatureSimulator   = "simulator"
+	FeatureHeadless    = "headless"
+	FeatureRecording   = "recording"
+	FeatureSchedule    = "recordingSchedule"
+	FeatureExport      = "export"
+	FeatureFirmware    = "firmware"
+	FeatureConsole     = "console"
+	FeatureCalibration = "calibration"
+	FeatureClockSync   = "clockSync"
+	FeatureMQTT        = "mqtt"
+	FeaturePlugins     = "plugins"
+	FeatureProfiles    = "profiles"
+	FeatureDiagnostics = "diagnostics"
+	FeatureBenchmark   = "benchmark"
+)
+
+// TelemetryReport is everything a usage report contains: anonymous
+// aggregates of the app and feature use, never sensor data, patient data,
+// device identities, names or paths
+type TelemetryReport struct {
+	InstallID string           `json:"installId"` // Random, created when telemetry is enabled and dropped when it is disabled
+	Version   string           `json:"version"`
+	OS        string           `json:"os"`
+	Arch      string           `json:"arch"`
+	Features  map[string]int64 `json:"features"` // Times each feature was started
+	Crashes   int64            `json:"crashes"`  // Recovered panics
+	From      time.Time        `json:"from"`
+	To        time.Time        `json:"to"`
+}
+
+// TelemetryStatus describes the telemetry and the report that would be
+// sent next, so the user can see exactly what is shared
+type TelemetryStatus struct {
+	Enabled   bool            `json:"enabled"`
+	Available bool            `json:"available"` // This build has a telemetry server
+	LastSent  time.Time       `json:"lastSent"`
+	Pending   TelemetryReport `json:"pending"`
+}
+
+// telemetryState is the persisted telemetry
+type telemetryState struct {
+	Enabled   bool             `json:"enabled"`
+	InstallID string           `json:"installId,omitempty"`
+	Features  map[string]int64 `json:"features"`
+	Crashes   int64            `json:"crashes"`
+	Since     time.Time        `json:"since"` // Start of the pending report
+	LastSent  time.Time        `json:"lastSent"`
+}
+
+// usageTelemetry counts feature use and crashes while enabled. It is
+// shared because crashes are counted outside the App.
+type usageTelemetry struct {
+	mu    sync.Mutex
+	state telemetryState
+}
+
+// telemetry is off until the user enables it
+var telemetry = &usageTelemetry{state: telemetryState{Features: make(map[string]int64)}}
+
+// load reads the telemetry state
+func (t *usageTelemetry) load() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := loadJSONFile(telemetryFile, &t.state); err != nil {
+		appLog.Errorf("Error loading telemetry: %v", err)
+	}
+	if t.state.Features == nil {
+		t.state.Features = make(map[string]int64)
+	}
+}
+
+// save persists the telemetry state; the caller holds the lock
+func (t *usageTelemetry) save() {
+	if err := saveJSONFile(telemetryFile, t.state); err != nil {
+		appLog.Errorf("Error saving telemetry: %v", err)
+	}
+}
+
+// collecting reports whether use is counted; the caller holds the lock
+func (t *usageTelemetry) collecting() bool {
+	return t.state.Enabled && telemetryURL != ""
+}
+
+// count records one use of a feature, only while telemetry is enabled
+func (t *usageTelemetry) count(feature string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.collecting() {
+		return
+	}
+	t.state.Features[feature]++
+	t.save()
+}
+
+// crash records a recovered panic, only while telemetry is enabled
+func (t *usageTelemetry) crash() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.collecting() {
+		return
+	}
+	t.state.Crashes++
+	t.save()
+}
+
+// pending builds the next report; the caller holds the lock
+func (t *usageTelemetry) pending(at time.Time) TelemetryReport {
+	features := make(map[string]int64, len(t.state.Features))
+	for feature, count := range t.state.Features {
+		features[feature] = count
+	}
+	return TelemetryReport{
+		InstallID: t.state.InstallID,
+		Version:   appVersion,
+		OS:        goruntime.GOOS,
+		Arch:      goruntime.GOARCH,
+		Features:  features,
+		Crashes:   t.state.Crashes,
+		From:      t.state.Since,
+		To:        at,
+	}
+}
+
+// setEnabled turns telemetry on with a new install ID, or off, dropping
+// the ID and everything counted
+func (t *usageTelemetry) setEnabled(enabled bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := telemetryState{Enabled: enabled, Features: make(map[string]int64)}
+	if enabled {
+		id := make([]byte, telemetryIDBytes)
+		if _, err := rand.Read(id); err != nil {
+			return fmt.Errorf("failed to generate install ID: %v", err)
+		}
+		state.InstallID = hex.EncodeToString(id)
+		state.Since = time.Now()
+	}
+	t.state = state
+	t.save()
+	return nil
+}
+
+// telemetryLoop sends a report once a day while telemetry is enabled
+func (a *App) telemetryLoop() {
+	client := &http.Client{Timeout: telemetryTimeout, Transport: certificates.httpTransport()}
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-time.After(telemetryCheckInterval):
+		}
+
+		telemetry.mu.Lock()
+		due := telemetry.collecting() && time.Since(telemetry.state.Since) >= telemetryReportPeriod
+		report := telemetry.pending(time.Now())
+		telemetry.mu.Unlock()
+		if !due {
+			continue
+		}
+
+		if err := sendTelemetry(client, report); err != nil {
+			// Kept and sent with the next report
+			appLog.Warnf("Error sending usage report: %v", err)
+			continue
+		}
+
+		telemetry.mu.Lock()
+		// Telemetry may have been disabled or reset while sending
+		if telemetry.state.InstallID == report.InstallID && telemetry.collecting() {
+			for feature, count := range report.Features {
+				if telemetry.state.Features[feature] -= count; telemetry.state.Features[feature] <= 0 {
+					delete(telemetry.state.Features, feature)
+				}
+			}
+			telemetry.state.Crashes -= report.Crashes
+			telemetry.state.Since = report.To
+			telemetry.state.LastSent = time.Now()
+			telemetry.save()
+		}
+		telemetry.mu.Unlock()
+		appLog.Infof("Usage report sent")
+	}
+}
+
+// sendTelemetry posts a report to the telemetry server
+func sendTelemetry(client *http.Client, report TelemetryReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(telemetryURL, "application/json", bytes.NewReader(data))
+	if tlsErr := tlsFailure(err); tlsErr != nil {
+		return tlsErr
+	}
+	if err != nil {
+		return err
+	}
+	return checkResponse(resp)
+}
+
+// GetTelemetry returns whether usage telemetry is enabled and the report
+// that would be sent next
+func (a *App) GetTelemetry() TelemetryStatus {
+	telemetry.mu.Lock()
+	defer telemetry.mu.Unlock()
+
+	return TelemetryStatus{
+		Enabled:   telemetry.state.Enabled,
+		Available: telemetryURL != "",
+		LastSent:  telemetry.state.LastSent,
+		Pending:   telemetry.pending(time.Now()),
+	}
+}
+
+// SetTelemetryEnabled opts in to or out of the anonymous usage telemetry.
+// Opting out deletes the install ID and everything not yet sent.
+func (a *App) SetTelemetryEnabled(enabled bool) (TelemetryStatus, error) {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return TelemetryStatus{}, err
+	}
+	if err := telemetry.setEnabled(enabled); err != nil {
+		return TelemetryStatus{}, err
+	}
+
+	if enabled {
+		appLog.Infof("Usage telemetry enabled")
+		a.audit.record("", AuditTelemetry, "Usage telemetry enabled")
+	} else {
+		appLog.Infof("Usage telemetry disabled")
+		a.audit.record("", AuditTelemetry, "Usage telemetry disabled")
+	}
+	return a.GetTelemetry(), nil
+}
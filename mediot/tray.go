@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"fyne.io/systray"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// trayRefreshInterval is how often the tray status line is updated
+const trayRefreshInterval = 2 * time.Second
+
+// trayState is the system tray icon and whether the app is really quitting
+// rather than hiding its window
+type trayState struct {
+	mu       sync.Mutex
+	started  bool
+	quitting bool // Set by the tray's Quit item, so closing is not turned into hiding
+	end      func()
+}
+
+// newTrayState creates the tray; the icon appears once the app has started
+func newTrayState() *trayState {
+	return &trayState{}
+}
+
+// startTray shows the tray icon; it runs alongside the Wails event loop
+func (a *App) startTray() {
+	start, end := systray.RunWithExternalLoop(a.trayReady, nil)
+	a.tray.mu.Lock()
+	a.tray.started = true
+	a.tray.end = end
+	a.tray.mu.Unlock()
+	start()
+}
+
+// stopTray removes the tray icon
+func (a *App) stopTray() {
+	a.tray.mu.Lock()
+	end := a.tray.end
+	a.tray.end = nil
+	a.tray.mu.Unlock()
+
+	if end != nil {
+		end()
+	}
+}
+
+// trayReady builds the tray menu and serves its clicks until shutdown
+func (a *App) trayReady() {
+	systray.SetIcon(trayIcon)
+	systray.SetTooltip("mediot")
+
+	status := systray.AddMenuItem("", "")
+	status.Disable()
+	systray.AddSeparator()
+	show := systray.AddMenuItem("Show mediot", "Show the main window")
+	connect := systray.AddMenuItem("Connect", "Connect to the default port and start a session")
+	systray.AddSeparator()
+	quit := systray.AddMenuItem("Quit", "Stop monitoring and quit")
+
+	refresh := func() {
+		text := a.trayStatus()
+		status.SetTitle(text)
+		systray.SetTooltip("mediot: " + text)
+		if a.conn.connected() {
+			connect.SetTitle("Disconnect")
+			connect.Enable()
+		} else {
+			connect.SetTitle("Connect")
+			if a.GetSettings().DefaultPort == "" {
+				connect.Disable()
+			} else {
+				connect.Enable()
+			}
+		}
+	}
+	refresh()
+
+	ticker := time.NewTicker(trayRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-ticker.C:
+		case <-show.ClickedCh:
+			a.focusWindow()
+		case <-connect.ClickedCh:
+			a.trayConnect()
+		case <-quit.ClickedCh:
+			a.tray.mu.Lock()
+			a.tray.quitting = true
+			a.tray.mu.Unlock()
+			runtime.Quit(a.ctx)
+		}
+		refresh()
+	}
+}
+
+// trayStatus summarizes the connection and the active alarms
+func (a *App) trayStatus() string {
+	status := a.GetConnectionStatus()
+	text := status.State
+	if status.Port != "" && status.State != ConnectionDisconnected {
+		text = fmt.Sprintf("%s (%s)", status.State, status.Port)
+	}
+	if alarms := len(a.GetActiveAlarms()); alarms > 0 {
+		text += fmt.Sprintf(", %d active alarm(s)", alarms)
+	}
+	return text
+}
+
+// trayConnect connects to the default port from the settings, or
+// disconnects, and reports failures as a notification
+func (a *App) trayConnect() {
+	var result ConnectionResult
+	if a.conn.connected() {
+		result = a.DisconnectFromSerialPort()
+	} else {
+		settings := a.GetSettings()
+		result = a.ConnectToSerialPort(settings.DefaultPort, settings.DefaultBaudRate)
+	}
+	if !result.Success {
+		a.sendNotification("mediot", result.Message, false)
+	}
+}
+
+// hideToTray hides the window instead of closing it when the settings ask
+// for it and the tray is running; acquisition and alarms continue
+func (a *App) hideToTray() bool {
+	a.tray.mu.Lock()
+	hide := a.tray.started && !a.tray.quitting
+	a.tray.mu.Unlock()
+
+	if !hide || !a.GetSettings().MinimizeToTray {
+		return false
+	}
+	runtime.WindowHide(a.ctx)
+	appLog.Infof("Window hidden to the system tray")
+	return true
+}
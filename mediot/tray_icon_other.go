@@ -0,0 +1,10 @@
+//go:build !windows
+
+package main
+
+import _ "embed"
+
+// trayIcon is the application icon
+//
+//go:embed build/appicon.png
+var trayIcon []byte
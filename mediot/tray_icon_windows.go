@@ -0,0 +1,8 @@
+package main
+
+import _ "embed"
+
+// trayIcon is the application icon; the Windows tray needs an .ico
+//
+//go:embed build/windows/icon.ico
+var trayIcon []byte
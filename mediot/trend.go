@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// trendSuffix is appended to a channel name to form its rate-of-change channel
+const trendSuffix = "_trend"
+
+// TrendConfig configures the rate-of-change analysis of a channel
+type TrendConfig struct {
+	Channel            string   `json:"channel"`
+	WindowSeconds      float64  `json:"windowSeconds"`          // Length of the linear regression window
+	FallingLimit       *float64 `json:"fallingLimit,omitempty"` // Alarm when falling faster than this many units/min
+	RisingLimit        *float64 `json:"risingLimit,omitempty"`  // Alarm when rising faster than this many units/min
+	Severity           string   `json:"severity"`               // Severity of the rate-of-change alarm
+	MinDurationSeconds float64  `json:"minDurationSeconds"`     // Time the rate must persist before alarming
+}
+
+// trendTracker fits a least-squares line to the window incrementally
+type trendTracker struct {
+	config TrendConfig
+	window *timedWindow
+	origin time.Time // Times are taken relative to this to keep the sums well conditioned
+	sumT   float64
+	sumV   float64
+	sumTT  float64
+	sumTV  float64
+}
+
+// add accounts for a new reading and forgets the ones that left the window
+func (t *trendTracker) add(at time.Time, v float64) {
+	// Move the origin forward now and then so the sums don't lose precision
+	if t.window.len() == 0 || at.Sub(t.origin) > 10*t.window.span {
+		t.rebase(at)
+	}
+
+	x := at.Sub(t.origin).Seconds()
+	t.sumT += x
+	t.sumV += v
+	t.sumTT += x * x
+	t.sumTV += x * v
+
+	for _, old := range t.window.push(at, v) {
+		x := old.t.Sub(t.origin).Seconds()
+		t.sumT -= x
+		t.sumV -= old.v
+		t.sumTT -= x * x
+		t.sumTV -= x * old.v
+	}
+}
+
+// rebase recomputes the sums of the readings in the window relative to a new origin
+func (t *trendTracker) rebase(origin time.Time) {
+	t.origin = origin
+	t.sumT, t.sumV, t.sumTT, t.sumTV = 0, 0, 0, 0
+	for _, tv := range t.window.values {
+		x := tv.t.Sub(origin).Seconds()
+		t.sumT += x
+		t.sumV += tv.v
+		t.sumTT += x * x
+		t.sumTV += x * tv.v
+	}
+}
+
+// slope returns the fitted rate of change in units per minute
+func (t *trendTracker) slope() (float64, bool) {
+	if t.window.len() < 2 || t.window.coverage() < secondsToDuration(t.config.WindowSeconds)/2 {
+		return 0, false
+	}
+
+	n := float64(t.window.len())
+	denominator := n*t.sumTT - t.sumT*t.sumT
+	if denominator == 0 {
+		return 0, false
+	}
+	perSecond := (n*t.sumTV - t.sumT*t.sumV) / denominator
+	return perSecond * 60, true
+}
+
+// trendProcessor publishes the slope of the configured channels as derived channels
+type trendProcessor struct {
+	mu       sync.Mutex
+	trackers map[string]*trendTracker
+}
+
+// newTrendProcessor creates the trend stage without configured channels
+func newTrendProcessor() *trendProcessor {
+	return &trendProcessor{
+		trackers: make(map[string]*trendTracker),
+	}
+}
+
+func (p *trendProcessor) process(sample *SensorData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for channel, tracker := range p.trackers {
+		value, ok := sample.channelValue(channel)
+		if !ok {
+			continue
+		}
+		tracker.add(sample.Timestamp, value)
+		if slope, ok := tracker.slope(); ok {
+			sample.setDerived(channel+trendSuffix, slope)
+		}
+	}
+}
+
+// SetTrend starts or reconfigures the rate-of-change analysis of a channel.
+// The slope is published as the "<channel>_trend" derived channel in units
+// per minute; falling/rising limits install an alarm rule on that channel.
+func (a *App) SetTrend(config TrendConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if config.Channel == "" {
+		return fmt.Errorf("channel is required")
+	}
+	if config.WindowSeconds <= 0 {
+		return fmt.Errorf("window must be positive, got %.2f s", config.WindowSeconds)
+	}
+
+	trendChannel := config.Channel + trendSuffix
+	if config.FallingLimit != nil || config.RisingLimit != nil {
+		rule := AlarmRule{Channel: trendChannel, MinDurationSeconds: config.MinDurationSeconds}
+		var falling *float64
+		if config.FallingLimit != nil {
+			limit := -*config.FallingLimit
+			falling = &limit
+		}
+		switch config.Severity {
+		case SeverityCritical:
+			rule.CriticalLow, rule.CriticalHigh = falling, config.RisingLimit
+		case SeverityWarning:
+			rule.WarningLow, rule.WarningHigh = falling, config.RisingLimit
+		default:
+			return fmt.Errorf("unknown severity '%s'", config.Severity)
+		}
+		if err := a.setAlarmRule(rule); err != nil {
+			return err
+		}
+	} else {
+		a.removeAlarmRule(trendChannel)
+	}
+
+	a.trends.mu.Lock()
+	defer a.trends.mu.Unlock()
+
+	a.trends.trackers[config.Channel] = &trendTracker{
+		config: config,
+		window: newTimedWindow(secondsToDuration(config.WindowSeconds)),
+	}
+
+	processingLog.Infof("Trend analysis set for channel %s over %.0f s", config.Channel, config.WindowSeconds)
+	return nil
+}
+
+// RemoveTrend stops the rate-of-change analysis of a channel and its alarm
+func (a *App) RemoveTrend(channel string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.trends.mu.Lock()
+	_, ok := a.trends.trackers[channel]
+	delete(a.trends.trackers, channel)
+	a.trends.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("no trend analysis for channel '%s'", channel)
+	}
+	a.removeAlarmRule(channel + trendSuffix)
+	return nil
+}
+
+// GetTrends returns the configured trend analyses sorted by channel
+func (a *App) GetTrends() []TrendConfig {
+	a.trends.mu.Lock()
+	defer a.trends.mu.Unlock()
+
+	result := make([]TrendConfig, 0, len(a.trends.trackers))
+	for _, tracker := range a.trends.trackers {
+		result = append(result, tracker.config)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Channel < result[j].Channel })
+	return result
+}
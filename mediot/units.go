@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// unitsFile stores the user-configured channel units
+const unitsFile = "units.json"
+
+// unitScale relates a unit to the base unit of its dimension: base = value*factor + offset
+type unitScale struct {
+	dimension string
+	factor    float64
+	offset    float64
+}
+
+// knownUnits lists the units limits can be converted between
+var knownUnits = map[string]unitScale{
+	"%":           {dimension: "fraction", factor: 1},
+	"ratio":       {dimension: "fraction", factor: 100},
+	"bpm":         {dimension: "rate", factor: 1},
+	"breaths/min": {dimension: "rate", factor: 1},
+	"/min":        {dimension: "rate", factor: 1},
+	"Hz":          {dimension: "rate", factor: 60},
+	"ms":          {dimension: "time", factor: 1},
+	"s":           {dimension: "time", factor: 1000},
+	"mmHg":        {dimension: "pressure", factor: 1},
+	"kPa":         {dimension: "pressure", factor: 7.50062},
+	"cmH2O":       {dimension: "pressure", factor: 0.735559},
+	"°C":          {dimension: "temperature", factor: 1},
+	"°F":          {dimension: "temperature", factor: 5.0 / 9, offset: -32 * 5.0 / 9},
+	"K":           {dimension: "temperature", factor: 1, offset: -273.15},
+	"mg/dL":       {dimension: "glucose", factor: 1},
+	"mmol/L":      {dimension: "glucose", factor: 18.016},
+	"mL":          {dimension: "volume", factor: 1},
+	"L":           {dimension: "volume", factor: 1000},
+	"mV":          {dimension: "voltage", factor: 1},
+	"µV":          {dimension: "voltage", factor: 0.001},
+}
+
+// defaultChannelUnits are the units of the channels the pipeline computes
+var defaultChannelUnits = map[string]string{
+	ChannelValue1:          "mV",
+	ChannelSpO2:            "%",
+	ChannelPerfusionIndex:  "%",
+	ChannelHeartRate:       "bpm",
+	ChannelRespiratoryRate: "breaths/min",
+	ChannelSDNN:            "ms",
+	ChannelRMSSD:           "ms",
+	ChannelPNN50:           "%",
+}
+
+// convertUnit converts a value between two units of the same dimension
+func convertUnit(value float64, from, to string) (float64, error) {
+	if from == to {
+		return value, nil
+	}
+	a, ok := knownUnits[from]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit '%s'", from)
+	}
+	b, ok := knownUnits[to]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit '%s'", to)
+	}
+	if a.dimension != b.dimension {
+		return 0, fmt.Errorf("cannot convert %s (%s) to %s (%s)", from, a.dimension, to, b.dimension)
+	}
+	return (value*a.factor + a.offset - b.offset) / b.factor, nil
+}
+
+// convertDelta converts a difference between two values, such as a margin,
+// which scales with the units but ignores their offsets
+func convertDelta(delta float64, from, to string) (float64, error) {
+	if _, err := convertUnit(0, from, to); err != nil {
+		return 0, err
+	}
+	if from == to {
+		return delta, nil
+	}
+	return delta * knownUnits[from].factor / knownUnits[to].factor, nil
+}
+
+// unitStore keeps the engineering unit of every channel
+type unitStore struct {
+	mu    sync.Mutex
+	units map[string]string // User-configured units, overriding the defaults
+}
+
+// newUnitStore creates the unit store and loads the persisted channel units
+func newUnitStore() *unitStore {
+	s := &unitStore{units: make(map[string]string)}
+	if err := loadJSONFile(unitsFile, &s.units); err != nil {
+		processingLog.Errorf("Error loading channel units: %v", err)
+	}
+	return s
+}
+
+// unitOf returns the unit of a channel, empty when it has none
+func (s *unitStore) unitOf(channel string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if unit, ok := s.units[channel]; ok {
+		return unit
+	}
+	return defaultChannelUnits[channel]
+}
+
+// GetChannelUnits returns the unit of every channel that has one
+func (a *App) GetChannelUnits() map[string]string {
+	a.units.mu.Lock()
+	defer a.units.mu.Unlock()
+
+	result := make(map[string]string, len(defaultChannelUnits)+len(a.units.units))
+	for channel, unit := range defaultChannelUnits {
+		result[channel] = unit
+	}
+	for channel, unit := range a.units.units {
+		result[channel] = unit
+	}
+	return result
+}
+
+// SetChannelUnit sets the engineering unit a channel is reported in. Alarm
+// limits given in another unit of the same dimension are converted to it.
+func (a *App) SetChannelUnit(channel string, unit string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+	return a.setChannelUnit(channel, unit)
+}
+
+// setChannelUnit is SetChannelUnit without the role check, for units the app applies itself
+func (a *App) setChannelUnit(channel string, unit string) error {
+	if channel == "" {
+		return fmt.Errorf("channel is required")
+	}
+
+	a.units.mu.Lock()
+	defer a.units.mu.Unlock()
+
+	a.units.units[channel] = unit
+
+	processingLog.Infof("Channel %s unit set to '%s'", channel, unit)
+	return saveJSONFile(unitsFile, a.units.units)
+}
+
+// GetUnits returns the units alarm limits can be converted between, grouped by dimension
+func (a *App) GetUnits() map[string][]string {
+	result := make(map[string][]string)
+	for unit, scale := range knownUnits {
+		result[scale.dimension] = append(result[scale.dimension], unit)
+	}
+	for _, units := range result {
+		sort.Strings(units)
+	}
+	return result
+}
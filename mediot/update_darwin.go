@@ -0,0 +1,11 @@
+package main
+
+import "os/exec"
+
+// installerSuffix is the file type of the macOS installer package
+const installerSuffix = ".pkg"
+
+// launchInstaller opens the package in the macOS Installer
+func launchInstaller(path string) error {
+	return exec.Command("open", path).Run()
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// installerSuffix is the file type of the Linux release
+const installerSuffix = ".AppImage"
+
+// launchInstaller moves the new AppImage over the running one when the app
+// runs as an AppImage, and otherwise starts the downloaded one in its place
+func launchInstaller(path string) error {
+	if current := os.Getenv("APPIMAGE"); current != "" {
+		if err := os.Rename(path, current); err != nil {
+			return err
+		}
+		path = current
+	}
+	if err := os.Chmod(path, 0755); err != nil {
+		return err
+	}
+	cmd := exec.Command(path)
+	cmd.Dir = filepath.Dir(path)
+	return cmd.Start()
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import "fmt"
+
+// installerSuffix matches no release asset on unsupported systems
+const installerSuffix = ".unsupported"
+
+// launchInstaller is not supported on this system
+func launchInstaller(path string) error {
+	return fmt.Errorf("updates are not supported on this system")
+}
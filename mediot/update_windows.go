@@ -0,0 +1,11 @@
+package main
+
+import "os/exec"
+
+// installerSuffix is the file type of the Windows installer
+const installerSuffix = ".exe"
+
+// launchInstaller starts the installer, which waits for the app to exit
+func launchInstaller(path string) error {
+	return exec.Command(path).Start()
+}
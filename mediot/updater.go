@@ -0,0 +1,483 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Release feed and download limits
+const (
+	updateFeedURL      = "https://api.github.com/repos/Venus00/mediot_waild_desktop_app/releases/latest"
+	updateChecksumFile = "SHA256SUMS" // Release asset listing the SHA-256 of every installer
+	updateSignatureExt = ".sig"       // Ed25519 signature of the checksum file, base64, in the asset of that name
+	updateDirName      = "updates"
+	updateTimeout      = 30 * time.Second // For the feed and the checksum file, and the response headers of the download
+	updateIdleTimeout  = 60 * time.Second // A download receiving nothing for this long fails
+	updateMaxDuration  = 2 * time.Hour    // Longest a download may take in all
+	maxUpdateSize      = 512 << 20
+	updateProgressStep = 250 * time.Millisecond // Minimum time between progress events
+)
+
+// updateSigningKey is the base64 Ed25519 public key release checksum files
+// are signed with, set at build time with
+// -ldflags "-X main.updateSigningKey=...". Builds without it do not
+// download updates.
+var updateSigningKey = ""
+
+// Update phases reported in the progress events
+const (
+	UpdatePhaseDownload = "download"
+	UpdatePhaseVerify   = "verify"
+	UpdatePhaseReady    = "ready"
+	UpdatePhaseFailed   = "failed"
+)
+
+// UpdateInfo describes the latest release and the state of its download
+type UpdateInfo struct {
+	CurrentVersion string    `json:"currentVersion"`
+	LatestVersion  string    `json:"latestVersion"`
+	Available      bool      `json:"available"` // The latest release is newer than this build
+	Notes          string    `json:"notes"`
+	PublishedAt    time.Time `json:"publishedAt"`
+	Installer      string    `json:"installer"` // Asset for this OS and architecture
+	Size           int64     `json:"size"`
+	Phase          string    `json:"phase,omitempty"` // Download phase, empty before the download starts
+	Message        string    `json:"message,omitempty"`
+}
+
+// UpdateProgress is pushed to the frontend while an update downloads
+type UpdateProgress struct {
+	Version    string  `json:"version"`
+	Phase      string  `json:"phase"`
+	BytesRead  int64   `json:"bytesRead"`
+	TotalBytes int64   `json:"totalBytes"`
+	Progress   float64 `json:"progress"`
+	Message    string  `json:"message,omitempty"`
+}
+
+// releaseAsset is a file attached to a release in the feed
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// releaseFeed is the part of the release feed the updater reads
+type releaseFeed struct {
+	Tag         string         `json:"tag_name"`
+	Notes       string         `json:"body"`
+	PublishedAt time.Time      `json:"published_at"`
+	Assets      []releaseAsset `json:"assets"`
+}
+
+// updater checks for new releases and downloads at most one installer at a time
+type updater struct {
+	mu       sync.Mutex
+	client   *http.Client
+	info     UpdateInfo
+	path     string // Verified installer, once the phase is ready
+	sum      string // Its SHA-256 from the signed checksum file, checked again before it is installed
+	checking bool
+}
+
+// newUpdater creates an updater that has not checked yet
+func newUpdater() *updater {
+	transport := certificates.httpTransport()
+	transport.ResponseHeaderTimeout = updateTimeout
+	return &updater{
+		client: &http.Client{Transport: transport},
+		info:   UpdateInfo{CurrentVersion: appVersion},
+	}
+}
+
+// parseVersion splits a version like v1.2.3 into its numbers, ignoring any
+// pre-release or build suffix
+func parseVersion(version string) ([]int, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	parts := strings.Split(version, ".")
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		numbers[i] = n
+	}
+	return numbers, true
+}
+
+// newerVersion reports whether latest is a higher version than current
+func newerVersion(latest, current string) bool {
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	for i := 0; i < len(l) || i < len(c); i++ {
+		var x, y int
+		if i < len(l) {
+			x = l[i]
+		}
+		if i < len(c) {
+			y = c[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+// installerAsset picks the installer for this OS and architecture, named
+// like mediot-1.3.0-windows-amd64.exe
+func installerAsset(assets []releaseAsset) (releaseAsset, bool) {
+	platform := goruntime.GOOS + "-" + goruntime.GOARCH
+	for _, asset := range assets {
+		if strings.Contains(asset.Name, platform) && strings.HasSuffix(asset.Name, installerSuffix) {
+			return asset, true
+		}
+	}
+	return releaseAsset{}, false
+}
+
+// fetch performs a GET request and returns the response if it succeeded
+func (u *updater) fetch(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "mediot/"+appVersion)
+	resp, err := u.client.Do(req)
+	if tlsErr := tlsFailure(err); tlsErr != nil {
+		return nil, tlsErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// fetchAsset downloads a small release asset, within updateTimeout
+func (u *updater) fetchAsset(feed releaseFeed, name string) ([]byte, error) {
+	for _, asset := range feed.Assets {
+		if asset.Name != name {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), updateTimeout)
+		defer cancel()
+		resp, err := u.fetch(ctx, asset.URL)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	}
+	return nil, fmt.Errorf("release has no %s; refusing an unverifiable installer", name)
+}
+
+// verifySignature checks a signature of data against the pinned release key
+func verifySignature(data, signature []byte) error {
+	key, err := base64.StdEncoding.DecodeString(updateSigningKey)
+	if updateSigningKey == "" || err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("this build has no valid update signing key; install updates by hand")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+		return fmt.Errorf("%s is not signed with the release key", updateChecksumFile)
+	}
+	return nil
+}
+
+// checksum returns the expected SHA-256 of an asset from the release's
+// checksum file, once its signature verifies against the pinned key: the
+// checksum alone comes from the same host as the installer
+func (u *updater) checksum(feed releaseFeed, name string) (string, error) {
+	sums, err := u.fetchAsset(feed, updateChecksumFile)
+	if err != nil {
+		return "", err
+	}
+	signature, err := u.fetchAsset(feed, updateChecksumFile+updateSignatureExt)
+	if err != nil {
+		return "", err
+	}
+	if err := verifySignature(sums, signature); err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no entry for %s", updateChecksumFile, name)
+}
+
+// fileChecksum returns the SHA-256 of a file
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// CheckForUpdate asks the release feed for the latest version. When it is
+// newer, its installer for this platform starts downloading in the
+// background, reported by update-progress events.
+func (a *App) CheckForUpdate() (UpdateInfo, error) {
+	a.updater.mu.Lock()
+	if a.updater.checking || a.updater.info.Phase == UpdatePhaseDownload || a.updater.info.Phase == UpdatePhaseVerify {
+		info := a.updater.info
+		a.updater.mu.Unlock()
+		return info, nil
+	}
+	a.updater.checking = true
+	a.updater.mu.Unlock()
+
+	info, feed, asset, err := a.updater.check()
+
+	a.updater.mu.Lock()
+	defer a.updater.mu.Unlock()
+
+	a.updater.checking = false
+	if err != nil {
+		return a.updater.info, err
+	}
+	if a.updater.info.LatestVersion == info.LatestVersion && a.updater.info.Phase == UpdatePhaseReady {
+		return a.updater.info, nil
+	}
+	a.updater.info = info
+	if info.Available {
+		a.updater.info.Phase = UpdatePhaseDownload
+		goSafe("update download", func() { a.downloadUpdate(feed, asset) })
+	}
+	return a.updater.info, nil
+}
+
+// check fetches the release feed and picks the installer for this platform
+func (u *updater) check() (UpdateInfo, releaseFeed, releaseAsset, error) {
+	info := UpdateInfo{CurrentVersion: appVersion}
+	var feed releaseFeed
+
+	ctx, cancel := context.WithTimeout(context.Background(), updateTimeout)
+	defer cancel()
+	resp, err := u.fetch(ctx, updateFeedURL)
+	if err != nil {
+		return info, feed, releaseAsset{}, fmt.Errorf("update check failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(&feed); err != nil {
+		return info, feed, releaseAsset{}, fmt.Errorf("update check failed: invalid release feed: %v", err)
+	}
+	info.LatestVersion = strings.TrimPrefix(feed.Tag, "v")
+	info.Notes = feed.Notes
+	info.PublishedAt = feed.PublishedAt
+
+	if _, ok := parseVersion(appVersion); !ok {
+		info.Message = "development builds are not updated"
+		return info, feed, releaseAsset{}, nil
+	}
+	if !newerVersion(feed.Tag, appVersion) {
+		return info, feed, releaseAsset{}, nil
+	}
+	asset, ok := installerAsset(feed.Assets)
+	if !ok {
+		info.Message = fmt.Sprintf("release has no installer for %s-%s", goruntime.GOOS, goruntime.GOARCH)
+		return info, feed, releaseAsset{}, nil
+	}
+	if asset.Size > maxUpdateSize {
+		return info, feed, releaseAsset{}, fmt.Errorf("installer %s is too large (%d bytes)", asset.Name, asset.Size)
+	}
+	info.Available = true
+	info.Installer = asset.Name
+	info.Size = asset.Size
+	appLog.Infof("Update available: %s (running %s)", info.LatestVersion, appVersion)
+	return info, feed, asset, nil
+}
+
+// downloadUpdate downloads and verifies an installer, then marks it ready
+func (a *App) downloadUpdate(feed releaseFeed, asset releaseAsset) {
+	path, sum, err := a.updater.download(feed, asset, func(progress UpdateProgress) {
+		progress.Version = strings.TrimPrefix(feed.Tag, "v")
+		a.emit(EventUpdateProgress, progress)
+	})
+
+	a.updater.mu.Lock()
+	progress := UpdateProgress{Version: a.updater.info.LatestVersion, Phase: UpdatePhaseReady, BytesRead: asset.Size, TotalBytes: asset.Size, Progress: 1}
+	if err != nil {
+		a.updater.info.Phase = UpdatePhaseFailed
+		a.updater.info.Message = err.Error()
+		progress.Phase, progress.Message, progress.Progress = UpdatePhaseFailed, err.Error(), 0
+	} else {
+		a.updater.info.Phase = UpdatePhaseReady
+		a.updater.path, a.updater.sum = path, sum
+	}
+	a.updater.mu.Unlock()
+
+	if err != nil {
+		appLog.Errorf("Update download failed: %v", err)
+	} else {
+		appLog.Infof("Update %s downloaded and verified", progress.Version)
+	}
+	a.emit(EventUpdateProgress, progress)
+}
+
+// download writes an installer to the updates directory, hashing it on the
+// way, and keeps it only if it matches the signed release checksum. It
+// returns the path and checksum of the installer. A download receiving
+// nothing for updateIdleTimeout fails, so a stalled server cannot hold the
+// phase at download.
+func (u *updater) download(feed releaseFeed, asset releaseAsset, report func(UpdateProgress)) (string, string, error) {
+	expected, err := u.checksum(feed, asset.Name)
+	if err != nil {
+		return "", "", err
+	}
+	dir, err := appDataDir()
+	if err != nil {
+		return "", "", err
+	}
+	dir = filepath.Join(dir, updateDirName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), updateMaxDuration)
+	defer cancel()
+	idle := time.AfterFunc(updateIdleTimeout, cancel)
+	defer idle.Stop()
+	resp, err := u.fetch(ctx, asset.URL)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	path := filepath.Join(dir, filepath.Base(asset.Name))
+	partial := path + ".part"
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0700)
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(partial)
+
+	hash := sha256.New()
+	var read int64
+	last := time.Time{}
+	buf := make([]byte, 64<<10)
+	for {
+		idle.Reset(updateIdleTimeout)
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			read += int64(n)
+			if read > maxUpdateSize {
+				file.Close()
+				return "", "", fmt.Errorf("installer is larger than %d bytes", maxUpdateSize)
+			}
+			hash.Write(buf[:n])
+			if _, err := file.Write(buf[:n]); err != nil {
+				file.Close()
+				return "", "", err
+			}
+			if time.Since(last) >= updateProgressStep {
+				last = time.Now()
+				progress := UpdateProgress{Phase: UpdatePhaseDownload, BytesRead: read, TotalBytes: asset.Size}
+				if asset.Size > 0 {
+					progress.Progress = float64(read) / float64(asset.Size)
+				}
+				report(progress)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			file.Close()
+			if ctx.Err() != nil {
+				return "", "", fmt.Errorf("download stalled: nothing received for %v or not finished within %v", updateIdleTimeout, updateMaxDuration)
+			}
+			return "", "", fmt.Errorf("download failed: %v", err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		return "", "", err
+	}
+
+	report(UpdateProgress{Phase: UpdatePhaseVerify, BytesRead: read, TotalBytes: asset.Size, Progress: 1})
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != expected {
+		return "", "", fmt.Errorf("installer checksum %s does not match the release (%s)", sum, expected)
+	}
+	if err := os.Rename(partial, path); err != nil {
+		return "", "", err
+	}
+	return path, expected, nil
+}
+
+// ApplyUpdate starts the downloaded installer and quits the app so it can
+// replace it. Monitoring must be stopped first.
+func (a *App) ApplyUpdate() error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+	if a.conn.connected() {
+		return fmt.Errorf("disconnect from the device before updating")
+	}
+
+	a.updater.mu.Lock()
+	phase, path, sum, version := a.updater.info.Phase, a.updater.path, a.updater.sum, a.updater.info.LatestVersion
+	a.updater.mu.Unlock()
+	if phase != UpdatePhaseReady {
+		return fmt.Errorf("no update has been downloaded")
+	}
+	// The file may have been replaced since it was verified
+	if actual, err := fileChecksum(path); err != nil || actual != sum {
+		a.updater.mu.Lock()
+		a.updater.info.Phase, a.updater.info.Message = UpdatePhaseFailed, "downloaded installer changed after verification"
+		a.updater.mu.Unlock()
+		return fmt.Errorf("downloaded installer changed after verification; check for the update again")
+	}
+
+	if err := launchInstaller(path); err != nil {
+		return fmt.Errorf("failed to start installer: %v", err)
+	}
+	appLog.Infof("Installing update %s", version)
+	a.audit.record("", AuditUpdate, fmt.Sprintf("Update from %s to %s started", appVersion, version))
+	runtime.Quit(a.ctx)
+	return nil
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// usersFile stores the local user accounts
+const usersFile = "users.json"
+
+// User roles
+const (
+	RoleOperator = "operator" // Views data, connects, records and handles alarms
+	RoleAdmin    = "admin"    // Also changes parsers, calibration, alarm limits and integrations
+)
+
+// minPasswordLength is the shortest password accepted for an account
+const minPasswordLength = 6
+
+// errLoginRequired is returned by protected bindings while nobody is logged in
+var errLoginRequired = fmt.Errorf("log in to use this function")
+
+// UserInfo is a user account as shown to the frontend
+type UserInfo struct {
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// userAccount is a stored account with its password hash
+type userAccount struct {
+	UserInfo
+	Salt []byte `json:"salt"`
+	Hash []byte `json:"hash"` // Argon2id of the password
+}
+
+// userStore keeps the local accounts and who is logged in. Without any
+// account the app runs unrestricted, so the first account must be an admin.
+type userStore struct {
+	mu       sync.Mutex
+	accounts map[string]*userAccount
+	current  *userAccount // nil while nobody is logged in
+}
+
+// newUserStore loads the accounts
+func newUserStore() *userStore {
+	s := &userStore{accounts: make(map[string]*userAccount)}
+	if err := loadJSONFile(usersFile, &s.accounts); err != nil {
+		securityLog.Errorf("Error loading user accounts: %v", err)
+	}
+	return s
+}
+
+// hashPassword derives the stored hash of a password
+func hashPassword(password string, salt []byte) []byte {
+	return argon2.IDKey([]byte(password), salt, 1, 64*1024, 4, 32)
+}
+
+// requireRole returns an error unless the logged-in user has the role. An
+// admin has every role. Checks pass while no account exists. Every protected
+// binding is rejected while the app is locked.
+func (a *App) requireRole(role string) error {
+	if err := a.lock.check(); err != nil {
+		return err
+	}
+	return a.checkRole(role)
+}
+
+// requireReadRole is requireRole for the bindings reading stored patient
+// data and the audit trail. The UI polls some of them, so they do not keep
+// the idle lock from engaging. Live readings and active alarms stay
+// readable while locked, as monitoring continues.
+func (a *App) requireReadRole(role string) error {
+	if err := a.lock.checkLocked(); err != nil {
+		return err
+	}
+	return a.checkRole(role)
+}
+
+// checkRole returns an error unless the logged-in user has the role
+func (a *App) checkRole(role string) error {
+	a.users.mu.Lock()
+	defer a.users.mu.Unlock()
+
+	if len(a.users.accounts) == 0 {
+		return nil
+	}
+	if a.users.current == nil {
+		return errLoginRequired
+	}
+	if role == RoleAdmin && a.users.current.Role != RoleAdmin {
+		return fmt.Errorf("user '%s' is not allowed to do this; an admin is required", a.users.current.Name)
+	}
+	return nil
+}
+
+// actingUser returns the name an action is attributed to. With accounts it
+// is the user logged in, and any other name is refused; without, the name
+// given is taken as is.
+func (a *App) actingUser(name string) (string, error) {
+	a.users.mu.Lock()
+	defer a.users.mu.Unlock()
+
+	if len(a.users.accounts) == 0 {
+		return name, nil
+	}
+	if a.users.current == nil {
+		return "", errLoginRequired
+	}
+	if name != "" && !strings.EqualFold(name, a.users.current.Name) {
+		return "", fmt.Errorf("logged in as '%s', cannot act as '%s'", a.users.current.Name, name)
+	}
+	return a.users.current.Name, nil
+}
+
+// Login checks the password of an account and makes it the current user.
+// Audit entries are attributed to this user until Logout.
+func (a *App) Login(name string, password string) (UserInfo, error) {
+	a.users.mu.Lock()
+	account, ok := a.users.accounts[strings.ToLower(name)]
+	if !ok || subtle.ConstantTimeCompare(hashPassword(password, account.Salt), account.Hash) != 1 {
+		a.users.mu.Unlock()
+		a.audit.record(name, AuditSecurity, "Failed login")
+		return UserInfo{}, fmt.Errorf("unknown user or wrong password")
+	}
+	a.users.current = account
+	info := account.UserInfo
+	a.users.mu.Unlock()
+
+	a.audit.setUser(info.Name)
+	a.audit.record("", AuditSecurity, "Logged in")
+	return info, nil
+}
+
+// Logout ends the session of the current user
+func (a *App) Logout() {
+	a.users.mu.Lock()
+	a.users.current = nil
+	a.users.mu.Unlock()
+
+	a.audit.record("", AuditSecurity, "Logged out")
+	a.audit.setUser("")
+}
+
+// GetCurrentUser returns the logged-in user, or nil while nobody is logged in
+func (a *App) GetCurrentUser() *UserInfo {
+	a.users.mu.Lock()
+	defer a.users.mu.Unlock()
+
+	if a.users.current == nil {
+		return nil
+	}
+	info := a.users.current.UserInfo
+	return &info
+}
+
+// GetUsers returns the accounts sorted by name
+func (a *App) GetUsers() []UserInfo {
+	a.users.mu.Lock()
+	defer a.users.mu.Unlock()
+
+	result := make([]UserInfo, 0, len(a.users.accounts))
+	for _, account := range a.users.accounts {
+		result = append(result, account.UserInfo)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// SaveUser creates an account or changes its role and password. An empty
+// password keeps the current one. The first account must be an admin.
+func (a *App) SaveUser(name string, role string, password string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("user name is required")
+	}
+	if role != RoleOperator && role != RoleAdmin {
+		return fmt.Errorf("unknown role '%s'", role)
+	}
+	if password != "" && len(password) < minPasswordLength {
+		return fmt.Errorf("password must have at least %d characters", minPasswordLength)
+	}
+
+	a.users.mu.Lock()
+	defer a.users.mu.Unlock()
+
+	key := strings.ToLower(name)
+	account, exists := a.users.accounts[key]
+	if !exists {
+		if password == "" {
+			return fmt.Errorf("password is required for a new user")
+		}
+		if len(a.users.accounts) == 0 && role != RoleAdmin {
+			return fmt.Errorf("the first user must be an admin")
+		}
+		account = &userAccount{UserInfo: UserInfo{Name: name, CreatedAt: time.Now()}}
+	}
+	if exists && account.Role == RoleAdmin && role != RoleAdmin && a.adminCount() == 1 {
+		return fmt.Errorf("'%s' is the last admin", account.Name)
+	}
+	account.Role = role
+	if password != "" {
+		account.Salt = make([]byte, 16)
+		if _, err := rand.Read(account.Salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %v", err)
+		}
+		account.Hash = hashPassword(password, account.Salt)
+	}
+	a.users.accounts[key] = account
+
+	// Whoever set up the first account is logged in as it
+	if len(a.users.accounts) == 1 && a.users.current == nil {
+		a.users.current = account
+		a.audit.setUser(account.Name)
+	}
+
+	securityLog.Infof("User %s saved as %s", name, role)
+	a.audit.record("", AuditSecurity, fmt.Sprintf("User %s saved as %s", name, role))
+	return saveJSONFile(usersFile, a.users.accounts)
+}
+
+// adminCount returns the number of admin accounts; the caller holds the lock
+func (a *App) adminCount() int {
+	count := 0
+	for _, account := range a.users.accounts {
+		if account.Role == RoleAdmin {
+			count++
+		}
+	}
+	return count
+}
+
+// RemoveUser deletes an account. The last admin cannot be removed while
+// other accounts exist.
+func (a *App) RemoveUser(name string) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	a.users.mu.Lock()
+	defer a.users.mu.Unlock()
+
+	key := strings.ToLower(name)
+	account, ok := a.users.accounts[key]
+	if !ok {
+		return fmt.Errorf("unknown user '%s'", name)
+	}
+	if account.Role == RoleAdmin && a.adminCount() == 1 && len(a.users.accounts) > 1 {
+		return fmt.Errorf("'%s' is the last admin", account.Name)
+	}
+	delete(a.users.accounts, key)
+	if a.users.current == account {
+		a.users.current = nil
+		a.audit.setUser("")
+	}
+
+	securityLog.Infof("User %s removed", name)
+	a.audit.record("", AuditSecurity, fmt.Sprintf("User %s removed", account.Name))
+	return saveJSONFile(usersFile, a.users.accounts)
+}
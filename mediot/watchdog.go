@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// watchdogFile stores the data-stream watchdog settings
+const watchdogFile = "watchdog.json"
+
+// watchdogTick is how often the watchdog checks the arrival of samples
+const watchdogTick = 500 * time.Millisecond
+
+// ChannelNoData is the pseudo channel the data-stream watchdog raises its alarm on
+const ChannelNoData = "no_data"
+
+// WatchdogConfig configures the alarm raised when a connected device stops sending
+type WatchdogConfig struct {
+	Enabled        bool    `json:"enabled"`
+	TimeoutSeconds float64 `json:"timeoutSeconds"` // Time without a valid sample before the alarm is raised
+	Severity       string  `json:"severity"`
+}
+
+// defaultWatchdogConfig raises a critical alarm after 5 s without data
+func defaultWatchdogConfig() WatchdogConfig {
+	return WatchdogConfig{
+		Enabled:        true,
+		TimeoutSeconds: 5,
+		Severity:       SeverityCritical,
+	}
+}
+
+// streamWatchdog holds the watchdog settings
+type streamWatchdog struct {
+	mu     sync.Mutex
+	config WatchdogConfig
+}
+
+// newStreamWatchdog creates the watchdog and loads the persisted settings
+func newStreamWatchdog() *streamWatchdog {
+	w := &streamWatchdog{config: defaultWatchdogConfig()}
+	if err := loadJSONFile(watchdogFile, &w.config); err != nil {
+		alarmsLog.Errorf("Error loading watchdog settings: %v", err)
+	}
+	return w
+}
+
+// setCondition raises, changes or (with SeverityNormal) clears an alarm that
+// isn't driven by channel limits, such as the watchdog alarm. It notifies
+// like a rule alarm and can be acknowledged, silenced and escalated the same way.
+func (e *alarmEngine) setCondition(channel, severity string, value, limit float64, now time.Time, code string, args ...interface{}) {
+	e.mu.Lock()
+	state, ok := e.conditions[channel]
+	if !ok {
+		state = &alarmState{rule: AlarmRule{Channel: channel}}
+		e.conditions[channel] = state
+	}
+	current := SeverityNormal
+	if state.active != nil {
+		current = state.active.Severity
+	}
+	if severity == current {
+		e.mu.Unlock()
+		return
+	}
+
+	if state.active == nil {
+		state.active = &Alarm{ID: e.nextID, Channel: channel, RaisedAt: now}
+		e.nextID++
+	}
+	event := AlarmEvent{
+		ID:        state.active.ID,
+		Channel:   channel,
+		Severity:  severity,
+		Previous:  current,
+		Value:     value,
+		Limit:     limit,
+		Code:      code,
+		Message:   tr(code, args...),
+		Timestamp: now,
+	}
+	if severity == SeverityNormal {
+		state.active = nil
+	} else {
+		if severityRank[severity] > severityRank[current] {
+			state.active.Acknowledged = false
+			state.active.SnoozedUntil = time.Time{}
+		}
+		state.active.Severity = severity
+		state.active.Value = value
+		state.active.Limit = limit
+		event.Silenced = e.quiet(state.active, now)
+	}
+	e.mu.Unlock()
+
+	e.notify(event)
+}
+
+// watchdogLoop raises the no-data alarm while connected without valid samples
+// and clears it when they resume or the port is closed
+func (a *App) watchdogLoop() {
+	for {
+		select {
+		case <-a.quit:
+			return
+		case <-time.After(watchdogTick):
+		}
+
+		a.watchdog.mu.Lock()
+		config := a.watchdog.config
+		a.watchdog.mu.Unlock()
+
+		now := time.Now()
+		silent := now.Sub(a.clock.lastSample())
+		timeout := secondsToDuration(config.TimeoutSeconds)
+		switch {
+		case !config.Enabled || !a.conn.connected():
+			a.alarms.setCondition(ChannelNoData, SeverityNormal, silent.Seconds(), config.TimeoutSeconds, now,
+				MsgNoDataInactive)
+		case silent >= timeout:
+			a.alarms.setCondition(ChannelNoData, config.Severity, silent.Seconds(), config.TimeoutSeconds, now,
+				MsgNoData, silent.Seconds())
+		default:
+			a.alarms.setCondition(ChannelNoData, SeverityNormal, silent.Seconds(), config.TimeoutSeconds, now,
+				MsgStreamResumed)
+		}
+	}
+}
+
+// GetWatchdogConfig returns the data-stream watchdog settings
+func (a *App) GetWatchdogConfig() WatchdogConfig {
+	a.watchdog.mu.Lock()
+	defer a.watchdog.mu.Unlock()
+
+	return a.watchdog.config
+}
+
+// SetWatchdogConfig replaces and persists the data-stream watchdog settings
+func (a *App) SetWatchdogConfig(config WatchdogConfig) error {
+	if err := a.requireRole(RoleAdmin); err != nil {
+		return err
+	}
+
+	if config.TimeoutSeconds <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	if config.Severity != SeverityWarning && config.Severity != SeverityCritical {
+		return fmt.Errorf("unknown severity '%s'", config.Severity)
+	}
+
+	a.watchdog.mu.Lock()
+	defer a.watchdog.mu.Unlock()
+
+	a.watchdog.config = config
+	return saveJSONFile(watchdogFile, config)
+}
@@ -0,0 +1,103 @@
+package main
+
+import "time"
+
+// timedValue is a single channel reading with its arrival time
+type timedValue struct {
+	t time.Time
+	v float64
+}
+
+// timedWindow keeps the readings of the last span of time in arrival order
+type timedWindow struct {
+	span   time.Duration
+	values []timedValue
+}
+
+// newTimedWindow creates an empty window covering the given span
+func newTimedWindow(span time.Duration) *timedWindow {
+	return &timedWindow{
+		span:   span,
+		values: make([]timedValue, 0),
+	}
+}
+
+// push appends a reading and returns the readings that fell out of the window
+func (w *timedWindow) push(t time.Time, v float64) []timedValue {
+	w.values = append(w.values, timedValue{t: t, v: v})
+	return w.evict(t)
+}
+
+// evict drops and returns the readings older than one span before now
+func (w *timedWindow) evict(now time.Time) []timedValue {
+	cutoff := now.Add(-w.span)
+	drop := 0
+	for drop < len(w.values) && w.values[drop].t.Before(cutoff) {
+		drop++
+	}
+	if drop == 0 {
+		return nil
+	}
+
+	// Reslice in place: the dropped head is never written again, so the
+	// evicted readings stay valid, and once push runs out of capacity append
+	// compacts the live readings into a new array, which bounds the memory
+	// to about twice the window at O(1) amortized per reading
+	evicted := w.values[:drop:drop]
+	w.values = w.values[drop:]
+	return evicted
+}
+
+// len returns the number of readings currently in the window
+func (w *timedWindow) len() int {
+	return len(w.values)
+}
+
+// coverage returns the time between the oldest and newest reading
+func (w *timedWindow) coverage() time.Duration {
+	if len(w.values) < 2 {
+		return 0
+	}
+	return w.values[len(w.values)-1].t.Sub(w.values[0].t)
+}
+
+// minMaxMean returns the extremes and the average of the readings
+func (w *timedWindow) minMaxMean() (float64, float64, float64) {
+	if len(w.values) == 0 {
+		return 0, 0, 0
+	}
+
+	min, max, sum := w.values[0].v, w.values[0].v, 0.0
+	for _, tv := range w.values {
+		if tv.v < min {
+			min = tv.v
+		}
+		if tv.v > max {
+			max = tv.v
+		}
+		sum += tv.v
+	}
+	return min, max, sum / float64(len(w.values))
+}
+
+// since returns a copy of the readings newer than the cutoff
+func (w *timedWindow) since(cutoff time.Time) []timedValue {
+	i := len(w.values)
+	for i > 0 && !w.values[i-1].t.Before(cutoff) {
+		i--
+	}
+	result := make([]timedValue, len(w.values)-i)
+	copy(result, w.values[i:])
+	return result
+}
+
+// reset drops all readings and applies a new span
+func (w *timedWindow) reset(span time.Duration) {
+	w.span = span
+	w.values = w.values[:0]
+}
+
+// secondsToDuration converts a user-facing seconds value to a time.Duration
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
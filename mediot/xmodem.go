@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// XMODEM/YMODEM control bytes
+const (
+	xmodemSOH = 0x01 // Start of a 128-byte block
+	xmodemSTX = 0x02 // Start of a 1024-byte block
+	xmodemEOT = 0x04
+	xmodemACK = 0x06
+	xmodemNAK = 0x15
+	xmodemCAN = 0x18
+	xmodemCRC = 'C'  // Receiver asks for CRC-16 blocks
+	xmodemPad = 0x1A // CP/M end-of-file, pads the last data block
+)
+
+// XMODEM timing and limits
+const (
+	xmodemBlockSize    = 1024
+	xmodemShortBlock   = 128
+	xmodemRetries      = 10
+	xmodemStartTimeout = 60 * time.Second // Time the bootloader may take to ask for the file
+	xmodemReplyTimeout = 10 * time.Second
+)
+
+// errTransferCancelled is returned when the user or the receiver aborts a transfer
+var errTransferCancelled = errors.New("transfer cancelled")
+
+// xmodemPort is the part of a serial port the transfer needs
+type xmodemPort interface {
+	Read(p []byte) (int, error)
+	Write(p []byte) (int, error)
+	SetReadTimeout(t time.Duration) error
+}
+
+// xmodemSender sends a file with XMODEM-1K or YMODEM, both with CRC-16 blocks
+type xmodemSender struct {
+	port      xmodemPort
+	progress  func(sent int) // Called after every acknowledged data block
+	cancelled func() bool    // Polled before every block
+}
+
+// crc16XModem computes the CRC-16/XMODEM of a block (polynomial 0x1021, initial value 0)
+func crc16XModem(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// readByte waits up to timeout for one byte from the receiver
+func (s *xmodemSender) readByte(timeout time.Duration) (byte, error) {
+	deadline := time.Now().Add(timeout)
+	buf := make([]byte, 1)
+	for time.Now().Before(deadline) {
+		if s.cancelled() {
+			return 0, errTransferCancelled
+		}
+		s.port.SetReadTimeout(100 * time.Millisecond)
+		n, err := s.port.Read(buf)
+		if err != nil {
+			return 0, err
+		}
+		if n == 1 {
+			return buf[0], nil
+		}
+	}
+	return 0, fmt.Errorf("receiver did not answer within %s", timeout)
+}
+
+// waitStart waits for the receiver to ask for CRC-16 blocks
+func (s *xmodemSender) waitStart(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		b, err := s.readByte(time.Until(deadline))
+		if err != nil {
+			return fmt.Errorf("waiting for the receiver: %v", err)
+		}
+		switch b {
+		case xmodemCRC:
+			return nil
+		case xmodemCAN:
+			return errTransferCancelled
+		}
+		// Anything else, such as a checksum-mode NAK or bootloader output, is skipped
+	}
+}
+
+// sendBlock sends one block, padded to size, until the receiver acknowledges it
+func (s *xmodemSender) sendBlock(number byte, data []byte, size int, pad byte) error {
+	frame := make([]byte, 3+size+2)
+	frame[0] = xmodemSOH
+	if size == xmodemBlockSize {
+		frame[0] = xmodemSTX
+	}
+	frame[1] = number
+	frame[2] = ^number
+	payload := frame[3 : 3+size]
+	copy(payload, data)
+	for i := len(data); i < size; i++ {
+		payload[i] = pad
+	}
+	crc := crc16XModem(payload)
+	frame[3+size] = byte(crc >> 8)
+	frame[4+size] = byte(crc)
+
+	for attempt := 0; attempt < xmodemRetries; attempt++ {
+		if s.cancelled() {
+			return errTransferCancelled
+		}
+		if _, err := s.port.Write(frame); err != nil {
+			return err
+		}
+		reply, err := s.readByte(xmodemReplyTimeout)
+		if errors.Is(err, errTransferCancelled) {
+			return err
+		}
+		switch {
+		case err != nil:
+			continue
+		case reply == xmodemACK:
+			return nil
+		case reply == xmodemCAN:
+			return fmt.Errorf("receiver aborted at block %d", number)
+		}
+	}
+	return fmt.Errorf("block %d was not acknowledged after %d attempts", number, xmodemRetries)
+}
+
+// sendData sends the data blocks, numbered from 1. A short final chunk goes
+// in a 128-byte block to limit the padding.
+func (s *xmodemSender) sendData(data []byte) error {
+	number := byte(1)
+	for sent := 0; sent < len(data); number++ {
+		size := xmodemBlockSize
+		if len(data)-sent <= xmodemShortBlock {
+			size = xmodemShortBlock
+		}
+		end := sent + size
+		if end > len(data) {
+			end = len(data)
+		}
+		if err := s.sendBlock(number, data[sent:end], size, xmodemPad); err != nil {
+			return err
+		}
+		sent = end
+		s.progress(sent)
+	}
+	return nil
+}
+
+// sendEOT ends the file until the receiver acknowledges it
+func (s *xmodemSender) sendEOT() error {
+	for attempt := 0; attempt < xmodemRetries; attempt++ {
+		if _, err := s.port.Write([]byte{xmodemEOT}); err != nil {
+			return err
+		}
+		reply, err := s.readByte(xmodemReplyTimeout)
+		if err == nil && reply == xmodemACK {
+			return nil
+		}
+		if errors.Is(err, errTransferCancelled) {
+			return err
+		}
+	}
+	return fmt.Errorf("end of transfer was not acknowledged")
+}
+
+// abort tells the receiver to give up the transfer
+func (s *xmodemSender) abort() {
+	s.port.Write([]byte{xmodemCAN, xmodemCAN, xmodemCAN})
+}
+
+// sendXModem1K transfers data with XMODEM-1K
+func (s *xmodemSender) sendXModem1K(data []byte) error {
+	if err := s.waitStart(xmodemStartTimeout); err != nil {
+		return err
+	}
+	if err := s.sendData(data); err != nil {
+		return err
+	}
+	return s.sendEOT()
+}
+
+// sendYModem transfers data as a single-file YMODEM batch, which also tells
+// the receiver the file name and exact size
+func (s *xmodemSender) sendYModem(name string, data []byte) error {
+	if err := s.waitStart(xmodemStartTimeout); err != nil {
+		return err
+	}
+	header := append([]byte(filepath.Base(name)), 0)
+	header = append(header, strconv.Itoa(len(data))...)
+	if len(header) > xmodemShortBlock {
+		return fmt.Errorf("file name '%s' is too long for YMODEM", name)
+	}
+	if err := s.sendBlock(0, header, xmodemShortBlock, 0); err != nil {
+		return err
+	}
+
+	if err := s.waitStart(xmodemReplyTimeout); err != nil {
+		return err
+	}
+	if err := s.sendData(data); err != nil {
+		return err
+	}
+	if err := s.sendEOT(); err != nil {
+		return err
+	}
+
+	// An empty header block ends the batch
+	if err := s.waitStart(xmodemReplyTimeout); err != nil {
+		return err
+	}
+	return s.sendBlock(0, nil, xmodemShortBlock, 0)
+}